@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Keep a kubeconfig synced in a container and report health over HTTP",
+	Long: `Runs sync in watch mode for containers and VMs, such as CI runners that
+need fresh cluster access: the kubeconfig given by --kubeconfig (usually a
+mounted volume) is re-synced every --interval, and the result is reported
+over HTTP:
+
+  /healthz   200 until --health-failure-threshold syncs in a row have failed
+  /readyz    200 while the last sync succeeded
+  /metrics   the Prometheus metrics of sync --metrics-address
+
+Both health endpoints answer with the last sync status as JSON. The agent is
+configured like sync, and every setting can be given as a CLOUDCTL_*
+environment variable, e.g. CLOUDCTL_GREENHOUSE_CLUSTER_NAMESPACE,
+CLOUDCTL_KUBECONFIG or CLOUDCTL_INTERVAL.
+
+Examples:
+  CLOUDCTL_GREENHOUSE_CLUSTER_NAMESPACE=my-org CLOUDCTL_KUBECONFIG=/kube/config cloudctl agent
+  cloudctl agent --health-address :9090`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runAgent,
+}
+
+func init() {
+	agentCmd.Flags().String("health-address", ":8080", "Address to serve /healthz, /readyz and /metrics on")
+	agentCmd.Flags().Int("health-failure-threshold", 3, "Consecutive failed syncs after which /healthz reports unhealthy")
+}
+
+// syncHealth, when set, is told about the result of every watch cycle of
+// runSync. The agent sets it.
+var syncHealth *agentHealth
+
+// agentHealth tracks the sync cycles of the agent for its health endpoints.
+type agentHealth struct {
+	threshold int
+
+	mu          sync.Mutex
+	lastSync    time.Time
+	lastSuccess time.Time
+	failures    int
+	lastErr     string
+}
+
+// agentStatus is the JSON body of the health endpoints.
+type agentStatus struct {
+	Healthy             bool       `json:"healthy"`
+	Ready               bool       `json:"ready"`
+	LastSync            *time.Time `json:"lastSync,omitempty"`
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Error               string     `json:"error,omitempty"`
+}
+
+// cycleDone records the result of a sync cycle; it is a watch hook.
+func (h *agentHealth) cycleDone(err error, failures int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSync = time.Now()
+	h.failures = failures
+	h.lastErr = ""
+	if err != nil {
+		h.lastErr = err.Error()
+		return
+	}
+	h.lastSuccess = h.lastSync
+}
+
+func (h *agentHealth) status() agentStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := agentStatus{
+		Healthy:             h.failures < h.threshold,
+		Ready:               !h.lastSuccess.IsZero() && h.failures == 0,
+		ConsecutiveFailures: h.failures,
+		Error:               h.lastErr,
+	}
+	if !h.lastSync.IsZero() {
+		st.LastSync = &h.lastSync
+	}
+	if !h.lastSuccess.IsZero() {
+		st.LastSuccess = &h.lastSuccess
+	}
+	return st
+}
+
+// handler serves /healthz, /readyz and /metrics.
+func (h *agentHealth) handler() http.Handler {
+	mux := http.NewServeMux()
+	respond := func(w http.ResponseWriter, ok bool) {
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h.status())
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { respond(w, h.status().Healthy) })
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) { respond(w, h.status().Ready) })
+	mux.Handle("/metrics", metricsHandler())
+	return mux
+}
+
+func runAgent(cmd *cobra.Command, _ []string) error {
+	threshold := viper.GetInt("health-failure-threshold")
+	if threshold < 1 {
+		return fmt.Errorf("--health-failure-threshold must be at least 1, got %d", threshold)
+	}
+	health := &agentHealth{threshold: threshold}
+	stop, err := serveHTTP("health", viper.GetString("health-address"), health.handler())
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	syncHealth = health
+	defer func() { syncHealth = nil }()
+	viper.Set("watch", true)
+	return runSync(cmd, nil)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAgentHealth(t *testing.T) {
+	g := NewWithT(t)
+	health := &agentHealth{threshold: 2}
+	srv := httptest.NewServer(health.handler())
+	defer srv.Close()
+
+	get := func(path string) (int, agentStatus) {
+		resp, err := http.Get(srv.URL + path)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = resp.Body.Close() }()
+		var st agentStatus
+		g.Expect(json.NewDecoder(resp.Body).Decode(&st)).To(Succeed())
+		return resp.StatusCode, st
+	}
+
+	code, st := get("/readyz")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable), "not ready before the first sync")
+	g.Expect(st.LastSync).To(BeNil())
+	code, _ = get("/healthz")
+	g.Expect(code).To(Equal(http.StatusOK))
+
+	health.cycleDone(nil, 0)
+	code, st = get("/readyz")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(st.LastSuccess).NotTo(BeNil())
+
+	health.cycleDone(errors.New("greenhouse unreachable"), 1)
+	code, st = get("/readyz")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(st.Error).To(Equal("greenhouse unreachable"))
+	g.Expect(st.LastSuccess).NotTo(BeNil(), "the last success is kept")
+	code, _ = get("/healthz")
+	g.Expect(code).To(Equal(http.StatusOK), "below the failure threshold")
+
+	health.cycleDone(errors.New("greenhouse unreachable"), 2)
+	code, st = get("/healthz")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(st.ConsecutiveFailures).To(Equal(2))
+
+	health.cycleDone(nil, 0)
+	code, _ = get("/healthz")
+	g.Expect(code).To(Equal(http.StatusOK))
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.Body.Close()).To(Succeed())
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var apiCheckCmd = &cobra.Command{
+	Use:   "api-check <api>...",
+	Short: "Check which managed clusters serve the given APIs or CRDs",
+	Long: `Checks every cluster managed by cloudctl (contexts written by sync) for the
+given APIs and prints a cluster × API matrix. Each argument may be
+
+  group/version           e.g. monitoring.coreos.com/v1 (or v1 for the core API)
+  group                   e.g. monitoring.coreos.com, any version
+  resource.group          e.g. prometheuses.monitoring.coreos.com, any version
+  resource.group/version  e.g. prometheuses.monitoring.coreos.com/v1
+
+The command exits with an error when any cluster lacks an API or cannot be
+reached, so it can gate fleet-wide rollouts in scripts.
+
+Examples:
+  # Is the Prometheus operator installed everywhere?
+  cloudctl api-check monitoring.coreos.com/v1
+
+  # Several APIs, only production clusters, as JSON
+  cloudctl api-check gateway.networking.k8s.io/v1 certificates.cert-manager.io --context 'prod-*' -o json`,
+	Args:    cobra.MinimumNArgs(1),
+	PreRunE: bindCommandFlags,
+	RunE:    runAPICheck,
+}
+
+func init() {
+	apiCheckCmd.Flags().StringSliceP("context", "c", nil, "Only check contexts matching this glob pattern, can be repeated (default: all managed contexts)")
+	apiCheckCmd.Flags().Int("concurrency", 10, "Number of clusters checked in parallel")
+}
+
+// discoverAPIs returns the API resource lists served by the cluster. Groups
+// that fail discovery are left out rather than failing the whole cluster. It
+// is a variable so tests can avoid live clusters.
+var discoverAPIs = func(_ context.Context, cfg *rest.Config) ([]*metav1.APIResourceList, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(withTracing(cfg))
+	if err != nil {
+		return nil, err
+	}
+	_, lists, err := dc.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func runAPICheck(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	timeout, err := requestTimeout()
+	if err != nil {
+		return err
+	}
+	timeout = cmp.Or(timeout, defaultProbeTimeout)
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	names, err := fleetContexts(cfg, viper.GetStringSlice("context"))
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no managed contexts found in %s; run `cloudctl sync` first", displayKubeconfig(path))
+	}
+
+	slog.Info("checking APIs", "kubeconfig", displayKubeconfig(path), "clusters", len(names), "apis", args)
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	step, stop := printer.StartProgress("Checking clusters", len(names))
+	result := output.APICheckResult{APIs: args, Clusters: make([]output.APICheckCluster, len(names))}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		result.Clusters[i] = output.APICheckCluster{Context: name, Error: "not checked"}
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return err
+	}
+	defer tun.Close()
+
+	forEachContext(cmd.Context(), names, viper.GetInt("concurrency"), func(ctx context.Context, name string) {
+		result.Clusters[index[name]] = checkContextAPIs(ctx, path, name, tun, contextClusterLabels(cfg, name), timeout, args)
+		step()
+	})
+	stop()
+
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	var missing, failed int
+	for _, c := range result.Clusters {
+		switch {
+		case c.Error != "":
+			failed++
+		case slices.Contains(slices.Collect(maps.Values(c.Available)), false):
+			missing++
+		}
+	}
+	switch {
+	case failed > 0 && missing > 0:
+		return fmt.Errorf("%d cluster(s) lack a requested API and %d could not be checked", missing, failed)
+	case failed > 0:
+		return fmt.Errorf("%d cluster(s) could not be checked", failed)
+	case missing > 0:
+		return fmt.Errorf("%d of %d cluster(s) lack a requested API", missing, len(names))
+	}
+	return nil
+}
+
+// checkContextAPIs runs discovery against one context, routed through the
+// tunnel for its cluster labels, and reports which of the queried APIs it
+// serves.
+func checkContextAPIs(ctx context.Context, path, contextName string, tun *tunnels, labels map[string]string, timeout time.Duration, apis []string) output.APICheckCluster {
+	result := output.APICheckCluster{Context: contextName}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	restCfg.Timeout = timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := tun.apply(ctx, restCfg, labels); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	lists, err := discoverAPIs(ctx, restCfg)
+	if err != nil {
+		slog.Debug("API discovery failed", "context", contextName, "error", err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Available = make(map[string]bool, len(apis))
+	for _, api := range apis {
+		result.Available[api] = apiServed(lists, api)
+	}
+	return result
+}
+
+// apiServed reports whether query names a group version, group, resource.group
+// or resource.group/version served in lists. Subresources are ignored.
+func apiServed(lists []*metav1.APIResourceList, query string) bool {
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		if query == gv.String() || (gv.Group != "" && query == gv.Group) {
+			return true
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			full := r.Name
+			if gv.Group != "" {
+				full += "." + gv.Group
+			}
+			if query == full || query == full+"/"+gv.Version {
+				return true
+			}
+		}
+	}
+	return false
+}
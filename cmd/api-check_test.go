@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+var testAPILists = []*metav1.APIResourceList{
+	{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods"}, {Name: "pods/log"}}},
+	{GroupVersion: "monitoring.coreos.com/v1", APIResources: []metav1.APIResource{{Name: "prometheuses"}, {Name: "prometheuses/status"}}},
+}
+
+func TestAPIServed(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, q := range []string{"v1", "pods", "monitoring.coreos.com", "monitoring.coreos.com/v1", "prometheuses.monitoring.coreos.com", "prometheuses.monitoring.coreos.com/v1"} {
+		g.Expect(apiServed(testAPILists, q)).To(BeTrue(), q)
+	}
+	for _, q := range []string{"monitoring.coreos.com/v2", "alertmanagers.monitoring.coreos.com", "prometheuses/status.monitoring.coreos.com", "gateway.networking.k8s.io/v1", ""} {
+		g.Expect(apiServed(testAPILists, q)).To(BeFalse(), q)
+	}
+}
+
+func TestRunAPICheck_Matrix(t *testing.T) {
+	g := NewWithT(t)
+	writeTestKubeconfig(t, newManagedCfg("eu", "us", "dark"))
+	viper.Set("concurrency", 2)
+	t.Cleanup(func() { viper.Set("concurrency", nil) })
+
+	prev := discoverAPIs
+	discoverAPIs = func(_ context.Context, cfg *rest.Config) ([]*metav1.APIResourceList, error) {
+		switch cfg.Host {
+		case "https://eu.example":
+			return testAPILists, nil
+		case "https://us.example":
+			return testAPILists[:1], nil
+		}
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { discoverAPIs = prev })
+
+	var out bytes.Buffer
+	apiCheckCmd.SetOut(&out)
+	apiCheckCmd.SetContext(context.Background())
+	t.Cleanup(func() { apiCheckCmd.SetOut(nil) })
+
+	err := runAPICheck(apiCheckCmd, []string{"monitoring.coreos.com/v1", "v1"})
+	g.Expect(err).To(MatchError("1 cluster(s) lack a requested API and 1 could not be checked"))
+	g.Expect(out.String()).To(Equal(
+		"CONTEXT  monitoring.coreos.com/v1  v1\n" +
+			"dark     error                     error\n" +
+			"eu       yes                       yes\n" +
+			"us       no                        yes\n" +
+			"\n" +
+			"dark: connection refused\n"))
+
+	out.Reset()
+	viper.Set("context", []string{"eu"})
+	t.Cleanup(func() { viper.Set("context", nil) })
+	g.Expect(runAPICheck(apiCheckCmd, []string{"v1"})).To(Succeed())
+}
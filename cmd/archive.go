@@ -0,0 +1,290 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "List and restore managed entries archived by sync --archive-removed",
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List archived contexts",
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runArchiveList,
+}
+
+var archiveRestoreCmd = &cobra.Command{
+	Use:   "restore <context>...",
+	Short: "Move archived contexts back into the kubeconfig",
+	Long: `Moves archived contexts, with their cluster and user entries, from the
+archive back into the kubeconfig. Cluster and user entries that exist in the
+kubeconfig already are kept as they are.
+
+Restored entries are still managed: if their cluster is still gone from
+Greenhouse, the next sync removes them again (and archives them again with
+--archive-removed). Restore a context to inspect or use it in the meantime,
+or to recover from an accidental deletion that has since been undone.
+
+Examples:
+  cloudctl archive restore prod-eu`,
+	Args:              cobra.MinimumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeArchivedContexts,
+	RunE:              runArchiveRestore,
+}
+
+func init() {
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveRestoreCmd)
+}
+
+// archivedAtExtension is the name of the context extension that records when
+// an archived context was removed from the kubeconfig.
+const archivedAtExtension = "archived-at"
+
+// archiveFile returns the archive kept next to kubeconfig.
+func archiveFile(kubeconfig string) string {
+	return filepath.Join(filepath.Dir(kubeconfig), "cloudctl-archive.yaml")
+}
+
+// loadArchive reads the archive at path. A missing archive is empty.
+func loadArchive(path string) (*clientcmdapi.Config, error) {
+	archive, err := clientcmd.LoadFromFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return clientcmdapi.NewConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive %s: %w", path, err)
+	}
+	return archive, nil
+}
+
+// archiveRemovedContexts copies the contexts that diff reports as removed,
+// with the cluster and user entries they referred to in before, into the
+// archive next to kubeconfig. It returns the number of archived contexts.
+func archiveRemovedContexts(before *clientcmdapi.Config, diff KubeconfigDiff, kubeconfig string, now time.Time) (int, error) {
+	var removed []string
+	for _, d := range diff.Contexts {
+		if d.ChangeType == DiffChangeRemoved && before.Contexts[d.Name] != nil {
+			removed = append(removed, d.Name)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	path := archiveFile(kubeconfig)
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	archive, err := loadArchive(path)
+	if err != nil {
+		return 0, err
+	}
+	archivedAt, err := json.Marshal(now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range removed {
+		ctx := before.Contexts[name].DeepCopy()
+		if ctx.Extensions == nil {
+			ctx.Extensions = map[string]runtime.Object{}
+		}
+		ctx.Extensions[archivedAtExtension] = &runtime.Unknown{Raw: archivedAt}
+		archive.Contexts[name] = ctx
+		if c := before.Clusters[ctx.Cluster]; c != nil {
+			archive.Clusters[ctx.Cluster] = c.DeepCopy()
+		}
+		if ai := before.AuthInfos[ctx.AuthInfo]; ai != nil {
+			archive.AuthInfos[ctx.AuthInfo] = ai.DeepCopy()
+		}
+	}
+	if err := writeArchive(archive, path); err != nil {
+		return 0, err
+	}
+	return len(removed), nil
+}
+
+// writeArchive writes archive to path, readable only by the current user as it
+// holds credentials.
+func writeArchive(archive *clientcmdapi.Config, path string) error {
+	content, err := clientcmd.Write(*archive)
+	if err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", path, err)
+	}
+	return nil
+}
+
+// archivedAt returns when ctx was archived, or the zero time if unknown.
+func archivedAt(ctx *clientcmdapi.Context) time.Time {
+	var s string
+	if err := json.Unmarshal(extensionRaw(ctx.Extensions, archivedAtExtension), &s); err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// archivePath returns the archive of the kubeconfig that sync writes to.
+func archivePath() (string, error) {
+	kubeconfig, err := resolveWriteTarget(resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig")))
+	if err != nil {
+		return "", err
+	}
+	return archiveFile(kubeconfig), nil
+}
+
+func runArchiveList(cmd *cobra.Command, _ []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	path, err := archivePath()
+	if err != nil {
+		return err
+	}
+	archive, err := loadArchive(path)
+	if err != nil {
+		return err
+	}
+
+	result := output.ArchiveListResult{Archive: path}
+	for _, name := range slices.Sorted(maps.Keys(archive.Contexts)) {
+		ctx := archive.Contexts[name]
+		entry := output.ArchivedContext{Context: name, Cluster: ctx.Cluster, Namespace: ctx.Namespace, ArchivedAt: archivedAt(ctx)}
+		if c := archive.Clusters[ctx.Cluster]; c != nil {
+			entry.Server = c.Server
+		}
+		result.Contexts = append(result.Contexts, entry)
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
+
+func runArchiveRestore(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	kubeconfig := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	path, err := archivePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	archive, err := loadArchive(path)
+	if err != nil {
+		return err
+	}
+	po := kubeconfigPathOptions(kubeconfig)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(kubeconfig), err)
+	}
+	before := cfg.DeepCopy()
+
+	for _, name := range args {
+		ctx := archive.Contexts[name]
+		if ctx == nil {
+			return fmt.Errorf("context %q is not archived in %s", name, path)
+		}
+		if _, exists := cfg.Contexts[name]; exists {
+			return fmt.Errorf("context %q already exists in %s", name, displayKubeconfig(kubeconfig))
+		}
+		// Clearing LocationOfOrigin, which points at the archive, makes
+		// ModifyConfig write the entries to the kubeconfig.
+		restored := ctx.DeepCopy()
+		restored.LocationOfOrigin = ""
+		delete(restored.Extensions, archivedAtExtension)
+		cfg.Contexts[name] = restored
+		if c := archive.Clusters[ctx.Cluster]; c != nil && cfg.Clusters[ctx.Cluster] == nil {
+			cfg.Clusters[ctx.Cluster] = c.DeepCopy()
+			cfg.Clusters[ctx.Cluster].LocationOfOrigin = ""
+		}
+		if ai := archive.AuthInfos[ctx.AuthInfo]; ai != nil && cfg.AuthInfos[ctx.AuthInfo] == nil {
+			cfg.AuthInfos[ctx.AuthInfo] = ai.DeepCopy()
+			cfg.AuthInfos[ctx.AuthInfo].LocationOfOrigin = ""
+		}
+	}
+
+	if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+		return fmt.Errorf("failed to update kubeconfig: %w", err)
+	}
+	if err := auditMutations("archive restore", kubeconfig, diffAllEntries(before, cfg)); err != nil {
+		return err
+	}
+	for _, name := range args {
+		delete(archive.Contexts, name)
+	}
+	pruneArchive(archive)
+	if err := writeArchive(archive, path); err != nil {
+		return err
+	}
+	slog.Info("restored archived contexts", "kubeconfig", displayKubeconfig(kubeconfig), "contexts", len(args))
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(output.ArchiveRestoreResult{Kubeconfig: displayKubeconfig(kubeconfig), Restored: args})
+}
+
+// pruneArchive deletes the archived clusters and users that no archived
+// context refers to any more.
+func pruneArchive(archive *clientcmdapi.Config) {
+	clusters, users := map[string]bool{}, map[string]bool{}
+	for _, ctx := range archive.Contexts {
+		clusters[ctx.Cluster], users[ctx.AuthInfo] = true, true
+	}
+	maps.DeleteFunc(archive.Clusters, func(name string, _ *clientcmdapi.Cluster) bool { return !clusters[name] })
+	maps.DeleteFunc(archive.AuthInfos, func(name string, _ *clientcmdapi.AuthInfo) bool { return !users[name] })
+}
+
+// completeArchivedContexts completes the names of archived contexts.
+func completeArchivedContexts(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	path, err := archivePath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	archive, err := loadArchive(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return slices.Sorted(maps.Keys(archive.Contexts)), cobra.ShellCompDirectiveNoFileComp
+}
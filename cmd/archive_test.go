@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func archiveFixture() *clientcmdapi.Config {
+	cfg := newManagedCfg("eu", "us")
+	cfg.Contexts["eu"].Namespace = "monitoring"
+	return cfg
+}
+
+func TestArchiveRemovedContexts(t *testing.T) {
+	g := NewWithT(t)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	diff := KubeconfigDiff{Contexts: []EntryDiff{
+		{Name: "eu", ChangeType: DiffChangeRemoved},
+		{Name: "us", ChangeType: DiffChangeModified},
+	}}
+
+	n, err := archiveRemovedContexts(archiveFixture(), diff, kubeconfig, now)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(n).To(Equal(1))
+	archive, err := clientcmd.LoadFromFile(archiveFile(kubeconfig))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(archive.Contexts).To(HaveLen(1))
+	g.Expect(archive.Contexts["eu"].Namespace).To(Equal("monitoring"))
+	g.Expect(archivedAt(archive.Contexts["eu"])).To(BeTemporally("==", now))
+	g.Expect(archive.Clusters).To(HaveKey("cloudctl:eu"))
+	g.Expect(archive.AuthInfos).To(HaveKey("cloudctl:eu"))
+	info, err := os.Stat(archiveFile(kubeconfig))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+	// Nothing removed, nothing written.
+	n, err = archiveRemovedContexts(archiveFixture(), KubeconfigDiff{}, filepath.Join(t.TempDir(), "config"), now)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(n).To(BeZero())
+}
+
+func TestRunArchiveListAndRestore(t *testing.T) {
+	g := NewWithT(t)
+	before := archiveFixture()
+	after := before.DeepCopy()
+	delete(after.Contexts, "eu")
+	delete(after.Clusters, "cloudctl:eu")
+	kubeconfig := writeTestKubeconfig(t, after)
+	_, err := archiveRemovedContexts(before, KubeconfigDiff{Contexts: []EntryDiff{{Name: "eu", ChangeType: DiffChangeRemoved}}}, kubeconfig, time.Now())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var out bytes.Buffer
+	archiveListCmd.SetOut(&out)
+	archiveRestoreCmd.SetOut(&out)
+	t.Cleanup(func() {
+		archiveListCmd.SetOut(nil)
+		archiveRestoreCmd.SetOut(nil)
+	})
+
+	g.Expect(runArchiveList(archiveListCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("eu  cloudctl:eu  https://eu.example  archived"))
+
+	g.Expect(runArchiveRestore(archiveRestoreCmd, []string{"missing"})).To(MatchError(ContainSubstring(`context "missing" is not archived`)))
+
+	out.Reset()
+	g.Expect(runArchiveRestore(archiveRestoreCmd, []string{"eu"})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Restored eu into " + kubeconfig))
+	cfg, err := clientcmd.LoadFromFile(kubeconfig)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts["eu"]).To(And(HaveField("Cluster", "cloudctl:eu"), HaveField("Namespace", "monitoring")))
+	g.Expect(cfg.Contexts["eu"].Extensions).NotTo(HaveKey(archivedAtExtension))
+	g.Expect(cfg.Clusters["cloudctl:eu"].Server).To(Equal("https://eu.example"))
+
+	archive, err := clientcmd.LoadFromFile(archiveFile(kubeconfig))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(archive.Contexts).To(BeEmpty())
+	g.Expect(archive.Clusters).To(BeEmpty())
+	g.Expect(archive.AuthInfos).To(BeEmpty())
+
+	out.Reset()
+	g.Expect(runArchiveList(archiveListCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("No archived contexts"))
+}
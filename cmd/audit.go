@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// AuditRecord is a single kubeconfig mutation written as one JSON line to the
+// --audit-log file. Field values come from the kubeconfig diff, which already
+// redacts secrets (client secrets, tokens) and reduces CA data to fingerprints.
+type AuditRecord struct {
+	Time       time.Time    `json:"time"`
+	User       string       `json:"user"`
+	SudoUser   string       `json:"sudoUser,omitzero"`
+	Host       string       `json:"host"`
+	Command    string       `json:"command"`
+	Kubeconfig string       `json:"kubeconfig"`
+	Kind       string       `json:"kind"`
+	Name       string       `json:"name"`
+	Action     string       `json:"action"`
+	Fields     []AuditField `json:"fields,omitzero"`
+}
+
+// AuditField is a single field-level change within an AuditRecord.
+type AuditField struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitzero"`
+	New   string `json:"new,omitzero"`
+}
+
+// auditMutations appends one record per changed entry in diff to the file
+// configured via --audit-log. It is a no-op when no audit log is configured.
+func auditMutations(command, kubeconfigPath string, diff KubeconfigDiff) error {
+	return writeAudit(buildAuditRecords(time.Now().UTC(), command, kubeconfigPath, diff))
+}
+
+// auditFieldChange records a single field change of a kubeconfig entry, such
+// as a current-context switch or a context namespace update.
+func auditFieldChange(command, kubeconfigPath, kind, name, field, previous, current string) error {
+	r := newAuditRecord(time.Now().UTC(), command, kubeconfigPath)
+	r.Kind = kind
+	r.Name = name
+	r.Action = string(DiffChangeModified)
+	r.Fields = []AuditField{{Field: field, Old: previous, New: current}}
+	return writeAudit([]AuditRecord{r})
+}
+
+// writeAudit appends records to the --audit-log file, if one is configured.
+func writeAudit(records []AuditRecord) error {
+	path := viper.GetString("audit-log")
+	if path == "" || len(records) == 0 {
+		return nil
+	}
+	if err := appendAuditRecords(path, records); err != nil {
+		return fmt.Errorf("failed to write audit log %s: %w", path, err)
+	}
+	return nil
+}
+
+// newAuditRecord returns a record stamped with the invoking user and host.
+func newAuditRecord(now time.Time, command, kubeconfigPath string) AuditRecord {
+	r := AuditRecord{
+		Time:       now,
+		User:       currentUsername(),
+		SudoUser:   os.Getenv("SUDO_USER"),
+		Command:    command,
+		Kubeconfig: displayKubeconfig(kubeconfigPath),
+	}
+	r.Host, _ = os.Hostname()
+	return r
+}
+
+// buildAuditRecords converts a kubeconfig diff into audit records.
+func buildAuditRecords(now time.Time, command, kubeconfigPath string, diff KubeconfigDiff) []AuditRecord {
+	base := newAuditRecord(now, command, kubeconfigPath)
+
+	var records []AuditRecord
+	for _, group := range []struct {
+		kind    string
+		entries []EntryDiff
+	}{
+		{"cluster", diff.Clusters},
+		{"context", diff.Contexts},
+		{"user", diff.AuthInfos},
+	} {
+		for _, e := range group.entries {
+			r := base
+			r.Kind = group.kind
+			r.Name = e.Name
+			r.Action = string(e.ChangeType)
+			for _, f := range e.Fields {
+				r.Fields = append(r.Fields, AuditField(f))
+			}
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// appendAuditRecords writes records as JSON lines to path, creating the file
+// with owner-only permissions if it does not exist.
+func appendAuditRecords(path string, records []AuditRecord) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// currentUsername returns the login name of the invoking user, falling back
+// to $USER when the user database is unavailable.
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
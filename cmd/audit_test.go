@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestBuildAuditRecords(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("SUDO_USER", "admin")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	diff := KubeconfigDiff{
+		Clusters: []EntryDiff{{
+			Name: "cloudctl:a", ChangeType: DiffChangeModified,
+			Fields: []FieldDiff{{Field: "Server", Old: "https://old", New: "https://new"}},
+		}},
+		Contexts:  []EntryDiff{{Name: "cloudctl:a", ChangeType: DiffChangeAdded}},
+		AuthInfos: []EntryDiff{{Name: "cloudctl:b", ChangeType: DiffChangeRemoved}},
+	}
+
+	records := buildAuditRecords(now, "sync", "/tmp/kubeconfig", diff)
+	g.Expect(records).To(HaveLen(3))
+	g.Expect(records[0].Kind).To(Equal("cluster"))
+	g.Expect(records[0].Action).To(Equal("modified"))
+	g.Expect(records[0].Fields).To(Equal([]AuditField{{Field: "Server", Old: "https://old", New: "https://new"}}))
+	g.Expect(records[1].Kind).To(Equal("context"))
+	g.Expect(records[2].Kind).To(Equal("user"))
+	g.Expect(records[2].Action).To(Equal("removed"))
+	for _, r := range records {
+		g.Expect(r.Time).To(Equal(now))
+		g.Expect(r.SudoUser).To(Equal("admin"))
+		g.Expect(r.Kubeconfig).To(Equal("/tmp/kubeconfig"))
+	}
+}
+
+func TestAuditMutationsAppends(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	viper.Set("audit-log", path)
+	t.Cleanup(func() { viper.Set("audit-log", "") })
+
+	diff := KubeconfigDiff{Contexts: []EntryDiff{{Name: "cloudctl:a", ChangeType: DiffChangeAdded}}}
+	g.Expect(auditMutations("sync", "/tmp/kubeconfig", diff)).To(Succeed())
+	g.Expect(auditMutations("sync", "/tmp/kubeconfig", diff)).To(Succeed())
+	g.Expect(auditMutations("sync", "/tmp/kubeconfig", KubeconfigDiff{})).To(Succeed())
+
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+	f, err := os.Open(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = f.Close() }()
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		g.Expect(json.Unmarshal(scanner.Bytes(), &r)).To(Succeed())
+		g.Expect(r.Name).To(Equal("cloudctl:a"))
+		lines++
+	}
+	g.Expect(lines).To(Equal(2), "empty diffs must not produce records")
+}
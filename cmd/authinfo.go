@@ -16,12 +16,40 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// defaultVolatileAuthKeys are the auth-provider config keys the oidc provider
+// updates on every token refresh.
+var defaultVolatileAuthKeys = []string{"id-token", "refresh-token"}
+
+// volatileAuthKeys are the auth-provider config keys holding session state
+// rather than configuration: they are ignored when AuthInfos are compared or
+// deduplicated, and carried over from the local entry when merging. runSync
+// adds the --volatile-auth-keys to the defaults.
+var volatileAuthKeys = defaultVolatileAuthKeys
+
+// loadVolatileAuthKeys returns the default volatile keys followed by extra,
+// without duplicates.
+func loadVolatileAuthKeys(extra []string) ([]string, error) {
+	keys := slices.Clone(defaultVolatileAuthKeys)
+	for _, k := range extra {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, fmt.Errorf("--volatile-auth-keys must not contain empty keys")
+		}
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
 // authInfoEqual compares the credential-bearing fields of two AuthInfo objects
 // for deduplication purposes. It compares ClientCertificateData, ClientKeyData,
-// Exec (all fields except tokens), and AuthProvider (name + config, excluding
-// "id-token" and "refresh-token"). Fields that carry local-only or session state
-// (Token, TokenFile, Username, Password, Impersonate, etc.) are intentionally
-// not compared so that local customisations do not prevent deduplication.
+// the impersonation settings, Exec (all fields except tokens), and
+// AuthProvider (name + config, excluding the volatileAuthKeys).
+// Impersonation is compared because it changes who the requests act as. Fields
+// that carry local-only or session state (Token, TokenFile, Username,
+// Password, etc.) are intentionally not compared so that local customisations
+// do not prevent deduplication.
 func authInfoEqual(a, b *clientcmdapi.AuthInfo) bool {
 	if a == nil && b == nil {
 		return true
@@ -39,6 +67,10 @@ func authInfoEqual(a, b *clientcmdapi.AuthInfo) bool {
 		return false
 	}
 
+	if impersonationKey(a) != impersonationKey(b) {
+		return false
+	}
+
 	// Compare Exec first (new style)
 	if (a.Exec == nil) != (b.Exec == nil) {
 		return false
@@ -59,7 +91,7 @@ func authInfoEqual(a, b *clientcmdapi.AuthInfo) bool {
 		return true
 	}
 
-	// Compare AuthProvider, excluding "id-token" and "refresh-token"
+	// Compare AuthProvider, excluding the volatile keys
 	if (a.AuthProvider == nil) != (b.AuthProvider == nil) {
 		return false
 	}
@@ -69,7 +101,7 @@ func authInfoEqual(a, b *clientcmdapi.AuthInfo) bool {
 			return false
 		}
 
-		// Compare AuthProvider Config excluding "id-token" and "refresh-token"
+		// Compare AuthProvider Config excluding the volatile keys
 		aConfigFiltered := filterAuthProviderConfig(a.AuthProvider.Config)
 		bConfigFiltered := filterAuthProviderConfig(b.AuthProvider.Config)
 		if !maps.Equal(aConfigFiltered, bConfigFiltered) {
@@ -99,49 +131,90 @@ func equalExecEnv(a, b []clientcmdapi.ExecEnvVar) bool {
 	return true
 }
 
-// filterAuthProviderConfig returns a copy of the config map excluding "id-token" and "refresh-token".
+// filterAuthProviderConfig returns a copy of the config map excluding the
+// volatileAuthKeys.
 func filterAuthProviderConfig(config map[string]string) map[string]string {
 	filtered := make(map[string]string)
 	for k, v := range config {
-		if k != "id-token" && k != "refresh-token" {
+		if !slices.Contains(volatileAuthKeys, k) {
 			filtered[k] = v
 		}
 	}
 	return filtered
 }
 
+// hasImpersonation reports whether authInfo impersonates a user, UID, group or
+// extra attribute.
+func hasImpersonation(authInfo *clientcmdapi.AuthInfo) bool {
+	return authInfo.Impersonate != "" || authInfo.ImpersonateUID != "" ||
+		len(authInfo.ImpersonateGroups) > 0 || len(authInfo.ImpersonateUserExtra) > 0
+}
+
+// impersonationKey serializes the impersonation settings of authInfo, with
+// groups and extra values sorted, or returns "" when there are none.
+func impersonationKey(authInfo *clientcmdapi.AuthInfo) string {
+	if !hasImpersonation(authInfo) {
+		return ""
+	}
+	groups := slices.Sorted(slices.Values(authInfo.ImpersonateGroups))
+	var extra []string
+	for _, k := range slices.Sorted(maps.Keys(authInfo.ImpersonateUserExtra)) {
+		values := slices.Sorted(slices.Values(authInfo.ImpersonateUserExtra[k]))
+		extra = append(extra, k+"="+strings.Join(values, ","))
+	}
+	return fmt.Sprintf(";as:%s;as-uid:%s;as-groups:%s;as-extra:%s",
+		authInfo.Impersonate, authInfo.ImpersonateUID, strings.Join(groups, ","), strings.Join(extra, ";"))
+}
+
+// oidcExecFlags are the exec arguments generateAuthInfoKey reads.
+var oidcExecFlags = []string{
+	"--oidc-issuer-url", "--oidc-client-id", "--oidc-client-secret",
+	"--oidc-extra-scope", "--oidc-auth-request-extra-params",
+}
+
 // generateAuthInfoKey creates a stable deduplication key for an AuthInfo.
 // The key intentionally uses a subset of fields so that tokens and irrelevant
 // args do not prevent deduplication of otherwise-identical credentials:
 //   - Exec-based: Command, APIVersion, InteractiveMode, Env, and the OIDC-
 //     related flag values (issuer, client-id, client-secret, extra-params,
-//     scopes). Non-OIDC extra args are intentionally excluded.
+//     the connector_id among them, scopes). Non-OIDC extra args are
+//     intentionally excluded.
 //   - AuthProvider-based: provider Name plus the full filtered config
-//     (all keys except "id-token" and "refresh-token"), sorted for stability.
+//     (all keys except the volatileAuthKeys), sorted for stability.
 //   - Certificate-based: SHA-256 of ClientCertificateData + ClientKeyData.
 //
+// Impersonation settings, when present, are appended to every kind of key, so
+// keys of AuthInfos without them are unchanged.
+//
 // Note: authInfoEqual compares the full Exec.Args slice, so two authinfos that
 // differ only in non-OIDC extra args will have the same key but fail equality.
 // The reuse path in mergeKubeconfig guards against this with authInfoEqual.
 func generateAuthInfoKey(authInfo *clientcmdapi.AuthInfo) string {
 	// Exec-based key: derive from stable subset of args to avoid including tokens
 	if authInfo.Exec != nil {
-		// Extract known kubelogin flags
-		var issuer, clientID, clientSecret, extraParams string
-		var scopes []string
+		// Extract known kubelogin flags, given as --flag=value or --flag value
+		var issuer, clientID, clientSecret string
+		var scopes, extraParams []string
 		var envParts []string
-		for _, arg := range authInfo.Exec.Args {
-			switch {
-			case strings.HasPrefix(arg, "--oidc-issuer-url="):
-				issuer = strings.TrimPrefix(arg, "--oidc-issuer-url=")
-			case strings.HasPrefix(arg, "--oidc-client-id="):
-				clientID = strings.TrimPrefix(arg, "--oidc-client-id=")
-			case strings.HasPrefix(arg, "--oidc-client-secret="):
-				clientSecret = strings.TrimPrefix(arg, "--oidc-client-secret=")
-			case strings.HasPrefix(arg, "--oidc-extra-scope="):
-				scopes = append(scopes, strings.TrimPrefix(arg, "--oidc-extra-scope="))
-			case strings.HasPrefix(arg, "--oidc-auth-request-extra-params="):
-				extraParams = strings.TrimPrefix(arg, "--oidc-auth-request-extra-params=")
+		args := authInfo.Exec.Args
+		for i := 0; i < len(args); i++ {
+			name, value, ok := strings.Cut(args[i], "=")
+			if !ok && slices.Contains(oidcExecFlags, name) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "--oidc-issuer-url":
+				issuer = value
+			case "--oidc-client-id":
+				clientID = value
+			case "--oidc-client-secret":
+				clientSecret = value
+			case "--oidc-extra-scope":
+				scopes = append(scopes, value)
+			case "--oidc-auth-request-extra-params":
+				// kubelogin merges repeated flags.
+				extraParams = append(extraParams, value)
 			}
 		}
 		sort.Strings(scopes)
@@ -150,10 +223,17 @@ func generateAuthInfoKey(authInfo *clientcmdapi.AuthInfo) string {
 			envParts = append(envParts, e.Name+"="+e.Value)
 		}
 		sort.Strings(envParts)
+		params := strings.Join(extraParams, ",")
 		data := fmt.Sprintf("exec:cmd:%s;api:%s;mode:%s;issuer:%s;client-id:%s;client-secret:%s;extra-params:%s;scopes:%s;env:%s",
 			authInfo.Exec.Command, authInfo.Exec.APIVersion, authInfo.Exec.InteractiveMode,
-			issuer, clientID, clientSecret, extraParams, strings.Join(scopes, ","), strings.Join(envParts, ","))
-		return data
+			issuer, clientID, clientSecret, params, strings.Join(scopes, ","), strings.Join(envParts, ","))
+		// The Dex connector selects the IdP behind a shared issuer. It is
+		// part of params already, but named so that no rewriting of the
+		// params can drop it; keys without one keep their earlier form.
+		if connector := connectorIDFromExtraParams(params); connector != "" {
+			data += ";connector:" + connector
+		}
+		return data + impersonationKey(authInfo)
 	}
 
 	if authInfo.AuthProvider == nil {
@@ -162,7 +242,7 @@ func generateAuthInfoKey(authInfo *clientcmdapi.AuthInfo) string {
 		h := sha256.New()
 		h.Write(authInfo.ClientCertificateData)
 		h.Write(authInfo.ClientKeyData)
-		return fmt.Sprintf("cert:%s", hex.EncodeToString(h.Sum(nil)))
+		return fmt.Sprintf("cert:%s", hex.EncodeToString(h.Sum(nil))) + impersonationKey(authInfo)
 	}
 
 	// Hash the full filtered config (same set authInfoEqual compares) so the key
@@ -177,10 +257,12 @@ func generateAuthInfoKey(authInfo *clientcmdapi.AuthInfo) string {
 	data := fmt.Sprintf("name:%s;config:%s",
 		authInfo.AuthProvider.Name, strings.Join(parts, ";"))
 
-	return data
+	return data + impersonationKey(authInfo)
 }
 
-// mergeAuthInfo merges two AuthInfo objects, preserving id-token and refresh-token from localAuth.
+// mergeAuthInfo merges two AuthInfo objects, preserving the volatileAuthKeys
+// (such as id-token and refresh-token) from localAuth, and its impersonation
+// settings unless serverAuth has its own.
 func mergeAuthInfo(serverAuth, localAuth *clientcmdapi.AuthInfo) *clientcmdapi.AuthInfo {
 	if localAuth == nil {
 		// If there's no local AuthInfo, return the server AuthInfo as is
@@ -190,19 +272,46 @@ func mergeAuthInfo(serverAuth, localAuth *clientcmdapi.AuthInfo) *clientcmdapi.A
 	// Create a copy of the serverAuth to avoid mutating the original
 	mergedAuth := serverAuth.DeepCopy()
 
-	// Preserve id-token and refresh-token from localAuth
+	// Preserve the volatile keys from localAuth
 	if localAuth.AuthProvider != nil && mergedAuth.AuthProvider != nil {
 		// Ensure the merged config map is initialized to avoid panics on assignment
 		if mergedAuth.AuthProvider.Config == nil {
 			mergedAuth.AuthProvider.Config = make(map[string]string)
 		}
-		if idToken, exists := localAuth.AuthProvider.Config["id-token"]; exists {
-			mergedAuth.AuthProvider.Config["id-token"] = idToken
-		}
-		if refreshToken, exists := localAuth.AuthProvider.Config["refresh-token"]; exists {
-			mergedAuth.AuthProvider.Config["refresh-token"] = refreshToken
+		for _, k := range volatileAuthKeys {
+			if v, exists := localAuth.AuthProvider.Config[k]; exists {
+				mergedAuth.AuthProvider.Config[k] = v
+			}
 		}
 	}
 
+	if !hasImpersonation(mergedAuth) {
+		mergedAuth.Impersonate = localAuth.Impersonate
+		mergedAuth.ImpersonateUID = localAuth.ImpersonateUID
+		mergedAuth.ImpersonateGroups = slices.Clone(localAuth.ImpersonateGroups)
+		mergedAuth.ImpersonateUserExtra = maps.Clone(localAuth.ImpersonateUserExtra)
+	}
+
 	return mergedAuth
 }
+
+// previousAuthInfo returns the managed AuthInfo that the local contexts of
+// the server contexts using serverAuthName referenced before this merge, or
+// nil. It lets the tokens of a merged user follow it to a new name, e.g. when
+// generateAuthInfoKey distinguishes users an earlier version merged.
+func previousAuthInfo(localConfig, serverConfig *clientcmdapi.Config, serverAuthName string) (string, *clientcmdapi.AuthInfo) {
+	for _, serverCtxName := range slices.Sorted(maps.Keys(serverConfig.Contexts)) {
+		serverCtx := serverConfig.Contexts[serverCtxName]
+		if serverCtx == nil || serverCtx.AuthInfo != serverAuthName {
+			continue
+		}
+		localCtx := localConfig.Contexts[localContextName(serverCtxName)]
+		if localCtx == nil || !isManaged(localCtx.AuthInfo) {
+			continue
+		}
+		if auth := localConfig.AuthInfos[localCtx.AuthInfo]; auth != nil {
+			return localCtx.AuthInfo, auth
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "Manage the kubeconfig backups written by sync --backup",
+}
+
+var backupsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove kubeconfig backups outside the retention policy",
+	Long: `Removes backups of the kubeconfig that sync --backup wrote to the
+cloudctl-backups directory next to it, keeping the newest ones allowed by the
+retention policy. sync applies the same policy after every backup it writes;
+prune is for cleaning up after the limits were lowered or backups were turned
+off.
+
+A backup is kept only if it is among the newest --backup-keep, younger than
+--backup-max-age, and fits into --backup-max-size together with the newer
+ones. A zero limit is not applied.
+
+Examples:
+  # Keep only the five newest backups
+  cloudctl backups prune --backup-keep 5
+
+  # Preview removing backups older than a week
+  cloudctl backups prune --backup-max-age 168h --dry-run`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runBackupsPrune,
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsPruneCmd)
+	addBackupRetentionFlags(backupsPruneCmd)
+	backupsPruneCmd.Flags().Bool("dry-run", false, "Show which backups would be removed without removing them")
+}
+
+// addBackupRetentionFlags adds the retention flags shared by sync and
+// backups prune, so both read the same config-file keys.
+func addBackupRetentionFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("backup-keep", 10, "Keep at most this many kubeconfig backups (0 for no limit)")
+	cmd.Flags().Duration("backup-max-age", 0, "Remove kubeconfig backups older than this (0 keeps them)")
+	cmd.Flags().Int("backup-max-size", 0, "Keep at most this many megabytes of kubeconfig backups (0 for no limit)")
+}
+
+// backupTimeFormat is the timestamp in backup file names; like
+// logFileTimeFormat it sorts chronologically and is valid on Windows.
+const backupTimeFormat = logFileTimeFormat
+
+// backupRetention limits the backups kept per kubeconfig. Zero fields are not
+// applied.
+type backupRetention struct {
+	Keep    int
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+// loadBackupRetention reads the retention flags.
+func loadBackupRetention() (backupRetention, error) {
+	r := backupRetention{
+		Keep:    viper.GetInt("backup-keep"),
+		MaxAge:  viper.GetDuration("backup-max-age"),
+		MaxSize: int64(viper.GetInt("backup-max-size")) << 20,
+	}
+	switch {
+	case r.Keep < 0:
+		return r, fmt.Errorf("--backup-keep must not be negative, got %d", r.Keep)
+	case r.MaxAge < 0:
+		return r, fmt.Errorf("--backup-max-age must not be negative, got %s", r.MaxAge)
+	case r.MaxSize < 0:
+		return r, fmt.Errorf("--backup-max-size must not be negative, got %d", viper.GetInt("backup-max-size"))
+	}
+	return r, nil
+}
+
+// backupDir returns the directory backups of kubeconfig are written to.
+func backupDir(kubeconfig string) string {
+	return filepath.Join(filepath.Dir(kubeconfig), "cloudctl-backups")
+}
+
+// backupFile is a backup of a kubeconfig.
+type backupFile struct {
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// backupKubeconfig copies kubeconfig to <dir>/<name>-<timestamp>, readable
+// only by the current user, and returns the path of the copy. A kubeconfig
+// that does not exist yet needs no backup and yields "".
+func backupKubeconfig(kubeconfig string, now time.Time) (string, error) {
+	data, err := os.ReadFile(kubeconfig)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", kubeconfig, err)
+	}
+	dir := backupDir(kubeconfig)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(dir, filepath.Base(kubeconfig)+"-"+now.UTC().Format(backupTimeFormat))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", kubeconfig, err)
+	}
+	return path, nil
+}
+
+// listBackups returns the backups of kubeconfig, newest first.
+func listBackups(kubeconfig string) ([]backupFile, error) {
+	dir := backupDir(kubeconfig)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), filepath.Base(kubeconfig)+"-")
+		if !ok || e.IsDir() {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{Path: filepath.Join(dir, e.Name()), Time: t, Size: info.Size()})
+	}
+	slices.SortFunc(backups, func(a, b backupFile) int { return b.Time.Compare(a.Time) })
+	return backups, nil
+}
+
+// expiredBackups splits backups, sorted newest first, into those r keeps and
+// those it removes. Once one backup is removed all older ones are as well.
+func (r backupRetention) expiredBackups(backups []backupFile, now time.Time) (kept, expired []backupFile) {
+	var size int64
+	for i, b := range backups {
+		size += b.Size
+		if (r.Keep > 0 && i >= r.Keep) ||
+			(r.MaxAge > 0 && now.Sub(b.Time) > r.MaxAge) ||
+			(r.MaxSize > 0 && size > r.MaxSize) {
+			return backups[:i], backups[i:]
+		}
+	}
+	return backups, nil
+}
+
+// pruneBackups removes the backups of kubeconfig that r does not keep and
+// returns them along with the number kept.
+func pruneBackups(kubeconfig string, r backupRetention, now time.Time, dryRun bool) ([]backupFile, int, error) {
+	backups, err := listBackups(kubeconfig)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list backups: %w", err)
+	}
+	kept, expired := r.expiredBackups(backups, now)
+	if dryRun {
+		return expired, len(kept), nil
+	}
+	for _, b := range expired {
+		if err := os.Remove(b.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, 0, fmt.Errorf("failed to remove backup: %w", err)
+		}
+	}
+	return expired, len(kept), nil
+}
+
+// backupBeforeWrite backs up kubeconfig when sync --backup is set and
+// enforces the retention policy. Failing to prune is only logged.
+func backupBeforeWrite(kubeconfig string) error {
+	if !backupKubeconfigs {
+		return nil
+	}
+	now := time.Now()
+	path, err := backupKubeconfig(kubeconfig, now)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		slog.Debug("backed up kubeconfig", "kubeconfig", kubeconfig, "backup", path)
+	}
+	removed, _, err := pruneBackups(kubeconfig, backupPolicy, now, false)
+	if err != nil {
+		slog.Warn("failed to prune kubeconfig backups", "kubeconfig", kubeconfig, "error", err)
+	} else if len(removed) > 0 {
+		slog.Debug("pruned kubeconfig backups", "kubeconfig", kubeconfig, "removed", len(removed))
+	}
+	return nil
+}
+
+func runBackupsPrune(cmd *cobra.Command, _ []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	policy, err := loadBackupRetention()
+	if err != nil {
+		return err
+	}
+	dryRun := viper.GetBool("dry-run")
+	kubeconfig, err := resolveWriteTarget(resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig")))
+	if err != nil {
+		return err
+	}
+
+	removed, kept, err := pruneBackups(kubeconfig, policy, time.Now(), dryRun)
+	if err != nil {
+		return err
+	}
+	result := output.BackupsPruneResult{Directory: backupDir(kubeconfig), DryRun: dryRun, Kept: kept}
+	for _, b := range removed {
+		result.Removed = append(result.Removed, output.BackupEntry{Name: filepath.Base(b.Path), Time: b.Time, Size: b.Size})
+	}
+	if !dryRun && len(removed) > 0 {
+		slog.Info("pruned kubeconfig backups", "directory", result.Directory, "removed", len(removed))
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
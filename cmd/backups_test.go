@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestBackupRetention_ExpiredBackups(t *testing.T) {
+	now := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	backups := []backupFile{
+		{Path: "a", Time: now.Add(-1 * time.Hour), Size: 1 << 20},
+		{Path: "b", Time: now.Add(-25 * time.Hour), Size: 1 << 20},
+		{Path: "c", Time: now.Add(-49 * time.Hour), Size: 1 << 20},
+	}
+	for _, tc := range []struct {
+		name   string
+		policy backupRetention
+		kept   int
+	}{
+		{name: "no limits", policy: backupRetention{}, kept: 3},
+		{name: "keep", policy: backupRetention{Keep: 2}, kept: 2},
+		{name: "max age", policy: backupRetention{MaxAge: 24 * time.Hour}, kept: 1},
+		{name: "max size", policy: backupRetention{MaxSize: 2 << 20}, kept: 2},
+		{name: "strictest wins", policy: backupRetention{Keep: 3, MaxAge: 48 * time.Hour, MaxSize: 1 << 20}, kept: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			kept, expired := tc.policy.expiredBackups(backups, now)
+			g.Expect(kept).To(Equal(backups[:tc.kept]))
+			g.Expect(expired).To(HaveLen(len(backups) - tc.kept))
+		})
+	}
+}
+
+func TestWriteConfig_BacksUpAndPrunes(t *testing.T) {
+	g := NewWithT(t)
+	prevBackup, prevPolicy := backupKubeconfigs, backupPolicy
+	backupKubeconfigs, backupPolicy = true, backupRetention{Keep: 2}
+	t.Cleanup(func() { backupKubeconfigs, backupPolicy = prevBackup, prevPolicy })
+
+	path := filepath.Join(t.TempDir(), "config")
+	// Nothing to back up before the first write.
+	g.Expect(writeConfig(clientcmdapi.NewConfig(), path)).To(Succeed())
+	g.Expect(listBackups(path)).To(BeEmpty())
+
+	for i := range 3 {
+		cfg := clientcmdapi.NewConfig()
+		cfg.CurrentContext = string(rune('a' + i))
+		g.Expect(writeConfig(cfg, path)).To(Succeed())
+		time.Sleep(2 * time.Millisecond)
+	}
+	backups, err := listBackups(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(backups).To(HaveLen(2))
+	// The newest backup holds the content before the last write.
+	data, err := os.ReadFile(backups[0].Path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring("current-context: b"))
+	info, err := os.Stat(backupDir(path))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+}
+
+func TestRunBackupsPrune(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(path, []byte("apiVersion: v1\n"), 0o600)).To(Succeed())
+	now := time.Now()
+	for _, age := range []time.Duration{time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		_, err := backupKubeconfig(path, now.Add(-age))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	viper.Set("kubeconfig", path)
+	viper.Set("output", "text")
+	viper.Set("backup-keep", 0)
+	viper.Set("backup-max-age", 24*time.Hour)
+	viper.Set("backup-max-size", 0)
+	viper.Set("dry-run", true)
+	t.Cleanup(func() {
+		for _, key := range []string{"kubeconfig", "output", "backup-keep", "backup-max-age", "backup-max-size", "dry-run"} {
+			viper.Set(key, nil)
+		}
+	})
+	var out bytes.Buffer
+	backupsPruneCmd.SetOut(&out)
+	t.Cleanup(func() { backupsPruneCmd.SetOut(nil) })
+
+	g.Expect(runBackupsPrune(backupsPruneCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Would remove 2 backups from " + backupDir(path) + ", keeping 1."))
+	g.Expect(listBackups(path)).To(HaveLen(3))
+
+	viper.Set("dry-run", false)
+	out.Reset()
+	g.Expect(runBackupsPrune(backupsPruneCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Removed 2 backups"))
+	g.Expect(listBackups(path)).To(HaveLen(1))
+
+	viper.Set("backup-keep", -1)
+	g.Expect(runBackupsPrune(backupsPruneCmd, nil)).To(MatchError(ContainSubstring("--backup-keep must not be negative")))
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// syncBench, when set, times the phases of sync. sync --benchmark sets it.
+var syncBench *syncBenchmark
+
+// syncBenchmarkPhases are the reported phases, in pipeline order. "api" and
+// "decode" split the time spent fetching ClusterKubeconfigs into waiting on
+// the Greenhouse API and decoding its responses.
+var syncBenchmarkPhases = []string{"api", "decode", "load", "build", "merge", "serialize", "write"}
+
+// syncBenchmark accumulates the time spent in each sync phase, across all
+// sources, and the memory statistics at its start.
+type syncBenchmark struct {
+	start    time.Time
+	memStart runtime.MemStats
+
+	mu       sync.Mutex
+	phases   map[string]time.Duration
+	network  time.Duration
+	fetchAPI time.Duration
+	clusters int
+}
+
+func newSyncBenchmark() *syncBenchmark {
+	b := &syncBenchmark{phases: map[string]time.Duration{}}
+	runtime.ReadMemStats(&b.memStart)
+	b.start = time.Now()
+	return b
+}
+
+// track starts timing phase and returns the function ending it. It is safe
+// to call on a nil benchmark.
+func (b *syncBenchmark) track(phase string) func() {
+	if b == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.phases[phase] += time.Since(start)
+	}
+}
+
+// trackFetch starts timing the fetch of ClusterKubeconfigs and returns the
+// function ending it, which is given the number fetched. Requests made
+// meanwhile count as API time, the rest as decode time.
+func (b *syncBenchmark) trackFetch() func(n int) {
+	if b == nil {
+		return func(int) {}
+	}
+	done := b.track("fetch")
+	b.mu.Lock()
+	network := b.network
+	b.mu.Unlock()
+	return func(n int) {
+		done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.fetchAPI += b.network - network
+		b.clusters += n
+	}
+}
+
+// wrapTransport times the Greenhouse API requests, up to the end of their
+// response bodies, so the time the client spends decoding can be told apart.
+func (b *syncBenchmark) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return benchmarkRoundTripper{next: rt, b: b}
+}
+
+type benchmarkRoundTripper struct {
+	next http.RoundTripper
+	b    *syncBenchmark
+}
+
+func (t benchmarkRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.b.addNetwork(time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &benchmarkBody{ReadCloser: resp.Body, b: t.b}
+	return resp, nil
+}
+
+func (b *syncBenchmark) addNetwork(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.network += d
+}
+
+// benchmarkBody counts the time spent waiting for response bytes as network
+// time.
+type benchmarkBody struct {
+	io.ReadCloser
+	b *syncBenchmark
+}
+
+func (r *benchmarkBody) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.ReadCloser.Read(p)
+	r.b.addNetwork(time.Since(start))
+	return n, err
+}
+
+// result returns the report. Connecting to Greenhouse is part of the total
+// only.
+func (b *syncBenchmark) result() output.SyncBenchmarkResult {
+	total := time.Since(b.start)
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	phases := map[string]time.Duration{
+		"api":    b.fetchAPI,
+		"decode": max(b.phases["fetch"]-b.fetchAPI, 0),
+	}
+	for name, d := range b.phases {
+		if name != "fetch" {
+			phases[name] = d
+		}
+	}
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	res := output.SyncBenchmarkResult{
+		Clusters:       b.clusters,
+		TotalMs:        ms(total),
+		Phases:         make([]output.BenchmarkPhase, 0, len(syncBenchmarkPhases)),
+		AllocatedBytes: mem.TotalAlloc - b.memStart.TotalAlloc,
+		Allocations:    mem.Mallocs - b.memStart.Mallocs,
+		HeapBytes:      mem.HeapAlloc,
+		GCCycles:       mem.NumGC - b.memStart.NumGC,
+	}
+	for _, name := range syncBenchmarkPhases {
+		res.Phases = append(res.Phases, output.BenchmarkPhase{Name: name, Ms: ms(phases[name])})
+	}
+	return res
+}
+
+// benchmarkUnchangedWrite times serializing config and writing it to a
+// temporary file. sync --benchmark uses it when the kubeconfig is up to date,
+// so the serialize and write phases are reported without touching the file.
+func benchmarkUnchangedWrite(config *clientcmdapi.Config) error {
+	doneSerialize := syncBench.track("serialize")
+	content, err := clientcmd.Write(*config)
+	doneSerialize()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "cloudctl-benchmark-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	doneWrite := syncBench.track("write")
+	_, err = f.Write(content)
+	doneWrite()
+	return errors.Join(err, f.Close())
+}
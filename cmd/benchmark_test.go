@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestSyncBenchmark(t *testing.T) {
+	g := NewWithT(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+
+	b := newSyncBenchmark()
+	client := &http.Client{Transport: b.wrapTransport(http.DefaultTransport)}
+	doneFetch := b.trackFetch()
+	resp, err := client.Get(srv.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	time.Sleep(10 * time.Millisecond)
+	doneFetch(3)
+	b.track("merge")()
+
+	res := b.result()
+	g.Expect(res.Clusters).To(Equal(3))
+	names := make([]string, 0, len(res.Phases))
+	for _, p := range res.Phases {
+		names = append(names, p.Name)
+	}
+	g.Expect(names).To(Equal(syncBenchmarkPhases))
+	g.Expect(res.Phases[0].Ms).To(BeNumerically(">=", 20), "api covers the request")
+	g.Expect(res.Phases[1].Ms).To(BeNumerically(">=", 10), "decode covers the rest of the fetch")
+	g.Expect(res.TotalMs).To(BeNumerically(">=", res.Phases[0].Ms+res.Phases[1].Ms))
+
+	var nilBench *syncBenchmark
+	nilBench.track("load")()
+	nilBench.trackFetch()(1)
+}
+
+func TestRunSync_BenchmarkLeavesUpToDateKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	useTempSyncState(t)
+
+	ghCfg := clientcmdapi.NewConfig()
+	ghCfg.Clusters["gh"] = &clientcmdapi.Cluster{Server: "https://greenhouse.example.com"}
+	ghCfg.AuthInfos["gh"] = &clientcmdapi.AuthInfo{Token: "x"}
+	ghCfg.Contexts["gh"] = &clientcmdapi.Context{Cluster: "gh", AuthInfo: "gh"}
+	ghCfg.CurrentContext = "gh"
+	local := filepath.Join(t.TempDir(), "local")
+
+	ckc := greenhousev1alpha1.ClusterKubeconfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "eu", Namespace: "org", ResourceVersion: "1"},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+			Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: "eu", Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: "https://eu.example"}}},
+			AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{Name: "eu", AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{
+				AuthProvider: clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"client-id": "cloudctl"}},
+			}}},
+			Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "eu", Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: "eu", AuthInfo: "eu"}}},
+		}},
+	}
+	ckc.Status.Conditions.SetConditions(greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", ""))
+	stub := &stubGreenhouseClient{allowed: true, ckcs: []greenhousev1alpha1.ClusterKubeconfig{ckc}}
+	prev, prevNamespace := newGreenhouseClient, greenhouseClusterNamespace
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) { return stub, nil }
+
+	settings := map[string]any{
+		"greenhouse-cluster-kubeconfig": writeTestKubeconfig(t, ghCfg),
+		"greenhouse-cluster-namespace":  "org",
+		"remote-cluster-kubeconfig":     local,
+		"prefix":                        "cloudctl",
+		"auth-type":                     "auth-provider",
+		"output":                        "text",
+	}
+	for k, v := range settings {
+		viper.Set(k, v)
+	}
+	t.Cleanup(func() {
+		newGreenhouseClient, greenhouseClusterNamespace = prev, prevNamespace
+		for _, k := range []string{"benchmark", "changed-exit-code", "backup"} {
+			viper.Set(k, nil)
+		}
+		for k := range settings {
+			viper.Set(k, nil)
+		}
+	})
+	syncCmd.SetContext(context.Background())
+	syncCmd.SetOut(io.Discard)
+	t.Cleanup(func() { syncCmd.SetOut(nil) })
+
+	g.Expect(runSync(syncCmd, nil)).To(Succeed())
+	written, err := os.Stat(local)
+	g.Expect(err).NotTo(HaveOccurred())
+	past := written.ModTime().Add(-time.Hour)
+	g.Expect(os.Chtimes(local, past, past)).To(Succeed())
+
+	viper.Set("benchmark", true)
+	viper.Set("changed-exit-code", true)
+	viper.Set("backup", true)
+	g.Expect(runSync(syncCmd, nil)).To(Succeed(), "an up-to-date kubeconfig is no change, benchmark or not")
+	after, err := os.Stat(local)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(after.ModTime()).To(BeTemporally("==", past), "the kubeconfig is not rewritten")
+	backups, err := filepath.Glob(local + "*")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(backups).To(ConsistOf(local), "no backup is written")
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Report expiry dates of kubeconfig certificates",
+	Long: `Parses the client certificates and cluster CA certificates of managed
+kubeconfig entries and reports when they expire. Certificates expiring within
+--warn-days are flagged, so certificate-based clusters do not silently break
+when their certificates lapse. The command exits with an error when a
+certificate has already expired.
+
+Examples:
+  # Managed entries, warn 30 days ahead
+  cloudctl certs
+
+  # Every entry in the kubeconfig, warn 60 days ahead, as JSON
+  cloudctl certs --all --warn-days 60 -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runCerts,
+}
+
+func init() {
+	certsCmd.Flags().Int("warn-days", 30, "Flag certificates expiring within this many days")
+	certsCmd.Flags().Bool("all", false, "Include entries not managed by cloudctl")
+}
+
+// certNow is the reference time for certificate expiry checks. It is a
+// variable so tests can pin it.
+var certNow = time.Now
+
+func runCerts(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	warnDays := viper.GetInt("warn-days")
+	if warnDays < 0 {
+		return fmt.Errorf("--warn-days must not be negative, got %d", warnDays)
+	}
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+
+	result := output.CertsResult{WarnDays: warnDays, Certificates: collectCerts(cfg, viper.GetBool("all"), certNow(), warnDays)}
+	var expired int
+	for _, c := range result.Certificates {
+		switch c.Status {
+		case output.CertStatusExpiring:
+			slog.Warn("certificate expires soon", "entry", c.Kind+"/"+c.Name, "type", c.Type, "notAfter", c.NotAfter, "daysLeft", c.DaysLeft)
+		case output.CertStatusExpired:
+			expired++
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if expired > 0 {
+		return fmt.Errorf("%d certificate(s) have expired", expired)
+	}
+	return nil
+}
+
+// collectCerts reports the CA certificates of clusters and the client
+// certificates of users, sorted by expiry (soonest first). Unless all is set,
+// only managed entries are included.
+func collectCerts(cfg *clientcmdapi.Config, all bool, now time.Time, warnDays int) []output.CertInfo {
+	var certs []output.CertInfo
+	for _, name := range slices.Sorted(maps.Keys(cfg.Clusters)) {
+		c := cfg.Clusters[name]
+		if c == nil || (!all && !isManaged(name)) {
+			continue
+		}
+		if info := certInfo(c.CertificateAuthorityData, c.CertificateAuthority, now, warnDays); info != nil {
+			info.Kind, info.Name, info.Type = "cluster", name, "CA"
+			certs = append(certs, *info)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.AuthInfos)) {
+		ai := cfg.AuthInfos[name]
+		if ai == nil || (!all && !isManaged(name)) {
+			continue
+		}
+		if info := certInfo(ai.ClientCertificateData, ai.ClientCertificate, now, warnDays); info != nil {
+			info.Kind, info.Name, info.Type = "user", name, "client"
+			certs = append(certs, *info)
+		}
+	}
+	slices.SortStableFunc(certs, func(a, b output.CertInfo) int {
+		// Invalid certificates (zero NotAfter) sort first.
+		return a.NotAfter.Compare(b.NotAfter)
+	})
+	return certs
+}
+
+// certInfo parses the certificate in data (or file) and classifies its expiry.
+// It returns nil when there is no certificate.
+func certInfo(data []byte, file string, now time.Time, warnDays int) *output.CertInfo {
+	cert, err := parseCertificate(data, file)
+	if errors.Is(err, errNoCertificate) {
+		return nil
+	}
+	if err != nil {
+		return &output.CertInfo{Status: output.CertStatusInvalid, Error: err.Error()}
+	}
+	info := &output.CertInfo{
+		Subject:  cert.Subject.String(),
+		NotAfter: cert.NotAfter,
+		DaysLeft: int(cert.NotAfter.Sub(now) / (24 * time.Hour)),
+		Status:   output.CertStatusOK,
+	}
+	switch {
+	case now.After(cert.NotAfter):
+		info.Status = output.CertStatusExpired
+	case cert.NotAfter.Before(now.AddDate(0, 0, warnDays)):
+		info.Status = output.CertStatusExpiring
+	}
+	return info
+}
+
+// errNoCertificate is returned by parseCertificate when neither data nor file
+// is set.
+var errNoCertificate = errors.New("no certificate")
+
+// parseCertificate returns the first PEM certificate in data, or in file when
+// data is empty.
+func parseCertificate(data []byte, file string) (*x509.Certificate, error) {
+	if len(data) == 0 && file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read certificate file: %w", err)
+		}
+		data = b
+	}
+	if len(data) == 0 {
+		return nil, errNoCertificate
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse certificate: %w", err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestCollectCerts(t *testing.T) {
+	g := NewWithT(t)
+	prev := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = prev })
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{CertificateAuthorityData: testCertPEM(t, now.AddDate(2, 0, 0))}
+	cfg.Clusters["cloudctl:us"] = &clientcmdapi.Cluster{CertificateAuthorityData: []byte("garbage")}
+	cfg.Clusters["local"] = &clientcmdapi.Cluster{CertificateAuthorityData: testCertPEM(t, now.AddDate(0, 0, -3))}
+	cfg.AuthInfos["cloudctl:eu"] = &clientcmdapi.AuthInfo{ClientCertificateData: testCertPEM(t, now.AddDate(0, 0, 10))}
+	cfg.AuthInfos["cloudctl:oidc"] = &clientcmdapi.AuthInfo{Token: "t"}
+
+	certs := collectCerts(cfg, false, now, 30)
+	g.Expect(certs).To(HaveLen(3))
+	g.Expect(certs[0]).To(And(
+		HaveField("Name", "cloudctl:us"),
+		HaveField("Status", output.CertStatusInvalid),
+		HaveField("Error", "not PEM encoded"),
+	))
+	g.Expect(certs[1]).To(And(
+		HaveField("Kind", "user"),
+		HaveField("Type", "client"),
+		HaveField("Status", output.CertStatusExpiring),
+		HaveField("DaysLeft", 10),
+	))
+	g.Expect(certs[2]).To(And(
+		HaveField("Kind", "cluster"),
+		HaveField("Type", "CA"),
+		HaveField("Status", output.CertStatusOK),
+		HaveField("Subject", "CN=test"),
+	))
+
+	certs = collectCerts(cfg, true, now, 5)
+	g.Expect(certs).To(HaveLen(4))
+	g.Expect(certs[1]).To(And(HaveField("Name", "local"), HaveField("Status", output.CertStatusExpired), HaveField("DaysLeft", -3)))
+	g.Expect(certs[2]).To(HaveField("Status", output.CertStatusOK), "outside the 5 day window")
+}
+
+func TestRunCerts_FailsOnExpired(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("eu"))
+	viper.Set("warn-days", 30)
+	t.Cleanup(func() { viper.Set("warn-days", nil) })
+
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	cfg.Clusters["cloudctl:eu"].CertificateAuthorityData = testCertPEM(t, time.Now().AddDate(0, 0, -1))
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+
+	var out bytes.Buffer
+	certsCmd.SetOut(&out)
+	t.Cleanup(func() { certsCmd.SetOut(nil) })
+
+	g.Expect(runCerts(certsCmd, nil)).To(MatchError("1 certificate(s) have expired"))
+	g.Expect(out.String()).To(ContainSubstring("cluster/cloudctl:eu  CA"))
+	g.Expect(out.String()).To(ContainSubstring("expired 1 day(s) ago"))
+}
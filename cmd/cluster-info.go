@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var clusterInfoCmd = &cobra.Command{
+	Use:   "cluster-info <context>",
+	Short: "Show how a kubeconfig context connects and authenticates",
+	Long: `Prints what a kubeconfig context is configured to do, without contacting the
+cluster: its server URL, the subject, expiry and SHA-256 fingerprint of the
+cluster CA, the TLS server name, the proxy URL, how it authenticates (oidc,
+exec, cert, token, basic or none), and for contexts written by sync the
+Greenhouse organization and API server they came from, their labels and when
+they were last synced.
+
+The context is matched like in cloudctl use: an exact name or a unique fuzzy
+match of the name or its cluster labels.
+
+Examples:
+  cloudctl cluster-info prod-eu
+  cloudctl cluster-info cloudctl:prod-eu -o json`,
+	Args:              cobra.ExactArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runClusterInfo,
+}
+
+func runClusterInfo(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	name, err := selectTarget("context", args[0], contextItems(cfg))
+	if err != nil {
+		return err
+	}
+	result, err := clusterInfo(cfg, name)
+	if err != nil {
+		return err
+	}
+	if result.Managed {
+		_, syncs, err := recordedSyncs(path)
+		if err != nil {
+			return err
+		}
+		result.Sync = syncOwning(syncs, result.Cluster)
+	}
+
+	w := cmd.OutOrStdout()
+	return output.New(format, output.IsTTYWriter(w), w).Print(result)
+}
+
+// clusterInfo describes the context name of cfg and the cluster and user it
+// references, without the sync that wrote it.
+func clusterInfo(cfg *clientcmdapi.Config, name string) (output.ClusterInfoResult, error) {
+	kctx := cfg.Contexts[name]
+	if kctx == nil {
+		return output.ClusterInfoResult{}, fmt.Errorf("context %q not found", name)
+	}
+	result := output.ClusterInfoResult{
+		Context:   name,
+		Cluster:   kctx.Cluster,
+		User:      kctx.AuthInfo,
+		Namespace: kctx.Namespace,
+		Managed:   hasManagedCluster(kctx),
+	}
+	cluster := cfg.Clusters[kctx.Cluster]
+	if cluster == nil {
+		return output.ClusterInfoResult{}, fmt.Errorf("cluster %q of context %q not found", kctx.Cluster, name)
+	}
+	result.Server = cluster.Server
+	result.TLSServerName = cluster.TLSServerName
+	result.ProxyURL = cluster.ProxyURL
+	result.InsecureSkipTLSVerify = cluster.InsecureSkipTLSVerify
+	result.Labels = clusterLabels(cluster)
+	result.CA = caInfo(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	result.Auth = authInfoSummary(cfg.AuthInfos[kctx.AuthInfo])
+	return result, nil
+}
+
+// caInfo describes the CA certificate in data (or file), or returns nil when
+// the cluster uses the system trust store.
+func caInfo(data []byte, file string) *output.ClusterInfoCA {
+	info := &output.ClusterInfoCA{Source: "embedded"}
+	if len(data) == 0 && file != "" {
+		info.Source = file
+	}
+	cert, err := parseCertificate(data, file)
+	switch {
+	case errors.Is(err, errNoCertificate):
+		return nil
+	case err != nil:
+		info.Error = err.Error()
+		return info
+	}
+	sum := sha256.Sum256(cert.Raw)
+	info.Subject = cert.Subject.String()
+	info.Issuer = cert.Issuer.String()
+	info.NotAfter = cert.NotAfter
+	info.Fingerprint = colonHex(sum[:])
+	return info
+}
+
+// colonHex formats b as upper-case hex bytes separated by colons, the way
+// openssl prints certificate fingerprints.
+func colonHex(b []byte) string {
+	s := strings.ToUpper(hex.EncodeToString(b))
+	parts := make([]string, 0, len(b))
+	for i := 0; i < len(s); i += 2 {
+		parts = append(parts, s[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// authInfoSummary classifies how ai authenticates: oidc (kubelogin,
+// cloudctl get-token or the legacy oidc auth-provider), another exec plugin or
+// auth-provider, a client certificate, a bearer token, basic auth, or none.
+// Secrets are never included.
+func authInfoSummary(ai *clientcmdapi.AuthInfo) output.ClusterInfoAuth {
+	if ai == nil {
+		return output.ClusterInfoAuth{Mechanism: "none"}
+	}
+	auth := output.ClusterInfoAuth{Impersonate: ai.Impersonate}
+	if p, _, err := oidcParamsFromAuthInfo(ai); err == nil {
+		auth.Mechanism, auth.Issuer, auth.ClientID = "oidc", p.IssuerURL, p.ClientID
+	}
+	switch {
+	case ai.Exec != nil:
+		auth.Command = filepath.Base(ai.Exec.Command)
+		if len(ai.Exec.Args) > 0 {
+			auth.Command += " " + ai.Exec.Args[0]
+		}
+		if auth.Mechanism == "" {
+			auth.Mechanism = "exec"
+		}
+	case ai.AuthProvider != nil:
+		if auth.Mechanism == "" {
+			auth.Mechanism = "auth-provider " + ai.AuthProvider.Name
+		}
+	case len(ai.ClientCertificateData) > 0 || ai.ClientCertificate != "":
+		auth.Mechanism = "cert"
+		if cert, err := parseCertificate(ai.ClientCertificateData, ai.ClientCertificate); err == nil {
+			auth.Subject = cert.Subject.String()
+		}
+	case ai.Token != "" || ai.TokenFile != "":
+		auth.Mechanism = "token"
+	case ai.Username != "":
+		auth.Mechanism = "basic"
+		auth.Subject = ai.Username
+	default:
+		auth.Mechanism = "none"
+	}
+	return auth
+}
+
+// syncOwning returns the recorded sync whose prefix the managed cluster name
+// carries, or nil. Context names are not prefixed by sync, so the cluster a
+// context references is what ties it to a sync.
+func syncOwning(syncs []sourceState, cluster string) *output.ClusterInfoSync {
+	for _, s := range syncs {
+		if strings.HasPrefix(cluster, s.Prefix+":") {
+			return &output.ClusterInfoSync{Endpoint: s.Endpoint, Namespace: s.Namespace, Prefix: s.Prefix, LastSync: s.LastSync}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestAuthInfoSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(authInfoSummary(nil)).To(Equal(output.ClusterInfoAuth{Mechanism: "none"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{})).To(Equal(output.ClusterInfoAuth{Mechanism: "none"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command: "/usr/local/bin/kubectl",
+		Args:    []string{"oidc-login", "get-token", "--oidc-issuer-url=https://idp", "--oidc-client-id=cli", "--oidc-client-secret=s3cret"},
+	}})).To(Equal(output.ClusterInfoAuth{Mechanism: "oidc", Command: "kubectl oidc-login", Issuer: "https://idp", ClientID: "cli"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{Command: "aws", Args: []string{"eks", "get-token"}}})).
+		To(Equal(output.ClusterInfoAuth{Mechanism: "exec", Command: "aws eks"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name: "oidc", Config: map[string]string{"idp-issuer-url": "https://idp", "client-id": "cli", "id-token": "t"},
+	}})).To(Equal(output.ClusterInfoAuth{Mechanism: "oidc", Issuer: "https://idp", ClientID: "cli"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "gcp"}})).
+		To(Equal(output.ClusterInfoAuth{Mechanism: "auth-provider gcp"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{ClientCertificateData: testCertPEM(t, time.Now().AddDate(1, 0, 0))})).
+		To(Equal(output.ClusterInfoAuth{Mechanism: "cert", Subject: "CN=test"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{Token: "t", Impersonate: "admin"})).
+		To(Equal(output.ClusterInfoAuth{Mechanism: "token", Impersonate: "admin"}))
+	g.Expect(authInfoSummary(&clientcmdapi.AuthInfo{Username: "jane", Password: "p"})).
+		To(Equal(output.ClusterInfoAuth{Mechanism: "basic", Subject: "jane"}))
+}
+
+func TestCAInfo(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(caInfo(nil, "")).To(BeNil(), "no CA means the system trust store")
+
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	ca := testCertPEM(t, notAfter)
+	block, _ := pem.Decode(ca)
+	sum := sha256.Sum256(block.Bytes)
+	g.Expect(caInfo(ca, "")).To(Equal(&output.ClusterInfoCA{
+		Source:      "embedded",
+		Subject:     "CN=test",
+		Issuer:      "CN=test",
+		NotAfter:    notAfter,
+		Fingerprint: colonHex(sum[:]),
+	}))
+	g.Expect(colonHex([]byte{0xab, 0x01, 0xff})).To(Equal("AB:01:FF"))
+
+	info := caInfo(nil, filepath.Join(t.TempDir(), "missing.crt"))
+	g.Expect(info.Source).To(HaveSuffix("missing.crt"))
+	g.Expect(info.Error).To(ContainSubstring("cannot read certificate file"))
+}
+
+func TestRunClusterInfo(t *testing.T) {
+	g := NewWithT(t)
+	useTempSyncState(t)
+
+	labels, _ := json.Marshal(map[string]string{"region": "eu-de-1"})
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:eu-1"] = &clientcmdapi.Cluster{
+		Server:        "https://eu-1.example",
+		TLSServerName: "api.eu-1",
+		ProxyURL:      "socks5://localhost:1080",
+		Extensions:    map[string]runtime.Object{"labels": &runtime.Unknown{Raw: labels}},
+	}
+	cfg.AuthInfos["cloudctl:eu-1"] = &clientcmdapi.AuthInfo{Token: "secret-token"}
+	cfg.Contexts["eu-1"] = &clientcmdapi.Context{Cluster: "cloudctl:eu-1", AuthInfo: "cloudctl:eu-1", Namespace: "kube-system"}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+
+	lastSync := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	st.record(sourceState{Kubeconfig: stateKubeconfigPath(path), Endpoint: "https://gh", Namespace: "org", Prefix: "cloudctl", LastSync: lastSync})
+	g.Expect(st.save()).To(Succeed())
+
+	viper.Set("kubeconfig", path)
+	viper.Set("output", "text")
+	viper.Set("prefix", "cloudctl")
+	t.Cleanup(func() {
+		viper.Set("kubeconfig", nil)
+		viper.Set("output", nil)
+		viper.Set("prefix", nil)
+	})
+
+	var buf bytes.Buffer
+	clusterInfoCmd.SetOut(&buf)
+	t.Cleanup(func() { clusterInfoCmd.SetOut(nil) })
+	g.Expect(runClusterInfo(clusterInfoCmd, []string{"eu-de-1"})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("" +
+		"Context:          eu-1\n" +
+		"Cluster:          cloudctl:eu-1\n" +
+		"User:             cloudctl:eu-1\n" +
+		"Namespace:        kube-system\n" +
+		"Server:           https://eu-1.example\n" +
+		"TLS server name:  api.eu-1\n" +
+		"Proxy URL:        socks5://localhost:1080\n" +
+		"CA:               system trust store\n" +
+		"Auth:             token\n" +
+		"Managed:          yes, by sync of org from https://gh (prefix cloudctl)\n" +
+		"Last sync:        2026-05-01 12:00 UTC\n" +
+		"Labels:           region=eu-de-1\n"))
+	g.Expect(buf.String()).NotTo(ContainSubstring("secret-token"))
+
+	g.Expect(runClusterInfo(clusterInfoCmd, []string{"nope"})).To(MatchError(ContainSubstring(`no context matches "nope"`)))
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// versionCacheTTL is how long a probed server version is reused by
+// cluster-version --all-contexts before the API server is asked again.
+const versionCacheTTL = 10 * time.Minute
+
+// versionCacheDir returns the directory server versions are cached in. It is
+// a variable so tests can keep the cache out of the home directory.
+var versionCacheDir = func() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "versions"), nil
+}
+
+// cachedServerVersion is a cache file holding the version of one API server.
+type cachedServerVersion struct {
+	Fetched time.Time `json:"fetched"`
+	Context string    `json:"context"`
+	Server  string    `json:"server"`
+	Version string    `json:"version"`
+}
+
+// versionCachePath returns the cache file for the API server reached through
+// contextName. The server is part of the key, so a context pointed at another
+// cluster is probed afresh.
+func versionCachePath(contextName, server string) (string, error) {
+	dir, err := versionCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(contextName + "\x00" + server))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// cachedVersion returns the version cached for contextName and server while it
+// is younger than versionCacheTTL, reporting cached as true, and otherwise the
+// one returned by probe, caching it. refresh skips the lookup but still
+// caches the result. Failed probes are not cached, so unreachable clusters are
+// retried on the next call, and cache failures are ignored.
+func cachedVersion(contextName, server string, refresh bool, probe func() (string, error)) (version string, cached bool, err error) {
+	path, pathErr := versionCachePath(contextName, server)
+	if pathErr == nil && !refresh {
+		var c cachedServerVersion
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &c) == nil &&
+			c.Server == server && time.Since(c.Fetched) < versionCacheTTL {
+			return c.Version, true, nil
+		}
+	}
+	version, err = probe()
+	if err != nil {
+		return "", false, err
+	}
+	if pathErr == nil {
+		data, err := json.Marshal(cachedServerVersion{Fetched: time.Now(), Context: contextName, Server: server, Version: version})
+		if err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			_ = os.WriteFile(path, data, 0o600)
+		}
+	}
+	return version, false, nil
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// useVersionCacheDir keeps the version cache in a temporary directory.
+func useVersionCacheDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prev := versionCacheDir
+	versionCacheDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { versionCacheDir = prev })
+	return dir
+}
+
+func TestCachedVersion(t *testing.T) {
+	g := NewWithT(t)
+	useVersionCacheDir(t)
+
+	probes := 0
+	probe := func() (string, error) {
+		probes++
+		return "1.30.2", nil
+	}
+
+	version, cached, err := cachedVersion("cloudctl:eu-1", "https://eu-1.example", false, probe)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(version).To(Equal("1.30.2"))
+	g.Expect(cached).To(BeFalse())
+
+	version, cached, err = cachedVersion("cloudctl:eu-1", "https://eu-1.example", false, probe)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(version).To(Equal("1.30.2"))
+	g.Expect(cached).To(BeTrue())
+	g.Expect(probes).To(Equal(1))
+
+	_, cached, _ = cachedVersion("cloudctl:eu-1", "https://eu-1.example", true, probe)
+	g.Expect(cached).To(BeFalse(), "refresh bypasses the cache")
+	_, cached, _ = cachedVersion("cloudctl:eu-1", "https://eu-2.example", false, probe)
+	g.Expect(cached).To(BeFalse(), "another server is another cache entry")
+	g.Expect(probes).To(Equal(3))
+}
+
+func TestCachedVersion_Expired(t *testing.T) {
+	g := NewWithT(t)
+	useVersionCacheDir(t)
+
+	path, err := versionCachePath("cloudctl:eu-1", "https://eu-1.example")
+	g.Expect(err).NotTo(HaveOccurred())
+	data, _ := json.Marshal(cachedServerVersion{
+		Fetched: time.Now().Add(-versionCacheTTL - time.Minute),
+		Server:  "https://eu-1.example",
+		Version: "1.29.0",
+	})
+	g.Expect(os.WriteFile(path, data, 0o600)).To(Succeed())
+
+	version, cached, err := cachedVersion("cloudctl:eu-1", "https://eu-1.example", false, func() (string, error) { return "1.30.2", nil })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(version).To(Equal("1.30.2"))
+	g.Expect(cached).To(BeFalse())
+}
+
+func TestCachedVersion_ErrorsAreNotCached(t *testing.T) {
+	g := NewWithT(t)
+	useVersionCacheDir(t)
+
+	_, _, err := cachedVersion("cloudctl:dark", "https://dark.example", false, func() (string, error) { return "", errors.New("timeout") })
+	g.Expect(err).To(MatchError("timeout"))
+
+	version, cached, err := cachedVersion("cloudctl:dark", "https://dark.example", false, func() (string, error) { return "1.30.2", nil })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(version).To(Equal("1.30.2"))
+	g.Expect(cached).To(BeFalse())
+}
@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -12,12 +13,20 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 
@@ -33,7 +42,14 @@ An unauthenticated GET to /version is attempted first (faster, no token
 refresh required). If the server requires authentication, cloudctl falls
 back to an authenticated GET to /version using the kubeconfig credentials.
 
-If the API server is unreachable the command exits after --timeout (default 10s).
+If the API server is unreachable the command exits after --timeout (default 10s),
+which bounds both requests together. Ctrl+C aborts the probe immediately.
+
+With --all-contexts, every managed context (written by sync) is probed, and
+versions probed within the last 10 minutes are taken from a cache in
+$XDG_CACHE_HOME/cloudctl/versions instead, so repeated calls do not query
+dozens of API servers. --refresh ignores the cache. Probing a single context
+always asks the server and updates the cache.
 
 Examples:
   # Version of the current context
@@ -46,7 +62,13 @@ Examples:
   cloudctl cluster-version --context prod-eu -o json
 
   # Shorter timeout when scripting
-  cloudctl cluster-version --context prod-eu --timeout 5s`,
+  cloudctl cluster-version --context prod-eu --timeout 5s
+
+  # Kubelet versions and skew during a rolling upgrade
+  cloudctl cluster-version --context prod-eu --wide
+
+  # Versions of all managed clusters, ignoring cached results
+  cloudctl cluster-version --all-contexts --refresh`,
 	RunE: runClusterVersion,
 }
 
@@ -64,10 +86,20 @@ func runClusterVersion(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--kubeconfig must not be empty")
 	}
 
-	timeoutStr := viper.GetString("timeout")
-	timeout, err := time.ParseDuration(timeoutStr)
+	timeout, err := requestTimeout()
 	if err != nil {
-		return fmt.Errorf("invalid --timeout %q: %w", timeoutStr, err)
+		return err
+	}
+	timeout = cmp.Or(timeout, defaultProbeTimeout)
+
+	if viper.GetBool("all-contexts") {
+		if kubecontext != "" {
+			return fmt.Errorf("--all-contexts cannot be combined with --context")
+		}
+		if viper.GetBool("wide") {
+			return fmt.Errorf("--all-contexts cannot be combined with --wide")
+		}
+		return runClusterVersions(cmd, kubeconfig, timeout, viper.GetBool("refresh"))
 	}
 
 	cfg, err := configWithContext(kubecontext, kubeconfig)
@@ -105,34 +137,210 @@ func runClusterVersion(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
 	defer cancel()
 
-	// 1) Try unauthenticated GET /version
-	ver, err := getUnauthenticatedVersion(ctx, cfg)
-	if err != nil {
-		// 2) Fallback to authenticated
-		if !hasAuth(cfg) {
-			return fmt.Errorf("no authentication methods found in your kubeconfig. Please authenticate (`kubelogin`, etc.) and try again")
+	serverVersion, _, err := cachedVersion(effectiveContext, cfg.Host, true, func() (string, error) {
+		ver, err := probeVersion(ctx, effectiveContext, cfg)
+		if err != nil {
+			return "", err
 		}
+		return cleanVersion(ver.GitVersion), nil
+	})
+	if err != nil {
+		return err
+	}
 
-		ver, err = getAuthenticatedVersion(ctx, cfg)
+	result := output.ClusterVersionResult{Context: effectiveContext, Version: serverVersion}
+	if viper.GetBool("wide") {
+		nodes, err := listNodes(ctx, cfg)
 		if err != nil {
-			return fmt.Errorf("authenticated version fetch failed: %w", err)
+			return fmt.Errorf("failed to list nodes in context %q: %w", effectiveContext, err)
 		}
+		result.Nodes = nodeVersions(result.Version, nodes)
 	}
 
-	// Strip build metadata so we get a clean semver string (e.g. "1.29.3").
-	parts := strings.Split(ver.GitVersion, "-")
-	clean := parts[0]
-	parts = strings.Split(clean, "+")
-	clean = parts[0]
-	clusterVersion := strings.TrimPrefix(clean, "v")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
+
+// clusterVersionConcurrency is the number of clusters cluster-version
+// --all-contexts probes in parallel.
+const clusterVersionConcurrency = 10
 
+// runClusterVersions reports the server version of every managed context in
+// the kubeconfig at path, from the version cache unless refresh is set. It
+// fails when any cluster could not be probed.
+func runClusterVersions(cmd *cobra.Command, path string, timeout time.Duration, refresh bool) error {
 	format, err := output.ParseFormat(viper.GetString("output"))
 	if err != nil {
 		return err
 	}
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	names, err := fleetContexts(cfg, nil)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no managed contexts found in %s; run `cloudctl sync` first", displayKubeconfig(path))
+	}
+
+	slog.Info("querying cluster versions", "kubeconfig", displayKubeconfig(path), "clusters", len(names), "refresh", refresh)
+
 	w := cmd.OutOrStdout()
 	printer := output.New(format, output.IsTTYWriter(w), w)
-	return printer.Print(output.ClusterVersionResult{Context: effectiveContext, Version: clusterVersion})
+	step, stop := printer.StartProgress("Probing clusters", len(names))
+	result := output.ClusterVersionsResult{Clusters: make([]output.ClusterVersionEntry, len(names))}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		result.Clusters[i] = output.ClusterVersionEntry{Context: name, Error: "not probed"}
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return err
+	}
+	defer tun.Close()
+
+	forEachContext(cmd.Context(), names, clusterVersionConcurrency, func(ctx context.Context, name string) {
+		result.Clusters[index[name]] = contextVersion(ctx, path, name, tun, contextClusterLabels(cfg, name), timeout, refresh)
+		step()
+	})
+	stop()
+
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range result.Clusters {
+		if c.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cluster(s) could not be probed", failed, len(names))
+	}
+	return nil
+}
+
+// contextVersion returns the cached server version of one context, or probes
+// it through the tunnel for its cluster labels.
+func contextVersion(ctx context.Context, path, contextName string, tun *tunnels, labels map[string]string, timeout time.Duration, refresh bool) output.ClusterVersionEntry {
+	result := output.ClusterVersionEntry{Context: contextName}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	restCfg.Timeout = timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result.Version, result.Cached, err = cachedVersion(contextName, restCfg.Host, refresh, func() (string, error) {
+		if err := tun.apply(ctx, restCfg, labels); err != nil {
+			return "", err
+		}
+		ver, err := probeVersion(ctx, contextName, restCfg)
+		if err != nil {
+			return "", err
+		}
+		return cleanVersion(ver.GitVersion), nil
+	})
+	if err != nil {
+		slog.Debug("version probe failed", "context", contextName, "error", err)
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// cleanVersion strips the "v" prefix, pre-release and build metadata so we get
+// a clean semver string (e.g. "1.29.3").
+func cleanVersion(gitVersion string) string {
+	parts := strings.Split(gitVersion, "-")
+	clean := parts[0]
+	parts = strings.Split(clean, "+")
+	clean = parts[0]
+	return strings.TrimPrefix(clean, "v")
+}
+
+// maxKubeletMinorSkew is the number of minor versions a kubelet may lag behind
+// the API server under the Kubernetes version skew policy (since 1.28).
+const maxKubeletMinorSkew = 3
+
+// listNodes returns the cluster's nodes. It is a variable so tests can avoid
+// a live cluster.
+var listNodes = func(ctx context.Context, cfg *rest.Config) ([]corev1.Node, error) {
+	cs, err := kubernetes.NewForConfig(withTracing(cfg))
+	if err != nil {
+		return nil, err
+	}
+	list, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// nodeVersions reports the kubelet version of each node, sorted by name, and
+// its minor version skew to the control plane. Kubelets newer than the control
+// plane or more than maxKubeletMinorSkew behind are flagged as unsupported.
+func nodeVersions(controlPlane string, nodes []corev1.Node) []output.NodeVersion {
+	cp, cpErr := utilversion.ParseGeneric(controlPlane)
+	result := make([]output.NodeVersion, 0, len(nodes))
+	for _, n := range nodes {
+		nv := output.NodeVersion{
+			Name:           n.Name,
+			KubeletVersion: cleanVersion(n.Status.NodeInfo.KubeletVersion),
+			Supported:      true,
+		}
+		if kv, err := utilversion.ParseGeneric(n.Status.NodeInfo.KubeletVersion); err == nil && cpErr == nil && kv.Major() == cp.Major() {
+			nv.MinorSkew = int(cp.Minor()) - int(kv.Minor())
+			nv.Supported = nv.MinorSkew >= 0 && nv.MinorSkew <= maxKubeletMinorSkew
+		}
+		result = append(result, nv)
+	}
+	slices.SortFunc(result, func(a, b output.NodeVersion) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+// probeVersion queries the server version, trying an unauthenticated GET to
+// /version first and falling back to an authenticated request.
+func probeVersion(ctx context.Context, contextName string, cfg *rest.Config) (ver *version.Info, err error) {
+	ctx, span := tracer.Start(ctx, "cluster.probe", trace.WithAttributes(
+		attribute.String("context", contextName),
+		attribute.String("server", cfg.Host),
+	))
+	defer func() { endSpan(span, err) }()
+
+	// 1) Try unauthenticated GET /version
+	ver, err = getUnauthenticatedVersion(ctx, cfg)
+	if err == nil {
+		return ver, nil
+	}
+	// A timeout or Ctrl+C ends the probe; the authenticated request would fail
+	// the same way, possibly after starting an interactive login.
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("version probe of %s aborted: %w", cfg.Host, ctxErr)
+	}
+
+	// 2) Fallback to authenticated
+	if !hasAuth(cfg) {
+		return nil, fmt.Errorf("no authentication methods found in your kubeconfig. Please authenticate (`kubelogin`, etc.) and try again")
+	}
+	span.AddEvent("falling back to authenticated request")
+	ver, err = getAuthenticatedVersion(ctx, withTracing(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("authenticated version fetch failed: %w", err)
+	}
+	return ver, nil
 }
 
 // hasAuth returns true if the rest.Config contains any credential source.
@@ -192,6 +400,35 @@ func getAuthenticatedVersion(ctx context.Context, cfg *rest.Config) (*version.In
 func getUnauthenticatedVersion(ctx context.Context, cfg *rest.Config) (*version.Info, error) {
 	url := strings.TrimRight(cfg.Host, "/") + "/version"
 
+	client, err := unauthenticatedClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+
+	var v version.Info
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// unauthenticatedClient returns an HTTP client using the CA / TLS settings from
+// cfg, but no credentials.
+func unauthenticatedClient(cfg *rest.Config) (*http.Client, error) {
 	tlsCfg := &tls.Config{}
 	if cfg.Insecure {
 		tlsCfg.InsecureSkipVerify = true // #nosec G402 — user explicitly opted in
@@ -222,33 +459,22 @@ func getUnauthenticatedVersion(ctx context.Context, cfg *rest.Config) (*version.
 	// and HTTP/2 support are preserved; only override TLS configuration.
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.TLSClientConfig = tlsCfg
-	client := &http.Client{Transport: transport}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+	if cfg.Proxy != nil {
+		transport.Proxy = cfg.Proxy
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
-	}
-
-	var v version.Info
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		return nil, err
-	}
-	return &v, nil
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}, nil
 }
 
 func init() {
-	clusterVersionCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "k", clientcmd.RecommendedHomeFile, "Path to kubeconfig file")
-	clusterVersionCmd.Flags().StringVarP(&kubecontext, "context", "c", "", "Kubeconfig context to query (defaults to current context)")
-	clusterVersionCmd.Flags().String("timeout", "10s", "Maximum time to wait for the API server to respond")
+	clusterVersionCmd.Flags().Bool("wide", false, "Also report kubelet versions of all nodes and their skew to the control plane (alias: --detailed)")
+	clusterVersionCmd.Flags().Bool("all-contexts", false, "Report the versions of all managed contexts, reusing versions probed in the last 10 minutes")
+	clusterVersionCmd.Flags().Bool("refresh", false, "With --all-contexts, probe every cluster instead of using cached versions")
+	clusterVersionCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "detailed" {
+			name = "wide"
+		}
+		return pflag.NormalizedName(name)
+	})
 
 	// BindPFlags can theoretically return an error if called with `nil` as an argument
 	// which should never happen after at least one flag was defined. That's why the output
@@ -4,18 +4,27 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
 )
 
 func TestHasAuth(t *testing.T) {
@@ -136,7 +145,153 @@ func TestClusterVersionKubeconfigFlag_DefaultEqualsRecommendedHomeFile(t *testin
 	// Verify that the --kubeconfig flag default equals clientcmd.RecommendedHomeFile.
 	// resolveKubeconfig detects "user did not explicitly set a path" via viper.IsSet:
 	// when the flag is unset, viper returns the default and IsSet returns false.
-	f := clusterVersionCmd.Flags().Lookup("kubeconfig")
+	f := rootCmd.PersistentFlags().Lookup("kubeconfig")
 	g.Expect(f).ToNot(BeNil())
 	g.Expect(f.DefValue).To(Equal(clientcmd.RecommendedHomeFile))
 }
+
+func TestProbeVersion_StopsOnDeadline(t *testing.T) {
+	g := NewWithT(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// No credentials: without the deadline check this would report a missing
+	// authentication method instead of the timeout.
+	cfg := &rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
+	_, err := probeVersion(ctx, "dark", cfg)
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+}
+
+func TestProbeVersion_StopsOnCancel(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := &rest.Config{Host: "https://127.0.0.1:1", BearerToken: "t"}
+	_, err := probeVersion(ctx, "dark", cfg)
+	g.Expect(err).To(MatchError(context.Canceled))
+}
+
+func TestRunClusterVersion_RejectsInvalidTimeout(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { viper.Set("timeout", nil) })
+
+	viper.Set("timeout", "-1s")
+	g.Expect(runClusterVersion(clusterVersionCmd, nil)).To(MatchError(ContainSubstring("must not be negative")))
+
+	viper.Set("timeout", "soon")
+	g.Expect(runClusterVersion(clusterVersionCmd, nil)).To(MatchError(ContainSubstring("invalid --timeout")))
+}
+
+func TestNodeVersions_Skew(t *testing.T) {
+	g := NewWithT(t)
+
+	node := func(name, kubelet string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubelet}},
+		}
+	}
+	got := nodeVersions("1.30.2", []corev1.Node{
+		node("c", "v1.26.9"),
+		node("a", "v1.30.2"),
+		node("d", "v1.31.0"),
+		node("b", "v1.29.5-gke.100"),
+	})
+	g.Expect(got).To(Equal([]output.NodeVersion{
+		{Name: "a", KubeletVersion: "1.30.2", MinorSkew: 0, Supported: true},
+		{Name: "b", KubeletVersion: "1.29.5", MinorSkew: 1, Supported: true},
+		{Name: "c", KubeletVersion: "1.26.9", MinorSkew: 4, Supported: false},
+		{Name: "d", KubeletVersion: "1.31.0", MinorSkew: -1, Supported: false},
+	}))
+}
+
+func TestClusterVersion_DetailedAliasesWide(t *testing.T) {
+	g := NewWithT(t)
+
+	f := clusterVersionCmd.Flags().Lookup("detailed")
+	g.Expect(f).ToNot(BeNil())
+	g.Expect(f.Name).To(Equal("wide"))
+}
+
+func TestRunClusterVersion_AllContexts(t *testing.T) {
+	g := NewWithT(t)
+	useVersionCacheDir(t)
+
+	var requests atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_ = json.NewEncoder(w).Encode(&version.Info{GitVersion: "v1.30.2"})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	for _, n := range []string{"cloudctl:eu-1", "cloudctl:us-1", "local"} {
+		cfg.Clusters[n] = &clientcmdapi.Cluster{Server: srv.URL, InsecureSkipTLSVerify: true}
+		cfg.Contexts[n] = &clientcmdapi.Context{Cluster: n}
+	}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	viper.Set("kubeconfig", path)
+	viper.Set("output", "json")
+	viper.Set("all-contexts", true)
+	t.Cleanup(func() {
+		viper.Set("kubeconfig", nil)
+		viper.Set("output", nil)
+		viper.Set("all-contexts", nil)
+		viper.Set("refresh", nil)
+	})
+
+	run := func() output.ClusterVersionsResult {
+		var buf bytes.Buffer
+		clusterVersionCmd.SetOut(&buf)
+		clusterVersionCmd.SetContext(context.Background())
+		t.Cleanup(func() { clusterVersionCmd.SetOut(nil) })
+		g.Expect(runClusterVersion(clusterVersionCmd, nil)).To(Succeed())
+		var result output.ClusterVersionsResult
+		g.Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+		return result
+	}
+
+	g.Expect(run().Clusters).To(Equal([]output.ClusterVersionEntry{
+		{Context: "cloudctl:eu-1", Version: "1.30.2"},
+		{Context: "cloudctl:us-1", Version: "1.30.2"},
+	}))
+	g.Expect(requests.Load()).To(BeEquivalentTo(2))
+
+	g.Expect(run().Clusters).To(HaveEach(HaveField("Cached", BeTrue())))
+	g.Expect(requests.Load()).To(BeEquivalentTo(2), "cached versions are not probed again")
+
+	viper.Set("refresh", true)
+	g.Expect(run().Clusters).To(HaveEach(HaveField("Cached", BeFalse())))
+	g.Expect(requests.Load()).To(BeEquivalentTo(4))
+}
+
+func TestRunClusterVersion_AllContextsRejectsContextAndWide(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("all-contexts", true)
+	t.Cleanup(func() {
+		viper.Set("all-contexts", nil)
+		viper.Set("context", nil)
+		viper.Set("wide", nil)
+	})
+
+	viper.Set("context", "prod-eu")
+	g.Expect(runClusterVersion(clusterVersionCmd, nil)).To(MatchError(ContainSubstring("cannot be combined with --context")))
+
+	viper.Set("context", "")
+	viper.Set("wide", true)
+	g.Expect(runClusterVersion(clusterVersionCmd, nil)).To(MatchError(ContainSubstring("cannot be combined with --wide")))
+}
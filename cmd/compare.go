@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <fileA> <fileB>",
+	Short: "Semantically diff two kubeconfig files",
+	Long: `Compares the clusters, contexts and users of two kubeconfig files and prints
+the entries that were added, removed or modified going from fileA to fileB.
+
+The comparison is semantic: entry order, the current context and volatile
+credentials (static tokens, cached OIDC id/refresh tokens) are ignored, CA
+data is compared by fingerprint and secrets in exec arguments are redacted.
+Every entry is compared, whether managed by cloudctl or not. The command exits
+with an error when the files differ, so it can validate migrations in scripts.
+
+Examples:
+  # What did the last sync change?
+  cloudctl compare ~/.kube/config.bak ~/.kube/config
+
+  # Machine-readable diff
+  cloudctl compare old.yaml new.yaml -o json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCompare,
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	a, err := clientcmd.LoadFromFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", args[0], err)
+	}
+	b, err := clientcmd.LoadFromFile(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", args[1], err)
+	}
+
+	diff := diffAllEntries(a, b)
+	result := output.CompareResult{
+		FileA:     args[0],
+		FileB:     args[1],
+		Clusters:  toOutputDiffEntries(diff.Clusters),
+		Contexts:  toOutputDiffEntries(diff.Contexts),
+		AuthInfos: toOutputDiffEntries(diff.AuthInfos),
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if n := result.Changes(); n > 0 {
+		return fmt.Errorf("kubeconfigs differ in %d entry(ies)", n)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func compareFixture() *clientcmdapi.Config {
+	cfg := newManagedCfg("eu")
+	cfg.AuthInfos["cloudctl:eu"] = &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name:   "oidc",
+		Config: map[string]string{"client-id": "cloudctl", "id-token": "a"},
+	}}
+	cfg.AuthInfos["local"].Token = "first"
+	cfg.CurrentContext = "local"
+	return cfg
+}
+
+func TestRunCompare_IgnoresVolatileFields(t *testing.T) {
+	g := NewWithT(t)
+	a := compareFixture()
+	b := compareFixture()
+	b.AuthInfos["cloudctl:eu"].AuthProvider.Config["id-token"] = "b"
+	b.AuthInfos["local"].Token = "second"
+	b.CurrentContext = "eu"
+
+	var out bytes.Buffer
+	compareCmd.SetOut(&out)
+	t.Cleanup(func() { compareCmd.SetOut(nil) })
+
+	err := runCompare(compareCmd, []string{writeTestKubeconfig(t, a), writeTestKubeconfig(t, b)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(ContainSubstring("are equivalent"))
+}
+
+func TestRunCompare_ReportsUnmanagedChanges(t *testing.T) {
+	g := NewWithT(t)
+	a := compareFixture()
+	b := compareFixture()
+	b.Clusters["local"].Server = "https://127.0.0.1:7443"
+	b.Contexts["local"].Namespace = "kube-system"
+	delete(b.Clusters, "cloudctl:eu")
+	b.AuthInfos["new"] = &clientcmdapi.AuthInfo{Token: "x"}
+
+	var out bytes.Buffer
+	compareCmd.SetOut(&out)
+	t.Cleanup(func() { compareCmd.SetOut(nil) })
+
+	err := runCompare(compareCmd, []string{writeTestKubeconfig(t, a), writeTestKubeconfig(t, b)})
+	g.Expect(err).To(MatchError("kubeconfigs differ in 4 entry(ies)"))
+	g.Expect(out.String()).To(ContainSubstring("- cloudctl:eu\n"))
+	g.Expect(out.String()).To(ContainSubstring("~ local\n  - server:       https://local.example\n  + server:       https://127.0.0.1:7443\n"))
+	g.Expect(out.String()).To(ContainSubstring("  + namespace:    kube-system\n"))
+	g.Expect(out.String()).To(ContainSubstring("users:\n+ new\n"))
+}
+
+func TestRunCompare_MissingFile(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, compareFixture())
+	err := runCompare(compareCmd, []string{path, filepath.Join(t.TempDir(), "missing")})
+	g.Expect(err).To(MatchError(ContainSubstring("failed to load kubeconfig")))
+}
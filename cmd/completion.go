@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// completionTimeout bounds the Greenhouse requests made while completing, so
+// a slow or unreachable API does not hang the shell.
+const completionTimeout = 3 * time.Second
+
+// completionCacheTTL is how long completions fetched from Greenhouse are
+// reused, keeping repeated tab presses snappy.
+const completionCacheTTL = 5 * time.Minute
+
+// completionCacheDir returns the directory completions are cached in. It is
+// a variable so tests can keep the cache out of the home directory.
+var completionCacheDir = func() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "completion"), nil
+}
+
+// cachedCompletion is a cache file of completion candidates.
+type cachedCompletion struct {
+	Fetched    time.Time `json:"fetched"`
+	Candidates []string  `json:"candidates"`
+}
+
+// cachedCompletions returns the candidates cached under key while they are
+// younger than completionCacheTTL, and otherwise those returned by fetch,
+// caching them. Cache failures are ignored; completion must not fail on them.
+func cachedCompletions(key string, fetch func() ([]string, error)) ([]string, error) {
+	var path string
+	if dir, err := completionCacheDir(); err == nil {
+		sum := sha256.Sum256([]byte(key))
+		path = filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+		var cached cachedCompletion
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil &&
+			time.Since(cached.Fetched) < completionCacheTTL {
+			return cached.Candidates, nil
+		}
+	}
+	candidates, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if data, err := json.Marshal(cachedCompletion{Fetched: time.Now(), Candidates: candidates}); err == nil {
+			if os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+				_ = os.WriteFile(path, data, 0o600)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// completionClient connects to Greenhouse like sync does, from the flags
+// parsed so far, with requests limited to completionTimeout. The returned
+// key identifies the connection for caching.
+func completionClient() (greenhouse.Client, string, error) {
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.Timeout = completionTimeout
+	ghClient, err := newGreenhouseClient(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	imp := cfg.Impersonate
+	return ghClient, fmt.Sprintf("%s\x00%s\x00%s\x00%s", cfg.Host, imp.UserName, strings.Join(imp.Groups, ","), imp.UID), nil
+}
+
+// cachedOrgNamespaces returns the organization namespaces whose
+// ClusterKubeconfigs the user can list, cached under the connection key.
+func cachedOrgNamespaces(ctx context.Context, ghClient greenhouse.Client, key string) ([]string, error) {
+	return cachedCompletions("namespaces\x00"+key, func() ([]string, error) {
+		ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+		defer cancel()
+		return discoverOrgNamespaces(ctx, ghClient)
+	})
+}
+
+// completeOrgNamespaces completes --greenhouse-cluster-namespace with the
+// organizations whose ClusterKubeconfigs the user can list.
+func completeOrgNamespaces(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	ghClient, key, err := completionClient()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	namespaces, err := cachedOrgNamespaces(cmd.Context(), ghClient, key)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return namespaces, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemoteClusterNames completes --remote-cluster-name with the
+// ClusterKubeconfigs of --greenhouse-cluster-namespace, or of the only
+// accessible organization when it is not set. Clusters that are not ready
+// are described as such, since sync would skip them.
+func completeRemoteClusterNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	ghClient, key, err := completionClient()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		namespaces, err := cachedOrgNamespaces(cmd.Context(), ghClient, key)
+		if err != nil || len(namespaces) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		namespace = namespaces[0]
+	}
+	candidates, err := cachedCompletions("clusters\x00"+key+"\x00"+namespace, func() ([]string, error) {
+		ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+		defer cancel()
+		items, err := ghClient.ListClusterKubeconfigs(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		ready, notReady := partitionReady(items)
+		var names []string
+		for _, ckc := range ready {
+			names = append(names, ckc.Name)
+		}
+		for _, ckc := range notReady {
+			names = append(names, ckc.Name+"\tnot ready")
+		}
+		slices.Sort(names)
+		return names, nil
+	})
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
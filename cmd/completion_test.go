@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// useTempCompletionCache points the completion cache at a temporary directory.
+func useTempCompletionCache(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	prev := completionCacheDir
+	completionCacheDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { completionCacheDir = prev })
+}
+
+func TestCachedCompletions(t *testing.T) {
+	g := NewWithT(t)
+	useTempCompletionCache(t)
+	calls := 0
+	fetch := func() ([]string, error) {
+		calls++
+		return []string{"a", "b"}, nil
+	}
+
+	for range 2 {
+		got, err := cachedCompletions("key", fetch)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal([]string{"a", "b"}))
+	}
+	g.Expect(calls).To(Equal(1))
+
+	_, err := cachedCompletions("other", fetch)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(calls).To(Equal(2))
+}
+
+func TestCompleteRemoteClusterNames(t *testing.T) {
+	g := NewWithT(t)
+	useTempCompletionCache(t)
+	path := writeTestKubeconfig(t, newManagedCfg())
+	viper.Set("greenhouse-cluster-kubeconfig", path)
+	viper.Set("greenhouse-cluster-context", "local")
+	t.Cleanup(func() {
+		for _, k := range []string{"greenhouse-cluster-namespace", "greenhouse-cluster-kubeconfig", "greenhouse-cluster-context"} {
+			viper.Set(k, nil)
+		}
+	})
+
+	ready := greenhousev1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: "eu"}}
+	ready.Status.Conditions.SetConditions(greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", ""))
+	notReady := greenhousev1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: "ap"}}
+	prevClient := newGreenhouseClient
+	newGreenhouseClient = func(cfg *rest.Config) (greenhouse.Client, error) {
+		g.Expect(cfg.Timeout).To(Equal(completionTimeout))
+		return &stubGreenhouseClient{ckcs: []greenhousev1alpha1.ClusterKubeconfig{ready, notReady}}, nil
+	}
+	t.Cleanup(func() { newGreenhouseClient = prevClient })
+	syncCmd.SetContext(context.Background())
+
+	names, directive := completeRemoteClusterNames(syncCmd, nil, "")
+	g.Expect(names).To(BeEmpty(), "no namespace, nothing to list")
+	g.Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+
+	viper.Set("greenhouse-cluster-namespace", "my-org")
+	names, directive = completeRemoteClusterNames(syncCmd, nil, "")
+	g.Expect(names).To(Equal([]string{"ap\tnot ready", "eu"}))
+	g.Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+}
+
+func TestCompleteOrgNamespaces(t *testing.T) {
+	g := NewWithT(t)
+	useTempCompletionCache(t)
+	path := writeTestKubeconfig(t, newManagedCfg())
+	viper.Set("greenhouse-cluster-kubeconfig", path)
+	viper.Set("greenhouse-cluster-context", "local")
+	t.Cleanup(func() {
+		viper.Set("greenhouse-cluster-kubeconfig", nil)
+		viper.Set("greenhouse-cluster-context", nil)
+	})
+
+	calls := 0
+	prevClient := newGreenhouseClient
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) {
+		calls++
+		return &stubGreenhouseClient{allowed: true, orgs: []greenhousev1alpha1.Organization{
+			{ObjectMeta: metav1.ObjectMeta{Name: "zeta"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "alpha"}},
+		}}, nil
+	}
+	t.Cleanup(func() { newGreenhouseClient = prevClient })
+	syncCmd.SetContext(context.Background())
+
+	namespaces, directive := completeOrgNamespaces(syncCmd, nil, "")
+	g.Expect(namespaces).To(Equal([]string{"alpha", "zeta"}))
+	g.Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+
+	// Without a namespace, clusters are only completed for a single org.
+	names, _ := completeRemoteClusterNames(syncCmd, nil, "")
+	g.Expect(names).To(BeEmpty())
+	g.Expect(calls).To(Equal(2))
+}
@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and modify the cloudctl config file",
+	Long: `Reads and modifies the cloudctl config file, so scripts can configure
+cloudctl without templating YAML. Keys are flag names (e.g. prefix,
+greenhouse-cluster-namespace, auth-type); nested keys are separated by dots.
+
+The file in use (see --config) is modified; when there is none,
+~/.config/cloudctl/cloudctl.yaml (or $XDG_CONFIG_HOME/cloudctl/cloudctl.yaml)
+is created. Comments and unrelated keys are preserved.
+
+Examples:
+  cloudctl config set greenhouse-cluster-namespace my-org
+  cloudctl config set merge-identical-users false
+  cloudctl config get greenhouse-cluster-namespace
+  cloudctl config get -o yaml
+  cloudctl config unset auth-type`,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a key in the config file",
+	Long: `Sets key to value in the config file. The value is converted to the type
+of the flag of the same name: booleans and numbers are stored as such and list
+flags take a comma-separated value.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigSet,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print configuration values",
+	Long: `Without a key, prints every key set in the config file. With a key, prints
+its effective value, which may also come from a CLOUDCTL_* environment
+variable or the flag default.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigGet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a key from the config file",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigUnset,
+}
+
+// configFileUsed returns the config file loaded at startup, if any. It is a
+// variable so tests need not change viper's global config file.
+var configFileUsed = viper.ConfigFileUsed
+
+// structuredConfigKeys are config file keys that have no flag because their
+// values are maps or lists of objects.
+var structuredConfigKeys = []string{"kubelogin-args", "exec-api-versions", "sources"}
+
+// nonConfigFlags are flags that make no sense in the config file.
+var nonConfigFlags = []string{"config", "help", "version", "format"}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, raw := args[0], args[1]
+	flag, err := lookupConfigKey(key)
+	if err != nil {
+		return err
+	}
+	value, err := convertConfigValue(flag, raw)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	path, err := configFileTarget()
+	if err != nil {
+		return err
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return err
+	}
+	if err := setConfigKey(doc, key, value); err != nil {
+		return err
+	}
+	if err := writeConfigDocument(path, doc); err != nil {
+		return err
+	}
+	return printConfigResult(cmd, output.ConfigUpdateResult{File: path, Key: key, Value: value, Action: "set"})
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	path, err := configFileTarget()
+	if err != nil {
+		return err
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return err
+	}
+	if !unsetConfigKey(doc, key) {
+		return fmt.Errorf("%s is not set in %s", key, path)
+	}
+	if err := writeConfigDocument(path, doc); err != nil {
+		return err
+	}
+	return printConfigResult(cmd, output.ConfigUpdateResult{File: path, Key: key, Action: "unset"})
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		if _, err := lookupConfigKey(args[0]); err != nil {
+			return err
+		}
+		return printConfigResult(cmd, output.ConfigResult{File: configFileUsed(), Key: args[0], Value: viper.Get(args[0])})
+	}
+
+	path := configFileUsed()
+	result := output.ConfigResult{File: path}
+	if path == "" {
+		return printConfigResult(cmd, result)
+	}
+	doc, err := loadConfigDocument(path)
+	if err != nil {
+		return err
+	}
+	if err := doc.Decode(&result.Settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return printConfigResult(cmd, result)
+}
+
+func printConfigResult(cmd *cobra.Command, v any) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	return output.New(format, output.IsTTYWriter(w), w).Print(v)
+}
+
+// configFlags returns every flag of the command tree that can be set in the
+// config file, keyed by name. Flags defined by several commands are returned
+// once.
+func configFlags() map[string]*pflag.Flag {
+	flags := map[string]*pflag.Flag{}
+	add := func(f *pflag.Flag) {
+		if _, ok := flags[f.Name]; !ok && !slices.Contains(nonConfigFlags, f.Name) {
+			flags[f.Name] = f
+		}
+	}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		c.PersistentFlags().VisitAll(add)
+		c.LocalNonPersistentFlags().VisitAll(add)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	return flags
+}
+
+// lookupConfigKey validates key and returns the flag it configures, or nil
+// for keys below a structured key.
+func lookupConfigKey(key string) (*pflag.Flag, error) {
+	top, _, nested := strings.Cut(key, ".")
+	if slices.Contains(structuredConfigKeys, top) {
+		return nil, nil
+	}
+	f, ok := configFlags()[top]
+	if !ok || nested {
+		return nil, fmt.Errorf("unknown configuration key %q", key)
+	}
+	return f, nil
+}
+
+// convertConfigValue converts raw to the type of flag so the config file
+// holds booleans, numbers and lists rather than strings.
+func convertConfigValue(flag *pflag.Flag, raw string) (any, error) {
+	if flag == nil {
+		return raw, nil
+	}
+	switch flag.Value.Type() {
+	case "bool":
+		return strconv.ParseBool(raw)
+	case "int":
+		return strconv.Atoi(raw)
+	case "duration":
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	case "stringSlice", "stringArray":
+		if raw == "" {
+			return []string{}, nil
+		}
+		return strings.Split(raw, ","), nil
+	default:
+		return raw, nil
+	}
+}
+
+// configFileTarget returns the config file that config set/unset modify: the
+// one in use, or the default location when there is none.
+func configFileTarget() (string, error) {
+	if path := configFileUsed(); path != "" {
+		return path, nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cloudctl.yaml"), nil
+}
+
+// loadConfigDocument reads the config file at path as a YAML mapping node.
+// A missing or empty file yields an empty mapping.
+func loadConfigDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(file.Content) == 0 {
+		return doc, nil
+	}
+	if root := file.Content[0]; root.Kind == yaml.MappingNode {
+		return root, nil
+	}
+	return nil, fmt.Errorf("%s does not contain a YAML mapping", path)
+}
+
+// writeConfigDocument writes doc to path, creating parent directories.
+func writeConfigDocument(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node of key in the mapping node m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setConfigKey sets the dot-separated key in doc to value, creating
+// intermediate mappings as needed.
+func setConfigKey(doc *yaml.Node, key string, value any) error {
+	parts := strings.Split(key, ".")
+	m := doc
+	for _, part := range parts[:len(parts)-1] {
+		next := mappingValue(m, part)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, next)
+		}
+		if next.Kind != yaml.MappingNode {
+			return fmt.Errorf("cannot set %s: %s is not a mapping", key, part)
+		}
+		m = next
+	}
+	var v yaml.Node
+	if err := v.Encode(value); err != nil {
+		return err
+	}
+	last := parts[len(parts)-1]
+	if existing := mappingValue(m, last); existing != nil {
+		// Keep comments attached to the old value.
+		v.HeadComment, v.LineComment, v.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+		*existing = v
+		return nil
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, &v)
+	return nil
+}
+
+// unsetConfigKey removes the dot-separated key from doc and reports whether
+// it was present.
+func unsetConfigKey(doc *yaml.Node, key string) bool {
+	parts := strings.Split(key, ".")
+	m := doc
+	for _, part := range parts[:len(parts)-1] {
+		if m = mappingValue(m, part); m == nil || m.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	last := parts[len(parts)-1]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == last {
+			m.Content = slices.Delete(m.Content, i, i+2)
+			return true
+		}
+	}
+	return false
+}
+
+// completeConfigKeys completes the first argument with known config keys.
+func completeConfigKeys(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := slices.Sorted(maps.Keys(configFlags()))
+	return append(keys, structuredConfigKeys...), cobra.ShellCompDirectiveNoFileComp
+}
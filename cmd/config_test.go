@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+// useConfigFile makes a config file with the given content in a temp dir the
+// one in use and returns its path.
+func useConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cloudctl.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prev := configFileUsed
+	configFileUsed = func() string { return path }
+	viper.Set("output", "text")
+	t.Cleanup(func() {
+		configFileUsed = prev
+		viper.Set("output", nil)
+	})
+	return path
+}
+
+func TestRunConfigSet_PreservesCommentsAndConvertsTypes(t *testing.T) {
+	g := NewWithT(t)
+	path := useConfigFile(t, "# team defaults\nprefix: old # shared prefix\nlog-level: info\n")
+
+	var out bytes.Buffer
+	configSetCmd.SetOut(&out)
+	t.Cleanup(func() { configSetCmd.SetOut(nil) })
+
+	g.Expect(runConfigSet(configSetCmd, []string{"prefix", "myorg"})).To(Succeed())
+	g.Expect(runConfigSet(configSetCmd, []string{"merge-identical-users", "false"})).To(Succeed())
+	g.Expect(runConfigSet(configSetCmd, []string{"kubelogin-extra-args", "--a,--b"})).To(Succeed())
+	g.Expect(runConfigSet(configSetCmd, []string{"kubelogin-args.clusters.prod-*", "x"})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Set prefix to myorg in " + path))
+
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`# team defaults
+prefix: myorg # shared prefix
+log-level: info
+merge-identical-users: false
+kubelogin-extra-args:
+  - --a
+  - --b
+kubelogin-args:
+  clusters:
+    prod-*: x
+`))
+}
+
+func TestRunConfigSet_RejectsInvalidInput(t *testing.T) {
+	g := NewWithT(t)
+	useConfigFile(t, "")
+
+	g.Expect(runConfigSet(configSetCmd, []string{"prefx", "x"})).To(MatchError(`unknown configuration key "prefx"`))
+	g.Expect(runConfigSet(configSetCmd, []string{"prefix.nested", "x"})).To(MatchError(`unknown configuration key "prefix.nested"`))
+	g.Expect(runConfigSet(configSetCmd, []string{"watch", "maybe"})).To(MatchError(ContainSubstring("invalid value for watch")))
+	g.Expect(runConfigSet(configSetCmd, []string{"interval", "5"})).To(MatchError(ContainSubstring("invalid value for interval")))
+}
+
+func TestRunConfigSet_CreatesDefaultFile(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	prev := configFileUsed
+	configFileUsed = func() string { return "" }
+	viper.Set("output", "text")
+	t.Cleanup(func() {
+		configFileUsed = prev
+		viper.Set("output", nil)
+	})
+
+	var out bytes.Buffer
+	configSetCmd.SetOut(&out)
+	t.Cleanup(func() { configSetCmd.SetOut(nil) })
+
+	path, err := configFileTarget()
+	g.Expect(err).NotTo(HaveOccurred())
+	if runtime.GOOS != "windows" {
+		g.Expect(path).To(Equal(filepath.Join(dir, "cloudctl", "cloudctl.yaml")))
+	}
+	t.Cleanup(func() { _ = os.Remove(path) })
+	g.Expect(runConfigSet(configSetCmd, []string{"greenhouse-cluster-namespace", "my-org"})).To(Succeed())
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal("greenhouse-cluster-namespace: my-org\n"))
+}
+
+func TestRunConfigUnsetAndGet(t *testing.T) {
+	g := NewWithT(t)
+	path := useConfigFile(t, "prefix: myorg\nlog-level: debug\nkubelogin-extra-args: [--a, --b]\n")
+
+	var out bytes.Buffer
+	configGetCmd.SetOut(&out)
+	configUnsetCmd.SetOut(&out)
+	t.Cleanup(func() {
+		configGetCmd.SetOut(nil)
+		configUnsetCmd.SetOut(nil)
+	})
+
+	g.Expect(runConfigGet(configGetCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(Equal("# " + path + "\nkubelogin-extra-args: --a,--b\nlog-level: debug\nprefix: myorg\n"))
+
+	out.Reset()
+	viper.Set("prefix", "myorg")
+	t.Cleanup(func() { viper.Set("prefix", nil) })
+	g.Expect(runConfigGet(configGetCmd, []string{"prefix"})).To(Succeed())
+	g.Expect(out.String()).To(Equal("myorg\n"))
+
+	g.Expect(runConfigUnset(configUnsetCmd, []string{"log-level"})).To(Succeed())
+	g.Expect(runConfigUnset(configUnsetCmd, []string{"log-level"})).To(MatchError(ContainSubstring("log-level is not set")))
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).NotTo(ContainSubstring("log-level"))
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// customColumnsPrefix starts an --output value that selects custom columns,
+// as in kubectl: -o custom-columns=NAME:.metadata.name,REGION:.metadata.labels.region
+const customColumnsPrefix = "custom-columns="
+
+// customColumn is one column of -o custom-columns: a header and the JSONPath
+// that selects its cells.
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// parseOutputFlag splits an --output value into the format to print with and,
+// for custom-columns, the columns to print. Custom columns are a text table.
+func parseOutputFlag(s string) (output.Format, []customColumn, error) {
+	spec, ok := strings.CutPrefix(s, customColumnsPrefix)
+	if !ok {
+		format, err := output.ParseFormat(s)
+		return format, nil, err
+	}
+	columns, err := parseCustomColumns(spec)
+	return output.FormatText, columns, err
+}
+
+// parseCustomColumns parses a comma-separated list of HEADER:JSONPATH pairs.
+// Paths may be written with or without braces and the leading dot.
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns needs at least one HEADER:JSONPATH column")
+	}
+	var columns []customColumn
+	for _, part := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(part, ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("invalid custom column %q: want HEADER:JSONPATH", part)
+		}
+		if !strings.HasPrefix(path, "{") {
+			if !strings.HasPrefix(path, ".") {
+				path = "." + path
+			}
+			path = "{" + path + "}"
+		}
+		jp := jsonpath.New(header).AllowMissingKeys(true)
+		if err := jp.Parse(path); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath in custom column %q: %w", part, err)
+		}
+		columns = append(columns, customColumn{header: header, path: jp})
+	}
+	return columns, nil
+}
+
+// customColumnsResult evaluates columns against each of objs. Cells with no
+// value are shown as <none>, like kubectl does.
+func customColumnsResult(columns []customColumn, objs []runtime.Object) (output.CustomColumnsResult, error) {
+	result := output.CustomColumnsResult{Columns: make([]string, len(columns)), Rows: [][]string{}}
+	for i, c := range columns {
+		result.Columns[i] = c.header
+	}
+	for _, obj := range objs {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return result, err
+		}
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			values, err := c.path.FindResults(content)
+			if err != nil {
+				return result, fmt.Errorf("failed to evaluate custom column %s: %w", c.header, err)
+			}
+			var cells []string
+			for _, vs := range values {
+				for _, v := range vs {
+					cells = append(cells, fmt.Sprint(v.Interface()))
+				}
+			}
+			row[i] = "<none>"
+			if len(cells) > 0 {
+				row[i] = strings.Join(cells, ",")
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Clean up manually added entries that duplicate managed clusters",
+	Long: `Finds unmanaged kubeconfig clusters that point at the same server as a
+cluster managed by cloudctl, typically left over from adding clusters by hand
+before using sync. Each duplicate can be
+
+  removed   delete the cluster and the contexts using it
+  adopted   keep the contexts (name and namespace) but point them at the
+            managed cluster and its credentials, then delete the cluster
+
+Users that are no longer referenced by any context are deleted as well. On a
+terminal cloudctl asks what to do with each duplicate; otherwise pass --remove
+or --adopt, or the duplicates are only reported.
+
+Examples:
+  # Decide per duplicate
+  cloudctl dedupe
+
+  # Preview adopting all duplicates
+  cloudctl dedupe --adopt --dry-run`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runDedupe,
+}
+
+func init() {
+	dedupeCmd.Flags().Bool("remove", false, "Remove all duplicates without asking")
+	dedupeCmd.Flags().Bool("adopt", false, "Adopt all duplicates without asking")
+	dedupeCmd.Flags().Bool("dry-run", false, "Show what would change without writing the kubeconfig")
+	dedupeCmd.MarkFlagsMutuallyExclusive("remove", "adopt")
+}
+
+// dedupePrompt asks how to resolve one duplicate. It is a variable so tests
+// can answer without a terminal.
+var dedupePrompt = func(d output.DuplicateEntry) (output.DedupeAction, error) {
+	fmt.Fprintf(os.Stderr, "%s (%s) duplicates managed cluster %s", d.Cluster, d.Server, d.ManagedCluster)
+	if len(d.Contexts) > 0 {
+		fmt.Fprintf(os.Stderr, ", used by %s", strings.Join(d.Contexts, ", "))
+	}
+	fmt.Fprint(os.Stderr, ".\n[r]emove, [a]dopt, [k]eep? ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "r", "remove":
+		return output.DedupeActionRemove, nil
+	case "a", "adopt":
+		return output.DedupeActionAdopt, nil
+	default:
+		return output.DedupeActionKeep, nil
+	}
+}
+
+func runDedupe(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	dryRun := viper.GetBool("dry-run")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	po := kubeconfigPathOptions(path)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	before := cfg.DeepCopy()
+
+	result := output.DedupeResult{Kubeconfig: displayKubeconfig(path), DryRun: dryRun, Duplicates: findDuplicates(cfg)}
+	for i := range result.Duplicates {
+		d := &result.Duplicates[i]
+		switch {
+		case viper.GetBool("remove"):
+			d.Action = output.DedupeActionRemove
+		case viper.GetBool("adopt"):
+			d.Action = output.DedupeActionAdopt
+		case canPrompt() && format == output.FormatText:
+			if d.Action, err = dedupePrompt(*d); err != nil {
+				return err
+			}
+		default:
+			d.Action = output.DedupeActionKeep
+		}
+		switch d.Action {
+		case output.DedupeActionRemove:
+			removeDuplicate(cfg, *d)
+		case output.DedupeActionAdopt:
+			adoptDuplicate(cfg, *d)
+		}
+	}
+
+	if !dryRun {
+		diff := diffAllEntries(before, cfg)
+		if len(diff.Clusters)+len(diff.Contexts)+len(diff.AuthInfos) > 0 || before.CurrentContext != cfg.CurrentContext {
+			if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+				return fmt.Errorf("failed to update kubeconfig: %w", err)
+			}
+			slog.Info("resolved duplicate kubeconfig entries", "kubeconfig", displayKubeconfig(path))
+			if err := auditMutations("dedupe", path, diff); err != nil {
+				return err
+			}
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
+
+// findDuplicates returns the unmanaged clusters whose server is also used by a
+// managed cluster, sorted by name.
+func findDuplicates(cfg *clientcmdapi.Config) []output.DuplicateEntry {
+	managed := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Clusters)) {
+		if c := cfg.Clusters[name]; c != nil && c.Server != "" && isManaged(name) {
+			server := strings.TrimRight(c.Server, "/")
+			if _, ok := managed[server]; !ok {
+				managed[server] = name
+			}
+		}
+	}
+	var dups []output.DuplicateEntry
+	for _, name := range slices.Sorted(maps.Keys(cfg.Clusters)) {
+		c := cfg.Clusters[name]
+		if c == nil || isManaged(name) {
+			continue
+		}
+		managedName, ok := managed[strings.TrimRight(c.Server, "/")]
+		if !ok {
+			continue
+		}
+		d := output.DuplicateEntry{Cluster: name, Server: c.Server, ManagedCluster: managedName}
+		for _, ctxName := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+			if ctx := cfg.Contexts[ctxName]; ctx != nil && ctx.Cluster == name {
+				d.Contexts = append(d.Contexts, ctxName)
+			}
+		}
+		dups = append(dups, d)
+	}
+	return dups
+}
+
+// managedContextFor returns the first context (by name) that uses the managed
+// cluster, or "" if there is none. Sync does not prefix context names, so the
+// cluster alone marks a context as managed.
+func managedContextFor(cfg *clientcmdapi.Config, cluster string) string {
+	for _, name := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+		if ctx := cfg.Contexts[name]; hasManagedCluster(ctx) && ctx.Cluster == cluster {
+			return name
+		}
+	}
+	return ""
+}
+
+// removeDuplicate deletes the duplicate cluster and its contexts. A current
+// context among them is switched to the managed context of the same server.
+func removeDuplicate(cfg *clientcmdapi.Config, d output.DuplicateEntry) {
+	var users []string
+	for _, name := range d.Contexts {
+		users = append(users, cfg.Contexts[name].AuthInfo)
+		delete(cfg.Contexts, name)
+		if cfg.CurrentContext == name {
+			cfg.CurrentContext = managedContextFor(cfg, d.ManagedCluster)
+		}
+	}
+	delete(cfg.Clusters, d.Cluster)
+	pruneUsers(cfg, users)
+}
+
+// adoptDuplicate points the contexts of the duplicate cluster at the managed
+// cluster and its user, then deletes the duplicate cluster.
+func adoptDuplicate(cfg *clientcmdapi.Config, d output.DuplicateEntry) {
+	var user string
+	if name := managedContextFor(cfg, d.ManagedCluster); name != "" {
+		user = cfg.Contexts[name].AuthInfo
+	}
+	var users []string
+	for _, name := range d.Contexts {
+		ctx := cfg.Contexts[name]
+		ctx.Cluster = d.ManagedCluster
+		if user != "" && ctx.AuthInfo != user {
+			users = append(users, ctx.AuthInfo)
+			ctx.AuthInfo = user
+		}
+	}
+	delete(cfg.Clusters, d.Cluster)
+	pruneUsers(cfg, users)
+}
+
+// pruneUsers deletes the unmanaged users among names that no context refers
+// to any more.
+func pruneUsers(cfg *clientcmdapi.Config, names []string) {
+	for _, name := range names {
+		if name == "" || isManaged(name) {
+			continue
+		}
+		if !slices.ContainsFunc(slices.Collect(maps.Values(cfg.Contexts)), func(c *clientcmdapi.Context) bool {
+			return c != nil && c.AuthInfo == name
+		}) {
+			delete(cfg.AuthInfos, name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func dedupeFixture() *clientcmdapi.Config {
+	cfg := newManagedCfg("eu")
+	cfg.Clusters["eu-manual"] = &clientcmdapi.Cluster{Server: "https://eu.example/"}
+	cfg.AuthInfos["eu-admin"] = &clientcmdapi.AuthInfo{Token: "manual"}
+	cfg.Contexts["eu-manual"] = &clientcmdapi.Context{Cluster: "eu-manual", AuthInfo: "eu-admin", Namespace: "monitoring"}
+	cfg.CurrentContext = "eu-manual"
+	return cfg
+}
+
+func TestFindDuplicates(t *testing.T) {
+	g := NewWithT(t)
+	prev := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = prev })
+
+	g.Expect(findDuplicates(dedupeFixture())).To(Equal([]output.DuplicateEntry{{
+		Cluster:        "eu-manual",
+		Server:         "https://eu.example/",
+		ManagedCluster: "cloudctl:eu",
+		Contexts:       []string{"eu-manual"},
+	}}))
+}
+
+func TestRemoveDuplicate(t *testing.T) {
+	g := NewWithT(t)
+	prev := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = prev })
+
+	cfg := dedupeFixture()
+	removeDuplicate(cfg, findDuplicates(cfg)[0])
+	g.Expect(cfg.Clusters).To(HaveLen(2))
+	g.Expect(cfg.Contexts).To(HaveLen(2))
+	g.Expect(cfg.AuthInfos).NotTo(HaveKey("eu-admin"))
+	g.Expect(cfg.CurrentContext).To(Equal("eu"))
+}
+
+func TestAdoptDuplicate(t *testing.T) {
+	g := NewWithT(t)
+	prev := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = prev })
+
+	cfg := dedupeFixture()
+	adoptDuplicate(cfg, findDuplicates(cfg)[0])
+	g.Expect(cfg.Clusters).NotTo(HaveKey("eu-manual"))
+	g.Expect(cfg.AuthInfos).NotTo(HaveKey("eu-admin"))
+	g.Expect(cfg.Contexts["eu-manual"]).To(And(
+		HaveField("Cluster", "cloudctl:eu"),
+		HaveField("AuthInfo", "cloudctl:eu"),
+		HaveField("Namespace", "monitoring"),
+	))
+	g.Expect(cfg.CurrentContext).To(Equal("eu-manual"))
+}
+
+func TestRunDedupe(t *testing.T) {
+	g := NewWithT(t)
+	prevPrompt := canPrompt
+	canPrompt = func() bool { return false }
+	t.Cleanup(func() { canPrompt = prevPrompt })
+
+	path := writeTestKubeconfig(t, dedupeFixture())
+
+	var out bytes.Buffer
+	dedupeCmd.SetOut(&out)
+	t.Cleanup(func() { dedupeCmd.SetOut(nil) })
+
+	// Without a terminal or an action flag duplicates are only reported.
+	g.Expect(runDedupe(dedupeCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("kept  eu-manual  https://eu.example/ -> cloudctl:eu"))
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Clusters).To(HaveKey("eu-manual"))
+
+	viper.Set("remove", true)
+	t.Cleanup(func() { viper.Set("remove", nil) })
+	viper.Set("dry-run", true)
+	out.Reset()
+	g.Expect(runDedupe(dedupeCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("would remove  eu-manual"))
+	cfg, err = clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Clusters).To(HaveKey("eu-manual"))
+
+	viper.Set("dry-run", nil)
+	out.Reset()
+	g.Expect(runDedupe(dedupeCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("removed  eu-manual"))
+	cfg, err = clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Clusters).NotTo(HaveKey("eu-manual"))
+	g.Expect(cfg.Contexts).NotTo(HaveKey("eu-manual"))
+	g.Expect(cfg.CurrentContext).To(Equal("eu"))
+}
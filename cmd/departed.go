@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// pruneDepartedPrompt asks whether the managed entries of an organization the
+// user can no longer access should be removed. It is a variable so tests can
+// answer without a terminal.
+var pruneDepartedPrompt = func(rec sourceState, contexts int) (bool, error) {
+	fmt.Fprintf(os.Stderr, "You no longer have access to organization %s on %s (last synced %s).\nRemove its %d managed context(s) with prefix %q? [y/N] ",
+		rec.Namespace, rec.Endpoint, rec.LastSync.Local().Format("2006-01-02"), contexts, rec.Prefix)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// isDepartedError reports whether err means the user can no longer read an
+// organization's ClusterKubeconfigs: a failed RBAC preflight, Forbidden, or a
+// namespace that no longer exists.
+func isDepartedError(err error) bool {
+	return errors.As(err, new(*PermissionError)) || apierrors.IsForbidden(err) || apierrors.IsNotFound(err)
+}
+
+// departedSources returns the recorded syncs into the kubeconfigs the
+// sources write to from organizations that are no longer configured for that
+// kubeconfig and that the client of the same Greenhouse endpoint can no
+// longer read. Records whose prefix is used by a source writing to the same
+// kubeconfig are skipped; syncing that source prunes them.
+func departedSources(ctx context.Context, sources []syncSource, clients []greenhouse.Client) []sourceState {
+	if remoteClusterName != "" {
+		return nil
+	}
+	st, err := loadSyncState()
+	if err != nil {
+		slog.Warn("ignoring sync state", "error", err)
+		return nil
+	}
+	targets := make([]string, len(sources))
+	for i, s := range sources {
+		if target, err := resolveWriteTarget(s.Output); err == nil {
+			targets[i] = stateKubeconfigPath(target)
+		}
+	}
+	configured := func(rec sourceState) bool {
+		for i, s := range sources {
+			if targets[i] == rec.Kubeconfig && (s.Namespace == rec.Namespace || s.Prefix == rec.Prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	var departed []sourceState
+	for _, target := range slices.Compact(slices.Sorted(slices.Values(targets))) {
+		if target == "" {
+			continue
+		}
+		for _, rec := range st.forKubeconfig(target) {
+			i := slices.IndexFunc(sources, func(s syncSource) bool { return s.endpoint == rec.Endpoint })
+			if i < 0 || configured(rec) {
+				continue
+			}
+			if _, err := clients[i].ListClusterKubeconfigs(ctx, rec.Namespace); isDepartedError(err) {
+				slog.Debug("organization is no longer accessible", "namespace", rec.Namespace, "kubeconfig", rec.Kubeconfig, "error", err)
+				departed = append(departed, rec)
+			}
+		}
+	}
+	return departed
+}
+
+// pruneDeparted offers to remove the managed entries written by each of
+// the departed syncs. With --prune-departed they are removed without asking;
+// without a terminal they are kept and a hint is logged.
+func pruneDeparted(ctx context.Context, departed []sourceState) error {
+	for _, rec := range departed {
+		if err := pruneDepartedSource(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneDepartedSource removes the entries of rec from the kubeconfig it was
+// synced into.
+func pruneDepartedSource(ctx context.Context, rec sourceState) error {
+	localConfig, err := loadLocalKubeconfig(ctx, rec.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	before := localConfig.DeepCopy()
+
+	// Merging nothing under the departed prefix removes all of its entries.
+	savedPrefix := prefix
+	prefix = rec.Prefix
+	err = mergeKubeconfig(localConfig, clientcmdapi.NewConfig())
+	diff := diffKubeconfig(before, localConfig)
+	prefix = savedPrefix
+	if err != nil {
+		return err
+	}
+
+	if len(diff.Clusters)+len(diff.Contexts)+len(diff.AuthInfos) > 0 {
+		switch {
+		case viper.GetBool("prune-departed"):
+		case canPrompt():
+			ok, err := pruneDepartedPrompt(rec, len(diff.Contexts))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		default:
+			slog.Warn("organization is no longer accessible, keeping its managed entries; run sync with --prune-departed to remove them",
+				"namespace", rec.Namespace, "prefix", rec.Prefix, "contexts", len(diff.Contexts))
+			return nil
+		}
+		if err := writeConfig(localConfig, rec.Kubeconfig); err != nil {
+			return fmt.Errorf("failed to write kubeconfig: %w", err)
+		}
+		slog.Info("removed entries of departed organization", "namespace", rec.Namespace, "prefix", rec.Prefix, "kubeconfig", rec.Kubeconfig, "contexts", len(diff.Contexts))
+		if err := auditMutations("sync", rec.Kubeconfig, diff); err != nil {
+			return err
+		}
+	}
+
+	st, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	st.forget(rec)
+	return st.save()
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// departedGreenhouseClient denies listing ClusterKubeconfigs in forbidden.
+type departedGreenhouseClient struct {
+	stubGreenhouseClient
+	forbidden string
+}
+
+func (c *departedGreenhouseClient) ListClusterKubeconfigs(_ context.Context, namespace string) ([]greenhousev1alpha1.ClusterKubeconfig, error) {
+	if namespace == c.forbidden {
+		return nil, apierrors.NewForbidden(schema.GroupResource{Group: "greenhouse.sap", Resource: "clusterkubeconfigs"}, "", nil)
+	}
+	return nil, nil
+}
+
+// setupDepartedKubeconfig writes a kubeconfig with entries of the prefixes
+// "cloudctl" and "old" and records syncs of org-old and org-live into it.
+func setupDepartedKubeconfig(t *testing.T) string {
+	t.Helper()
+	useTempSyncState(t)
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	for _, p := range []string{"cloudctl", "old"} {
+		cfg.Clusters[p+":eu"] = &clientcmdapi.Cluster{Server: "https://" + p + ".example"}
+		cfg.AuthInfos[p+":auth"] = &clientcmdapi.AuthInfo{Token: "t"}
+		cfg.Contexts[p+"-eu"] = &clientcmdapi.Context{Cluster: p + ":eu", AuthInfo: p + ":auth"}
+	}
+	if err := clientcmd.WriteToFile(*cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	prevKubeconfig, prevPrefix, prevDryRun := remoteClusterKubeconfig, prefix, dryRun
+	remoteClusterKubeconfig, prefix, dryRun = path, "cloudctl", false
+	t.Cleanup(func() { remoteClusterKubeconfig, prefix, dryRun = prevKubeconfig, prevPrefix, prevDryRun })
+
+	st := &syncState{}
+	for _, ns := range []string{"old", "live"} {
+		st.record(sourceState{Kubeconfig: path, Endpoint: "https://gh", Namespace: "org-" + ns, Prefix: ns, LastSync: time.Now()})
+	}
+	if err := st.save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPruneDeparted(t *testing.T) {
+	g := NewWithT(t)
+	path := setupDepartedKubeconfig(t)
+	prevCanPrompt, prevPrompt := canPrompt, pruneDepartedPrompt
+	canPrompt = func() bool { return true }
+	var asked []string
+	pruneDepartedPrompt = func(rec sourceState, contexts int) (bool, error) {
+		asked = append(asked, rec.Namespace)
+		g.Expect(contexts).To(Equal(1))
+		return true, nil
+	}
+	t.Cleanup(func() { canPrompt, pruneDepartedPrompt = prevCanPrompt, prevPrompt })
+
+	sources := []syncSource{{Namespace: "org-a", Prefix: "cloudctl", Output: path, endpoint: "https://gh"}}
+	departed := departedSources(context.Background(), sources, []greenhouse.Client{&departedGreenhouseClient{forbidden: "org-old"}})
+	g.Expect(departed).To(ConsistOf(HaveField("Namespace", "org-old")))
+
+	g.Expect(pruneDeparted(context.Background(), departed)).To(Succeed())
+	g.Expect(asked).To(Equal([]string{"org-old"}))
+
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).To(HaveKey("cloudctl-eu"))
+	g.Expect(cfg.Contexts).NotTo(HaveKey("old-eu"))
+	g.Expect(cfg.Clusters).NotTo(HaveKey("old:eu"))
+	g.Expect(cfg.AuthInfos).NotTo(HaveKey("old:auth"))
+	g.Expect(prefix).To(Equal("cloudctl"))
+
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(st.Syncs).To(ConsistOf(HaveField("Namespace", "org-live")))
+}
+
+func TestPruneDeparted_EverySourceKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	path := setupDepartedKubeconfig(t)
+	other := filepath.Join(t.TempDir(), "other")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["old:us"] = &clientcmdapi.Cluster{Server: "https://us.example"}
+	cfg.Contexts["old-us"] = &clientcmdapi.Context{Cluster: "old:us"}
+	g.Expect(clientcmd.WriteToFile(*cfg, other)).To(Succeed())
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	st.record(sourceState{Kubeconfig: other, Endpoint: "https://gh", Namespace: "org-old", Prefix: "old", LastSync: time.Now()})
+	g.Expect(st.save()).To(Succeed())
+	viper.Set("prune-departed", true)
+	t.Cleanup(func() { viper.Set("prune-departed", nil) })
+
+	// The source writing to path still uses the "old" prefix, so only the
+	// record of the other kubeconfig is departed.
+	sources := []syncSource{
+		{Namespace: "org-a", Prefix: "old", Output: path, endpoint: "https://gh"},
+		{Namespace: "org-b", Prefix: "cloudctl", Output: other, endpoint: "https://gh"},
+	}
+	departed := departedSources(context.Background(), sources, []greenhouse.Client{&departedGreenhouseClient{forbidden: "org-old"}, &departedGreenhouseClient{forbidden: "org-old"}})
+	g.Expect(departed).To(ConsistOf(HaveField("Kubeconfig", other)))
+	g.Expect(pruneDeparted(context.Background(), departed)).To(Succeed())
+
+	cfg, err = clientcmd.LoadFromFile(other)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).NotTo(HaveKey("old-us"))
+	cfg, err = clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).To(HaveKey("old-eu"), "the kubeconfig of the other source is not touched")
+}
+
+func TestPruneDeparted_KeepsEntriesWithoutTerminal(t *testing.T) {
+	g := NewWithT(t)
+	path := setupDepartedKubeconfig(t)
+	prevCanPrompt := canPrompt
+	canPrompt = func() bool { return false }
+	t.Cleanup(func() { canPrompt = prevCanPrompt })
+
+	g.Expect(pruneDeparted(context.Background(), []sourceState{{Kubeconfig: path, Endpoint: "https://gh", Namespace: "org-old", Prefix: "old"}})).To(Succeed())
+
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).To(HaveKey("old-eu"))
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(st.Syncs).To(HaveLen(2), "the record is kept so the user is asked again")
+}
+
+func TestIsDepartedError(t *testing.T) {
+	g := NewWithT(t)
+	gr := schema.GroupResource{Resource: "clusterkubeconfigs"}
+	g.Expect(isDepartedError(apierrors.NewForbidden(gr, "", nil))).To(BeTrue())
+	g.Expect(isDepartedError(apierrors.NewNotFound(gr, "org"))).To(BeTrue())
+	g.Expect(isDepartedError(&PermissionError{})).To(BeTrue())
+	g.Expect(isDepartedError(apierrors.NewInternalError(errors.New("boom")))).To(BeFalse())
+	g.Expect(isDepartedError(nil)).To(BeFalse())
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunDocs(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("SOURCE_DATE_EPOCH", "1767225600")
+	var out bytes.Buffer
+	docsCmd.SetOut(&out)
+	t.Cleanup(func() {
+		docsCmd.SetOut(nil)
+		_ = docsCmd.Flags().Set("format", "man")
+	})
+
+	dir := t.TempDir()
+	g.Expect(runDocs(docsCmd, []string{dir})).To(Succeed())
+	page, err := os.ReadFile(filepath.Join(dir, "cloudctl-sync.1"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(page)).To(ContainSubstring(`.TH "CLOUDCTL" "1" "Jan 2026"`))
+	g.Expect(string(page)).To(ContainSubstring("greenhouse-cluster-namespace"))
+	g.Expect(filepath.Join(dir, "cloudctl-docs.1")).NotTo(BeAnExistingFile())
+
+	g.Expect(docsCmd.Flags().Set("format", "markdown")).To(Succeed())
+	g.Expect(runDocs(docsCmd, []string{dir})).To(Succeed())
+	g.Expect(filepath.Join(dir, "cloudctl_config_set.md")).To(BeAnExistingFile())
+
+	g.Expect(docsCmd.Flags().Set("format", "html")).To(Succeed())
+	g.Expect(runDocs(docsCmd, []string{dir})).To(MatchError(ContainSubstring(`invalid --format "html"`)))
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Exit non-zero when the managed kubeconfig entries differ from Greenhouse",
+	Long: `Checks whether a sync would change the managed entries of the local
+kubeconfig, without writing it. The command prints nothing and reports in its
+exit code, so scheduled jobs can alert on jump hosts whose kubeconfig has gone
+stale:
+
+  0  the managed entries match Greenhouse
+  2  a sync would add, remove or change managed entries
+  3  clusters were skipped because they are not ready or invalid
+  4  the check failed, e.g. Greenhouse could not be reached
+
+Errors are still reported on stderr. With --verbose the dry-run report of sync
+is printed, and a unified diff of the changes (secrets redacted) on stderr.
+
+drift is configured like sync, from the config file and CLOUDCTL_*
+environment variables (e.g. CLOUDCTL_GREENHOUSE_CLUSTER_NAMESPACE), and checks
+every configured source.
+
+Examples:
+  cloudctl drift || echo "kubeconfig is stale, run cloudctl sync"
+  cloudctl drift --verbose`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runDrift,
+}
+
+func init() {
+	driftCmd.Flags().BoolP("verbose", "v", false, "Print the differences instead of only reporting them in the exit code")
+}
+
+func runDrift(cmd *cobra.Command, _ []string) error {
+	quiet := !viper.GetBool("verbose")
+	if quiet {
+		// Keep informational logs out of the way; warnings and errors remain.
+		if !viper.IsSet("log-level") {
+			viper.Set("log-level", "warn")
+			if err := setupLogger(); err != nil {
+				return err
+			}
+		}
+		cmd.SetOut(io.Discard)
+		defer cmd.SetOut(nil)
+	}
+	viper.Set("dry-run", true)
+	viper.Set("changed-exit-code", true)
+	viper.Set("watch", false)
+	viper.Set("verify", false)
+	viper.Set("benchmark", false)
+	return runSync(cmd, nil)
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestRunDrift(t *testing.T) {
+	g := NewWithT(t)
+	useTempSyncState(t)
+
+	ghCfg := clientcmdapi.NewConfig()
+	ghCfg.Clusters["gh"] = &clientcmdapi.Cluster{Server: "https://greenhouse.example.com"}
+	ghCfg.AuthInfos["gh"] = &clientcmdapi.AuthInfo{Token: "x"}
+	ghCfg.Contexts["gh"] = &clientcmdapi.Context{Cluster: "gh", AuthInfo: "gh"}
+	ghCfg.CurrentContext = "gh"
+	local := filepath.Join(t.TempDir(), "local")
+
+	ckc := greenhousev1alpha1.ClusterKubeconfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "eu", Namespace: "org", ResourceVersion: "1"},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+			Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: "eu", Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: "https://eu.example"}}},
+			AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{Name: "eu"}},
+			Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "eu", Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: "eu", AuthInfo: "eu"}}},
+		}},
+	}
+	ckc.Status.Conditions.SetConditions(greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", ""))
+	stub := &stubGreenhouseClient{allowed: true, ckcs: []greenhousev1alpha1.ClusterKubeconfig{ckc}}
+	prev := newGreenhouseClient
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) { return stub, nil }
+
+	settings := map[string]any{
+		"greenhouse-cluster-kubeconfig": writeTestKubeconfig(t, ghCfg),
+		"greenhouse-cluster-namespace":  "org",
+		"remote-cluster-kubeconfig":     local,
+		"prefix":                        "cloudctl",
+		"auth-type":                     "auth-provider",
+		"output":                        "text",
+	}
+	for k, v := range settings {
+		viper.Set(k, v)
+	}
+	t.Cleanup(func() {
+		newGreenhouseClient = prev
+		for _, k := range []string{"dry-run", "changed-exit-code", "watch", "verify", "benchmark", "log-level", "verbose"} {
+			viper.Set(k, nil)
+		}
+		for k := range settings {
+			viper.Set(k, nil)
+		}
+		_ = setupLogger()
+	})
+	driftCmd.SetContext(context.Background())
+
+	var exitErr *ExitError
+	err := runDrift(driftCmd, nil)
+	g.Expect(errors.As(err, &exitErr)).To(BeTrue(), "a missing cluster is drift")
+	g.Expect(exitErr.Code).To(Equal(ExitCodeChanged))
+	g.Expect(exitErr.Err).NotTo(HaveOccurred())
+	_, statErr := clientcmd.LoadFromFile(local)
+	g.Expect(statErr).To(HaveOccurred(), "drift does not write the kubeconfig")
+
+	viper.Set("dry-run", false)
+	viper.Set("changed-exit-code", false)
+	driftCmd.SetOut(io.Discard)
+	var diffOut bytes.Buffer
+	driftCmd.SetErr(&diffOut)
+	t.Cleanup(func() { driftCmd.SetErr(nil) })
+	viper.Set("verbose", true)
+	g.Expect(runSync(driftCmd, nil)).To(Succeed())
+	g.Expect(diffOut.String()).To(ContainSubstring("cloudctl:eu"), "--verbose writes the diff to the command's stderr")
+	viper.Set("verbose", false)
+	g.Expect(runDrift(driftCmd, nil)).To(Succeed(), "no drift right after a sync")
+
+	var out bytes.Buffer
+	viper.Set("verbose", true)
+	driftCmd.SetOut(&out)
+	t.Cleanup(func() { driftCmd.SetOut(nil) })
+	stub.ckcs = nil
+	g.Expect(runDrift(driftCmd, nil)).To(Succeed(), "without ready clusters sync does not remove entries")
+	g.Expect(out.String()).NotTo(BeEmpty(), "--verbose prints the report")
+}
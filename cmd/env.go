@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [cluster]",
+	Short: "Print shell exports that scope the current shell to one cluster",
+	Long: `Writes a kubeconfig holding only the selected managed context to the
+cloudctl cache directory and prints the commands that point KUBECONFIG at it
+and set KUBECTL_CONTEXT, for eval-ing into the current shell. It is a lighter
+alternative to cloudctl shell; --unset prints the commands that undo it.
+
+The argument is matched against managed contexts like cloudctl use; without
+it a finder is shown.
+
+Examples:
+  eval "$(cloudctl env prod-eu)"
+  cloudctl env prod-eu --shell fish | source
+  cloudctl env prod-eu --shell powershell | Invoke-Expression
+  eval "$(cloudctl env --unset)"`,
+	Args:              cobra.MaximumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runEnv,
+}
+
+// envShells are the syntaxes env can print.
+var envShells = []string{"posix", "fish", "powershell"}
+
+func init() {
+	envCmd.Flags().String("shell", "posix", "Syntax of the printed commands: posix (bash, zsh, sh), fish, or powershell")
+	envCmd.Flags().Bool("unset", false, "Print commands that remove the variables again")
+	_ = envCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(envShells, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	shell := viper.GetString("shell")
+	if !slices.Contains(envShells, shell) {
+		return fmt.Errorf("invalid --shell %q: must be one of %s", shell, strings.Join(envShells, ", "))
+	}
+	if viper.GetBool("unset") {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), envUnsetScript(shell))
+		return err
+	}
+
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	var query string
+	if len(args) == 1 {
+		query = args[0]
+	}
+	contextName, err := selectManagedContext(cfg, query)
+	if err != nil {
+		return err
+	}
+	single, err := singleContextKubeconfig(cfg, contextName)
+	if err != nil {
+		return err
+	}
+	file, err := envKubeconfigPath(contextName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+	}
+	if err := clientcmd.WriteToFile(*single, file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	_, err = fmt.Fprint(cmd.OutOrStdout(), envScript(shell, file, contextName))
+	return err
+}
+
+// envKubeconfigPath returns where env stores the kubeconfig of the managed
+// cluster name.
+func envKubeconfigPath(name string) (string, error) {
+	dir, err := envKubeconfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+// envKubeconfigDir returns the directory env stores kubeconfigs in.
+func envKubeconfigDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "env"), nil
+}
+
+// envScript returns the commands exporting KUBECONFIG and KUBECTL_CONTEXT in
+// the syntax of shell.
+func envScript(shell, kubeconfigFile, contextName string) string {
+	vars := [][2]string{{"KUBECONFIG", kubeconfigFile}, {"KUBECTL_CONTEXT", contextName}}
+	var b strings.Builder
+	for _, v := range vars {
+		switch shell {
+		case "fish":
+			fmt.Fprintf(&b, "set -gx %s %s;\n", v[0], fishQuote(v[1]))
+		case "powershell":
+			fmt.Fprintf(&b, "$Env:%s = %s\n", v[0], powershellQuote(v[1]))
+		default:
+			fmt.Fprintf(&b, "export %s=%s\n", v[0], shellQuote(v[1]))
+		}
+	}
+	switch shell {
+	case "fish":
+		fmt.Fprintf(&b, "# Run this command to configure your shell:\n# cloudctl env %s --shell fish | source\n", contextName)
+	case "powershell":
+		fmt.Fprintf(&b, "# Run this command to configure your shell:\n# cloudctl env %s --shell powershell | Invoke-Expression\n", contextName)
+	default:
+		fmt.Fprintf(&b, "# Run this command to configure your shell:\n# eval \"$(cloudctl env %s)\"\n", contextName)
+	}
+	return b.String()
+}
+
+// envUnsetScript returns the commands removing the variables set by
+// envScript.
+func envUnsetScript(shell string) string {
+	switch shell {
+	case "fish":
+		return "set -e KUBECONFIG;\nset -e KUBECTL_CONTEXT;\n"
+	case "powershell":
+		return "Remove-Item Env:KUBECONFIG -ErrorAction SilentlyContinue\nRemove-Item Env:KUBECTL_CONTEXT -ErrorAction SilentlyContinue\n"
+	default:
+		return "unset KUBECONFIG KUBECTL_CONTEXT\n"
+	}
+}
+
+// fishQuote quotes s for fish, where backslashes and single quotes are
+// escaped inside single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// powershellQuote quotes s for PowerShell, where single quotes are doubled.
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunEnv(t *testing.T) {
+	g := NewWithT(t)
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	for _, name := range []string{"prod-eu", "local"} {
+		cluster := name
+		if name != "local" {
+			cluster = "cloudctl:" + name
+		}
+		cfg.Clusters[cluster] = &clientcmdapi.Cluster{Server: "https://" + name + ".example"}
+		cfg.AuthInfos[cluster] = &clientcmdapi.AuthInfo{Token: name}
+		cfg.Contexts[name] = &clientcmdapi.Context{Cluster: cluster, AuthInfo: cluster}
+	}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	for k, v := range map[string]any{"kubeconfig": path, "prefix": "cloudctl", "shell": "posix"} {
+		viper.Set(k, v)
+		t.Cleanup(func() { viper.Set(k, nil) })
+	}
+	var out bytes.Buffer
+	envCmd.SetOut(&out)
+	t.Cleanup(func() { envCmd.SetOut(nil) })
+
+	g.Expect(runEnv(envCmd, []string{"prod-eu"})).To(Succeed())
+	file, err := envKubeconfigPath("prod-eu")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(HavePrefix("export KUBECONFIG='" + file + "'\nexport KUBECTL_CONTEXT='prod-eu'\n"))
+	written, err := clientcmd.LoadFromFile(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.CurrentContext).To(Equal("prod-eu"))
+	g.Expect(written.Contexts).To(HaveLen(1))
+
+	out.Reset()
+	viper.Set("shell", "fish")
+	viper.Set("unset", true)
+	t.Cleanup(func() { viper.Set("unset", nil) })
+	g.Expect(runEnv(envCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(Equal("set -e KUBECONFIG;\nset -e KUBECTL_CONTEXT;\n"))
+
+	viper.Set("shell", "tcsh")
+	g.Expect(runEnv(envCmd, nil)).To(MatchError(ContainSubstring(`invalid --shell "tcsh"`)))
+}
+
+func TestEnvScript_Quoting(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(envScript("fish", `C:\it's`, "a")).To(HavePrefix(`set -gx KUBECONFIG 'C:\\it\'s';` + "\n"))
+	g.Expect(envScript("powershell", `C:\it's`, "a")).To(HavePrefix(`$Env:KUBECONFIG = 'C:\it''s'` + "\n"))
+	g.Expect(envScript("posix", "/tmp/it's", "a")).To(HavePrefix(`export KUBECONFIG='/tmp/it'\''s'` + "\n"))
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+)
+
+// execCommand returns the command to write into kubeconfig exec entries.
+// On Windows a bare name such as "kubelogin" gets an ".exe" suffix: client-go
+// resolves exec commands without consulting PATHEXT in every code path, and
+// other tools reading the kubeconfig (IDEs, WSL interop) expect the full name.
+func execCommand(path string) string {
+	return execCommandFor(path, runtime.GOOS)
+}
+
+func execCommandFor(path, goos string) string {
+	if goos == "windows" && filepath.Ext(path) == "" {
+		return path + ".exe"
+	}
+	return path
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+)
+
+// kubeloginArgsConfig holds per-cluster and per-connector argument templates
+// read from the "kubelogin-args" config-file key. Different identity providers
+// often need different kubelogin flags (e.g. --oidc-pkce, --grant-type), which a
+// single --kubelogin-extra-args list cannot express.
+//
+//	kubelogin-args:
+//	  connectors:
+//	    ldap: ["--grant-type=password"]
+//	  clusters:
+//	    prod-*: ["--token-cache-dir={{.TokenCacheDir}}/prod/{{.Connector}}"]
+type kubeloginArgsConfig struct {
+	Clusters   map[string][]string `mapstructure:"clusters"`
+	Connectors map[string][]string `mapstructure:"connectors"`
+}
+
+// kubeloginArgsData is the data available to argument templates.
+type kubeloginArgsData struct {
+	Cluster       string
+	Connector     string
+	IssuerURL     string
+	ClientID      string
+	TokenCacheDir string
+}
+
+// kubeloginArgTemplates is populated by runSync from the config file.
+var kubeloginArgTemplates kubeloginArgsConfig
+
+// loadKubeloginArgsConfig reads the "kubelogin-args" key and validates that
+// every cluster pattern and argument template parses.
+func loadKubeloginArgsConfig() (kubeloginArgsConfig, error) {
+	var c kubeloginArgsConfig
+	if err := viper.UnmarshalKey("kubelogin-args", &c); err != nil {
+		return c, fmt.Errorf("invalid kubelogin-args config: %w", err)
+	}
+	for pattern, args := range c.Clusters {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return c, fmt.Errorf("invalid kubelogin-args cluster pattern %q: %w", pattern, err)
+		}
+		for _, a := range args {
+			if _, err := parseArgTemplate(a); err != nil {
+				return c, fmt.Errorf("invalid kubelogin-args template for cluster %q: %w", pattern, err)
+			}
+		}
+	}
+	for connector, args := range c.Connectors {
+		for _, a := range args {
+			if _, err := parseArgTemplate(a); err != nil {
+				return c, fmt.Errorf("invalid kubelogin-args template for connector %q: %w", connector, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// argsFor renders the arguments that apply to the given cluster and connector.
+// Connector arguments come first and cluster arguments last, so a cluster can
+// override a flag set for its connector. Cluster keys are glob patterns
+// (path.Match syntax) applied in lexical order.
+func (c kubeloginArgsConfig) argsFor(data kubeloginArgsData) ([]string, error) {
+	var tmpls []string
+	if data.Connector != "" {
+		tmpls = append(tmpls, c.Connectors[data.Connector]...)
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(c.Clusters)) {
+		if ok, _ := path.Match(pattern, data.Cluster); ok {
+			tmpls = append(tmpls, c.Clusters[pattern]...)
+		}
+	}
+
+	args := make([]string, 0, len(tmpls))
+	for _, a := range tmpls {
+		t, err := parseArgTemplate(a)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("failed to render kubelogin argument %q for cluster %q: %w", a, data.Cluster, err)
+		}
+		args = append(args, b.String())
+	}
+	return args, nil
+}
+
+func parseArgTemplate(s string) (*template.Template, error) {
+	return template.New("arg").Option("missingkey=error").Parse(s)
+}
+
+// execAPIVersions are the client.authentication.k8s.io versions generated
+// exec entries can declare, by their short name.
+var execAPIVersions = map[string]string{
+	"v1":      "client.authentication.k8s.io/v1",
+	"v1beta1": "client.authentication.k8s.io/v1beta1",
+}
+
+// execAPIVersionConfig selects the ExecConfig API version of generated exec
+// entries. Default comes from --exec-api-version or the source; Clusters from
+// the "exec-api-versions" config-file key, whose glob patterns (path.Match
+// syntax) override it for matching clusters:
+//
+//	exec-api-versions:
+//	  legacy-*: v1beta1
+type execAPIVersionConfig struct {
+	Default  string
+	Clusters map[string]string
+}
+
+// execAPIVersionSettings is populated by runSync from the flags and the
+// config file.
+var execAPIVersionSettings execAPIVersionConfig
+
+// validateExecAPIVersion checks that version is a key of execAPIVersions.
+func validateExecAPIVersion(version string) error {
+	if _, ok := execAPIVersions[version]; !ok {
+		return fmt.Errorf("exec API version %q is not one of %s", version, strings.Join(slices.Sorted(maps.Keys(execAPIVersions)), ", "))
+	}
+	return nil
+}
+
+// loadExecAPIVersionConfig reads the "exec-api-versions" key and validates
+// every pattern and version, including def ("" meaning v1).
+func loadExecAPIVersionConfig(def string) (execAPIVersionConfig, error) {
+	c := execAPIVersionConfig{Default: cmp.Or(def, "v1")}
+	if err := validateExecAPIVersion(c.Default); err != nil {
+		return c, fmt.Errorf("invalid --exec-api-version: %w", err)
+	}
+	if err := viper.UnmarshalKey("exec-api-versions", &c.Clusters); err != nil {
+		return c, fmt.Errorf("invalid exec-api-versions config: %w", err)
+	}
+	for pattern, version := range c.Clusters {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return c, fmt.Errorf("invalid exec-api-versions cluster pattern %q: %w", pattern, err)
+		}
+		if err := validateExecAPIVersion(version); err != nil {
+			return c, fmt.Errorf("invalid exec-api-versions entry %q: %w", pattern, err)
+		}
+	}
+	return c, nil
+}
+
+// apiVersionFor returns the ExecConfig API version for cluster. Patterns are
+// applied in lexical order, so the last matching one wins.
+func (c execAPIVersionConfig) apiVersionFor(cluster string) string {
+	version := cmp.Or(c.Default, "v1")
+	for _, pattern := range slices.Sorted(maps.Keys(c.Clusters)) {
+		if ok, _ := path.Match(pattern, cluster); ok {
+			version = c.Clusters[pattern]
+		}
+	}
+	return execAPIVersions[version]
+}
+
+// connectorIDFromExtraParams returns the connector_id from a comma-separated
+// auth-request-extra-params value, or "" if none is set.
+func connectorIDFromExtraParams(v string) string {
+	for param := range strings.SplitSeq(v, ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "connector_id" {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
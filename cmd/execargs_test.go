@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestKubeloginArgsConfig_ArgsFor(t *testing.T) {
+	g := NewWithT(t)
+
+	c := kubeloginArgsConfig{
+		Connectors: map[string][]string{
+			"ldap": {"--grant-type=password"},
+		},
+		Clusters: map[string][]string{
+			"prod-*":  {"--token-cache-dir={{.TokenCacheDir}}/prod/{{.Connector}}"},
+			"prod-eu": {"--oidc-pkce"},
+			"dev-*":   {"--skip-open-browser"},
+		},
+	}
+
+	args, err := c.argsFor(kubeloginArgsData{Cluster: "prod-eu", Connector: "ldap", TokenCacheDir: "/cache"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args).To(Equal([]string{
+		"--grant-type=password",
+		"--token-cache-dir=/cache/prod/ldap",
+		"--oidc-pkce",
+	}))
+
+	args, err = c.argsFor(kubeloginArgsData{Cluster: "staging"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args).To(BeEmpty())
+}
+
+func TestLoadKubeloginArgsConfig_RejectsBadTemplate(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { viper.Set("kubelogin-args", nil) })
+
+	viper.Set("kubelogin-args", map[string]any{
+		"clusters": map[string]any{"prod-eu": []string{"--token-cache-dir={{.Nope"}},
+	})
+	_, err := loadKubeloginArgsConfig()
+	g.Expect(err).To(MatchError(ContainSubstring(`cluster "prod-eu"`)))
+
+	viper.Set("kubelogin-args", map[string]any{
+		"connectors": map[string]any{"ldap": []string{"--oidc-pkce"}},
+	})
+	c, err := loadKubeloginArgsConfig()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Connectors).To(HaveKeyWithValue("ldap", []string{"--oidc-pkce"}))
+}
+
+func TestBuildIncomingKubeconfig_InjectsTemplatedArgs(t *testing.T) {
+	g := NewWithT(t)
+
+	prevType, prevTemplates, prevExtra, prevCache := authType, kubeloginArgTemplates, kubeloginExtraArgs, kubeloginTokenCacheDir
+	t.Cleanup(func() {
+		authType, kubeloginArgTemplates, kubeloginExtraArgs, kubeloginTokenCacheDir = prevType, prevTemplates, prevExtra, prevCache
+	})
+	authType = "exec-plugin"
+	kubeloginExtraArgs = []string{"--v=1"}
+	kubeloginTokenCacheDir = "/cache"
+	kubeloginArgTemplates = kubeloginArgsConfig{
+		Connectors: map[string][]string{"ldap": {"--token-cache-dir={{.TokenCacheDir}}/{{.Connector}}-{{.Cluster}}"}},
+	}
+
+	items := []greenhousev1alpha1.ClusterKubeconfig{{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-eu"},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+			AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{
+				Name: "prod-eu",
+				AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{AuthProvider: clientcmdapi.AuthProviderConfig{
+					Name: "oidc",
+					Config: map[string]string{
+						"idp-issuer-url":            "https://issuer.example.com",
+						"client-id":                 "cid",
+						"auth-request-extra-params": "connector_id=ldap",
+					},
+				}},
+			}},
+		}},
+	}}
+
+	cfg, err := buildIncomingKubeconfig(items)
+	g.Expect(err).NotTo(HaveOccurred())
+	args := cfg.AuthInfos["prod-eu"].Exec.Args
+	// Templated args follow the connector default and --kubelogin-extra-args,
+	// so kubelogin's last-wins flag parsing picks the configured cache dir.
+	g.Expect(args[len(args)-3:]).To(Equal([]string{
+		"--token-cache-dir=" + filepath.Join("/cache", "ldap"),
+		"--v=1",
+		"--token-cache-dir=/cache/ldap-prod-eu",
+	}))
+}
+
+func TestExecAPIVersionConfig(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { viper.Set("exec-api-versions", nil) })
+
+	viper.Set("exec-api-versions", map[string]any{"legacy-*": "v1beta1", "legacy-new": "v1"})
+	c, err := loadExecAPIVersionConfig("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.apiVersionFor("prod-eu")).To(Equal("client.authentication.k8s.io/v1"))
+	g.Expect(c.apiVersionFor("legacy-eu")).To(Equal("client.authentication.k8s.io/v1beta1"))
+	g.Expect(c.apiVersionFor("legacy-new")).To(Equal("client.authentication.k8s.io/v1"), "the last matching pattern wins")
+
+	c, err = loadExecAPIVersionConfig("v1beta1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.apiVersionFor("prod-eu")).To(Equal("client.authentication.k8s.io/v1beta1"))
+
+	_, err = loadExecAPIVersionConfig("v2")
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid --exec-api-version: exec API version "v2"`)))
+	viper.Set("exec-api-versions", map[string]any{"legacy-*": "beta"})
+	_, err = loadExecAPIVersionConfig("v1")
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid exec-api-versions entry "legacy-*"`)))
+}
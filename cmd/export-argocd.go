@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+var exportArgoCDCmd = &cobra.Command{
+	Use:   "argocd",
+	Short: "Print managed clusters as ArgoCD cluster Secrets",
+	Long: `Prints the managed clusters of the local kubeconfig as ArgoCD declarative
+cluster Secrets, ready for kubectl apply or a GitOps repository. Each Secret
+holds the server, the CA and the credentials of the context: a bearer token,
+a client certificate or the exec plugin configuration. The Greenhouse
+labels of the cluster are copied to the Secret, so ApplicationSet cluster
+generators can select on them.
+
+--selector filters the clusters by their Greenhouse labels, with the
+Kubernetes label selector syntax.
+
+Examples:
+  cloudctl export argocd --selector env=prod | kubectl apply -f -
+  cloudctl export argocd --selector 'region in (eu-de-1,eu-nl-1)' --namespace gitops`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runExportArgoCD,
+}
+
+// argoCDSecretTypeLabel marks a Secret as an ArgoCD cluster.
+const argoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+
+func init() {
+	exportArgoCDCmd.Flags().StringP("selector", "l", "", "Only export clusters whose labels match this label selector")
+	exportArgoCDCmd.Flags().String("namespace", "argocd", "Namespace of the ArgoCD installation")
+}
+
+// argoCDClusterConfig is the "config" field of an ArgoCD cluster Secret.
+type argoCDClusterConfig struct {
+	BearerToken        string                    `json:"bearerToken,omitempty"`
+	TLSClientConfig    argoCDTLSClientConfig     `json:"tlsClientConfig"`
+	ExecProviderConfig *argoCDExecProviderConfig `json:"execProviderConfig,omitempty"`
+}
+
+type argoCDTLSClientConfig struct {
+	Insecure   bool   `json:"insecure"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     []byte `json:"caData,omitempty"`
+	CertData   []byte `json:"certData,omitempty"`
+	KeyData    []byte `json:"keyData,omitempty"`
+}
+
+type argoCDExecProviderConfig struct {
+	Command     string            `json:"command"`
+	Args        []string          `json:"args,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	APIVersion  string            `json:"apiVersion"`
+	InstallHint string            `json:"installHint,omitempty"`
+}
+
+func runExportArgoCD(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	cfg, names, err := exportContexts(path, viper.GetString("selector"))
+	if err != nil {
+		return err
+	}
+
+	var docs []string
+	for _, name := range names {
+		secret, err := argoCDClusterSecret(cfg, name, viper.GetString("namespace"))
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(secret)
+		if err != nil {
+			return fmt.Errorf("failed to serialize secret for context %q: %w", name, err)
+		}
+		docs = append(docs, string(data))
+	}
+	slog.Info("exported ArgoCD cluster secrets", "count", len(docs))
+	_, err = fmt.Fprint(cmd.OutOrStdout(), strings.Join(docs, "---\n"))
+	return err
+}
+
+// argoCDClusterSecret returns the ArgoCD cluster Secret for the managed
+// context name of cfg, whose files must already be inlined.
+func argoCDClusterSecret(cfg *clientcmdapi.Config, name, namespace string) (*corev1.Secret, error) {
+	kctx := cfg.Contexts[name]
+	if kctx == nil || cfg.Clusters[kctx.Cluster] == nil {
+		return nil, fmt.Errorf("context %q does not reference a known cluster", name)
+	}
+	cluster := cfg.Clusters[kctx.Cluster]
+	clusterConfig := argoCDClusterConfig{
+		TLSClientConfig: argoCDTLSClientConfig{
+			Insecure:   cluster.InsecureSkipTLSVerify,
+			ServerName: cluster.TLSServerName,
+			CAData:     cluster.CertificateAuthorityData,
+		},
+	}
+	auth := cfg.AuthInfos[kctx.AuthInfo]
+	switch {
+	case auth == nil:
+		slog.Warn("context has no credentials, ArgoCD will connect anonymously", "context", name)
+	case auth.AuthProvider != nil:
+		return nil, fmt.Errorf("context %q uses the %s auth provider, which ArgoCD does not support", name, auth.AuthProvider.Name)
+	case auth.Exec != nil:
+		exec := &argoCDExecProviderConfig{
+			Command:     auth.Exec.Command,
+			Args:        auth.Exec.Args,
+			APIVersion:  auth.Exec.APIVersion,
+			InstallHint: auth.Exec.InstallHint,
+		}
+		if len(auth.Exec.Env) > 0 {
+			exec.Env = map[string]string{}
+			for _, e := range auth.Exec.Env {
+				exec.Env[e.Name] = e.Value
+			}
+		}
+		clusterConfig.ExecProviderConfig = exec
+	default:
+		clusterConfig.BearerToken = auth.Token
+		clusterConfig.TLSClientConfig.CertData = auth.ClientCertificateData
+		clusterConfig.TLSClientConfig.KeyData = auth.ClientKeyData
+	}
+	configJSON, err := json.Marshal(clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize ArgoCD config for context %q: %w", name, err)
+	}
+
+	secretLabels := map[string]string{argoCDSecretTypeLabel: "cluster"}
+	clusterLabels := contextClusterLabels(cfg, name)
+	for _, k := range slices.Sorted(maps.Keys(clusterLabels)) {
+		if k != argoCDSecretTypeLabel {
+			secretLabels[k] = clusterLabels[k]
+		}
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-" + argoCDSecretName(name),
+			Namespace: namespace,
+			Labels:    secretLabels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"name":   name,
+			"server": cluster.Server,
+			"config": string(configJSON),
+		},
+	}, nil
+}
+
+// argoCDSecretName turns a cluster name into a valid Secret name: lowercase
+// alphanumerics and dashes, at most 55 characters so "cluster-" fits.
+func argoCDSecretName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	s := b.String()
+	if len(s) > 55 {
+		s = s[:55]
+	}
+	return strings.Trim(s, "-.")
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRunExportArgoCD(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:prod-eu"] = &clientcmdapi.Cluster{
+		Server:                   "https://prod-eu.example",
+		CertificateAuthorityData: []byte("ca"),
+		Extensions:               map[string]runtime.Object{"labels": &runtime.Unknown{Raw: []byte(`{"env":"prod","region":"eu"}`)}},
+	}
+	cfg.Clusters["cloudctl:QA_1"] = &clientcmdapi.Cluster{
+		Server:     "https://qa.example",
+		Extensions: map[string]runtime.Object{"labels": &runtime.Unknown{Raw: []byte(`{"env":"qa"}`)}},
+	}
+	cfg.AuthInfos["cloudctl:oidc"] = &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command:    "kubelogin",
+		Args:       []string{"get-token"},
+		Env:        []clientcmdapi.ExecEnvVar{{Name: "KEY", Value: "v"}},
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+	}}
+	cfg.AuthInfos["cloudctl:ci"] = &clientcmdapi.AuthInfo{Token: "secret-token"}
+	cfg.Contexts["prod-eu"] = &clientcmdapi.Context{Cluster: "cloudctl:prod-eu", AuthInfo: "cloudctl:oidc"}
+	cfg.Contexts["QA_1"] = &clientcmdapi.Context{Cluster: "cloudctl:QA_1", AuthInfo: "cloudctl:ci"}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+
+	viper.Set("kubeconfig", path)
+	viper.Set("prefix", "cloudctl")
+	viper.Set("namespace", "argocd")
+	t.Cleanup(func() {
+		viper.Set("kubeconfig", nil)
+		viper.Set("prefix", nil)
+		viper.Set("namespace", nil)
+		viper.Set("selector", nil)
+	})
+
+	export := func(selector string) ([]corev1.Secret, error) {
+		viper.Set("selector", selector)
+		var out bytes.Buffer
+		exportArgoCDCmd.SetOut(&out)
+		if err := runExportArgoCD(exportArgoCDCmd, nil); err != nil {
+			return nil, err
+		}
+		var secrets []corev1.Secret
+		for _, doc := range strings.Split(out.String(), "---\n") {
+			var s corev1.Secret
+			g.Expect(yaml.Unmarshal([]byte(doc), &s)).To(Succeed())
+			secrets = append(secrets, s)
+		}
+		return secrets, nil
+	}
+
+	secrets, err := export("env=prod")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secrets).To(HaveLen(1))
+	s := secrets[0]
+	g.Expect(s.Name).To(Equal("cluster-prod-eu"))
+	g.Expect(s.Namespace).To(Equal("argocd"))
+	g.Expect(s.Labels).To(Equal(map[string]string{argoCDSecretTypeLabel: "cluster", "env": "prod", "region": "eu"}))
+	g.Expect(s.StringData).To(HaveKeyWithValue("name", "prod-eu"))
+	g.Expect(s.StringData).To(HaveKeyWithValue("server", "https://prod-eu.example"))
+	var conf argoCDClusterConfig
+	g.Expect(json.Unmarshal([]byte(s.StringData["config"]), &conf)).To(Succeed())
+	g.Expect(conf.TLSClientConfig.CAData).To(Equal([]byte("ca")))
+	g.Expect(conf.ExecProviderConfig).To(Equal(&argoCDExecProviderConfig{
+		Command:    "kubelogin",
+		Args:       []string{"get-token"},
+		Env:        map[string]string{"KEY": "v"},
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+	}))
+
+	secrets, err = export("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secrets).To(HaveLen(2))
+	g.Expect(secrets[0].Name).To(Equal("cluster-qa-1"))
+	g.Expect(json.Unmarshal([]byte(secrets[0].StringData["config"]), &conf)).To(Succeed())
+	g.Expect(conf.BearerToken).To(Equal("secret-token"))
+
+	_, err = export("env=staging")
+	g.Expect(err).To(MatchError(ContainSubstring("no managed cluster matches")))
+	_, err = export("env in (")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid --selector")))
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var exportLensCmd = &cobra.Command{
+	Use:   "lens",
+	Short: "Write managed clusters as kubeconfig files and a catalog for Lens",
+	Long: `Writes one kubeconfig file per managed cluster into --dir, plus a
+catalog.json describing each cluster as a Lens KubernetesCluster entity with
+its Greenhouse labels. Add the directory under Preferences > Kubernetes >
+Kubeconfig Syncs in Lens or OpenLens and the clusters show up in the
+catalog without manual imports; run the command again after a sync to
+update them.
+
+Each file holds a single context with certificates inlined and is written
+with mode 0600. Files written by a previous run for clusters that are no
+longer exported are removed; other files in the directory are left alone.
+--selector filters the clusters by their Greenhouse labels.
+
+Examples:
+  cloudctl export lens
+  cloudctl export lens --dir ~/lens-clusters --selector env=prod`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runExportLens,
+}
+
+// lensCatalogFile lists the clusters written by the last run in --dir.
+const lensCatalogFile = "catalog.json"
+
+func init() {
+	exportLensCmd.Flags().StringP("selector", "l", "", "Only export clusters whose labels match this label selector")
+	exportLensCmd.Flags().String("dir", "", "Directory to write the kubeconfig files to (default: ~/.kube/cloudctl-lens)")
+}
+
+// lensEntity is a catalog.json entry, shaped like a Lens KubernetesCluster
+// catalog entity.
+type lensEntity struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   lensEntityMetadata `json:"metadata"`
+	Spec       lensEntitySpec     `json:"spec"`
+}
+
+type lensEntityMetadata struct {
+	UID    string            `json:"uid"`
+	Name   string            `json:"name"`
+	Source string            `json:"source"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type lensEntitySpec struct {
+	KubeconfigPath    string `json:"kubeconfigPath"`
+	KubeconfigContext string `json:"kubeconfigContext"`
+}
+
+// defaultLensDir returns ~/.kube/cloudctl-lens.
+func defaultLensDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".kube", "cloudctl-lens"), nil
+}
+
+// lensFileName returns the kubeconfig file name for a cluster.
+func lensFileName(name string) string {
+	return safeFileName(name) + ".yaml"
+}
+
+// safeFileName replaces the characters of a cluster name that cannot appear
+// in file names on every platform.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+func runExportLens(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	dir := viper.GetString("dir")
+	if dir == "" {
+		var err error
+		if dir, err = defaultLensDir(); err != nil {
+			return err
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, names, err := exportContexts(path, viper.GetString("selector"))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	previous := readLensCatalog(dir)
+
+	entities := make([]lensEntity, 0, len(names))
+	written := map[string]bool{}
+	for _, name := range names {
+		single, err := singleContextKubeconfig(cfg, name)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, lensFileName(name))
+		if err := clientcmd.WriteToFile(*single, file); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		// WriteToFile keeps the mode of an existing file.
+		if err := os.Chmod(file, 0o600); err != nil {
+			return fmt.Errorf("failed to restrict permissions of %s: %w", file, err)
+		}
+		written[file] = true
+		entities = append(entities, lensEntity{
+			APIVersion: "entity.k8slens.dev/v1alpha1",
+			Kind:       "KubernetesCluster",
+			Metadata: lensEntityMetadata{
+				UID:    "cloudctl-" + name,
+				Name:   name,
+				Source: "cloudctl",
+				Labels: contextClusterLabels(cfg, name),
+			},
+			Spec: lensEntitySpec{KubeconfigPath: file, KubeconfigContext: name},
+		})
+	}
+
+	for _, e := range previous {
+		file := e.Spec.KubeconfigPath
+		if written[file] || filepath.Dir(file) != dir {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+		slog.Info("removed Lens kubeconfig", "file", file)
+	}
+
+	data, err := json.MarshalIndent(entities, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize catalog: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, lensCatalogFile), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	slog.Info("exported Lens clusters", "count", len(entities), "dir", dir)
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), dir)
+	return err
+}
+
+// readLensCatalog returns the entities written by the previous run in dir,
+// or nil when there is none or it cannot be read.
+func readLensCatalog(dir string) []lensEntity {
+	data, err := os.ReadFile(filepath.Join(dir, lensCatalogFile))
+	if err != nil {
+		return nil
+	}
+	var entities []lensEntity
+	if err := json.Unmarshal(data, &entities); err != nil {
+		slog.Warn("ignoring unreadable Lens catalog", "dir", dir, "error", err)
+		return nil
+	}
+	return entities
+}
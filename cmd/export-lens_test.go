@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestRunExportLens(t *testing.T) {
+	g := NewWithT(t)
+	writeTestKubeconfig(t, newManagedCfg("prod-eu", "qa"))
+	dir := t.TempDir()
+	viper.Set("dir", dir)
+	t.Cleanup(func() { viper.Set("dir", nil) })
+	exportLensCmd.SetOut(io.Discard)
+
+	g.Expect(runExportLens(exportLensCmd, nil)).To(Succeed())
+	file := filepath.Join(dir, "prod-eu.yaml")
+	fi, err := os.Stat(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+	cfg, err := clientcmd.LoadFromFile(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.CurrentContext).To(Equal("prod-eu"))
+	g.Expect(cfg.Contexts).To(HaveLen(1))
+
+	data, err := os.ReadFile(filepath.Join(dir, lensCatalogFile))
+	g.Expect(err).NotTo(HaveOccurred())
+	var entities []lensEntity
+	g.Expect(json.Unmarshal(data, &entities)).To(Succeed())
+	g.Expect(entities).To(HaveLen(2))
+	g.Expect(entities[0].Metadata.Name).To(Equal("prod-eu"))
+	g.Expect(entities[0].Spec).To(Equal(lensEntitySpec{KubeconfigPath: file, KubeconfigContext: "prod-eu"}))
+
+	// A cluster that is no longer exported loses its file; unrelated files stay.
+	g.Expect(os.WriteFile(filepath.Join(dir, "mine.yaml"), nil, 0o600)).To(Succeed())
+	writeTestKubeconfig(t, newManagedCfg("prod-eu"))
+	g.Expect(runExportLens(exportLensCmd, nil)).To(Succeed())
+	g.Expect(filepath.Join(dir, "qa.yaml")).NotTo(BeAnExistingFile())
+	g.Expect(file).To(BeAnExistingFile())
+	g.Expect(filepath.Join(dir, "mine.yaml")).To(BeAnExistingFile())
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Convert managed clusters into configuration for other tools",
+}
+
+func init() {
+	exportCmd.AddCommand(exportArgoCDCmd)
+	exportCmd.AddCommand(exportLensCmd)
+}
+
+// exportContexts loads the kubeconfig at path with certificate files inlined
+// and returns it with the sorted managed contexts whose cluster labels match
+// selector. It fails when no context matches.
+func exportContexts(path, selector string) (*clientcmdapi.Config, []string, error) {
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	if err := clientcmdapi.FlattenConfig(cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to inline certificate files: %w", err)
+	}
+	all, err := fleetContexts(cfg, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := matchingContexts(cfg, all, selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("no managed cluster matches --selector %q", selector)
+	}
+	return cfg, names, nil
+}
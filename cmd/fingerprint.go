@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [context|file]",
+	Short: "Print stable hashes of what kubeconfig contexts connect to and as whom",
+	Long: `Prints a hash per context over what it connects to and as whom: the server
+URL, TLS server name and verification setting, the cluster CA certificate, and
+the identity of its user (auth mechanism, OIDC issuer and client ID, exec
+plugin and its arguments, client certificate, impersonation). With several
+contexts a combined hash over all of them is printed too.
+
+Two machines or two users with the same fingerprints have the same clusters
+configured with the same identities, which can be checked without sharing the
+files. Secrets and volatile state are not hashed, so refreshed tokens, file
+locations and the install path of exec plugins do not change fingerprints;
+context names are not hashed either, only printed next to their fingerprint.
+
+Without an argument every context of the kubeconfig is fingerprinted. An
+argument naming an existing file fingerprints every context of that file;
+otherwise it selects one context like cloudctl use.
+
+Examples:
+  cloudctl fingerprint
+  cloudctl fingerprint prod-eu
+  cloudctl fingerprint ./shared-kubeconfig.yaml -o json`,
+	Args:              cobra.MaximumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runFingerprint,
+}
+
+func runFingerprint(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	var cfg *clientcmdapi.Config
+	var names []string
+	result := output.FingerprintResult{}
+	if len(args) == 1 {
+		if info, statErr := os.Stat(args[0]); statErr == nil && info.Mode().IsRegular() {
+			if cfg, err = clientcmd.LoadFromFile(args[0]); err != nil {
+				return fmt.Errorf("failed to load kubeconfig %s: %w", args[0], err)
+			}
+			result.Kubeconfig = args[0]
+		}
+	}
+	if cfg == nil {
+		path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+		if cfg, err = kubeconfigPathOptions(path).GetStartingConfig(); err != nil {
+			return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+		}
+		result.Kubeconfig = displayKubeconfig(path)
+		if len(args) == 1 {
+			name, err := selectTarget("context", args[0], contextItems(cfg))
+			if err != nil {
+				return err
+			}
+			names = []string{name}
+		}
+	}
+	if names == nil {
+		names = slices.Sorted(maps.Keys(cfg.Contexts))
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no contexts found in %s", result.Kubeconfig)
+	}
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		fp, err := contextFingerprint(cfg, name)
+		entry := output.FingerprintEntry{Context: name, Fingerprint: fp}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		result.Contexts = append(result.Contexts, entry)
+		lines = append(lines, combinedLine(fp, err))
+	}
+	if len(names) > 1 {
+		// Sorted, so the combined hash does not depend on context names.
+		slices.Sort(lines)
+		sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+		result.Combined = hex.EncodeToString(sum[:])[:16]
+	}
+
+	w := cmd.OutOrStdout()
+	return output.New(format, output.IsTTYWriter(w), w).Print(result)
+}
+
+// combinedLine returns fp, or a marker of err so a broken entry still changes
+// the combined fingerprint.
+func combinedLine(fp string, err error) string {
+	if err != nil {
+		return "error:" + err.Error()
+	}
+	return fp
+}
+
+// contextFingerprint hashes what the context name of cfg connects to and the
+// identity it authenticates with, and returns the first 16 hex characters.
+func contextFingerprint(cfg *clientcmdapi.Config, name string) (string, error) {
+	kctx := cfg.Contexts[name]
+	if kctx == nil {
+		return "", fmt.Errorf("context %q not found", name)
+	}
+	cluster := cfg.Clusters[kctx.Cluster]
+	if cluster == nil {
+		return "", fmt.Errorf("cluster %q not found", kctx.Cluster)
+	}
+	ca, err := certificateDigest(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	if err != nil {
+		return "", fmt.Errorf("CA of cluster %q: %w", kctx.Cluster, err)
+	}
+	identity, err := authIdentity(cfg.AuthInfos[kctx.AuthInfo])
+	if err != nil {
+		return "", fmt.Errorf("user %q: %w", kctx.AuthInfo, err)
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "server:%s\ntls-server-name:%s\ninsecure:%t\nca:%s\n%s",
+		strings.TrimSuffix(cluster.Server, "/"), cluster.TLSServerName, cluster.InsecureSkipTLSVerify, ca, identity)
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// certificateDigest returns the SHA-256 of the DER encoding of the certificate
+// in data (or file), so PEM formatting and the file location do not matter,
+// or "" when there is none.
+func certificateDigest(data []byte, file string) (string, error) {
+	cert, err := parseCertificate(data, file)
+	if errors.Is(err, errNoCertificate) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// authIdentity serializes who ai authenticates as, leaving out secrets and
+// state that changes without changing the identity: tokens, passwords and
+// client keys, OIDC token cache settings and the path of exec plugins. Other
+// exec plugins are identified by all their arguments and environment, which
+// select e.g. the cloud account.
+func authIdentity(ai *clientcmdapi.AuthInfo) (string, error) {
+	auth := authInfoSummary(ai)
+	identity := fmt.Sprintf("auth:%s\ncommand:%s\nissuer:%s\nclient-id:%s\nsubject:%s\n",
+		auth.Mechanism, auth.Command, auth.Issuer, auth.ClientID, auth.Subject)
+	if ai == nil {
+		return identity, nil
+	}
+	if auth.Mechanism == "exec" {
+		identity += "args:" + strings.Join(ai.Exec.Args, " ") + "\n"
+		env := make([]string, 0, len(ai.Exec.Env))
+		for _, e := range ai.Exec.Env {
+			env = append(env, e.Name+"="+e.Value)
+		}
+		slices.Sort(env)
+		identity += "env:" + strings.Join(env, ",") + "\n"
+	}
+	if auth.Mechanism == "cert" {
+		cert, err := certificateDigest(ai.ClientCertificateData, ai.ClientCertificate)
+		if err != nil {
+			return "", err
+		}
+		identity += "cert:" + cert + "\n"
+	}
+	return identity + impersonationKey(ai), nil
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// fingerprintConfig returns a kubeconfig with one OIDC context.
+func fingerprintConfig(ca []byte) *clientcmdapi.Config {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["eu-1"] = &clientcmdapi.Cluster{Server: "https://eu-1.example", CertificateAuthorityData: ca}
+	cfg.AuthInfos["eu-1"] = &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command: "/usr/local/bin/kubectl",
+		Args:    []string{"oidc-login", "get-token", "--oidc-issuer-url=https://idp", "--oidc-client-id=cli", "--token-cache-dir=/home/a/.kube/cache"},
+	}}
+	cfg.Contexts["eu-1"] = &clientcmdapi.Context{Cluster: "eu-1", AuthInfo: "eu-1"}
+	return cfg
+}
+
+func TestContextFingerprint(t *testing.T) {
+	g := NewWithT(t)
+	ca := testCertPEM(t, time.Now().AddDate(1, 0, 0))
+
+	base, err := contextFingerprint(fingerprintConfig(ca), "eu-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(base).To(HaveLen(16))
+
+	same := fingerprintConfig(ca)
+	same.AuthInfos["eu-1"].Exec.Command = "/opt/homebrew/bin/kubectl"
+	same.AuthInfos["eu-1"].Exec.Args[4] = "--token-cache-dir=/Users/b/.kube/cache"
+	same.Clusters["eu-1"].Server += "/"
+	g.Expect(contextFingerprint(same, "eu-1")).To(Equal(base), "install paths and token caches are not part of the identity")
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	g.Expect(os.WriteFile(caFile, ca, 0o600)).To(Succeed())
+	fromFile := fingerprintConfig(nil)
+	fromFile.Clusters["eu-1"].CertificateAuthority = caFile
+	g.Expect(contextFingerprint(fromFile, "eu-1")).To(Equal(base), "a CA file hashes like the same embedded CA")
+
+	for desc, change := range map[string]func(*clientcmdapi.Config){
+		"server":      func(c *clientcmdapi.Config) { c.Clusters["eu-1"].Server = "https://eu-2.example" },
+		"CA":          func(c *clientcmdapi.Config) { c.Clusters["eu-1"].CertificateAuthorityData = testCertPEM(t, time.Now()) },
+		"insecure":    func(c *clientcmdapi.Config) { c.Clusters["eu-1"].InsecureSkipTLSVerify = true },
+		"client ID":   func(c *clientcmdapi.Config) { c.AuthInfos["eu-1"].Exec.Args[3] = "--oidc-client-id=other" },
+		"impersonate": func(c *clientcmdapi.Config) { c.AuthInfos["eu-1"].Impersonate = "admin" },
+	} {
+		cfg := fingerprintConfig(ca)
+		change(cfg)
+		g.Expect(contextFingerprint(cfg, "eu-1")).NotTo(Equal(base), desc)
+	}
+
+	broken := fingerprintConfig([]byte("garbage"))
+	_, err = contextFingerprint(broken, "eu-1")
+	g.Expect(err).To(MatchError(ContainSubstring(`CA of cluster "eu-1"`)))
+}
+
+func TestAuthIdentity_IgnoresSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	a, err := authIdentity(&clientcmdapi.AuthInfo{Token: "one"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(authIdentity(&clientcmdapi.AuthInfo{Token: "two"})).To(Equal(a))
+
+	exec := func(profile string) *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{Command: "aws", Args: []string{"eks", "get-token"},
+			Env: []clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: profile}}}}
+	}
+	dev, err := authIdentity(exec("dev"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(authIdentity(exec("prod"))).NotTo(Equal(dev), "exec plugins are identified by their environment")
+}
+
+func TestRunFingerprint_File(t *testing.T) {
+	g := NewWithT(t)
+	ca := testCertPEM(t, time.Now().AddDate(1, 0, 0))
+	cfg := fingerprintConfig(ca)
+	cfg.Contexts["renamed"] = cfg.Contexts["eu-1"].DeepCopy()
+	path := filepath.Join(t.TempDir(), "shared.yaml")
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+
+	viper.Set("output", "json")
+	t.Cleanup(func() { viper.Set("output", nil) })
+	var buf bytes.Buffer
+	fingerprintCmd.SetOut(&buf)
+	t.Cleanup(func() { fingerprintCmd.SetOut(nil) })
+	g.Expect(runFingerprint(fingerprintCmd, []string{path})).To(Succeed())
+
+	var result output.FingerprintResult
+	g.Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+	base, _ := contextFingerprint(cfg, "eu-1")
+	g.Expect(result.Kubeconfig).To(Equal(path))
+	g.Expect(result.Contexts).To(Equal([]output.FingerprintEntry{
+		{Context: "eu-1", Fingerprint: base},
+		{Context: "renamed", Fingerprint: base},
+	}))
+	g.Expect(result.Combined).To(HaveLen(16))
+}
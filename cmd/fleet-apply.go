@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var fleetApplyCmd = &cobra.Command{
+	Use:   "apply -f <manifest>",
+	Short: "Apply a manifest to many managed clusters",
+	Long: `Applies the objects of a manifest to every managed cluster matching
+--selector and --context, with server-side apply and "cloudctl" as field
+manager, and reports the outcome per cluster. Objects without a namespace
+go to --namespace, or "default".
+
+--dry-run sends the requests with server-side dry run: the clusters
+validate and admit the objects, but nothing is persisted. The command exits
+with an error when any object could not be applied.
+
+Examples:
+  cloudctl fleet apply -f manifest.yaml --selector tier=edge --dry-run
+  cloudctl fleet apply -f manifest.yaml --selector tier=edge
+  kustomize build . | cloudctl fleet apply -f - --context 'qa-*'`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runFleetApply,
+}
+
+// fleetFieldManager is the server-side apply field manager of fleet apply.
+const fleetFieldManager = "cloudctl"
+
+func init() {
+	fleetApplyCmd.Flags().StringP("filename", "f", "", "Manifest to apply, - for stdin")
+	fleetApplyCmd.Flags().StringP("selector", "l", "", "Only apply to clusters whose labels match this label selector")
+	fleetApplyCmd.Flags().StringSliceP("context", "c", nil, "Only apply to contexts matching this glob pattern, can be repeated (default: all managed contexts)")
+	fleetApplyCmd.Flags().StringP("namespace", "n", "", "Namespace for objects that have none (default: default)")
+	fleetApplyCmd.Flags().Bool("dry-run", false, "Use server-side dry run; nothing is persisted")
+	fleetApplyCmd.Flags().Int("concurrency", 10, "Number of clusters applied to in parallel")
+	_ = fleetApplyCmd.MarkFlagRequired("filename")
+}
+
+// readManifest decodes the YAML or JSON documents of r into objects. Empty
+// documents are skipped; every object needs apiVersion, kind and name.
+func readManifest(r io.Reader) ([]*unstructured.Unstructured, error) {
+	dec := k8syaml.NewYAMLOrJSONDecoder(r, 4096)
+	var objs []*unstructured.Unstructured
+	for i := 1; ; i++ {
+		var raw map[string]any
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid manifest document %d: %w", i, err)
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: raw}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("invalid manifest document %d: apiVersion, kind and metadata.name are required", i)
+		}
+		objs = append(objs, obj)
+	}
+	if len(objs) == 0 {
+		return nil, errors.New("the manifest holds no objects")
+	}
+	return objs, nil
+}
+
+// applyObjects applies objs to the cluster of cfg with server-side apply and
+// returns the outcome per object. Objects without a namespace go to
+// namespace. It is a variable so tests can avoid live clusters.
+var applyObjects = func(ctx context.Context, cfg *rest.Config, objs []*unstructured.Unstructured, namespace string, dryRun bool) ([]output.FleetApplyObject, error) {
+	cfg = withTracing(cfg)
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	opts := metav1.PatchOptions{FieldManager: fleetFieldManager}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	results := make([]output.FleetApplyObject, 0, len(objs))
+	for _, obj := range objs {
+		obj = obj.DeepCopy()
+		res := output.FleetApplyObject{Kind: obj.GetKind(), Name: obj.GetName()}
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		var client dynamic.ResourceInterface = dyn.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			res.Namespace = cmp.Or(obj.GetNamespace(), namespace, metav1.NamespaceDefault)
+			obj.SetNamespace(res.Namespace)
+			client = dyn.Resource(mapping.Resource).Namespace(res.Namespace)
+		}
+		data, err := obj.MarshalJSON()
+		if err == nil {
+			_, err = client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, opts)
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func runFleetApply(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	var manifest io.Reader = cmd.InOrStdin()
+	if file := viper.GetString("filename"); file != "-" {
+		f, err := os.Open(file) // #nosec G304 -- the user names the manifest
+		if err != nil {
+			return fmt.Errorf("failed to open manifest: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		manifest = f
+	}
+	objs, err := readManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	names, err := fleetContexts(cfg, viper.GetStringSlice("context"))
+	if err != nil {
+		return err
+	}
+	if names, err = matchingContexts(cfg, names, viper.GetString("selector")); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no managed contexts match in %s", displayKubeconfig(path))
+	}
+
+	dryRun := viper.GetBool("dry-run")
+	namespace := viper.GetString("namespace")
+	slog.Info("applying manifest", "clusters", len(names), "objects", len(objs), "dryRun", dryRun)
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	step, stop := printer.StartProgress("Applying to clusters", len(names))
+	result := output.FleetApplyResult{DryRun: dryRun, Clusters: make([]output.FleetApplyCluster, len(names))}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		result.Clusters[i] = output.FleetApplyCluster{Context: name, Error: "not applied"}
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return err
+	}
+	defer tun.Close()
+
+	forEachContext(cmd.Context(), names, viper.GetInt("concurrency"), func(ctx context.Context, name string) {
+		c := output.FleetApplyCluster{Context: name}
+		restCfg, err := configWithContext(name, path)
+		if err == nil {
+			err = tun.apply(ctx, restCfg, contextClusterLabels(cfg, name))
+		}
+		if err == nil {
+			c.Objects, err = applyObjects(ctx, restCfg, objs, namespace, dryRun)
+		}
+		if err != nil {
+			slog.Debug("fleet apply failed", "context", name, "error", err)
+			c.Error = err.Error()
+		}
+		result.Clusters[index[name]] = c
+		step()
+	})
+	stop()
+
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range result.Clusters {
+		if c.Error != "" || c.Failed() > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cluster(s) failed", failed, len(names))
+	}
+	return nil
+}
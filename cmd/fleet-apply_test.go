@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: edge
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: edge
+data:
+  mode: fast
+---
+`
+
+func TestReadManifest(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := readManifest(strings.NewReader(testManifest))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(objs).To(HaveLen(2))
+	g.Expect(objs[1].GetKind()).To(Equal("ConfigMap"))
+	g.Expect(objs[1].GetNamespace()).To(Equal("edge"))
+
+	_, err = readManifest(strings.NewReader("apiVersion: v1\nkind: ConfigMap\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("document 1: apiVersion, kind and metadata.name are required")))
+	_, err = readManifest(strings.NewReader("---\n"))
+	g.Expect(err).To(MatchError("the manifest holds no objects"))
+}
+
+func TestRunFleetApply(t *testing.T) {
+	g := NewWithT(t)
+	writeTestKubeconfig(t, newManagedCfg("edge-1", "edge-2", "dark"))
+	manifest := filepath.Join(t.TempDir(), "manifest.yaml")
+	g.Expect(os.WriteFile(manifest, []byte(testManifest), 0o600)).To(Succeed())
+	viper.Set("filename", manifest)
+	viper.Set("dry-run", true)
+	t.Cleanup(func() {
+		viper.Set("filename", nil)
+		viper.Set("dry-run", nil)
+	})
+
+	prev := applyObjects
+	applyObjects = func(_ context.Context, cfg *rest.Config, objs []*unstructured.Unstructured, namespace string, dryRun bool) ([]output.FleetApplyObject, error) {
+		g.Expect(dryRun).To(BeTrue())
+		g.Expect(objs).To(HaveLen(2))
+		switch cfg.Host {
+		case "https://dark.example":
+			return nil, errors.New("connection refused")
+		case "https://edge-2.example":
+			return []output.FleetApplyObject{
+				{Kind: "Namespace", Name: "edge"},
+				{Kind: "ConfigMap", Namespace: "edge", Name: "settings", Error: "admission webhook denied the request"},
+			}, nil
+		}
+		return []output.FleetApplyObject{{Kind: "Namespace", Name: "edge"}, {Kind: "ConfigMap", Namespace: "edge", Name: "settings"}}, nil
+	}
+	t.Cleanup(func() { applyObjects = prev })
+
+	var out bytes.Buffer
+	fleetApplyCmd.SetOut(&out)
+	fleetApplyCmd.SetContext(context.Background())
+	t.Cleanup(func() { fleetApplyCmd.SetOut(nil) })
+
+	err := runFleetApply(fleetApplyCmd, nil)
+	g.Expect(err).To(MatchError("2 of 3 cluster(s) failed"))
+	g.Expect(out.String()).To(Equal(
+		"Dry run, nothing was changed.\n" +
+			"\n" +
+			"CONTEXT  APPLIED  FAILED\n" +
+			"dark     -        -\n" +
+			"edge-1   2        0\n" +
+			"edge-2   1        1\n" +
+			"\n" +
+			"dark: connection refused\n" +
+			"edge-2: ConfigMap edge/settings: admission webhook denied the request\n"))
+
+	viper.Set("context", []string{"edge-1"})
+	t.Cleanup(func() { viper.Set("context", nil) })
+	g.Expect(runFleetApply(fleetApplyCmd, nil)).To(Succeed())
+
+	viper.Set("selector", "tier=edge")
+	t.Cleanup(func() { viper.Set("selector", nil) })
+	g.Expect(runFleetApply(fleetApplyCmd, nil)).To(MatchError(ContainSubstring("no managed contexts match")))
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var fleetGetCmd = &cobra.Command{
+	Use:   "get <resource>",
+	Short: "List a resource type across many managed clusters",
+	Long: `Lists a resource type on every managed cluster matching --selector and
+--context and prints one table with a CLUSTER column. The resource is named
+like in kubectl get: plural, singular or short name, optionally with its
+group (daemonsets, ds, certificates.cert-manager.io).
+
+The columns are rendered by the API servers, as for kubectl get; --wide adds
+the columns kubectl shows with -o wide, such as container images. Without
+-n every namespace is listed. Use -o json for scripting.
+
+Examples:
+  # Which clusters still run the old node-exporter image?
+  cloudctl fleet get daemonsets -n monitoring --wide --selector env=prod
+
+  cloudctl fleet get nodes --context 'qa-*' -o json`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: bindCommandFlags,
+	RunE:    runFleetGet,
+}
+
+func init() {
+	fleetGetCmd.Flags().StringP("selector", "l", "", "Only query clusters whose labels match this label selector")
+	fleetGetCmd.Flags().StringSliceP("context", "c", nil, "Only query contexts matching this glob pattern, can be repeated (default: all managed contexts)")
+	fleetGetCmd.Flags().StringP("namespace", "n", "", "Namespace to list (default: all namespaces)")
+	fleetGetCmd.Flags().Bool("wide", false, "Include the additional columns of kubectl get -o wide")
+	fleetGetCmd.Flags().Int("concurrency", 10, "Number of clusters queried in parallel")
+}
+
+// listTable lists resource in namespace (all namespaces when empty) and
+// returns it as a server-rendered Table with object metadata. It is a
+// variable so tests can avoid live clusters.
+var listTable = func(ctx context.Context, cfg *rest.Config, resource, namespace string) (*metav1.Table, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(withTracing(cfg))
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)), dc, nil)
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join("/apis", gvr.Group, gvr.Version)
+	if gvr.Group == "" {
+		p = path.Join("/api", gvr.Version)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+		p = path.Join(p, "namespaces", namespace)
+	}
+	raw, err := dc.RESTClient().Get().AbsPath(p, gvr.Resource).
+		SetHeader("Accept", "application/json;as=Table;v=v1;g=meta.k8s.io").
+		Param("includeObject", string(metav1.IncludeMetadata)).
+		Do(ctx).Raw()
+	if err != nil {
+		return nil, err
+	}
+	var table metav1.Table
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return nil, fmt.Errorf("failed to decode table: %w", err)
+	}
+	return &table, nil
+}
+
+func runFleetGet(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	names, err := fleetContexts(cfg, viper.GetStringSlice("context"))
+	if err != nil {
+		return err
+	}
+	if names, err = matchingContexts(cfg, names, viper.GetString("selector")); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no managed contexts match in %s", displayKubeconfig(path))
+	}
+
+	resource, namespace := args[0], viper.GetString("namespace")
+	slog.Info("listing resources", "resource", resource, "namespace", namespace, "clusters", len(names))
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	step, stop := printer.StartProgress("Querying clusters", len(names))
+	tables := make([]*metav1.Table, len(names))
+	errs := make([]error, len(names))
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		errs[i] = errors.New("not queried")
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return err
+	}
+	defer tun.Close()
+
+	forEachContext(cmd.Context(), names, viper.GetInt("concurrency"), func(ctx context.Context, name string) {
+		i := index[name]
+		restCfg, err := configWithContext(name, path)
+		if err == nil {
+			err = tun.apply(ctx, restCfg, contextClusterLabels(cfg, name))
+		}
+		if err == nil {
+			tables[i], err = listTable(ctx, restCfg, resource, namespace)
+		}
+		if err != nil {
+			slog.Debug("fleet get failed", "context", name, "error", err)
+		}
+		errs[i] = err
+		step()
+	})
+	stop()
+
+	result := fleetGetResult(resource, names, tables, errs, viper.GetBool("wide"))
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d of %d cluster(s) could not be queried", len(result.Failed), len(names))
+	}
+	return nil
+}
+
+// fleetGetResult merges the tables of the clusters into one. Columns are
+// matched by name, so clusters whose servers render different columns line
+// up; missing cells are empty. Columns only shown by kubectl -o wide are
+// left out unless wide is set.
+func fleetGetResult(resource string, names []string, tables []*metav1.Table, errs []error, wide bool) output.FleetGetResult {
+	result := output.FleetGetResult{Resource: resource, Columns: []string{}, Rows: []output.FleetGetRow{}}
+	for i, table := range tables {
+		if errs[i] != nil {
+			result.Failed = append(result.Failed, output.FleetGetError{Cluster: names[i], Error: errs[i].Error()})
+			continue
+		}
+		for _, c := range table.ColumnDefinitions {
+			if (wide || c.Priority == 0) && !slices.Contains(result.Columns, c.Name) {
+				result.Columns = append(result.Columns, c.Name)
+			}
+		}
+	}
+	for i, table := range tables {
+		if errs[i] != nil {
+			continue
+		}
+		for _, row := range table.Rows {
+			r := output.FleetGetRow{Cluster: names[i], Cells: make([]string, len(result.Columns))}
+			for j, c := range table.ColumnDefinitions {
+				if k := slices.Index(result.Columns, c.Name); k >= 0 && j < len(row.Cells) {
+					r.Cells[k] = tableCell(row.Cells[j])
+				}
+			}
+			var m metav1.PartialObjectMetadata
+			if len(row.Object.Raw) > 0 && json.Unmarshal(row.Object.Raw, &m) == nil {
+				r.Namespace = m.Namespace
+			}
+			result.Rows = append(result.Rows, r)
+		}
+	}
+	return result
+}
+
+// tableCell formats a Table cell like kubectl get.
+func tableCell(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return t
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+)
+
+// testTable returns a DaemonSet-like table with one row per namespace/name
+// pair and the given image.
+func testTable(image string, objects ...string) *metav1.Table {
+	table := &metav1.Table{ColumnDefinitions: []metav1.TableColumnDefinition{
+		{Name: "Name"}, {Name: "Ready"}, {Name: "Images", Priority: 1},
+	}}
+	for i := 0; i < len(objects); i += 2 {
+		meta, _ := json.Marshal(metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: objects[i], Name: objects[i+1]}})
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells:  []any{objects[i+1], float64(3), image},
+			Object: runtime.RawExtension{Raw: meta},
+		})
+	}
+	return table
+}
+
+func TestRunFleetGet(t *testing.T) {
+	g := NewWithT(t)
+	writeTestKubeconfig(t, newManagedCfg("eu", "us", "dark"))
+	viper.Set("namespace", "monitoring")
+	viper.Set("wide", true)
+	t.Cleanup(func() {
+		viper.Set("namespace", nil)
+		viper.Set("wide", nil)
+	})
+
+	prev := listTable
+	listTable = func(_ context.Context, cfg *rest.Config, resource, namespace string) (*metav1.Table, error) {
+		g.Expect(resource).To(Equal("ds"))
+		g.Expect(namespace).To(Equal("monitoring"))
+		switch cfg.Host {
+		case "https://eu.example":
+			return testTable("node-exporter:1.7", "monitoring", "node-exporter"), nil
+		case "https://us.example":
+			return testTable("node-exporter:1.8", "monitoring", "node-exporter", "monitoring", "fluent-bit"), nil
+		}
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { listTable = prev })
+
+	var out bytes.Buffer
+	fleetGetCmd.SetOut(&out)
+	fleetGetCmd.SetContext(context.Background())
+	t.Cleanup(func() { fleetGetCmd.SetOut(nil) })
+
+	err := runFleetGet(fleetGetCmd, []string{"ds"})
+	g.Expect(err).To(MatchError("1 of 3 cluster(s) could not be queried"))
+	g.Expect(out.String()).To(Equal(
+		"CLUSTER  NAMESPACE   NAME           READY  IMAGES\n" +
+			"eu       monitoring  node-exporter  3      node-exporter:1.7\n" +
+			"us       monitoring  node-exporter  3      node-exporter:1.8\n" +
+			"us       monitoring  fluent-bit     3      node-exporter:1.8\n" +
+			"\n" +
+			"dark: connection refused\n"))
+
+	out.Reset()
+	viper.Set("wide", false)
+	viper.Set("context", []string{"eu"})
+	viper.Set("output", "json")
+	t.Cleanup(func() { viper.Set("context", nil) })
+	g.Expect(runFleetGet(fleetGetCmd, []string{"ds"})).To(Succeed())
+	g.Expect(out.String()).To(MatchJSON(`{
+		"resource": "ds",
+		"columns": ["Name", "Ready"],
+		"rows": [{"cluster": "eu", "namespace": "monitoring", "cells": ["node-exporter", "3"]}]
+	}`))
+}
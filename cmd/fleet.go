@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Run operations across many managed clusters",
+}
+
+func init() {
+	fleetCmd.AddCommand(fleetApplyCmd)
+	fleetCmd.AddCommand(fleetGetCmd)
+}
+
+// fleetContexts returns the sorted names of the managed contexts in cfg. When
+// patterns are given, only contexts matching at least one of them are kept; a
+// pattern is a glob (path.Match syntax) tried against the context name.
+func fleetContexts(cfg *clientcmdapi.Config, patterns []string) ([]string, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid context pattern %q: %w", p, err)
+		}
+	}
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+		if !hasManagedCluster(cfg.Contexts[name]) {
+			continue
+		}
+		if len(patterns) > 0 && !slices.ContainsFunc(patterns, func(p string) bool {
+			ok, _ := path.Match(p, name)
+			return ok
+		}) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// matchingContexts returns the names whose cluster labels in cfg match
+// selector, in Kubernetes label selector syntax. An empty selector keeps all
+// names.
+func matchingContexts(cfg *clientcmdapi.Config, names []string, selector string) ([]string, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --selector: %w", err)
+	}
+	var matched []string
+	for _, name := range names {
+		if sel.Matches(labels.Set(contextClusterLabels(cfg, name))) {
+			matched = append(matched, name)
+		}
+	}
+	return matched, nil
+}
+
+// forEachContext calls fn for every name with at most concurrency calls in
+// flight. Names not yet started when ctx is cancelled are skipped.
+func forEachContext(ctx context.Context, names []string, concurrency int, fn func(ctx context.Context, name string)) {
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for _, name := range names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			fn(ctx, name)
+		})
+	}
+	wg.Wait()
+}
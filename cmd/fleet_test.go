@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// writeFleetKubeconfig writes a kubeconfig with one managed context per name
+// plus an unmanaged "local" context, and points viper at it.
+func TestFleetContexts(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("prod-eu", "prod-us", "qa"))
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	names, err := fleetContexts(cfg, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(names).To(Equal([]string{"prod-eu", "prod-us", "qa"}), "contexts are managed by their cluster")
+
+	names, err = fleetContexts(cfg, []string{"prod-*", "qa"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(names).To(Equal([]string{"prod-eu", "prod-us", "qa"}))
+
+	names, err = fleetContexts(cfg, []string{"*-us"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(names).To(Equal([]string{"prod-us"}))
+
+	_, err = fleetContexts(cfg, []string{"["})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid context pattern")))
+}
+
+func TestForEachContext_BoundsConcurrency(t *testing.T) {
+	g := NewWithT(t)
+
+	var inFlight, peak, calls atomic.Int32
+	forEachContext(context.Background(), []string{"a", "b", "c", "d", "e"}, 2, func(context.Context, string) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		calls.Add(1)
+		inFlight.Add(-1)
+	})
+	g.Expect(calls.Load()).To(Equal(int32(5)))
+	g.Expect(peak.Load()).To(BeNumerically("<=", 2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls.Store(0)
+	forEachContext(ctx, []string{"a", "b", "c"}, 1, func(context.Context, string) { calls.Add(1) })
+	g.Expect(calls.Load()).To(BeNumerically("<=", 1))
+}
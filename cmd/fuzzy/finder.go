@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package fuzzy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrAborted is returned by Select when the user cancels the selection.
+var ErrAborted = errors.New("selection aborted")
+
+// maxVisible is the number of matches rendered below the prompt.
+const maxVisible = 10
+
+var (
+	styleCursor   = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
+	styleKeywords = lipgloss.NewStyle().Faint(true)
+	styleCount    = lipgloss.NewStyle().Faint(true)
+)
+
+type model struct {
+	input    textinput.Model
+	items    []Item
+	matches  []Match
+	cursor   int
+	selected string
+	aborted  bool
+}
+
+func newModel(prompt, query string, items []Item) model {
+	ti := textinput.New()
+	ti.Prompt = prompt + "> "
+	ti.SetValue(query)
+	ti.Focus()
+	return model{input: ti, items: items, matches: Filter(query, items)}
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.aborted = true
+			return m, tea.Quit
+		case tea.KeyEnter:
+			if len(m.matches) > 0 {
+				m.selected = m.matches[m.cursor].Item.Name
+				return m, tea.Quit
+			}
+			return m, nil
+		case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+			if m.cursor < min(len(m.matches), maxVisible)-1 {
+				m.cursor++
+			}
+			return m, nil
+		}
+	}
+
+	prev := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != prev {
+		m.matches = Filter(m.input.Value(), m.items)
+		m.cursor = 0
+	}
+	return m, cmd
+}
+
+func (m model) View() string {
+	if m.selected != "" || m.aborted {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(m.input.View())
+	b.WriteString("\n")
+	b.WriteString(styleCount.Render(fmt.Sprintf("  %d/%d", len(m.matches), len(m.items))))
+	b.WriteString("\n")
+	for i, mt := range m.matches[:min(len(m.matches), maxVisible)] {
+		line := "  " + mt.Item.Name
+		if i == m.cursor {
+			line = styleCursor.Render("> " + mt.Item.Name)
+		}
+		b.WriteString(line)
+		if len(mt.Item.Keywords) > 0 {
+			b.WriteString(" " + styleKeywords.Render(strings.Join(mt.Item.Keywords, " ")))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Select runs an interactive fuzzy finder over items, pre-filled with query,
+// reading keys from in and rendering to out (typically stderr so stdout stays
+// clean for scripting). It returns the Name of the chosen item, or ErrAborted.
+func Select(prompt, query string, items []Item, in io.Reader, out io.Writer) (string, error) {
+	prog := tea.NewProgram(newModel(prompt, query, items), tea.WithInput(in), tea.WithOutput(out))
+	final, err := prog.Run()
+	if err != nil {
+		return "", err
+	}
+	m := final.(model)
+	if m.aborted || m.selected == "" {
+		return "", ErrAborted
+	}
+	return m.selected, nil
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fuzzy implements an fzf-like fuzzy selector used to pick kubeconfig
+// contexts and clusters without requiring an external fzf installation.
+package fuzzy
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Item is a selectable candidate. Name is what gets returned on selection;
+// Keywords (e.g. cluster label values) are also searched but rank below a
+// match on Name.
+type Item struct {
+	Name     string
+	Keywords []string
+}
+
+// Match is an Item that matched a query, together with its score.
+type Match struct {
+	Item  Item
+	Score int
+}
+
+// Filter returns the items matching query, best match first. A query matches
+// a string when all of its runes occur in order (case-insensitive), as in fzf.
+// Whitespace separates terms which must all match. An empty query matches
+// every item, preserving input order.
+func Filter(query string, items []Item) []Match {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		matches := make([]Match, 0, len(items))
+		for _, it := range items {
+			matches = append(matches, Match{Item: it})
+		}
+		return matches
+	}
+	matches := make([]Match, 0, len(items))
+	for _, it := range items {
+		total := 0
+		ok := true
+		for _, term := range terms {
+			s, found := scoreItem(term, it)
+			if !found {
+				ok = false
+				break
+			}
+			total += s
+		}
+		if ok {
+			matches = append(matches, Match{Item: it, Score: total})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(len(a.Item.Name), len(b.Item.Name))
+	})
+	return matches
+}
+
+// nameBonus makes a match on the item name win over a keyword match.
+const nameBonus = 100
+
+func scoreItem(term string, it Item) (int, bool) {
+	best, found := score(term, it.Name)
+	if found {
+		best += nameBonus
+	}
+	for _, kw := range it.Keywords {
+		if s, ok := score(term, kw); ok && (!found || s > best) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// score returns how well term matches s as a subsequence. Runes that are
+// consecutive or start a word (after '-', ':', '_', '.', '/' or a space)
+// score higher; gaps between matched runes are penalised.
+func score(term, s string) (int, bool) {
+	if term == "" {
+		return 0, true
+	}
+	hay := []rune(strings.ToLower(s))
+	needle := []rune(term)
+	total, ni, last := 0, 0, -1
+	for i, r := range hay {
+		if ni == len(needle) {
+			break
+		}
+		if r != needle[ni] {
+			continue
+		}
+		if i == 0 || last == i-1 || isBoundary(hay[i-1]) {
+			total += 8
+		} else {
+			total++
+		}
+		if last >= 0 {
+			total -= min(i-last-1, 3)
+		}
+		last = i
+		ni++
+	}
+	if ni < len(needle) {
+		return 0, false
+	}
+	return total, true
+}
+
+func isBoundary(r rune) bool {
+	return r == '-' || r == ':' || r == '_' || r == '.' || r == '/' || unicode.IsSpace(r)
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package fuzzy
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	. "github.com/onsi/gomega"
+)
+
+func names(ms []Match) []string {
+	out := make([]string, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, m.Item.Name)
+	}
+	return out
+}
+
+func TestFilter(t *testing.T) {
+	g := NewWithT(t)
+	items := []Item{
+		{Name: "cloudctl:prod-eu", Keywords: []string{"europe", "production"}},
+		{Name: "cloudctl:prod-us", Keywords: []string{"america", "production"}},
+		{Name: "cloudctl:qa-eu", Keywords: []string{"europe", "qa"}},
+		{Name: "minikube"},
+	}
+
+	g.Expect(names(Filter("", items))).To(Equal([]string{"cloudctl:prod-eu", "cloudctl:prod-us", "cloudctl:qa-eu", "minikube"}))
+	g.Expect(names(Filter("peu", items))).To(HaveExactElements("cloudctl:prod-eu"), "subsequence across word boundary")
+	g.Expect(names(Filter("PROD", items))).To(ConsistOf("cloudctl:prod-eu", "cloudctl:prod-us"), "case-insensitive")
+	g.Expect(names(Filter("europe", items))).To(ConsistOf("cloudctl:prod-eu", "cloudctl:qa-eu"), "label values are searched")
+	g.Expect(names(Filter("prod europe", items))).To(HaveExactElements("cloudctl:prod-eu"), "all terms must match")
+	g.Expect(Filter("xyz", items)).To(BeEmpty())
+}
+
+func TestFilterRanksNameAboveKeywords(t *testing.T) {
+	g := NewWithT(t)
+	items := []Item{
+		{Name: "cloudctl:a", Keywords: []string{"staging"}},
+		{Name: "cloudctl:staging"},
+	}
+	g.Expect(names(Filter("staging", items))[0]).To(Equal("cloudctl:staging"))
+}
+
+func TestFilterPrefersContiguousMatches(t *testing.T) {
+	g := NewWithT(t)
+	items := []Item{
+		{Name: "e-u-x"},
+		{Name: "eux"},
+	}
+	g.Expect(names(Filter("eu", items))[0]).To(Equal("eux"))
+}
+
+func TestModelNavigationAndSelect(t *testing.T) {
+	g := NewWithT(t)
+	items := []Item{{Name: "a-one"}, {Name: "a-two"}, {Name: "b"}}
+
+	var m tea.Model = newModel("context", "a", items)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown}) // clamped to the last match
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	g.Expect(cmd).NotTo(BeNil())
+	g.Expect(m.(model).selected).To(Equal("a-two"))
+
+	m = newModel("context", "", items)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	g.Expect(m.(model).aborted).To(BeTrue())
+}
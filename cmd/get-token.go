@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+)
+
+var getTokenCmd = &cobra.Command{
+	Use:   "get-token",
+	Short: "Print an OIDC token as a client-go ExecCredential (kubectl credential plugin)",
+	Long: `Implements the client.authentication.k8s.io ExecCredential protocol so that
+kubeconfig user entries can reference cloudctl itself as credential plugin.
+sync writes such entries with --auth-type=cloudctl.
+
+The flags are compatible with "kubelogin get-token". A cached token is used
+while valid and refreshed with its refresh token when expired. Otherwise an
+interactive login is started: in a browser via the authorization code flow
+(with PKCE, redirecting to a local port), or with the device code flow on
+headless hosts (see --grant-type). Tokens from "cloudctl login --device"
+are picked up from the same cache. With --non-interactive (or
+CLOUDCTL_NON_INTERACTIVE=true) no login is started and get-token fails when
+there is no usable cached or refreshable token.
+
+This command is meant to be run by kubectl, not by hand:
+
+  users:
+  - name: cloudctl:prod-eu
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1
+        command: cloudctl
+        args:
+        - get-token
+        - --oidc-issuer-url=https://auth.example.com
+        - --oidc-client-id=greenhouse`,
+	Args: cobra.NoArgs,
+	// Unknown kubelogin flags (e.g. from --kubelogin-extra-args) are ignored
+	// so existing exec entries keep working when switched to cloudctl.
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+	RunE:               runGetToken,
+}
+
+func init() {
+	f := getTokenCmd.Flags()
+	f.String("oidc-issuer-url", "", "Issuer URL of the OIDC provider (required)")
+	f.String("oidc-client-id", "", "Client ID registered at the OIDC provider (required)")
+	f.String("oidc-client-secret", "", "Client secret, if the client is confidential")
+	f.StringArray("oidc-extra-scope", nil, "Scope to request in addition to openid, can be repeated")
+	f.String("oidc-auth-request-extra-params", "", "Extra authorization request parameters as k1=v1,k2=v2 (e.g. connector_id=ldap)")
+	f.String("certificate-authority-data", "", "Base64-encoded PEM CA bundle of the OIDC provider")
+	f.String("token-cache-dir", defaultCloudctlTokenCacheDir(), "Directory for cached tokens")
+	f.String("token-store", tokenStoreFile, "Where tokens are kept: file (in --token-cache-dir) or keyring (the OS credential store)")
+	f.String("grant-type", "auto", "Interactive login flow: auto, authcode or device-code")
+	f.StringArray("listen-address", oidc.DefaultListenAddresses, "Loopback address for the authcode redirect, tried in order")
+	f.Bool("skip-open-browser", false, "Print the login URL instead of opening a browser")
+}
+
+func runGetToken(cmd *cobra.Command, _ []string) error {
+	f := cmd.Flags()
+	var params oidc.Params
+	params.IssuerURL, _ = f.GetString("oidc-issuer-url")
+	params.ClientID, _ = f.GetString("oidc-client-id")
+	params.ClientSecret, _ = f.GetString("oidc-client-secret")
+	params.ExtraScopes, _ = f.GetStringArray("oidc-extra-scope")
+	extraParams, _ := f.GetString("oidc-auth-request-extra-params")
+	params.ExtraParams = parseExtraParams(extraParams)
+	if params.IssuerURL == "" || params.ClientID == "" {
+		return fmt.Errorf("--oidc-issuer-url and --oidc-client-id are required")
+	}
+
+	var caData []byte
+	if v, _ := f.GetString("certificate-authority-data"); v != "" {
+		data, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("invalid --certificate-authority-data: %w", err)
+		}
+		caData = data
+	}
+	cacheDir, _ := f.GetString("token-cache-dir")
+	storeKind, _ := f.GetString("token-store")
+	store, err := newTokenStore(storeKind, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	tok, err := store.Load(params)
+	if err != nil {
+		slog.Debug("ignoring unreadable token cache", "error", err)
+		tok = nil
+	}
+	if !tok.Valid(time.Now()) {
+		httpClient, err := oidcHTTPClient(caData)
+		if err != nil {
+			return err
+		}
+		provider, err := oidc.Discover(cmd.Context(), httpClient, params.IssuerURL)
+		if err != nil {
+			return err
+		}
+		tok, err = refreshOrLogin(cmd, provider, params, tok)
+		if err != nil {
+			return err
+		}
+		if err := store.Save(params, tok); err != nil {
+			slog.Warn("failed to cache token", "store", storeKind, "error", err)
+		}
+	}
+	return writeExecCredential(cmd.OutOrStdout(), tok)
+}
+
+// refreshOrLogin renews tok with its refresh token, falling back to an
+// interactive login when there is no refresh token or it was rejected.
+func refreshOrLogin(cmd *cobra.Command, provider *oidc.Provider, params oidc.Params, tok *oidc.Token) (*oidc.Token, error) {
+	if tok != nil && tok.RefreshToken != "" {
+		refreshed, err := provider.Refresh(cmd.Context(), params, tok.RefreshToken)
+		if err == nil {
+			return refreshed, nil
+		}
+		slog.Debug("token refresh failed, logging in again", "error", err)
+	}
+	if nonInteractive() {
+		return nil, fmt.Errorf("no valid token for %s is cached and --non-interactive forbids an interactive login; run cloudctl login --device first", params.IssuerURL)
+	}
+
+	errW := cmd.ErrOrStderr()
+	grantType, _ := cmd.Flags().GetString("grant-type")
+	if grantType == "auto" {
+		grantType = "authcode"
+		if isHeadless() && provider.DeviceAuthorizationEndpoint != "" {
+			grantType = "device-code"
+		}
+	}
+	switch grantType {
+	case "authcode":
+		addrs, _ := cmd.Flags().GetStringArray("listen-address")
+		skipBrowser, _ := cmd.Flags().GetBool("skip-open-browser")
+		opts := oidc.AuthCodeOptions{
+			ListenAddresses: addrs,
+			Prompt: func(u string) {
+				_, _ = fmt.Fprintf(errW, "Open %s in your browser to log in.\n", u)
+			},
+		}
+		if !skipBrowser {
+			opts.OpenURL = openBrowser
+		}
+		return provider.AuthCode(cmd.Context(), params, opts)
+	case "device-code":
+		da, err := provider.StartDevice(cmd.Context(), params)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = fmt.Fprintf(errW, "To sign in, open %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+		return provider.PollDevice(cmd.Context(), params, da)
+	default:
+		return nil, fmt.Errorf("invalid --grant-type %q: must be one of \"auto\", \"authcode\" or \"device-code\"", grantType)
+	}
+}
+
+// writeExecCredential prints tok as an ExecCredential in the API version
+// kubectl asked for via KUBERNETES_EXEC_INFO (v1 unless it requests v1beta1,
+// which shares the same status fields).
+func writeExecCredential(w io.Writer, tok *oidc.Token) error {
+	apiVersion := clientauthenticationv1.SchemeGroupVersion.String()
+	if info := os.Getenv("KUBERNETES_EXEC_INFO"); info != "" {
+		var meta metav1.TypeMeta
+		if err := json.Unmarshal([]byte(info), &meta); err == nil && meta.APIVersion != "" {
+			apiVersion = meta.APIVersion
+		}
+	}
+	cred := clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{Kind: "ExecCredential", APIVersion: apiVersion},
+		Status:   &clientauthenticationv1.ExecCredentialStatus{Token: tok.IDToken},
+	}
+	if !tok.Expiry.IsZero() {
+		exp := metav1.NewTime(tok.Expiry)
+		cred.Status.ExpirationTimestamp = &exp
+	}
+	return json.NewEncoder(w).Encode(cred)
+}
+
+// isHeadless reports whether no local browser can be expected: an SSH session
+// or, on Linux, no graphical display.
+func isHeadless() bool {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return true
+	}
+	return runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// openBrowser opens u in the user's default browser.
+func openBrowser(u string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", u)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		c = exec.Command("xdg-open", u)
+	}
+	return c.Start()
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteExecCredential(t *testing.T) {
+	g := NewWithT(t)
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Setenv("KUBERNETES_EXEC_INFO", "")
+	var buf bytes.Buffer
+	g.Expect(writeExecCredential(&buf, &oidc.Token{IDToken: "id", Expiry: exp})).To(Succeed())
+	var cred clientauthenticationv1.ExecCredential
+	g.Expect(json.Unmarshal(buf.Bytes(), &cred)).To(Succeed())
+	g.Expect(cred.APIVersion).To(Equal("client.authentication.k8s.io/v1"))
+	g.Expect(cred.Kind).To(Equal("ExecCredential"))
+	g.Expect(cred.Status.Token).To(Equal("id"))
+	g.Expect(cred.Status.ExpirationTimestamp.Time).To(BeTemporally("==", exp))
+
+	t.Setenv("KUBERNETES_EXEC_INFO", `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{}}`)
+	buf.Reset()
+	g.Expect(writeExecCredential(&buf, &oidc.Token{IDToken: "id"})).To(Succeed())
+	g.Expect(buf.String()).To(ContainSubstring(`"apiVersion":"client.authentication.k8s.io/v1beta1"`))
+}
+
+func TestRunGetTokenUsesValidCachedToken(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("KUBERNETES_EXEC_INFO", "")
+	dir := t.TempDir()
+	params := oidc.Params{IssuerURL: "https://issuer.invalid", ClientID: "cid", ExtraScopes: []string{"groups"}}
+	g.Expect(oidc.Cache{Dir: dir}.Save(params, &oidc.Token{IDToken: "cached", Expiry: time.Now().Add(time.Hour)})).To(Succeed())
+
+	var out bytes.Buffer
+	getTokenCmd.SetOut(&out)
+	t.Cleanup(func() { getTokenCmd.SetOut(nil) })
+	// --oidc-use-pkce is a kubelogin flag cloudctl does not know; it must be ignored.
+	g.Expect(getTokenCmd.ParseFlags([]string{
+		"--oidc-issuer-url=https://issuer.invalid", "--oidc-client-id=cid",
+		"--oidc-extra-scope=groups", "--token-cache-dir=" + dir, "--oidc-use-pkce",
+	})).To(Succeed())
+
+	g.Expect(runGetToken(getTokenCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`"token":"cached"`))
+}
+
+func TestRefreshOrLoginNonInteractive(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("non-interactive", true)
+	t.Cleanup(func() { viper.Set("non-interactive", nil) })
+
+	// Without a refresh token no request is made and no login is started.
+	_, err := refreshOrLogin(getTokenCmd, &oidc.Provider{}, oidc.Params{IssuerURL: "https://issuer.invalid"}, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("--non-interactive forbids an interactive login")))
+}
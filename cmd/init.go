@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up cloudctl interactively",
+	Long: `Walks through the first-time setup: choosing the kubeconfig and context
+that reach the Greenhouse cluster, finding your organization namespace,
+picking a prefix for managed kubeconfig entries and running a first sync.
+
+The answers are written to the config file (see cloudctl config), so later
+runs of sync need no flags. Existing keys in the file are kept. init needs a
+terminal; in scripts use cloudctl config set instead.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+// orgDiscoveryTimeout bounds listing organizations during init.
+const orgDiscoveryTimeout = 15 * time.Second
+
+// initPrompt asks question on the terminal and returns the trimmed answer, or
+// def when the answer is empty. It is a variable so tests can answer without
+// a terminal.
+var initPrompt = func(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return cmp.Or(strings.TrimSpace(line), def), nil
+}
+
+func runInit(cmd *cobra.Command, _ []string) error {
+	if !canPrompt() {
+		return errors.New("init needs a terminal and cannot run with --non-interactive; use `cloudctl config set` to configure cloudctl non-interactively")
+	}
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	defaultPath, defaultContext := greenhouseKubeconfig()
+	path, err := initPrompt("Kubeconfig of the Greenhouse cluster", displayKubeconfig(defaultPath))
+	if err != nil {
+		return err
+	}
+	if path == displayKubeconfig(defaultPath) {
+		path = defaultPath
+	}
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+
+	contextName := defaultContext
+	if _, ok := cfg.Contexts[contextName]; !ok {
+		if contextName, err = pickOne("context", slices.Sorted(maps.Keys(cfg.Contexts))); err != nil {
+			return err
+		}
+	}
+
+	namespace, err := initNamespace(cmd.Context(), path, contextName)
+	if err != nil {
+		return err
+	}
+
+	profilePrefix, err := initPrompt("Prefix for managed kubeconfig entries", viper.GetString("prefix"))
+	if err != nil {
+		return err
+	}
+
+	file, err := configFileTarget()
+	if err != nil {
+		return err
+	}
+	doc, err := loadConfigDocument(file)
+	if err != nil {
+		return err
+	}
+	profile := map[string]string{
+		"greenhouse-cluster-context":   contextName,
+		"greenhouse-cluster-namespace": namespace,
+		"prefix":                       profilePrefix,
+	}
+	// An empty path means $KUBECONFIG, which sync falls back to anyway.
+	if path != "" {
+		profile["greenhouse-cluster-kubeconfig"] = path
+	}
+	for _, key := range slices.Sorted(maps.Keys(profile)) {
+		if err := setConfigKey(doc, key, profile[key]); err != nil {
+			return err
+		}
+	}
+	if err := writeConfigDocument(file, doc); err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(output.InitResult{
+		File:       file,
+		Kubeconfig: displayKubeconfig(path),
+		Context:    contextName,
+		Namespace:  namespace,
+		Prefix:     profilePrefix,
+	}); err != nil {
+		return err
+	}
+
+	answer, err := initPrompt("Run a first sync now? [Y/n]", "")
+	if err != nil {
+		return err
+	}
+	if answer != "" && !strings.HasPrefix(strings.ToLower(answer), "y") {
+		return nil
+	}
+	for key, value := range profile {
+		viper.Set(key, value)
+	}
+	return runSync(cmd, nil)
+}
+
+// initNamespace finds the organization namespaces the user may sync from and
+// lets them choose one. When none can be discovered the namespace is asked
+// for.
+func initNamespace(ctx context.Context, path, contextName string) (string, error) {
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to build kubeconfig for context %q: %w", contextName, err)
+	}
+	if err := applyGreenhouseProxy(restCfg, viper.GetString("proxy-url")); err != nil {
+		return "", err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(restCfg))
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, orgDiscoveryTimeout)
+	defer cancel()
+	namespaces, err := discoverOrgNamespaces(ctx, ghClient)
+	if err != nil {
+		slog.Debug("organization discovery failed", "error", err)
+	}
+	if len(namespaces) == 0 {
+		return initPrompt("Greenhouse organization namespace", viper.GetString("greenhouse-cluster-namespace"))
+	}
+	return pickOne("organization", namespaces)
+}
+
+// pickOne returns the only element of names, or lets the user pick one.
+func pickOne(kind string, names []string) (string, error) {
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no %ss found", kind)
+	case 1:
+		fmt.Fprintf(os.Stderr, "Using %s %s.\n", kind, names[0])
+		return names[0], nil
+	}
+	return selectName(kind, names)
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestRunInit_WritesProfile(t *testing.T) {
+	g := NewWithT(t)
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["gh"] = &clientcmdapi.Cluster{Server: "https://greenhouse.example.com"}
+	cfg.AuthInfos["gh"] = &clientcmdapi.AuthInfo{Token: "x"}
+	cfg.Contexts["greenhouse"] = &clientcmdapi.Context{Cluster: "gh", AuthInfo: "gh"}
+	cfg.Contexts["other"] = &clientcmdapi.Context{Cluster: "gh", AuthInfo: "gh"}
+	kubeconfig := writeTestKubeconfig(t, cfg)
+	configFile := useConfigFile(t, "# mine\nlog-level: debug\n")
+
+	prevCanPrompt, prevPrompt, prevSelect, prevClient := canPrompt, initPrompt, selectName, newGreenhouseClient
+	canPrompt = func() bool { return true }
+	var selected []string
+	selectName = func(kind string, names []string) (string, error) {
+		selected = append(selected, kind+": "+strings.Join(names, ","))
+		return names[len(names)-1], nil
+	}
+	initPrompt = func(question, def string) (string, error) {
+		switch {
+		case strings.HasPrefix(question, "Prefix"):
+			return "gh", nil
+		case strings.HasPrefix(question, "Run a first sync"):
+			return "n", nil
+		}
+		return def, nil
+	}
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) {
+		return &stubGreenhouseClient{allowed: true, orgs: []greenhousev1alpha1.Organization{
+			{ObjectMeta: metav1.ObjectMeta{Name: "org-b"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "org-a"}},
+		}}, nil
+	}
+	t.Cleanup(func() {
+		canPrompt, initPrompt, selectName, newGreenhouseClient = prevCanPrompt, prevPrompt, prevSelect, prevClient
+	})
+
+	var out bytes.Buffer
+	initCmd.SetOut(&out)
+	initCmd.SetContext(context.Background())
+	t.Cleanup(func() { initCmd.SetOut(nil) })
+
+	g.Expect(runInit(initCmd, nil)).To(Succeed())
+	g.Expect(selected).To(Equal([]string{"context: greenhouse,other", "organization: org-a,org-b"}))
+	g.Expect(out.String()).To(ContainSubstring("Wrote profile to " + configFile))
+
+	data, err := os.ReadFile(configFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`# mine
+log-level: debug
+greenhouse-cluster-context: other
+greenhouse-cluster-kubeconfig: ` + kubeconfig + `
+greenhouse-cluster-namespace: org-b
+prefix: gh
+`))
+}
+
+func TestRunInit_RequiresTerminal(t *testing.T) {
+	g := NewWithT(t)
+	prev := canPrompt
+	canPrompt = func() bool { return false }
+	t.Cleanup(func() { canPrompt = prev })
+
+	g.Expect(runInit(initCmd, nil)).To(MatchError(ContainSubstring("init needs a terminal")))
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+)
+
+var k9sCmd = &cobra.Command{
+	Use:   "k9s [cluster] [-- k9s flags]",
+	Short: "Open k9s on a managed cluster",
+	Long: `Resolves a managed context and starts k9s on it with --context, so picking
+a cluster and opening k9s is one command. Arguments after -- are passed on
+to k9s.
+
+The argument is matched against managed contexts like cloudctl use; without
+it a finder is shown. Before k9s starts, one authenticated request is made
+to the cluster, so an expired token is refreshed, or a login prompt shown,
+in the terminal rather than hidden behind the k9s screen. k9s is looked up
+on PATH unless --k9s is given; its exit code is passed on.
+
+Examples:
+  cloudctl k9s prod-eu
+  cloudctl k9s prod-eu -- --readonly -n kube-system`,
+	Args:              cobra.ArbitraryArgs,
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runK9s,
+}
+
+func init() {
+	k9sCmd.Flags().String("k9s", "k9s", "k9s executable to run")
+}
+
+// refreshCredentials makes an authenticated request with cfg, which runs its
+// exec plugin or refreshes its auth-provider token. It is a variable so tests
+// need no cluster.
+var refreshCredentials = func(ctx context.Context, cfg *rest.Config) error {
+	_, err := getAuthenticatedVersion(ctx, withTracing(cfg))
+	return err
+}
+
+func runK9s(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+
+	query, k9sArgs := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		query, k9sArgs = args[:dash], args[dash:]
+	}
+	if len(query) > 1 {
+		return fmt.Errorf("expected at most one cluster, got %d; pass k9s flags after --", len(query))
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	var q string
+	if len(query) == 1 {
+		q = query[0]
+	}
+	contextName, err := selectManagedContext(cfg, q)
+	if err != nil {
+		return err
+	}
+
+	bin, err := exec.LookPath(viper.GetString("k9s"))
+	if err != nil {
+		return fmt.Errorf("k9s not found; install it from https://k9scli.io or pass --k9s: %w", err)
+	}
+
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig for context %q: %w", contextName, err)
+	}
+	if hasAuth(restCfg) {
+		slog.Debug("refreshing credentials", "context", contextName)
+		if err := refreshCredentials(cmd.Context(), restCfg); err != nil {
+			return fmt.Errorf("failed to authenticate to context %q: %w", contextName, err)
+		}
+	}
+
+	k9sArgs = append([]string{"--context", contextName}, k9sArgs...)
+	if path != "" {
+		k9sArgs = append([]string{"--kubeconfig", path}, k9sArgs...)
+	}
+	c := exec.Command(bin, k9sArgs...) // #nosec G204 -- the user picks the executable and its arguments
+	slog.Info("starting k9s", "k9s", bin, "context", contextName)
+	code, err := runSubshell(c)
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", bin, err)
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
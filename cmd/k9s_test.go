@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+)
+
+func TestRunK9s(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("prod-eu", "qa"))
+	k9s := filepath.Join(t.TempDir(), "k9s")
+	g.Expect(os.WriteFile(k9s, []byte("#!/bin/sh\n"), 0o755)).To(Succeed())
+	viper.Set("k9s", k9s)
+	t.Cleanup(func() { viper.Set("k9s", nil) })
+
+	var refreshed []string
+	prevRefresh := refreshCredentials
+	refreshCredentials = func(_ context.Context, cfg *rest.Config) error {
+		refreshed = append(refreshed, cfg.Host)
+		return nil
+	}
+	var args []string
+	prevRun := runSubshell
+	runSubshell = func(c *exec.Cmd) (int, error) {
+		args = c.Args[1:]
+		return 2, nil
+	}
+	t.Cleanup(func() {
+		refreshCredentials = prevRefresh
+		runSubshell = prevRun
+	})
+
+	g.Expect(k9sCmd.Flags().Parse([]string{"prod-eu", "--", "--readonly"})).To(Succeed())
+	err := runK9s(k9sCmd, k9sCmd.Flags().Args())
+	g.Expect(err).To(MatchError(&ExitError{Code: 2}), "the k9s exit code is passed on")
+	g.Expect(refreshed).To(Equal([]string{"https://prod-eu.example"}))
+	g.Expect(args).To(Equal([]string{"--kubeconfig", path, "--context", "prod-eu", "--readonly"}))
+
+	refreshCredentials = func(context.Context, *rest.Config) error { return errors.New("token expired") }
+	args = nil
+	err = runK9s(k9sCmd, []string{"qa"})
+	g.Expect(err).To(MatchError(ContainSubstring(`failed to authenticate to context "qa": token expired`)))
+	g.Expect(args).To(BeNil(), "k9s is not started without valid credentials")
+
+	viper.Set("k9s", filepath.Join(t.TempDir(), "missing"))
+	g.Expect(runK9s(k9sCmd, []string{"qa"})).To(MatchError(ContainSubstring("k9s not found")))
+}
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/fuzzy"
+)
+
+var kubeconfigCreateSACmd = &cobra.Command{
+	Use:   "create-sa",
+	Short: "Create a short-lived service-account kubeconfig for CI",
+	Long: `Requests a short-lived token for a service account on a managed cluster
+(TokenRequest API) and prints a standalone kubeconfig that uses it, for CI
+pipelines that should not run with personal credentials.
+
+--cluster names a managed context (with or without the sync --prefix) and is
+matched like cloudctl use. The service account must already exist; the
+token is bound to it and expires after --duration. The kubeconfig is written
+to stdout, or to --file with mode 0600.
+
+Examples:
+  cloudctl kubeconfig create-sa --cluster prod-eu --namespace ci --service-account deployer --duration 1h
+  cloudctl kubeconfig create-sa --cluster prod-eu -n ci --service-account deployer --file ci.kubeconfig`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runKubeconfigCreateSA,
+}
+
+// minTokenDuration is the shortest token lifetime the TokenRequest API
+// accepts.
+const minTokenDuration = 10 * time.Minute
+
+// tokenRequestTimeout bounds the TokenRequest call.
+const tokenRequestTimeout = 30 * time.Second
+
+// createServiceAccountToken requests a token for the service account
+// namespace/name valid for duration and returns it with its expiry. It is a
+// variable so tests can avoid a live cluster.
+var createServiceAccountToken = func(ctx context.Context, cfg *rest.Config, namespace, name string, duration time.Duration) (string, time.Time, error) {
+	cs, err := kubernetes.NewForConfig(withTracing(cfg))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	seconds := int64(duration.Seconds())
+	tr, err := cs.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tr.Status.Token, tr.Status.ExpirationTimestamp.Time, nil
+}
+
+func init() {
+	kubeconfigCreateSACmd.Flags().String("cluster", "", "Managed context to create the token on")
+	kubeconfigCreateSACmd.Flags().StringP("namespace", "n", "default", "Namespace of the service account")
+	kubeconfigCreateSACmd.Flags().String("service-account", "", "Name of the service account")
+	kubeconfigCreateSACmd.Flags().Duration("duration", time.Hour, "Lifetime of the token (at least 10m)")
+	kubeconfigCreateSACmd.Flags().String("file", "", "Write the kubeconfig to this file instead of stdout")
+	_ = kubeconfigCreateSACmd.MarkFlagRequired("cluster")
+	_ = kubeconfigCreateSACmd.MarkFlagRequired("service-account")
+	_ = kubeconfigCreateSACmd.RegisterFlagCompletionFunc("cluster", completeContexts)
+}
+
+func runKubeconfigCreateSA(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	namespace := viper.GetString("namespace")
+	serviceAccount := viper.GetString("service-account")
+	duration := viper.GetDuration("duration")
+	if duration < minTokenDuration {
+		return fmt.Errorf("--duration must be at least %s, got %s", minTokenDuration, duration)
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	contextName, err := selectManagedContext(cfg, viper.GetString("cluster"))
+	if err != nil {
+		return err
+	}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig for context %q: %w", contextName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), tokenRequestTimeout)
+	defer cancel()
+	token, expires, err := createServiceAccountToken(ctx, restCfg, namespace, serviceAccount, duration)
+	if err != nil {
+		return fmt.Errorf("failed to create token for service account %s/%s in context %q: %w", namespace, serviceAccount, contextName, err)
+	}
+	slog.Info("created service-account token", "context", contextName, "namespace", namespace, "serviceAccount", serviceAccount, "expires", expires)
+
+	saConfig, err := serviceAccountKubeconfig(cfg, contextName, namespace, serviceAccount, token)
+	if err != nil {
+		return err
+	}
+	data, err := clientcmd.Write(*saConfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+	if file := viper.GetString("file"); file != "" {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		return nil
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
+
+// selectManagedContext resolves query to a managed context of cfg. Contexts
+// keep their server-side names, so an exact context name is tried first, then
+// the context referencing the managed cluster of that name, and finally the
+// query is matched like in cloudctl use.
+func selectManagedContext(cfg *clientcmdapi.Config, query string) (string, error) {
+	if hasManagedCluster(cfg.Contexts[query]) {
+		return query, nil
+	}
+	items := slices.DeleteFunc(contextItems(cfg), func(it fuzzy.Item) bool { return !hasManagedCluster(cfg.Contexts[it.Name]) })
+	for _, it := range items {
+		if cluster := cfg.Contexts[it.Name].Cluster; cluster == query || cluster == managedNameFunc(query) {
+			return it.Name, nil
+		}
+	}
+	return selectTarget("managed context", query, items)
+}
+
+// serviceAccountKubeconfig returns a kubeconfig with a single context that
+// reaches the cluster of contextName as the given service account. Files
+// referenced by the cluster entry are inlined so the result is standalone.
+func serviceAccountKubeconfig(cfg *clientcmdapi.Config, contextName, namespace, serviceAccount, token string) (*clientcmdapi.Config, error) {
+	kctx := cfg.Contexts[contextName]
+	if kctx == nil || cfg.Clusters[kctx.Cluster] == nil {
+		return nil, fmt.Errorf("context %q does not reference a known cluster", contextName)
+	}
+	cluster := cfg.Clusters[kctx.Cluster].DeepCopy()
+	// Labels and other extensions only matter to cloudctl.
+	cluster.Extensions = nil
+
+	user := serviceAccount + "@" + contextName
+	out := clientcmdapi.NewConfig()
+	out.Clusters[contextName] = cluster
+	out.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: token}
+	out.Contexts[contextName] = &clientcmdapi.Context{Cluster: contextName, AuthInfo: user, Namespace: namespace}
+	out.CurrentContext = contextName
+	if err := clientcmdapi.FlattenConfig(out); err != nil {
+		return nil, fmt.Errorf("failed to inline certificate files: %w", err)
+	}
+	return out, nil
+}
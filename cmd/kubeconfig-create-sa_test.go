@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunKubeconfigCreateSA(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca-bytes"), 0o600)).To(Succeed())
+	path := filepath.Join(dir, "config")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:prod-eu"] = &clientcmdapi.Cluster{
+		Server:               "https://prod-eu.example",
+		CertificateAuthority: "ca.crt",
+		Extensions:           map[string]runtime.Object{"labels": &runtime.Unknown{Raw: []byte(`{"region":"eu"}`)}},
+	}
+	cfg.AuthInfos["cloudctl:prod-eu"] = &clientcmdapi.AuthInfo{Token: "personal"}
+	cfg.Contexts["prod-eu-admin"] = &clientcmdapi.Context{Cluster: "cloudctl:prod-eu", AuthInfo: "cloudctl:prod-eu"}
+	cfg.Contexts["prod-eu"] = &clientcmdapi.Context{Cluster: "cloudctl:prod-eu", AuthInfo: "cloudctl:prod-eu"}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+
+	for k, v := range map[string]any{
+		"kubeconfig": path, "prefix": "cloudctl", "cluster": "prod-eu",
+		"namespace": "ci", "service-account": "deployer", "duration": "1h",
+	} {
+		viper.Set(k, v)
+		t.Cleanup(func() { viper.Set(k, nil) })
+	}
+	prev := createServiceAccountToken
+	createServiceAccountToken = func(_ context.Context, cfg *rest.Config, namespace, name string, duration time.Duration) (string, time.Time, error) {
+		g.Expect(cfg.Host).To(Equal("https://prod-eu.example"))
+		g.Expect([]any{namespace, name, duration}).To(Equal([]any{"ci", "deployer", time.Hour}))
+		return "sa-token", time.Now().Add(duration), nil
+	}
+	t.Cleanup(func() { createServiceAccountToken = prev })
+
+	var out bytes.Buffer
+	kubeconfigCreateSACmd.SetOut(&out)
+	kubeconfigCreateSACmd.SetContext(context.Background())
+	t.Cleanup(func() { kubeconfigCreateSACmd.SetOut(nil) })
+	g.Expect(runKubeconfigCreateSA(kubeconfigCreateSACmd, nil)).To(Succeed())
+
+	written, err := clientcmd.Load(out.Bytes())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.CurrentContext).To(Equal("prod-eu"))
+	g.Expect(written.Contexts["prod-eu"].Namespace).To(Equal("ci"))
+	g.Expect(written.AuthInfos["deployer@prod-eu"].Token).To(Equal("sa-token"))
+	cluster := written.Clusters["prod-eu"]
+	g.Expect(cluster.CertificateAuthorityData).To(Equal([]byte("ca-bytes")))
+	g.Expect(cluster.CertificateAuthority).To(BeEmpty())
+	g.Expect(cluster.Extensions).To(BeEmpty())
+
+	viper.Set("duration", "5m")
+	g.Expect(runKubeconfigCreateSA(kubeconfigCreateSACmd, nil)).To(MatchError(ContainSubstring("--duration must be at least 10m0s")))
+}
@@ -0,0 +1,340 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var kubeconfigLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate the local kubeconfig",
+	Long: `Checks the local kubeconfig for problems:
+
+  dangling-reference        contexts pointing at missing clusters or users, or a missing current-context
+  duplicate-server          several clusters with the same server URL
+  expired-certificate       expired client or CA certificates
+  invalid-certificate       certificate data that cannot be parsed
+  deprecated-auth-provider  users relying on the deprecated auth-provider mechanism
+  unused-entry              managed clusters or users no context refers to
+  unreachable-server        servers that do not answer (with --probe)
+  stale-managed-entry       managed contexts whose ClusterKubeconfig no longer exists
+                            in Greenhouse (with --greenhouse-cluster-namespace)
+
+The command exits with an error when any finding has severity "error".
+
+Examples:
+  # Offline checks only
+  cloudctl kubeconfig lint
+
+  # Also probe every server and compare managed entries with Greenhouse
+  cloudctl kubeconfig lint --probe -n my-org
+
+  # Machine-readable findings
+  cloudctl kubeconfig lint -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runKubeconfigLint,
+}
+
+func init() {
+	kubeconfigLintCmd.Flags().Bool("probe", false, "Check that every cluster's server answers")
+	kubeconfigLintCmd.Flags().Duration("probe-timeout", 5*time.Second, "Maximum time to wait for each server with --probe")
+	kubeconfigLintCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace; enables the stale-managed-entry check")
+	_ = kubeconfigLintCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	kubeconfigLintCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	kubeconfigLintCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+}
+
+func runKubeconfigLint(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+
+	findings := lintKubeconfig(cfg, certNow())
+	if viper.GetBool("probe") {
+		timeout := viper.GetDuration("probe-timeout")
+		if timeout <= 0 {
+			return fmt.Errorf("--probe-timeout must be positive, got %s", timeout)
+		}
+		tun, err := newTunnels()
+		if err != nil {
+			return err
+		}
+		findings = append(findings, lintReachability(cmd.Context(), cfg, tun, timeout, printer)...)
+		tun.Close()
+	}
+	if namespace := viper.GetString("greenhouse-cluster-namespace"); namespace != "" {
+		stale, err := lintStaleManagedEntries(cmd.Context(), cfg, namespace)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, stale...)
+	}
+	sortFindings(findings)
+
+	result := output.LintResult{Kubeconfig: displayKubeconfig(path), Findings: findings}
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if n := result.Count(output.LintSeverityError); n > 0 {
+		return fmt.Errorf("kubeconfig lint found %d error(s)", n)
+	}
+	return nil
+}
+
+// lintKubeconfig runs the offline checks against cfg.
+func lintKubeconfig(cfg *clientcmdapi.Config, now time.Time) []output.LintFinding {
+	var findings []output.LintFinding
+	add := func(sev output.LintSeverity, check, kind, name, format string, a ...any) {
+		findings = append(findings, output.LintFinding{Severity: sev, Check: check, Kind: kind, Name: name, Message: fmt.Sprintf(format, a...)})
+	}
+
+	// Dangling references
+	if cfg.CurrentContext != "" && cfg.Contexts[cfg.CurrentContext] == nil {
+		add(output.LintSeverityError, "dangling-reference", "current-context", cfg.CurrentContext, "current-context refers to a context that does not exist")
+	}
+	usedClusters, usedUsers := map[string]bool{}, map[string]bool{}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+		c := cfg.Contexts[name]
+		if c == nil {
+			continue
+		}
+		usedClusters[c.Cluster], usedUsers[c.AuthInfo] = true, true
+		switch {
+		case c.Cluster == "":
+			add(output.LintSeverityError, "dangling-reference", "context", name, "no cluster set")
+		case cfg.Clusters[c.Cluster] == nil:
+			add(output.LintSeverityError, "dangling-reference", "context", name, "cluster %q does not exist", c.Cluster)
+		}
+		if c.AuthInfo != "" && cfg.AuthInfos[c.AuthInfo] == nil {
+			add(output.LintSeverityError, "dangling-reference", "context", name, "user %q does not exist", c.AuthInfo)
+		}
+	}
+
+	// Duplicate servers
+	byServer := map[string][]string{}
+	for name, c := range cfg.Clusters {
+		if c != nil && c.Server != "" {
+			server := strings.TrimRight(c.Server, "/")
+			byServer[server] = append(byServer[server], name)
+		}
+	}
+	for _, server := range slices.Sorted(maps.Keys(byServer)) {
+		if names := byServer[server]; len(names) > 1 {
+			slices.Sort(names)
+			add(output.LintSeverityWarning, "duplicate-server", "cluster", names[0], "server %s is also used by %s", server, strings.Join(names[1:], ", "))
+		}
+	}
+
+	// Certificates and unused managed clusters
+	for _, name := range slices.Sorted(maps.Keys(cfg.Clusters)) {
+		c := cfg.Clusters[name]
+		if c == nil {
+			continue
+		}
+		if f := lintCertificate(c.CertificateAuthorityData, c.CertificateAuthority, "CA certificate", now); f != nil {
+			f.Kind, f.Name = "cluster", name
+			findings = append(findings, *f)
+		}
+		if isManaged(name) && !usedClusters[name] {
+			add(output.LintSeverityInfo, "unused-entry", "cluster", name, "managed cluster is not used by any context")
+		}
+	}
+
+	// Users
+	for _, name := range slices.Sorted(maps.Keys(cfg.AuthInfos)) {
+		ai := cfg.AuthInfos[name]
+		if ai == nil {
+			continue
+		}
+		if f := lintCertificate(ai.ClientCertificateData, ai.ClientCertificate, "client certificate", now); f != nil {
+			f.Kind, f.Name = "user", name
+			findings = append(findings, *f)
+		}
+		if ai.AuthProvider != nil {
+			hint := "switch to an exec credential plugin such as kubelogin"
+			if isManaged(name) {
+				hint = "run `cloudctl sync --auth-type=exec-plugin` to migrate"
+			}
+			add(output.LintSeverityWarning, "deprecated-auth-provider", "user", name, "uses the deprecated %q auth-provider; %s", ai.AuthProvider.Name, hint)
+		}
+		if isManaged(name) && !usedUsers[name] {
+			add(output.LintSeverityInfo, "unused-entry", "user", name, "managed user is not used by any context")
+		}
+	}
+	return findings
+}
+
+// lintCertificate checks the first certificate in data, or in file when data
+// is empty. It returns nil when there is nothing to report.
+func lintCertificate(data []byte, file, what string, now time.Time) *output.LintFinding {
+	cert, err := parseCertificate(data, file)
+	if errors.Is(err, errNoCertificate) {
+		return nil
+	}
+	if err != nil {
+		return &output.LintFinding{Severity: output.LintSeverityWarning, Check: "invalid-certificate", Message: fmt.Sprintf("%s: %v", what, err)}
+	}
+	if now.After(cert.NotAfter) {
+		return &output.LintFinding{Severity: output.LintSeverityError, Check: "expired-certificate", Message: fmt.Sprintf("%s expired on %s", what, cert.NotAfter.UTC().Format(time.DateOnly))}
+	}
+	return nil
+}
+
+// lintReachability sends an unauthenticated GET /version to every cluster
+// server, routed through the tunnel for its labels. Any HTTP response,
+// including 401 or 403, counts as reachable. Progress is shown through
+// printer.
+func lintReachability(ctx context.Context, cfg *clientcmdapi.Config, tun *tunnels, timeout time.Duration, printer output.Printer) []output.LintFinding {
+	names := slices.Sorted(maps.Keys(cfg.Clusters))
+	results := make([]*output.LintFinding, len(names))
+	index := make(map[string]int, len(names))
+	for i, n := range names {
+		index[n] = i
+	}
+	step, stop := printer.StartProgress("Probing servers", len(names))
+	defer stop()
+	forEachContext(ctx, names, 10, func(ctx context.Context, name string) {
+		defer step()
+		c := cfg.Clusters[name]
+		if c == nil || c.Server == "" {
+			return
+		}
+		proxy, err := tun.proxyFor(ctx, clusterLabels(c))
+		if err == nil {
+			err = probeServer(ctx, c, proxy, timeout)
+		}
+		if err != nil {
+			slog.Debug("server probe failed", "cluster", name, "error", err)
+			results[index[name]] = &output.LintFinding{
+				Severity: output.LintSeverityWarning,
+				Check:    "unreachable-server",
+				Kind:     "cluster",
+				Name:     name,
+				Message:  fmt.Sprintf("%s did not answer: %v", c.Server, err),
+			}
+		}
+	})
+	var findings []output.LintFinding
+	for _, f := range results {
+		if f != nil {
+			findings = append(findings, *f)
+		}
+	}
+	return findings
+}
+
+// probeServer is a variable so tests can avoid network access.
+var probeServer = func(ctx context.Context, c *clientcmdapi.Cluster, proxy *url.URL, timeout time.Duration) error {
+	restCfg := &rest.Config{
+		Host: c.Server,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure:   c.InsecureSkipTLSVerify,
+			ServerName: c.TLSServerName,
+			CAData:     c.CertificateAuthorityData,
+			CAFile:     c.CertificateAuthority,
+		},
+	}
+	if proxy != nil {
+		restCfg.Proxy = http.ProxyURL(proxy)
+	}
+	client, err := unauthenticatedClient(restCfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.Server, "/")+"/version", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// lintStaleManagedEntries reports managed contexts whose ClusterKubeconfig no
+// longer exists in the Greenhouse namespace.
+func lintStaleManagedEntries(ctx context.Context, cfg *clientcmdapi.Config, namespace string) ([]output.LintFinding, error) {
+	ghKubeconfig, ghContext := greenhouseKubeconfig()
+	restCfg, err := greenhouseRestConfig(ghContext, ghKubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build greenhouse kubeconfig (source: %s): %w", displayKubeconfig(ghKubeconfig), err)
+	}
+	if err := applyGreenhouseProxy(restCfg, viper.GetString("proxy-url")); err != nil {
+		return nil, err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(restCfg))
+	if err != nil {
+		return nil, err
+	}
+	items, err := ghClient.ListClusterKubeconfigs(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(items))
+	for _, ckc := range items {
+		for _, c := range ckc.Spec.Kubeconfig.Contexts {
+			known[c.Name] = true
+		}
+	}
+
+	var findings []output.LintFinding
+	for _, name := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+		if ctx := cfg.Contexts[name]; hasManagedCluster(ctx) && !known[serverContextName(name, ctx)] {
+			findings = append(findings, output.LintFinding{
+				Severity: output.LintSeverityWarning,
+				Check:    "stale-managed-entry",
+				Kind:     "context",
+				Name:     name,
+				Message:  fmt.Sprintf("no ClusterKubeconfig for it in namespace %q; run `cloudctl sync` to remove it", namespace),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// sortFindings orders findings by severity (errors first), then kind and name.
+func sortFindings(findings []output.LintFinding) {
+	rank := map[output.LintSeverity]int{output.LintSeverityError: 0, output.LintSeverityWarning: 1, output.LintSeverityInfo: 2}
+	slices.SortStableFunc(findings, func(a, b output.LintFinding) int {
+		return cmp.Or(
+			cmp.Compare(rank[a.Severity], rank[b.Severity]),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// testCertPEM returns a self-signed PEM certificate valid until notAfter.
+func testCertPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func findingKeys(findings []output.LintFinding) []string {
+	keys := make([]string, 0, len(findings))
+	for _, f := range findings {
+		keys = append(keys, string(f.Severity)+" "+f.Check+" "+f.Kind+"/"+f.Name)
+	}
+	return keys
+}
+
+func TestLintKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	prev := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = prev })
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := clientcmdapi.NewConfig()
+	cfg.CurrentContext = "gone"
+	cfg.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{Server: "https://eu.example", CertificateAuthorityData: testCertPEM(t, now.AddDate(1, 0, 0))}
+	cfg.Clusters["eu-admin"] = &clientcmdapi.Cluster{Server: "https://eu.example/"}
+	cfg.Clusters["cloudctl:orphan"] = &clientcmdapi.Cluster{Server: "https://orphan.example", CertificateAuthorityData: []byte("garbage")}
+	cfg.AuthInfos["cloudctl:oidc"] = &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc"}}
+	cfg.AuthInfos["admin"] = &clientcmdapi.AuthInfo{ClientCertificateData: testCertPEM(t, now.AddDate(0, 0, -1))}
+	cfg.Contexts["cloudctl:eu"] = &clientcmdapi.Context{Cluster: "cloudctl:eu", AuthInfo: "cloudctl:oidc"}
+	cfg.Contexts["eu-admin"] = &clientcmdapi.Context{Cluster: "eu-admin", AuthInfo: "admin"}
+	cfg.Contexts["broken"] = &clientcmdapi.Context{Cluster: "missing", AuthInfo: "nobody"}
+
+	findings := lintKubeconfig(cfg, now)
+	sortFindings(findings)
+	g.Expect(findingKeys(findings)).To(Equal([]string{
+		"error dangling-reference context/broken",
+		"error dangling-reference context/broken",
+		"error dangling-reference current-context/gone",
+		"error expired-certificate user/admin",
+		"warning duplicate-server cluster/cloudctl:eu",
+		"warning invalid-certificate cluster/cloudctl:orphan",
+		"warning deprecated-auth-provider user/cloudctl:oidc",
+		"info unused-entry cluster/cloudctl:orphan",
+	}))
+	g.Expect(findings[3].Message).To(Equal("client certificate expired on 2025-12-31"))
+	g.Expect(findings[6].Message).To(ContainSubstring("cloudctl sync --auth-type=exec-plugin"))
+}
+
+func TestRunKubeconfigLint_ProbeAndStaleEntries(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("eu", "retired"))
+	viper.Set("probe", true)
+	viper.Set("probe-timeout", time.Second)
+	viper.Set("greenhouse-cluster-namespace", "my-org")
+	viper.Set("greenhouse-cluster-kubeconfig", path)
+	viper.Set("greenhouse-cluster-context", "local")
+	t.Cleanup(func() {
+		for _, k := range []string{"probe", "probe-timeout", "greenhouse-cluster-namespace", "greenhouse-cluster-kubeconfig", "greenhouse-cluster-context"} {
+			viper.Set(k, nil)
+		}
+	})
+
+	prevProbe := probeServer
+	probeServer = func(_ context.Context, c *clientcmdapi.Cluster, _ *url.URL, _ time.Duration) error {
+		if c.Server == "https://retired.example" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	prevClient := newGreenhouseClient
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) {
+		return &stubGreenhouseClient{ckcs: []greenhousev1alpha1.ClusterKubeconfig{{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu"},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "eu"}},
+			}},
+		}}}, nil
+	}
+	t.Cleanup(func() { probeServer, newGreenhouseClient = prevProbe, prevClient })
+
+	var out bytes.Buffer
+	kubeconfigLintCmd.SetOut(&out)
+	kubeconfigLintCmd.SetContext(context.Background())
+	t.Cleanup(func() { kubeconfigLintCmd.SetOut(nil) })
+
+	g.Expect(runKubeconfigLint(kubeconfigLintCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("warning  cluster/cloudctl:retired  unreachable-server   "))
+	g.Expect(out.String()).To(ContainSubstring("warning  context/retired           stale-managed-entry  "))
+	g.Expect(out.String()).To(ContainSubstring("0 error(s), 2 warning(s), 0 info."))
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Inspect and maintain the local kubeconfig",
+}
+
+func init() {
+	kubeconfigCmd.AddCommand(kubeconfigLintCmd)
+	kubeconfigCmd.AddCommand(kubeconfigCreateSACmd)
+}
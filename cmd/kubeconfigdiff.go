@@ -38,7 +38,7 @@ const (
 )
 
 // KubeconfigDiff holds the set of entry-level differences between two kubeconfigs.
-// Only managed entries (those carrying the configured prefix) are included.
+// For sync, only managed entries (those carrying the configured prefix) are included.
 type KubeconfigDiff struct {
 	Clusters  []EntryDiff
 	Contexts  []EntryDiff
@@ -63,19 +63,33 @@ type FieldDiff struct {
 // restricting to entries whose names are managed (have the prefix).
 func diffKubeconfig(oldCfg, newCfg *clientcmdapi.Config) KubeconfigDiff {
 	var d KubeconfigDiff
-	d.Clusters = diffClusters(oldCfg, newCfg)
-	d.Contexts = diffContexts(oldCfg, newCfg)
-	d.AuthInfos = diffAuthInfos(oldCfg, newCfg)
+	d.Clusters = diffClusters(oldCfg, newCfg, isManaged)
+	d.Contexts = diffContexts(oldCfg, newCfg, func(name string) bool {
+		return isManagedContext(name, oldCfg, newCfg)
+	})
+	d.AuthInfos = diffAuthInfos(oldCfg, newCfg, isManaged)
 	return d
 }
 
-// diffClusters returns added/removed/modified cluster entries for managed names.
-func diffClusters(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
+// diffAllEntries computes the diff between two kubeconfigs over every entry,
+// managed or not.
+func diffAllEntries(oldCfg, newCfg *clientcmdapi.Config) KubeconfigDiff {
+	all := func(string) bool { return true }
+	return KubeconfigDiff{
+		Clusters:  diffClusters(oldCfg, newCfg, all),
+		Contexts:  diffContexts(oldCfg, newCfg, all),
+		AuthInfos: diffAuthInfos(oldCfg, newCfg, all),
+	}
+}
+
+// diffClusters returns added/removed/modified cluster entries for names
+// accepted by include.
+func diffClusters(oldCfg, newCfg *clientcmdapi.Config, include func(name string) bool) []EntryDiff {
 	var diffs []EntryDiff
 
 	// Added or modified in new
 	for name, newCluster := range newCfg.Clusters {
-		if !isManaged(name) {
+		if !include(name) {
 			continue
 		}
 		if newCluster == nil {
@@ -107,7 +121,7 @@ func diffClusters(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
 
 	// Removed from old
 	for name, oldCluster := range oldCfg.Clusters {
-		if !isManaged(name) {
+		if !include(name) {
 			continue
 		}
 		if oldCluster == nil {
@@ -137,13 +151,13 @@ func isManagedContext(name string, oldCfg, newCfg *clientcmdapi.Config) bool {
 	return false
 }
 
-// diffContexts returns added/removed/modified context entries for managed contexts.
-// A context is considered managed if its cluster reference carries the managed prefix.
-func diffContexts(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
+// diffContexts returns added/removed/modified context entries for names
+// accepted by include.
+func diffContexts(oldCfg, newCfg *clientcmdapi.Config, include func(name string) bool) []EntryDiff {
 	var diffs []EntryDiff
 
 	for name, newCtx := range newCfg.Contexts {
-		if !isManagedContext(name, oldCfg, newCfg) {
+		if !include(name) {
 			continue
 		}
 		if newCtx == nil {
@@ -170,7 +184,7 @@ func diffContexts(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
 	}
 
 	for name, oldCtx := range oldCfg.Contexts {
-		if !isManagedContext(name, oldCfg, newCfg) {
+		if !include(name) {
 			continue
 		}
 		if oldCtx == nil {
@@ -186,12 +200,13 @@ func diffContexts(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
 	return diffs
 }
 
-// diffAuthInfos returns added/removed/modified authinfo entries for managed names.
-func diffAuthInfos(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
+// diffAuthInfos returns added/removed/modified authinfo entries for names
+// accepted by include.
+func diffAuthInfos(oldCfg, newCfg *clientcmdapi.Config, include func(name string) bool) []EntryDiff {
 	var diffs []EntryDiff
 
 	for name, newAuth := range newCfg.AuthInfos {
-		if !isManaged(name) {
+		if !include(name) {
 			continue
 		}
 		if newAuth == nil {
@@ -269,7 +284,7 @@ func diffAuthInfos(oldCfg, newCfg *clientcmdapi.Config) []EntryDiff {
 	}
 
 	for name, oldAuth := range oldCfg.AuthInfos {
-		if !isManaged(name) {
+		if !include(name) {
 			continue
 		}
 		if oldAuth == nil {
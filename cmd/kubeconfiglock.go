@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// kubeconfigLockTimeout bounds how long a write waits for a concurrent writer.
+var kubeconfigLockTimeout = 10 * time.Second
+
+// lockKubeconfig takes an exclusive lock on the kubeconfig at path and returns
+// a function releasing it. It uses the same "<path>.lock" sibling file as
+// kubectl, created with O_EXCL. Unlike flock this works identically on NTFS,
+// where advisory locks are not available and open files cannot be replaced.
+func lockKubeconfig(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(kubeconfigLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock kubeconfig: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("kubeconfig %s is locked by another process; remove %s if it is stale", path, lockPath)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
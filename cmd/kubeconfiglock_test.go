@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLockKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	prev := kubeconfigLockTimeout
+	kubeconfigLockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { kubeconfigLockTimeout = prev })
+
+	path := filepath.Join(t.TempDir(), "config")
+	unlock, err := lockKubeconfig(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(path + ".lock").To(BeARegularFile())
+
+	_, err = lockKubeconfig(path)
+	g.Expect(err).To(MatchError(ContainSubstring("locked by another process")))
+
+	unlock()
+	_, err = os.Stat(path + ".lock")
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+
+	unlock, err = lockKubeconfig(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	unlock()
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Summarize the labels used across the clusters of an organization",
+	Long: `Aggregates the labels of all ClusterKubeconfigs of a Greenhouse
+organization and prints each distinct key with the number of clusters that
+carry it and its values, most common first. Use it to discover the keys that
+list --sort-by and label selectors can work with.
+
+Examples:
+  cloudctl labels -n my-org
+  cloudctl labels -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runLabels,
+}
+
+func init() {
+	labelsCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
+	_ = labelsCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	labelsCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	labelsCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+}
+
+// summarizeLabels counts the label keys and values of items. Keys are sorted
+// by name, values by count (most common first) and then by value.
+func summarizeLabels(namespace string, items []v1alpha1.ClusterKubeconfig) output.LabelsResult {
+	counts := map[string]map[string]int{}
+	for _, ckc := range items {
+		for k, v := range ckc.Labels {
+			if counts[k] == nil {
+				counts[k] = map[string]int{}
+			}
+			counts[k][v]++
+		}
+	}
+
+	result := output.LabelsResult{Namespace: namespace, Clusters: len(items), Keys: []output.LabelKey{}}
+	for _, k := range slices.Sorted(maps.Keys(counts)) {
+		key := output.LabelKey{Key: k}
+		for v, n := range counts[k] {
+			key.Clusters += n
+			key.Values = append(key.Values, output.LabelValue{Value: v, Clusters: n})
+		}
+		slices.SortFunc(key.Values, func(a, b output.LabelValue) int {
+			return cmp.Or(cmp.Compare(b.Clusters, a.Clusters), cmp.Compare(a.Value, b.Value))
+		})
+		result.Keys = append(result.Keys, key)
+	}
+	return result
+}
+
+func runLabels(cmd *cobra.Command, _ []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		if namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return err
+		}
+	}
+	items, err := ghClient.ListClusterKubeconfigs(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(summarizeLabels(namespace, items))
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestSummarizeLabels(t *testing.T) {
+	g := NewWithT(t)
+	ckc := func(name string, labels map[string]string) v1alpha1.ClusterKubeconfig {
+		return v1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	result := summarizeLabels("org", []v1alpha1.ClusterKubeconfig{
+		ckc("a", map[string]string{"region": "eu", "tier": "prod"}),
+		ckc("b", map[string]string{"region": "ap"}),
+		ckc("c", map[string]string{"region": "eu"}),
+		ckc("d", nil),
+	})
+	g.Expect(result).To(Equal(output.LabelsResult{Namespace: "org", Clusters: 4, Keys: []output.LabelKey{
+		{Key: "region", Clusters: 3, Values: []output.LabelValue{{Value: "eu", Clusters: 2}, {Value: "ap", Clusters: 1}}},
+		{Key: "tier", Clusters: 1, Values: []output.LabelValue{{Value: "prod", Clusters: 1}}},
+	}}))
+
+	g.Expect(summarizeLabels("org", nil).Keys).To(BeEmpty())
+}
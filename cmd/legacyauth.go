@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// legacyAuthPlugins maps the auth providers removed from kubectl 1.26 to the
+// exec plugins replacing them.
+var legacyAuthPlugins = map[string]string{
+	"azure": "kubelogin",
+	"gcp":   "gke-gcloud-auth-plugin",
+}
+
+// legacyAuthWarnings returns warnings, keyed by ClusterKubeconfig name, for
+// the users of items that rely on a removed auth provider. Each warning is
+// also logged. With --convert-legacy-auth the users are converted and nothing
+// is reported.
+func legacyAuthWarnings(items []v1alpha1.ClusterKubeconfig) map[string][]string {
+	warnings := map[string][]string{}
+	if convertLegacyAuth {
+		return warnings
+	}
+	for _, ckc := range items {
+		for _, authItem := range ckc.Spec.Kubeconfig.AuthInfo {
+			provider := authItem.AuthInfo.AuthProvider.Name
+			plugin, ok := legacyAuthPlugins[provider]
+			if !ok {
+				continue
+			}
+			slog.Warn("user relies on an auth provider kubectl 1.26 and later no longer support, sync with --convert-legacy-auth to use an exec plugin",
+				"clusterKubeconfig", ckc.Name, "user", authItem.Name, "authProvider", provider, "plugin", plugin)
+			warnings[ckc.Name] = append(warnings[ckc.Name],
+				fmt.Sprintf("user %q uses the removed %s auth provider; sync with --convert-legacy-auth to use %s", authItem.Name, provider, plugin))
+		}
+	}
+	return warnings
+}
+
+// legacyAuthExec returns the exec entry replacing an azure or gcp auth
+// provider, as written by "kubelogin convert-kubeconfig" and "gcloud
+// container clusters get-credentials", or nil for other providers.
+func legacyAuthExec(provider clientcmdapi.AuthProviderConfig) *clientcmdapi.ExecConfig {
+	switch provider.Name {
+	case "azure":
+		cfg := provider.Config
+		environment := cfg["environment"]
+		if environment == "" {
+			environment = "AzurePublicCloud"
+		}
+		args := []string{"get-token", "--login", "devicecode", "--environment", environment,
+			"--server-id", cfg["apiserver-id"], "--client-id", cfg["client-id"], "--tenant-id", cfg["tenant-id"]}
+		// Config mode 1 requests tokens without the "spn:" audience prefix.
+		if cfg["config-mode"] != "1" {
+			args = append(args, "--legacy")
+		}
+		return &clientcmdapi.ExecConfig{
+			APIVersion:      "client.authentication.k8s.io/v1beta1",
+			Command:         execCommand(legacyAuthPlugins["azure"]),
+			Args:            args,
+			InstallHint:     "Install kubelogin for Azure by following https://azure.github.io/kubelogin/install.html",
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+		}
+	case "gcp":
+		return &clientcmdapi.ExecConfig{
+			APIVersion:         "client.authentication.k8s.io/v1beta1",
+			Command:            execCommand(legacyAuthPlugins["gcp"]),
+			InstallHint:        "Install gke-gcloud-auth-plugin by following https://cloud.google.com/kubernetes-engine/docs/how-to/cluster-access-for-kubectl#install_plugin",
+			ProvideClusterInfo: true,
+			InteractiveMode:    clientcmdapi.IfAvailableExecInteractiveMode,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"runtime"
+	"testing"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func legacyAuthItems() []greenhousev1alpha1.ClusterKubeconfig {
+	user := func(name string, provider clientcmdapi.AuthProviderConfig) greenhousev1alpha1.ClusterKubeconfigAuthInfoItem {
+		return greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{
+			Name:     name,
+			AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{AuthProvider: provider},
+		}
+	}
+	return []greenhousev1alpha1.ClusterKubeconfig{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "aks"},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{user("aks", clientcmdapi.AuthProviderConfig{
+					Name:   "azure",
+					Config: map[string]string{"apiserver-id": "server", "client-id": "client", "tenant-id": "tenant", "config-mode": "1"},
+				})},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gke"},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{user("gke", clientcmdapi.AuthProviderConfig{Name: "gcp"})},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{user("prod", clientcmdapi.AuthProviderConfig{
+					Name:   "oidc",
+					Config: map[string]string{"idp-issuer-url": "https://issuer.example.com", "client-id": "cid"},
+				})},
+			}},
+		},
+	}
+}
+
+func TestLegacyAuthWarnings(t *testing.T) {
+	g := NewWithT(t)
+	prev := convertLegacyAuth
+	t.Cleanup(func() { convertLegacyAuth = prev })
+
+	convertLegacyAuth = false
+	g.Expect(legacyAuthWarnings(legacyAuthItems())).To(Equal(map[string][]string{
+		"aks": {`user "aks" uses the removed azure auth provider; sync with --convert-legacy-auth to use kubelogin`},
+		"gke": {`user "gke" uses the removed gcp auth provider; sync with --convert-legacy-auth to use gke-gcloud-auth-plugin`},
+	}))
+
+	convertLegacyAuth = true
+	g.Expect(legacyAuthWarnings(legacyAuthItems())).To(BeEmpty())
+}
+
+func TestBuildIncomingKubeconfig_ConvertsLegacyAuth(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec commands carry an .exe suffix on Windows")
+	}
+	g := NewWithT(t)
+	prevType, prevConvert := authType, convertLegacyAuth
+	t.Cleanup(func() { authType, convertLegacyAuth = prevType, prevConvert })
+	authType = "auth-provider"
+
+	convertLegacyAuth = false
+	cfg, err := buildIncomingKubeconfig(legacyAuthItems())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AuthInfos["aks"].AuthProvider.Name).To(Equal("azure"), "kept unless conversion is requested")
+
+	convertLegacyAuth = true
+	cfg, err = buildIncomingKubeconfig(legacyAuthItems())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AuthInfos["aks"].AuthProvider).To(BeNil())
+	g.Expect(cfg.AuthInfos["aks"].Exec.Command).To(Equal("kubelogin"))
+	g.Expect(cfg.AuthInfos["aks"].Exec.Args).To(Equal([]string{"get-token", "--login", "devicecode", "--environment", "AzurePublicCloud",
+		"--server-id", "server", "--client-id", "client", "--tenant-id", "tenant"}))
+	g.Expect(cfg.AuthInfos["gke"].Exec.Command).To(Equal("gke-gcloud-auth-plugin"))
+	g.Expect(cfg.AuthInfos["gke"].Exec.ProvideClusterInfo).To(BeTrue())
+	g.Expect(cfg.AuthInfos["prod"].AuthProvider.Name).To(Equal("oidc"), "other providers are not converted")
+}
+
+func TestLegacyAuthExec_AzureLegacyAudience(t *testing.T) {
+	g := NewWithT(t)
+	exec := legacyAuthExec(clientcmdapi.AuthProviderConfig{Name: "azure", Config: map[string]string{"environment": "AzureChinaCloud"}})
+	g.Expect(exec.Args).To(ContainElements("AzureChinaCloud", "--legacy"))
+	g.Expect(legacyAuthExec(clientcmdapi.AuthProviderConfig{Name: "oidc"})).To(BeNil())
+}
@@ -0,0 +1,432 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the clusters of a Greenhouse organization",
+	Long: `Lists the ClusterKubeconfigs of a Greenhouse organization with their
+readiness and age, without touching the local kubeconfig.
+
+With --watch, the list is followed by a line per change to a ClusterKubeconfig
+(added, modified or deleted) with its time and readiness, calling out clusters
+that became ready or not ready. This is handy while onboarding a cluster and
+waiting for it to become ready. Press Ctrl+C to stop.
+
+--sort-by orders the list by name, age (oldest first), readiness (not ready
+first) or the value of any label key (clusters without the label last).
+-o custom-columns prints the columns given as HEADER:JSONPATH pairs, with the
+paths evaluated against each ClusterKubeconfig like kubectl does.
+--group-by shows the clusters in one section per value of a label, with the
+number of clusters and ready clusters of each.
+
+When the Greenhouse Clusters owning the ClusterKubeconfigs can be read, the
+list also shows their access mode, Kubernetes version and when a cluster
+marked for deletion is going to be deleted, so decommissions do not come as
+a surprise. Clusters scheduled for deletion are also logged as warnings.
+
+Examples:
+  cloudctl list -n my-org
+
+  # Wait for a new cluster to become ready
+  cloudctl list -n my-org --watch
+
+  # Fleet inventory by region
+  cloudctl list --sort-by region -o custom-columns=NAME:.metadata.name,REGION:.metadata.labels.region`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runList,
+}
+
+func init() {
+	listCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
+	_ = listCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	listCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	listCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	listCmd.Flags().BoolP("watch", "w", false, "After listing, stream changes to the clusters until interrupted")
+	listCmd.Flags().String("sort-by", "name", "Sort clusters by name, age, readiness, or the value of a label key")
+	listCmd.Flags().String("group-by", "", "Show clusters in sections per value of this label key (e.g. region), with subtotals")
+	_ = listCmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions([]string{"name", "age", "readiness"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// sortClusterKubeconfigs sorts items by key: "name", "age" (oldest first),
+// "readiness" (not ready first) or any other value as a label key (clusters
+// without the label last). Ties are broken by name.
+func sortClusterKubeconfigs(items []v1alpha1.ClusterKubeconfig, key string) {
+	var compare func(a, b v1alpha1.ClusterKubeconfig) int
+	switch key {
+	case "", "name":
+		compare = func(_, _ v1alpha1.ClusterKubeconfig) int { return 0 }
+	case "age":
+		compare = func(a, b v1alpha1.ClusterKubeconfig) int {
+			return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+		}
+	case "readiness":
+		compare = func(a, b v1alpha1.ClusterKubeconfig) int {
+			return cmpBool(greenhouse.IsReady(a), greenhouse.IsReady(b))
+		}
+	default:
+		compare = func(a, b v1alpha1.ClusterKubeconfig) int { return compareLabel(a.Labels, b.Labels, key) }
+	}
+	slices.SortStableFunc(items, func(a, b v1alpha1.ClusterKubeconfig) int {
+		return cmp.Or(compare(a, b), strings.Compare(a.Name, b.Name))
+	})
+}
+
+// compareLabel orders label sets by the value of key, those without it last.
+func compareLabel(a, b map[string]string, key string) int {
+	va, vb := a[key], b[key]
+	return cmp.Or(cmpBool(va == "", vb == ""), strings.Compare(va, vb))
+}
+
+// cmpBool orders false before true.
+func cmpBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// readyReason returns the message of the Ready condition of ckc, or its
+// reason when there is no message.
+func readyReason(ckc v1alpha1.ClusterKubeconfig) string {
+	cond := ckc.Status.Conditions.GetConditionByType(greenhousemetav1alpha1.ReadyCondition)
+	if cond == nil {
+		return ""
+	}
+	return cmp.Or(cond.Message, string(cond.Reason))
+}
+
+// clusterListItem describes ckc for the list command, with the details of
+// the Cluster owning it when that is not nil.
+func clusterListItem(ckc v1alpha1.ClusterKubeconfig, cluster *v1alpha1.Cluster) output.ClusterListItem {
+	item := output.ClusterListItem{
+		Name:    ckc.Name,
+		Ready:   greenhouse.IsReady(ckc),
+		Created: ckc.CreationTimestamp.Time,
+		Labels:  ckc.Labels,
+	}
+	if !item.Ready {
+		item.Reason = readyReason(ckc)
+	}
+	if cluster != nil {
+		item.AccessMode = string(cluster.Spec.AccessMode)
+		item.KubernetesVersion = cluster.Status.KubernetesVersion
+		item.DeletionSchedule, item.DeletionScheduled = greenhouse.DeletionSchedule(*cluster)
+	}
+	return item
+}
+
+// listClusters returns the Clusters of namespace. They only add details to
+// the list, so failing to read them is logged rather than returned.
+func listClusters(ctx context.Context, ghClient greenhouse.Client, namespace string) []v1alpha1.Cluster {
+	clusters, err := ghClient.ListClusters(ctx, namespace)
+	if err != nil {
+		slog.Warn("Listing clusters without access mode, version and deletion schedule", "error", err)
+		return nil
+	}
+	for _, c := range clusters {
+		if at, ok := greenhouse.DeletionSchedule(c); ok {
+			attrs := []any{"cluster", c.Name}
+			if !at.IsZero() {
+				attrs = append(attrs, "at", at.Format(time.RFC3339))
+			}
+			slog.Warn("Cluster is scheduled for deletion", attrs...)
+		}
+	}
+	return clusters
+}
+
+// clusterEvent describes a change of ckc. prev is the ClusterKubeconfig before
+// the change, nil when it was added.
+func clusterEvent(typ output.ClusterEventType, ckc v1alpha1.ClusterKubeconfig, prev *v1alpha1.ClusterKubeconfig, now time.Time) output.ClusterEvent {
+	e := output.ClusterEvent{Time: now, Type: typ, Name: ckc.Name, Ready: greenhouse.IsReady(ckc)}
+	if typ == output.ClusterEventDeleted {
+		e.Ready = false
+		return e
+	}
+	if prev != nil {
+		wasReady := greenhouse.IsReady(*prev)
+		e.BecameReady = e.Ready && !wasReady
+		e.BecameNotReady = !e.Ready && wasReady
+	}
+	if !e.Ready {
+		e.Reason = readyReason(ckc)
+	}
+	return e
+}
+
+// clusterWatch follows the ClusterKubeconfigs of a namespace and reports each
+// change as an event.
+type clusterWatch struct {
+	watcher   greenhouse.Watcher
+	namespace string
+	emit      func(output.ClusterEvent) error
+	now       func() time.Time
+
+	known           map[string]v1alpha1.ClusterKubeconfig
+	resourceVersion string
+}
+
+// reset replaces the known ClusterKubeconfigs with a fresh list, reporting
+// the differences when there was an earlier one.
+func (w *clusterWatch) reset(items []v1alpha1.ClusterKubeconfig, resourceVersion string) error {
+	next := make(map[string]v1alpha1.ClusterKubeconfig, len(items))
+	for _, ckc := range items {
+		next[ckc.Name] = ckc
+	}
+	if w.known != nil {
+		for _, name := range slices.Sorted(maps.Keys(next)) {
+			ckc := next[name]
+			prev, ok := w.known[name]
+			var err error
+			switch {
+			case !ok:
+				err = w.emit(clusterEvent(output.ClusterEventAdded, ckc, nil, w.now()))
+			case prev.ResourceVersion != ckc.ResourceVersion:
+				err = w.emit(clusterEvent(output.ClusterEventModified, ckc, &prev, w.now()))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(w.known)) {
+			if _, ok := next[name]; !ok {
+				if err := w.emit(clusterEvent(output.ClusterEventDeleted, w.known[name], nil, w.now())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	w.known, w.resourceVersion = next, resourceVersion
+	return nil
+}
+
+// run reports changes until ctx is cancelled. A watch closed by the server is
+// resumed from the last resourceVersion seen; one that expired starts over
+// from a new list.
+func (w *clusterWatch) run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		watch, err := w.watcher.WatchClusterKubeconfigs(ctx, w.namespace, w.resourceVersion)
+		if greenhouse.WatchExpired(err) {
+			err = w.relist(ctx)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		err = w.follow(ctx, watch)
+		watch.Stop()
+		if greenhouse.WatchExpired(err) {
+			slog.Debug("ClusterKubeconfig watch expired, listing again", "namespace", w.namespace)
+			err = w.relist(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relist lists the ClusterKubeconfigs again after the watch expired.
+func (w *clusterWatch) relist(ctx context.Context) error {
+	items, resourceVersion, err := w.watcher.ListClusterKubeconfigsForWatch(ctx, w.namespace)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	return w.reset(items, resourceVersion)
+}
+
+// follow reports the events of watch until it ends.
+func (w *clusterWatch) follow(ctx context.Context, watch k8swatch.Interface) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-watch.ResultChan():
+			if !ok {
+				return nil
+			}
+			if e.Type == k8swatch.Error {
+				return apierrors.FromObject(e.Object)
+			}
+			ckc, ok := e.Object.(*v1alpha1.ClusterKubeconfig)
+			if !ok {
+				return fmt.Errorf("unexpected %T in ClusterKubeconfig watch", e.Object)
+			}
+			if ckc.ResourceVersion != "" {
+				w.resourceVersion = ckc.ResourceVersion
+			}
+			prev, known := w.known[ckc.Name]
+			var event output.ClusterEvent
+			switch e.Type {
+			case k8swatch.Added, k8swatch.Modified:
+				typ := output.ClusterEventModified
+				var before *v1alpha1.ClusterKubeconfig
+				if known {
+					before = &prev
+				} else {
+					typ = output.ClusterEventAdded
+				}
+				event = clusterEvent(typ, *ckc, before, w.now())
+				w.known[ckc.Name] = *ckc
+			case k8swatch.Deleted:
+				event = clusterEvent(output.ClusterEventDeleted, *ckc, nil, w.now())
+				delete(w.known, ckc.Name)
+			default:
+				continue
+			}
+			if err := w.emit(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	format, columns, err := parseOutputFlag(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	sortBy, groupBy := viper.GetString("sort-by"), viper.GetString("group-by")
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		if namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return err
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if !viper.GetBool("watch") {
+		items, err := ghClient.ListClusterKubeconfigs(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		return printClusterList(printer, namespace, items, listClusters(ctx, ghClient, namespace), sortBy, groupBy, columns)
+	}
+
+	watcher, ok := ghClient.(greenhouse.Watcher)
+	if !ok {
+		return errors.New("--watch is not supported by this Greenhouse client")
+	}
+	items, resourceVersion, err := watcher.ListClusterKubeconfigsForWatch(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if err := printClusterList(printer, namespace, items, listClusters(ctx, ghClient, namespace), sortBy, groupBy, columns); err != nil {
+		return err
+	}
+	watch := &clusterWatch{
+		watcher:   watcher,
+		namespace: namespace,
+		emit:      func(e output.ClusterEvent) error { return printer.Print(e) },
+		now:       time.Now,
+	}
+	if err := watch.reset(items, resourceVersion); err != nil {
+		return err
+	}
+	return watch.run(ctx)
+}
+
+// printClusterList prints items sorted by sortBy and grouped by the label
+// groupBy, in columns when custom columns were requested. clusters are the
+// Greenhouse Clusters the items are described with.
+func printClusterList(printer output.Printer, namespace string, items []v1alpha1.ClusterKubeconfig, clusters []v1alpha1.Cluster, sortBy, groupBy string, columns []customColumn) error {
+	items = slices.Clone(items)
+	sortClusterKubeconfigs(items, sortBy)
+	if groupBy != "" {
+		slices.SortStableFunc(items, func(a, b v1alpha1.ClusterKubeconfig) int { return compareLabel(a.Labels, b.Labels, groupBy) })
+	}
+	if columns == nil {
+		result := clusterListResult(namespace, items, clusters)
+		if groupBy != "" {
+			result.GroupBy = groupBy
+			for _, c := range result.Clusters {
+				result.Groups = countClusterGroup(result.Groups, c.Labels[groupBy], c.Ready)
+			}
+		}
+		return printer.Print(result)
+	}
+	objs := make([]runtime.Object, len(items))
+	for i := range items {
+		objs[i] = &items[i]
+	}
+	result, err := customColumnsResult(columns, objs)
+	if err != nil {
+		return err
+	}
+	return printer.Print(result)
+}
+
+// countClusterGroup counts a cluster with the label value into groups, which
+// are in the order their values were first counted.
+func countClusterGroup(groups []output.ClusterGroup, value string, ready bool) []output.ClusterGroup {
+	i := slices.IndexFunc(groups, func(g output.ClusterGroup) bool { return g.Value == value })
+	if i < 0 {
+		groups = append(groups, output.ClusterGroup{Value: value})
+		i = len(groups) - 1
+	}
+	groups[i].Clusters++
+	if ready {
+		groups[i].Ready++
+	}
+	return groups
+}
+
+// clusterListResult describes items in their order, each with the Cluster
+// owning it among clusters.
+func clusterListResult(namespace string, items []v1alpha1.ClusterKubeconfig, clusters []v1alpha1.Cluster) output.ClusterListResult {
+	result := output.ClusterListResult{Namespace: namespace, Clusters: []output.ClusterListItem{}}
+	for _, ckc := range items {
+		result.Clusters = append(result.Clusters, clusterListItem(ckc, greenhouse.OwningCluster(ckc, clusters)))
+	}
+	return result
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// readyCKC returns a watched ClusterKubeconfig with its Ready condition set.
+func readyCKC(name, rv string, ready bool, message string) *v1alpha1.ClusterKubeconfig {
+	ckc := watchedCKC(name, rv)
+	cond := greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", "")
+	if !ready {
+		cond = greenhousemetav1alpha1.FalseCondition(greenhousemetav1alpha1.ReadyCondition, "Onboarding", message)
+	}
+	ckc.Status.Conditions.SetConditions(cond)
+	return ckc
+}
+
+func TestClusterListResult(t *testing.T) {
+	g := NewWithT(t)
+	result := clusterListResult("org", []v1alpha1.ClusterKubeconfig{
+		*readyCKC("a", "1", true, ""),
+		*readyCKC("b", "1", false, "waiting for nodes"),
+	}, nil)
+	g.Expect(result.Namespace).To(Equal("org"))
+	g.Expect(result.Clusters).To(HaveLen(2))
+	g.Expect(result.Clusters[0]).To(Equal(output.ClusterListItem{Name: "a", Ready: true}))
+	g.Expect(result.Clusters[1]).To(Equal(output.ClusterListItem{Name: "b", Reason: "waiting for nodes"}))
+}
+
+func TestClusterListResult_ClusterDetails(t *testing.T) {
+	g := NewWithT(t)
+	ghClient := &stubGreenhouseClient{clusters: []v1alpha1.Cluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Spec:       v1alpha1.ClusterSpec{AccessMode: v1alpha1.ClusterAccessModeDirect},
+			Status:     v1alpha1.ClusterStatus{KubernetesVersion: "v1.31.2"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Annotations: map[string]string{
+				"greenhouse.sap/delete-cluster":    "true",
+				"greenhouse.sap/deletion-schedule": "2026-10-20 12:00:00",
+			}},
+		},
+	}}
+	clusters := listClusters(context.Background(), ghClient, "org")
+
+	result := clusterListResult("org", []v1alpha1.ClusterKubeconfig{
+		*readyCKC("a", "1", true, ""),
+		*readyCKC("b", "1", true, ""),
+		*readyCKC("c", "1", true, ""),
+	}, clusters)
+	g.Expect(result.Clusters[0]).To(Equal(output.ClusterListItem{Name: "a", Ready: true, AccessMode: "direct", KubernetesVersion: "v1.31.2"}))
+	g.Expect(result.Clusters[1]).To(Equal(output.ClusterListItem{
+		Name: "b", Ready: true, DeletionScheduled: true, DeletionSchedule: time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC),
+	}))
+	g.Expect(result.Clusters[2]).To(Equal(output.ClusterListItem{Name: "c", Ready: true}))
+}
+
+func TestClusterWatch(t *testing.T) {
+	g := NewWithT(t)
+	stub := &stubWatcher{watches: make(chan *k8swatch.FakeWatcher)}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := make(chan output.ClusterEvent, 10)
+	w := &clusterWatch{
+		watcher:   stub,
+		namespace: "org",
+		emit:      func(e output.ClusterEvent) error { events <- e; return nil },
+		now:       func() time.Time { return now },
+	}
+	g.Expect(w.reset([]v1alpha1.ClusterKubeconfig{*readyCKC("a", "3", true, "")}, "10")).To(Succeed())
+	g.Expect(events).To(BeEmpty(), "the first list is not reported as changes")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.run(ctx) }()
+
+	fake := k8swatch.NewFake()
+	stub.watches <- fake
+	fake.Add(readyCKC("new", "11", false, "waiting for nodes"))
+	g.Eventually(events).Should(Receive(Equal(output.ClusterEvent{
+		Time: now, Type: output.ClusterEventAdded, Name: "new", Reason: "waiting for nodes",
+	})))
+	fake.Modify(readyCKC("new", "12", true, ""))
+	g.Eventually(events).Should(Receive(Equal(output.ClusterEvent{
+		Time: now, Type: output.ClusterEventModified, Name: "new", Ready: true, BecameReady: true,
+	})))
+	fake.Delete(readyCKC("a", "13", true, ""))
+	g.Eventually(events).Should(Receive(Equal(output.ClusterEvent{Time: now, Type: output.ClusterEventDeleted, Name: "a"})))
+
+	// An expired watch lists again and reports what changed meanwhile.
+	stub.mu.Lock()
+	stub.items = []v1alpha1.ClusterKubeconfig{*readyCKC("new", "14", false, "")}
+	stub.mu.Unlock()
+	gone := apierrors.NewResourceExpired("too old").ErrStatus
+	fake.Error(&gone)
+	g.Eventually(events).Should(Receive(Equal(output.ClusterEvent{
+		Time: now, Type: output.ClusterEventModified, Name: "new", BecameNotReady: true, Reason: "Onboarding",
+	})))
+	stub.watches <- k8swatch.NewFake()
+	_, from := stub.calls()
+	g.Expect(from).To(Equal([]string{"10", "10"}))
+
+	cancel()
+	g.Eventually(done).Should(Receive(BeNil()))
+}
+
+func TestSortClusterKubeconfigs(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ckc := func(name string, ready bool, age int, labels map[string]string) v1alpha1.ClusterKubeconfig {
+		c := *readyCKC(name, "1", ready, "")
+		c.CreationTimestamp = metav1.NewTime(base.Add(-time.Duration(age) * time.Hour))
+		c.Labels = labels
+		return c
+	}
+	items := []v1alpha1.ClusterKubeconfig{
+		ckc("c", true, 1, map[string]string{"region": "eu"}),
+		ckc("a", false, 2, nil),
+		ckc("b", true, 3, map[string]string{"region": "ap"}),
+		ckc("d", false, 1, map[string]string{"region": "eu"}),
+	}
+	for key, want := range map[string][]string{
+		"name":      {"a", "b", "c", "d"},
+		"age":       {"b", "a", "c", "d"},
+		"readiness": {"a", "d", "b", "c"},
+		"region":    {"b", "c", "d", "a"},
+	} {
+		t.Run(key, func(t *testing.T) {
+			g := NewWithT(t)
+			sorted := slices.Clone(items)
+			sortClusterKubeconfigs(sorted, key)
+			var names []string
+			for _, c := range sorted {
+				names = append(names, c.Name)
+			}
+			g.Expect(names).To(Equal(want))
+		})
+	}
+}
+
+func TestPrintClusterList_CustomColumns(t *testing.T) {
+	g := NewWithT(t)
+	format, columns, err := parseOutputFlag("custom-columns=NAME:.metadata.name,REGION:metadata.labels.region,READY:{.status.statusConditions.conditions[?(@.type==\"Ready\")].status}")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(format).To(Equal(output.FormatText))
+
+	eu := *readyCKC("eu-1", "1", true, "")
+	eu.Labels = map[string]string{"region": "eu"}
+	var out bytes.Buffer
+	printer := output.New(format, false, &out)
+	g.Expect(printClusterList(printer, "org", []v1alpha1.ClusterKubeconfig{eu, *readyCKC("ap-1", "1", false, "")}, nil, "name", "", columns)).To(Succeed())
+	g.Expect(out.String()).To(Equal("NAME   REGION   READY\nap-1   <none>   False\neu-1   eu       True\n"))
+
+	for _, bad := range []string{"custom-columns=", "custom-columns=NAME", "custom-columns=NAME:{.metadata.name", "table"} {
+		_, _, err := parseOutputFlag(bad)
+		g.Expect(err).To(HaveOccurred(), bad)
+	}
+}
+
+func TestPrintClusterList_GroupBy(t *testing.T) {
+	g := NewWithT(t)
+	ckc := func(name, region string, ready bool) v1alpha1.ClusterKubeconfig {
+		c := *readyCKC(name, "1", ready, "")
+		if region != "" {
+			c.Labels = map[string]string{"region": region}
+		}
+		return c
+	}
+	items := []v1alpha1.ClusterKubeconfig{ckc("c", "eu", true), ckc("a", "", true), ckc("b", "eu", false), ckc("d", "ap", true)}
+
+	var out bytes.Buffer
+	g.Expect(printClusterList(output.New(output.FormatJSON, false, &out), "org", items, nil, "name", "region", nil)).To(Succeed())
+	var result output.ClusterListResult
+	g.Expect(json.Unmarshal(out.Bytes(), &result)).To(Succeed())
+	var names []string
+	for _, c := range result.Clusters {
+		names = append(names, c.Name)
+	}
+	g.Expect(names).To(Equal([]string{"d", "b", "c", "a"}))
+	g.Expect(result.Groups).To(Equal([]output.ClusterGroup{
+		{Value: "ap", Clusters: 1, Ready: 1},
+		{Value: "eu", Clusters: 2, Ready: 1},
+		{Value: "", Clusters: 1, Ready: 1},
+	}))
+
+	out.Reset()
+	g.Expect(printClusterList(output.New(output.FormatText, false, &out), "org", items, nil, "name", "region", nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("region=eu (2 clusters, 1 ready)\nNAME  READY  AGE\nb     false"))
+	g.Expect(out.String()).To(ContainSubstring("region=<none> (1 cluster, 1 ready)"))
+	g.Expect(out.String()).To(HaveSuffix("\nTotal: 4 clusters, 3 ready\n"))
+}
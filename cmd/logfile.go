@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logFileTimeFormat is the timestamp inserted into the names of rotated log
+// files. It sorts chronologically and contains no characters that are invalid
+// in Windows file names.
+const logFileTimeFormat = "20060102T150405.000"
+
+// rotatingLogFile is an io.Writer appending to a log file. When a write would
+// grow the file beyond maxSize bytes the file is renamed to
+// <name>-<timestamp><ext> and a new one is started. Rotated files older than
+// maxAge are removed. A zero maxSize or maxAge disables the respective limit.
+type rotatingLogFile struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// openLogFile opens path for appending, creating it and its directory as
+// needed, and removes expired rotated files.
+func openLogFile(path string, maxSize int64, maxAge time.Duration) (*rotatingLogFile, error) {
+	l := &rotatingLogFile{path: path, maxSize: maxSize, maxAge: maxAge, now: time.Now}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	l.prune()
+	return l, nil
+}
+
+func (l *rotatingLogFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+func (l *rotatingLogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(p)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file.
+func (l *rotatingLogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// rotate moves the current file aside and starts a new one.
+func (l *rotatingLogFile) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	l.f = nil
+	if err := os.Rename(l.path, l.backupName(l.now())); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.prune()
+	return nil
+}
+
+// backupName returns the name a file rotated at t is renamed to.
+func (l *rotatingLogFile) backupName(t time.Time) string {
+	ext := filepath.Ext(l.path)
+	return strings.TrimSuffix(l.path, ext) + "-" + t.UTC().Format(logFileTimeFormat) + ext
+}
+
+// prune removes rotated files whose timestamp is older than maxAge. Errors
+// are ignored; a leftover file is no reason to fail the command.
+func (l *rotatingLogFile) prune() {
+	if l.maxAge <= 0 {
+		return
+	}
+	ext := filepath.Ext(l.path)
+	base := strings.TrimSuffix(filepath.Base(l.path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(l.path))
+	if err != nil {
+		return
+	}
+	cutoff := l.now().Add(-l.maxAge)
+	for _, e := range entries {
+		name := e.Name()
+		stamp, ok := strings.CutPrefix(name, base)
+		if !ok || e.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ext)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(logFileTimeFormat, stamp)
+		if err != nil || !t.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(l.path), name)); err != nil {
+			slog.Debug("failed to remove old log file", "file", name, "error", err)
+		}
+	}
+}
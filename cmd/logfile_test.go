@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRotatingLogFile_RotatesBySize(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "cloudctl.log")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	l, err := openLogFile(path, 10, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	l.now = func() time.Time { return now }
+	t.Cleanup(func() { _ = l.Close() })
+
+	_, err = l.Write([]byte("12345678\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = l.Write([]byte("abc\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	rotated, err := os.ReadFile(filepath.Join(dir, "logs", "cloudctl-20260301T120000.000.log"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(rotated)).To(Equal("12345678\n"))
+	current, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(current)).To(Equal("abc\n"))
+}
+
+func TestRotatingLogFile_PrunesByAge(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cloudctl.log")
+	old := filepath.Join(dir, "cloudctl-"+time.Now().Add(-48*time.Hour).UTC().Format(logFileTimeFormat)+".log")
+	recent := filepath.Join(dir, "cloudctl-"+time.Now().Add(-time.Hour).UTC().Format(logFileTimeFormat)+".log")
+	unrelated := filepath.Join(dir, "cloudctl-notes.log")
+	for _, f := range []string{old, recent, unrelated} {
+		g.Expect(os.WriteFile(f, []byte("x"), 0o600)).To(Succeed())
+	}
+
+	l, err := openLogFile(path, 0, 24*time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	t.Cleanup(func() { _ = l.Close() })
+
+	g.Expect(old).NotTo(BeAnExistingFile())
+	g.Expect(recent).To(BeAnExistingFile())
+	g.Expect(unrelated).To(BeAnExistingFile())
+}
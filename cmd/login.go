@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login [context]",
+	Short: "Log in to the OIDC issuer of a context",
+	Long: `Obtains OIDC tokens for a kubeconfig context ahead of time.
+
+With --device the OAuth 2.0 device authorization grant is used: cloudctl
+prints a URL and a one-time code that you open on any other device, so no
+browser is needed on the host itself (SSH sessions, bastions, containers).
+
+The issuer, client ID and scopes are read from the context's user entry as
+written by sync. Tokens for auth-provider entries are stored in the kubeconfig;
+tokens for exec-plugin entries are stored in the cloudctl token cache, where
+"cloudctl get-token" (sync --auth-type=cloudctl) picks them up.
+
+Without an argument the --context or current context is used; otherwise the
+argument is fuzzy-matched against context names.
+
+Examples:
+  # Log in on a headless host
+  cloudctl login --device
+
+  # Log in for a specific context
+  cloudctl login prod-eu --device`,
+	Args:              cobra.ArbitraryArgs,
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runLogin,
+}
+
+func init() {
+	loginCmd.Flags().Bool("device", false, "Use the device authorization grant (no local browser required)")
+	loginCmd.Flags().String("token-cache-dir", defaultCloudctlTokenCacheDir(), "Directory for cached OIDC tokens of exec-plugin entries")
+}
+
+// defaultCloudctlTokenCacheDir returns ~/.kube/cache/cloudctl.
+func defaultCloudctlTokenCacheDir() string {
+	return filepath.Join(filepath.Dir(defaultTokenCacheDir()), "cloudctl")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	if !viper.GetBool("device") {
+		return fmt.Errorf("only --device login is supported; browser logins happen automatically on first kubectl use")
+	}
+	if nonInteractive() {
+		return errors.New("login waits for the device code to be entered and cannot run with --non-interactive")
+	}
+
+	po := kubeconfigPathOptions(path)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	contextName := cmp.Or(viper.GetString("context"), cfg.CurrentContext)
+	if len(args) > 0 {
+		if contextName, err = selectTarget("context", strings.Join(args, " "), contextItems(cfg)); err != nil {
+			return err
+		}
+	}
+	kctx := cfg.Contexts[contextName]
+	if kctx == nil {
+		return fmt.Errorf("context %q not found in %s", contextName, displayKubeconfig(path))
+	}
+	authInfo := cfg.AuthInfos[kctx.AuthInfo]
+	params, caData, err := oidcParamsFromAuthInfo(authInfo)
+	if err != nil {
+		return fmt.Errorf("context %q: %w", contextName, err)
+	}
+
+	httpClient, err := oidcHTTPClient(caData)
+	if err != nil {
+		return err
+	}
+	provider, err := oidc.Discover(cmd.Context(), httpClient, params.IssuerURL)
+	if err != nil {
+		return err
+	}
+	da, err := provider.StartDevice(cmd.Context(), params)
+	if err != nil {
+		return err
+	}
+	errW := cmd.ErrOrStderr()
+	_, _ = fmt.Fprintf(errW, "To sign in, open %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+	if da.VerificationURIComplete != "" {
+		_, _ = fmt.Fprintf(errW, "or open %s directly.\n", da.VerificationURIComplete)
+	}
+	tok, err := provider.PollDevice(cmd.Context(), params, da)
+	if err != nil {
+		return err
+	}
+
+	result := output.LoginResult{Context: contextName, Issuer: params.IssuerURL, Expiry: tok.Expiry}
+	if authInfo.AuthProvider != nil {
+		authInfo.AuthProvider.Config["id-token"] = tok.IDToken
+		if tok.RefreshToken != "" {
+			authInfo.AuthProvider.Config["refresh-token"] = tok.RefreshToken
+		}
+		if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+			return fmt.Errorf("failed to store tokens in kubeconfig: %w", err)
+		}
+		if err := auditFieldChange("login", path, "user", kctx.AuthInfo, "id-token", "", "<redacted>"); err != nil {
+			return err
+		}
+		result.StoredIn = displayKubeconfig(path)
+	} else {
+		dir := viper.GetString("token-cache-dir")
+		if !cmd.Flags().Changed("token-cache-dir") {
+			// Use the directory the exec entry itself points get-token at.
+			dir = cmp.Or(execArgValue(authInfo.Exec.Args, "--token-cache-dir"), dir)
+		}
+		kind, store, err := execTokenStore(authInfo.Exec.Args, dir)
+		if err != nil {
+			return err
+		}
+		result.StoredIn = dir
+		if kind == tokenStoreKeyring {
+			result.StoredIn = "keyring"
+		}
+		if err := store.Save(params, tok); err != nil {
+			return fmt.Errorf("failed to store tokens in %s: %w", result.StoredIn, err)
+		}
+	}
+	slog.Debug("stored OIDC tokens", "context", contextName, "location", result.StoredIn)
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
+
+// oidcParamsFromAuthInfo extracts the OIDC client settings from a user entry,
+// either a legacy oidc auth-provider or an exec entry with kubelogin-style
+// --oidc-* arguments (as produced by buildKubeloginArgs). It also returns the
+// issuer CA bundle, if one is configured.
+func oidcParamsFromAuthInfo(ai *clientcmdapi.AuthInfo) (oidc.Params, []byte, error) {
+	var p oidc.Params
+	var caData []byte
+	switch {
+	case ai == nil:
+		return p, nil, fmt.Errorf("user entry not found")
+	case ai.AuthProvider != nil && ai.AuthProvider.Name == "oidc":
+		c := ai.AuthProvider.Config
+		p.IssuerURL = c["idp-issuer-url"]
+		p.ClientID = c["client-id"]
+		p.ClientSecret = c["client-secret"]
+		for s := range strings.SplitSeq(c["extra-scopes"], ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				p.ExtraScopes = append(p.ExtraScopes, s)
+			}
+		}
+		p.ExtraParams = parseExtraParams(c["auth-request-extra-params"])
+		if v := c["idp-certificate-authority-data"]; v != "" {
+			data, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return p, nil, fmt.Errorf("invalid idp-certificate-authority-data: %w", err)
+			}
+			caData = data
+		}
+	case ai.Exec != nil:
+		for _, arg := range ai.Exec.Args {
+			name, value, _ := strings.Cut(arg, "=")
+			switch name {
+			case "--oidc-issuer-url":
+				p.IssuerURL = value
+			case "--oidc-client-id":
+				p.ClientID = value
+			case "--oidc-client-secret":
+				p.ClientSecret = value
+			case "--oidc-extra-scope":
+				p.ExtraScopes = append(p.ExtraScopes, value)
+			case "--oidc-auth-request-extra-params":
+				p.ExtraParams = parseExtraParams(value)
+			case "--certificate-authority-data":
+				data, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return p, nil, fmt.Errorf("invalid --certificate-authority-data: %w", err)
+				}
+				caData = data
+			}
+		}
+	default:
+		return p, nil, fmt.Errorf("user entry does not use OIDC")
+	}
+	if p.IssuerURL == "" || p.ClientID == "" {
+		return p, nil, fmt.Errorf("user entry has no OIDC issuer URL or client ID")
+	}
+	return p, caData, nil
+}
+
+// execArgValue returns the value of the last --name=value argument, which
+// wins when the flag is parsed, or "".
+func execArgValue(args []string, name string) string {
+	var value string
+	for _, arg := range args {
+		if k, v, ok := strings.Cut(arg, "="); ok && k == name {
+			value = v
+		}
+	}
+	return value
+}
+
+// parseExtraParams parses "k1=v1,k2=v2" as used by auth-request-extra-params.
+func parseExtraParams(s string) map[string]string {
+	var m map[string]string
+	for kv := range strings.SplitSeq(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			if m == nil {
+				m = map[string]string{}
+			}
+			m[k] = strings.TrimSpace(v)
+		}
+	}
+	return m
+}
+
+// oidcHTTPClient returns an HTTP client for the issuer. When caData is set it
+// is the only trusted CA, matching how kubectl treats idp-certificate-authority.
+func oidcHTTPClient(caData []byte) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(caData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("failed to parse issuer CA data")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: &tracingRoundTripper{next: transport}}, nil
+}
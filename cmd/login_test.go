@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestOIDCParamsFromAuthInfo_AuthProvider(t *testing.T) {
+	g := NewWithT(t)
+	ai := &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name: "oidc",
+		Config: map[string]string{
+			"idp-issuer-url":            "https://issuer",
+			"client-id":                 "cid",
+			"client-secret":             "sec",
+			"extra-scopes":              "groups, email",
+			"auth-request-extra-params": "connector_id=ldap",
+		},
+	}}
+
+	p, ca, err := oidcParamsFromAuthInfo(ai)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(BeNil())
+	g.Expect(p).To(Equal(oidc.Params{
+		IssuerURL: "https://issuer", ClientID: "cid", ClientSecret: "sec",
+		ExtraScopes: []string{"groups", "email"}, ExtraParams: map[string]string{"connector_id": "ldap"},
+	}))
+}
+
+func TestOIDCParamsFromAuthInfo_ExecRoundTripsKubeloginArgs(t *testing.T) {
+	g := NewWithT(t)
+	cfg := map[string]string{
+		"idp-issuer-url": "https://issuer",
+		"client-id":      "cid",
+		"extra-scopes":   "groups",
+	}
+	ai := &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command: "kubelogin",
+		Args:    buildKubeloginArgs(cfg, nil, "/tmp/cache"),
+	}}
+
+	p, _, err := oidcParamsFromAuthInfo(ai)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.IssuerURL).To(Equal("https://issuer"))
+	g.Expect(p.ClientID).To(Equal("cid"))
+	g.Expect(p.ExtraScopes).To(Equal([]string{"groups"}))
+}
+
+func TestOIDCParamsFromAuthInfo_Errors(t *testing.T) {
+	g := NewWithT(t)
+
+	_, _, err := oidcParamsFromAuthInfo(nil)
+	g.Expect(err).To(HaveOccurred())
+	_, _, err = oidcParamsFromAuthInfo(&clientcmdapi.AuthInfo{Token: "static"})
+	g.Expect(err).To(MatchError("user entry does not use OIDC"))
+	_, _, err = oidcParamsFromAuthInfo(&clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{Command: "aws"}})
+	g.Expect(err).To(MatchError(ContainSubstring("no OIDC issuer URL")))
+}
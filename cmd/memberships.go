@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+var membershipsCmd = &cobra.Command{
+	Use:   "memberships [cluster]",
+	Short: "Show the roles your Greenhouse teams give you on each cluster",
+	Long: `Resolves the Greenhouse Teams you are a member of and the TeamRoleBindings
+that grant you roles, through a team or directly, and prints the roles you
+hold on each cluster and in which namespaces. Use it to see what a context
+will allow before trying. Roles whose RBAC objects have not been created on
+the cluster successfully are marked as not applied.
+
+With a cluster name, only the roles on that cluster are shown. --as shows
+the memberships of another user.
+
+Examples:
+  cloudctl memberships -n my-org
+  cloudctl memberships prod-eu
+  cloudctl memberships -o json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRemoteClusterNames,
+	PreRunE:           bindCommandFlags,
+	RunE:              runMemberships,
+}
+
+func init() {
+	membershipsCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
+	_ = membershipsCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	membershipsCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	membershipsCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	membershipsCmd.Flags().String("as", "", "Username to show the memberships of, impersonated on the Greenhouse cluster")
+}
+
+// resolveMemberships returns the teams of the current user in namespace and
+// the roles they hold on cluster, or on every cluster when it is empty.
+func resolveMemberships(ctx context.Context, ghClient greenhouse.Client, namespace, cluster string) (output.MembershipsResult, error) {
+	result := output.MembershipsResult{Namespace: namespace, Teams: []string{}, Grants: []output.MembershipRole{}}
+	username, err := ghClient.WhoAmI(ctx)
+	if err != nil {
+		return result, err
+	}
+	teams, err := ghClient.ListTeams(ctx, namespace)
+	if err != nil {
+		return result, err
+	}
+	bindings, err := ghClient.ListTeamRoleBindings(ctx, namespace)
+	if err != nil {
+		return result, err
+	}
+	result.User = username
+	teamNames := greenhouse.UserTeams(username, teams)
+	if teamNames != nil {
+		result.Teams = teamNames
+	}
+	for _, grant := range greenhouse.UserRoleGrants(username, teamNames, bindings) {
+		if cluster != "" && grant.Cluster != cluster {
+			continue
+		}
+		result.Grants = append(result.Grants, output.MembershipRole{
+			Cluster:    grant.Cluster,
+			Role:       grant.TeamRole,
+			Team:       grant.Team,
+			Namespaces: grant.Namespaces,
+			Applied:    grant.Applied,
+		})
+	}
+	return result, nil
+}
+
+func runMemberships(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		if namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return err
+		}
+	}
+	var cluster string
+	if len(args) > 0 {
+		cluster = args[0]
+	}
+	result, err := resolveMemberships(ctx, ghClient, namespace, cluster)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
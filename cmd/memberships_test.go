@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	greenhousev1alpha2 "github.com/cloudoperators/greenhouse/api/v1alpha2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestResolveMemberships(t *testing.T) {
+	g := NewWithT(t)
+	propagated := func(clusters ...string) greenhousev1alpha2.TeamRoleBindingStatus {
+		var status greenhousev1alpha2.TeamRoleBindingStatus
+		for _, c := range clusters {
+			status.PropagationStatus = append(status.PropagationStatus, greenhousev1alpha2.PropagationStatus{
+				ClusterName: c,
+				Condition:   greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", ""),
+			})
+		}
+		return status
+	}
+	c := &stubGreenhouseClient{
+		username: "jane@example.com",
+		teams: []greenhousev1alpha1.Team{{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Status:     greenhousev1alpha1.TeamStatus{Members: []greenhousev1alpha1.User{{Email: "jane@example.com"}}},
+		}},
+		bindings: []greenhousev1alpha2.TeamRoleBinding{
+			{
+				Spec:   greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "platform", TeamRoleRef: "cluster-admin"},
+				Status: propagated("eu-1", "us-1"),
+			},
+			{
+				Spec:   greenhousev1alpha2.TeamRoleBindingSpec{Usernames: []string{"jane@example.com"}, TeamRoleRef: "viewer", Namespaces: []string{"kube-system"}},
+				Status: propagated("us-1"),
+			},
+			{
+				Spec:   greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "storage", TeamRoleRef: "cluster-admin"},
+				Status: propagated("storage-1"),
+			},
+		},
+	}
+
+	result, err := resolveMemberships(context.Background(), c, "org", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.User).To(Equal("jane@example.com"))
+	g.Expect(result.Teams).To(Equal([]string{"platform"}))
+	g.Expect(result.Grants).To(Equal([]output.MembershipRole{
+		{Cluster: "eu-1", Role: "cluster-admin", Team: "platform", Applied: true},
+		{Cluster: "us-1", Role: "cluster-admin", Team: "platform", Applied: true},
+		{Cluster: "us-1", Role: "viewer", Namespaces: []string{"kube-system"}, Applied: true},
+	}))
+
+	result, err = resolveMemberships(context.Background(), c, "org", "eu-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Grants).To(HaveLen(1))
+
+	c.username = "bob@example.com"
+	result, err = resolveMemberships(context.Background(), c, "org", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Teams).To(BeEmpty())
+	g.Expect(result.Grants).To(BeEmpty())
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsRegistry holds cloudctl's own metrics. A dedicated registry keeps
+// the exposition free of collectors registered globally by dependencies.
+var metricsRegistry = prometheus.NewRegistry()
+
+// syncMetrics is updated by every sync cycle. It is only exposed over HTTP
+// when --metrics-address is set, but recording is cheap enough to do always.
+var syncMetrics = newSyncMetricsSet(metricsRegistry)
+
+type syncMetricsSet struct {
+	runs            *prometheus.CounterVec
+	entriesAdded    prometheus.Counter
+	entriesRemoved  prometheus.Counter
+	apiErrors       prometheus.Counter
+	duration        prometheus.Histogram
+	lastSuccessTime prometheus.Gauge
+}
+
+func newSyncMetricsSet(reg prometheus.Registerer) *syncMetricsSet {
+	m := &syncMetricsSet{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cloudctl_sync_runs_total",
+			Help: "Number of sync cycles, partitioned by result (success or failure).",
+		}, []string{"result"}),
+		entriesAdded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cloudctl_sync_entries_added_total",
+			Help: "Number of managed kubeconfig contexts added by sync.",
+		}),
+		entriesRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cloudctl_sync_entries_removed_total",
+			Help: "Number of managed kubeconfig contexts removed by sync.",
+		}),
+		apiErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cloudctl_greenhouse_api_errors_total",
+			Help: "Number of failed requests to the Greenhouse API.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cloudctl_sync_duration_seconds",
+			Help:    "Duration of sync cycles in seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+		}),
+		lastSuccessTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cloudctl_sync_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful sync cycle.",
+		}),
+	}
+	// Pre-create both result series so they are exported as 0 before the first run.
+	m.runs.WithLabelValues("success")
+	m.runs.WithLabelValues("failure")
+	reg.MustRegister(m.runs, m.entriesAdded, m.entriesRemoved, m.apiErrors, m.duration, m.lastSuccessTime)
+	return m
+}
+
+// observe records the result of one sync cycle.
+func (m *syncMetricsSet) observe(outcome syncOutcome, err error, d time.Duration) {
+	m.duration.Observe(d.Seconds())
+	if err != nil {
+		m.runs.WithLabelValues("failure").Inc()
+		return
+	}
+	m.runs.WithLabelValues("success").Inc()
+	m.entriesAdded.Add(float64(outcome.Added))
+	m.entriesRemoved.Add(float64(outcome.Removed))
+	m.lastSuccessTime.SetToCurrentTime()
+}
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// metricsHandler returns the HTTP handler serving /metrics.
+func metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// serveMetrics starts an HTTP server exposing /metrics on addr in the
+// background and returns a function that shuts it down.
+func serveMetrics(addr string) (func(), error) {
+	return serveHTTP("metrics", addr, metricsHandler())
+}
+
+// serveHTTP starts an HTTP server for handler on addr in the background and
+// returns a function that shuts it down. name labels errors and logs.
+func serveHTTP(name, addr string, handler http.Handler) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s address %q: %w", name, addr, err)
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "server", name, "error", err)
+		}
+	}()
+	slog.Info("serving HTTP", "server", name, "address", ln.Addr().String())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSyncMetricsObserve(t *testing.T) {
+	g := NewWithT(t)
+	m := newSyncMetricsSet(prometheus.NewRegistry())
+
+	m.observe(syncOutcome{Added: 3, Removed: 1}, nil, time.Second)
+	m.observe(syncOutcome{Added: 5}, errors.New("boom"), time.Second)
+
+	g.Expect(testutil.ToFloat64(m.runs.WithLabelValues("success"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(m.runs.WithLabelValues("failure"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(m.entriesAdded)).To(Equal(3.0), "failed cycles must not count entries")
+	g.Expect(testutil.ToFloat64(m.entriesRemoved)).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(m.lastSuccessTime)).To(BeNumerically(">", 0))
+}
+
+func TestServeMetrics(t *testing.T) {
+	g := NewWithT(t)
+
+	stop, err := serveMetrics("127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	stop()
+
+	_, err = serveMetrics("not-an-address")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMetricsHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring("cloudctl_sync_runs_total"))
+	g.Expect(rec.Body.String()).To(ContainSubstring("cloudctl_greenhouse_api_errors_total"))
+}
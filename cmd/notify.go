@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchHooks are called by the sync --watch loop.
+type watchHooks struct {
+	// onChange is called when the clusters or their readiness differ from
+	// the previous successful cycle.
+	onChange []func(fleetChange)
+	// onCycle is called after every cycle with its error and the number of
+	// consecutive failed cycles.
+	onCycle []func(err error, failures int)
+}
+
+// fleetChange describes how the synced clusters differ between two watch
+// cycles. Names are managed context names, sorted.
+type fleetChange struct {
+	Added    []string
+	Removed  []string
+	Ready    []string
+	NotReady []string
+}
+
+// diffFleet compares the cluster readiness of two cycles.
+func diffFleet(before, after map[string]bool) fleetChange {
+	var c fleetChange
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		ready, existed := before[name]
+		switch {
+		case !existed:
+			c.Added = append(c.Added, name)
+		case !ready && after[name]:
+			c.Ready = append(c.Ready, name)
+		case ready && !after[name]:
+			c.NotReady = append(c.NotReady, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[name]; !ok {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+	return c
+}
+
+func (c fleetChange) empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Ready)+len(c.NotReady) == 0
+}
+
+// String summarises the change in one line per kind, e.g.
+// "added: prod-eu, qa-eu".
+func (c fleetChange) String() string {
+	var lines []string
+	for _, part := range []struct {
+		label string
+		names []string
+	}{
+		{"added", c.Added},
+		{"removed", c.Removed},
+		{"now ready", c.Ready},
+		{"no longer ready", c.NotReady},
+	} {
+		if len(part.names) > 0 {
+			lines = append(lines, part.label+": "+strings.Join(part.names, ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sendDesktopNotification shows a desktop notification with osascript on
+// macOS and notify-send elsewhere. It is a variable so tests need not show
+// notifications.
+var sendDesktopNotification = func(title, body string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(body), strconv.Quote(title))
+		c = exec.Command("osascript", "-e", script)
+	case "windows":
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	default:
+		c = exec.Command("notify-send", "--app-name=cloudctl", title, body)
+	}
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", c.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// notifyDesktop reports change as a desktop notification. Failures are only
+// logged; a missing notification daemon must not stop the sync loop.
+func notifyDesktop(change fleetChange) {
+	slog.Info("clusters changed", "added", change.Added, "removed", change.Removed, "ready", change.Ready, "notReady", change.NotReady)
+	if err := sendDesktopNotification("cloudctl: clusters changed", change.String()); err != nil {
+		slog.Warn("failed to show desktop notification", "error", err)
+	}
+}
+
+// webhookTimeout bounds a single webhook request.
+const webhookTimeout = 10 * time.Second
+
+// webhookNotifier posts Slack-compatible {"text": ...} messages about fleet
+// changes and about syncs that keep failing, so platform teams notice jump
+// hosts whose kubeconfigs go stale.
+type webhookNotifier struct {
+	url       string
+	threshold int
+	host      string
+	client    *http.Client
+	// alerted is set once a failure streak has been reported, so recovery is
+	// reported too.
+	alerted bool
+}
+
+// newWebhookNotifier returns a notifier posting to rawURL, which must be an
+// http or https URL.
+func newWebhookNotifier(rawURL string, threshold int) (*webhookNotifier, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("invalid --webhook-url: must be an http or https URL")
+	}
+	host, _ := os.Hostname()
+	return &webhookNotifier{url: rawURL, threshold: threshold, host: host, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+func (n *webhookNotifier) fleetChanged(change fleetChange) {
+	n.post(fmt.Sprintf("cloudctl on %s: clusters changed\n%s", n.host, change))
+}
+
+// cycleDone reports a failure streak once it reaches the threshold, and its
+// end.
+func (n *webhookNotifier) cycleDone(err error, failures int) {
+	switch {
+	case err != nil && failures == n.threshold:
+		n.alerted = true
+		n.post(fmt.Sprintf("cloudctl on %s: sync failed %d times in a row, the kubeconfig is getting stale\nlast error: %v", n.host, failures, err))
+	case err == nil && n.alerted:
+		n.alerted = false
+		n.post(fmt.Sprintf("cloudctl on %s: sync recovered", n.host))
+	}
+}
+
+// post sends text to the webhook. Failures are only logged; the URL is left
+// out because it usually embeds a secret.
+func (n *webhookNotifier) post(text string) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		slog.Warn("failed to encode webhook message", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("invalid webhook URL", "error", redactURLError(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		slog.Warn("failed to post webhook", "error", redactURLError(err))
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("webhook rejected message", "status", resp.Status)
+	}
+}
+
+// redactURLError drops the URL from errors returned by http.Client.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
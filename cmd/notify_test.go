@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffFleet(t *testing.T) {
+	g := NewWithT(t)
+	change := diffFleet(
+		map[string]bool{"a": true, "b": false, "c": true, "d": true},
+		map[string]bool{"a": true, "b": true, "c": false, "e": true},
+	)
+	g.Expect(change).To(Equal(fleetChange{
+		Added:    []string{"e"},
+		Removed:  []string{"d"},
+		Ready:    []string{"b"},
+		NotReady: []string{"c"},
+	}))
+	g.Expect(change.String()).To(Equal("added: e\nremoved: d\nnow ready: b\nno longer ready: c"))
+	g.Expect(diffFleet(map[string]bool{"a": true}, map[string]bool{"a": true}).empty()).To(BeTrue())
+}
+
+func TestRunSyncWatch_ReportsFleetChanges(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cycles := []struct {
+		clusters map[string]bool
+		err      error
+	}{
+		{map[string]bool{"a": true}, nil},
+		// A failed cycle that saw only part of the fleet is not compared.
+		{map[string]bool{}, errors.New("boom")},
+		{map[string]bool{"a": true}, nil},
+		{map[string]bool{"a": false, "b": true}, nil},
+	}
+	var calls int
+	cycle := func(context.Context) (syncOutcome, error) {
+		c := cycles[calls]
+		calls++
+		if calls == len(cycles) {
+			cancel()
+		}
+		return syncOutcome{Clusters: c.clusters}, c.err
+	}
+	var changes []fleetChange
+	g.Expect(runSyncWatch(ctx, cycle, time.Millisecond, nil, "", watchHooks{
+		onChange: []func(fleetChange){func(c fleetChange) { changes = append(changes, c) }},
+	})).To(Succeed())
+	g.Expect(changes).To(Equal([]fleetChange{{Added: []string{"b"}, NotReady: []string{"a"}}}))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	g := NewWithT(t)
+	var messages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct{ Text string }
+		g.Expect(json.NewDecoder(r.Body).Decode(&payload)).To(Succeed())
+		g.Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+		messages = append(messages, payload.Text)
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := newWebhookNotifier(srv.URL+"/hooks/secret", 2)
+	g.Expect(err).NotTo(HaveOccurred())
+	n.host = "jump-1"
+	n.fleetChanged(fleetChange{Added: []string{"a"}})
+	boom := errors.New("boom")
+	n.cycleDone(boom, 1)
+	n.cycleDone(boom, 2)
+	n.cycleDone(boom, 3)
+	n.cycleDone(nil, 0)
+	n.cycleDone(nil, 0)
+	g.Expect(messages).To(Equal([]string{
+		"cloudctl on jump-1: clusters changed\nadded: a",
+		"cloudctl on jump-1: sync failed 2 times in a row, the kubeconfig is getting stale\nlast error: boom",
+		"cloudctl on jump-1: sync recovered",
+	}))
+
+	_, err = newWebhookNotifier("hooks.example/x", 1)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid --webhook-url")))
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/fuzzy"
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var nsCmd = &cobra.Command{
+	Use:   "ns [namespace]",
+	Short: "Switch the namespace of the current managed context",
+	Long: `Sets the default namespace of the current kubeconfig context, or of the
+context given with --context.
+
+Only contexts managed by cloudctl (those whose cluster carries the sync
+--prefix) are modified; sync keeps the namespace chosen here. Without an argument, namespaces are listed from the live cluster and
+a fuzzy finder is shown; shell completion also queries the live cluster.
+
+Examples:
+  # Pick a namespace interactively
+  cloudctl ns
+
+  # Switch to a namespace
+  cloudctl ns kube-system`,
+	Args:              cobra.MaximumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeNamespaces,
+	RunE:              runNs,
+}
+
+// nsListTimeout bounds namespace listing for the picker and completion.
+const nsListTimeout = 10 * time.Second
+
+// listNamespaces returns the namespace names visible with cfg. It is a
+// variable so tests can avoid a live cluster.
+var listNamespaces = func(ctx context.Context, cfg *rest.Config) ([]string, error) {
+	cs, err := kubernetes.NewForConfig(withTracing(cfg))
+	if err != nil {
+		return nil, err
+	}
+	list, err := cs.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		names = append(names, ns.Name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func runNs(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	po := kubeconfigPathOptions(path)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	contextName := cmp.Or(viper.GetString("context"), cfg.CurrentContext)
+	kctx := cfg.Contexts[contextName]
+	switch {
+	case contextName == "":
+		return fmt.Errorf("no current context set in %s; run `cloudctl use` first", displayKubeconfig(path))
+	case kctx == nil:
+		return fmt.Errorf("context %q not found in %s", contextName, displayKubeconfig(path))
+	case !hasManagedCluster(kctx):
+		return fmt.Errorf("context %q is not managed by cloudctl (prefix %q)", contextName, prefix)
+	}
+
+	var namespace string
+	if len(args) == 1 {
+		namespace = args[0]
+	} else {
+		restCfg, err := configWithContext(contextName, path)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), nsListTimeout)
+		defer cancel()
+		names, err := listNamespaces(ctx, restCfg)
+		if err != nil {
+			return fmt.Errorf("failed to list namespaces in context %q: %w", contextName, err)
+		}
+		items := make([]fuzzy.Item, 0, len(names))
+		for _, n := range names {
+			items = append(items, fuzzy.Item{Name: n})
+		}
+		if namespace, err = selectTarget("namespace", "", items); err != nil {
+			return err
+		}
+	}
+
+	previous := kctx.Namespace
+	if previous != namespace {
+		kctx.Namespace = namespace
+		if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+			return fmt.Errorf("failed to update kubeconfig: %w", err)
+		}
+		if err := auditFieldChange("ns", path, "context", contextName, "namespace", previous, namespace); err != nil {
+			return err
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(output.NamespaceResult{Context: contextName, Namespace: namespace, Previous: previous})
+}
+
+// completeNamespaces completes namespace names from the live cluster of the
+// --context or current context.
+func completeNamespaces(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	path, _ := cmd.Flags().GetString("kubeconfig")
+	if !cmd.Flags().Changed("kubeconfig") {
+		path = resolveKubeconfig("kubeconfig", path)
+	}
+	contextName, _ := cmd.Flags().GetString("context")
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), nsListTimeout)
+	defer cancel()
+	names, err := listNamespaces(ctx, restCfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
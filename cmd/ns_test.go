@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunNsUpdatesManagedContext(t *testing.T) {
+	g := NewWithT(t)
+	cfg := newManagedCfg("prod")
+	cfg.CurrentContext = "prod"
+	path := writeTestKubeconfig(t, cfg)
+
+	var out bytes.Buffer
+	nsCmd.SetOut(&out)
+	t.Cleanup(func() { nsCmd.SetOut(nil) })
+	g.Expect(runNs(nsCmd, []string{"kube-system"})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`now uses namespace "kube-system"`))
+
+	written, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.Contexts["prod"].Namespace).To(Equal("kube-system"))
+	g.Expect(written.Contexts["local"].Namespace).To(BeEmpty())
+}
+
+func TestRunNsSurvivesSync(t *testing.T) {
+	g := NewWithT(t)
+	cfg := newManagedCfg("prod")
+	cfg.CurrentContext = "prod"
+	path := writeTestKubeconfig(t, cfg)
+	orig := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix = orig })
+
+	nsCmd.SetOut(&bytes.Buffer{})
+	t.Cleanup(func() { nsCmd.SetOut(nil) })
+	g.Expect(runNs(nsCmd, []string{"kube-system"})).To(Succeed())
+
+	localConfig, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.Clusters["prod"] = &clientcmdapi.Cluster{Server: "https://prod.example"}
+	serverConfig.AuthInfos["prod"] = &clientcmdapi.AuthInfo{Token: "t"}
+	serverConfig.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod", AuthInfo: "prod", Namespace: "default"}
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts["prod"].Namespace).To(Equal("kube-system"), "sync keeps the namespace picked with ns")
+	g.Expect(localConfig.Contexts["prod"].Cluster).To(Equal("cloudctl:prod"))
+}
+
+func TestRunNsRejectsUnmanagedContext(t *testing.T) {
+	g := NewWithT(t)
+	cfg := newManagedCfg("prod")
+	cfg.CurrentContext = "local"
+	writeTestKubeconfig(t, cfg)
+
+	err := runNs(nsCmd, []string{"kube-system"})
+	g.Expect(err).To(MatchError(ContainSubstring(`"local" is not managed by cloudctl`)))
+}
+
+func TestCompleteNamespacesUsesLiveList(t *testing.T) {
+	g := NewWithT(t)
+	cfg := newManagedCfg("prod")
+	cfg.CurrentContext = "prod"
+	writeTestKubeconfig(t, cfg)
+	prev := listNamespaces
+	listNamespaces = func(_ context.Context, cfg *rest.Config) ([]string, error) {
+		g.Expect(cfg.Host).To(Equal("https://prod.example"))
+		return []string{"default", "kube-system"}, nil
+	}
+	t.Cleanup(func() { listNamespaces = prev })
+
+	c := &cobra.Command{}
+	c.SetContext(context.Background())
+	c.Flags().String("kubeconfig", viper.GetString("kubeconfig"), "")
+	g.Expect(c.Flags().Set("kubeconfig", viper.GetString("kubeconfig"))).To(Succeed())
+
+	names, directive := completeNamespaces(c, nil, "")
+	g.Expect(names).To(Equal([]string{"default", "kube-system"}))
+	g.Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+}
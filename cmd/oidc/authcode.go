@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuthCodeOptions configure an authorization code login.
+type AuthCodeOptions struct {
+	// ListenAddresses are tried in order for the loopback redirect listener.
+	// They must match redirect URIs registered at the issuer, which is why
+	// the defaults are the same as kubelogin's (127.0.0.1:8000 and :18000).
+	ListenAddresses []string
+	// OpenURL is called with the authorization URL, typically to launch a
+	// browser. It may fail; the URL is also passed to Prompt.
+	OpenURL func(string) error
+	// Prompt receives the authorization URL so it can be shown to the user.
+	Prompt func(string)
+}
+
+// DefaultListenAddresses are the loopback addresses kubelogin registers.
+var DefaultListenAddresses = []string{"127.0.0.1:8000", "127.0.0.1:18000"}
+
+// AuthCode runs the authorization code grant with PKCE (RFC 7636) using a
+// loopback redirect (RFC 8252) and returns the resulting token.
+func (p *Provider) AuthCode(ctx context.Context, params Params, opts AuthCodeOptions) (*Token, error) {
+	if p.AuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s does not advertise an authorization endpoint", p.Issuer)
+	}
+	addrs := opts.ListenAddresses
+	if len(addrs) == 0 {
+		addrs = DefaultListenAddresses
+	}
+	ln, err := listenFirst(addrs)
+	if err != nil {
+		return nil, err
+	}
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	redirectURI := "http://localhost:" + port
+
+	verifier := randomString()
+	state := randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {params.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(params.Scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {randomString()},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	for k, v := range params.ExtraParams {
+		q.Set(k, v)
+	}
+	authURL := p.AuthorizationEndpoint + "?" + q.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{ReadHeaderTimeout: 10 * time.Second, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("error") != "":
+			res.err = &oauthError{Code: q.Get("error"), Description: q.Get("error_description")}
+		case q.Get("state") != state:
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			return
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			_, _ = fmt.Fprintf(w, "Login failed: %s. You can close this window.", res.err)
+		} else {
+			_, _ = fmt.Fprint(w, "Login successful. You can close this window and return to your terminal.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	if opts.Prompt != nil {
+		opts.Prompt(authURL)
+	}
+	if opts.OpenURL != nil {
+		_ = opts.OpenURL(authURL)
+	}
+
+	var res result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-results:
+	}
+	if res.err != nil {
+		return nil, fmt.Errorf("authorization failed: %w", res.err)
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	var tr tokenResponse
+	if err := p.postForm(ctx, p.TokenEndpoint, params, form, &tr); err != nil {
+		return nil, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	return newToken(tr)
+}
+
+func listenFirst(addrs []string) (net.Listener, error) {
+	var errs []error
+	for _, addr := range addrs {
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			return ln, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("could not listen for the login redirect: %w", errors.Join(errs...))
+}
+
+// randomString returns 32 bytes of crypto randomness, base64url-encoded.
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
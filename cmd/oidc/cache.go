@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Store keeps tokens per client registration. Cache and Keyring implement it.
+type Store interface {
+	// Load returns the token stored for p, or nil when there is none.
+	Load(p Params) (*Token, error)
+	// Save stores tok for p, replacing any previous token.
+	Save(p Params, tok *Token) error
+	// Delete removes the token stored for p, if any.
+	Delete(p Params) error
+}
+
+// Cache stores tokens as one JSON file per client registration in Dir.
+type Cache struct {
+	Dir string
+}
+
+// tokenKey derives a stable name from the parameters that identify a token.
+// The client secret is deliberately excluded so rotating it keeps the token.
+func tokenKey(p Params) string {
+	scopes := slices.Clone(p.ExtraScopes)
+	slices.Sort(scopes)
+	extra := make([]string, 0, len(p.ExtraParams))
+	for k, v := range p.ExtraParams {
+		extra = append(extra, k+"="+v)
+	}
+	slices.Sort(extra)
+	h := sha256.Sum256([]byte(strings.Join([]string{
+		p.IssuerURL, p.ClientID, strings.Join(scopes, ","), strings.Join(extra, ","),
+	}, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+func (c Cache) path(p Params) string {
+	return filepath.Join(c.Dir, "cloudctl-"+tokenKey(p)+".json")
+}
+
+// Load returns the cached token for p, or nil when none is cached.
+func (c Cache) Load(p Params) (*Token, error) {
+	data, err := os.ReadFile(c.path(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tok Token
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
+
+// Save writes tok for p, readable only by the current user. The file is
+// replaced atomically so concurrent readers never see a partial token.
+func (c Cache) Save(p Params, tok *Token) error {
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.Dir, ".cloudctl-token-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(p))
+}
+
+// Delete removes the cached token for p, if any.
+func (c Cache) Delete(p Params) error {
+	if err := os.Remove(c.path(p)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stale returns the paths of the cached tokens that cannot be used any more:
+// those whose ID token expired without a refresh token, and those not
+// refreshed within maxAge, by which time refresh tokens have usually expired
+// as well. A zero maxAge only returns the former.
+func (c Cache) Stale(now time.Time, maxAge time.Duration) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(c.Dir, "cloudctl-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if maxAge > 0 && now.Sub(info.ModTime()) > maxAge {
+			stale = append(stale, path)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var tok Token
+		if err := json.Unmarshal(data, &tok); err != nil || (tok.RefreshToken == "" && !tok.Valid(now)) {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DeviceAuthorization is the response to a device authorization request.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// deviceGrantType is the grant_type for polling the token endpoint (RFC 8628).
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// StartDevice begins a device authorization grant. The caller shows
+// VerificationURI and UserCode to the user and then calls PollDevice.
+func (p *Provider) StartDevice(ctx context.Context, params Params) (*DeviceAuthorization, error) {
+	if p.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s does not support the device authorization grant", p.Issuer)
+	}
+	form := url.Values{"scope": {strings.Join(params.Scopes(), " ")}}
+	for k, v := range params.ExtraParams {
+		form.Set(k, v)
+	}
+	var da DeviceAuthorization
+	if err := p.postForm(ctx, p.DeviceAuthorizationEndpoint, params, form, &da); err != nil {
+		return nil, fmt.Errorf("device authorization request failed: %w", err)
+	}
+	if da.DeviceCode == "" || da.UserCode == "" {
+		return nil, errors.New("device authorization response is missing device_code or user_code")
+	}
+	return &da, nil
+}
+
+// PollDevice polls the token endpoint until the user approves or denies the
+// request, the device code expires, or ctx is cancelled.
+func (p *Provider) PollDevice(ctx context.Context, params Params, da *DeviceAuthorization) (*Token, error) {
+	interval := time.Duration(max(da.Interval, 5)) * time.Second
+	if da.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(da.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+	form := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {da.DeviceCode},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, errors.New("device code expired before the login was approved")
+			}
+			return nil, ctx.Err()
+		case <-time.After(pollDelay(interval)):
+		}
+
+		var tr tokenResponse
+		err := p.postForm(ctx, p.TokenEndpoint, params, form, &tr)
+		var oe *oauthError
+		switch {
+		case err == nil:
+			return newToken(tr)
+		case errors.As(err, &oe) && oe.Code == "authorization_pending":
+		case errors.As(err, &oe) && oe.Code == "slow_down":
+			interval += 5 * time.Second
+		case errors.As(err, &oe) && oe.Code == "access_denied":
+			return nil, errors.New("login was denied")
+		case errors.As(err, &oe) && oe.Code == "expired_token":
+			return nil, errors.New("device code expired before the login was approved")
+		default:
+			return nil, fmt.Errorf("polling for device token: %w", err)
+		}
+	}
+}
+
+// pollDelay is the wait between polls. It is a variable so tests can poll
+// without waiting for the RFC's minimum interval.
+var pollDelay = func(interval time.Duration) time.Duration { return interval }
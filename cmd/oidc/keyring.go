@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service name tokens are stored under.
+const keyringService = "cloudctl"
+
+// Keyring stores tokens in the credential store of the operating system: the
+// login keychain on macOS (through security) and the Secret Service on Linux
+// (through secret-tool from libsecret). Tokens are stored base64-encoded, one
+// item per client registration.
+type Keyring struct{}
+
+// runKeyringTool runs a keyring command line tool with stdin and returns its
+// standard output. It is a variable so tests can stub the tools.
+var runKeyringTool = func(stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...) // #nosec G204 -- fixed tools, arguments built by Keyring
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// errKeyringItemNotFound is returned by the platform implementations when no
+// token is stored.
+var errKeyringItemNotFound = errors.New("keyring item not found")
+
+// Load returns the token stored for p, or nil when none is stored.
+func (Keyring) Load(p Params) (*Token, error) {
+	data, err := keyringGet(tokenKey(p))
+	if errors.Is(err, errKeyringItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token in keyring: %w", err)
+	}
+	var tok Token
+	if err := json.Unmarshal(raw, &tok); err != nil {
+		return nil, fmt.Errorf("invalid token in keyring: %w", err)
+	}
+	return &tok, nil
+}
+
+// Save stores tok for p, replacing any previous token.
+func (Keyring) Save(p Params, tok *Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return keyringSet(tokenKey(p), base64.StdEncoding.EncodeToString(data))
+}
+
+// Delete removes the token stored for p, if any.
+func (Keyring) Delete(p Params) error {
+	if err := keyringDelete(tokenKey(p)); err != nil && !errors.Is(err, errKeyringItemNotFound) {
+		return err
+	}
+	return nil
+}
+
+// keyringOS is the operating system whose credential store is used. It is a
+// variable so tests can exercise every platform.
+var keyringOS = runtime.GOOS
+
+func keyringGet(account string) ([]byte, error) {
+	var (
+		out []byte
+		err error
+	)
+	switch keyringOS {
+	case "darwin":
+		out, err = runKeyringTool("", "security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case "linux":
+		out, err = runKeyringTool("", "secret-tool", "lookup", "service", keyringService, "account", account)
+	default:
+		return nil, errKeyringUnsupported()
+	}
+	// Both tools fail without output when there is no such item.
+	var exitErr *exec.ExitError
+	if (errors.As(err, &exitErr) || err == nil) && len(bytes.TrimSpace(out)) == 0 {
+		return nil, errKeyringItemNotFound
+	}
+	return out, err
+}
+
+func keyringSet(account, secret string) error {
+	var err error
+	switch keyringOS {
+	case "darwin":
+		// security -i reads the command from stdin, which keeps the secret out
+		// of the process list; base64 needs no quoting.
+		_, err = runKeyringTool(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", keyringService, account, secret), "security", "-i")
+	case "linux":
+		_, err = runKeyringTool(secret, "secret-tool", "store", "--label=cloudctl OIDC token", "service", keyringService, "account", account)
+	default:
+		return errKeyringUnsupported()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store token in keyring: %w", err)
+	}
+	return nil
+}
+
+func keyringDelete(account string) error {
+	var err error
+	switch keyringOS {
+	case "darwin":
+		_, err = runKeyringTool("", "security", "delete-generic-password", "-s", keyringService, "-a", account)
+	case "linux":
+		_, err = runKeyringTool("", "secret-tool", "clear", "service", keyringService, "account", account)
+	default:
+		return errKeyringUnsupported()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && keyringOS == "darwin" && exitErr.ExitCode() == 44 {
+		return errKeyringItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete token from keyring: %w", err)
+	}
+	return nil
+}
+
+func errKeyringUnsupported() error {
+	return fmt.Errorf("the keyring token store is not supported on %s", keyringOS)
+}
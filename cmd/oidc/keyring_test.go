@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// stubKeyring replaces the keyring tools of goos with an in-memory store that
+// understands the commands Keyring runs.
+func stubKeyring(t *testing.T, goos string) map[string]string {
+	t.Helper()
+	items := map[string]string{}
+	prevRun, prevOS := runKeyringTool, keyringOS
+	t.Cleanup(func() { runKeyringTool, keyringOS = prevRun, prevOS })
+	keyringOS = goos
+	// security exits with 44 when there is no such item.
+	notFound := func() error { return exec.Command("sh", "-c", "exit 44").Run() }
+	runKeyringTool = func(stdin, name string, args ...string) ([]byte, error) {
+		if name == "security" && len(args) == 1 && args[0] == "-i" {
+			// add-generic-password -U -s cloudctl -a <account> -w <secret>
+			fields := strings.Fields(stdin)
+			items[fields[5]] = fields[7]
+			return nil, nil
+		}
+		account := args[len(args)-1]
+		if name == "security" {
+			account = args[4]
+		}
+		switch args[0] {
+		case "find-generic-password", "lookup":
+			return []byte(items[account]), nil
+		case "store":
+			items[account] = stdin
+			return nil, nil
+		case "delete-generic-password", "clear":
+			if _, ok := items[account]; !ok && name == "security" {
+				return nil, notFound()
+			}
+			delete(items, account)
+			return nil, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+	return items
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	for _, goos := range []string{"darwin", "linux"} {
+		t.Run(goos, func(t *testing.T) {
+			g := NewWithT(t)
+			items := stubKeyring(t, goos)
+			k := Keyring{}
+			p := Params{IssuerURL: "https://issuer", ClientID: "a"}
+
+			tok, err := k.Load(p)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tok).To(BeNil())
+
+			g.Expect(k.Save(p, &Token{IDToken: "id", RefreshToken: "r"})).To(Succeed())
+			g.Expect(items).To(HaveKey(tokenKey(p)))
+			g.Expect(items[tokenKey(p)]).NotTo(ContainSubstring("id"))
+			tok, err = k.Load(p)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tok).To(Equal(&Token{IDToken: "id", RefreshToken: "r"}))
+
+			g.Expect(k.Delete(p)).To(Succeed())
+			g.Expect(items).To(BeEmpty())
+			g.Expect(k.Delete(p)).To(Succeed())
+		})
+	}
+}
+
+func TestKeyringUnsupported(t *testing.T) {
+	g := NewWithT(t)
+	stubKeyring(t, "plan9")
+	_, err := Keyring{}.Load(Params{})
+	g.Expect(err).To(MatchError(ContainSubstring("not supported on plan9")))
+}
+
+func TestCacheDelete(t *testing.T) {
+	g := NewWithT(t)
+	c := Cache{Dir: t.TempDir()}
+	p := Params{IssuerURL: "https://issuer", ClientID: "a"}
+	g.Expect(c.Save(p, &Token{IDToken: "id"})).To(Succeed())
+	g.Expect(c.Delete(p)).To(Succeed())
+	tok, err := c.Load(p)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).To(BeNil())
+	g.Expect(c.Delete(p)).To(Succeed())
+}
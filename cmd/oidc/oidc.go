@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package oidc implements the small subset of OpenID Connect that cloudctl
+// needs to obtain and refresh ID tokens itself: provider discovery, the
+// device authorization grant (RFC 8628), refresh, and an on-disk token cache.
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Params identify an OIDC client registration at an issuer.
+type Params struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	ExtraScopes  []string
+	// ExtraParams are added to authorization requests, e.g. Dex's connector_id.
+	ExtraParams map[string]string
+}
+
+// Scopes returns the scopes to request: "openid" plus ExtraScopes.
+func (p Params) Scopes() []string {
+	return append([]string{"openid"}, p.ExtraScopes...)
+}
+
+// Token is an ID token together with the refresh token to renew it.
+type Token struct {
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitzero"`
+}
+
+// Valid reports whether the ID token is present and not about to expire.
+func (t *Token) Valid(now time.Time) bool {
+	return t != nil && t.IDToken != "" && (t.Expiry.IsZero() || now.Add(30*time.Second).Before(t.Expiry))
+}
+
+// Provider holds the endpoints discovered from an issuer.
+type Provider struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+
+	client *http.Client
+}
+
+// Discover fetches the issuer's /.well-known/openid-configuration.
+func Discover(ctx context.Context, client *http.Client, issuerURL string) (*Provider, error) {
+	u := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery at %s returned HTTP %d", u, resp.StatusCode)
+	}
+	var p Provider
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decoding OIDC discovery document: %w", err)
+	}
+	if p.TokenEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s does not advertise a token endpoint", issuerURL)
+	}
+	p.client = client
+	return &p, nil
+}
+
+// tokenResponse is the token endpoint's success or error body.
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oauthError is an error response from the token endpoint.
+type oauthError struct {
+	Code        string
+	Description string
+}
+
+func (e *oauthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// postForm sends an application/x-www-form-urlencoded request and decodes a
+// JSON response into out. OAuth error bodies are returned as *oauthError.
+func (p *Provider) postForm(ctx context.Context, endpoint string, params Params, form url.Values, out any) error {
+	form.Set("client_id", params.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if params.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(params.ClientID), url.QueryEscape(params.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e tokenResponse
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return &oauthError{Code: e.Error, Description: e.ErrorDescription}
+		}
+		return fmt.Errorf("%s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// Refresh exchanges a refresh token for a new ID token.
+func (p *Provider) Refresh(ctx context.Context, params Params, refreshToken string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"scope":         {strings.Join(params.Scopes(), " ")},
+	}
+	var tr tokenResponse
+	if err := p.postForm(ctx, p.TokenEndpoint, params, form, &tr); err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	tok, err := newToken(tr)
+	if err != nil {
+		return nil, err
+	}
+	if tok.RefreshToken == "" {
+		// Providers may keep the refresh token unchanged and omit it.
+		tok.RefreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+func newToken(tr tokenResponse) (*Token, error) {
+	if tr.IDToken == "" {
+		return nil, errors.New("token response does not contain an id_token")
+	}
+	tok := &Token{IDToken: tr.IDToken, RefreshToken: tr.RefreshToken}
+	if exp, err := idTokenExpiry(tr.IDToken); err == nil {
+		tok.Expiry = exp
+	} else if tr.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// TokenFromIDToken returns a token for an ID token and refresh token that
+// were stored elsewhere, e.g. in a kubeconfig, with the expiry read from the
+// ID token.
+func TokenFromIDToken(idToken, refreshToken string) *Token {
+	tok := &Token{IDToken: idToken, RefreshToken: refreshToken}
+	if exp, err := idTokenExpiry(idToken); err == nil {
+		tok.Expiry = exp
+	}
+	return tok
+}
+
+// idTokenExpiry reads the exp claim from a JWT without verifying it. The token
+// was received directly from the issuer over TLS; the API server verifies it.
+func idTokenExpiry(idToken string) (time.Time, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("malformed JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, errors.New("JWT has no exp claim")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func fakeJWT(exp time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"sub": "jane", "exp": exp.Unix()})
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+// newFakeIssuer serves discovery, device authorization and token endpoints.
+// The device token endpoint reports authorization_pending pendingPolls times.
+func newFakeIssuer(t *testing.T, pendingPolls int32, idToken string) *httptest.Server {
+	t.Helper()
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        srv.URL,
+			"authorization_endpoint":        srv.URL + "/auth",
+			"token_endpoint":                srv.URL + "/token",
+			"device_authorization_endpoint": srv.URL + "/device",
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("client_id") != "cloudctl" || r.Form.Get("scope") != "openid groups" || r.Form.Get("connector_id") != "ldap" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(DeviceAuthorization{
+			DeviceCode: "dev-123", UserCode: "ABCD-EFGH", VerificationURI: srv.URL + "/verify", ExpiresIn: 60, Interval: 1,
+		})
+	})
+	var challenge string
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		challenge = q.Get("code_challenge")
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=code-1&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id_token": idToken, "refresh_token": "refresh-1"})
+		case deviceGrantType:
+			if polls.Add(1) <= pendingPolls {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"authorization_pending"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id_token": idToken, "refresh_token": "refresh-1"})
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant","error_description":"expired"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id_token": idToken})
+		}
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDeviceFlow(t *testing.T) {
+	g := NewWithT(t)
+	pollDelay = func(time.Duration) time.Duration { return time.Millisecond }
+	t.Cleanup(func() { pollDelay = func(d time.Duration) time.Duration { return d } })
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	srv := newFakeIssuer(t, 2, fakeJWT(exp))
+	params := Params{IssuerURL: srv.URL, ClientID: "cloudctl", ExtraScopes: []string{"groups"}, ExtraParams: map[string]string{"connector_id": "ldap"}}
+
+	p, err := Discover(context.Background(), srv.Client(), srv.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+	da, err := p.StartDevice(context.Background(), params)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(da.UserCode).To(Equal("ABCD-EFGH"))
+
+	tok, err := p.PollDevice(context.Background(), params, da)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok.RefreshToken).To(Equal("refresh-1"))
+	g.Expect(tok.Expiry).To(BeTemporally("==", exp))
+	g.Expect(tok.Valid(time.Now())).To(BeTrue())
+	g.Expect(tok.Valid(exp)).To(BeFalse())
+
+	refreshed, err := p.Refresh(context.Background(), params, "refresh-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(refreshed.RefreshToken).To(Equal("refresh-1"), "unchanged refresh token is kept")
+
+	_, err = p.Refresh(context.Background(), params, "stale")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid_grant: expired")))
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	c := Cache{Dir: t.TempDir()}
+	p := Params{IssuerURL: "https://issuer", ClientID: "a", ExtraScopes: []string{"b", "a"}}
+
+	tok, err := c.Load(p)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).To(BeNil())
+
+	g.Expect(c.Save(p, &Token{IDToken: "id", RefreshToken: "r"})).To(Succeed())
+	// Scope order and client secret do not change the cache entry.
+	tok, err = c.Load(Params{IssuerURL: "https://issuer", ClientID: "a", ClientSecret: "s", ExtraScopes: []string{"a", "b"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok.IDToken).To(Equal("id"))
+
+	tok, err = c.Load(Params{IssuerURL: "https://issuer", ClientID: "other"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).To(BeNil())
+}
+
+func TestAuthCodeFlowWithPKCE(t *testing.T) {
+	g := NewWithT(t)
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	srv := newFakeIssuer(t, 0, fakeJWT(exp))
+	params := Params{IssuerURL: srv.URL, ClientID: "cloudctl"}
+
+	p, err := Discover(context.Background(), srv.Client(), srv.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var prompted string
+	tok, err := p.AuthCode(context.Background(), params, AuthCodeOptions{
+		ListenAddresses: []string{"127.0.0.1:0"},
+		Prompt:          func(u string) { prompted = u },
+		// Play the browser: follow the issuer's redirect to the loopback listener.
+		OpenURL: func(u string) error {
+			go func() {
+				resp, err := http.Get(u)
+				if err == nil {
+					_ = resp.Body.Close()
+				}
+			}()
+			return nil
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(prompted).To(ContainSubstring("code_challenge_method=S256"))
+	g.Expect(tok.RefreshToken).To(Equal("refresh-1"))
+	g.Expect(tok.Expiry).To(BeTemporally("==", exp))
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Switch between Greenhouse organizations",
+}
+
+var orgUseCmd = &cobra.Command{
+	Use:   "use <organization>",
+	Short: "Make an organization the default for sync and the other commands",
+	Long: `Sets greenhouse-cluster-namespace in the config file (see cloudctl config)
+to the given organization, so later runs of sync, list and the other
+Greenhouse commands use it without -n. The organization is checked against
+those whose ClusterKubeconfigs you can list; when they cannot be discovered
+it is set anyway.
+
+With --sync the kubeconfig is synced from the new organization right away.
+As both organizations share the prefix, that sync replaces the managed
+entries of the previous one. To keep several organizations in one
+kubeconfig, configure them as sources instead.
+
+Examples:
+  cloudctl org use my-org
+  cloudctl org use my-other-org --sync`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeOrgNamespaces,
+	PreRunE:           bindCommandFlags,
+	RunE:              runOrgUse,
+}
+
+func init() {
+	orgUseCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	orgUseCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	orgUseCmd.Flags().Bool("sync", false, "Sync the kubeconfig from the organization after switching")
+	orgCmd.AddCommand(orgUseCmd)
+}
+
+func runOrgUse(cmd *cobra.Command, args []string) error {
+	namespace := args[0]
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	// With several sources the namespace would replace them all; see
+	// loadSyncSources.
+	if viper.IsSet("sources") {
+		return fmt.Errorf("the config file defines sources; edit them or pass -n instead of switching the default organization")
+	}
+	if err := checkOrgNamespace(cmd.Context(), namespace); err != nil {
+		return err
+	}
+
+	file, err := configFileTarget()
+	if err != nil {
+		return err
+	}
+	doc, err := loadConfigDocument(file)
+	if err != nil {
+		return err
+	}
+	result := output.OrgUseResult{File: file, Namespace: namespace}
+	if prev := mappingValue(doc, "greenhouse-cluster-namespace"); prev != nil {
+		result.Previous = prev.Value
+	}
+	if err := setConfigKey(doc, "greenhouse-cluster-namespace", namespace); err != nil {
+		return err
+	}
+	if err := writeConfigDocument(file, doc); err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if !viper.GetBool("sync") {
+		return nil
+	}
+	viper.Set("greenhouse-cluster-namespace", namespace)
+	return runSync(cmd, nil)
+}
+
+// checkOrgNamespace fails when the organizations the user can sync from are
+// known and namespace is not among them.
+func checkOrgNamespace(ctx context.Context, namespace string) error {
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, orgDiscoveryTimeout)
+	defer cancel()
+	namespaces, err := discoverOrgNamespaces(ctx, ghClient)
+	if err != nil {
+		slog.Warn("could not discover organizations; switching without checking", "error", err)
+		return nil
+	}
+	if !slices.Contains(namespaces, namespace) {
+		if len(namespaces) == 0 {
+			return fmt.Errorf("organization %s not found: you cannot list the ClusterKubeconfigs of any organization", namespace)
+		}
+		return fmt.Errorf("organization %s not found; available: %s", namespace, strings.Join(namespaces, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// useOrgGreenhouse points the Greenhouse flags at a kubeconfig and serves
+// orgs, all of which the user may sync from.
+func useOrgGreenhouse(t *testing.T, orgs ...string) {
+	t.Helper()
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["gh"] = &clientcmdapi.Cluster{Server: "https://greenhouse.example.com"}
+	cfg.AuthInfos["gh"] = &clientcmdapi.AuthInfo{Token: "x"}
+	cfg.Contexts["gh"] = &clientcmdapi.Context{Cluster: "gh", AuthInfo: "gh"}
+	cfg.CurrentContext = "gh"
+	writeTestKubeconfig(t, cfg)
+	stub := &stubGreenhouseClient{allowed: true}
+	for _, org := range orgs {
+		stub.orgs = append(stub.orgs, greenhousev1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: org}})
+	}
+	prev := newGreenhouseClient
+	newGreenhouseClient = func(*rest.Config) (greenhouse.Client, error) { return stub, nil }
+	t.Cleanup(func() { newGreenhouseClient = prev })
+}
+
+func TestRunOrgUse(t *testing.T) {
+	g := NewWithT(t)
+	configFile := useConfigFile(t, "# mine\ngreenhouse-cluster-namespace: org-a # default org\nprefix: gh\n")
+	useOrgGreenhouse(t, "org-a", "org-b")
+
+	var out bytes.Buffer
+	orgUseCmd.SetOut(&out)
+	orgUseCmd.SetContext(context.Background())
+	t.Cleanup(func() { orgUseCmd.SetOut(nil) })
+
+	g.Expect(runOrgUse(orgUseCmd, []string{"org-b"})).To(Succeed())
+	g.Expect(out.String()).To(Equal("Switched from organization org-a to org-b in " + configFile + ".\n"))
+	data, err := os.ReadFile(configFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal("# mine\ngreenhouse-cluster-namespace: org-b # default org\nprefix: gh\n"))
+
+	err = runOrgUse(orgUseCmd, []string{"org-c"})
+	g.Expect(err).To(MatchError("organization org-c not found; available: org-a, org-b"))
+}
+
+func TestRunOrgUse_RefusesWithSources(t *testing.T) {
+	g := NewWithT(t)
+	useConfigFile(t, "")
+	useOrgGreenhouse(t, "org-a")
+	viper.Set("sources", []map[string]string{{"namespace": "org-a", "prefix": "a"}})
+	t.Cleanup(func() { viper.Set("sources", nil) })
+
+	orgUseCmd.SetContext(context.Background())
+	g.Expect(runOrgUse(orgUseCmd, []string{"org-a"})).To(MatchError(ContainSubstring("the config file defines sources")))
+}
@@ -4,10 +4,14 @@
 package output
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -33,20 +37,29 @@ type interactivePrinter struct {
 type spinnerModel struct {
 	spinner spinner.Model
 	label   string
-	done    bool
+	// total is the number of items a progress spinner counts; 0 shows no count.
+	total    int
+	finished int
+	done     bool
 }
 
 type quitSpinnerMsg struct{}
 
+// progressMsg reports the number of items finished so far.
+type progressMsg int
+
 func (m spinnerModel) Init() tea.Cmd {
 	return m.spinner.Tick
 }
 
 func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg.(type) {
+	switch msg := msg.(type) {
 	case quitSpinnerMsg:
 		m.done = true
 		return m, tea.Quit
+	case progressMsg:
+		m.finished = int(msg)
+		return m, nil
 	}
 	var cmd tea.Cmd
 	m.spinner, cmd = m.spinner.Update(msg)
@@ -57,15 +70,31 @@ func (m spinnerModel) View() string {
 	if m.done {
 		return ""
 	}
+	if m.total > 0 {
+		return fmt.Sprintf("%s %s %d/%d\n", m.spinner.View(), m.label, m.finished, m.total)
+	}
 	return fmt.Sprintf("%s %s\n", m.spinner.View(), m.label)
 }
 
 func (p *interactivePrinter) StartSpinner(label string) func() {
+	_, stop := p.startSpinner(label, 0)
+	return stop
+}
+
+func (p *interactivePrinter) StartProgress(label string, total int) (func(), func()) {
+	prog, stop := p.startSpinner(label, total)
+	var finished atomic.Int64
+	return func() { prog.Send(progressMsg(finished.Add(1))) }, stop
+}
+
+// startSpinner runs a spinner program until the returned stop function is
+// called.
+func (p *interactivePrinter) startSpinner(label string, total int) (*tea.Program, func()) {
 	sp := spinner.New(
 		spinner.WithSpinner(spinner.Dot),
 		spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("5"))),
 	)
-	m := spinnerModel{spinner: sp, label: label}
+	m := spinnerModel{spinner: sp, label: label, total: total}
 	prog := tea.NewProgram(m, tea.WithOutput(p.w), tea.WithInput(nil))
 
 	var wg sync.WaitGroup
@@ -78,7 +107,7 @@ func (p *interactivePrinter) StartSpinner(label string) func() {
 	// Give the program a moment to start before returning.
 	time.Sleep(10 * time.Millisecond)
 
-	return func() {
+	return prog, func() {
 		prog.Send(quitSpinnerMsg{})
 		wg.Wait()
 	}
@@ -99,6 +128,388 @@ func (p *interactivePrinter) Print(v any) error {
 		writeErr = p.printSyncDryRunResult(t)
 	case ClusterVersionResult:
 		w("%s %s\n", styleFaint.Render("Kubernetes version:"), styleBold.Render(t.Version))
+		width := 0
+		for _, n := range t.Nodes {
+			width = max(width, len(n.Name))
+		}
+		for _, n := range t.Nodes {
+			icon, style := styleGreen.Render("✓"), styleFaint
+			switch {
+			case !n.Supported:
+				icon, style = styleRed.Render("✗"), styleRed
+			case n.MinorSkew != 0:
+				icon, style = styleYellow.Render("!"), styleYellow
+			}
+			line := fmt.Sprintf("  %s %-*s  %s", icon, width, n.Name, n.KubeletVersion)
+			if d := n.SkewDescription(); d != "" {
+				line += "  " + style.Render(d)
+			}
+			w("%s\n", line)
+		}
+	case ClusterInfoResult:
+		fields := t.Fields()
+		width := 0
+		for _, f := range fields {
+			width = max(width, len(f[0])+1)
+		}
+		for _, f := range fields {
+			w("%s  %s\n", styleFaint.Render(fmt.Sprintf("%-*s", width, f[0]+":")), f[1])
+		}
+	case APICheckResult:
+		width := len("CONTEXT")
+		for _, c := range t.Clusters {
+			width = max(width, len(c.Context))
+		}
+		header := fmt.Sprintf("%-*s", width, "CONTEXT")
+		for _, api := range t.APIs {
+			header += "  " + api
+		}
+		w("%s\n", styleHeader.Render(header))
+		for _, c := range t.Clusters {
+			line := fmt.Sprintf("%-*s", width, c.Context)
+			if c.Error != "" {
+				w("%s  %s\n", line, styleRed.Render("error: "+c.Error))
+				continue
+			}
+			for _, api := range t.APIs {
+				// Pad to the column width before styling so ANSI codes do not skew alignment.
+				cell := fmt.Sprintf("%-*s", len(api), "✗")
+				style := styleRed
+				if c.Available[api] {
+					cell, style = fmt.Sprintf("%-*s", len(api), "✓"), styleGreen
+				}
+				line += "  " + style.Render(cell)
+			}
+			w("%s\n", line)
+		}
+	case FleetApplyResult:
+		if t.DryRun {
+			w("%s\n", styleYellow.Render("Dry run, nothing was changed."))
+		}
+		for _, c := range t.Clusters {
+			switch {
+			case c.Error != "":
+				w("%s %s  %s\n", styleRed.Render("✗"), styleBold.Render(c.Context), styleRed.Render("error: "+c.Error))
+			case c.Failed() > 0:
+				w("%s %s  %s\n", styleRed.Render("✗"), styleBold.Render(c.Context), styleFaint.Render(fmt.Sprintf("%d applied, %d failed", len(c.Objects)-c.Failed(), c.Failed())))
+			default:
+				w("%s %s  %s\n", styleGreen.Render("✓"), styleBold.Render(c.Context), styleFaint.Render(fmt.Sprintf("%d applied", len(c.Objects))))
+			}
+			for _, o := range c.Objects {
+				if o.Error != "" {
+					w("    %s %s\n", styleRed.Render(o.Ref()+":"), o.Error)
+				}
+			}
+		}
+	case FleetGetResult:
+		if len(t.Rows) == 0 {
+			w("%s\n", styleFaint.Render("No "+t.Resource+" found."))
+		} else {
+			// Render the plain table and style its header line.
+			var buf strings.Builder
+			_ = (&plainPrinter{w: &buf}).Print(FleetGetResult{Resource: t.Resource, Columns: t.Columns, Rows: t.Rows})
+			header, rows, _ := strings.Cut(buf.String(), "\n")
+			w("%s\n%s", styleHeader.Render(header), rows)
+		}
+		for _, f := range t.Failed {
+			w("%s %s\n", styleRed.Render("✗ "+f.Cluster+":"), f.Error)
+		}
+	case PingResult, SyncVerifyResult, SyncBenchmarkResult, CustomColumnsResult, ClusterVersionsResult, FingerprintResult:
+		var buf strings.Builder
+		_ = (&plainPrinter{w: &buf}).Print(t)
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		w("%s\n%s", styleHeader.Render(header), rows)
+	case LintResult:
+		if len(t.Findings) == 0 {
+			w("%s %s\n", styleGreen.Render("✓ No problems found in"), styleBold.Render(t.Kubeconfig))
+			break
+		}
+		for _, f := range t.Findings {
+			icon := styleFaint.Render("i")
+			switch f.Severity {
+			case LintSeverityError:
+				icon = styleRed.Render("✗")
+			case LintSeverityWarning:
+				icon = styleYellow.Render("!")
+			}
+			w("  %s %s %s %s\n", icon, styleBold.Render(f.Kind+"/"+f.Name), f.Message, styleFaint.Render("("+f.Check+")"))
+		}
+		w("\n%s %s %s\n",
+			styleRed.Render(fmt.Sprintf("%d error(s),", t.Count(LintSeverityError))),
+			styleYellow.Render(fmt.Sprintf("%d warning(s),", t.Count(LintSeverityWarning))),
+			styleFaint.Render(fmt.Sprintf("%d info.", t.Count(LintSeverityInfo))))
+	case CertsResult:
+		if len(t.Certificates) == 0 {
+			w("%s\n", styleFaint.Render("No certificates found."))
+			break
+		}
+		for _, c := range t.Certificates {
+			icon, style := styleGreen.Render("✓"), styleFaint
+			switch c.Status {
+			case CertStatusExpired, CertStatusInvalid:
+				icon, style = styleRed.Render("✗"), styleRed
+			case CertStatusExpiring:
+				icon, style = styleYellow.Render("!"), styleYellow
+			}
+			entry := styleBold.Render(c.Kind + "/" + c.Name)
+			if c.NotAfter.IsZero() {
+				w("  %s %s %s %s\n", icon, entry, c.Type, style.Render(certStatusText(c)))
+				continue
+			}
+			w("  %s %s %s %s %s\n", icon, entry, c.Type, styleFaint.Render(c.NotAfter.UTC().Format(time.DateOnly)), style.Render(certStatusText(c)))
+		}
+	case CompareResult:
+		if t.Changes() == 0 {
+			w("%s %s %s %s\n", styleGreen.Render("✓"), styleBold.Render(t.FileA), styleFaint.Render("and"), styleBold.Render(t.FileB)+styleGreen.Render(" are equivalent."))
+			break
+		}
+		w("%s %s\n%s %s\n", styleRed.Render("---"), t.FileA, styleGreen.Render("+++"), t.FileB)
+		for _, section := range []struct {
+			title   string
+			entries []DiffEntry
+		}{{"CLUSTERS", t.Clusters}, {"CONTEXTS", t.Contexts}, {"USERS", t.AuthInfos}} {
+			if len(section.entries) == 0 {
+				continue
+			}
+			w("\n%s\n", styleHeader.Render(section.title))
+			for _, e := range section.entries {
+				marker := diffMarker(e.ChangeType)
+				switch e.ChangeType {
+				case "added":
+					marker = styleGreen.Render(marker)
+				case "removed":
+					marker = styleRed.Render(marker)
+				default:
+					marker = styleYellow.Render(marker)
+				}
+				w("%s %s\n", marker, e.Name)
+				for _, f := range e.Fields {
+					label := strings.ToLower(f.Field) + ":"
+					if f.Old == f.New {
+						w("  %s %-12s  %s\n", styleYellow.Render("~"), label, styleYellow.Render("changed"))
+						continue
+					}
+					if f.Old != "" {
+						w("  %s %-12s  %s\n", styleRed.Render("-"), label, styleRed.Render(f.Old))
+					}
+					if f.New != "" {
+						w("  %s %-12s  %s\n", styleGreen.Render("+"), label, styleGreen.Render(f.New))
+					}
+				}
+			}
+		}
+		w("\n%s\n", styleYellow.Render(fmt.Sprintf("%d difference(s).", t.Changes())))
+	case DedupeResult:
+		if len(t.Duplicates) == 0 {
+			w("%s %s\n", styleGreen.Render("✓ No duplicate entries found in"), styleBold.Render(t.Kubeconfig))
+			break
+		}
+		for _, d := range t.Duplicates {
+			style := styleGreen
+			if d.Action == DedupeActionKeep {
+				style = styleFaint
+			}
+			w("  %s %s %s %s %s\n", style.Render(dedupeActionText(d.Action, t.DryRun)), styleBold.Render(d.Cluster),
+				styleFaint.Render(d.Server+" →"), d.ManagedCluster, styleFaint.Render(strings.Join(d.Contexts, ", ")))
+		}
+		if t.DryRun {
+			w("\n%s\n", styleFaint.Render("Dry-run: no changes were written."))
+		}
+	case ConfigResult:
+		switch {
+		case t.Key != "":
+			w("%s\n", configValueText(t.Value))
+		case t.File == "":
+			w("%s\n", styleFaint.Render("No config file in use."))
+		default:
+			w("%s\n", styleFaint.Render("# "+t.File))
+			for _, k := range slices.Sorted(maps.Keys(t.Settings)) {
+				w("%s %s\n", styleBold.Render(k+":"), configValueText(t.Settings[k]))
+			}
+		}
+	case ConfigUpdateResult:
+		if t.Action == "unset" {
+			w("%s %s %s\n", styleFaint.Render("Unset"), styleBold.Render(t.Key), styleFaint.Render("in "+t.File))
+			break
+		}
+		w("%s %s %s %s %s\n", styleFaint.Render("Set"), styleBold.Render(t.Key), styleFaint.Render("to"), styleBold.Render(configValueText(t.Value)), styleFaint.Render("in "+t.File))
+	case InitResult:
+		w("%s %s\n", styleGreen.Render("Wrote profile to"), styleBold.Render(t.File))
+		w("  %s %s\n", styleFaint.Render("kubeconfig:"), t.Kubeconfig)
+		w("  %s %s\n", styleFaint.Render("context:   "), t.Context)
+		w("  %s %s\n", styleFaint.Render("namespace: "), t.Namespace)
+		w("  %s %s\n", styleFaint.Render("prefix:    "), t.Prefix)
+	case UseResult:
+		w("%s %s\n", styleFaint.Render("Switched to context"), styleBold.Render(t.Context))
+	case OrgUseResult:
+		if t.Previous != "" && t.Previous != t.Namespace {
+			w("%s %s %s %s %s\n", styleFaint.Render("Switched from organization"), t.Previous, styleFaint.Render("to"), styleBold.Render(t.Namespace), styleFaint.Render("in "+t.File))
+			break
+		}
+		w("%s %s %s\n", styleFaint.Render("Using organization"), styleBold.Render(t.Namespace), styleFaint.Render("in "+t.File))
+	case NamespaceResult:
+		w("%s %s %s %s\n", styleFaint.Render("Context"), styleBold.Render(t.Context), styleFaint.Render("now uses namespace"), styleBold.Render(t.Namespace))
+	case ProxyResult:
+		w("%s %s %s %s %s\n", styleFaint.Render("Serving the API of context"), styleBold.Render(t.Context), styleFaint.Render("on"), styleGreen.Render(t.URL), styleFaint.Render("(Ctrl+C to stop)"))
+	case StatusResult:
+		if len(t.Syncs) == 0 {
+			w("%s %s%s\n", styleFaint.Render("No sync recorded for"), styleBold.Render(t.Kubeconfig), styleFaint.Render(". Run cloudctl sync."))
+			break
+		}
+		for _, s := range t.Syncs {
+			icon, age := styleGreen.Render("✓"), styleFaint.Render(syncAgeText(s.AgeDays))
+			if s.Stale {
+				icon, age = styleYellow.Render("!"), styleYellow.Render(syncAgeText(s.AgeDays))
+			}
+			w("%s %s %s %s\n", icon, styleBold.Render(s.Namespace), styleFaint.Render("prefix "+s.Prefix+" from"), s.Endpoint)
+			w("  %s %s %s\n", styleFaint.Render("last sync:"), s.LastSync.Local().Format("2006-01-02 15:04"), age)
+			w("  %s %d %s\n", styleFaint.Render("clusters: "), s.Clusters, styleFaint.Render(fmt.Sprintf("(%d ready)", s.Ready)))
+			for _, g := range s.Groups {
+				w("    %s %d\n", styleFaint.Render(t.GroupBy+"="+cmp.Or(g.Value, "<none>")+":"), g.Clusters)
+			}
+			if s.Stale {
+				w("  %s\n", styleYellow.Render(fmt.Sprintf("your kubeconfig is %d days old, run cloudctl sync", s.AgeDays)))
+			}
+		}
+	case LoginResult:
+		w("%s %s %s %s\n", styleGreen.Render("Logged in to"), styleBold.Render(t.Issuer), styleFaint.Render("for context"), styleBold.Render(t.Context))
+		if !t.Expiry.IsZero() {
+			w("  %s %s\n", styleFaint.Render("token expires:"), t.Expiry.Local().Format(time.RFC1123))
+		}
+		w("  %s %s\n", styleFaint.Render("stored in:    "), t.StoredIn)
+	case SecretsMigrateResult:
+		verb := "Migrated"
+		if t.DryRun {
+			verb = "Would migrate"
+		}
+		migrated := 0
+		for _, e := range t.Entries {
+			if e.Status == SecretsMigrateMigrated {
+				migrated++
+			}
+		}
+		w("%s %s %s\n", styleBold.Render(fmt.Sprintf("%s %d of %d users to %s", verb, migrated, len(t.Entries), t.To)), styleFaint.Render("in"), t.Kubeconfig)
+		for _, e := range t.Entries {
+			status := styleFaint.Render(fmt.Sprintf("%-10s", e.Status))
+			if e.Status == SecretsMigrateMigrated {
+				status = styleGreen.Render(fmt.Sprintf("%-10s", e.Status))
+			}
+			line := fmt.Sprintf("  %s %s %s", status, e.User, styleFaint.Render("(from "+e.From+")"))
+			if e.Reason != "" {
+				line += styleFaint.Render(": " + e.Reason)
+			}
+			w("%s\n", line)
+		}
+	case BackupsPruneResult:
+		verb := "Removed"
+		if t.DryRun {
+			verb = "Would remove"
+		}
+		w("%s %s %s\n", styleBold.Render(fmt.Sprintf("%s %d backups", verb, len(t.Removed))), styleFaint.Render("from"), t.Directory)
+		for _, b := range t.Removed {
+			w("  %s %s\n", b.Name, styleFaint.Render(fmt.Sprintf("%s, %d bytes", b.Time.Local().Format(time.RFC1123), b.Size)))
+		}
+		w("%s\n", styleFaint.Render(fmt.Sprintf("%d backups kept", t.Kept)))
+	case ArchiveListResult:
+		if len(t.Contexts) == 0 {
+			w("%s\n", styleFaint.Render("No archived contexts in "+t.Archive+"."))
+			break
+		}
+		for _, c := range t.Contexts {
+			archived := "-"
+			if !c.ArchivedAt.IsZero() {
+				archived = c.ArchivedAt.Local().Format(time.RFC1123)
+			}
+			w("%s %s %s\n", styleBold.Render(c.Context), styleFaint.Render(c.Cluster+" "+c.Server), styleFaint.Render("archived "+archived))
+		}
+	case ArchiveRestoreResult:
+		w("%s %s %s %s\n", styleGreen.Render("Restored"), styleBold.Render(strings.Join(t.Restored, ", ")), styleFaint.Render("into"), t.Kubeconfig)
+	case StateCompactResult:
+		verb := "Removed"
+		if t.DryRun {
+			verb = "Would remove"
+		}
+		if len(t.Removed) == 0 {
+			w("%s\n", styleGreen.Render("Nothing to compact."))
+			break
+		}
+		w("%s\n", styleBold.Render(fmt.Sprintf("%s %d entries", verb, len(t.Removed))))
+		for _, e := range t.Removed {
+			w("  %s %s %s\n", styleFaint.Render(fmt.Sprintf("%-11s", e.Kind)), e.Name, styleFaint.Render("("+e.Reason+")"))
+		}
+	case ClusterListResult:
+		if len(t.Clusters) == 0 {
+			w("%s\n", styleFaint.Render("No clusters in "+t.Namespace+"."))
+			break
+		}
+		details := hasClusterDetails(t.Clusters)
+		table := func(clusters []ClusterListItem) {
+			var buf strings.Builder
+			_ = writeClusterTable(&buf, clusters, details)
+			header, rows, _ := strings.Cut(buf.String(), "\n")
+			w("%s\n%s", styleHeader.Render(header), rows)
+		}
+		if t.GroupBy == "" {
+			table(t.Clusters)
+			break
+		}
+		rest, total, ready := t.Clusters, 0, 0
+		for i, g := range t.Groups {
+			if i > 0 {
+				w("\n")
+			}
+			w("%s\n", styleBold.Render(clusterGroupTitle(t.GroupBy, g)))
+			table(rest[:g.Clusters])
+			rest = rest[g.Clusters:]
+			total, ready = total+g.Clusters, ready+g.Ready
+		}
+		w("\n%s %s\n", styleBold.Render("Total:"), clusterCountText(total, ready))
+	case ClusterEvent:
+		var text string
+		switch {
+		case t.Type == ClusterEventDeleted:
+		case t.Ready:
+			text = " " + styleGreen.Render(clusterEventText(t))
+		default:
+			text = " " + styleRed.Render(clusterEventText(t))
+		}
+		w("%s %s %s%s\n", styleFaint.Render(t.Time.Local().Format(time.TimeOnly)), t.Type, styleBold.Render(t.Name), text)
+	case LabelsResult:
+		if len(t.Keys) == 0 {
+			w("%s\n", styleFaint.Render(fmt.Sprintf("No labels on the %d clusters in %s.", t.Clusters, t.Namespace)))
+			break
+		}
+		var buf strings.Builder
+		_ = (&plainPrinter{w: &buf}).Print(t)
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		w("%s\n%s", styleHeader.Render(header), rows)
+	case MembershipsResult:
+		w("%s %s\n", styleBold.Render("User:"), t.User)
+		w("%s %s\n", styleBold.Render("Teams:"), cmp.Or(strings.Join(t.Teams, ", "), styleFaint.Render("none")))
+		if len(t.Grants) == 0 {
+			w("%s\n", styleFaint.Render(fmt.Sprintf("No TeamRoleBindings in %s grant %s access to a cluster.", t.Namespace, t.User)))
+			break
+		}
+		var buf strings.Builder
+		_ = writeMembershipTable(&buf, t.Grants)
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		w("\n%s\n%s", styleHeader.Render(header), rows)
+	case RBACInspectResult:
+		if len(t.Bindings) == 0 {
+			w("%s\n", styleFaint.Render(fmt.Sprintf("No TeamRoleBindings in %s apply to %s.", t.Namespace, t.Cluster)))
+			break
+		}
+		table := func(write func(io.Writer, []RBACBinding) error) {
+			var buf strings.Builder
+			_ = write(&buf, t.Bindings)
+			header, rows, _ := strings.Cut(buf.String(), "\n")
+			w("%s\n%s", styleHeader.Render(header), rows)
+		}
+		table(writeRBACBindingTable)
+		if t.Context != "" {
+			w("\n%s\n", styleBold.Render(fmt.Sprintf("RBAC objects on %s (context %s):", t.Cluster, t.Context)))
+			table(writeRBACObjectTable)
+		}
 	case VersionInfo:
 		w("%s\n", styleHeader.Render("cloudctl "+t.Version))
 		w("  git commit: %s\n", t.GitCommit)
@@ -148,7 +559,7 @@ func (p *interactivePrinter) printSyncResult(r SyncResult) error {
 	// Collect only clusters that need attention.
 	var issues []ClusterSyncResult
 	for _, c := range r.Clusters {
-		if c.Status != ClusterSyncStatusSynced {
+		if c.Status != ClusterSyncStatusSynced || len(c.Warnings) > 0 {
 			issues = append(issues, c)
 		}
 	}
@@ -167,6 +578,9 @@ func (p *interactivePrinter) printSyncResult(r SyncResult) error {
 			case ClusterSyncStatusFailed:
 				icon = styleRed.Render("✗")
 				statusStr = styleRed.Render("failed")
+			case ClusterSyncStatusSynced:
+				icon = styleYellow.Render("!")
+				statusStr = styleGreen.Render("synced")
 			default:
 				icon = styleYellow.Render("!")
 				statusStr = styleYellow.Render("skipped")
@@ -177,6 +591,9 @@ func (p *interactivePrinter) printSyncResult(r SyncResult) error {
 				name = name[:colCluster-5] + "..."
 			}
 			reason := c.Reason
+			if c.Status == ClusterSyncStatusSynced {
+				reason = strings.Join(c.Warnings, "; ")
+			}
 			if reason == "" {
 				if c.Status == ClusterSyncStatusSkipped {
 					reason = "not ready"
@@ -221,6 +638,15 @@ func (p *interactivePrinter) printSyncResult(r SyncResult) error {
 		}
 	}
 	w("%s\n", summary)
+	if r.Unchanged {
+		w("%s\n", styleFaint.Render("Kubeconfig already up to date; nothing written."))
+	}
+	if len(r.SharedUsers) > 0 {
+		w("\n%s\n", styleHeader.Render("SHARED USERS"))
+		for _, u := range r.SharedUsers {
+			w("%s  %s\n  %s\n", u.User, styleFaint.Render(u.Settings()), strings.Join(u.Contexts, ", "))
+		}
+	}
 	return writeErr
 }
 
@@ -31,3 +31,7 @@ func (p *jsonPrinter) PrintError(err error) {
 func (p *jsonPrinter) StartSpinner(_ string) func() {
 	return func() {}
 }
+
+func (p *jsonPrinter) StartProgress(_ string, _ int) (func(), func()) {
+	return func() {}, func() {}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/yaml"
@@ -166,6 +167,19 @@ func TestPlainPrinter_SyncResult_AllSynced(t *testing.T) {
 	g.Expect(buf.String()).To(ContainSubstring("Synced all 2 clusters successfully."))
 }
 
+func TestPlainPrinter_SyncResult_Unchanged(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	result := output.SyncResult{
+		Clusters:  []output.ClusterSyncResult{{Name: "a", Status: output.ClusterSyncStatusSynced}},
+		Synced:    1,
+		Unchanged: true,
+	}
+	g.Expect(p.Print(result)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("Synced 1 cluster successfully.\nKubeconfig already up to date; nothing written.\n"))
+}
+
 func TestPlainPrinter_SyncResult_NoClusters(t *testing.T) {
 	g := NewWithT(t)
 	var buf bytes.Buffer
@@ -184,10 +198,81 @@ func TestPlainPrinter_ClusterVersionResult(t *testing.T) {
 	g.Expect(out).To(Equal("Kubernetes version: 1.29.0"))
 }
 
+func TestPlainPrinter_SyncResult_SharedUsers(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	g.Expect(p.Print(output.SyncResult{
+		Clusters: []output.ClusterSyncResult{{Name: "eu", Status: output.ClusterSyncStatusSynced}},
+		Synced:   1,
+		SharedUsers: []output.SharedUser{{
+			User:      "cloudctl:auth-0123",
+			Contexts:  []string{"eu", "us"},
+			Auth:      output.ClusterInfoAuth{Mechanism: "oidc", Command: "kubelogin get-token", Issuer: "https://idp", ClientID: "cli"},
+			Connector: "ldap",
+			Scopes:    []string{"email", "groups"},
+		}},
+	})).To(Succeed())
+
+	g.Expect(buf.String()).To(Equal("Synced 1 cluster successfully.\n" +
+		"  [=] cloudctl:auth-0123 — shared by eu, us (oidc, issuer https://idp, client ID cli, connector ldap, scopes email,groups)\n"))
+}
+
+func TestPlainPrinter_SyncResult_Warnings(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	g.Expect(p.Print(output.SyncResult{
+		Clusters: []output.ClusterSyncResult{
+			{Name: "eu", Status: output.ClusterSyncStatusSynced, Warnings: []string{`CA certificate of cluster "eu" expires on 2026-01-13 (in 12 day(s))`}},
+			{Name: "us", Status: output.ClusterSyncStatusSynced},
+		},
+		Synced: 2,
+	})).To(Succeed())
+
+	g.Expect(buf.String()).To(Equal("  [~] eu — warning: CA certificate of cluster \"eu\" expires on 2026-01-13 (in 12 day(s))\n" +
+		"Synced all 2 clusters successfully.\n"))
+}
+
+func TestPlainPrinter_ClusterVersionResult_Nodes(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	g.Expect(p.Print(output.ClusterVersionResult{Context: "my-ctx", Version: "1.30.2", Nodes: []output.NodeVersion{
+		{Name: "node-a", KubeletVersion: "1.30.2", Supported: true},
+		{Name: "b", KubeletVersion: "1.29.5", MinorSkew: 1, Supported: true},
+		{Name: "c", KubeletVersion: "1.26.9", MinorSkew: 4},
+	}})).To(Succeed())
+
+	g.Expect(buf.String()).To(Equal("Kubernetes version: 1.30.2\n" +
+		"Nodes (3):\n" +
+		"      node-a  kubelet 1.30.2\n" +
+		"  [~] b       kubelet 1.29.5 — 1 minor version behind\n" +
+		"  [!] c       kubelet 1.26.9 — 4 minor versions behind (unsupported skew)\n"))
+}
+
 // ---------------------------------------------------------------------------
 // TTY / Non-TTY selection
 // ---------------------------------------------------------------------------
 
+func TestPlainPrinter_ClusterVersionsResult(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+
+	g.Expect(p.Print(output.ClusterVersionsResult{Clusters: []output.ClusterVersionEntry{
+		{Context: "cloudctl:eu-1", Version: "1.30.2", Cached: true},
+		{Context: "cloudctl:us-1", Version: "1.31.0"},
+		{Context: "cloudctl:dark", Error: "timeout"},
+	}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("" +
+		"CONTEXT        VERSION  SOURCE\n" +
+		"cloudctl:eu-1  1.30.2   cache\n" +
+		"cloudctl:us-1  1.31.0   server\n" +
+		"cloudctl:dark  -        -\n" +
+		"\ncloudctl:dark: timeout\n"))
+}
+
 func TestNew_NonTTY_Text_NoANSI(t *testing.T) {
 	g := NewWithT(t)
 	var buf bytes.Buffer
@@ -210,6 +295,17 @@ func TestStartSpinner_NoOp_JSON(t *testing.T) {
 	g.Expect(buf.String()).To(BeEmpty())
 }
 
+func TestStartProgress_NoOp_Plain(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	step, stop := p.StartProgress("Checking clusters", 3)
+	step()
+	step()
+	stop()
+	g.Expect(buf.String()).To(BeEmpty())
+}
+
 // ---------------------------------------------------------------------------
 // SyncDryRunResult printers
 // ---------------------------------------------------------------------------
@@ -315,3 +411,96 @@ func TestYAMLPrinter_SyncDryRunResult(t *testing.T) {
 	g.Expect(got.Removed).To(Equal(1))
 	g.Expect(got.Clusters[0].ChangeType).To(Equal("removed"))
 }
+
+func TestPlainPrinter_ClusterEvent(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	g.Expect(p.Print(output.ClusterEvent{Time: at, Type: output.ClusterEventAdded, Name: "eu", Reason: "waiting for nodes"})).To(Succeed())
+	g.Expect(p.Print(output.ClusterEvent{Time: at, Type: output.ClusterEventModified, Name: "eu", Ready: true, BecameReady: true})).To(Succeed())
+	g.Expect(p.Print(output.ClusterEvent{Time: at, Type: output.ClusterEventDeleted, Name: "eu"})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("2026-01-02T03:04:05Z  added  eu  not ready: waiting for nodes\n" +
+		"2026-01-02T03:04:05Z  modified  eu  became ready\n" +
+		"2026-01-02T03:04:05Z  deleted  eu\n"))
+}
+
+func TestPlainPrinter_ClusterListDetails(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+
+	g.Expect(p.Print(output.ClusterListResult{Namespace: "org", Clusters: []output.ClusterListItem{
+		{Name: "eu", Ready: true, AccessMode: "direct", KubernetesVersion: "v1.31.2"},
+		{Name: "old", Ready: true, DeletionScheduled: true},
+		{Name: "older", DeletionScheduled: true, DeletionSchedule: time.Now().Add(-time.Hour)},
+		{Name: "us"},
+	}})).To(Succeed())
+	lines := strings.Split(buf.String(), "\n")
+	g.Expect(strings.Fields(lines[0])).To(Equal([]string{"NAME", "READY", "AGE", "VERSION", "ACCESS", "DELETION"}))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"eu", "true", "<unknown>", "v1.31.2", "direct", "-"}))
+	g.Expect(strings.Fields(lines[2])).To(HaveLen(6))
+	g.Expect(lines[2]).To(HaveSuffix("pending"))
+	g.Expect(lines[3]).To(HaveSuffix("due"))
+	g.Expect(strings.Fields(lines[4])).To(Equal([]string{"us", "false", "<unknown>", "-", "-", "-"}))
+}
+
+func TestPlainPrinter_MembershipsResult(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+
+	g.Expect(p.Print(output.MembershipsResult{Namespace: "org", User: "jane", Teams: []string{"platform"}, Grants: []output.MembershipRole{
+		{Cluster: "eu-1", Role: "cluster-admin", Team: "platform", Applied: true},
+		{Cluster: "us-1", Role: "viewer", Namespaces: []string{"a", "b"}},
+	}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("User: jane\nTeams: platform\n\n" +
+		"CLUSTER  ROLE                  VIA            NAMESPACES\n" +
+		"eu-1     cluster-admin         team platform  (cluster-wide)\n" +
+		"us-1     viewer (not applied)  user           a, b\n"))
+
+	buf.Reset()
+	g.Expect(p.Print(output.MembershipsResult{Namespace: "org", User: "bob", Teams: []string{}, Grants: []output.MembershipRole{}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("User: bob\nTeams: none\nNo TeamRoleBindings in org grant bob access to a cluster.\n"))
+}
+
+func TestPlainPrinter_RBACInspectResult(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+
+	g.Expect(p.Print(output.RBACInspectResult{Namespace: "org", Cluster: "eu-1", Context: "cloudctl:eu-1", Bindings: []output.RBACBinding{
+		{Name: "admin", Team: "platform", IDPGroup: "ADMINS", Role: "cluster-admin", Status: "applied", Objects: []output.RBACObject{
+			{Kind: "ClusterRole", Name: "greenhouse:cluster-admin", Exists: true},
+			{Kind: "ClusterRoleBinding", Name: "greenhouse:admin"},
+		}},
+		{Name: "oncall", Usernames: []string{"jane"}, Role: "viewer", Namespaces: []string{"a"}, Status: "failed", Message: "boom", Objects: []output.RBACObject{
+			{Kind: "RoleBinding", Namespace: "a", Name: "greenhouse:oncall", Error: "forbidden"},
+		}},
+	}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("" +
+		"BINDING  SUBJECTS                ROLE           NAMESPACES      STATUS\n" +
+		"admin    team platform (ADMINS)  cluster-admin  (cluster-wide)  applied\n" +
+		"oncall   user jane               viewer         a               failed: boom\n" +
+		"\nRBAC objects on eu-1 (context cloudctl:eu-1):\n" +
+		"BINDING  KIND                NAME                      STATUS\n" +
+		"admin    ClusterRole         greenhouse:cluster-admin  present\n" +
+		"admin    ClusterRoleBinding  greenhouse:admin          missing\n" +
+		"oncall   RoleBinding         a/greenhouse:oncall       unknown: forbidden\n"))
+
+	buf.Reset()
+	g.Expect(p.Print(output.RBACInspectResult{Namespace: "org", Cluster: "eu-1", Bindings: []output.RBACBinding{}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("No TeamRoleBindings in org apply to eu-1.\n"))
+}
+
+func TestPlainPrinter_LabelsResult(t *testing.T) {
+	g := NewWithT(t)
+	var buf bytes.Buffer
+	p := output.New(output.FormatText, false, &buf)
+
+	g.Expect(p.Print(output.LabelsResult{Namespace: "org", Clusters: 4, Keys: []output.LabelKey{
+		{Key: "region", Clusters: 3, Values: []output.LabelValue{{Value: "eu", Clusters: 2}, {Value: "ap", Clusters: 1}}},
+	}})).To(Succeed())
+	g.Expect(buf.String()).To(Equal("KEY     CLUSTERS  VALUES\nregion  3/4       eu (2), ap (1)\n"))
+}
@@ -4,10 +4,18 @@
 package output
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
 )
 
 type plainPrinter struct {
@@ -41,6 +49,10 @@ func (p *plainPrinter) Print(v any) error {
 					reason = "unknown error"
 				}
 				w("  [!] %s — failed: %s\n", c.Name, reason)
+			case ClusterSyncStatusSynced:
+				for _, warning := range c.Warnings {
+					w("  [~] %s — warning: %s\n", c.Name, warning)
+				}
 			}
 		}
 
@@ -67,6 +79,12 @@ func (p *plainPrinter) Print(v any) error {
 				w("Synced all %d clusters successfully.\n", total)
 			}
 		}
+		if t.Unchanged {
+			w("Kubeconfig already up to date; nothing written.\n")
+		}
+		for _, u := range t.SharedUsers {
+			w("  [=] %s — shared by %s (%s)\n", u.User, strings.Join(u.Contexts, ", "), u.Settings())
+		}
 
 	case SyncDryRunResult:
 		total := t.Added + t.Removed + t.Modified
@@ -80,6 +98,491 @@ func (p *plainPrinter) Print(v any) error {
 
 	case ClusterVersionResult:
 		w("Kubernetes version: %s\n", t.Version)
+		if len(t.Nodes) > 0 {
+			width := 0
+			for _, n := range t.Nodes {
+				width = max(width, len(n.Name))
+			}
+			w("Nodes (%d):\n", len(t.Nodes))
+			for _, n := range t.Nodes {
+				marker := "   "
+				switch {
+				case !n.Supported:
+					marker = "[!]"
+				case n.MinorSkew != 0:
+					marker = "[~]"
+				}
+				line := fmt.Sprintf("  %s %-*s  kubelet %s", marker, width, n.Name, n.KubeletVersion)
+				if d := n.SkewDescription(); d != "" {
+					line += " — " + d
+				}
+				w("%s\n", line)
+			}
+		}
+
+	case ClusterInfoResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		for _, f := range t.Fields() {
+			_, _ = fmt.Fprintf(tw, "%s:\t%s\n", f[0], f[1])
+		}
+		writeErr = tw.Flush()
+
+	case FingerprintResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\tFINGERPRINT\n")
+		for _, c := range t.Contexts {
+			fp := c.Fingerprint
+			if c.Error != "" {
+				fp = "error: " + c.Error
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", c.Context, fp)
+		}
+		writeErr = tw.Flush()
+		if t.Combined != "" {
+			w("\ncombined: %s\n", t.Combined)
+		}
+
+	case ClusterVersionsResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\tVERSION\tSOURCE\n")
+		var failed []ClusterVersionEntry
+		for _, c := range t.Clusters {
+			version, source := c.Version, "server"
+			switch {
+			case c.Error != "":
+				version, source = "-", "-"
+				failed = append(failed, c)
+			case c.Cached:
+				source = "cache"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Context, version, source)
+		}
+		writeErr = tw.Flush()
+		if len(failed) > 0 {
+			w("\n")
+		}
+		for _, c := range failed {
+			w("%s: %s\n", c.Context, c.Error)
+		}
+
+	case APICheckResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\t%s\n", strings.Join(t.APIs, "\t"))
+		var failed []APICheckCluster
+		for _, c := range t.Clusters {
+			cells := make([]string, len(t.APIs))
+			for i, api := range t.APIs {
+				switch {
+				case c.Error != "":
+					cells[i] = "error"
+				case c.Available[api]:
+					cells[i] = "yes"
+				default:
+					cells[i] = "no"
+				}
+			}
+			if c.Error != "" {
+				failed = append(failed, c)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", c.Context, strings.Join(cells, "\t"))
+		}
+		writeErr = tw.Flush()
+		if len(failed) > 0 {
+			w("\n")
+		}
+		for _, c := range failed {
+			w("%s: %s\n", c.Context, c.Error)
+		}
+
+	case FleetApplyResult:
+		if t.DryRun {
+			w("Dry run, nothing was changed.\n\n")
+		}
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\tAPPLIED\tFAILED\n")
+		var failed []string
+		for _, c := range t.Clusters {
+			if c.Error != "" {
+				_, _ = fmt.Fprintf(tw, "%s\t-\t-\n", c.Context)
+				failed = append(failed, c.Context+": "+c.Error)
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", c.Context, len(c.Objects)-c.Failed(), c.Failed())
+			for _, o := range c.Objects {
+				if o.Error != "" {
+					failed = append(failed, c.Context+": "+o.Ref()+": "+o.Error)
+				}
+			}
+		}
+		writeErr = tw.Flush()
+		if len(failed) > 0 {
+			w("\n")
+		}
+		for _, f := range failed {
+			w("%s\n", f)
+		}
+
+	case FleetGetResult:
+		if len(t.Rows) == 0 {
+			w("No %s found.\n", t.Resource)
+		} else {
+			tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+			header := []string{"CLUSTER"}
+			if t.Namespaced() {
+				header = append(header, "NAMESPACE")
+			}
+			_, _ = fmt.Fprintf(tw, "%s\n", strings.Join(append(header, upper(t.Columns)...), "\t"))
+			for _, row := range t.Rows {
+				cells := []string{row.Cluster}
+				if t.Namespaced() {
+					cells = append(cells, row.Namespace)
+				}
+				_, _ = fmt.Fprintf(tw, "%s\n", strings.Join(append(cells, row.Cells...), "\t"))
+			}
+			writeErr = tw.Flush()
+		}
+		if len(t.Failed) > 0 {
+			w("\n")
+		}
+		for _, f := range t.Failed {
+			w("%s: %s\n", f.Cluster, f.Error)
+		}
+
+	case PingResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\tRECEIVED\tMIN\tAVG\tP50\tP95\tMAX\tTCP\tTLS\n")
+		var failed []PingCluster
+		for _, c := range t.Clusters {
+			if c.Received == 0 {
+				_, _ = fmt.Fprintf(tw, "%s\t0/%d\t-\t-\t-\t-\t-\t-\t-\n", c.Context, t.Count)
+			} else {
+				_, _ = fmt.Fprintf(tw, "%s\t%d/%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Context, c.Received, t.Count,
+					msText(c.MinMs), msText(c.AvgMs), msText(c.P50Ms), msText(c.P95Ms), msText(c.MaxMs), msText(c.TCPMs), msText(c.TLSMs))
+			}
+			if c.Error != "" {
+				failed = append(failed, c)
+			}
+		}
+		writeErr = tw.Flush()
+		if len(failed) > 0 {
+			w("\n")
+		}
+		for _, c := range failed {
+			w("%s: %s\n", c.Context, c.Error)
+		}
+
+	case SyncVerifyResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "CONTEXT\tSERVER\tSTATUS\n")
+		var failed []SyncVerifyContext
+		for _, c := range t.Contexts {
+			status := "reachable (" + msText(c.LatencyMs) + ")"
+			if !c.Reachable {
+				status = "unreachable"
+				failed = append(failed, c)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Context, cmp.Or(c.Server, "-"), status)
+		}
+		writeErr = tw.Flush()
+		if len(failed) > 0 {
+			w("\n")
+		}
+		for _, c := range failed {
+			w("%s: %s\n", c.Context, c.Error)
+		}
+
+	case SyncBenchmarkResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "PHASE\tTIME\n")
+		for _, ph := range t.Phases {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", ph.Name, msText(ph.Ms))
+		}
+		_, _ = fmt.Fprintf(tw, "total\t%s\n", msText(t.TotalMs))
+		writeErr = tw.Flush()
+		w("\n%d clusters, %s allocated in %d allocations, %s heap, %d GC cycles\n",
+			t.Clusters, mibText(t.AllocatedBytes), t.Allocations, mibText(t.HeapBytes), t.GCCycles)
+
+	case LintResult:
+		if len(t.Findings) == 0 {
+			w("No problems found in %s.\n", t.Kubeconfig)
+			break
+		}
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		for _, f := range t.Findings {
+			_, _ = fmt.Fprintf(tw, "%s\t%s/%s\t%s\t%s\n", f.Severity, f.Kind, f.Name, f.Check, f.Message)
+		}
+		writeErr = tw.Flush()
+		w("\n%d error(s), %d warning(s), %d info.\n",
+			t.Count(LintSeverityError), t.Count(LintSeverityWarning), t.Count(LintSeverityInfo))
+
+	case CertsResult:
+		if len(t.Certificates) == 0 {
+			w("No certificates found.\n")
+			break
+		}
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "ENTRY\tCERTIFICATE\tNOT AFTER\tSTATUS\n")
+		for _, c := range t.Certificates {
+			notAfter := "-"
+			if !c.NotAfter.IsZero() {
+				notAfter = c.NotAfter.UTC().Format(time.DateOnly)
+			}
+			_, _ = fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\n", c.Kind, c.Name, c.Type, notAfter, certStatusText(c))
+		}
+		writeErr = tw.Flush()
+
+	case CompareResult:
+		if t.Changes() == 0 {
+			w("%s and %s are equivalent.\n", t.FileA, t.FileB)
+			break
+		}
+		w("--- %s\n+++ %s\n", t.FileA, t.FileB)
+		for _, section := range []struct {
+			title   string
+			entries []DiffEntry
+		}{{"clusters", t.Clusters}, {"contexts", t.Contexts}, {"users", t.AuthInfos}} {
+			if len(section.entries) == 0 {
+				continue
+			}
+			w("\n%s:\n", section.title)
+			for _, e := range section.entries {
+				w("%s %s\n", diffMarker(e.ChangeType), e.Name)
+				for _, f := range e.Fields {
+					label := strings.ToLower(f.Field) + ":"
+					switch {
+					case f.Old == f.New:
+						w("  ~ %-12s  changed\n", label)
+					case f.Old == "":
+						w("  + %-12s  %s\n", label, f.New)
+					case f.New == "":
+						w("  - %-12s  %s\n", label, f.Old)
+					default:
+						w("  - %-12s  %s\n", label, f.Old)
+						w("  + %-12s  %s\n", label, f.New)
+					}
+				}
+			}
+		}
+		w("\n%d difference(s).\n", t.Changes())
+
+	case DedupeResult:
+		if len(t.Duplicates) == 0 {
+			w("No duplicate entries found in %s.\n", t.Kubeconfig)
+			break
+		}
+		for _, d := range t.Duplicates {
+			w("%s  %s  %s -> %s\n", dedupeActionText(d.Action, t.DryRun), d.Cluster, d.Server, d.ManagedCluster)
+			for _, c := range d.Contexts {
+				w("    context %s\n", c)
+			}
+		}
+		if t.DryRun {
+			w("Dry-run: no changes were written.\n")
+		}
+
+	case ConfigResult:
+		switch {
+		case t.Key != "":
+			w("%s\n", configValueText(t.Value))
+		case t.File == "":
+			w("No config file in use.\n")
+		default:
+			w("# %s\n", t.File)
+			for _, k := range slices.Sorted(maps.Keys(t.Settings)) {
+				w("%s: %s\n", k, configValueText(t.Settings[k]))
+			}
+		}
+
+	case ConfigUpdateResult:
+		if t.Action == "unset" {
+			w("Unset %s in %s.\n", t.Key, t.File)
+			break
+		}
+		w("Set %s to %s in %s.\n", t.Key, configValueText(t.Value), t.File)
+
+	case InitResult:
+		w("Wrote profile to %s:\n", t.File)
+		w("  kubeconfig: %s\n", t.Kubeconfig)
+		w("  context:    %s\n", t.Context)
+		w("  namespace:  %s\n", t.Namespace)
+		w("  prefix:     %s\n", t.Prefix)
+
+	case UseResult:
+		w("Switched to context %q.\n", t.Context)
+
+	case OrgUseResult:
+		if t.Previous != "" && t.Previous != t.Namespace {
+			w("Switched from organization %s to %s in %s.\n", t.Previous, t.Namespace, t.File)
+			break
+		}
+		w("Using organization %s in %s.\n", t.Namespace, t.File)
+
+	case NamespaceResult:
+		w("Context %q now uses namespace %q.\n", t.Context, t.Namespace)
+
+	case ProxyResult:
+		w("Serving the API of context %q on %s (Ctrl+C to stop).\n", t.Context, t.URL)
+
+	case StatusResult:
+		if len(t.Syncs) == 0 {
+			w("No sync recorded for %s. Run cloudctl sync.\n", t.Kubeconfig)
+			break
+		}
+		w("Kubeconfig %s:\n", t.Kubeconfig)
+		for _, s := range t.Syncs {
+			w("  %s (prefix %s) from %s\n", s.Namespace, s.Prefix, s.Endpoint)
+			w("    last sync: %s (%s)\n", s.LastSync.UTC().Format("2006-01-02 15:04 UTC"), syncAgeText(s.AgeDays))
+			w("    clusters:  %d (%d ready)\n", s.Clusters, s.Ready)
+			for _, g := range s.Groups {
+				w("      %s=%s: %d\n", t.GroupBy, cmp.Or(g.Value, "<none>"), g.Clusters)
+			}
+			if s.Stale {
+				w("    warning:   your kubeconfig is %d days old, run cloudctl sync\n", s.AgeDays)
+			}
+		}
+
+	case LoginResult:
+		w("Logged in to %s for context %q.\n", t.Issuer, t.Context)
+		if !t.Expiry.IsZero() {
+			w("  token expires: %s\n", t.Expiry.Local().Format(time.RFC1123))
+		}
+		w("  stored in:     %s\n", t.StoredIn)
+
+	case SecretsMigrateResult:
+		verb := "Migrated"
+		if t.DryRun {
+			verb = "Would migrate"
+		}
+		migrated := 0
+		for _, e := range t.Entries {
+			if e.Status == SecretsMigrateMigrated {
+				migrated++
+			}
+		}
+		w("%s %d of %d users to %s in %s.\n", verb, migrated, len(t.Entries), t.To, t.Kubeconfig)
+		for _, e := range t.Entries {
+			line := fmt.Sprintf("  %-10s %s (from %s)", e.Status, e.User, e.From)
+			if e.Reason != "" {
+				line += ": " + e.Reason
+			}
+			w("%s\n", line)
+		}
+
+	case BackupsPruneResult:
+		verb := "Removed"
+		if t.DryRun {
+			verb = "Would remove"
+		}
+		w("%s %d backups from %s, keeping %d.\n", verb, len(t.Removed), t.Directory, t.Kept)
+		for _, b := range t.Removed {
+			w("  %s  %s  %d bytes\n", b.Name, b.Time.Local().Format(time.RFC1123), b.Size)
+		}
+
+	case ArchiveListResult:
+		if len(t.Contexts) == 0 {
+			w("No archived contexts in %s.\n", t.Archive)
+			break
+		}
+		for _, c := range t.Contexts {
+			archived := "-"
+			if !c.ArchivedAt.IsZero() {
+				archived = c.ArchivedAt.Local().Format(time.RFC1123)
+			}
+			w("%s  %s  %s  archived %s\n", c.Context, c.Cluster, c.Server, archived)
+		}
+
+	case ArchiveRestoreResult:
+		w("Restored %s into %s.\n", strings.Join(t.Restored, ", "), t.Kubeconfig)
+
+	case StateCompactResult:
+		verb := "Removed"
+		if t.DryRun {
+			verb = "Would remove"
+		}
+		if len(t.Removed) == 0 {
+			w("Nothing to compact.\n")
+			break
+		}
+		w("%s %d entries:\n", verb, len(t.Removed))
+		for _, e := range t.Removed {
+			w("  %-11s  %s  (%s)\n", e.Kind, e.Name, e.Reason)
+		}
+
+	case ClusterListResult:
+		if len(t.Clusters) == 0 {
+			w("No clusters in %s.\n", t.Namespace)
+			break
+		}
+		details := hasClusterDetails(t.Clusters)
+		if t.GroupBy == "" {
+			writeErr = writeClusterTable(p.w, t.Clusters, details)
+			break
+		}
+		rest, total, ready := t.Clusters, 0, 0
+		for i, g := range t.Groups {
+			if i > 0 {
+				w("\n")
+			}
+			w("%s\n", clusterGroupTitle(t.GroupBy, g))
+			if err := writeClusterTable(p.w, rest[:g.Clusters], details); err != nil {
+				writeErr = err
+			}
+			rest = rest[g.Clusters:]
+			total, ready = total+g.Clusters, ready+g.Ready
+		}
+		w("\nTotal: %s\n", clusterCountText(total, ready))
+
+	case ClusterEvent:
+		line := fmt.Sprintf("%s  %s  %s", t.Time.UTC().Format(time.RFC3339), t.Type, t.Name)
+		if t.Type != ClusterEventDeleted {
+			line += "  " + clusterEventText(t)
+		}
+		w("%s\n", line)
+
+	case CustomColumnsResult:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "%s\n", strings.Join(t.Columns, "\t"))
+		for _, row := range t.Rows {
+			_, _ = fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+		}
+		writeErr = tw.Flush()
+
+	case LabelsResult:
+		if len(t.Keys) == 0 {
+			w("No labels on the %d clusters in %s.\n", t.Clusters, t.Namespace)
+			break
+		}
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "KEY\tCLUSTERS\tVALUES\n")
+		for _, k := range t.Keys {
+			values := make([]string, len(k.Values))
+			for i, v := range k.Values {
+				values[i] = fmt.Sprintf("%s (%d)", v.Value, v.Clusters)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d/%d\t%s\n", k.Key, k.Clusters, t.Clusters, strings.Join(values, ", "))
+		}
+		writeErr = tw.Flush()
+
+	case MembershipsResult:
+		w("User: %s\n", t.User)
+		w("Teams: %s\n", cmp.Or(strings.Join(t.Teams, ", "), "none"))
+		if len(t.Grants) == 0 {
+			w("No TeamRoleBindings in %s grant %s access to a cluster.\n", t.Namespace, t.User)
+			break
+		}
+		w("\n")
+		writeErr = writeMembershipTable(p.w, t.Grants)
+
+	case RBACInspectResult:
+		if len(t.Bindings) == 0 {
+			w("No TeamRoleBindings in %s apply to %s.\n", t.Namespace, t.Cluster)
+			break
+		}
+		writeErr = writeRBACBindingTable(p.w, t.Bindings)
+		if t.Context != "" && writeErr == nil {
+			w("\nRBAC objects on %s (context %s):\n", t.Cluster, t.Context)
+			writeErr = writeRBACObjectTable(p.w, t.Bindings)
+		}
 
 	case VersionInfo:
 		w("cloudctl %s\n", t.Version)
@@ -114,6 +617,10 @@ func (p *plainPrinter) StartSpinner(_ string) func() {
 	return func() {}
 }
 
+func (p *plainPrinter) StartProgress(_ string, _ int) (func(), func()) {
+	return func() {}, func() {}
+}
+
 // printDryRunDiff renders dry-run output in git-style unified diff format:
 // each changed field is shown as a - (old) and + (new) line.
 func (p *plainPrinter) printDryRunDiff(w func(string, ...any), t SyncDryRunResult) {
@@ -173,6 +680,57 @@ func (p *plainPrinter) printDryRunDiff(w func(string, ...any), t SyncDryRunResul
 		t.Added, t.Removed, t.Modified, modSuffix)
 }
 
+// dedupeActionText describes what happened (or would happen) to a duplicate.
+func dedupeActionText(a DedupeAction, dryRun bool) string {
+	switch {
+	case a == DedupeActionKeep:
+		return "kept"
+	case dryRun:
+		return "would " + string(a)
+	case a == DedupeActionAdopt:
+		return "adopted"
+	default:
+		return "removed"
+	}
+}
+
+// configValueText renders a config value on one line; lists are
+// comma-separated like on the command line and maps are shown as JSON.
+func configValueText(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = fmt.Sprint(e)
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(t, ",")
+	case map[string]any:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// diffMarker returns the +/-/~ marker for a change type.
+func diffMarker(changeType string) string {
+	switch changeType {
+	case "added":
+		return "+"
+	case "removed":
+		return "-"
+	default:
+		return "~"
+	}
+}
+
 // modifiedBreakdown counts individual change categories across all modified
 // accesses and returns them as sorted "N reason" strings
 // (e.g. ["45 credentials", "1 server"]).
@@ -228,3 +786,206 @@ func modifiedBreakdown(accesses []AccessDiff) []string {
 	}
 	return parts
 }
+
+// syncAgeText describes how long ago a sync happened.
+func syncAgeText(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "1 day ago"
+	}
+	return fmt.Sprintf("%d days ago", days)
+}
+
+// certStatusText describes a certificate's status with the remaining days.
+func certStatusText(c CertInfo) string {
+	switch c.Status {
+	case CertStatusExpired:
+		if c.DaysLeft == 0 {
+			return "expired today"
+		}
+		return fmt.Sprintf("expired %d day(s) ago", -c.DaysLeft)
+	case CertStatusExpiring:
+		return fmt.Sprintf("expires in %d day(s)", c.DaysLeft)
+	case CertStatusInvalid:
+		return "invalid: " + c.Error
+	}
+	return fmt.Sprintf("ok (%d days left)", c.DaysLeft)
+}
+
+// upper returns the strings in upper case, for table headers.
+// ageText formats the age of t like kubectl, or "<unknown>" for a zero t.
+func ageText(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
+
+// writeClusterTable writes clusters as the table of the list command.
+func writeClusterTable(out io.Writer, clusters []ClusterListItem, details bool) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !details {
+		_, _ = fmt.Fprintf(tw, "NAME\tREADY\tAGE\n")
+		for _, c := range clusters {
+			_, _ = fmt.Fprintf(tw, "%s\t%t\t%s\n", c.Name, c.Ready, ageText(c.Created))
+		}
+		return tw.Flush()
+	}
+	_, _ = fmt.Fprintf(tw, "NAME\tREADY\tAGE\tVERSION\tACCESS\tDELETION\n")
+	for _, c := range clusters {
+		_, _ = fmt.Fprintf(tw, "%s\t%t\t%s\t%s\t%s\t%s\n", c.Name, c.Ready, ageText(c.Created),
+			cmp.Or(c.KubernetesVersion, "-"), cmp.Or(c.AccessMode, "-"), deletionText(c, time.Now()))
+	}
+	return tw.Flush()
+}
+
+// writeMembershipTable writes one row per role held on a cluster.
+func writeMembershipTable(out io.Writer, grants []MembershipRole) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "CLUSTER\tROLE\tVIA\tNAMESPACES\n")
+	for _, r := range grants {
+		role, via, namespaces := r.Role, "user", "(cluster-wide)"
+		if !r.Applied {
+			role += " (not applied)"
+		}
+		if r.Team != "" {
+			via = "team " + r.Team
+		}
+		if len(r.Namespaces) > 0 {
+			namespaces = strings.Join(r.Namespaces, ", ")
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Cluster, role, via, namespaces)
+	}
+	return tw.Flush()
+}
+
+// writeRBACBindingTable writes one row per TeamRoleBinding.
+func writeRBACBindingTable(out io.Writer, bindings []RBACBinding) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "BINDING\tSUBJECTS\tROLE\tNAMESPACES\tSTATUS\n")
+	for _, b := range bindings {
+		var subjects []string
+		if b.Team != "" {
+			team := "team " + b.Team
+			if b.IDPGroup != "" {
+				team += " (" + b.IDPGroup + ")"
+			}
+			subjects = append(subjects, team)
+		}
+		for _, u := range b.Usernames {
+			subjects = append(subjects, "user "+u)
+		}
+		namespaces := "(cluster-wide)"
+		if len(b.Namespaces) > 0 {
+			namespaces = strings.Join(b.Namespaces, ", ")
+		}
+		status := b.Status
+		if b.Message != "" {
+			status += ": " + b.Message
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b.Name, cmp.Or(strings.Join(subjects, ", "), "-"), b.Role, namespaces, status)
+	}
+	return tw.Flush()
+}
+
+// writeRBACObjectTable writes one row per RBAC object verified on the
+// cluster.
+func writeRBACObjectTable(out io.Writer, bindings []RBACBinding) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "BINDING\tKIND\tNAME\tSTATUS\n")
+	for _, b := range bindings {
+		for _, o := range b.Objects {
+			name := o.Name
+			if o.Namespace != "" {
+				name = o.Namespace + "/" + name
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", b.Name, o.Kind, name, rbacObjectStatus(o))
+		}
+	}
+	return tw.Flush()
+}
+
+// rbacObjectStatus describes whether o was found.
+func rbacObjectStatus(o RBACObject) string {
+	switch {
+	case o.Error != "":
+		return "unknown: " + o.Error
+	case o.Exists:
+		return "present"
+	default:
+		return "missing"
+	}
+}
+
+// hasClusterDetails reports whether any of clusters carries details of its
+// Greenhouse Cluster, which then get columns of their own.
+func hasClusterDetails(clusters []ClusterListItem) bool {
+	return slices.ContainsFunc(clusters, func(c ClusterListItem) bool {
+		return c.AccessMode != "" || c.KubernetesVersion != "" || c.DeletionScheduled
+	})
+}
+
+// deletionText describes when c is going to be deleted, as of now.
+func deletionText(c ClusterListItem, now time.Time) string {
+	switch {
+	case !c.DeletionScheduled:
+		return "-"
+	case c.DeletionSchedule.IsZero():
+		return "pending"
+	case !now.Before(c.DeletionSchedule):
+		return "due"
+	default:
+		return "in " + duration.HumanDuration(c.DeletionSchedule.Sub(now))
+	}
+}
+
+// clusterGroupTitle heads the section of group g of label key.
+func clusterGroupTitle(key string, g ClusterGroup) string {
+	return fmt.Sprintf("%s=%s (%s)", key, cmp.Or(g.Value, "<none>"), clusterCountText(g.Clusters, g.Ready))
+}
+
+// clusterCountText counts clusters and how many of them are ready.
+func clusterCountText(clusters, ready int) string {
+	noun := "clusters"
+	if clusters == 1 {
+		noun = "cluster"
+	}
+	return fmt.Sprintf("%d %s, %d ready", clusters, noun, ready)
+}
+
+// clusterEventText describes the readiness of a cluster after e.
+func clusterEventText(e ClusterEvent) string {
+	text := "not ready"
+	switch {
+	case e.BecameReady:
+		text = "became ready"
+	case e.BecameNotReady:
+		text = "became not ready"
+	case e.Ready:
+		text = "ready"
+	}
+	if e.Reason != "" && !e.Ready {
+		text += ": " + e.Reason
+	}
+	return text
+}
+
+func upper(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = strings.ToUpper(s)
+	}
+	return out
+}
+
+// msText formats milliseconds with one decimal.
+func msText(ms float64) string {
+	return fmt.Sprintf("%.1fms", ms)
+}
+
+// mibText formats a byte count in MiB with one decimal.
+func mibText(b uint64) string {
+	return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+}
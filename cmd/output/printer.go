@@ -16,6 +16,11 @@ type Printer interface {
 	// StartSpinner starts a spinner with the given label and returns a stop function.
 	// Calling stop() halts the spinner. In non-interactive printers this is a no-op.
 	StartSpinner(label string) (stop func())
+	// StartProgress starts a spinner that shows label followed by a done/total
+	// count. Calling step() counts one item as done and is safe for concurrent
+	// use; stop() halts the spinner. In non-interactive printers both are
+	// no-ops.
+	StartProgress(label string, total int) (step func(), stop func())
 }
 
 // New returns the appropriate Printer for the given format and TTY state.
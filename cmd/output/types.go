@@ -3,6 +3,13 @@
 
 package output
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
 // ClusterSyncStatus represents the sync outcome for a single cluster.
 type ClusterSyncStatus string
 
@@ -14,10 +21,11 @@ const (
 
 // ClusterSyncResult holds per-cluster sync information.
 type ClusterSyncResult struct {
-	Name    string            `json:"name"             yaml:"name"`
-	Context string            `json:"context"          yaml:"context"`
-	Status  ClusterSyncStatus `json:"status"           yaml:"status"`
-	Reason  string            `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Name     string            `json:"name"               yaml:"name"`
+	Context  string            `json:"context"            yaml:"context"`
+	Status   ClusterSyncStatus `json:"status"             yaml:"status"`
+	Reason   string            `json:"reason,omitempty"   yaml:"reason,omitempty"`
+	Warnings []string          `json:"warnings,omitempty" yaml:"warnings,omitempty"`
 }
 
 // SyncResult is the top-level output of the sync command.
@@ -26,12 +34,770 @@ type SyncResult struct {
 	Synced   int                 `json:"synced"   yaml:"synced"`
 	Skipped  int                 `json:"skipped"  yaml:"skipped"`
 	Failed   int                 `json:"failed"   yaml:"failed"`
+	// Unchanged is set when the merge changed nothing and the kubeconfig was
+	// not rewritten.
+	Unchanged bool `json:"unchanged,omitempty" yaml:"unchanged,omitempty"`
+	// SharedUsers lists the user entries shared by several contexts, when
+	// requested with --show-shared-users.
+	SharedUsers []SharedUser `json:"sharedUsers,omitempty" yaml:"sharedUsers,omitempty"`
+}
+
+// SharedUser is a user entry that --merge-identical-users shares between
+// contexts, so one login covers all of them, with the settings their
+// server-side users have in common.
+type SharedUser struct {
+	User      string          `json:"user"                yaml:"user"`
+	Contexts  []string        `json:"contexts"            yaml:"contexts"`
+	Auth      ClusterInfoAuth `json:"auth"                yaml:"auth"`
+	Connector string          `json:"connector,omitempty" yaml:"connector,omitempty"`
+	Scopes    []string        `json:"scopes,omitempty"    yaml:"scopes,omitempty"`
+}
+
+// Settings describes the shared settings in one line, e.g. "oidc, issuer
+// https://idp, client ID cli, connector ldap".
+func (u SharedUser) Settings() string {
+	parts := []string{u.Auth.Mechanism}
+	add := func(label, value string) {
+		if value != "" {
+			parts = append(parts, label+" "+value)
+		}
+	}
+	add("issuer", u.Auth.Issuer)
+	add("client ID", u.Auth.ClientID)
+	add("connector", u.Connector)
+	add("scopes", strings.Join(u.Scopes, ","))
+	add("subject", u.Auth.Subject)
+	add("impersonating", u.Auth.Impersonate)
+	return strings.Join(parts, ", ")
 }
 
 // ClusterVersionResult is the output of the cluster-version command.
 type ClusterVersionResult struct {
+	Context string        `json:"context"         yaml:"context"`
+	Version string        `json:"version"         yaml:"version"`
+	Nodes   []NodeVersion `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+}
+
+// ClusterInfoResult is the output of the cluster-info command: how a context
+// connects to its cluster and authenticates, and which sync wrote it.
+type ClusterInfoResult struct {
+	Context               string            `json:"context"                         yaml:"context"`
+	Cluster               string            `json:"cluster"                         yaml:"cluster"`
+	User                  string            `json:"user"                            yaml:"user"`
+	Namespace             string            `json:"namespace,omitempty"             yaml:"namespace,omitempty"`
+	Server                string            `json:"server"                          yaml:"server"`
+	TLSServerName         string            `json:"tlsServerName,omitempty"         yaml:"tlsServerName,omitempty"`
+	ProxyURL              string            `json:"proxyURL,omitempty"              yaml:"proxyURL,omitempty"`
+	InsecureSkipTLSVerify bool              `json:"insecureSkipTLSVerify,omitempty" yaml:"insecureSkipTLSVerify,omitempty"`
+	CA                    *ClusterInfoCA    `json:"ca,omitempty"                    yaml:"ca,omitempty"`
+	Auth                  ClusterInfoAuth   `json:"auth"                            yaml:"auth"`
+	Managed               bool              `json:"managed"                         yaml:"managed"`
+	Labels                map[string]string `json:"labels,omitempty"                yaml:"labels,omitempty"`
+	Sync                  *ClusterInfoSync  `json:"sync,omitempty"                  yaml:"sync,omitempty"`
+}
+
+// Fields returns the labelled lines of the text output, in order, leaving out
+// settings that are not configured.
+func (r ClusterInfoResult) Fields() [][2]string {
+	fields := [][2]string{{"Context", r.Context}, {"Cluster", r.Cluster}, {"User", r.User}}
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{label, value})
+		}
+	}
+	add("Namespace", r.Namespace)
+	add("Server", r.Server)
+	add("TLS server name", r.TLSServerName)
+	add("Proxy URL", r.ProxyURL)
+	if r.InsecureSkipTLSVerify {
+		add("TLS verification", "disabled")
+	}
+	switch {
+	case r.CA == nil:
+		add("CA", "system trust store")
+	case r.CA.Error != "":
+		add("CA", fmt.Sprintf("invalid (%s): %s", r.CA.Source, r.CA.Error))
+	default:
+		add("CA", fmt.Sprintf("%s (%s)", r.CA.Subject, r.CA.Source))
+		if r.CA.Issuer != r.CA.Subject {
+			add("CA issuer", r.CA.Issuer)
+		}
+		add("CA expires", r.CA.NotAfter.UTC().Format("2006-01-02 15:04 UTC"))
+		add("CA fingerprint", "SHA256 "+r.CA.Fingerprint)
+	}
+	auth := r.Auth.Mechanism
+	if r.Auth.Command != "" {
+		auth += " (" + r.Auth.Command + ")"
+	}
+	add("Auth", auth)
+	add("OIDC issuer", r.Auth.Issuer)
+	add("OIDC client ID", r.Auth.ClientID)
+	add("Auth subject", r.Auth.Subject)
+	add("Impersonate", r.Auth.Impersonate)
+	switch {
+	case !r.Managed:
+		add("Managed", "no")
+	case r.Sync == nil:
+		add("Managed", "yes, no sync recorded")
+	default:
+		add("Managed", fmt.Sprintf("yes, by sync of %s from %s (prefix %s)", r.Sync.Namespace, r.Sync.Endpoint, r.Sync.Prefix))
+		add("Last sync", r.Sync.LastSync.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	if len(r.Labels) > 0 {
+		labels := make([]string, 0, len(r.Labels))
+		for k, v := range r.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		slices.Sort(labels)
+		add("Labels", strings.Join(labels, ", "))
+	}
+	return fields
+}
+
+// ClusterInfoCA describes the cluster CA certificate. Source is "embedded" or
+// the CA file; Error is set when the certificate cannot be parsed.
+type ClusterInfoCA struct {
+	Source      string    `json:"source"                yaml:"source"`
+	Subject     string    `json:"subject,omitempty"     yaml:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"      yaml:"issuer,omitempty"`
+	NotAfter    time.Time `json:"notAfter,omitzero"     yaml:"notAfter,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+	Error       string    `json:"error,omitempty"       yaml:"error,omitempty"`
+}
+
+// ClusterInfoAuth describes how a user entry authenticates. Mechanism is one
+// of oidc, exec, "auth-provider <name>", cert, token, basic or none.
+type ClusterInfoAuth struct {
+	Mechanism   string `json:"mechanism"             yaml:"mechanism"`
+	Command     string `json:"command,omitempty"     yaml:"command,omitempty"`
+	Issuer      string `json:"issuer,omitempty"      yaml:"issuer,omitempty"`
+	ClientID    string `json:"clientID,omitempty"    yaml:"clientID,omitempty"`
+	Subject     string `json:"subject,omitempty"     yaml:"subject,omitempty"`
+	Impersonate string `json:"impersonate,omitempty" yaml:"impersonate,omitempty"`
+}
+
+// ClusterInfoSync is the recorded sync that wrote a managed context.
+type ClusterInfoSync struct {
+	Endpoint  string    `json:"endpoint"  yaml:"endpoint"`
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Prefix    string    `json:"prefix"    yaml:"prefix"`
+	LastSync  time.Time `json:"lastSync"  yaml:"lastSync"`
+}
+
+// FingerprintResult is the output of the fingerprint command. Combined hashes
+// the fingerprints of all contexts and is set when there are several.
+type FingerprintResult struct {
+	Kubeconfig string             `json:"kubeconfig"         yaml:"kubeconfig"`
+	Contexts   []FingerprintEntry `json:"contexts"           yaml:"contexts"`
+	Combined   string             `json:"combined,omitempty" yaml:"combined,omitempty"`
+}
+
+// FingerprintEntry is the fingerprint of one context; Error is set instead
+// when it references a missing entry or an unreadable certificate.
+type FingerprintEntry struct {
+	Context     string `json:"context"               yaml:"context"`
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+	Error       string `json:"error,omitempty"       yaml:"error,omitempty"`
+}
+
+// ClusterVersionsResult is the output of cluster-version --all-contexts.
+type ClusterVersionsResult struct {
+	Clusters []ClusterVersionEntry `json:"clusters" yaml:"clusters"`
+}
+
+// ClusterVersionEntry is the server version of one context. Cached is set when
+// the version was taken from the cache; Error when the probe failed.
+type ClusterVersionEntry struct {
+	Context string `json:"context"           yaml:"context"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Cached  bool   `json:"cached"            yaml:"cached"`
+	Error   string `json:"error,omitempty"   yaml:"error,omitempty"`
+}
+
+// NodeVersion is the kubelet version of a node and its skew to the control plane.
+type NodeVersion struct {
+	Name           string `json:"name"           yaml:"name"`
+	KubeletVersion string `json:"kubeletVersion" yaml:"kubeletVersion"`
+	// MinorSkew is how many minor versions the kubelet is behind the control
+	// plane; negative when it is ahead.
+	MinorSkew int  `json:"minorSkew" yaml:"minorSkew"`
+	Supported bool `json:"supported" yaml:"supported"`
+}
+
+// SkewDescription describes the node's version skew in words, or returns ""
+// when the kubelet matches the control plane.
+func (n NodeVersion) SkewDescription() string {
+	var d string
+	switch {
+	case n.MinorSkew == 1:
+		d = "1 minor version behind"
+	case n.MinorSkew > 1:
+		d = fmt.Sprintf("%d minor versions behind", n.MinorSkew)
+	case n.MinorSkew < 0:
+		d = fmt.Sprintf("%d minor version(s) ahead", -n.MinorSkew)
+	}
+	if !n.Supported {
+		if d == "" {
+			return "unsupported version skew"
+		}
+		d += " (unsupported skew)"
+	}
+	return d
+}
+
+// APICheckResult is the output of the api-check command: for each cluster,
+// whether it serves each of the queried APIs.
+type APICheckResult struct {
+	APIs     []string          `json:"apis"     yaml:"apis"`
+	Clusters []APICheckCluster `json:"clusters" yaml:"clusters"`
+}
+
+// APICheckCluster holds the api-check outcome for one context. Error is set
+// when the cluster could not be checked; Available is empty then.
+type APICheckCluster struct {
+	Context   string          `json:"context"             yaml:"context"`
+	Available map[string]bool `json:"available,omitempty" yaml:"available,omitempty"`
+	Error     string          `json:"error,omitempty"     yaml:"error,omitempty"`
+}
+
+// FleetApplyResult is the output of the fleet apply command: for each
+// cluster, the outcome of applying every object of the manifest.
+type FleetApplyResult struct {
+	DryRun   bool                `json:"dryRun"   yaml:"dryRun"`
+	Clusters []FleetApplyCluster `json:"clusters" yaml:"clusters"`
+}
+
+// FleetApplyCluster holds the fleet apply outcome for one context. Error is
+// set when the cluster could not be reached; Objects is empty then.
+type FleetApplyCluster struct {
+	Context string             `json:"context"           yaml:"context"`
+	Objects []FleetApplyObject `json:"objects,omitempty" yaml:"objects,omitempty"`
+	Error   string             `json:"error,omitempty"   yaml:"error,omitempty"`
+}
+
+// Failed returns the number of objects that could not be applied.
+func (c FleetApplyCluster) Failed() int {
+	n := 0
+	for _, o := range c.Objects {
+		if o.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// FleetApplyObject is the outcome of applying one object to one cluster.
+type FleetApplyObject struct {
+	Kind      string `json:"kind"                yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name"                yaml:"name"`
+	Error     string `json:"error,omitempty"     yaml:"error,omitempty"`
+}
+
+// Ref returns Kind namespace/name, or Kind name for cluster-scoped objects.
+func (o FleetApplyObject) Ref() string {
+	if o.Namespace == "" {
+		return o.Kind + " " + o.Name
+	}
+	return o.Kind + " " + o.Namespace + "/" + o.Name
+}
+
+// FleetGetResult is the output of the fleet get command: the rows of every
+// queried cluster, with the columns the API servers render for the resource.
+type FleetGetResult struct {
+	Resource string          `json:"resource"         yaml:"resource"`
+	Columns  []string        `json:"columns"          yaml:"columns"`
+	Rows     []FleetGetRow   `json:"rows"             yaml:"rows"`
+	Failed   []FleetGetError `json:"failed,omitempty" yaml:"failed,omitempty"`
+}
+
+// FleetGetRow is one object of one cluster. Cells line up with Columns.
+type FleetGetRow struct {
+	Cluster   string   `json:"cluster"             yaml:"cluster"`
+	Namespace string   `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Cells     []string `json:"cells"               yaml:"cells"`
+}
+
+// FleetGetError is a cluster fleet get could not query.
+type FleetGetError struct {
+	Cluster string `json:"cluster" yaml:"cluster"`
+	Error   string `json:"error"   yaml:"error"`
+}
+
+// Namespaced reports whether any row has a namespace.
+func (r FleetGetResult) Namespaced() bool {
+	for _, row := range r.Rows {
+		if row.Namespace != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// PingResult is the output of the ping command: round-trip statistics per
+// cluster, sorted by average, nearest first.
+type PingResult struct {
+	Endpoint string        `json:"endpoint" yaml:"endpoint"`
+	Count    int           `json:"count"    yaml:"count"`
+	Clusters []PingCluster `json:"clusters" yaml:"clusters"`
+}
+
+// PingCluster holds the round-trip times of one context in milliseconds.
+// TCP and TLS are the average connect and handshake times. Error is the
+// last failure; the times are zero when no request succeeded.
+type PingCluster struct {
+	Context  string  `json:"context"         yaml:"context"`
+	Server   string  `json:"server"          yaml:"server"`
+	Received int     `json:"received"        yaml:"received"`
+	MinMs    float64 `json:"minMs"           yaml:"minMs"`
+	AvgMs    float64 `json:"avgMs"           yaml:"avgMs"`
+	P50Ms    float64 `json:"p50Ms"           yaml:"p50Ms"`
+	P95Ms    float64 `json:"p95Ms"           yaml:"p95Ms"`
+	MaxMs    float64 `json:"maxMs"           yaml:"maxMs"`
+	TCPMs    float64 `json:"tcpMs"           yaml:"tcpMs"`
+	TLSMs    float64 `json:"tlsMs"           yaml:"tlsMs"`
+	Error    string  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// SyncVerifyResult is the report of sync --verify: whether the API servers
+// of the contexts sync added or changed answered Endpoint.
+type SyncVerifyResult struct {
+	Endpoint string              `json:"endpoint" yaml:"endpoint"`
+	Contexts []SyncVerifyContext `json:"contexts" yaml:"contexts"`
+}
+
+// SyncVerifyContext is the probe of one context. Any HTTP answer counts as
+// reachable; Error is set otherwise.
+type SyncVerifyContext struct {
+	Context   string  `json:"context"             yaml:"context"`
+	Server    string  `json:"server"              yaml:"server"`
+	Reachable bool    `json:"reachable"           yaml:"reachable"`
+	LatencyMs float64 `json:"latencyMs,omitempty" yaml:"latencyMs,omitempty"`
+	Error     string  `json:"error,omitempty"     yaml:"error,omitempty"`
+}
+
+// SyncBenchmarkResult is the timing and memory report of sync --benchmark.
+type SyncBenchmarkResult struct {
+	Clusters       int              `json:"clusters"       yaml:"clusters"`
+	TotalMs        float64          `json:"totalMs"        yaml:"totalMs"`
+	Phases         []BenchmarkPhase `json:"phases"         yaml:"phases"`
+	AllocatedBytes uint64           `json:"allocatedBytes" yaml:"allocatedBytes"`
+	Allocations    uint64           `json:"allocations"    yaml:"allocations"`
+	HeapBytes      uint64           `json:"heapBytes"      yaml:"heapBytes"`
+	GCCycles       uint32           `json:"gcCycles"       yaml:"gcCycles"`
+}
+
+// BenchmarkPhase is the time spent in one phase of a sync.
+type BenchmarkPhase struct {
+	Name string  `json:"name" yaml:"name"`
+	Ms   float64 `json:"ms"   yaml:"ms"`
+}
+
+// LintSeverity ranks kubeconfig lint findings.
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+	LintSeverityInfo    LintSeverity = "info"
+)
+
+// LintFinding is a single problem reported by kubeconfig lint.
+type LintFinding struct {
+	Severity LintSeverity `json:"severity" yaml:"severity"`
+	Check    string       `json:"check"    yaml:"check"`
+	Kind     string       `json:"kind"     yaml:"kind"`
+	Name     string       `json:"name"     yaml:"name"`
+	Message  string       `json:"message"  yaml:"message"`
+}
+
+// LintResult is the output of the kubeconfig lint command.
+type LintResult struct {
+	Kubeconfig string        `json:"kubeconfig" yaml:"kubeconfig"`
+	Findings   []LintFinding `json:"findings"   yaml:"findings"`
+}
+
+// Count returns the number of findings with the given severity.
+func (r LintResult) Count(severity LintSeverity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// CertStatus classifies a certificate's expiry.
+type CertStatus string
+
+const (
+	CertStatusOK       CertStatus = "ok"
+	CertStatusExpiring CertStatus = "expiring"
+	CertStatusExpired  CertStatus = "expired"
+	CertStatusInvalid  CertStatus = "invalid"
+)
+
+// CertInfo describes one certificate found in the kubeconfig.
+type CertInfo struct {
+	Kind     string     `json:"kind"              yaml:"kind"`
+	Name     string     `json:"name"              yaml:"name"`
+	Type     string     `json:"type"              yaml:"type"`
+	Subject  string     `json:"subject,omitempty" yaml:"subject,omitempty"`
+	NotAfter time.Time  `json:"notAfter,omitzero" yaml:"notAfter,omitempty"`
+	DaysLeft int        `json:"daysLeft"          yaml:"daysLeft"`
+	Status   CertStatus `json:"status"            yaml:"status"`
+	Error    string     `json:"error,omitempty"   yaml:"error,omitempty"`
+}
+
+// CertsResult is the output of the certs command.
+type CertsResult struct {
+	WarnDays     int        `json:"warnDays"     yaml:"warnDays"`
+	Certificates []CertInfo `json:"certificates" yaml:"certificates"`
+}
+
+// CompareResult is the output of the compare command.
+type CompareResult struct {
+	FileA     string      `json:"fileA"     yaml:"fileA"`
+	FileB     string      `json:"fileB"     yaml:"fileB"`
+	Clusters  []DiffEntry `json:"clusters"  yaml:"clusters"`
+	Contexts  []DiffEntry `json:"contexts"  yaml:"contexts"`
+	AuthInfos []DiffEntry `json:"authInfos" yaml:"authInfos"`
+}
+
+// Changes returns the total number of differing entries.
+func (r CompareResult) Changes() int {
+	return len(r.Clusters) + len(r.Contexts) + len(r.AuthInfos)
+}
+
+// DedupeAction is how a duplicate kubeconfig entry is resolved.
+type DedupeAction string
+
+const (
+	DedupeActionRemove DedupeAction = "remove"
+	DedupeActionAdopt  DedupeAction = "adopt"
+	DedupeActionKeep   DedupeAction = "keep"
+)
+
+// DuplicateEntry is an unmanaged cluster pointing at the same server as a
+// managed one.
+type DuplicateEntry struct {
+	Cluster        string       `json:"cluster"            yaml:"cluster"`
+	Server         string       `json:"server"             yaml:"server"`
+	ManagedCluster string       `json:"managedCluster"     yaml:"managedCluster"`
+	Contexts       []string     `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+	Action         DedupeAction `json:"action"             yaml:"action"`
+}
+
+// DedupeResult is the output of the dedupe command.
+type DedupeResult struct {
+	Kubeconfig string           `json:"kubeconfig" yaml:"kubeconfig"`
+	DryRun     bool             `json:"dryRun"     yaml:"dryRun"`
+	Duplicates []DuplicateEntry `json:"duplicates" yaml:"duplicates"`
+}
+
+// ConfigResult is the output of the config get command. With Key set it
+// holds that key's effective value, otherwise the settings of the config file.
+type ConfigResult struct {
+	File     string         `json:"file,omitempty"     yaml:"file,omitempty"`
+	Key      string         `json:"key,omitempty"      yaml:"key,omitempty"`
+	Value    any            `json:"value,omitempty"    yaml:"value,omitempty"`
+	Settings map[string]any `json:"settings,omitempty" yaml:"settings,omitempty"`
+}
+
+// ConfigUpdateResult is the output of the config set and unset commands.
+type ConfigUpdateResult struct {
+	File   string `json:"file"            yaml:"file"`
+	Key    string `json:"key"             yaml:"key"`
+	Value  any    `json:"value,omitempty" yaml:"value,omitempty"`
+	Action string `json:"action"          yaml:"action"`
+}
+
+// InitResult is the output of the init command: the profile written to the
+// config file.
+type InitResult struct {
+	File       string `json:"file"       yaml:"file"`
+	Kubeconfig string `json:"kubeconfig" yaml:"kubeconfig"`
+	Context    string `json:"context"    yaml:"context"`
+	Namespace  string `json:"namespace"  yaml:"namespace"`
+	Prefix     string `json:"prefix"     yaml:"prefix"`
+}
+
+// UseResult is the output of the use command.
+type UseResult struct {
+	Context  string `json:"context"            yaml:"context"`
+	Previous string `json:"previous,omitempty" yaml:"previous,omitempty"`
+}
+
+// OrgUseResult is the output of the org use command.
+type OrgUseResult struct {
+	File      string `json:"file"               yaml:"file"`
+	Namespace string `json:"namespace"          yaml:"namespace"`
+	Previous  string `json:"previous,omitempty" yaml:"previous,omitempty"`
+}
+
+// NamespaceResult is the output of the ns command.
+type NamespaceResult struct {
+	Context   string `json:"context"            yaml:"context"`
+	Namespace string `json:"namespace"          yaml:"namespace"`
+	Previous  string `json:"previous,omitempty" yaml:"previous,omitempty"`
+}
+
+// ProxyResult is printed by the proxy command once it accepts connections.
+type ProxyResult struct {
 	Context string `json:"context" yaml:"context"`
-	Version string `json:"version" yaml:"version"`
+	URL     string `json:"url"     yaml:"url"`
+}
+
+// SyncStatus is the last recorded sync of one Greenhouse organization.
+type SyncStatus struct {
+	Endpoint  string    `json:"endpoint"  yaml:"endpoint"`
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Prefix    string    `json:"prefix"    yaml:"prefix"`
+	LastSync  time.Time `json:"lastSync"  yaml:"lastSync"`
+	AgeDays   int       `json:"ageDays"   yaml:"ageDays"`
+	Stale     bool      `json:"stale"     yaml:"stale"`
+	Clusters  int       `json:"clusters"  yaml:"clusters"`
+	Ready     int       `json:"ready"     yaml:"ready"`
+	// Groups counts the clusters of the sync in the local kubeconfig per
+	// value of the status --group-by label.
+	Groups []ClusterGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// StatusResult is the output of the status command.
+type StatusResult struct {
+	Kubeconfig string       `json:"kubeconfig"        yaml:"kubeconfig"`
+	GroupBy    string       `json:"groupBy,omitempty" yaml:"groupBy,omitempty"`
+	Syncs      []SyncStatus `json:"syncs"             yaml:"syncs"`
+}
+
+// LoginResult is the output of the login command.
+type LoginResult struct {
+	Context  string    `json:"context"         yaml:"context"`
+	Issuer   string    `json:"issuer"          yaml:"issuer"`
+	Expiry   time.Time `json:"expiry,omitzero" yaml:"expiry,omitempty"`
+	StoredIn string    `json:"storedIn"        yaml:"storedIn"`
+}
+
+// SecretsMigrateStatus is the outcome for one user entry of secrets migrate.
+type SecretsMigrateStatus string
+
+const (
+	SecretsMigrateMigrated SecretsMigrateStatus = "migrated"
+	SecretsMigrateNoToken  SecretsMigrateStatus = "no-token"
+	SecretsMigrateSkipped  SecretsMigrateStatus = "skipped"
+)
+
+// SecretsMigrateEntry describes the migration of one managed user entry.
+type SecretsMigrateEntry struct {
+	User   string               `json:"user"             yaml:"user"`
+	From   string               `json:"from"             yaml:"from"`
+	Status SecretsMigrateStatus `json:"status"           yaml:"status"`
+	Reason string               `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// SecretsMigrateResult is the output of the secrets migrate command.
+type SecretsMigrateResult struct {
+	Kubeconfig string                `json:"kubeconfig" yaml:"kubeconfig"`
+	To         string                `json:"to"         yaml:"to"`
+	DryRun     bool                  `json:"dryRun"     yaml:"dryRun"`
+	Entries    []SecretsMigrateEntry `json:"entries"    yaml:"entries"`
+}
+
+// BackupEntry describes one kubeconfig backup.
+type BackupEntry struct {
+	Name string    `json:"name" yaml:"name"`
+	Time time.Time `json:"time" yaml:"time"`
+	Size int64     `json:"size" yaml:"size"`
+}
+
+// BackupsPruneResult is the output of the backups prune command.
+type BackupsPruneResult struct {
+	Directory string        `json:"directory" yaml:"directory"`
+	DryRun    bool          `json:"dryRun"    yaml:"dryRun"`
+	Removed   []BackupEntry `json:"removed"   yaml:"removed"`
+	Kept      int           `json:"kept"      yaml:"kept"`
+}
+
+// ArchivedContext describes a context in the archive.
+type ArchivedContext struct {
+	Context    string    `json:"context"             yaml:"context"`
+	Cluster    string    `json:"cluster"             yaml:"cluster"`
+	Server     string    `json:"server,omitempty"    yaml:"server,omitempty"`
+	Namespace  string    `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ArchivedAt time.Time `json:"archivedAt,omitzero" yaml:"archivedAt,omitempty"`
+}
+
+// ArchiveListResult is the output of the archive list command.
+type ArchiveListResult struct {
+	Archive  string            `json:"archive"  yaml:"archive"`
+	Contexts []ArchivedContext `json:"contexts" yaml:"contexts"`
+}
+
+// ArchiveRestoreResult is the output of the archive restore command.
+type ArchiveRestoreResult struct {
+	Kubeconfig string   `json:"kubeconfig" yaml:"kubeconfig"`
+	Restored   []string `json:"restored"   yaml:"restored"`
+}
+
+// StateCompactKind is the kind of file or record removed by state compact.
+type StateCompactKind string
+
+const (
+	StateCompactSyncRecord StateCompactKind = "sync-record"
+	StateCompactToken      StateCompactKind = "token"
+	StateCompactEnvFile    StateCompactKind = "env-file"
+)
+
+// StateCompactEntry describes one removed record or file.
+type StateCompactEntry struct {
+	Kind   StateCompactKind `json:"kind"   yaml:"kind"`
+	Name   string           `json:"name"   yaml:"name"`
+	Reason string           `json:"reason" yaml:"reason"`
+}
+
+// StateCompactResult is the output of the state compact command.
+type StateCompactResult struct {
+	DryRun  bool                `json:"dryRun"  yaml:"dryRun"`
+	Removed []StateCompactEntry `json:"removed" yaml:"removed"`
+}
+
+// ClusterListItem is one ClusterKubeconfig of the list command.
+type ClusterListItem struct {
+	Name    string            `json:"name"             yaml:"name"`
+	Ready   bool              `json:"ready"            yaml:"ready"`
+	Reason  string            `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Created time.Time         `json:"created,omitzero" yaml:"created,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// AccessMode, KubernetesVersion and the deletion schedule come from the
+	// Greenhouse Cluster owning the ClusterKubeconfig, when it can be read.
+	AccessMode        string `json:"accessMode,omitempty"        yaml:"accessMode,omitempty"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty" yaml:"kubernetesVersion,omitempty"`
+	// DeletionScheduled is set when the cluster is marked for deletion, at
+	// DeletionSchedule if Greenhouse has scheduled it already.
+	DeletionScheduled bool      `json:"deletionScheduled,omitempty" yaml:"deletionScheduled,omitempty"`
+	DeletionSchedule  time.Time `json:"deletionSchedule,omitzero"   yaml:"deletionSchedule,omitempty"`
+}
+
+// ClusterListResult is the output of the list command.
+type ClusterListResult struct {
+	Namespace string            `json:"namespace"         yaml:"namespace"`
+	GroupBy   string            `json:"groupBy,omitempty" yaml:"groupBy,omitempty"`
+	Clusters  []ClusterListItem `json:"clusters"          yaml:"clusters"`
+	// Groups holds the subtotals per value of GroupBy. Clusters are sorted
+	// by group, in the order of Groups.
+	Groups []ClusterGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// ClusterGroup counts the clusters that share a value of a label. An empty
+// Value groups the clusters without the label.
+type ClusterGroup struct {
+	Value    string `json:"value"           yaml:"value"`
+	Clusters int    `json:"clusters"        yaml:"clusters"`
+	Ready    int    `json:"ready,omitempty" yaml:"ready,omitempty"`
+}
+
+// ClusterEventType is the kind of change a ClusterEvent reports.
+type ClusterEventType string
+
+const (
+	ClusterEventAdded    ClusterEventType = "added"
+	ClusterEventModified ClusterEventType = "modified"
+	ClusterEventDeleted  ClusterEventType = "deleted"
+)
+
+// ClusterEvent is a change to a ClusterKubeconfig streamed by list --watch.
+type ClusterEvent struct {
+	Time  time.Time        `json:"time"  yaml:"time"`
+	Type  ClusterEventType `json:"type"  yaml:"type"`
+	Name  string           `json:"name"  yaml:"name"`
+	Ready bool             `json:"ready" yaml:"ready"`
+	// BecameReady and BecameNotReady are set when the event changed the
+	// readiness of an existing cluster.
+	BecameReady    bool   `json:"becameReady,omitempty"    yaml:"becameReady,omitempty"`
+	BecameNotReady bool   `json:"becameNotReady,omitempty" yaml:"becameNotReady,omitempty"`
+	Reason         string `json:"reason,omitempty"         yaml:"reason,omitempty"`
+}
+
+// CustomColumnsResult is a table selected with -o custom-columns. Cells line
+// up with Columns.
+type CustomColumnsResult struct {
+	Columns []string   `json:"columns" yaml:"columns"`
+	Rows    [][]string `json:"rows"    yaml:"rows"`
+}
+
+// LabelValue is a value of a label key and how many clusters carry it.
+type LabelValue struct {
+	Value    string `json:"value"    yaml:"value"`
+	Clusters int    `json:"clusters" yaml:"clusters"`
+}
+
+// LabelKey is a label key used by clusters of an organization.
+type LabelKey struct {
+	Key      string       `json:"key"      yaml:"key"`
+	Clusters int          `json:"clusters" yaml:"clusters"`
+	Values   []LabelValue `json:"values"   yaml:"values"`
+}
+
+// LabelsResult is the output of the labels command.
+type LabelsResult struct {
+	Namespace string     `json:"namespace" yaml:"namespace"`
+	Clusters  int        `json:"clusters"  yaml:"clusters"`
+	Keys      []LabelKey `json:"keys"      yaml:"keys"`
+}
+
+// MembershipsResult is the output of the memberships command.
+type MembershipsResult struct {
+	Namespace string           `json:"namespace" yaml:"namespace"`
+	User      string           `json:"user"      yaml:"user"`
+	Teams     []string         `json:"teams"     yaml:"teams"`
+	Grants    []MembershipRole `json:"grants"    yaml:"grants"`
+}
+
+// MembershipRole is a TeamRole the user holds on a cluster. Team is empty
+// when the TeamRoleBinding names the user directly; no Namespaces means the
+// role is bound cluster-wide.
+type MembershipRole struct {
+	Cluster    string   `json:"cluster"              yaml:"cluster"`
+	Role       string   `json:"role"                 yaml:"role"`
+	Team       string   `json:"team,omitempty"       yaml:"team,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	Applied    bool     `json:"applied"              yaml:"applied"`
+}
+
+// RBACInspectResult is the output of the rbac inspect command.
+type RBACInspectResult struct {
+	Namespace string `json:"namespace"         yaml:"namespace"`
+	Cluster   string `json:"cluster"           yaml:"cluster"`
+	// Context is the local context the RBAC objects were verified through;
+	// it is empty when they were not verified.
+	Context  string        `json:"context,omitempty" yaml:"context,omitempty"`
+	Bindings []RBACBinding `json:"bindings"          yaml:"bindings"`
+}
+
+// RBACBinding is a TeamRoleBinding that applies to the inspected cluster.
+type RBACBinding struct {
+	Name       string   `json:"name"                 yaml:"name"`
+	Team       string   `json:"team,omitempty"       yaml:"team,omitempty"`
+	IDPGroup   string   `json:"idpGroup,omitempty"   yaml:"idpGroup,omitempty"`
+	Usernames  []string `json:"usernames,omitempty"  yaml:"usernames,omitempty"`
+	Role       string   `json:"role"                 yaml:"role"`
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	// Status is applied, failed, or pending when the binding selects the
+	// cluster but has not been propagated to it.
+	Status  string       `json:"status"            yaml:"status"`
+	Message string       `json:"message,omitempty" yaml:"message,omitempty"`
+	Objects []RBACObject `json:"objects,omitempty" yaml:"objects,omitempty"`
+}
+
+// RBACObject is an RBAC object Greenhouse creates on the cluster for a
+// TeamRoleBinding, and whether it was found there.
+type RBACObject struct {
+	Kind      string `json:"kind"                yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name"                yaml:"name"`
+	Exists    bool   `json:"exists"              yaml:"exists"`
+	Error     string `json:"error,omitempty"     yaml:"error,omitempty"`
 }
 
 // VersionInfo is the output of the version command.
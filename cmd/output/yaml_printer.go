@@ -31,3 +31,7 @@ func (p *yamlPrinter) PrintError(err error) {
 func (p *yamlPrinter) StartSpinner(_ string) func() {
 	return func() {}
 }
+
+func (p *yamlPrinter) StartProgress(_ string, _ int) (func(), func()) {
+	return func() {}, func() {}
+}
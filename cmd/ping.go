@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Measure the round-trip time to managed clusters",
+	Long: `Sends --count unauthenticated requests to the --endpoint of every managed
+cluster matching --selector and --context, each over a new connection, and
+prints min, average, median, 95th percentile and max round-trip times, plus
+the average TCP connect and TLS handshake times. Any HTTP answer counts,
+including 401 and 403. Clusters are sorted nearest first, which helps to
+pick a close cluster and to tell VPN or proxy trouble from slow API servers.
+
+Examples:
+  cloudctl ping
+  cloudctl ping --selector region=eu-de-1 --count 20
+  cloudctl ping --context 'prod-*' --endpoint /version -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runPing,
+}
+
+func init() {
+	pingCmd.Flags().StringP("selector", "l", "", "Only ping clusters whose labels match this label selector")
+	pingCmd.Flags().StringSliceP("context", "c", nil, "Only ping contexts matching this glob pattern, can be repeated (default: all managed contexts)")
+	pingCmd.Flags().Int("count", 5, "Requests per cluster")
+	pingCmd.Flags().String("endpoint", "/livez", "API server path to request, e.g. /livez or /version")
+	pingCmd.Flags().Int("concurrency", 10, "Number of clusters pinged in parallel")
+}
+
+// pingSample is the timing of one request.
+type pingSample struct {
+	Total, TCP, TLS time.Duration
+}
+
+// pingOnce requests url over a new connection and returns its timing. It is
+// a variable so tests need no server.
+var pingOnce = func(ctx context.Context, client *http.Client, url string) (pingSample, error) {
+	var s pingSample
+	var connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { s.TCP = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { s.TLS = time.Since(tlsStart) },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, url, nil)
+	if err != nil {
+		return s, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return s, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	s.Total = time.Since(start)
+	return s, nil
+}
+
+func runPing(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	timeout, err := requestTimeout()
+	if err != nil {
+		return err
+	}
+	timeout = cmp.Or(timeout, defaultProbeTimeout)
+	count := viper.GetInt("count")
+	if count < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", count)
+	}
+	endpoint := viper.GetString("endpoint")
+	if !strings.HasPrefix(endpoint, "/") {
+		return fmt.Errorf("--endpoint must be a path starting with /, got %q", endpoint)
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	names, err := fleetContexts(cfg, viper.GetStringSlice("context"))
+	if err != nil {
+		return err
+	}
+	if names, err = matchingContexts(cfg, names, viper.GetString("selector")); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no managed contexts match in %s", displayKubeconfig(path))
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	step, stop := printer.StartProgress("Pinging clusters", len(names))
+	result := output.PingResult{Endpoint: endpoint, Count: count, Clusters: make([]output.PingCluster, len(names))}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		result.Clusters[i] = output.PingCluster{Context: name, Error: "not pinged"}
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return err
+	}
+	defer tun.Close()
+
+	forEachContext(cmd.Context(), names, viper.GetInt("concurrency"), func(ctx context.Context, name string) {
+		result.Clusters[index[name]] = pingContext(ctx, path, name, tun, contextClusterLabels(cfg, name), timeout, endpoint, count)
+		step()
+	})
+	stop()
+
+	slices.SortStableFunc(result.Clusters, func(a, b output.PingCluster) int {
+		if (a.Received == 0) != (b.Received == 0) {
+			return cmp.Compare(b.Received, a.Received)
+		}
+		return cmp.Compare(a.AvgMs, b.AvgMs)
+	})
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	unreachable := 0
+	for _, c := range result.Clusters {
+		if c.Received == 0 {
+			unreachable++
+		}
+	}
+	if unreachable > 0 {
+		return fmt.Errorf("%d of %d cluster(s) could not be reached", unreachable, len(names))
+	}
+	return nil
+}
+
+// pingContext pings the server of one context count times, routed through
+// the tunnel for its cluster labels.
+func pingContext(ctx context.Context, path, contextName string, tun *tunnels, labels map[string]string, timeout time.Duration, endpoint string, count int) output.PingCluster {
+	result := output.PingCluster{Context: contextName}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Server = restCfg.Host
+	restCfg.Timeout = timeout
+	if err := tun.apply(ctx, restCfg, labels); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	client, err := pingClient(restCfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	url := strings.TrimRight(restCfg.Host, "/") + endpoint
+	var samples []pingSample
+	for range count {
+		if ctx.Err() != nil {
+			break
+		}
+		s, err := pingOnce(ctx, client, url)
+		if err != nil {
+			slog.Debug("ping failed", "context", contextName, "error", err)
+			result.Error = err.Error()
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return pingStats(result, samples)
+}
+
+// pingClient returns an unauthenticated client for cfg that opens a new
+// connection for every request, so each sample includes TCP and TLS setup.
+func pingClient(cfg *rest.Config) (*http.Client, error) {
+	client, err := unauthenticatedClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+	return client, nil
+}
+
+// pingStats fills the statistics of c from samples.
+func pingStats(c output.PingCluster, samples []pingSample) output.PingCluster {
+	c.Received = len(samples)
+	if len(samples) == 0 {
+		return c
+	}
+	totals := make([]time.Duration, len(samples))
+	var sum, tcp, tlsSum time.Duration
+	for i, s := range samples {
+		totals[i] = s.Total
+		sum += s.Total
+		tcp += s.TCP
+		tlsSum += s.TLS
+	}
+	slices.Sort(totals)
+	n := time.Duration(len(samples))
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	// Nearest-rank percentiles.
+	rank := func(p int) time.Duration { return totals[(p*len(totals)+99)/100-1] }
+	c.MinMs, c.MaxMs = ms(totals[0]), ms(totals[len(totals)-1])
+	c.AvgMs, c.P50Ms, c.P95Ms = ms(sum/n), ms(rank(50)), ms(rank(95))
+	c.TCPMs, c.TLSMs = ms(tcp/n), ms(tlsSum/n)
+	return c
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestPingStats(t *testing.T) {
+	g := NewWithT(t)
+	var samples []pingSample
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, pingSample{Total: time.Duration(i) * time.Millisecond, TCP: time.Millisecond, TLS: 2 * time.Millisecond})
+	}
+	c := pingStats(output.PingCluster{Context: "eu"}, samples)
+	g.Expect(c).To(Equal(output.PingCluster{
+		Context: "eu", Received: 20,
+		MinMs: 1, AvgMs: 10.5, P50Ms: 10, P95Ms: 19, MaxMs: 20, TCPMs: 1, TLSMs: 2,
+	}))
+	g.Expect(pingStats(output.PingCluster{Context: "eu"}, nil).Received).To(BeZero())
+}
+
+func TestPingOnce(t *testing.T) {
+	g := NewWithT(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client, err := pingClient(&rest.Config{Host: srv.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	g.Expect(err).NotTo(HaveOccurred())
+	s, err := pingOnce(context.Background(), client, srv.URL+"/livez")
+	g.Expect(err).NotTo(HaveOccurred(), "any HTTP answer counts")
+	g.Expect(s.Total).To(BeNumerically(">", 0))
+	g.Expect(s.TCP).To(BeNumerically(">", 0))
+	g.Expect(s.TLS).To(BeNumerically(">", 0))
+}
+
+func TestRunPing(t *testing.T) {
+	g := NewWithT(t)
+	writeTestKubeconfig(t, newManagedCfg("eu", "us", "dark"))
+	viper.Set("count", 2)
+	viper.Set("endpoint", "/livez")
+	t.Cleanup(func() {
+		viper.Set("count", nil)
+		viper.Set("endpoint", nil)
+	})
+
+	prev := pingOnce
+	pingOnce = func(_ context.Context, _ *http.Client, url string) (pingSample, error) {
+		switch url {
+		case "https://eu.example/livez":
+			return pingSample{Total: 30 * time.Millisecond, TCP: 10 * time.Millisecond, TLS: 15 * time.Millisecond}, nil
+		case "https://us.example/livez":
+			return pingSample{Total: 120 * time.Millisecond, TCP: 40 * time.Millisecond, TLS: 60 * time.Millisecond}, nil
+		}
+		return pingSample{}, errors.New("i/o timeout")
+	}
+	t.Cleanup(func() { pingOnce = prev })
+
+	var out bytes.Buffer
+	pingCmd.SetOut(&out)
+	pingCmd.SetContext(context.Background())
+	t.Cleanup(func() { pingCmd.SetOut(nil) })
+
+	err := runPing(pingCmd, nil)
+	g.Expect(err).To(MatchError("1 of 3 cluster(s) could not be reached"))
+	g.Expect(out.String()).To(Equal(
+		"CONTEXT  RECEIVED  MIN      AVG      P50      P95      MAX      TCP     TLS\n" +
+			"eu       2/2       30.0ms   30.0ms   30.0ms   30.0ms   30.0ms   10.0ms  15.0ms\n" +
+			"us       2/2       120.0ms  120.0ms  120.0ms  120.0ms  120.0ms  40.0ms  60.0ms\n" +
+			"dark     0/2       -        -        -        -        -        -       -\n" +
+			"\n" +
+			"dark: i/o timeout\n"))
+
+	viper.Set("endpoint", "livez")
+	g.Expect(runPing(pingCmd, nil)).To(MatchError(ContainSubstring("--endpoint must be a path")))
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/viper"
+)
+
+// pluginPrefix is prepended to an unknown subcommand name when searching PATH
+// for an external plugin executable (kubectl-style: `cloudctl foo` runs `cloudctl-foo`).
+const pluginPrefix = "cloudctl-"
+
+// reservedCommands are handled by cobra itself and are added to the root
+// command lazily, so rootCmd.Find does not know about them before Execute.
+var reservedCommands = []string{"help", "completion", "__complete", "__completeNoDesc"}
+
+// lookupPlugin searches PATH for the most specific plugin matching args.
+// Consecutive non-flag arguments are joined with dashes, longest match first,
+// so `cloudctl foo bar baz` prefers `cloudctl-foo-bar` over `cloudctl-foo`.
+// It returns the executable path and the arguments to pass to it.
+func lookupPlugin(args []string) (string, []string, bool) {
+	var parts []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			break
+		}
+		parts = append(parts, a)
+	}
+	for i := len(parts); i > 0; i-- {
+		name := pluginPrefix + strings.Join(parts[:i], "-")
+		if path, err := exec.LookPath(name); err == nil {
+			return path, args[i:], true
+		}
+	}
+	return "", nil, false
+}
+
+// pluginEnv returns the environment for a plugin process: the current
+// environment plus CLOUDCTL_PLUGIN_* variables describing the active
+// configuration, so plugins need not re-implement config discovery.
+func pluginEnv() []string {
+	env := os.Environ()
+	set := func(key, value string) {
+		if value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+	if self, err := os.Executable(); err == nil {
+		set("CLOUDCTL_PLUGIN_CALLER", self)
+	}
+	set("CLOUDCTL_PLUGIN_CONFIG_FILE", viper.ConfigFileUsed())
+	set("CLOUDCTL_PLUGIN_PREFIX", viper.GetString("prefix"))
+	set("CLOUDCTL_PLUGIN_NAMESPACE", viper.GetString("greenhouse-cluster-namespace"))
+	ghKubeconfig, ghContext := greenhouseKubeconfig()
+	set("CLOUDCTL_PLUGIN_GREENHOUSE_KUBECONFIG", displayKubeconfig(ghKubeconfig))
+	set("CLOUDCTL_PLUGIN_GREENHOUSE_CONTEXT", ghContext)
+	set("CLOUDCTL_PLUGIN_KUBECONFIG", displayKubeconfig(localKubeconfig()))
+	set("CLOUDCTL_PLUGIN_CONTEXT", viper.GetString("context"))
+	return env
+}
+
+// runPlugin dispatches args to an external plugin when the first argument is
+// not a built-in command and a matching cloudctl-<name> executable exists on
+// PATH. It reports whether a plugin was found; when it was, the returned error
+// is the plugin's failure (on Unix a successful exec never returns).
+func runPlugin(args []string) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false, nil
+	}
+	if slices.Contains(reservedCommands, args[0]) {
+		return false, nil
+	}
+	if _, _, err := rootCmd.Find(args); err == nil {
+		return false, nil
+	}
+	path, pluginArgs, ok := lookupPlugin(args)
+	if !ok {
+		return false, nil
+	}
+
+	if err := setupConfig(); err != nil {
+		return true, err
+	}
+	if err := setupLogger(); err != nil {
+		return true, err
+	}
+	slog.Debug("running plugin", "path", path, "args", pluginArgs)
+
+	env := pluginEnv()
+	if runtime.GOOS != "windows" {
+		// Replace the current process so signals and exit codes pass through untouched.
+		return true, syscall.Exec(path, append([]string{path}, pluginArgs...), env) // #nosec G204 — user-installed plugin on PATH
+	}
+
+	c := exec.Command(path, pluginArgs...) // #nosec G204 — user-installed plugin on PATH
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = env
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return true, fmt.Errorf("failed to run plugin %q: %w", path, err)
+	}
+	os.Exit(0)
+	return true, nil
+}
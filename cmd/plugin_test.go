@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	. "github.com/onsi/gomega"
+)
+
+func writePlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLookupPlugin_LongestMatchWins(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	writePlugin(t, dir, "cloudctl-foo")
+	want := writePlugin(t, dir, "cloudctl-foo-bar")
+	t.Setenv("PATH", dir)
+
+	path, args, ok := lookupPlugin([]string{"foo", "bar", "baz", "--flag"})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(path).To(Equal(want))
+	g.Expect(args).To(Equal([]string{"baz", "--flag"}))
+}
+
+func TestLookupPlugin_StopsAtFlags(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	want := writePlugin(t, dir, "cloudctl-foo")
+	writePlugin(t, dir, "cloudctl-foo-x")
+	t.Setenv("PATH", dir)
+
+	path, args, ok := lookupPlugin([]string{"foo", "--name", "x"})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(path).To(Equal(want))
+	g.Expect(args).To(Equal([]string{"--name", "x"}))
+}
+
+func TestLookupPlugin_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("PATH", t.TempDir())
+
+	_, _, ok := lookupPlugin([]string{"nope"})
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestRunPlugin_BuiltinCommandNotDispatched(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	writePlugin(t, dir, "cloudctl-version")
+	t.Setenv("PATH", dir)
+
+	handled, err := runPlugin([]string{"version"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(handled).To(BeFalse())
+}
+
+func TestPluginEnv_DescribesConfiguration(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { viper.Reset() })
+	viper.Set("prefix", "myorg")
+	viper.Set("greenhouse-cluster-namespace", "org-a")
+
+	env := pluginEnv()
+	g.Expect(env).To(ContainElement("CLOUDCTL_PLUGIN_PREFIX=myorg"))
+	g.Expect(env).To(ContainElement("CLOUDCTL_PLUGIN_NAMESPACE=org-a"))
+	g.Expect(slices.ContainsFunc(env, func(s string) bool {
+		return strings.HasPrefix(s, "CLOUDCTL_PLUGIN_KUBECONFIG=")
+	})).To(BeTrue())
+}
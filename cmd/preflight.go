@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// PermissionError reports a failed RBAC preflight with enough context to fix
+// it: the missing permission, the authorizer's reason, and the bindings (and
+// Greenhouse teams) that would grant it, when those could be determined.
+type PermissionError struct {
+	Attrs  greenhouse.ResourceAttributes
+	User   string
+	Reason string
+	Grants []greenhouse.Grant
+}
+
+func (e *PermissionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "missing permission: cannot %s", e.Attrs)
+	if e.User != "" {
+		fmt.Fprintf(&b, " as user %q", e.User)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", e.Reason)
+	}
+	if len(e.Grants) > 0 {
+		b.WriteString("\nAccess is granted by:")
+		for _, g := range e.Grants {
+			b.WriteString("\n  - " + g.String())
+		}
+		b.WriteString("\nAsk a Greenhouse organization admin to add you to one of these teams or groups.")
+	} else {
+		fmt.Fprintf(&b, "\nAsk a Greenhouse organization admin of %q to add you to a team of the organization, "+
+			"or check that --greenhouse-cluster-namespace is correct.", e.Attrs.Namespace)
+	}
+	return b.String()
+}
+
+// preflightAccess checks that the current user may perform attrs before any
+// real request is made, turning a bare Forbidden into an actionable error.
+// If the access review itself cannot be performed the check is skipped and
+// the real request reports whatever error the API server returns.
+func preflightAccess(ctx context.Context, ghClient greenhouse.Client, attrs greenhouse.ResourceAttributes) error {
+	allowed, reason, err := ghClient.CanI(ctx, attrs)
+	if err != nil {
+		slog.Debug("skipping RBAC preflight", "error", err)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	permErr := &PermissionError{Attrs: attrs, Reason: reason}
+	if user, err := ghClient.WhoAmI(ctx); err == nil {
+		permErr.User = user
+	}
+	grants, err := ghClient.FindGrants(ctx, attrs)
+	if err != nil {
+		slog.Debug("could not determine which roles grant access", "error", err)
+	}
+	permErr.Grants = grants
+	return permErr
+}
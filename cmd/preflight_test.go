@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPreflightAccess_Allowed(t *testing.T) {
+	g := NewWithT(t)
+	c := &stubGreenhouseClient{allowed: true}
+	g.Expect(preflightAccess(context.Background(), c, greenhouse.ClusterKubeconfigAccess("org", "list"))).To(Succeed())
+}
+
+func TestPreflightAccess_DeniedListsGrants(t *testing.T) {
+	g := NewWithT(t)
+	c := &stubGreenhouseClient{
+		username: "jane@example.com",
+		grants: []greenhouse.Grant{
+			{RoleKind: "Role", RoleName: "role:org:viewer", SubjectKind: "Group", SubjectName: "ORG", Team: "org-members"},
+		},
+	}
+
+	err := preflightAccess(context.Background(), c, greenhouse.ClusterKubeconfigAccess("org", "list"))
+	var permErr *PermissionError
+	g.Expect(errors.As(err, &permErr)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring(`cannot list clusterkubeconfigs.greenhouse.sap in namespace "org" as user "jane@example.com"`))
+	g.Expect(err.Error()).To(ContainSubstring(`(team "org-members")`))
+}
+
+func TestPreflightAccess_DeniedWithoutGrants(t *testing.T) {
+	g := NewWithT(t)
+	err := preflightAccess(context.Background(), &stubGreenhouseClient{}, greenhouse.ClusterKubeconfigAccess("org", "get"))
+	g.Expect(err).To(MatchError(ContainSubstring("--greenhouse-cluster-namespace is correct")))
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy [cluster]",
+	Short: "Serve the API of a managed cluster on a local port",
+	Long: `Starts a local HTTP proxy to the API server of a managed context, so
+browser-based tools and plain HTTP clients can reach the cluster. Requests
+are forwarded with the credentials of the context, including exec plugins
+such as kubelogin. The proxy runs until Ctrl+C.
+
+The argument is matched against managed contexts like cloudctl use; without
+it a finder is shown. A free port on 127.0.0.1 is chosen unless --port is
+given. Only requests for localhost are accepted, which protects the proxy
+against DNS rebinding from web pages; binding a non-loopback --address
+disables that check.
+
+Examples:
+  cloudctl proxy prod-eu
+  cloudctl proxy prod-eu --port 8001`,
+	Args:              cobra.MaximumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runProxy,
+}
+
+// proxyLocalHosts are the loopback names. While listening on one of them,
+// only requests for these hosts are accepted.
+var proxyLocalHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+func init() {
+	proxyCmd.Flags().String("address", "127.0.0.1", "Address to listen on")
+	proxyCmd.Flags().Int("port", 0, "Port to listen on (0 picks a free port)")
+}
+
+func runProxy(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	var query string
+	if len(args) == 1 {
+		query = args[0]
+	}
+	contextName, err := selectManagedContext(cfg, query)
+	if err != nil {
+		return err
+	}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig for context %q: %w", contextName, err)
+	}
+	address := viper.GetString("address")
+	handler, err := newAPIProxy(restCfg, slices.Contains(proxyLocalHosts, address))
+	if err != nil {
+		return fmt.Errorf("failed to set up proxy for context %q: %w", contextName, err)
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(viper.GetInt("port"))))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+	slog.Info("proxying API server", "context", contextName, "server", restCfg.Host, "address", ln.Addr().String())
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(output.ProxyResult{Context: contextName, URL: "http://" + ln.Addr().String()}); err != nil {
+		_ = srv.Close()
+		return err
+	}
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("proxy stopped: %w", err)
+	case <-cmd.Context().Done():
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newAPIProxy returns a handler forwarding requests to the API server of cfg
+// with its credentials. With localOnly, requests whose Host is not a loopback
+// name are rejected.
+func newAPIProxy(cfg *rest.Config, localOnly bool) (http.Handler, error) {
+	target, _, err := rest.DefaultServerUrlFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := rest.TransportFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = target.Host
+		},
+		Transport: transport,
+		// Flush immediately so watches and log streams are not buffered.
+		FlushInterval: -1,
+	}
+	if !localOnly {
+		return proxy, nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if !slices.Contains(proxyLocalHosts, host) {
+			http.Error(w, "Forbidden: only localhost requests are accepted", http.StatusForbidden)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}), nil
+}
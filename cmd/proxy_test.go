@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewAPIProxy(t *testing.T) {
+	g := NewWithT(t)
+	var gotAuth, gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.Path
+		_, _ = io.WriteString(w, `{"kind":"NamespaceList"}`)
+	}))
+	t.Cleanup(backend.Close)
+
+	handler, err := newAPIProxy(&rest.Config{Host: backend.URL, BearerToken: "secret"}, true)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8001/api/v1/namespaces", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring("NamespaceList"))
+	g.Expect(gotAuth).To(Equal("Bearer secret"))
+	g.Expect(gotPath).To(Equal("/api/v1/namespaces"))
+
+	req = httptest.NewRequest(http.MethodGet, "http://attacker.example:8001/api/v1/secrets", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	g.Expect(rec.Code).To(Equal(http.StatusForbidden))
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/api"
+	"github.com/cloudoperators/greenhouse/api/v1alpha2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+var rbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Inspect the access Greenhouse grants on clusters",
+}
+
+var rbacInspectCmd = &cobra.Command{
+	Use:   "inspect <cluster>",
+	Short: "List the TeamRoleBindings applying to a cluster",
+	Long: `Lists the Greenhouse TeamRoleBindings that apply to a cluster, those
+propagated to it and those whose cluster selector matches it, with their
+team and its IdP group, directly bound users, TeamRole, namespaces, and
+whether Greenhouse applied them.
+
+With --verify, the ClusterRoles, ClusterRoleBindings and RoleBindings
+Greenhouse creates for the bindings are looked up on the cluster itself,
+through its managed context in the local kubeconfig. The command fails when
+any of them is missing. This needs read access to RBAC objects on the
+cluster.
+
+Examples:
+  cloudctl rbac inspect prod-eu -n my-org
+  cloudctl rbac inspect prod-eu --verify`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemoteClusterNames,
+	PreRunE:           bindCommandFlags,
+	RunE:              runRBACInspect,
+}
+
+func init() {
+	rbacInspectCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
+	_ = rbacInspectCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	rbacInspectCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	rbacInspectCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	rbacInspectCmd.Flags().Bool("verify", false, "Check that the resulting RBAC objects exist on the cluster, through its managed context")
+	rbacCmd.AddCommand(rbacInspectCmd)
+}
+
+// inspectClusterBindings describes the TeamRoleBindings of namespace that
+// apply to cluster.
+func inspectClusterBindings(ctx context.Context, ghClient greenhouse.Client, namespace, cluster string) (output.RBACInspectResult, []v1alpha2.TeamRoleBinding, error) {
+	result := output.RBACInspectResult{Namespace: namespace, Cluster: cluster, Bindings: []output.RBACBinding{}}
+	ckc, err := ghClient.GetClusterKubeconfig(ctx, namespace, cluster)
+	if err != nil {
+		return result, nil, err
+	}
+	teams, err := ghClient.ListTeams(ctx, namespace)
+	if err != nil {
+		return result, nil, err
+	}
+	bindings, err := ghClient.ListTeamRoleBindings(ctx, namespace)
+	if err != nil {
+		return result, nil, err
+	}
+	idpGroups := make(map[string]string, len(teams))
+	for _, team := range teams {
+		idpGroups[team.Name] = team.Spec.MappedIDPGroup
+	}
+
+	// ClusterKubeconfigs carry the labels of their Cluster.
+	matched := greenhouse.ClusterBindings(cluster, ckc.Labels, bindings)
+	for _, trb := range matched {
+		b := output.RBACBinding{
+			Name:       trb.Name,
+			Team:       trb.Spec.TeamRef,
+			IDPGroup:   idpGroups[trb.Spec.TeamRef],
+			Usernames:  trb.Spec.Usernames,
+			Role:       trb.Spec.TeamRoleRef,
+			Namespaces: trb.Spec.Namespaces,
+			Status:     "pending",
+		}
+		for _, ps := range trb.Status.PropagationStatus {
+			if ps.ClusterName != cluster {
+				continue
+			}
+			b.Status = "applied"
+			if !ps.IsTrue() {
+				b.Status, b.Message = "failed", cmp.Or(ps.Message, string(ps.Reason))
+			}
+		}
+		result.Bindings = append(result.Bindings, b)
+	}
+	return result, matched, nil
+}
+
+// verifyRBACObjects looks up the RBAC objects Greenhouse creates on a cluster
+// for trb: the ClusterRole of its TeamRole, and a ClusterRoleBinding, or a
+// RoleBinding per namespace when it is bound to namespaces.
+func verifyRBACObjects(ctx context.Context, cs kubernetes.Interface, trb v1alpha2.TeamRoleBinding) []output.RBACObject {
+	check := func(kind, namespace, name string, get func() error) output.RBACObject {
+		o := output.RBACObject{Kind: kind, Namespace: namespace, Name: name}
+		switch err := get(); {
+		case err == nil:
+			o.Exists = true
+		case !apierrors.IsNotFound(err):
+			o.Error = err.Error()
+		}
+		return o
+	}
+	rbac := cs.RbacV1()
+	roleName := greenhouseapis.RBACPrefix + trb.Spec.TeamRoleRef
+	bindingName := trb.GetRBACName()
+	objects := []output.RBACObject{check("ClusterRole", "", roleName, func() error {
+		_, err := rbac.ClusterRoles().Get(ctx, roleName, metav1.GetOptions{})
+		return err
+	})}
+	if len(trb.Spec.Namespaces) == 0 {
+		return append(objects, check("ClusterRoleBinding", "", bindingName, func() error {
+			_, err := rbac.ClusterRoleBindings().Get(ctx, bindingName, metav1.GetOptions{})
+			return err
+		}))
+	}
+	for _, ns := range trb.Spec.Namespaces {
+		objects = append(objects, check("RoleBinding", ns, bindingName, func() error {
+			_, err := rbac.RoleBindings(ns).Get(ctx, bindingName, metav1.GetOptions{})
+			return err
+		}))
+	}
+	return objects
+}
+
+func runRBACInspect(cmd *cobra.Command, args []string) error {
+	cluster := args[0]
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		if namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return err
+		}
+	}
+	result, bindings, err := inspectClusterBindings(ctx, ghClient, namespace, cluster)
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	if viper.GetBool("verify") && len(bindings) > 0 {
+		if result.Context, err = verifyClusterBindings(ctx, cluster, bindings, result.Bindings); err != nil {
+			return err
+		}
+		for _, b := range result.Bindings {
+			for _, o := range b.Objects {
+				if !o.Exists && o.Error == "" {
+					missing++
+				}
+			}
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d RBAC object(s) missing on %s", missing, cluster)
+	}
+	return nil
+}
+
+// verifyClusterBindings fills in the Objects of described, which describe
+// bindings in the same order, from the cluster behind its managed context in
+// the local kubeconfig. It returns that context.
+func verifyClusterBindings(ctx context.Context, cluster string, bindings []v1alpha2.TeamRoleBinding, described []output.RBACBinding) (string, error) {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	local, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	contextName := managedContextFor(local, managedNameFunc(cluster))
+	if contextName == "" {
+		return "", fmt.Errorf("no managed context for cluster %s in %s; run cloudctl sync first", cluster, displayKubeconfig(path))
+	}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		return "", err
+	}
+	tun, err := newTunnels()
+	if err != nil {
+		return "", err
+	}
+	defer tun.Close()
+	if err := tun.apply(ctx, restCfg, contextClusterLabels(local, contextName)); err != nil {
+		return "", err
+	}
+	cs, err := kubernetes.NewForConfig(withTracing(restCfg))
+	if err != nil {
+		return "", err
+	}
+	for i, trb := range bindings {
+		described[i].Objects = verifyRBACObjects(ctx, cs, trb)
+	}
+	return contextName, nil
+}
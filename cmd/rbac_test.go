@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	greenhousev1alpha2 "github.com/cloudoperators/greenhouse/api/v1alpha2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestInspectClusterBindings(t *testing.T) {
+	g := NewWithT(t)
+	propagation := func(cluster string, ready bool, message string) greenhousev1alpha2.TeamRoleBindingStatus {
+		condition := greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", "")
+		if !ready {
+			condition = greenhousemetav1alpha1.FalseCondition(greenhousemetav1alpha1.ReadyCondition, "RBACReconcileFailed", message)
+		}
+		return greenhousev1alpha2.TeamRoleBindingStatus{PropagationStatus: []greenhousev1alpha2.PropagationStatus{{ClusterName: cluster, Condition: condition}}}
+	}
+	c := &stubGreenhouseClient{
+		ckcs: []greenhousev1alpha1.ClusterKubeconfig{{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu-1", Labels: map[string]string{"region": "eu"}},
+		}},
+		teams: []greenhousev1alpha1.Team{{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec:       greenhousev1alpha1.TeamSpec{MappedIDPGroup: "PLATFORM_ADMINS"},
+		}},
+		bindings: []greenhousev1alpha2.TeamRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform-admin"},
+				Spec:       greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "platform", TeamRoleRef: "cluster-admin"},
+				Status:     propagation("eu-1", true, ""),
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform-viewer"},
+				Spec:       greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "platform", TeamRoleRef: "viewer", Namespaces: []string{"kube-system"}},
+				Status:     propagation("eu-1", false, "namespace kube-system not found"),
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "eu-oncall"},
+				Spec: greenhousev1alpha2.TeamRoleBindingSpec{
+					Usernames:       []string{"jane@example.com"},
+					TeamRoleRef:     "viewer",
+					ClusterSelector: greenhousev1alpha2.ClusterSelector{LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "us-admin"},
+				Spec:       greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "platform", TeamRoleRef: "cluster-admin"},
+				Status:     propagation("us-1", true, ""),
+			},
+		},
+	}
+
+	result, bindings, err := inspectClusterBindings(context.Background(), c, "org", "eu-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(bindings).To(HaveLen(3))
+	g.Expect(result.Bindings).To(Equal([]output.RBACBinding{
+		{Name: "platform-admin", Team: "platform", IDPGroup: "PLATFORM_ADMINS", Role: "cluster-admin", Status: "applied"},
+		{Name: "platform-viewer", Team: "platform", IDPGroup: "PLATFORM_ADMINS", Role: "viewer", Namespaces: []string{"kube-system"}, Status: "failed", Message: "namespace kube-system not found"},
+		{Name: "eu-oncall", Usernames: []string{"jane@example.com"}, Role: "viewer", Status: "pending"},
+	}))
+
+	_, _, err = inspectClusterBindings(context.Background(), c, "org", "missing")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestVerifyRBACObjects(t *testing.T) {
+	g := NewWithT(t)
+	cs := fake.NewClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "greenhouse:viewer"}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "greenhouse:platform-viewer", Namespace: "kube-system"}},
+	)
+
+	objects := verifyRBACObjects(context.Background(), cs, greenhousev1alpha2.TeamRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform-viewer"},
+		Spec:       greenhousev1alpha2.TeamRoleBindingSpec{TeamRoleRef: "viewer", Namespaces: []string{"kube-system", "monitoring"}},
+	})
+	g.Expect(objects).To(Equal([]output.RBACObject{
+		{Kind: "ClusterRole", Name: "greenhouse:viewer", Exists: true},
+		{Kind: "RoleBinding", Namespace: "kube-system", Name: "greenhouse:platform-viewer", Exists: true},
+		{Kind: "RoleBinding", Namespace: "monitoring", Name: "greenhouse:platform-viewer"},
+	}))
+
+	objects = verifyRBACObjects(context.Background(), cs, greenhousev1alpha2.TeamRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform-admin"},
+		Spec:       greenhousev1alpha2.TeamRoleBindingSpec{TeamRoleRef: "cluster-admin"},
+	})
+	g.Expect(objects).To(Equal([]output.RBACObject{
+		{Kind: "ClusterRole", Name: "greenhouse:cluster-admin"},
+		{Kind: "ClusterRoleBinding", Name: "greenhouse:platform-admin"},
+	}))
+}
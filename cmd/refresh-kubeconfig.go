@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+var refreshKubeconfigCmd = &cobra.Command{
+	Use:   "refresh-kubeconfig <cluster>",
+	Short: "Have Greenhouse render a cluster's kubeconfig again and sync it",
+	Long: `Asks the Greenhouse controller to render the ClusterKubeconfig of a
+cluster again, waits until it is ready and then syncs that cluster into the
+local kubeconfig, like sync --remote-cluster-name. Use it when the served
+credentials are stale, e.g. after the cluster's CA or the organization's OIDC
+settings were rotated.
+
+The controller is triggered by setting the
+cloudctl.greenhouse.sap/refresh-requested-at annotation on the
+ClusterKubeconfig, which needs permission to patch ClusterKubeconfigs. The
+sync flags, such as --prefix, are taken from the config file.
+
+Examples:
+  cloudctl refresh-kubeconfig prod-eu -n my-org
+  cloudctl refresh-kubeconfig prod-eu --wait-ready-timeout 2m`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeRemoteClusterNames,
+	PreRunE:           bindCommandFlags,
+	RunE:              runRefreshKubeconfig,
+}
+
+func init() {
+	refreshKubeconfigCmd.Flags().StringP("greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
+	_ = refreshKubeconfigCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
+	refreshKubeconfigCmd.Flags().String("greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	refreshKubeconfigCmd.Flags().String("greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	refreshKubeconfigCmd.Flags().Duration("wait-ready-timeout", 10*time.Minute, "How long to wait for the rendered ClusterKubeconfig to become ready")
+}
+
+// refreshClusterKubeconfig asks Greenhouse to render the ClusterKubeconfig
+// name again and waits until it is ready. The controller is given one
+// readyPollInterval to pick up the request first, so the old ready state is
+// not mistaken for the new one. It reports whether the spec changed.
+func refreshClusterKubeconfig(ctx context.Context, ghClient greenhouse.Client, namespace, name string, timeout time.Duration) (*v1alpha1.ClusterKubeconfig, bool, error) {
+	refresher, ok := ghClient.(greenhouse.Refresher)
+	if !ok {
+		return nil, false, errors.New("refreshing ClusterKubeconfigs is not supported by this Greenhouse client")
+	}
+	requested, err := refresher.RequestRefresh(ctx, namespace, name, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	slog.Info("Requested a new kubeconfig from Greenhouse", "cluster", name)
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case <-time.After(readyPollInterval):
+	}
+	ckc, err := waitForClusterKubeconfig(ctx, ghClient, namespace, name, timeout, greenhouse.IsReady)
+	if err != nil {
+		return nil, false, err
+	}
+	return ckc, ckc.Generation != requested.Generation, nil
+}
+
+func runRefreshKubeconfig(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	format, err := output.ParseFormat(OutputFormat())
+	if err != nil {
+		return err
+	}
+	timeout := viper.GetDuration("wait-ready-timeout")
+	if timeout <= 0 {
+		return fmt.Errorf("--wait-ready-timeout must be positive, got %s", timeout)
+	}
+	cfg, err := greenhouseFlagsConfig()
+	if err != nil {
+		return err
+	}
+	ghClient, err := newGreenhouseClient(withTracing(cfg))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	namespace := viper.GetString("greenhouse-cluster-namespace")
+	if namespace == "" {
+		if namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return err
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	stop := output.New(format, output.IsTTYWriter(w), w).StartSpinner(fmt.Sprintf("Waiting for Greenhouse to render %s...", name))
+	_, changed, err := refreshClusterKubeconfig(ctx, ghClient, namespace, name, timeout)
+	stop()
+	if err != nil {
+		return err
+	}
+	if changed {
+		slog.Info("Greenhouse rendered a new kubeconfig", "cluster", name)
+	} else {
+		slog.Info("Greenhouse rendered the kubeconfig without changes", "cluster", name)
+	}
+
+	viper.Set("greenhouse-cluster-namespace", namespace)
+	viper.Set("remote-cluster-name", name)
+	return runSync(cmd, nil)
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+// refreshingClient is a sequenceClient that records refresh requests.
+type refreshingClient struct {
+	*sequenceClient
+	requested []string
+}
+
+func (r *refreshingClient) RequestRefresh(_ context.Context, _, name string, _ time.Time) (*v1alpha1.ClusterKubeconfig, error) {
+	r.requested = append(r.requested, name)
+	ckc := readyCKC(name, "1", true, "")
+	ckc.Generation = 1
+	return ckc, nil
+}
+
+func TestRefreshClusterKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	useFastReadyPoll(t)
+	generation := func(gen int64, ready bool) func() (*v1alpha1.ClusterKubeconfig, error) {
+		return func() (*v1alpha1.ClusterKubeconfig, error) {
+			ckc := readyCKC("eu-1", "2", ready, "rendering")
+			ckc.Generation = gen
+			return ckc, nil
+		}
+	}
+
+	c := &refreshingClient{sequenceClient: &sequenceClient{responses: []func() (*v1alpha1.ClusterKubeconfig, error){
+		generation(2, false), generation(2, true),
+	}}}
+	ckc, changed, err := refreshClusterKubeconfig(context.Background(), c, "org", "eu-1", time.Minute)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.requested).To(Equal([]string{"eu-1"}))
+	g.Expect(ckc.Generation).To(Equal(int64(2)))
+	g.Expect(changed).To(BeTrue())
+
+	c = &refreshingClient{sequenceClient: &sequenceClient{responses: []func() (*v1alpha1.ClusterKubeconfig, error){generation(1, true)}}}
+	_, changed, err = refreshClusterKubeconfig(context.Background(), c, "org", "eu-1", time.Minute)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+
+	_, _, err = refreshClusterKubeconfig(context.Background(), &sequenceClient{}, "org", "eu-1", time.Minute)
+	g.Expect(err).To(MatchError(ContainSubstring("not supported")))
+}
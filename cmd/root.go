@@ -4,18 +4,26 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
 )
 
 var rootCmd = &cobra.Command{
@@ -27,15 +35,67 @@ var rootCmd = &cobra.Command{
 in your Greenhouse organization — so kubectl just works.
 
 Commands:
+  init              Set up cloudctl interactively and run a first sync
   sync              Fetch ClusterKubeconfigs from Greenhouse and merge them locally
+  status            Show when the local kubeconfig was last synced
+  drift             Exit non-zero when the managed entries differ from Greenhouse (for CI)
+  list              List the clusters of a Greenhouse organization
+  labels            Summarize the labels used across the clusters of an organization
+  refresh-kubeconfig  Have Greenhouse render a cluster's kubeconfig again and sync it
+  memberships       Show the roles your Greenhouse teams give you on each cluster
+  org use           Switch the default Greenhouse organization, optionally resyncing
+  rbac inspect      Show the TeamRoleBindings applying to a cluster and verify their RBAC objects
+  agent             Keep a kubeconfig synced in a container, with health endpoints
+  use               Switch the current context (fuzzy-matched, interactive picker)
+  login             Obtain OIDC tokens for a context (device code flow for headless hosts)
+  get-token         kubectl credential plugin (ExecCredential) used by --auth-type=cloudctl
+  secrets migrate   Move stored OIDC tokens between the kubeconfig, token files and the OS keyring
+  ns                Switch the namespace of the current managed context
+  proxy             Serve the API of a managed cluster on a local port
+  shell             Start a subshell that can only reach one managed cluster
+  k9s               Open k9s on a managed cluster
+  serve             Serve the merged kubeconfig to local tools over a unix socket
+  env               Print shell exports that scope the current shell to one cluster
+  shims generate    Write a kubectl-<cluster> wrapper script per managed context
+  shell-init        Print shell integration (prompt segment, aliases, completion) to eval in rc files
   cluster-version   Query the Kubernetes server version of a kubeconfig context
+  cluster-info      Show the server, CA, auth and sync metadata of a kubeconfig context
+  api-check         Check which managed clusters serve given APIs or CRDs
+  ping              Measure the round-trip time to managed clusters
+  fleet apply       Apply a manifest to all managed clusters matching a selector
+  fleet get         List a resource type across managed clusters in one table
+  kubeconfig lint   Validate the local kubeconfig (dangling references, expired certificates, ...)
+  kubeconfig create-sa  Emit a short-lived service-account kubeconfig for CI
+  export argocd     Print managed clusters as ArgoCD cluster Secrets
+  export lens       Write managed clusters as kubeconfig files and a catalog for Lens
+  certs             Report expiry dates of kubeconfig certificates
+  compare           Semantically diff two kubeconfig files
+  fingerprint       Print stable hashes of contexts to compare setups without sharing kubeconfigs
+  dedupe            Clean up manually added entries that duplicate managed clusters
+  backups prune     Remove kubeconfig backups outside the retention policy
+  archive           List and restore contexts archived by sync --archive-removed
+  state compact     Prune old sync records, expired cached tokens and stale env kubeconfigs
+  config            Read and modify the cloudctl config file (set/get/unset)
   version           Print cloudctl build information
   update            Check for and install the latest cloudctl release
 
+Plugins:
+  Any executable named cloudctl-<name> on your PATH can be invoked as
+  "cloudctl <name>". Plugins receive CLOUDCTL_PLUGIN_* environment variables
+  describing the active prefix, namespace, and kubeconfig paths.
+
 Global flags available on every command:
   -o, --output text|json|yaml   Output format (default: text)
+  -k, --kubeconfig PATH         Kubeconfig to read and write (default: $KUBECONFIG or ~/.kube/config)
+  -c, --context NAME            Kubeconfig context to use (default: current context)
       --log-level debug|info|warn|error
       --log-format text|json
+      --log-file PATH           Write logs to a size-rotated file instead of stderr
+      --audit-log PATH          Append kubeconfig mutations as JSON lines
+      --timeout DURATION        Maximum time for each API request (default: no limit)
+      --proxy-url URL           Proxy for connections to Greenhouse (default: $HTTPS_PROXY)
+      --non-interactive         Never prompt, show a picker or open a browser; fail instead
+                                (implied for prompts and pickers when stdin is not a terminal)
 
 Examples:
   # Sync all clusters for an organization
@@ -50,7 +110,15 @@ Examples:
   # Print version as YAML
   cloudctl version -o yaml`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return setupLogger()
+		trace.SpanFromContext(cmd.Context()).SetName(cmd.CommandPath())
+		if _, err := requestTimeout(); err != nil {
+			return err
+		}
+		if err := setupLogger(); err != nil {
+			return err
+		}
+		logPathMigrations()
+		return nil
 	},
 }
 
@@ -58,14 +126,61 @@ var (
 	configFilePath string
 )
 
+// Exit codes of sync with --changed-exit-code. Other failures exit with 1.
+const (
+	ExitCodeChanged = 2
+	ExitCodePartial = 3
+	ExitCodeError   = 4
+)
+
+// ExitError asks the caller of Execute to exit with Code. Err, if set, is
+// reported as usual; a nil Err means the command already printed its result.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
 // Execute runs the CLI with the provided context.
+// Unknown subcommands are dispatched to cloudctl-<name> plugins found on PATH.
 func Execute(ctx context.Context) error {
-	return rootCmd.ExecuteContext(ctx)
+	if handled, err := runPlugin(os.Args[1:]); handled {
+		return err
+	}
+
+	shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Use a fresh context so spans are flushed even after cancellation.
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Debug("failed to flush traces", "error", err)
+		}
+	}()
+
+	// The root span is renamed to the invoked command path once cobra has
+	// resolved it (see PersistentPreRunE).
+	ctx, span := tracer.Start(ctx, "cloudctl")
+	err = rootCmd.ExecuteContext(ctx)
+	endSpan(span, err)
+	return err
 }
 
-// OutputFormat returns the value of the --output flag after flag parsing.
-// It is safe to call after Execute returns.
+// OutputFormat returns the value of the --output flag after flag parsing;
+// sync --ci always reports JSON. It is safe to call after Execute returns.
 func OutputFormat() string {
+	if viper.GetBool("ci") {
+		return string(output.FormatJSON)
+	}
 	return viper.GetString("output")
 }
 
@@ -76,7 +191,16 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&configFilePath, "config", "", "Path to configuration file")
 	rootCmd.PersistentFlags().String("log-level", "info", "Log verbosity: debug, info, warn, error")
 	rootCmd.PersistentFlags().String("log-format", "text", "Log format: text or json (written to stderr)")
+	rootCmd.PersistentFlags().String("log-file", "", "Write logs to this file instead of stderr")
+	rootCmd.PersistentFlags().Int("log-file-max-size", 10, "Rotate the --log-file when it would exceed this many megabytes (0 disables rotation)")
+	rootCmd.PersistentFlags().Duration("log-file-max-age", 7*24*time.Hour, "Remove rotated log files older than this (0 keeps them)")
 	rootCmd.PersistentFlags().StringP("output", "o", "text", "Output format: text, json, or yaml")
+	rootCmd.PersistentFlags().StringP("kubeconfig", "k", clientcmd.RecommendedHomeFile, "Path to kubeconfig file")
+	rootCmd.PersistentFlags().StringP("context", "c", "", "Kubeconfig context to use (defaults to the current context)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Maximum time for each API request; 0 means no limit (cluster-version and api-check default to 10s per cluster)")
+	rootCmd.PersistentFlags().String("proxy-url", "", "Proxy for connections to the Greenhouse API server (default: HTTPS_PROXY and NO_PROXY from the environment)")
+	rootCmd.PersistentFlags().String("audit-log", "", "Append every kubeconfig mutation as a JSON line to this file")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "Never prompt, show a picker, open a browser or wait for a login; fail with an error instead")
 
 	// BindPFlags can theroretically return an error if called with `nil` as an argument
 	// which should never happened after at least one flag was defined. That's why the output
@@ -84,8 +208,45 @@ func init() {
 	_ = viper.BindPFlags(rootCmd.PersistentFlags())
 
 	// Add subcommands here
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(docsCmd)
 	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(driftCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(nsCmd)
+	rootCmd.AddCommand(proxyCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(k9sCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(shellInitCmd)
+	rootCmd.AddCommand(shimsCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(getTokenCmd)
+	rootCmd.AddCommand(secretsCmd)
 	rootCmd.AddCommand(clusterVersionCmd)
+	rootCmd.AddCommand(clusterInfoCmd)
+	rootCmd.AddCommand(apiCheckCmd)
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(fleetCmd)
+	rootCmd.AddCommand(kubeconfigCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(certsCmd)
+	rootCmd.AddCommand(compareCmd)
+	rootCmd.AddCommand(fingerprintCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(labelsCmd)
+	rootCmd.AddCommand(refreshKubeconfigCmd)
+	rootCmd.AddCommand(membershipsCmd)
+	rootCmd.AddCommand(rbacCmd)
+	rootCmd.AddCommand(orgCmd)
+	rootCmd.AddCommand(dedupeCmd)
+	rootCmd.AddCommand(backupsCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(updateCmd)
 }
@@ -118,6 +279,18 @@ func displayKubeconfig(path string) string {
 	return path
 }
 
+// greenhouseKubeconfig returns the kubeconfig path and context used to reach
+// the Greenhouse cluster. --greenhouse-cluster-kubeconfig and
+// --greenhouse-cluster-context take precedence; otherwise the global
+// --kubeconfig and --context are used.
+func greenhouseKubeconfig() (path, contextName string) {
+	path = resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	if viper.IsSet("greenhouse-cluster-kubeconfig") {
+		path = viper.GetString("greenhouse-cluster-kubeconfig")
+	}
+	return path, cmp.Or(viper.GetString("greenhouse-cluster-context"), viper.GetString("context"))
+}
+
 // configWithContext builds a rest.Config for the specified context name from the given kubeconfig path.
 // When kubeconfigPath is empty, client-go's default loading rules are used (reads KUBECONFIG env var
 // and falls back to ~/.kube/config).
@@ -132,15 +305,97 @@ func configWithContext(contextName, kubeconfigPath string) (*rest.Config, error)
 		CurrentContext: contextName,
 	}
 	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
-	return cc.ClientConfig()
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if timeout, err := requestTimeout(); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	return cfg, nil
 }
 
-func setupConfig() error {
-	home, err := os.UserHomeDir()
+// runningInPod reports whether cloudctl runs in a Kubernetes pod. It is a
+// variable so tests can pretend to.
+var runningInPod = func() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != ""
+}
+
+// inClusterConfig is rest.InClusterConfig. It is a variable so tests can
+// substitute a service account.
+var inClusterConfig = rest.InClusterConfig
+
+// inClusterFallback reports whether the Greenhouse connection for contextName
+// and path may fall back to the pod's service account: cloudctl runs in a pod
+// and neither a Greenhouse kubeconfig nor a context was chosen.
+func inClusterFallback(contextName, path string) bool {
+	defaultPath, _ := greenhouseKubeconfig()
+	return contextName == "" && !viper.IsSet("greenhouse-cluster-kubeconfig") && path == defaultPath && runningInPod()
+}
+
+// greenhouseRestConfig builds the rest.Config of the Greenhouse connection
+// like configWithContext. When the kubeconfig is missing or has no current
+// context and inClusterFallback allows it, the in-cluster config is used, so
+// cloudctl can run as a Job that renders kubeconfigs into a volume.
+func greenhouseRestConfig(contextName, path string) (*rest.Config, error) {
+	cfg, err := configWithContext(contextName, path)
+	if err == nil || !(errors.Is(err, fs.ErrNotExist) || clientcmd.IsEmptyConfig(err)) || !inClusterFallback(contextName, path) {
+		return cfg, err
+	}
+	cfg, err = inClusterConfig()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to build in-cluster config: %w", err)
 	}
+	slog.Info("no Greenhouse kubeconfig found, using the in-cluster service account", "server", cfg.Host)
+	if timeout, err := requestTimeout(); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	return cfg, nil
+}
+
+// greenhouseFlagsConfig builds the rest.Config of the Greenhouse connection
+// from greenhouseKubeconfig and the TLS, proxy and impersonation flags, for
+// commands that talk to a single Greenhouse organization.
+func greenhouseFlagsConfig() (*rest.Config, error) {
+	path, contextName := greenhouseKubeconfig()
+	cfg, err := greenhouseRestConfig(contextName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build greenhouse kubeconfig (source: %s): %w", displayKubeconfig(path), err)
+	}
+	if err := applyGreenhouseTLS(cfg, viper.GetString("greenhouse-ca-file"), viper.GetString("tls-server-name"), viper.GetBool("insecure-skip-tls-verify")); err != nil {
+		return nil, err
+	}
+	if err := applyGreenhouseProxy(cfg, viper.GetString("proxy-url")); err != nil {
+		return nil, err
+	}
+	if err := applyImpersonation(cfg, viper.GetString("as"), viper.GetStringSlice("as-group"), viper.GetString("as-uid")); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
+// defaultProbeTimeout bounds each cluster probe of cluster-version and
+// api-check when --timeout is not set.
+const defaultProbeTimeout = 10 * time.Second
+
+// requestTimeout returns the global --timeout, which every rest.Config built
+// by configWithContext carries, or 0 when requests are not limited.
+func requestTimeout() (time.Duration, error) {
+	s := viper.GetString("timeout")
+	if s == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --timeout %q: %w", s, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("--timeout must not be negative, got %s", timeout)
+	}
+	return timeout, nil
+}
+
+func setupConfig() error {
 	// Optionally read environment variables, config files, etc.
 	viper.SetEnvPrefix("CLOUDCTL")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -149,55 +404,40 @@ func setupConfig() error {
 	viper.SetConfigType("yaml")
 
 	configFilePath = viper.GetString("config")
+	pathMigrations = append(pathMigrations, migrateLegacyPaths(configFilePath != "")...)
 	if len(configFilePath) > 0 {
-		// Phase 1
-		// First we are trying config provided as a command line parameter. Fail if there was an error
-		// during reading configuration from this specified path.
+		// A config file given as a command line parameter must be readable.
 		viper.SetConfigFile(configFilePath)
 		return viper.ReadInConfig()
-	} else {
-		// Phase 2
-		// Then we are searching for ".cloudctl.yaml" in current or home directory
-		viper.AddConfigPath(".")
-		viper.AddConfigPath(home)
-		// NOTE: viper is automatically adding a file extension basing on the value of called above `SetConfigType`
-		viper.SetConfigName(".cloudctl")
-	}
-
-	err = viper.ReadInConfig()
-	if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-		// Phase 3
-		// If reading config in above described locations failed, we are looking for configuration
-		// in these locations:
-		//   locations set in PHASE 2:
-		//     ./cloudctl.yaml
-		//     $HOME/cloudctl.yaml
-		//   if $XDG_CONFIG_HOME is set:
-		//     $XDG_CONFIG_HOME/cloudctl/cloudctl.yaml
-		//     $XDG_CONFIG_HOME/cloudctl.yaml
-		//   else:
-		//     $HOME/.config/cloudctl/cloudctl.yaml
-		//     $HOME/.config/cloudctl.yaml
-		// NOTE: viper is automatically adding a file extension basing on the value of called above `SetConfigType`
-		viper.SetConfigName("cloudctl")
-		if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); len(xdgConfig) > 0 {
-			viper.AddConfigPath(filepath.Join(xdgConfig, "cloudctl"))
-			viper.AddConfigPath(xdgConfig)
-		} else {
-			viper.AddConfigPath(filepath.Join(home, ".config", "cloudctl"))
-			viper.AddConfigPath(filepath.Join(home, ".config"))
-		}
-		err = viper.ReadInConfig()
-		// If configuration was not found in any of above listed locations - that's ok.
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			err = nil
-		}
 	}
 
-	return err
+	// Otherwise the first config file found is read, if any:
+	//   ./.cloudctl.yaml
+	//   ./cloudctl.yaml
+	//   $XDG_CONFIG_HOME/cloudctl/cloudctl.yaml (see configDir)
+	// Files in the locations of earlier versions, such as ~/.cloudctl.yaml,
+	// were moved there by migrateLegacyPaths; they are still read when that
+	// failed.
+	candidates := []string{".cloudctl.yaml", "cloudctl.yaml"}
+	if dir, err := configDir(); err == nil {
+		target := filepath.Join(dir, "cloudctl.yaml")
+		candidates = append(candidates, target)
+		candidates = append(candidates, legacyConfigFiles(target)...)
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && info.Mode().IsRegular() {
+			viper.SetConfigFile(c)
+			return viper.ReadInConfig()
+		}
+	}
+	return nil
 }
 
-// setupLogger configures slog based on --log-level and --log-format flags.
+// logFile is the --log-file in use, closed when the logger is reconfigured.
+var logFile *rotatingLogFile
+
+// setupLogger configures slog based on --log-level, --log-format and
+// --log-file flags.
 func setupLogger() error {
 	levelStr := viper.GetString("log-level")
 	format := viper.GetString("log-format")
@@ -207,15 +447,33 @@ func setupLogger() error {
 		return fmt.Errorf("invalid --log-level %q: must be one of \"debug\", \"info\", \"warn\", \"error\"", levelStr)
 	}
 
+	if format != "json" && format != "text" {
+		return fmt.Errorf("invalid --log-format %q: must be \"text\" or \"json\"", format)
+	}
+
+	if logFile != nil {
+		_ = logFile.Close()
+		logFile = nil
+	}
+	var w io.Writer = os.Stderr
+	if path := viper.GetString("log-file"); path != "" {
+		maxSize := viper.GetInt("log-file-max-size")
+		if maxSize < 0 {
+			return fmt.Errorf("--log-file-max-size must not be negative, got %d", maxSize)
+		}
+		f, err := openLogFile(path, int64(maxSize)<<20, viper.GetDuration("log-file-max-age"))
+		if err != nil {
+			return err
+		}
+		w, logFile = f, f
+	}
+
 	opts := &slog.HandlerOptions{Level: level}
 	var handler slog.Handler
-	switch format {
-	case "json":
-		handler = slog.NewJSONHandler(os.Stderr, opts)
-	case "text":
-		handler = slog.NewTextHandler(os.Stderr, opts)
-	default:
-		return fmt.Errorf("invalid --log-format %q: must be \"text\" or \"json\"", format)
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
 	}
 	slog.SetDefault(slog.New(handler))
 	return nil
@@ -7,8 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	. "github.com/onsi/gomega"
@@ -22,6 +26,9 @@ func TestNotSetConfigPath(t *testing.T) {
 	g := NewWithT(t)
 
 	t.Cleanup(func() { viper.Reset() })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
 
 	// Ensure default config file location is not set
 	config := rootCmd.PersistentFlags().Lookup("config")
@@ -152,3 +159,141 @@ func TestResolveKubeconfig_ViperIsSetFalseForUnparsedCobraFlag(t *testing.T) {
 	result := resolveKubeconfig("kubeconfig", clientcmd.RecommendedHomeFile)
 	g.Expect(result).To(BeEmpty())
 }
+
+func TestGreenhouseKubeconfig_FallsBackToGlobalFlags(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv("KUBECONFIG", "")
+	viper.Set("kubeconfig", "/local/config")
+	viper.Set("context", "greenhouse")
+	t.Cleanup(func() {
+		for _, k := range []string{"kubeconfig", "context", "greenhouse-cluster-kubeconfig", "greenhouse-cluster-context", "remote-cluster-kubeconfig"} {
+			viper.Set(k, nil)
+		}
+	})
+
+	path, ctx := greenhouseKubeconfig()
+	g.Expect(path).To(Equal("/local/config"))
+	g.Expect(ctx).To(Equal("greenhouse"))
+	g.Expect(localKubeconfig()).To(Equal("/local/config"))
+
+	viper.Set("greenhouse-cluster-kubeconfig", "/greenhouse/config")
+	viper.Set("greenhouse-cluster-context", "admin")
+	viper.Set("remote-cluster-kubeconfig", "/legacy/config")
+	path, ctx = greenhouseKubeconfig()
+	g.Expect(path).To(Equal("/greenhouse/config"))
+	g.Expect(ctx).To(Equal("admin"))
+	g.Expect(localKubeconfig()).To(Equal("/legacy/config"))
+}
+
+// parseCommandFlags parses args as the flags of cmd and resets every flag
+// they changed when the test ends.
+func parseCommandFlags(t *testing.T, cmd *cobra.Command, args ...string) error {
+	t.Cleanup(func() {
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		rootCmd.PersistentFlags().Visit(func(f *pflag.Flag) {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+	})
+	return cmd.ParseFlags(args)
+}
+
+func TestCheckRemovedSyncShorthands(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(parseCommandFlags(t, syncCmd, "-vk", "/greenhouse/config")).To(Succeed())
+	g.Expect(checkRemovedSyncShorthands(syncCmd)).To(MatchError(ContainSubstring("use --greenhouse-cluster-kubeconfig")), "grouped shorthands are caught")
+}
+
+func TestCheckRemovedSyncShorthands_ContextShorthand(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(parseCommandFlags(t, syncCmd, "-cadmin")).To(Succeed())
+	g.Expect(checkRemovedSyncShorthands(syncCmd)).To(MatchError(ContainSubstring("use --greenhouse-cluster-context")))
+}
+
+func TestCheckRemovedSyncShorthands_LongFlagsReachGlobalFlags(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(parseCommandFlags(t, syncCmd, "-n", "my-org", "--kubeconfig", "/local/config", "--context", "greenhouse")).To(Succeed())
+	g.Expect(checkRemovedSyncShorthands(syncCmd)).To(Succeed())
+	kubeconfig := rootCmd.PersistentFlags().Lookup("kubeconfig")
+	g.Expect(kubeconfig.Changed).To(BeTrue(), "viper sees the flag as set")
+	g.Expect(kubeconfig.Value.String()).To(Equal("/local/config"))
+	g.Expect(rootCmd.PersistentFlags().Lookup("context").Value.String()).To(Equal("greenhouse"))
+}
+
+func TestCheckRemovedSyncShorthands_OtherCommandsKeepShorthands(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(parseCommandFlags(t, driftCmd, "-k", "/prod/config", "-c", "greenhouse")).To(Succeed())
+	g.Expect(checkRemovedSyncShorthands(driftCmd)).To(Succeed())
+	g.Expect(driftCmd.Flags().Lookup("kubeconfig").Value.String()).To(Equal("/prod/config"))
+}
+
+func TestConfigWithContext_AppliesTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(path, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: eu
+  cluster: {server: "https://eu.example"}
+contexts:
+- name: eu
+  context: {cluster: eu}
+current-context: eu
+`), 0o600)).To(Succeed())
+	t.Cleanup(func() { viper.Set("timeout", nil) })
+
+	cfg, err := configWithContext("", path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Timeout).To(BeZero(), "requests are not limited by default")
+
+	viper.Set("timeout", "30s")
+	cfg, err = configWithContext("", path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Timeout).To(Equal(30 * time.Second))
+
+	viper.Set("timeout", "soon")
+	_, err = requestTimeout()
+	g.Expect(err).To(MatchError(ContainSubstring("invalid --timeout")))
+}
+
+func TestGreenhouseRestConfig_InClusterFallback(t *testing.T) {
+	g := NewWithT(t)
+
+	missing := filepath.Join(t.TempDir(), "config")
+	t.Setenv("KUBECONFIG", "")
+	viper.Set("kubeconfig", missing)
+	prevInPod, prevInCluster := runningInPod, inClusterConfig
+	inPod := false
+	runningInPod = func() bool { return inPod }
+	inClusterConfig = func() (*rest.Config, error) { return &rest.Config{Host: "https://10.0.0.1:443"}, nil }
+	t.Cleanup(func() {
+		runningInPod, inClusterConfig = prevInPod, prevInCluster
+		for _, k := range []string{"kubeconfig", "greenhouse-cluster-kubeconfig"} {
+			viper.Set(k, nil)
+		}
+	})
+
+	_, err := greenhouseRestConfig("", missing)
+	g.Expect(err).To(MatchError(os.ErrNotExist), "outside a pod the kubeconfig is required")
+
+	inPod = true
+	cfg, err := greenhouseRestConfig("", missing)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Host).To(Equal("https://10.0.0.1:443"))
+
+	_, err = greenhouseRestConfig("admin", missing)
+	g.Expect(err).To(HaveOccurred(), "an explicit context is not replaced")
+
+	viper.Set("greenhouse-cluster-kubeconfig", missing)
+	_, err = greenhouseRestConfig("", missing)
+	g.Expect(err).To(HaveOccurred(), "an explicit Greenhouse kubeconfig is not replaced")
+}
@@ -0,0 +1,274 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// tokenLocationKubeconfig is the --to value that keeps tokens in legacy oidc
+// auth-provider entries of the kubeconfig.
+const tokenLocationKubeconfig = "kubeconfig"
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move stored OIDC tokens between the kubeconfig, token files and the OS keyring",
+	Long: `Moves the OIDC tokens of managed users to another location and rewrites
+their kubeconfig entries to match:
+
+  kubeconfig  id-token and refresh-token in oidc auth-provider entries
+  file        "cloudctl get-token" exec entries with a token file in
+              --token-cache-dir
+  keyring     "cloudctl get-token --token-store=keyring" exec entries with
+              the token in the OS credential store (macOS keychain, or the
+              Secret Service through secret-tool on Linux)
+
+Moving tokens out of the kubeconfig keeps them from leaking when the file is
+shared or backed up. Tokens are removed from their old location only after
+the kubeconfig has been written. Users with kubelogin exec entries are
+skipped, as kubelogin keeps its own token cache.
+
+A later sync rewrites the entries in the form its --auth-type selects. To keep
+using the keyring, sync with
+--auth-type cloudctl --kubelogin-extra-args=--token-store=keyring.
+
+Examples:
+  # Move tokens out of the kubeconfig into the OS keyring
+  cloudctl secrets migrate --to keyring
+
+  # Preview moving them back
+  cloudctl secrets migrate --to kubeconfig --dry-run`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runSecretsMigrate,
+}
+
+func init() {
+	secretsMigrateCmd.Flags().String("to", "", "Where to move tokens: kubeconfig, file or keyring (required)")
+	secretsMigrateCmd.Flags().String("token-cache-dir", defaultCloudctlTokenCacheDir(), "Directory for token files of entries that do not set their own")
+	secretsMigrateCmd.Flags().Bool("dry-run", false, "Show what would be migrated without changing anything")
+	_ = secretsMigrateCmd.MarkFlagRequired("to")
+}
+
+// tokenMigration is a planned move of the token of one user entry.
+type tokenMigration struct {
+	name   string
+	params oidc.Params
+	token  *oidc.Token
+	// from is the store the token is deleted from once the kubeconfig is
+	// written, nil when it lived in the kubeconfig.
+	from oidc.Store
+	// to is the store the token is saved in, nil when it moves into the
+	// kubeconfig.
+	to oidc.Store
+}
+
+func runSecretsMigrate(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	to := viper.GetString("to")
+	cacheDir := viper.GetString("token-cache-dir")
+	dryRun := viper.GetBool("dry-run")
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	switch to {
+	case tokenLocationKubeconfig, tokenStoreFile, tokenStoreKeyring:
+	default:
+		return fmt.Errorf("invalid --to %q: must be %s, %s or %s", to, tokenLocationKubeconfig, tokenStoreFile, tokenStoreKeyring)
+	}
+
+	po := kubeconfigPathOptions(path)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	before := cfg.DeepCopy()
+
+	result := output.SecretsMigrateResult{Kubeconfig: displayKubeconfig(path), To: to, DryRun: dryRun}
+	var migrations []tokenMigration
+	for _, name := range slices.Sorted(maps.Keys(cfg.AuthInfos)) {
+		if !isManaged(name) {
+			continue
+		}
+		entry, m, err := planTokenMigration(name, cfg.AuthInfos[name], to, cacheDir)
+		if err != nil {
+			return fmt.Errorf("user %q: %w", name, err)
+		}
+		result.Entries = append(result.Entries, entry)
+		if m != nil {
+			migrations = append(migrations, *m)
+		}
+	}
+
+	if !dryRun && len(migrations) > 0 {
+		for _, m := range migrations {
+			if m.to == nil {
+				setAuthProviderTokens(cfg.AuthInfos[m.name], m.token)
+			} else if err := m.to.Save(m.params, m.token); err != nil {
+				return fmt.Errorf("user %q: failed to store token in %s: %w", m.name, to, err)
+			}
+		}
+		if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+			return fmt.Errorf("failed to update kubeconfig: %w", err)
+		}
+		slog.Info("migrated OIDC tokens", "kubeconfig", displayKubeconfig(path), "to", to, "users", len(migrations))
+		if err := auditMutations("secrets migrate", path, diffAllEntries(before, cfg)); err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if m.from == nil {
+				continue
+			}
+			if err := m.from.Delete(m.params); err != nil {
+				slog.Warn("failed to delete migrated token", "user", m.name, "error", err)
+			}
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
+
+// planTokenMigration rewrites ai in place to keep its token in to and returns
+// what to report and, for users with a token, the migration to perform.
+func planTokenMigration(name string, ai *clientcmdapi.AuthInfo, to, cacheDir string) (output.SecretsMigrateEntry, *tokenMigration, error) {
+	entry := output.SecretsMigrateEntry{User: name}
+	m := &tokenMigration{name: name}
+	switch {
+	case ai != nil && ai.AuthProvider != nil && ai.AuthProvider.Name == "oidc":
+		entry.From = tokenLocationKubeconfig
+		if c := ai.AuthProvider.Config; c["id-token"] != "" || c["refresh-token"] != "" {
+			m.token = oidc.TokenFromIDToken(c["id-token"], c["refresh-token"])
+		}
+	case isCloudctlExec(ai):
+		kind, store, err := execTokenStore(ai.Exec.Args, cacheDir)
+		if err != nil {
+			return entry, nil, err
+		}
+		entry.From = kind
+		if kind == to {
+			break
+		}
+		params, _, err := oidcParamsFromAuthInfo(ai)
+		if err != nil {
+			return entry, nil, err
+		}
+		if m.token, err = store.Load(params); err != nil {
+			return entry, nil, fmt.Errorf("failed to read token from %s: %w", kind, err)
+		}
+		m.from = store
+	case ai != nil && ai.Exec != nil:
+		entry.From = "kubelogin"
+		entry.Status, entry.Reason = output.SecretsMigrateSkipped, "kubelogin keeps its own token cache"
+		return entry, nil, nil
+	default:
+		entry.From = "-"
+		entry.Status, entry.Reason = output.SecretsMigrateSkipped, "not an OIDC user"
+		return entry, nil, nil
+	}
+	if entry.From == to {
+		entry.Status, entry.Reason = output.SecretsMigrateSkipped, "already in "+to
+		return entry, nil, nil
+	}
+	if m.token == nil {
+		entry.Status = output.SecretsMigrateNoToken
+		return entry, nil, nil
+	}
+
+	params, caData, err := oidcParamsFromAuthInfo(ai)
+	if err != nil {
+		return entry, nil, err
+	}
+	if to == tokenLocationKubeconfig {
+		ai.Exec = nil
+		ai.AuthProvider = &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: authProviderConfig(params, caData)}
+	} else {
+		rewriteTokenStore(ai, params, caData, to, cacheDir)
+		_, store, err := execTokenStore(ai.Exec.Args, cacheDir)
+		if err != nil {
+			return entry, nil, err
+		}
+		m.to = store
+	}
+	m.params = params
+	entry.Status = output.SecretsMigrateMigrated
+	return entry, m, nil
+}
+
+// rewriteTokenStore points ai at the get-token store kind, converting an oidc
+// auth-provider entry into a "cloudctl get-token" exec entry.
+func rewriteTokenStore(ai *clientcmdapi.AuthInfo, params oidc.Params, caData []byte, kind, cacheDir string) {
+	if ai.Exec == nil {
+		providerCfg := maps.Clone(ai.AuthProvider.Config)
+		delete(providerCfg, "id-token")
+		delete(providerCfg, "refresh-token")
+		var extra []string
+		if len(caData) > 0 {
+			extra = append(extra, "--certificate-authority-data="+base64.StdEncoding.EncodeToString(caData))
+		}
+		ai.AuthProvider = nil
+		ai.Exec = &clientcmdapi.ExecConfig{
+			APIVersion:      execAPIVersions["v1"],
+			Command:         cloudctlCommand(),
+			Args:            buildKubeloginArgs(providerCfg, extra, cacheDir),
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+		}
+	}
+	ai.Exec.Args = slices.DeleteFunc(ai.Exec.Args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--token-store=")
+	})
+	if kind != tokenStoreFile {
+		ai.Exec.Args = append(ai.Exec.Args, "--token-store="+kind)
+	}
+}
+
+// authProviderConfig returns the oidc auth-provider config for params, without
+// tokens.
+func authProviderConfig(params oidc.Params, caData []byte) map[string]string {
+	c := map[string]string{
+		"idp-issuer-url": params.IssuerURL,
+		"client-id":      params.ClientID,
+	}
+	if params.ClientSecret != "" {
+		c["client-secret"] = params.ClientSecret
+	}
+	if len(params.ExtraScopes) > 0 {
+		c["extra-scopes"] = strings.Join(params.ExtraScopes, ",")
+	}
+	if len(params.ExtraParams) > 0 {
+		var kv []string
+		for _, k := range slices.Sorted(maps.Keys(params.ExtraParams)) {
+			kv = append(kv, k+"="+params.ExtraParams[k])
+		}
+		c["auth-request-extra-params"] = strings.Join(kv, ",")
+	}
+	if len(caData) > 0 {
+		c["idp-certificate-authority-data"] = base64.StdEncoding.EncodeToString(caData)
+	}
+	return c
+}
+
+// setAuthProviderTokens stores tok in the oidc auth-provider entry ai.
+func setAuthProviderTokens(ai *clientcmdapi.AuthInfo, tok *oidc.Token) {
+	ai.AuthProvider.Config["id-token"] = tok.IDToken
+	if tok.RefreshToken != "" {
+		ai.AuthProvider.Config["refresh-token"] = tok.RefreshToken
+	}
+}
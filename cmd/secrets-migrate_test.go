@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunSecretsMigrate_RoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	providerConfig := map[string]string{
+		"idp-issuer-url":            "https://issuer.example.com",
+		"client-id":                 "greenhouse",
+		"extra-scopes":              "groups,email",
+		"auth-request-extra-params": "connector_id=ldap",
+	}
+	cfg := clientcmdapi.NewConfig()
+	withTokens := map[string]string{"id-token": "id", "refresh-token": "refresh"}
+	for k, v := range providerConfig {
+		withTokens[k] = v
+	}
+	cfg.AuthInfos["cloudctl:eu"] = &clientcmdapi.AuthInfo{
+		AuthProvider:   &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: withTokens},
+		ImpersonateUID: "42",
+	}
+	cfg.AuthInfos["cloudctl:us"] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{Command: "kubelogin", Args: []string{"get-token"}},
+	}
+	cfg.AuthInfos["admin"] = &clientcmdapi.AuthInfo{Token: "static"}
+	path := writeTestKubeconfig(t, cfg)
+
+	cacheDir := t.TempDir()
+	viper.Set("token-cache-dir", cacheDir)
+	viper.Set("to", "file")
+	t.Cleanup(func() {
+		for _, key := range []string{"token-cache-dir", "to", "dry-run"} {
+			viper.Set(key, nil)
+		}
+	})
+	var out bytes.Buffer
+	secretsMigrateCmd.SetOut(&out)
+	t.Cleanup(func() { secretsMigrateCmd.SetOut(nil) })
+
+	viper.Set("dry-run", true)
+	g.Expect(runSecretsMigrate(secretsMigrateCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Would migrate 1 of 2 users to file"))
+	loaded, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(loaded.AuthInfos["cloudctl:eu"].AuthProvider).NotTo(BeNil())
+
+	viper.Set("dry-run", nil)
+	out.Reset()
+	g.Expect(runSecretsMigrate(secretsMigrateCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("migrated   cloudctl:eu (from kubeconfig)"))
+	g.Expect(out.String()).To(ContainSubstring("skipped    cloudctl:us (from kubelogin): kubelogin keeps its own token cache"))
+	loaded, err = clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	eu := loaded.AuthInfos["cloudctl:eu"]
+	g.Expect(eu.AuthProvider).To(BeNil())
+	g.Expect(isCloudctlExec(eu)).To(BeTrue())
+	g.Expect(eu.ImpersonateUID).To(Equal("42"))
+	g.Expect(eu.Exec.Args).To(ContainElement("--token-cache-dir=" + filepath.Join(cacheDir, "ldap")))
+	params, _, err := oidcParamsFromAuthInfo(eu)
+	g.Expect(err).NotTo(HaveOccurred())
+	tok, err := oidc.Cache{Dir: filepath.Join(cacheDir, "ldap")}.Load(params)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).To(Equal(&oidc.Token{IDToken: "id", RefreshToken: "refresh"}))
+
+	viper.Set("to", "kubeconfig")
+	out.Reset()
+	g.Expect(runSecretsMigrate(secretsMigrateCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("migrated   cloudctl:eu (from file)"))
+	loaded, err = clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	eu = loaded.AuthInfos["cloudctl:eu"]
+	g.Expect(eu.Exec).To(BeNil())
+	g.Expect(eu.AuthProvider.Config).To(Equal(withTokens))
+	tok, err = oidc.Cache{Dir: filepath.Join(cacheDir, "ldap")}.Load(params)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).To(BeNil())
+}
+
+func TestRewriteTokenStore(t *testing.T) {
+	g := NewWithT(t)
+	ai := &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command: "cloudctl",
+		Args:    []string{"get-token", "--oidc-issuer-url=https://issuer", "--token-store=file"},
+	}}
+	rewriteTokenStore(ai, oidc.Params{}, nil, tokenStoreKeyring, "")
+	g.Expect(ai.Exec.Args).To(Equal([]string{"get-token", "--oidc-issuer-url=https://issuer", "--token-store=keyring"}))
+
+	rewriteTokenStore(ai, oidc.Params{}, nil, tokenStoreFile, "")
+	g.Expect(ai.Exec.Args).To(Equal([]string{"get-token", "--oidc-issuer-url=https://issuer"}))
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage where OIDC tokens are stored",
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsMigrateCmd)
+}
+
+// Token stores selectable with get-token --token-store.
+const (
+	tokenStoreFile    = "file"
+	tokenStoreKeyring = "keyring"
+)
+
+// newTokenStore returns the token store of the given kind; file stores keep
+// their tokens in cacheDir.
+func newTokenStore(kind, cacheDir string) (oidc.Store, error) {
+	switch kind {
+	case "", tokenStoreFile:
+		return oidc.Cache{Dir: cacheDir}, nil
+	case tokenStoreKeyring:
+		return oidc.Keyring{}, nil
+	default:
+		return nil, fmt.Errorf("unknown token store %q: must be %s or %s", kind, tokenStoreFile, tokenStoreKeyring)
+	}
+}
+
+// execTokenStore returns the kind and the token store that the get-token
+// arguments args select, with cacheDir as the default directory of file
+// stores.
+func execTokenStore(args []string, cacheDir string) (string, oidc.Store, error) {
+	kind := cmp.Or(execArgValue(args, "--token-store"), tokenStoreFile)
+	store, err := newTokenStore(kind, cmp.Or(execArgValue(args, "--token-cache-dir"), cacheDir))
+	return kind, store, err
+}
+
+// isCloudctlExec reports whether ai runs "cloudctl get-token", as written by
+// sync --auth-type=cloudctl, rather than kubelogin, which keeps its own
+// token cache. Entries that run this binary by its path count as well.
+func isCloudctlExec(ai *clientcmdapi.AuthInfo) bool {
+	if ai == nil || ai.Exec == nil || len(ai.Exec.Args) == 0 || ai.Exec.Args[0] != "get-token" {
+		return false
+	}
+	return strings.HasPrefix(filepath.Base(ai.Exec.Command), "cloudctl") || ai.Exec.Command == cloudctlCommand()
+}
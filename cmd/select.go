@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/fuzzy"
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// canPrompt reports whether an interactive selector can be shown; never with
+// --non-interactive, sync --ci or when stdin is not a terminal. It is a
+// variable so tests can force the non-interactive path.
+var canPrompt = func() bool {
+	return !nonInteractive() && !viper.GetBool("ci") && output.IsTTYWriter(os.Stdin) && output.IsTTYWriter(os.Stderr)
+}
+
+// nonInteractive reports whether --non-interactive (CLOUDCTL_NON_INTERACTIVE)
+// forbids prompts, pickers, browser launches and logins that wait for a person,
+// even where cloudctl does not need stdin to be a terminal for them.
+func nonInteractive() bool {
+	return viper.GetBool("non-interactive")
+}
+
+// selectTarget resolves query to exactly one item name. An exact name match
+// wins; otherwise the items are fuzzy-filtered and a unique match is used
+// directly. When the query is empty or ambiguous the built-in fuzzy finder is
+// shown on a terminal, and an error listing the candidates is returned when
+// no terminal is attached.
+func selectTarget(kind, query string, items []fuzzy.Item) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("no %ss found", kind)
+	}
+	if slices.ContainsFunc(items, func(it fuzzy.Item) bool { return it.Name == query }) {
+		return query, nil
+	}
+	matches := fuzzy.Filter(query, items)
+	if query != "" {
+		switch len(matches) {
+		case 0:
+			return "", fmt.Errorf("no %s matches %q", kind, query)
+		case 1:
+			return matches[0].Item.Name, nil
+		}
+	}
+	if !canPrompt() {
+		if query == "" {
+			return "", fmt.Errorf("a %s name is required when not running in a terminal", kind)
+		}
+		candidates := make([]string, 0, len(matches))
+		for _, m := range matches {
+			candidates = append(candidates, m.Item.Name)
+		}
+		return "", fmt.Errorf("%q is ambiguous, matching %ss: %s", query, kind, strings.Join(candidates, ", "))
+	}
+	return fuzzy.Select(kind, query, items, os.Stdin, os.Stderr)
+}
+
+// selectName picks one of names with the fuzzy finder. It is a variable so
+// tests can answer without a terminal.
+var selectName = func(kind string, names []string) (string, error) {
+	items := make([]fuzzy.Item, 0, len(names))
+	for _, n := range names {
+		items = append(items, fuzzy.Item{Name: n})
+	}
+	return selectTarget(kind, "", items)
+}
+
+// contextItems returns the contexts of cfg as fuzzy finder items. Label values
+// of the referenced cluster (stored by sync in the "labels" extension) are
+// added as keywords so contexts can be found by region, environment, etc.
+func contextItems(cfg *clientcmdapi.Config) []fuzzy.Item {
+	names := slices.Sorted(maps.Keys(cfg.Contexts))
+	items := make([]fuzzy.Item, 0, len(names))
+	for _, name := range names {
+		item := fuzzy.Item{Name: name}
+		if c := cfg.Contexts[name]; c != nil {
+			if cluster := cfg.Clusters[c.Cluster]; cluster != nil {
+				item.Keywords = clusterLabelValues(cluster)
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// clusterLabelValues returns the sorted, de-duplicated label values stored in
+// the cluster's "labels" extension.
+func clusterLabelValues(cluster *clientcmdapi.Cluster) []string {
+	labels := clusterLabels(cluster)
+	if len(labels) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(labels))
+	for _, v := range labels {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// clusterLabels returns the labels stored in the cluster's "labels"
+// extension, or nil.
+func clusterLabels(cluster *clientcmdapi.Cluster) map[string]string {
+	if cluster == nil {
+		return nil
+	}
+	raw := extensionRaw(cluster.Extensions, "labels")
+	if len(raw) == 0 {
+		return nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		return nil
+	}
+	return labels
+}
+
+// contextClusterLabels returns the labels of the cluster referenced by the
+// context name in cfg.
+func contextClusterLabels(cfg *clientcmdapi.Config, name string) map[string]string {
+	if c := cfg.Contexts[name]; c != nil {
+		return clusterLabels(cfg.Clusters[c.Cluster])
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the merged kubeconfig to local tools over a unix socket",
+	Long: `Serves the kubeconfig that sync merges into over HTTP on a unix socket, so
+IDE plugins and other local tools can read cloudctl's view of the clusters
+without parsing the file or reading it while sync is writing it. Every
+request reads the kubeconfig under the same lock sync writes it with.
+
+  GET /kubeconfig          the merged kubeconfig as YAML
+  GET /clusters            the managed contexts as JSON
+  GET /clusters/{context}  a kubeconfig holding only that context, with
+                           certificates inlined; the managed prefix may be
+                           left out
+
+The socket is only accessible by the current user: it is created with mode
+0600 in a directory with mode 0700, so the operating system authenticates
+every client. The server runs until Ctrl+C.
+
+Examples:
+  cloudctl serve
+  curl --unix-socket ~/.local/state/cloudctl/serve.sock http://cloudctl/clusters`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runServe,
+}
+
+func init() {
+	serveCmd.Flags().String("socket", "", "Path of the unix socket (default: serve.sock next to the sync state file)")
+}
+
+// serveCluster is an entry of GET /clusters.
+type serveCluster struct {
+	Context   string `json:"context"`
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	Server    string `json:"server,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// defaultServeSocket returns serve.sock in the directory of the sync state
+// file.
+func defaultServeSocket() (string, error) {
+	path, err := syncStateFile()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine socket path: %w", err)
+	}
+	return filepath.Join(filepath.Dir(path), "serve.sock"), nil
+}
+
+// listenServeSocket listens on the unix socket at path, which only the
+// current user may connect to. A socket left behind by a previous run is
+// replaced, but a socket another server is still answering on is not.
+func listenServeSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("another cloudctl serve is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// readKubeconfigLocked loads the kubeconfig at path under the write lock, so
+// it is never read half-written. A missing file yields an empty config.
+func readKubeconfigLocked(path string) (*clientcmdapi.Config, error) {
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	cfg, err := clientcmd.LoadFromFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return clientcmdapi.NewConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// serveHandler serves the kubeconfig at path; see serveCmd.
+func serveHandler(path string) http.Handler {
+	load := func(w http.ResponseWriter) *clientcmdapi.Config {
+		cfg, err := readKubeconfigLocked(path)
+		if err != nil {
+			slog.Error("failed to read kubeconfig", "path", path, "error", err)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return nil
+		}
+		return cfg
+	}
+	writeYAML := func(w http.ResponseWriter, cfg *clientcmdapi.Config) {
+		data, err := clientcmd.Write(*cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(data)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /kubeconfig", func(w http.ResponseWriter, _ *http.Request) {
+		if cfg := load(w); cfg != nil {
+			writeYAML(w, cfg)
+		}
+	})
+	mux.HandleFunc("GET /clusters", func(w http.ResponseWriter, _ *http.Request) {
+		cfg := load(w)
+		if cfg == nil {
+			return
+		}
+		names, _ := fleetContexts(cfg, nil)
+		clusters := make([]serveCluster, 0, len(names))
+		for _, name := range names {
+			kctx := cfg.Contexts[name]
+			c := serveCluster{Context: name, Name: unmanagedNameFunc(kctx.Cluster), Cluster: kctx.Cluster, Namespace: kctx.Namespace}
+			if cluster, ok := cfg.Clusters[kctx.Cluster]; ok {
+				c.Server = cluster.Server
+			}
+			clusters = append(clusters, c)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(clusters)
+	})
+	mux.HandleFunc("GET /clusters/{context}", func(w http.ResponseWriter, r *http.Request) {
+		cfg := load(w)
+		if cfg == nil {
+			return
+		}
+		name := serveContextName(cfg, r.PathValue("context"))
+		if name == "" {
+			http.Error(w, fmt.Sprintf("no managed context %q", r.PathValue("context")), http.StatusNotFound)
+			return
+		}
+		single, err := singleContextKubeconfig(cfg, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeYAML(w, single)
+	})
+	return mux
+}
+
+// serveContextName resolves name to a managed context of cfg: the context of
+// that name, or else the one referencing the managed cluster of that name,
+// with or without the prefix. It returns "" when there is none.
+func serveContextName(cfg *clientcmdapi.Config, name string) string {
+	if hasManagedCluster(cfg.Contexts[name]) {
+		return name
+	}
+	for _, n := range slices.Sorted(maps.Keys(cfg.Contexts)) {
+		if ctx := cfg.Contexts[n]; hasManagedCluster(ctx) && (ctx.Cluster == name || ctx.Cluster == managedNameFunc(name)) {
+			return n
+		}
+	}
+	return ""
+}
+
+func runServe(cmd *cobra.Command, _ []string) error {
+	prefix = viper.GetString("prefix")
+	path, err := resolveWriteTarget(localKubeconfig())
+	if err != nil {
+		return err
+	}
+	socket := viper.GetString("socket")
+	if socket == "" {
+		if socket, err = defaultServeSocket(); err != nil {
+			return err
+		}
+	}
+
+	ln, err := listenServeSocket(socket)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(socket) }()
+	srv := &http.Server{Handler: serveHandler(path), ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+	slog.Info("serving kubeconfig", "kubeconfig", path, "socket", socket)
+	fmt.Fprintln(cmd.OutOrStdout(), socket)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-cmd.Context().Done():
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestServeHandler(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("prod-eu", "qa"))
+	prefix = "cloudctl"
+
+	socket := filepath.Join(t.TempDir(), "serve.sock")
+	ln, err := listenServeSocket(socket)
+	g.Expect(err).NotTo(HaveOccurred())
+	fi, err := os.Stat(socket)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+	srv := &http.Server{Handler: serveHandler(path)}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	_, err = listenServeSocket(socket)
+	g.Expect(err).To(MatchError(ContainSubstring("another cloudctl serve is listening")))
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	get := func(p string) (int, []byte) {
+		resp, err := client.Get("http://cloudctl" + p)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		g.Expect(err).NotTo(HaveOccurred())
+		return resp.StatusCode, body
+	}
+
+	code, body := get("/clusters")
+	g.Expect(code).To(Equal(http.StatusOK))
+	var clusters []serveCluster
+	g.Expect(json.Unmarshal(body, &clusters)).To(Succeed())
+	g.Expect(clusters).To(HaveLen(2))
+	g.Expect(clusters[0]).To(Equal(serveCluster{Context: "prod-eu", Name: "prod-eu", Cluster: "cloudctl:prod-eu", Server: "https://prod-eu.example"}))
+
+	code, body = get("/kubeconfig")
+	g.Expect(code).To(Equal(http.StatusOK))
+	cfg, err := clientcmd.Load(body)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).To(HaveKey("local"))
+
+	for _, name := range []string{"prod-eu", "cloudctl:prod-eu"} {
+		code, body = get("/clusters/" + name)
+		g.Expect(code).To(Equal(http.StatusOK), name)
+		cfg, err = clientcmd.Load(body)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cfg.CurrentContext).To(Equal("prod-eu"))
+		g.Expect(cfg.Contexts).To(HaveLen(1))
+	}
+
+	code, _ = get("/clusters/local")
+	g.Expect(code).To(Equal(http.StatusNotFound), "unmanaged contexts are not served")
+
+	g.Expect(os.WriteFile(path+".lock", nil, 0o600)).To(Succeed())
+	prevTimeout := kubeconfigLockTimeout
+	kubeconfigLockTimeout = 0
+	t.Cleanup(func() { kubeconfigLockTimeout = prevTimeout })
+	code, _ = get("/kubeconfig")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable), "a kubeconfig locked by a writer is not read")
+}
@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <bash|zsh|fish>",
+	Short: "Print shell integration: prompt segment, aliases and completion",
+	Long: `Prints a script that integrates cloudctl into your shell, so one line in the
+rc file sets up:
+
+  - a prompt segment with the current managed cluster and the value of its
+    --env-label label, e.g. "(prod-eu|production) "; it is recomputed only
+    when KUBECONFIG or the kubeconfig file changes, and left out inside
+    cloudctl shell, which already names the cluster
+  - aliases: k runs kubectl against the context set by cloudctl env, kuse
+    runs cloudctl use, kns runs cloudctl ns, and kenv evals cloudctl env
+  - completion for cloudctl (and for k, when kubectl completion is loaded)
+
+In zsh, evaluate the script after compinit so completion can be registered.
+
+Examples:
+  # ~/.bashrc
+  eval "$(cloudctl shell-init bash)"
+
+  # ~/.zshrc
+  eval "$(cloudctl shell-init zsh --env-label region)"
+
+  # ~/.config/fish/config.fish
+  cloudctl shell-init fish | source`,
+	Args:              cobra.RangeArgs(0, 1),
+	ValidArgsFunction: cobra.FixedCompletions(shellInitShells, cobra.ShellCompDirectiveNoFileComp),
+	PreRunE:           bindCommandFlags,
+	RunE:              runShellInit,
+}
+
+// shellInitShells are the shells shell-init can integrate with.
+var shellInitShells = []string{"bash", "zsh", "fish"}
+
+func init() {
+	shellInitCmd.Flags().String("env-label", "environment", "Cluster label whose value the prompt segment shows next to the cluster name")
+	shellInitCmd.Flags().Bool("skip-prompt", false, "Do not add the prompt segment")
+	shellInitCmd.Flags().Bool("skip-aliases", false, "Do not define the k, kuse, kns and kenv aliases")
+	shellInitCmd.Flags().Bool("skip-completion", false, "Do not load shell completion")
+	// The prompt hooks call shell-init --segment to compute the segment.
+	shellInitCmd.Flags().Bool("segment", false, "Print the prompt segment of the current context")
+	_ = shellInitCmd.Flags().MarkHidden("segment")
+}
+
+func runShellInit(cmd *cobra.Command, args []string) error {
+	if viper.GetBool("segment") {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), promptSegment(viper.GetString("env-label")))
+		return err
+	}
+	if len(args) != 1 || !slices.Contains(shellInitShells, args[0]) {
+		return fmt.Errorf("a shell is required: one of %s", strings.Join(shellInitShells, ", "))
+	}
+	script := shellInitScript(args[0], viper.GetString("env-label"),
+		!viper.GetBool("skip-prompt"), !viper.GetBool("skip-aliases"), !viper.GetBool("skip-completion"))
+	_, err := fmt.Fprint(cmd.OutOrStdout(), script)
+	return err
+}
+
+// promptSegment returns the name of the managed cluster of the current
+// context, followed by the value of its envLabel label, or "" when the
+// current context is not managed. Errors yield "" too; a prompt must not fail.
+func promptSegment(envLabel string) string {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		slog.Debug("no prompt segment", "error", err)
+		return ""
+	}
+	kctx := cfg.Contexts[cfg.CurrentContext]
+	if kctx == nil || !isManaged(kctx.Cluster) {
+		return ""
+	}
+	segment := cfg.CurrentContext
+	if value := clusterLabels(cfg.Clusters[kctx.Cluster])[envLabel]; envLabel != "" && value != "" {
+		segment += "|" + value
+	}
+	return segment
+}
+
+// shellInitScript returns the integration script for shell with the chosen
+// parts.
+func shellInitScript(shell, envLabel string, prompt, aliases, completion bool) string {
+	const segmentCmd = "command cloudctl shell-init --segment --env-label "
+	var b strings.Builder
+	b.WriteString("# cloudctl shell integration, generated by cloudctl shell-init " + shell + "\n")
+	if shell == "fish" {
+		if prompt {
+			fmt.Fprintf(&b, fishPromptScript, segmentCmd+fishQuote(envLabel))
+		}
+		if aliases {
+			b.WriteString(fishAliasScript)
+		}
+		if completion {
+			b.WriteString("command cloudctl completion fish | source\n")
+		}
+		return b.String()
+	}
+
+	if prompt {
+		fmt.Fprintf(&b, posixPromptUpdate, segmentCmd+shellQuote(envLabel))
+		if shell == "zsh" {
+			b.WriteString(zshPromptHook)
+		} else {
+			b.WriteString(bashPromptHook)
+		}
+	}
+	if aliases {
+		b.WriteString(posixAliasScript)
+	}
+	if completion {
+		if shell == "zsh" {
+			b.WriteString(zshCompletionScript)
+		} else {
+			b.WriteString(bashCompletionScript)
+		}
+	}
+	return b.String()
+}
+
+// posixPromptUpdate defines _cloudctl_prompt_update for bash and zsh. It
+// recomputes the segment only when KUBECONFIG changed or its first file is
+// newer than the stamp file touched at the last computation, so cloudctl does
+// not run on every prompt. %s is the command printing the segment.
+const posixPromptUpdate = `_CLOUDCTL_PROMPT_STAMP="${TMPDIR:-/tmp}/cloudctl-prompt.$$"
+_cloudctl_prompt_update() {
+  if [ -n "${CLOUDCTL_SHELL_CONTEXT-}" ]; then
+    _CLOUDCTL_PROMPT=
+    return
+  fi
+  local config="${KUBECONFIG:-$HOME/.kube/config}"
+  if [ "$config" != "${_CLOUDCTL_PROMPT_CONFIG-}" ] || [ "${config%%%%:*}" -nt "$_CLOUDCTL_PROMPT_STAMP" ]; then
+    _CLOUDCTL_PROMPT_CONFIG="$config"
+    local segment
+    segment="$(%s 2>/dev/null)"
+    _CLOUDCTL_PROMPT="${segment:+($segment) }"
+    : >"$_CLOUDCTL_PROMPT_STAMP"
+  fi
+}
+`
+
+const bashPromptHook = `case ";${PROMPT_COMMAND-};" in
+  *";_cloudctl_prompt_update;"*) ;;
+  *) PROMPT_COMMAND="_cloudctl_prompt_update;${PROMPT_COMMAND-}" ;;
+esac
+case "$PS1" in
+  *_CLOUDCTL_PROMPT*) ;;
+  *) PS1='${_CLOUDCTL_PROMPT-}'"$PS1" ;;
+esac
+`
+
+const zshPromptHook = `autoload -Uz add-zsh-hook
+add-zsh-hook precmd _cloudctl_prompt_update
+setopt prompt_subst
+case "$PROMPT" in
+  *_CLOUDCTL_PROMPT*) ;;
+  *) PROMPT='${_CLOUDCTL_PROMPT-}'"$PROMPT" ;;
+esac
+`
+
+// posixAliasScript defines the aliases as functions, so k can add --context
+// only when cloudctl env has set KUBECTL_CONTEXT.
+const posixAliasScript = `k() {
+  if [ -n "${KUBECTL_CONTEXT-}" ]; then
+    command kubectl --context "$KUBECTL_CONTEXT" "$@"
+  else
+    command kubectl "$@"
+  fi
+}
+kuse() { command cloudctl use "$@"; }
+kns() { command cloudctl ns "$@"; }
+kenv() { eval "$(command cloudctl env "$@")"; }
+`
+
+const bashCompletionScript = `source <(command cloudctl completion bash)
+if declare -F __start_kubectl >/dev/null; then
+  complete -o default -F __start_kubectl k
+fi
+`
+
+const zshCompletionScript = `if (( $+functions[compdef] )); then
+  source <(command cloudctl completion zsh)
+  compdef k=kubectl
+else
+  print -u2 "cloudctl shell-init: completion not loaded, evaluate it after compinit"
+fi
+`
+
+// fishPromptScript keeps the segment in a global variable updated before each
+// prompt and wraps the existing fish_prompt function. %s is the command
+// printing the segment.
+const fishPromptScript = `function _cloudctl_prompt_update --on-event fish_prompt
+    if set -q CLOUDCTL_SHELL_CONTEXT
+        set -g _cloudctl_prompt ''
+        return
+    end
+    set -l config $KUBECONFIG
+    test -n "$config"; or set config ~/.kube/config
+    set -l mtime (path mtime -- (string split -m1 : -- $config)[1] 2>/dev/null)
+    if test "$config" != "$_cloudctl_prompt_config"; or test "$mtime" != "$_cloudctl_prompt_mtime"
+        set -g _cloudctl_prompt_config $config
+        set -g _cloudctl_prompt_mtime $mtime
+        set -l segment (%s 2>/dev/null)
+        set -g _cloudctl_prompt ''
+        test -n "$segment"; and set -g _cloudctl_prompt "($segment) "
+    end
+end
+if functions -q fish_prompt; and not functions -q _cloudctl_original_fish_prompt
+    functions -c fish_prompt _cloudctl_original_fish_prompt
+    function fish_prompt
+        printf '%%s' $_cloudctl_prompt
+        _cloudctl_original_fish_prompt
+    end
+end
+`
+
+const fishAliasScript = `function k --wraps kubectl
+    if set -q KUBECTL_CONTEXT
+        command kubectl --context $KUBECTL_CONTEXT $argv
+    else
+        command kubectl $argv
+    end
+end
+function kuse --wraps 'cloudctl use'
+    command cloudctl use $argv
+end
+function kns --wraps 'cloudctl ns'
+    command cloudctl ns $argv
+end
+function kenv --wraps 'cloudctl env'
+    command cloudctl env $argv --shell fish | source
+end
+`
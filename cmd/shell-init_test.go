@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPromptSegment(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:prod-eu"] = &clientcmdapi.Cluster{Server: "https://prod-eu.example"}
+	cfg.Contexts["prod-eu"] = &clientcmdapi.Context{Cluster: "cloudctl:prod-eu"}
+	cfg.Clusters["local"] = &clientcmdapi.Cluster{Server: "https://local.example"}
+	cfg.Contexts["local"] = &clientcmdapi.Context{Cluster: "local"}
+	cfg.Clusters["cloudctl:prod-eu"].Extensions = map[string]runtime.Object{
+		"labels": &runtime.Unknown{Raw: []byte(`{"environment":"production","region":"eu-de-1"}`)},
+	}
+	cfg.CurrentContext = "prod-eu"
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	for k, v := range map[string]any{"kubeconfig": path, "prefix": "cloudctl", "segment": true, "env-label": "environment"} {
+		viper.Set(k, v)
+		t.Cleanup(func() { viper.Set(k, nil) })
+	}
+
+	var out bytes.Buffer
+	shellInitCmd.SetOut(&out)
+	t.Cleanup(func() { shellInitCmd.SetOut(nil) })
+	g.Expect(runShellInit(shellInitCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(Equal("prod-eu|production\n"))
+
+	g.Expect(promptSegment("region")).To(Equal("prod-eu|eu-de-1"))
+	g.Expect(promptSegment("tier")).To(Equal("prod-eu"))
+
+	cfg.CurrentContext = "local"
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	g.Expect(promptSegment("environment")).To(BeEmpty())
+
+	viper.Set("kubeconfig", filepath.Join(t.TempDir(), "missing", "config"))
+	g.Expect(promptSegment("environment")).To(BeEmpty())
+}
+
+func TestShellInitScript(t *testing.T) {
+	g := NewWithT(t)
+
+	bash := shellInitScript("bash", "env label", true, true, true)
+	g.Expect(bash).To(ContainSubstring(`segment="$(command cloudctl shell-init --segment --env-label 'env label' 2>/dev/null)"`))
+	g.Expect(bash).To(ContainSubstring(`[ "${config%%:*}" -nt "$_CLOUDCTL_PROMPT_STAMP" ]`))
+	g.Expect(bash).To(ContainSubstring(`PROMPT_COMMAND="_cloudctl_prompt_update;${PROMPT_COMMAND-}"`))
+	g.Expect(bash).To(ContainSubstring(`kenv() { eval "$(command cloudctl env "$@")"; }`))
+	g.Expect(bash).To(ContainSubstring("source <(command cloudctl completion bash)"))
+	g.Expect(bash).NotTo(ContainSubstring("add-zsh-hook"))
+
+	zsh := shellInitScript("zsh", "environment", true, false, true)
+	g.Expect(zsh).To(ContainSubstring("add-zsh-hook precmd _cloudctl_prompt_update"))
+	g.Expect(zsh).To(ContainSubstring("source <(command cloudctl completion zsh)"))
+	g.Expect(zsh).NotTo(ContainSubstring("kuse()"))
+
+	fish := shellInitScript("fish", "it's", true, true, false)
+	g.Expect(fish).To(ContainSubstring(`set -l segment (command cloudctl shell-init --segment --env-label 'it\'s' 2>/dev/null)`))
+	g.Expect(fish).To(ContainSubstring("printf '%s' $_cloudctl_prompt"))
+	g.Expect(fish).To(ContainSubstring("command cloudctl env $argv --shell fish | source"))
+	g.Expect(fish).NotTo(ContainSubstring("completion fish"))
+
+	g.Expect(shellInitScript("bash", "environment", false, false, false)).To(Equal("# cloudctl shell integration, generated by cloudctl shell-init bash\n"))
+}
+
+func TestRunShellInit_RequiresShell(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(runShellInit(shellInitCmd, nil)).To(MatchError("a shell is required: one of bash, zsh, fish"))
+	g.Expect(runShellInit(shellInitCmd, []string{"tcsh"})).To(MatchError("a shell is required: one of bash, zsh, fish"))
+}
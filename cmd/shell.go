@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [cluster]",
+	Short: "Start a subshell that can only reach one managed cluster",
+	Long: `Writes a temporary kubeconfig holding only the selected managed context,
+points KUBECONFIG at it and starts your shell ($SHELL, or %COMSPEC% on
+Windows). kubectl and other tools in that shell can only ever reach the
+intended cluster. The prompt is prefixed with the cluster name and
+CLOUDCTL_SHELL_CONTEXT is set, so prompt themes can show it too. The
+kubeconfig is removed when the shell exits, and its exit code is passed on.
+
+The argument is matched against managed contexts like cloudctl use; without
+it a finder is shown.
+
+Examples:
+  cloudctl shell prod-eu
+  cloudctl shell prod-eu --shell /bin/zsh`,
+	Args:              cobra.MaximumNArgs(1),
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runShell,
+}
+
+func init() {
+	shellCmd.Flags().String("shell", "", "Shell to start (defaults to $SHELL, or %COMSPEC% on Windows)")
+}
+
+// runSubshell runs the shell command c with the terminal attached and returns
+// its exit code. It is a variable so tests need not start a shell.
+var runSubshell = func(c *exec.Cmd) (int, error) {
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+func runShell(_ *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	var query string
+	if len(args) == 1 {
+		query = args[0]
+	}
+	contextName, err := selectManagedContext(cfg, query)
+	if err != nil {
+		return err
+	}
+	single, err := singleContextKubeconfig(cfg, contextName)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "cloudctl-shell-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	kubeconfigFile := filepath.Join(dir, "kubeconfig")
+	if err := clientcmd.WriteToFile(*single, kubeconfigFile); err != nil {
+		return fmt.Errorf("failed to write temporary kubeconfig: %w", err)
+	}
+
+	c, err := subshellCommand(viper.GetString("shell"), dir, contextName)
+	if err != nil {
+		return err
+	}
+	c.Env = append(c.Env, "KUBECONFIG="+kubeconfigFile, "CLOUDCTL_SHELL_CONTEXT="+contextName)
+	slog.Info("starting shell", "shell", c.Path, "context", contextName)
+	code, err := runSubshell(c)
+	if err != nil {
+		return fmt.Errorf("failed to run shell %s: %w", c.Path, err)
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// singleContextKubeconfig returns a copy of cfg holding only contextName and
+// the cluster and user it references, with referenced files inlined so the
+// result can be written anywhere.
+func singleContextKubeconfig(cfg *clientcmdapi.Config, contextName string) (*clientcmdapi.Config, error) {
+	out := cfg.DeepCopy()
+	out.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(out); err != nil {
+		return nil, fmt.Errorf("failed to extract context %q: %w", contextName, err)
+	}
+	if err := clientcmdapi.FlattenConfig(out); err != nil {
+		return nil, fmt.Errorf("failed to inline certificate files: %w", err)
+	}
+	return out, nil
+}
+
+// subshellCommand returns the command starting shell (or the user's default
+// shell) with its prompt prefixed by label. bash and zsh read their usual
+// startup files first, from rc files placed in dir.
+func subshellCommand(shell, dir, label string) (*exec.Cmd, error) {
+	if shell == "" {
+		shell = os.Getenv("SHELL")
+	}
+	if shell == "" && runtime.GOOS == "windows" {
+		shell = os.Getenv("COMSPEC")
+	}
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	env := os.Environ()
+	var args []string
+	switch name := strings.TrimSuffix(filepath.Base(shell), ".exe"); name {
+	case "bash":
+		rc := filepath.Join(dir, "bashrc")
+		script := fmt.Sprintf("[ -f ~/.bashrc ] && . ~/.bashrc\nPS1=%s\"$PS1\"\n", shellQuote("("+label+") "))
+		if err := os.WriteFile(rc, []byte(script), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write shell startup file: %w", err)
+		}
+		args = []string{"--rcfile", rc}
+	case "zsh":
+		// zsh reads .zshrc from $ZDOTDIR; source the user's own first.
+		script := fmt.Sprintf("ZDOTDIR=%s\n[ -f \"$ZDOTDIR/.zshrc\" ] && . \"$ZDOTDIR/.zshrc\"\nPROMPT=%s\"$PROMPT\"\n",
+			shellQuote(cmp.Or(os.Getenv("ZDOTDIR"), os.Getenv("HOME"))), shellQuote("("+label+") "))
+		if err := os.WriteFile(filepath.Join(dir, ".zshrc"), []byte(script), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write shell startup file: %w", err)
+		}
+		env = append(env, "ZDOTDIR="+dir)
+	case "cmd":
+		env = append(env, "PROMPT=("+label+") $P$G")
+	default:
+		// Shells such as sh and dash take the prompt from the environment.
+		env = append(env, "PS1=("+label+") "+cmp.Or(os.Getenv("PS1"), "$ "))
+	}
+	c := exec.Command(shell, args...) // #nosec G204 — the user's own shell
+	c.Env = env
+	return c, nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunShell_ScopesKubeconfigToOneContext(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("PS1", "")
+	path := filepath.Join(t.TempDir(), "config")
+	cfg := clientcmdapi.NewConfig()
+	for _, name := range []string{"prod-eu", "qa-eu", "local"} {
+		cluster := name
+		if name != "local" {
+			cluster = "cloudctl:" + name
+		}
+		cfg.Clusters[cluster] = &clientcmdapi.Cluster{Server: "https://" + name + ".example"}
+		cfg.AuthInfos[cluster] = &clientcmdapi.AuthInfo{Token: name}
+		cfg.Contexts[name] = &clientcmdapi.Context{Cluster: cluster, AuthInfo: cluster}
+	}
+	cfg.CurrentContext = "local"
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	for k, v := range map[string]any{"kubeconfig": path, "prefix": "cloudctl", "shell": "/bin/sh"} {
+		viper.Set(k, v)
+		t.Cleanup(func() { viper.Set(k, nil) })
+	}
+
+	var (
+		env    []string
+		scoped *clientcmdapi.Config
+	)
+	prev := runSubshell
+	runSubshell = func(c *exec.Cmd) (int, error) {
+		env = c.Env
+		i := slices.IndexFunc(env, func(e string) bool { return strings.HasPrefix(e, "KUBECONFIG=") })
+		g.Expect(i).NotTo(Equal(-1))
+		var err error
+		scoped, err = clientcmd.LoadFromFile(strings.TrimPrefix(env[i], "KUBECONFIG="))
+		g.Expect(err).NotTo(HaveOccurred())
+		return 3, nil
+	}
+	t.Cleanup(func() { runSubshell = prev })
+
+	err := runShell(shellCmd, []string{"prod-eu"})
+	g.Expect(err).To(MatchError(&ExitError{Code: 3}))
+	g.Expect(env).To(ContainElements("CLOUDCTL_SHELL_CONTEXT=prod-eu", "PS1=(prod-eu) $ "))
+	g.Expect(scoped.CurrentContext).To(Equal("prod-eu"))
+	g.Expect(scoped.Contexts).To(HaveLen(1))
+	g.Expect(scoped.Clusters).To(HaveKey("cloudctl:prod-eu"))
+	g.Expect(scoped.AuthInfos).To(HaveLen(1))
+}
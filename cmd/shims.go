@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var shimsCmd = &cobra.Command{
+	Use:   "shims",
+	Short: "Manage per-cluster kubectl wrapper scripts",
+}
+
+var shimsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write a kubectl-<cluster> wrapper script for each managed context",
+	Long: `Writes one small executable per managed context into --dir, named
+kubectl-<cluster>, that runs kubectl against exactly that context. With the
+directory on PATH, "kubectl-prod-eu get pods" reaches prod-eu no matter which
+context is current, and the shell completes the cluster names. The scripts
+are also kubectl plugins, so "kubectl prod-eu get pods" works too.
+
+Run the command again after a sync: scripts for contexts that no longer exist
+in the kubeconfig are removed, while scripts written for other kubeconfigs or
+left out by --selector are kept. Only scripts written by cloudctl are
+overwritten or removed;
+other files in the directory are left alone. When --kubeconfig is given, the
+scripts pass it on to kubectl; otherwise kubectl uses its default
+kubeconfig. --selector limits the scripts to clusters whose Greenhouse
+labels match.
+
+Examples:
+  cloudctl shims generate
+  cloudctl shims generate --dir ~/bin --selector env=prod
+  cloudctl shims generate --kubectl kubecolor`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runShimsGenerate,
+}
+
+// shimMarker is written into every script so later runs recognize the
+// scripts they own. The rest of the line records the context and kubeconfig
+// the script was written for.
+const shimMarker = "Generated by cloudctl shims generate"
+
+// shimHeadSize is how much of a file is read to find the marker line.
+const shimHeadSize = 4096
+
+func init() {
+	shimsCmd.AddCommand(shimsGenerateCmd)
+	shimsGenerateCmd.Flags().String("dir", "", "Directory to write the scripts to (default: ~/.local/bin)")
+	shimsGenerateCmd.Flags().StringP("selector", "l", "", "Only write scripts for clusters whose labels match this label selector")
+	shimsGenerateCmd.Flags().String("kubectl", "kubectl", "Command the scripts run, e.g. kubecolor")
+}
+
+func runShimsGenerate(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	prefix = viper.GetString("prefix")
+	dir := viper.GetString("dir")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to determine home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "bin")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+	all, err := fleetContexts(cfg, nil)
+	if err != nil {
+		return err
+	}
+	names, err := matchingContexts(cfg, all, viper.GetString("selector"))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var kubeconfig string
+	if viper.IsSet("kubeconfig") {
+		if kubeconfig, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	// source identifies the kubeconfig in the marker line, so pruning only
+	// touches scripts written for it.
+	source := kubeconfig
+	if source == "" {
+		source = displayKubeconfig(path)
+	}
+	written := map[string]string{}
+	for _, name := range names {
+		file := filepath.Join(dir, shimFileName(name, runtime.GOOS))
+		if other, ok := written[file]; ok {
+			slog.Warn("skipping context whose script name is taken", "context", name, "by", other, "file", file)
+			continue
+		}
+		_, owned, err := readShim(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err == nil && !owned {
+			slog.Warn("not overwriting a file cloudctl did not write", "file", file, "context", name)
+			continue
+		}
+		script := shimScript(viper.GetString("kubectl"), kubeconfig, source, name, runtime.GOOS)
+		if err := os.WriteFile(file, []byte(script), 0o755); err != nil { // #nosec G306 — the script must be executable
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		// WriteFile keeps the mode of an existing file.
+		if err := os.Chmod(file, 0o755); err != nil {
+			return fmt.Errorf("failed to make %s executable: %w", file, err)
+		}
+		written[file] = name
+	}
+	if err := pruneShims(dir, source, cfg.Contexts, written); err != nil {
+		return err
+	}
+
+	if !slices.Contains(filepath.SplitList(os.Getenv("PATH")), dir) {
+		slog.Warn("the script directory is not on PATH", "dir", dir)
+	}
+	slog.Info("generated kubectl scripts", "count", len(written), "dir", dir)
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), dir)
+	return err
+}
+
+// shimFileName returns the script name for a cluster; Windows runs scripts
+// by their .cmd extension.
+func shimFileName(name, goos string) string {
+	file := "kubectl-" + safeFileName(name)
+	if goos == "windows" {
+		file += ".cmd"
+	}
+	return file
+}
+
+// shimScript returns a script running kubectl (with kubeconfig, unless it is
+// empty) against contextName, passing on all arguments. source identifies the
+// kubeconfig the context was read from and is recorded in the marker line.
+func shimScript(kubectl, kubeconfig, source, contextName, goos string) string {
+	marker := shimMarkerLine(source, contextName)
+	if goos == "windows" {
+		args := ""
+		if kubeconfig != "" {
+			args = " --kubeconfig " + cmdQuote(kubeconfig)
+		}
+		return fmt.Sprintf("@echo off\r\nrem %s\r\n%s%s --context %s %%*\r\n",
+			cmdEscapePercent(marker), kubectl, args, cmdQuote(contextName))
+	}
+	args := ""
+	if kubeconfig != "" {
+		args = " --kubeconfig " + shellQuote(kubeconfig)
+	}
+	return fmt.Sprintf("#!/bin/sh\n# %s\nexec %s%s --context %s \"$@\"\n",
+		marker, shellQuote(kubectl), args, shellQuote(contextName))
+}
+
+// shimMarkerLine returns the comment identifying a script written for
+// contextName of the kubeconfig source.
+func shimMarkerLine(source, contextName string) string {
+	return fmt.Sprintf("%s for context %q in kubeconfig %q; removed when the context is gone.", shimMarker, contextName, source)
+}
+
+// cmdQuote double-quotes s for a .cmd script: percent signs are doubled so
+// cmd does not expand them, and quotes are doubled, which kubectl reads as a
+// literal quote inside a quoted argument.
+func cmdQuote(s string) string {
+	return `"` + cmdEscapePercent(strings.ReplaceAll(s, `"`, `""`)) + `"`
+}
+
+// cmdEscapePercent doubles the percent signs in s, which cmd expands even in
+// rem lines.
+func cmdEscapePercent(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// shimOwner is the context and kubeconfig a script was written for.
+type shimOwner struct {
+	Context    string
+	Kubeconfig string
+}
+
+// readShim reports whether file is a script written by shims generate and,
+// if its marker line records them, the context and kubeconfig it was written
+// for. Only the start of the file is read, since plugins next to the scripts
+// can be large binaries.
+func readShim(file string) (*shimOwner, bool, error) {
+	f, err := os.Open(file) // #nosec G304 — a file in the script directory
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, shimHeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	_, line, owned := strings.Cut(string(head[:n]), shimMarker)
+	if !owned {
+		return nil, false, nil
+	}
+	line, _, _ = strings.Cut(line, "\n")
+	if strings.HasSuffix(file, ".cmd") {
+		line = strings.ReplaceAll(line, "%%", "%")
+	}
+	return parseShimOwner(line), true, nil
+}
+
+// parseShimOwner parses the rest of a marker line written by shimMarkerLine;
+// it returns nil when the line does not record an owner.
+func parseShimOwner(line string) *shimOwner {
+	var owner shimOwner
+	for _, field := range []struct {
+		prefix string
+		value  *string
+	}{
+		{" for context ", &owner.Context},
+		{" in kubeconfig ", &owner.Kubeconfig},
+	} {
+		rest, ok := strings.CutPrefix(line, field.prefix)
+		if !ok {
+			return nil
+		}
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil
+		}
+		if *field.value, err = strconv.Unquote(quoted); err != nil {
+			return nil
+		}
+		line = rest[len(quoted):]
+	}
+	return &owner
+}
+
+// pruneShims removes the scripts in dir that were written for a context of
+// the kubeconfig source which no longer exists in contexts. Scripts for
+// other kubeconfigs, and those not recording one, are kept.
+func pruneShims(dir, source string, contexts map[string]*clientcmdapi.Context, written map[string]string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		file := filepath.Join(dir, e.Name())
+		if !strings.HasPrefix(e.Name(), "kubectl-") || !e.Type().IsRegular() || written[file] != "" {
+			continue
+		}
+		owner, owned, err := readShim(file)
+		if err != nil || !owned || owner == nil || owner.Kubeconfig != source {
+			continue
+		}
+		if _, exists := contexts[owner.Context]; exists {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+		slog.Info("removed kubectl script", "file", file, "context", owner.Context)
+	}
+	return nil
+}
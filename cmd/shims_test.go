@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunShimsGenerate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts are .cmd files on Windows")
+	}
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("prod-eu", "qa"))
+	dir := t.TempDir()
+	viper.Set("dir", dir)
+	viper.Set("kubectl", "kubectl")
+	t.Cleanup(func() {
+		viper.Set("dir", nil)
+		viper.Set("kubectl", nil)
+	})
+	foreign := filepath.Join(dir, "kubectl-qa")
+	g.Expect(os.WriteFile(foreign, []byte("#!/bin/sh\necho mine\n"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "kubectl-krew"), []byte("binary"), 0o755)).To(Succeed())
+	other := filepath.Join(dir, "kubectl-staging")
+	g.Expect(os.WriteFile(other, []byte(shimScript("kubectl", "/other/config", "/other/config", "staging", "linux")), 0o755)).To(Succeed())
+
+	var out bytes.Buffer
+	shimsGenerateCmd.SetOut(&out)
+	t.Cleanup(func() { shimsGenerateCmd.SetOut(nil) })
+	g.Expect(runShimsGenerate(shimsGenerateCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(Equal(dir + "\n"))
+
+	shim := filepath.Join(dir, "kubectl-prod-eu")
+	data, err := os.ReadFile(shim)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(Equal("#!/bin/sh\n# " + shimMarker + " for context \"prod-eu\" in kubeconfig \"" + path + "\"; removed when the context is gone.\n" +
+		"exec 'kubectl' --kubeconfig '" + path + "' --context 'prod-eu' \"$@\"\n"))
+	info, err := os.Stat(shim)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o755)))
+	data, err = os.ReadFile(foreign)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring("echo mine"), "files cloudctl did not write are not overwritten")
+
+	// A run limited by --selector keeps the scripts of the other contexts.
+	viper.Set("selector", "env=none")
+	t.Cleanup(func() { viper.Set("selector", nil) })
+	g.Expect(runShimsGenerate(shimsGenerateCmd, nil)).To(Succeed())
+	g.Expect(shim).To(BeAnExistingFile(), "prod-eu still exists")
+	viper.Set("selector", nil)
+
+	// prod-eu is gone from the kubeconfig: its script is pruned on the next run.
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	delete(cfg.Contexts, "prod-eu")
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	g.Expect(runShimsGenerate(shimsGenerateCmd, nil)).To(Succeed())
+	g.Expect(shim).NotTo(BeAnExistingFile())
+	g.Expect(foreign).To(BeAnExistingFile())
+	g.Expect(filepath.Join(dir, "kubectl-krew")).To(BeAnExistingFile())
+	g.Expect(other).To(BeAnExistingFile(), "scripts for another kubeconfig are kept")
+}
+
+func TestShimScript(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(shimFileName("org/prod:eu", "linux")).To(Equal("kubectl-org-prod-eu"))
+	g.Expect(shimFileName("prod-eu", "windows")).To(Equal("kubectl-prod-eu.cmd"))
+
+	g.Expect(shimScript("kubecolor", "", "/home/me/.kube/config", "it's", "linux")).
+		To(HaveSuffix("exec 'kubecolor' --context 'it'\\''s' \"$@\"\n"))
+	g.Expect(shimScript("kubectl", `C:\Users\me\.kube\config`, `C:\Users\me\.kube\config`, "prod-eu", "windows")).
+		To(HaveSuffix("kubectl --kubeconfig \"C:\\Users\\me\\.kube\\config\" --context \"prod-eu\" %*\r\n"))
+	script := shimScript("kubectl", `C:\100%\config`, `C:\100%\config`, `say "hi"`, "windows")
+	g.Expect(script).To(HaveSuffix("kubectl --kubeconfig \"C:\\100%%\\config\" --context \"say \"\"hi\"\"\" %*\r\n"),
+		"percent signs and quotes are escaped for cmd")
+	g.Expect(script).NotTo(MatchRegexp(`[^%]%[^%*]`), "no single percent sign is left for cmd to expand")
+
+	for _, tc := range []struct{ goos, file string }{{"linux", "kubectl-x"}, {"windows", "kubectl-x.cmd"}} {
+		file := filepath.Join(t.TempDir(), tc.file)
+		g.Expect(os.WriteFile(file, []byte(shimScript("kubectl", "", `/100% "kube"/config`, `say "hi"`, tc.goos)), 0o600)).To(Succeed())
+		owner, owned, err := readShim(file)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(owned).To(BeTrue())
+		g.Expect(owner).To(Equal(&shimOwner{Context: `say "hi"`, Kubeconfig: `/100% "kube"/config`}), tc.goos)
+	}
+	g.Expect(parseShimOwner("; removed when the context is gone.")).To(BeNil(), "a marker without an owner")
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// syncSource is one Greenhouse organization that sync reads from. Several
+// sources are configured with the "sources" config-file key; each needs its
+// own prefix so entries of different organizations are distinguishable and
+// pruning only touches the entries of the source being synced. A source with
+// a kubeconfig of its own is merged into that file instead of --kubeconfig.
+//
+//	sources:
+//	  - namespace: org-prod
+//	    prefix: prod
+//	  - namespace: org-qa
+//	    prefix: qa
+//	    kubeconfig: ~/.kube/configs/org-qa.yaml
+//	    greenhouse-cluster-kubeconfig: /home/user/.kube/greenhouse-qa.yaml
+//	    greenhouse-cluster-context: qa
+//	    exec-api-version: v1beta1
+type syncSource struct {
+	Namespace      string `mapstructure:"namespace"`
+	Prefix         string `mapstructure:"prefix"`
+	Output         string `mapstructure:"kubeconfig"`
+	Kubeconfig     string `mapstructure:"greenhouse-cluster-kubeconfig"`
+	Context        string `mapstructure:"greenhouse-cluster-context"`
+	ExecAPIVersion string `mapstructure:"exec-api-version"`
+	// endpoint is the Greenhouse API server, set once connected.
+	endpoint string
+}
+
+// loadSyncSources returns the sources sync reads from. Without the "sources"
+// key, or when a namespace is given, there is a single source built from the
+// flags. Sources that do not name a kubeconfig to write, a Greenhouse
+// kubeconfig, context or exec API version use the flag values.
+func loadSyncSources() ([]syncSource, error) {
+	single := syncSource{
+		Namespace:      greenhouseClusterNamespace,
+		Prefix:         prefix,
+		Output:         remoteClusterKubeconfig,
+		Kubeconfig:     greenhouseClusterKubeconfig,
+		Context:        greenhouseClusterContext,
+		ExecAPIVersion: execAPIVersionSettings.Default,
+	}
+	var sources []syncSource
+	if err := viper.UnmarshalKey("sources", &sources); err != nil {
+		return nil, fmt.Errorf("invalid sources config: %w", err)
+	}
+	if len(sources) == 0 || greenhouseClusterNamespace != "" {
+		return []syncSource{single}, nil
+	}
+
+	var prefixes []string
+	for i := range sources {
+		s := &sources[i]
+		switch {
+		case s.Namespace == "":
+			return nil, fmt.Errorf("invalid sources config: entry %d has no namespace", i+1)
+		case s.Prefix == "":
+			return nil, fmt.Errorf("invalid sources config: %s has no prefix", s.Namespace)
+		case strings.Contains(s.Prefix, ":"):
+			return nil, fmt.Errorf("invalid sources config: prefix %q of %s must not contain ':'", s.Prefix, s.Namespace)
+		case slices.Contains(prefixes, s.Prefix):
+			return nil, fmt.Errorf("invalid sources config: prefix %q is used by more than one source", s.Prefix)
+		case s.ExecAPIVersion != "" && validateExecAPIVersion(s.ExecAPIVersion) != nil:
+			return nil, fmt.Errorf("invalid sources config: %s: %w", s.Namespace, validateExecAPIVersion(s.ExecAPIVersion))
+		}
+		prefixes = append(prefixes, s.Prefix)
+		output, err := expandHome(s.Output)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sources config: kubeconfig of %s: %w", s.Namespace, err)
+		}
+		s.Output = cmp.Or(output, single.Output)
+		s.Kubeconfig = cmp.Or(s.Kubeconfig, single.Kubeconfig)
+		s.Context = cmp.Or(s.Context, single.Context)
+		s.ExecAPIVersion = cmp.Or(s.ExecAPIVersion, single.ExecAPIVersion)
+	}
+	return sources, nil
+}
+
+// useSource points the sync globals at src, so syncOnce and the sync state
+// work with its namespace, prefix, endpoint and kubeconfig.
+func useSource(src syncSource) {
+	greenhouseClusterNamespace, prefix, greenhouseEndpoint = src.Namespace, src.Prefix, src.endpoint
+	execAPIVersionSettings.Default = src.ExecAPIVersion
+	remoteClusterKubeconfig = src.Output
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
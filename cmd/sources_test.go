@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// setSyncSourceGlobals sets the flag values loadSyncSources falls back to.
+func setSyncSourceGlobals(t *testing.T, namespace string) {
+	t.Helper()
+	prevNamespace, prevPrefix, prevKubeconfig, prevContext, prevExec := greenhouseClusterNamespace, prefix, greenhouseClusterKubeconfig, greenhouseClusterContext, execAPIVersionSettings
+	prevOutput := remoteClusterKubeconfig
+	greenhouseClusterNamespace, prefix, greenhouseClusterKubeconfig, greenhouseClusterContext = namespace, "cloudctl", "/gh.yaml", "gh"
+	remoteClusterKubeconfig = "/kube.yaml"
+	execAPIVersionSettings = execAPIVersionConfig{Default: "v1"}
+	t.Cleanup(func() {
+		greenhouseClusterNamespace, prefix, greenhouseClusterKubeconfig, greenhouseClusterContext, execAPIVersionSettings = prevNamespace, prevPrefix, prevKubeconfig, prevContext, prevExec
+		remoteClusterKubeconfig = prevOutput
+	})
+}
+
+func TestLoadSyncSources_SingleSourceFromFlags(t *testing.T) {
+	g := NewWithT(t)
+	setSyncSourceGlobals(t, "org-a")
+	viper.Set("sources", []map[string]any{{"namespace": "org-prod", "prefix": "prod"}})
+	t.Cleanup(func() { viper.Set("sources", nil) })
+
+	// An explicit namespace wins over the configured sources.
+	sources, err := loadSyncSources()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sources).To(Equal([]syncSource{{Namespace: "org-a", Prefix: "cloudctl", Output: "/kube.yaml", Kubeconfig: "/gh.yaml", Context: "gh", ExecAPIVersion: "v1"}}))
+}
+
+func TestLoadSyncSources_Configured(t *testing.T) {
+	g := NewWithT(t)
+	setSyncSourceGlobals(t, "")
+	viper.Set("sources", []map[string]any{
+		{"namespace": "org-prod", "prefix": "prod"},
+		{"namespace": "org-qa", "prefix": "qa", "kubeconfig": "/configs/org-qa.yaml", "greenhouse-cluster-kubeconfig": "/qa.yaml", "greenhouse-cluster-context": "qa", "exec-api-version": "v1beta1"},
+	})
+	t.Cleanup(func() { viper.Set("sources", nil) })
+
+	sources, err := loadSyncSources()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sources).To(Equal([]syncSource{
+		{Namespace: "org-prod", Prefix: "prod", Output: "/kube.yaml", Kubeconfig: "/gh.yaml", Context: "gh", ExecAPIVersion: "v1"},
+		{Namespace: "org-qa", Prefix: "qa", Output: "/configs/org-qa.yaml", Kubeconfig: "/qa.yaml", Context: "qa", ExecAPIVersion: "v1beta1"},
+	}))
+}
+
+func TestLoadSyncSources_Invalid(t *testing.T) {
+	g := NewWithT(t)
+	setSyncSourceGlobals(t, "")
+	t.Cleanup(func() { viper.Set("sources", nil) })
+
+	viper.Set("sources", []map[string]any{{"prefix": "prod"}})
+	_, err := loadSyncSources()
+	g.Expect(err).To(MatchError(ContainSubstring("entry 1 has no namespace")))
+
+	viper.Set("sources", []map[string]any{{"namespace": "org-prod"}})
+	_, err = loadSyncSources()
+	g.Expect(err).To(MatchError(ContainSubstring("org-prod has no prefix")))
+
+	viper.Set("sources", []map[string]any{{"namespace": "org-prod", "prefix": "x"}, {"namespace": "org-qa", "prefix": "x"}})
+	_, err = loadSyncSources()
+	g.Expect(err).To(MatchError(ContainSubstring(`prefix "x" is used by more than one source`)))
+
+	viper.Set("sources", []map[string]any{{"namespace": "org-prod", "prefix": "prod", "exec-api-version": "v2"}})
+	_, err = loadSyncSources()
+	g.Expect(err).To(MatchError(ContainSubstring(`org-prod: exec API version "v2" is not one of v1, v1beta1`)))
+}
+
+func TestExpandHome(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("HOME", "/home/jane")
+	t.Setenv("USERPROFILE", "/home/jane")
+
+	g.Expect(expandHome("~/.kube/configs/org.yaml")).To(Equal(filepath.Join("/home/jane", ".kube", "configs", "org.yaml")))
+	g.Expect(expandHome("~")).To(Equal(filepath.Clean("/home/jane")))
+	g.Expect(expandHome("~jane/config")).To(Equal("~jane/config"))
+	g.Expect(expandHome("/etc/kube.yaml")).To(Equal("/etc/kube.yaml"))
+	g.Expect(expandHome("")).To(Equal(""))
+}
+
+func TestSourceKubeconfig_CreatedOnFirstSync(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "configs", "org-qa.yaml")
+
+	// A source's own kubeconfig starts out empty and is created with its
+	// directory when first written.
+	cfg, err := loadLocalKubeconfig(context.Background(), path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Contexts).To(BeEmpty())
+	cfg.Contexts["qa:eu-1"] = clientcmdapi.NewContext()
+	g.Expect(writeConfig(cfg, path)).To(Succeed())
+
+	written, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.Contexts).To(HaveKey("qa:eu-1"))
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Maintain the files cloudctl keeps between runs",
+}
+
+var stateCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Prune old sync records, expired cached tokens and stale env kubeconfigs",
+	Long: `Keeps the cloudctl state and cache directories bounded on long-lived
+machines by removing
+
+  sync records   entries of the state file whose kubeconfig no longer exists,
+                 that were last synced more than --max-age ago, or beyond the
+                 newest --max-records
+  tokens         files in --token-cache-dir whose ID token expired without a
+                 refresh token, or that were not refreshed within --max-age
+  env files      kubeconfigs written by cloudctl env whose context is gone
+                 from the kubeconfig or that are older than --max-age
+
+A zero limit is not applied.
+
+Examples:
+  # Preview what would be removed
+  cloudctl state compact --dry-run
+
+  # Forget everything not used for a month
+  cloudctl state compact --max-age 720h`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runStateCompact,
+}
+
+func init() {
+	stateCmd.AddCommand(stateCompactCmd)
+	stateCompactCmd.Flags().Duration("max-age", 90*24*time.Hour, "Remove records, tokens and env files not used for this long (0 for no limit)")
+	stateCompactCmd.Flags().Int("max-records", 50, "Keep at most this many sync records, newest first (0 for no limit)")
+	stateCompactCmd.Flags().String("token-cache-dir", defaultCloudctlTokenCacheDir(), "Directory of the cloudctl token cache")
+	stateCompactCmd.Flags().Bool("dry-run", false, "Show what would be removed without removing anything")
+}
+
+// stateLimits bounds what state compact keeps. Zero fields are not applied.
+type stateLimits struct {
+	MaxAge     time.Duration
+	MaxRecords int
+}
+
+// compactSyncRecords removes the records of st that limits do not keep and
+// returns what it removed.
+func compactSyncRecords(st *syncState, limits stateLimits, now time.Time) []output.StateCompactEntry {
+	slices.SortStableFunc(st.Syncs, func(a, b sourceState) int { return b.LastSync.Compare(a.LastSync) })
+	var removed []output.StateCompactEntry
+	var kept []sourceState
+	for _, s := range st.Syncs {
+		name := s.Namespace + " -> " + s.Kubeconfig
+		reason := ""
+		switch _, err := os.Stat(s.Kubeconfig); {
+		case errors.Is(err, fs.ErrNotExist):
+			reason = "kubeconfig no longer exists"
+		case limits.MaxAge > 0 && now.Sub(s.LastSync) > limits.MaxAge:
+			reason = "last synced " + s.LastSync.Format(time.DateOnly)
+		case limits.MaxRecords > 0 && len(kept) >= limits.MaxRecords:
+			reason = fmt.Sprintf("beyond the newest %d records", limits.MaxRecords)
+		}
+		if reason != "" {
+			removed = append(removed, output.StateCompactEntry{Kind: output.StateCompactSyncRecord, Name: name, Reason: reason})
+			continue
+		}
+		kept = append(kept, s)
+	}
+	st.Syncs = kept
+	return removed
+}
+
+// staleEnvKubeconfigs returns the env kubeconfigs in dir that are older than
+// maxAge or whose context contexts does not contain. A nil contexts skips the
+// latter check.
+func staleEnvKubeconfigs(dir string, contexts map[string]bool, maxAge time.Duration, now time.Time) ([]output.StateCompactEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stale []output.StateCompactEntry
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".yaml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		reason := ""
+		if maxAge > 0 && now.Sub(info.ModTime()) > maxAge {
+			reason = "written " + info.ModTime().Format(time.DateOnly)
+		} else if contexts != nil {
+			cfg, err := clientcmd.LoadFromFile(path)
+			if err != nil || !contexts[cfg.CurrentContext] {
+				reason = "context no longer exists"
+			}
+		}
+		if reason != "" {
+			stale = append(stale, output.StateCompactEntry{Kind: output.StateCompactEnvFile, Name: path, Reason: reason})
+		}
+	}
+	return stale, nil
+}
+
+func runStateCompact(cmd *cobra.Command, _ []string) error {
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+	limits := stateLimits{MaxAge: viper.GetDuration("max-age"), MaxRecords: viper.GetInt("max-records")}
+	if limits.MaxAge < 0 {
+		return fmt.Errorf("--max-age must not be negative, got %s", limits.MaxAge)
+	}
+	if limits.MaxRecords < 0 {
+		return fmt.Errorf("--max-records must not be negative, got %d", limits.MaxRecords)
+	}
+	dryRun := viper.GetBool("dry-run")
+	now := time.Now()
+	result := output.StateCompactResult{DryRun: dryRun}
+
+	st, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	records := compactSyncRecords(st, limits, now)
+	result.Removed = append(result.Removed, records...)
+	if !dryRun && len(records) > 0 {
+		if err := st.save(); err != nil {
+			return err
+		}
+	}
+
+	var files []output.StateCompactEntry
+	stale, err := oidc.Cache{Dir: viper.GetString("token-cache-dir")}.Stale(now, limits.MaxAge)
+	if err != nil {
+		return fmt.Errorf("failed to list cached tokens: %w", err)
+	}
+	for _, path := range stale {
+		files = append(files, output.StateCompactEntry{Kind: output.StateCompactToken, Name: path, Reason: "expired"})
+	}
+
+	envDir, err := envKubeconfigDir()
+	if err != nil {
+		return err
+	}
+	// Env files are only checked against the kubeconfig when it can be read,
+	// so a broken kubeconfig does not remove all of them.
+	var contexts map[string]bool
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	if cfg, err := kubeconfigPathOptions(path).GetStartingConfig(); err == nil {
+		contexts = make(map[string]bool, len(cfg.Contexts))
+		for name := range cfg.Contexts {
+			contexts[name] = true
+		}
+	}
+	envFiles, err := staleEnvKubeconfigs(envDir, contexts, limits.MaxAge, now)
+	if err != nil {
+		return fmt.Errorf("failed to list env kubeconfigs: %w", err)
+	}
+	files = append(files, envFiles...)
+
+	for _, f := range files {
+		if !dryRun {
+			if err := os.Remove(f.Name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to remove %s: %w", f.Name, err)
+			}
+		}
+		result.Removed = append(result.Removed, f)
+	}
+	if !dryRun && len(result.Removed) > 0 {
+		slog.Info("compacted cloudctl state", "removed", len(result.Removed))
+	}
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(result)
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/oidc"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCompactSyncRecords(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(kubeconfig, nil, 0o600)).To(Succeed())
+	st := &syncState{Syncs: []sourceState{
+		{Kubeconfig: kubeconfig, Namespace: "old", LastSync: now.Add(-100 * 24 * time.Hour)},
+		{Kubeconfig: kubeconfig, Namespace: "a", LastSync: now.Add(-time.Hour)},
+		{Kubeconfig: filepath.Join(t.TempDir(), "gone"), Namespace: "gone", LastSync: now},
+		{Kubeconfig: kubeconfig, Namespace: "b", LastSync: now.Add(-2 * time.Hour)},
+		{Kubeconfig: kubeconfig, Namespace: "c", LastSync: now.Add(-3 * time.Hour)},
+	}}
+
+	removed := compactSyncRecords(st, stateLimits{MaxAge: 90 * 24 * time.Hour, MaxRecords: 2}, now)
+	g.Expect(removed).To(HaveLen(3))
+	g.Expect(removed).To(ContainElements(
+		HaveField("Reason", "kubeconfig no longer exists"),
+		HaveField("Reason", "beyond the newest 2 records"),
+		HaveField("Reason", "last synced 2030-02-21"),
+	))
+	g.Expect(st.Syncs).To(HaveLen(2))
+	g.Expect(st.Syncs[0].Namespace).To(Equal("a"))
+	g.Expect(st.Syncs[1].Namespace).To(Equal("b"))
+}
+
+func TestRunStateCompact(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	useTempSyncState(t)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{Server: "https://eu.example.com"}
+	cfg.Contexts["eu"] = &clientcmdapi.Context{Cluster: "cloudctl:eu"}
+	kubeconfig := writeTestKubeconfig(t, cfg)
+	st := &syncState{Syncs: []sourceState{
+		{Kubeconfig: kubeconfig, Namespace: "org", LastSync: time.Now()},
+		{Kubeconfig: filepath.Join(dir, "gone"), Namespace: "org", LastSync: time.Now()},
+	}}
+	g.Expect(st.save()).To(Succeed())
+
+	tokens := oidc.Cache{Dir: filepath.Join(dir, "tokens")}
+	expired := time.Now().Add(-time.Hour)
+	g.Expect(tokens.Save(oidc.Params{IssuerURL: "https://issuer", ClientID: "expired"}, &oidc.Token{IDToken: "id", Expiry: expired})).To(Succeed())
+	g.Expect(tokens.Save(oidc.Params{IssuerURL: "https://issuer", ClientID: "refreshable"}, &oidc.Token{IDToken: "id", RefreshToken: "r", Expiry: expired})).To(Succeed())
+
+	envDir, err := envKubeconfigDir()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(os.MkdirAll(envDir, 0o700)).To(Succeed())
+	for name, context := range map[string]string{"eu": "eu", "us": "us"} {
+		single := clientcmdapi.NewConfig()
+		single.CurrentContext = context
+		g.Expect(clientcmd.WriteToFile(*single, filepath.Join(envDir, name+".yaml"))).To(Succeed())
+	}
+
+	viper.Set("kubeconfig", kubeconfig)
+	viper.Set("output", "text")
+	viper.Set("max-age", 90*24*time.Hour)
+	viper.Set("max-records", 50)
+	viper.Set("token-cache-dir", tokens.Dir)
+	viper.Set("dry-run", true)
+	t.Cleanup(func() {
+		for _, key := range []string{"kubeconfig", "output", "max-age", "max-records", "token-cache-dir", "dry-run"} {
+			viper.Set(key, nil)
+		}
+	})
+	var out bytes.Buffer
+	stateCompactCmd.SetOut(&out)
+	t.Cleanup(func() { stateCompactCmd.SetOut(nil) })
+
+	g.Expect(runStateCompact(stateCompactCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Would remove 3 entries:"))
+	g.Expect(out.String()).To(ContainSubstring("env-file     " + filepath.Join(envDir, "us.yaml") + "  (context no longer exists)"))
+	g.Expect(filepath.Join(envDir, "us.yaml")).To(BeAnExistingFile())
+
+	viper.Set("dry-run", false)
+	out.Reset()
+	g.Expect(runStateCompact(stateCompactCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Removed 3 entries:"))
+	g.Expect(filepath.Join(envDir, "us.yaml")).NotTo(BeAnExistingFile())
+	g.Expect(filepath.Join(envDir, "eu.yaml")).To(BeAnExistingFile())
+	remaining, err := tokens.Stale(time.Now(), 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(remaining).To(BeEmpty())
+	tok, err := tokens.Load(oidc.Params{IssuerURL: "https://issuer", ClientID: "refreshable"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tok).NotTo(BeNil())
+	loaded, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(loaded.Syncs).To(ConsistOf(HaveField("Kubeconfig", kubeconfig)))
+
+	out.Reset()
+	g.Expect(runStateCompact(stateCompactCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Nothing to compact."))
+}
@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// syncState is what sync remembers between runs. It is stored as JSON in the
+// cloudctl state directory and read by status, the stale-kubeconfig warning
+// of use, and sync itself to skip merges that cannot change anything.
+type syncState struct {
+	Syncs []sourceState `json:"syncs"`
+}
+
+// sourceState records the last successful sync of one Greenhouse namespace
+// into one local kubeconfig.
+type sourceState struct {
+	Kubeconfig string    `json:"kubeconfig"`
+	Endpoint   string    `json:"endpoint"`
+	Namespace  string    `json:"namespace"`
+	Prefix     string    `json:"prefix"`
+	LastSync   time.Time `json:"lastSync"`
+	Clusters   int       `json:"clusters"`
+	Ready      int       `json:"ready"`
+	// ResourceVersions maps each fetched ClusterKubeconfig to its
+	// resourceVersion.
+	ResourceVersions map[string]string `json:"resourceVersions,omitempty"`
+	// Fingerprint hashes the managed entries that were merged, including the
+	// settings that shape them such as --auth-type.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// SpecHashes maps each merged ClusterKubeconfig to the hash of its spec
+	// and labels, so entries that did not change can skip validation and
+	// comparison on the next sync.
+	SpecHashes map[string]string `json:"specHashes,omitempty"`
+}
+
+// syncStateFile returns the path of the state file in stateDir:
+// $XDG_STATE_HOME/cloudctl or ~/.local/state/cloudctl, and the local app data
+// directory on Windows. It is a variable so tests can keep state out of the
+// home directory.
+var syncStateFile = func() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// loadSyncState reads the state file. A missing file yields an empty state.
+func loadSyncState() (*syncState, error) {
+	path, err := syncStateFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state file: %w", err)
+	}
+	st := &syncState{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// save writes the state file, replacing it atomically.
+func (st *syncState) save() error {
+	path, err := syncStateFile()
+	if err != nil {
+		return fmt.Errorf("failed to locate state file: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// find returns the record of namespace on endpoint synced into kubeconfig,
+// or nil.
+func (st *syncState) find(kubeconfig, endpoint, namespace string) *sourceState {
+	for i := range st.Syncs {
+		s := &st.Syncs[i]
+		if s.Kubeconfig == kubeconfig && s.Endpoint == endpoint && s.Namespace == namespace {
+			return s
+		}
+	}
+	return nil
+}
+
+// forKubeconfig returns the records of syncs into kubeconfig.
+func (st *syncState) forKubeconfig(kubeconfig string) []sourceState {
+	var out []sourceState
+	for _, s := range st.Syncs {
+		if s.Kubeconfig == kubeconfig {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// record stores s, replacing an earlier record of the same source.
+func (st *syncState) record(s sourceState) {
+	if prev := st.find(s.Kubeconfig, s.Endpoint, s.Namespace); prev != nil {
+		*prev = s
+		return
+	}
+	st.Syncs = append(st.Syncs, s)
+}
+
+// forget removes the record of the same source as s.
+func (st *syncState) forget(s sourceState) {
+	st.Syncs = slices.DeleteFunc(st.Syncs, func(r sourceState) bool {
+		return r.Kubeconfig == s.Kubeconfig && r.Endpoint == s.Endpoint && r.Namespace == s.Namespace
+	})
+}
+
+// stateKubeconfigPath returns the form of path used as key in the state file.
+func stateKubeconfigPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// resourceVersions maps the names of items to their resourceVersions.
+func resourceVersions(items []v1alpha1.ClusterKubeconfig) map[string]string {
+	rvs := make(map[string]string, len(items))
+	for _, ckc := range items {
+		rvs[ckc.Name] = ckc.ResourceVersion
+	}
+	return rvs
+}
+
+// specHash hashes what the merged entries of ckc are built from: its spec and
+// its labels.
+func specHash(ckc v1alpha1.ClusterKubeconfig) string {
+	data, err := json.Marshal(struct {
+		Labels map[string]string              `json:"labels,omitempty"`
+		Spec   v1alpha1.ClusterKubeconfigSpec `json:"spec"`
+	}{ckc.Labels, ckc.Spec})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// specHashes maps the names of items to their spec hashes.
+func specHashes(items []v1alpha1.ClusterKubeconfig) map[string]string {
+	hashes := make(map[string]string, len(items))
+	for _, ckc := range items {
+		hashes[ckc.Name] = specHash(ckc)
+	}
+	return hashes
+}
+
+// unchangedSpecs returns the names of the items whose spec hash matches the
+// one recorded by the last sync prev. It returns nil when the local
+// kubeconfig was modified since, as its entries may no longer match.
+func unchangedSpecs(prev *sourceState, items []v1alpha1.ClusterKubeconfig) map[string]bool {
+	if prev == nil || len(prev.SpecHashes) == 0 || !untouchedSince(prev) {
+		return nil
+	}
+	unchanged := make(map[string]bool)
+	for _, ckc := range items {
+		if h, ok := prev.SpecHashes[ckc.Name]; ok && h != "" && h == specHash(ckc) {
+			unchanged[ckc.Name] = true
+		}
+	}
+	return unchanged
+}
+
+// incomingFingerprint hashes the managed entries about to be merged together
+// with the merge settings, so a changed flag forces a full merge.
+func incomingFingerprint(incoming *clientcmdapi.Config) (string, error) {
+	data, err := clientcmd.Write(*incoming)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(data)
+	_, _ = fmt.Fprintf(h, "\nmerge-identical-users=%t\nmerge-identical-clusters=%t\nprefix=%s\norg-context-names=%t\nprefix-from-label=%s\ncontext-renames=%v\n", mergeIdenticalUsers, mergeIdenticalClusters, prefix, orgContextNames, prefixFromLabel, contextRenames)
+	// Only extra keys are hashed, keeping fingerprints of earlier syncs valid.
+	if len(volatileAuthKeys) > len(defaultVolatileAuthKeys) {
+		_, _ = fmt.Fprintf(h, "volatile-auth-keys=%v\n", volatileAuthKeys)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// syncIsCurrent reports whether merging can be skipped: the fetched
+// ClusterKubeconfigs and settings are those of the last sync prev and the
+// local kubeconfig has not been modified since.
+func syncIsCurrent(prev *sourceState, rvs map[string]string, fingerprint string) bool {
+	if prev == nil || prev.Fingerprint == "" || prev.Fingerprint != fingerprint || !maps.Equal(prev.ResourceVersions, rvs) {
+		return false
+	}
+	return untouchedSince(prev)
+}
+
+// untouchedSince reports whether the local kubeconfig of prev has not been
+// modified since that sync.
+func untouchedSince(prev *sourceState) bool {
+	info, err := os.Stat(prev.Kubeconfig)
+	return err == nil && info.ModTime().Before(prev.LastSync)
+}
+
+// previousSync returns the recorded sync of the current source into the local
+// kubeconfig, or nil.
+func previousSync() *sourceState {
+	return lookupSync(greenhouseEndpoint, greenhouseClusterNamespace)
+}
+
+// lookupSync returns the recorded sync of namespace on endpoint into the
+// local kubeconfig, or nil. Single-cluster syncs are neither recorded nor
+// compared.
+func lookupSync(endpoint, namespace string) *sourceState {
+	if remoteClusterName != "" {
+		return nil
+	}
+	target, err := resolveWriteTarget(remoteClusterKubeconfig)
+	if err != nil {
+		return nil
+	}
+	st, err := loadSyncState()
+	if err != nil {
+		slog.Warn("ignoring sync state", "error", err)
+		return nil
+	}
+	return st.find(stateKubeconfigPath(target), endpoint, namespace)
+}
+
+// recordSync stores a successful sync of the current source in the state
+// file; ready are the items that were merged. Failures are only logged; the
+// kubeconfig is what matters.
+func recordSync(items, ready []v1alpha1.ClusterKubeconfig, fingerprint string) {
+	if dryRun || remoteClusterName != "" {
+		return
+	}
+	target, err := resolveWriteTarget(remoteClusterKubeconfig)
+	if err != nil {
+		return
+	}
+	st, err := loadSyncState()
+	if err != nil {
+		slog.Warn("failed to update sync state", "error", err)
+		return
+	}
+	st.record(sourceState{
+		Kubeconfig:       stateKubeconfigPath(target),
+		Endpoint:         greenhouseEndpoint,
+		Namespace:        greenhouseClusterNamespace,
+		Prefix:           prefix,
+		LastSync:         time.Now().UTC(),
+		Clusters:         len(items),
+		Ready:            len(ready),
+		ResourceVersions: resourceVersions(items),
+		Fingerprint:      fingerprint,
+		SpecHashes:       specHashes(ready),
+	})
+	if err := st.save(); err != nil {
+		slog.Warn("failed to update sync state", "error", err)
+	}
+}
+
+// syncAgeDays returns how many whole days ago last was.
+func syncAgeDays(last, now time.Time) int {
+	return int(now.Sub(last) / (24 * time.Hour))
+}
+
+// defaultStaleAfter is the age from which a synced kubeconfig is reported as
+// stale.
+const defaultStaleAfter = 7 * 24 * time.Hour
+
+// recordedSyncs returns the state-file key of the local kubeconfig at path
+// (empty for the KUBECONFIG chain) and the syncs recorded into it.
+func recordedSyncs(path string) (string, []sourceState, error) {
+	target, err := resolveWriteTarget(path)
+	if err != nil {
+		target = clientcmd.RecommendedHomeFile
+	}
+	key := stateKubeconfigPath(target)
+	st, err := loadSyncState()
+	if err != nil {
+		return key, nil, err
+	}
+	return key, st.forKubeconfig(key), nil
+}
+
+// warnIfStale logs a warning when contextName of cfg was written by a sync
+// into the local kubeconfig at path that is older than the stale-after
+// setting. Sync prefixes the cluster of a context, not the context itself.
+func warnIfStale(path string, cfg *clientcmdapi.Config, contextName string) {
+	kctx := cfg.Contexts[contextName]
+	if kctx == nil {
+		return
+	}
+	_, syncs, err := recordedSyncs(path)
+	if err != nil {
+		return
+	}
+	for _, s := range syncs {
+		if !strings.HasPrefix(kctx.Cluster, s.Prefix+":") {
+			continue
+		}
+		if time.Since(s.LastSync) > cmp.Or(viper.GetDuration("stale-after"), defaultStaleAfter) {
+			slog.Warn("kubeconfig has not been synced recently, run cloudctl sync", "namespace", s.Namespace, "daysOld", syncAgeDays(s.LastSync, time.Now()), "lastSync", s.LastSync)
+		}
+		return
+	}
+}
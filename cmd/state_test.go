@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// useTempSyncState points the state file into a temporary directory.
+func useTempSyncState(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cloudctl", "state.json")
+	orig := syncStateFile
+	syncStateFile = func() (string, error) { return path, nil }
+	t.Cleanup(func() { syncStateFile = orig })
+	return path
+}
+
+func TestSyncState_SaveLoadRecord(t *testing.T) {
+	g := NewWithT(t)
+	useTempSyncState(t)
+
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(st.Syncs).To(BeEmpty(), "a missing file is an empty state")
+
+	st.record(sourceState{Kubeconfig: "/k", Endpoint: "https://gh", Namespace: "org-a", Clusters: 1})
+	st.record(sourceState{Kubeconfig: "/k", Endpoint: "https://gh", Namespace: "org-b", Clusters: 2})
+	st.record(sourceState{Kubeconfig: "/k", Endpoint: "https://gh", Namespace: "org-a", Clusters: 3})
+	g.Expect(st.save()).To(Succeed())
+
+	st, err = loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(st.Syncs).To(HaveLen(2))
+	g.Expect(st.find("/k", "https://gh", "org-a")).To(HaveField("Clusters", 3))
+	g.Expect(st.find("/other", "https://gh", "org-a")).To(BeNil())
+	g.Expect(st.forKubeconfig("/k")).To(HaveLen(2))
+}
+
+func TestSyncIsCurrent(t *testing.T) {
+	g := NewWithT(t)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(kubeconfig, []byte("apiVersion: v1\n"), 0o600)).To(Succeed())
+	mtime := time.Now().Add(-time.Hour)
+	g.Expect(os.Chtimes(kubeconfig, mtime, mtime)).To(Succeed())
+
+	rvs := map[string]string{"eu": "10"}
+	prev := &sourceState{Kubeconfig: kubeconfig, LastSync: time.Now(), ResourceVersions: rvs, Fingerprint: "abc"}
+	g.Expect(syncIsCurrent(prev, map[string]string{"eu": "10"}, "abc")).To(BeTrue())
+
+	g.Expect(syncIsCurrent(nil, rvs, "abc")).To(BeFalse())
+	g.Expect(syncIsCurrent(prev, map[string]string{"eu": "11"}, "abc")).To(BeFalse(), "a ClusterKubeconfig changed")
+	g.Expect(syncIsCurrent(prev, map[string]string{"eu": "10", "us": "1"}, "abc")).To(BeFalse(), "a cluster was added")
+	g.Expect(syncIsCurrent(prev, rvs, "def")).To(BeFalse(), "settings changed")
+
+	g.Expect(os.Chtimes(kubeconfig, time.Now().Add(time.Minute), time.Now().Add(time.Minute))).To(Succeed())
+	g.Expect(syncIsCurrent(prev, rvs, "abc")).To(BeFalse(), "the kubeconfig was edited since")
+}
+
+func TestUnchangedSpecs(t *testing.T) {
+	g := NewWithT(t)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(kubeconfig, []byte("apiVersion: v1\n"), 0o600)).To(Succeed())
+	mtime := time.Now().Add(-time.Hour)
+	g.Expect(os.Chtimes(kubeconfig, mtime, mtime)).To(Succeed())
+
+	ckc := func(name, server string) greenhousev1alpha1.ClusterKubeconfig {
+		return greenhousev1alpha1.ClusterKubeconfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"region": "eu"}},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: name, Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: server}}},
+			}},
+		}
+	}
+	synced := []greenhousev1alpha1.ClusterKubeconfig{ckc("eu", "https://eu"), ckc("us", "https://us")}
+	prev := &sourceState{Kubeconfig: kubeconfig, LastSync: time.Now(), SpecHashes: specHashes(synced)}
+
+	relabeled := ckc("us", "https://us")
+	relabeled.Labels["region"] = "us"
+	g.Expect(unchangedSpecs(prev, []greenhousev1alpha1.ClusterKubeconfig{ckc("eu", "https://eu"), relabeled, ckc("ap", "https://ap")})).
+		To(Equal(map[string]bool{"eu": true}), "relabeled and new clusters are compared")
+	g.Expect(unchangedSpecs(prev, []greenhousev1alpha1.ClusterKubeconfig{ckc("eu", "https://eu2")})).To(BeEmpty())
+	g.Expect(unchangedSpecs(nil, synced)).To(BeNil())
+
+	g.Expect(os.Chtimes(kubeconfig, time.Now().Add(time.Minute), time.Now().Add(time.Minute))).To(Succeed())
+	g.Expect(unchangedSpecs(prev, synced)).To(BeNil(), "the kubeconfig was edited since")
+}
+
+func TestMergeKubeconfig_SkipsUnchangedClusters(t *testing.T) {
+	g := NewWithT(t)
+	origPrefix := prefix
+	prefix = "cloudctl"
+	t.Cleanup(func() { prefix, unchangedClusters = origPrefix, nil })
+
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.AuthInfos["user"] = &clientcmdapi.AuthInfo{Token: "t"}
+	for _, name := range []string{"eu", "us"} {
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + "-new"}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: "user"}
+	}
+	localConfig := clientcmdapi.NewConfig()
+	localConfig.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{Server: "https://eu"}
+	localConfig.Clusters["cloudctl:us"] = &clientcmdapi.Cluster{Server: "https://us"}
+
+	unchangedClusters = map[string]bool{"eu": true}
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Clusters["cloudctl:eu"].Server).To(Equal("https://eu"), "unchanged entries are not compared")
+	g.Expect(localConfig.Clusters["cloudctl:us"].Server).To(Equal("https://us-new"))
+}
+
+func TestRunStatus(t *testing.T) {
+	g := NewWithT(t)
+	useTempSyncState(t)
+	path := writeTestKubeconfig(t, newManagedCfg("eu"))
+	viper.Set("stale-after", 7*24*time.Hour)
+	t.Cleanup(func() { viper.Set("stale-after", nil) })
+
+	var out bytes.Buffer
+	statusCmd.SetOut(&out)
+	t.Cleanup(func() { statusCmd.SetOut(nil) })
+
+	g.Expect(runStatus(statusCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("No sync recorded for " + path))
+
+	st := &syncState{}
+	st.record(sourceState{
+		Kubeconfig: path,
+		Endpoint:   "https://greenhouse.example",
+		Namespace:  "my-org",
+		Prefix:     "cloudctl",
+		LastSync:   time.Now().Add(-14*24*time.Hour - time.Hour),
+		Clusters:   3,
+		Ready:      2,
+	})
+	g.Expect(st.save()).To(Succeed())
+
+	out.Reset()
+	g.Expect(runStatus(statusCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("my-org (prefix cloudctl) from https://greenhouse.example"))
+	g.Expect(out.String()).To(ContainSubstring("clusters:  3 (2 ready)"))
+	g.Expect(out.String()).To(ContainSubstring("your kubeconfig is 14 days old"))
+
+	// --group-by counts the managed clusters per label value.
+	cfg, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	cfg.Clusters["cloudctl:eu"].Extensions = map[string]runtime.Object{"labels": &runtime.Unknown{Raw: []byte(`{"region":"eu-de-1"}`)}}
+	cfg.Clusters["cloudctl:ap"] = &clientcmdapi.Cluster{Server: "https://ap.example"}
+	g.Expect(clientcmd.WriteToFile(*cfg, path)).To(Succeed())
+	viper.Set("group-by", "region")
+	t.Cleanup(func() { viper.Set("group-by", nil) })
+	out.Reset()
+	g.Expect(runStatus(statusCmd, nil)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("      region=eu-de-1: 1\n      region=<none>: 1\n"))
+}
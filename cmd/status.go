@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show when the local kubeconfig was last synced",
+	Long: `Shows what sync recorded about the local kubeconfig: the Greenhouse
+API server and organization of each source, when it was last synced
+successfully, and how many clusters it had. Syncs older than --stale-after are
+flagged, since their clusters and credentials may be outdated.
+
+The records are kept in $XDG_STATE_HOME/cloudctl/state.json
+(~/.local/state/cloudctl/state.json by default).
+
+With --group-by, the clusters of each sync in the local kubeconfig are counted
+per value of a label (e.g. region or environment), read from the labels sync
+stores with every managed cluster.
+
+Examples:
+  cloudctl status
+  cloudctl status --stale-after 72h -o json
+  cloudctl status --group-by region`,
+	Args:    cobra.NoArgs,
+	PreRunE: bindCommandFlags,
+	RunE:    runStatus,
+}
+
+func init() {
+	statusCmd.Flags().Duration("stale-after", defaultStaleAfter, "Flag syncs older than this")
+	statusCmd.Flags().String("group-by", "", "Count the clusters of each sync per value of this label key (e.g. region)")
+}
+
+// prefixClusterGroups counts the managed clusters of prefix in cfg per value
+// of the label key, sorted by value with clusters lacking the label last.
+// Ready is not counted, as the kubeconfig does not record readiness.
+func prefixClusterGroups(cfg *clientcmdapi.Config, prefix, key string) []output.ClusterGroup {
+	var groups []output.ClusterGroup
+	for name, cluster := range cfg.Clusters {
+		if strings.HasPrefix(name, prefix+":") {
+			groups = countClusterGroup(groups, clusterLabels(cluster)[key], false)
+		}
+	}
+	slices.SortFunc(groups, func(a, b output.ClusterGroup) int {
+		return compareLabel(map[string]string{key: a.Value}, map[string]string{key: b.Value}, key)
+	})
+	return groups
+}
+
+func runStatus(cmd *cobra.Command, _ []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	staleAfter := viper.GetDuration("stale-after")
+	if staleAfter <= 0 {
+		return fmt.Errorf("--stale-after must be positive, got %s", staleAfter)
+	}
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	key, syncs, err := recordedSyncs(path)
+	if err != nil {
+		return err
+	}
+	groupBy := viper.GetString("group-by")
+	var cfg *clientcmdapi.Config
+	if groupBy != "" {
+		if cfg, err = kubeconfigPathOptions(path).GetStartingConfig(); err != nil {
+			return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+		}
+	}
+	now := time.Now()
+	result := output.StatusResult{Kubeconfig: key, GroupBy: groupBy, Syncs: []output.SyncStatus{}}
+	for _, s := range syncs {
+		status := output.SyncStatus{
+			Endpoint:  s.Endpoint,
+			Namespace: s.Namespace,
+			Prefix:    s.Prefix,
+			LastSync:  s.LastSync,
+			AgeDays:   syncAgeDays(s.LastSync, now),
+			Stale:     now.Sub(s.LastSync) > staleAfter,
+			Clusters:  s.Clusters,
+			Ready:     s.Ready,
+		}
+		if cfg != nil {
+			status.Groups = prefixClusterGroups(cfg, s.Prefix, groupBy)
+		}
+		result.Syncs = append(result.Syncs, status)
+	}
+
+	w := cmd.OutOrStdout()
+	return output.New(format, output.IsTTYWriter(w), w).Print(result)
+}
@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+// verifyEndpoint is requested by sync --verify without credentials, so no
+// login is triggered. Any HTTP answer, including 401 and 403, shows that the
+// API server can be reached.
+const verifyEndpoint = "/readyz"
+
+// verifyConcurrency bounds the contexts sync --verify probes in parallel.
+const verifyConcurrency = 10
+
+// updatedContexts returns the contexts of cfg that diff added or changed,
+// directly or through their cluster entry, sorted.
+func updatedContexts(cfg *clientcmdapi.Config, diff KubeconfigDiff) []string {
+	clusters := map[string]bool{}
+	for _, d := range diff.Clusters {
+		if d.ChangeType != DiffChangeRemoved {
+			clusters[d.Name] = true
+		}
+	}
+	names := map[string]bool{}
+	for _, d := range diff.Contexts {
+		if d.ChangeType != DiffChangeRemoved {
+			names[d.Name] = true
+		}
+	}
+	for name, c := range cfg.Contexts {
+		if c != nil && clusters[c.Cluster] {
+			names[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// verifySyncedContexts probes the API server of each updated context, keyed
+// by the kubeconfig it was written to, routed through its tunnel.
+func verifySyncedContexts(ctx context.Context, updated map[string][]string, timeout time.Duration) (output.SyncVerifyResult, error) {
+	result := output.SyncVerifyResult{Endpoint: verifyEndpoint, Contexts: []output.SyncVerifyContext{}}
+	tun, err := newTunnels()
+	if err != nil {
+		return result, err
+	}
+	defer tun.Close()
+
+	for _, path := range slices.Sorted(maps.Keys(updated)) {
+		cfg, err := clientcmd.LoadFromFile(path)
+		if err != nil {
+			return result, fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+		}
+		names := updated[path]
+		contexts := make([]output.SyncVerifyContext, len(names))
+		index := make(map[string]int, len(names))
+		for i, name := range names {
+			index[name] = i
+			contexts[i] = output.SyncVerifyContext{Context: name, Error: "not probed"}
+		}
+		forEachContext(ctx, names, verifyConcurrency, func(ctx context.Context, name string) {
+			contexts[index[name]] = probeContext(ctx, path, name, tun, contextClusterLabels(cfg, name), timeout)
+		})
+		result.Contexts = append(result.Contexts, contexts...)
+	}
+	return result, ctx.Err()
+}
+
+// probeContext requests verifyEndpoint from the server of one context.
+func probeContext(ctx context.Context, path, contextName string, tun *tunnels, labels map[string]string, timeout time.Duration) output.SyncVerifyContext {
+	result := output.SyncVerifyContext{Context: contextName}
+	restCfg, err := configWithContext(contextName, path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Server = restCfg.Host
+	restCfg.Timeout = timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := tun.apply(ctx, restCfg, labels); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	client, err := unauthenticatedClient(restCfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	s, err := pingOnce(ctx, client, strings.TrimRight(restCfg.Host, "/")+verifyEndpoint)
+	if err != nil {
+		slog.Debug("context not reachable", "context", contextName, "error", err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Reachable = true
+	result.LatencyMs = float64(s.Total.Microseconds()) / 1000
+	return result
+}
+
+// reportVerify runs sync --verify on the updated contexts and prints the
+// result on stderr, like --benchmark, so stdout keeps the sync result alone.
+// Unreachable servers fail the command.
+func reportVerify(cmd *cobra.Command, format output.Format, updated map[string][]string) error {
+	total := 0
+	for _, names := range updated {
+		total += len(names)
+	}
+	if total == 0 {
+		slog.Info("no contexts were added or changed, nothing to verify")
+		return nil
+	}
+	errW := cmd.ErrOrStderr()
+	printer := output.New(format, output.IsTTYWriter(errW), errW)
+	stop := printer.StartSpinner(fmt.Sprintf("Verifying %d context(s)...", total))
+	result, err := verifySyncedContexts(cmd.Context(), updated, viper.GetDuration("verify-timeout"))
+	stop()
+	if err != nil {
+		return err
+	}
+	if err := printer.Print(result); err != nil {
+		return err
+	}
+	unreachable := 0
+	for _, c := range result.Contexts {
+		if !c.Reachable {
+			unreachable++
+		}
+	}
+	if unreachable > 0 {
+		return fmt.Errorf("%d of %d synced context(s) could not be reached", unreachable, total)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+func TestUpdatedContexts(t *testing.T) {
+	g := NewWithT(t)
+	cfg := clientcmdapi.NewConfig()
+	cfg.Contexts["cloudctl:eu"] = &clientcmdapi.Context{Cluster: "cloudctl:eu"}
+	cfg.Contexts["cloudctl:eu-admin"] = &clientcmdapi.Context{Cluster: "cloudctl:eu"}
+	cfg.Contexts["cloudctl:us"] = &clientcmdapi.Context{Cluster: "cloudctl:us"}
+	cfg.Contexts["cloudctl:ap"] = &clientcmdapi.Context{Cluster: "cloudctl:ap"}
+
+	// A changed cluster entry affects every context using it.
+	g.Expect(updatedContexts(cfg, KubeconfigDiff{
+		Clusters: []EntryDiff{{Name: "cloudctl:eu", ChangeType: DiffChangeModified}, {Name: "cloudctl:old", ChangeType: DiffChangeRemoved}},
+		Contexts: []EntryDiff{{Name: "cloudctl:us", ChangeType: DiffChangeAdded}, {Name: "cloudctl:old", ChangeType: DiffChangeRemoved}},
+	})).To(Equal([]string{"cloudctl:eu", "cloudctl:eu-admin", "cloudctl:us"}))
+	g.Expect(updatedContexts(cfg, KubeconfigDiff{})).To(BeEmpty())
+}
+
+func TestReportVerify(t *testing.T) {
+	g := NewWithT(t)
+	path := writeTestKubeconfig(t, newManagedCfg("eu", "dark"))
+	viper.Set("verify-timeout", time.Second)
+	t.Cleanup(func() { viper.Set("verify-timeout", nil) })
+
+	prev := pingOnce
+	pingOnce = func(_ context.Context, _ *http.Client, url string) (pingSample, error) {
+		if url == "https://eu.example/readyz" {
+			return pingSample{Total: 12 * time.Millisecond}, nil
+		}
+		return pingSample{}, errors.New("i/o timeout")
+	}
+	t.Cleanup(func() { pingOnce = prev })
+
+	var errOut bytes.Buffer
+	syncCmd.SetErr(&errOut)
+	syncCmd.SetContext(context.Background())
+	t.Cleanup(func() { syncCmd.SetErr(nil) })
+
+	err := reportVerify(syncCmd, output.FormatText, map[string][]string{path: {"dark", "eu"}})
+	g.Expect(err).To(MatchError("1 of 2 synced context(s) could not be reached"))
+	g.Expect(errOut.String()).To(Equal("" +
+		"CONTEXT  SERVER                STATUS\n" +
+		"dark     https://dark.example  unreachable\n" +
+		"eu       https://eu.example    reachable (12.0ms)\n" +
+		"\ndark: i/o timeout\n"))
+
+	errOut.Reset()
+	g.Expect(reportVerify(syncCmd, output.FormatText, map[string][]string{path: nil})).To(Succeed())
+	g.Expect(errOut.String()).To(BeEmpty())
+}
+
+func TestRunSync_VerifyValidation(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("verify", true)
+	viper.Set("verify-timeout", time.Second)
+	t.Cleanup(func() {
+		viper.Set("verify", nil)
+		viper.Set("verify-timeout", nil)
+		viper.Set("dry-run", nil)
+		viper.Set("watch", nil)
+		viper.Set("interval", nil)
+	})
+
+	viper.Set("watch", true)
+	viper.Set("interval", time.Minute)
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--verify cannot be combined with --watch")))
+
+	viper.Set("watch", false)
+	viper.Set("dry-run", true)
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--verify cannot be combined with --dry-run")))
+
+	viper.Set("dry-run", false)
+	viper.Set("verify-timeout", 0)
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--verify-timeout must be positive")))
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// watchedClient answers ListClusterKubeconfigs for one namespace from a cache
+// that a watch keeps current, so --watch cycles cost no list requests and,
+// through the fast paths of syncOnce, only merge what changed. Until the
+// cache is filled, and while the watch is broken, lists go to the wrapped
+// client.
+type watchedClient struct {
+	greenhouse.Client
+	watcher   greenhouse.Watcher
+	namespace string
+	// changed is signalled, without blocking, when the cache changes.
+	changed chan<- struct{}
+
+	mu              sync.Mutex
+	items           map[string]v1alpha1.ClusterKubeconfig
+	resourceVersion string
+	current         bool
+}
+
+// newWatchedClient wraps ghClient for namespace, or returns nil when it
+// cannot watch.
+func newWatchedClient(ghClient greenhouse.Client, namespace string, changed chan<- struct{}) *watchedClient {
+	watcher, ok := ghClient.(greenhouse.Watcher)
+	if !ok {
+		return nil
+	}
+	return &watchedClient{Client: ghClient, watcher: watcher, namespace: namespace, changed: changed}
+}
+
+func (c *watchedClient) ListClusterKubeconfigs(ctx context.Context, namespace string) ([]v1alpha1.ClusterKubeconfig, error) {
+	c.mu.Lock()
+	if namespace != c.namespace || !c.current {
+		c.mu.Unlock()
+		return c.Client.ListClusterKubeconfigs(ctx, namespace)
+	}
+	items := slices.SortedFunc(maps.Values(c.items), func(a, b v1alpha1.ClusterKubeconfig) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	c.mu.Unlock()
+	return items, nil
+}
+
+// run keeps the cache current until ctx is cancelled. A failed list or watch
+// is retried after retry.
+func (c *watchedClient) run(ctx context.Context, retry time.Duration) {
+	for ctx.Err() == nil {
+		err := c.watchOnce(ctx)
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+		c.invalidate()
+		if errors.Is(err, greenhouse.ErrWatchUnsupported) {
+			slog.Info("ClusterKubeconfigs cannot be watched, listing them every cycle", "namespace", c.namespace)
+			return
+		}
+		slog.Warn("failed to watch ClusterKubeconfigs, listing them every cycle until it recovers", "namespace", c.namespace, "error", err, "retry", retry)
+		select {
+		case <-ctx.Done():
+		case <-time.After(retry):
+		}
+	}
+}
+
+// watchOnce lists the ClusterKubeconfigs unless the cache is current and
+// applies the changes after it until the watch ends. A watch closed by the
+// server is resumed from the last resourceVersion seen; one that expired
+// starts over from a new list.
+func (c *watchedClient) watchOnce(ctx context.Context) error {
+	c.mu.Lock()
+	rv, current := c.resourceVersion, c.current
+	c.mu.Unlock()
+	if !current {
+		items, listRV, err := c.watcher.ListClusterKubeconfigsForWatch(ctx, c.namespace)
+		if err != nil {
+			return err
+		}
+		c.reset(items, listRV)
+		rv = listRV
+	}
+
+	w, err := c.watcher.WatchClusterKubeconfigs(ctx, c.namespace, rv)
+	if greenhouse.WatchExpired(err) {
+		slog.Debug("ClusterKubeconfig watch expired, listing again", "namespace", c.namespace, "resourceVersion", rv)
+		c.invalidate()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			err := c.apply(e)
+			if greenhouse.WatchExpired(err) {
+				slog.Debug("ClusterKubeconfig watch expired, listing again", "namespace", c.namespace)
+				c.invalidate()
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// apply updates the cache with a watch event.
+func (c *watchedClient) apply(e k8swatch.Event) error {
+	if e.Type == k8swatch.Error {
+		return apierrors.FromObject(e.Object)
+	}
+	ckc, ok := e.Object.(*v1alpha1.ClusterKubeconfig)
+	if !ok {
+		return fmt.Errorf("unexpected %T in ClusterKubeconfig watch", e.Object)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ckc.ResourceVersion != "" {
+		c.resourceVersion = ckc.ResourceVersion
+	}
+	switch e.Type {
+	case k8swatch.Added, k8swatch.Modified:
+		c.items[ckc.Name] = *ckc
+	case k8swatch.Deleted:
+		delete(c.items, ckc.Name)
+	default:
+		return nil
+	}
+	slog.Debug("ClusterKubeconfig changed", "namespace", c.namespace, "name", ckc.Name, "event", e.Type)
+	c.notify()
+	return nil
+}
+
+// reset replaces the cache with a fresh list.
+func (c *watchedClient) reset(items []v1alpha1.ClusterKubeconfig, resourceVersion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := make(map[string]v1alpha1.ClusterKubeconfig, len(items))
+	for _, ckc := range items {
+		next[ckc.Name] = ckc
+	}
+	changed := c.items != nil && !maps.EqualFunc(c.items, next, func(a, b v1alpha1.ClusterKubeconfig) bool {
+		return a.ResourceVersion == b.ResourceVersion
+	})
+	c.items, c.resourceVersion, c.current = next, resourceVersion, true
+	if changed {
+		c.notify()
+	}
+}
+
+// invalidate sends lists to the wrapped client until the next reset.
+func (c *watchedClient) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = false
+}
+
+// notify signals changed unless a signal is already pending. c.mu is held.
+func (c *watchedClient) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// stubWatcher lists items and hands out the fake watches in watches, one per
+// call, recording the resourceVersions it was asked to watch from.
+type stubWatcher struct {
+	greenhouse.Client
+
+	mu      sync.Mutex
+	items   []v1alpha1.ClusterKubeconfig
+	lists   int
+	watches chan *k8swatch.FakeWatcher
+	from    []string
+}
+
+func (s *stubWatcher) ListClusterKubeconfigs(context.Context, string) ([]v1alpha1.ClusterKubeconfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items, nil
+}
+
+func (s *stubWatcher) ListClusterKubeconfigsForWatch(context.Context, string) ([]v1alpha1.ClusterKubeconfig, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists++
+	return s.items, "10", nil
+}
+
+func (s *stubWatcher) WatchClusterKubeconfigs(ctx context.Context, _, resourceVersion string) (k8swatch.Interface, error) {
+	s.mu.Lock()
+	s.from = append(s.from, resourceVersion)
+	s.mu.Unlock()
+	select {
+	case w := <-s.watches:
+		return w, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *stubWatcher) calls() (lists int, from []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists, append([]string(nil), s.from...)
+}
+
+func watchedCKC(name, rv string) *v1alpha1.ClusterKubeconfig {
+	return &v1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "org", ResourceVersion: rv}}
+}
+
+func TestWatchedClient(t *testing.T) {
+	g := NewWithT(t)
+	stub := &stubWatcher{items: []v1alpha1.ClusterKubeconfig{*watchedCKC("b", "5"), *watchedCKC("a", "3")}, watches: make(chan *k8swatch.FakeWatcher)}
+	changed := make(chan struct{}, 1)
+	c := newWatchedClient(stub, "org", changed)
+	g.Expect(c).ToNot(BeNil())
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	names := func() []string {
+		items, err := c.ListClusterKubeconfigs(ctx, "org")
+		g.Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, ckc := range items {
+			names = append(names, ckc.Name)
+		}
+		return names
+	}
+	// Before the first list, lists go to the wrapped client.
+	g.Expect(names()).To(Equal([]string{"b", "a"}))
+
+	go c.run(ctx, time.Millisecond)
+	first := k8swatch.NewFake()
+	stub.watches <- first
+	g.Expect(names()).To(Equal([]string{"a", "b"}), "served sorted from the cache")
+
+	stub.mu.Lock()
+	stub.items = nil
+	stub.mu.Unlock()
+	first.Add(watchedCKC("c", "11"))
+	g.Eventually(changed).Should(Receive())
+	first.Delete(watchedCKC("a", "12"))
+	first.Action(k8swatch.Bookmark, watchedCKC("", "13"))
+	g.Eventually(names).Should(Equal([]string{"b", "c"}))
+
+	// A watch closed by the server resumes from the last resourceVersion.
+	first.Stop()
+	second := k8swatch.NewFake()
+	stub.watches <- second
+	lists, from := stub.calls()
+	g.Expect(lists).To(Equal(1))
+	g.Expect(from).To(Equal([]string{"10", "13"}))
+
+	// An expired watch lists again.
+	gone := apierrors.NewResourceExpired("too old").ErrStatus
+	second.Error(&gone)
+	stub.watches <- k8swatch.NewFake()
+	lists, from = stub.calls()
+	g.Expect(lists).To(Equal(2))
+	g.Expect(from).To(Equal([]string{"10", "13", "10"}))
+	g.Eventually(names).Should(BeEmpty())
+}
+
+func TestWatchedClient_Unsupported(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(newWatchedClient(struct{ greenhouse.Client }{}, "org", nil)).To(BeNil())
+}
@@ -5,80 +5,161 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
-	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
 	"github.com/cloudoperators/greenhouse/api/v1alpha1"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
 )
 
+// newGreenhouseClient builds the Greenhouse API client used by commands.
+// It is a variable so tests can substitute a fake implementation.
+var newGreenhouseClient = greenhouse.New
+
 var (
 	greenhouseClusterKubeconfig string
 	greenhouseClusterContext    string
 	greenhouseClusterNamespace  string
+	greenhouseEndpoint          string
 	remoteClusterKubeconfig     string
 	remoteClusterName           string
+	waitReady                   bool
+	waitReadyTimeout            time.Duration
 	prefix                      string
 	mergeIdenticalUsers         bool
+	orgContextNames             bool
+	prefixFromLabel             string
+	mergeIdenticalClusters      bool
 	authType                    string
 	kubeloginPath               string
 	kubeloginExtraArgs          []string
 	kubeloginTokenCacheDir      string
+	omitClientSecret            bool
+	convertLegacyAuth           bool
 	dryRun                      bool
+	verbose                     bool
+	watch                       bool
+	syncInterval                time.Duration
+	metricsAddress              string
+	myTeams                     bool
+	impersonateUser             string
+	impersonateGroups           []string
+	impersonateUID              string
+	greenhouseCAFile            string
+	greenhouseTLSServerName     string
+	greenhouseInsecure          bool
+	caExpiryWarnDays            int
+	backupKubeconfigs           bool
+	archiveRemoved              bool
+	backupPolicy                backupRetention
 )
 
 func init() {
-	syncCmd.Flags().StringVarP(&greenhouseClusterKubeconfig, "greenhouse-cluster-kubeconfig", "k", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig")
-	syncCmd.Flags().StringVarP(&greenhouseClusterContext, "greenhouse-cluster-context", "c", "", "Context to use from the Greenhouse kubeconfig (defaults to current context)")
-	syncCmd.Flags().StringVarP(&greenhouseClusterNamespace, "greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (required)")
-	if err := syncCmd.MarkFlagRequired("greenhouse-cluster-namespace"); err != nil {
-		panic(err)
-	}
+	syncCmd.Flags().StringVar(&greenhouseClusterKubeconfig, "greenhouse-cluster-kubeconfig", clientcmd.RecommendedHomeFile, "Path to the Greenhouse cluster kubeconfig (defaults to --kubeconfig)")
+	syncCmd.Flags().StringVar(&greenhouseClusterContext, "greenhouse-cluster-context", "", "Context to use from the Greenhouse kubeconfig (defaults to --context, then the current context)")
+	syncCmd.Flags().StringVarP(&greenhouseClusterNamespace, "greenhouse-cluster-namespace", "n", "", "Greenhouse organization namespace (default: discovered from the organizations you can access)")
 	syncCmd.Flags().StringVarP(&remoteClusterKubeconfig, "remote-cluster-kubeconfig", "r", clientcmd.RecommendedHomeFile, "Local kubeconfig file to merge into")
+	_ = syncCmd.Flags().MarkDeprecated("remote-cluster-kubeconfig", "use --kubeconfig instead")
 	syncCmd.Flags().StringVar(&remoteClusterName, "remote-cluster-name", "", "Sync only this cluster by name (default: all ready clusters)")
+	syncCmd.Flags().BoolVar(&waitReady, "wait-ready", false, "Wait for the --remote-cluster-name cluster to exist and become ready before merging it")
+	syncCmd.Flags().DurationVar(&waitReadyTimeout, "wait-ready-timeout", 10*time.Minute, "How long --wait-ready waits before giving up")
 	syncCmd.Flags().StringVar(&prefix, "prefix", "cloudctl", "Prefix applied to managed kubeconfig entries to avoid collisions")
 	syncCmd.Flags().BoolVar(&mergeIdenticalUsers, "merge-identical-users", true, "Deduplicate auth entries that share the same OIDC config (single login for all such clusters)")
+	syncCmd.Flags().Bool("show-shared-users", false, "Report which contexts share a user entry and the settings that made their users identical")
+	syncCmd.Flags().BoolVar(&mergeIdenticalClusters, "merge-identical-clusters", false, "Collapse clusters with the same server URL and CA into a single cluster entry shared by their contexts")
+	syncCmd.Flags().BoolVar(&orgContextNames, "org-context-names", false, "Name contexts <organization>/<context> so identically named clusters of different organizations do not collide")
+	syncCmd.Flags().StringVar(&prefixFromLabel, "prefix-from-label", "", "Name contexts <label value>:<context> after this ClusterKubeconfig label (e.g. region), grouping them in context listings")
 
 	// Authentication flags
-	syncCmd.Flags().StringVar(&authType, "auth-type", "exec-plugin", "Auth credential style: exec-plugin (kubelogin) or auth-provider (legacy)")
+	syncCmd.Flags().StringVar(&authType, "auth-type", "exec-plugin", "Auth credential style: exec-plugin (kubelogin), cloudctl (cloudctl get-token) or auth-provider (legacy)")
 	syncCmd.Flags().StringVar(&kubeloginPath, "kubelogin-path", "kubelogin", "Path to the kubelogin binary (used with --auth-type=exec-plugin)")
 	syncCmd.Flags().StringSliceVar(&kubeloginExtraArgs, "kubelogin-extra-args", nil, "Additional arguments passed to the kubelogin exec plugin")
-	defaultTokenCacheDir := os.Getenv("HOME")
-	if defaultTokenCacheDir == "" {
-		if home, err := os.UserHomeDir(); err == nil {
-			defaultTokenCacheDir = home
-		}
-	}
-	if defaultTokenCacheDir != "" {
-		defaultTokenCacheDir = filepath.Join(defaultTokenCacheDir, ".kube", "cache", "oidc-login")
-	} else {
-		defaultTokenCacheDir = filepath.Join("~", ".kube", "cache", "oidc-login")
-	}
-	syncCmd.Flags().StringVar(&kubeloginTokenCacheDir, "kubelogin-token-cache-dir", defaultTokenCacheDir, "Directory for OIDC token cache files")
+	syncCmd.Flags().StringVar(&kubeloginTokenCacheDir, "kubelogin-token-cache-dir", defaultTokenCacheDir(), "Directory for OIDC token cache files")
+	syncCmd.Flags().BoolVar(&omitClientSecret, "omit-client-secret", false, "Do not write the OIDC client secret into the kubeconfig (for public clients using PKCE)")
+	syncCmd.Flags().BoolVar(&convertLegacyAuth, "convert-legacy-auth", false, "Replace users of the azure and gcp auth providers, which kubectl 1.26 removed, with the kubelogin and gke-gcloud-auth-plugin exec plugins")
+	syncCmd.Flags().StringSlice("volatile-auth-keys", nil, "Auth-provider config keys holding session state (e.g. access-token,expires-on) that are ignored when comparing users and kept from the local kubeconfig, in addition to id-token and refresh-token")
+	syncCmd.Flags().String("exec-api-version", "v1", "client.authentication.k8s.io version of generated exec entries: v1 or v1beta1 (for older tooling)")
 
 	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview changes without writing to the kubeconfig file")
+	syncCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print a unified diff of the kubeconfig changes (secrets redacted) to stderr")
+	syncCmd.Flags().Bool("verify", false, "After writing, check that the API servers of the added and changed contexts can be reached, reporting on stderr")
+	syncCmd.Flags().Duration("verify-timeout", 5*time.Second, "How long --verify waits for each API server")
+	syncCmd.Flags().Bool("prune-departed", false, "Remove the managed entries of previously synced organizations you can no longer access without asking")
+	syncCmd.Flags().Bool("changed-exit-code", false, "Exit with 2 when the kubeconfig changed, 3 when clusters were skipped or failed, and 4 on errors")
+	syncCmd.Flags().Bool("benchmark", false, "Report the time spent in each phase and memory statistics on stderr; every phase runs even when nothing changed")
+	syncCmd.Flags().Bool("ci", false, "Single non-interactive run for pipelines: no prompts, the report as JSON on stdout, logs on stderr, and --changed-exit-code")
+	syncCmd.Flags().IntVar(&caExpiryWarnDays, "ca-expiry-warn-days", 30, "Warn about cluster CA certificates from Greenhouse that expire within this many days")
+	syncCmd.Flags().BoolVar(&backupKubeconfigs, "backup", false, "Copy the kubeconfig to the cloudctl-backups directory next to it before every write")
+	addBackupRetentionFlags(syncCmd)
+	syncCmd.Flags().BoolVar(&archiveRemoved, "archive-removed", false, "Move managed contexts that vanished from Greenhouse into cloudctl-archive.yaml next to the kubeconfig instead of only deleting them")
+
+	syncCmd.Flags().BoolVar(&myTeams, "my-teams", false, "Only sync clusters on which one of your Greenhouse teams (or you directly) has a TeamRoleBinding")
+
+	// Impersonation flags for the Greenhouse connection
+	syncCmd.Flags().StringVar(&impersonateUser, "as", "", "Username to impersonate on the Greenhouse cluster")
+	syncCmd.Flags().StringSliceVar(&impersonateGroups, "as-group", nil, "Group to impersonate on the Greenhouse cluster, can be repeated")
+	syncCmd.Flags().StringVar(&impersonateUID, "as-uid", "", "UID to impersonate on the Greenhouse cluster")
+
+	// TLS overrides for the Greenhouse connection
+	syncCmd.Flags().StringVar(&greenhouseCAFile, "greenhouse-ca-file", "", "PEM CA bundle to verify the Greenhouse API server with (replaces the kubeconfig's CA)")
+	syncCmd.Flags().StringVar(&greenhouseTLSServerName, "tls-server-name", "", "Server name to use for Greenhouse API server certificate validation")
+	syncCmd.Flags().BoolVar(&greenhouseInsecure, "insecure-skip-tls-verify", false, "Skip Greenhouse API server certificate verification (insecure)")
+
+	// Daemon mode flags
+	syncCmd.Flags().BoolVar(&watch, "watch", false, "Keep running and re-sync every --interval (daemon mode)")
+	syncCmd.Flags().DurationVar(&syncInterval, "interval", 5*time.Minute, "Time between syncs in --watch mode")
+	syncCmd.Flags().StringVar(&metricsAddress, "metrics-address", "", "Serve Prometheus metrics on this address (e.g. :9090) in --watch mode")
+	syncCmd.Flags().Bool("notify", false, "Show a desktop notification when clusters are added, removed, or change readiness in --watch mode")
+	syncCmd.Flags().String("webhook-url", "", "Post Slack-compatible messages to this URL when clusters change or syncs keep failing in --watch mode")
+	syncCmd.Flags().Int("webhook-failure-threshold", 3, "Consecutive failed syncs after which --webhook-url is notified")
+
+	_ = syncCmd.RegisterFlagCompletionFunc("remote-cluster-name", completeRemoteClusterNames)
+	_ = syncCmd.RegisterFlagCompletionFunc("greenhouse-cluster-namespace", completeOrgNamespaces)
 
 	// BindPFlags can theoretically return an error if called with `nil` as an argument
 	// which should never happen after at least one flag was defined. That's why the output
 	// there is ignored.
 	_ = viper.BindPFlags(syncCmd.Flags())
+
+	// -k and -c used to select the Greenhouse kubeconfig and context of sync.
+	// sync redeclares --kubeconfig and --context without them, after binding
+	// so viper keeps reading the global flags, and claims the shorthands with
+	// hidden flags that checkRemovedSyncShorthands rejects.
+	syncCmd.Flags().String("kubeconfig", clientcmd.RecommendedHomeFile, "Path to kubeconfig file")
+	syncCmd.Flags().String("context", "", "Kubeconfig context to use (defaults to the current context)")
+	syncCmd.Flags().StringP("removed-kubeconfig-shorthand", "k", "", "")
+	syncCmd.Flags().StringP("removed-context-shorthand", "c", "", "")
+	_ = syncCmd.Flags().MarkHidden("removed-kubeconfig-shorthand")
+	_ = syncCmd.Flags().MarkHidden("removed-context-shorthand")
 }
 
 var syncCmd = &cobra.Command{
@@ -91,8 +172,23 @@ Only clusters whose Ready condition is True are merged. Clusters that have
 been removed from Greenhouse are cleaned up from your local config. Existing
 non-managed entries are never touched.
 
+Users of the azure and gcp auth providers, which kubectl 1.26 removed, are
+reported with a warning; --convert-legacy-auth replaces them with the
+kubelogin (for Azure) and gke-gcloud-auth-plugin exec plugins.
+
 OIDC credentials are preserved across syncs: id-token and refresh-token are
-carried forward so you do not need to re-authenticate after every sync.
+carried forward so you do not need to re-authenticate after every sync. Auth
+providers that keep other session state in their config (e.g. access-token or
+expires-on) need those keys listed in --volatile-auth-keys, or every sync
+would see a changed user.
+
+With --merge-identical-users, clusters whose users have the same OIDC issuer,
+client, connector and scopes share one user entry, so one login covers them
+all; --show-shared-users reports which contexts share each entry.
+
+Without --greenhouse-cluster-namespace, the organizations in which you may
+list ClusterKubeconfigs are discovered: a single one is used directly,
+otherwise a picker is shown on a terminal.
 
 Examples:
   # Sync all clusters for an organization
@@ -101,23 +197,59 @@ Examples:
   # Sync a single cluster
   cloudctl sync -n my-org --remote-cluster-name prod-eu
 
+  # Right after onboarding: wait until the new cluster is ready, then sync it
+  cloudctl sync -n my-org --remote-cluster-name prod-eu --wait-ready
+
   # Use a dedicated Greenhouse kubeconfig and emit JSON output
-  cloudctl sync -n my-org -k ~/.kube/greenhouse.yaml -o json
+  cloudctl sync -n my-org --greenhouse-cluster-kubeconfig ~/.kube/greenhouse.yaml -o json
+
+  # Reach Greenhouse through a context of the local kubeconfig
+  cloudctl sync -n my-org --context greenhouse
 
   # Preview what would change without writing
   cloudctl sync -n my-org --dry-run
 
+  # Show the exact kubeconfig changes as a diff (secrets redacted)
+  cloudctl sync -n my-org --dry-run --verbose
+
   # Debug mode — shows every cluster/authinfo/context decision on stderr
-  cloudctl sync -n my-org --log-level debug`,
+  cloudctl sync -n my-org --log-level debug
+
+  # Only clusters my teams have role bindings on
+  cloudctl sync -n my-org --my-teams
+
+  # Preview what a new team member would get
+  cloudctl sync -n my-org --as jane@example.com --as-group ORG-MEMBERS --dry-run
+
+  # Private PKI: trust a custom CA for the Greenhouse API server
+  cloudctl sync -n my-org --greenhouse-ca-file /etc/pki/greenhouse-ca.pem
+
+  # In CI: exit 2 if the kubeconfig changed, 3 if clusters were skipped
+  cloudctl sync -n my-org --changed-exit-code
+
+  # In a pipeline: JSON report on stdout, no prompts, outcome in the exit code
+  cloudctl sync -n my-org --ci
+
+  # Daemon mode: re-sync every 10 minutes and expose Prometheus metrics
+  cloudctl sync -n my-org --watch --interval 10m --metrics-address :9090`,
 	RunE: runSync,
 }
 
-func runSync(cmd *cobra.Command, args []string) error {
+func runSync(cmd *cobra.Command, args []string) (err error) {
+	defer func() {
+		if err != nil && changedExitCode() && !errors.As(err, new(*ExitError)) {
+			err = &ExitError{Code: ExitCodeError, Err: err}
+		}
+	}()
+
+	if err := checkRemovedSyncShorthands(cmd); err != nil {
+		return err
+	}
+
 	// Use viper as a source of configuration
-	greenhouseClusterKubeconfig = resolveKubeconfig("greenhouse-cluster-kubeconfig", viper.GetString("greenhouse-cluster-kubeconfig"))
-	greenhouseClusterContext = viper.GetString("greenhouse-cluster-context")
+	greenhouseClusterKubeconfig, greenhouseClusterContext = greenhouseKubeconfig()
 	greenhouseClusterNamespace = viper.GetString("greenhouse-cluster-namespace")
-	remoteClusterKubeconfig = resolveKubeconfig("remote-cluster-kubeconfig", viper.GetString("remote-cluster-kubeconfig"))
+	remoteClusterKubeconfig = localKubeconfig()
 
 	// Reject an explicit empty-string value — it would silently fall through to
 	// client-go default loading rules instead of failing fast.
@@ -127,162 +259,782 @@ func runSync(cmd *cobra.Command, args []string) error {
 	if viper.IsSet("remote-cluster-kubeconfig") && remoteClusterKubeconfig == "" {
 		return fmt.Errorf("--remote-cluster-kubeconfig must not be empty")
 	}
+	if viper.IsSet("kubeconfig") && remoteClusterKubeconfig == "" {
+		return fmt.Errorf("--kubeconfig must not be empty")
+	}
 	remoteClusterName = viper.GetString("remote-cluster-name")
+	waitReady = viper.GetBool("wait-ready")
+	waitReadyTimeout = viper.GetDuration("wait-ready-timeout")
+	if waitReady {
+		if remoteClusterName == "" {
+			return fmt.Errorf("--wait-ready requires --remote-cluster-name")
+		}
+		if waitReadyTimeout <= 0 {
+			return fmt.Errorf("--wait-ready-timeout must be positive, got %s", waitReadyTimeout)
+		}
+	}
 	prefix = viper.GetString("prefix")
 	mergeIdenticalUsers = viper.GetBool("merge-identical-users")
+	orgContextNames = viper.GetBool("org-context-names")
+	prefixFromLabel = viper.GetString("prefix-from-label")
+	mergeIdenticalClusters = viper.GetBool("merge-identical-clusters")
+	if contextRenames, err = loadContextRenames(); err != nil {
+		return err
+	}
 	authType = viper.GetString("auth-type")
 	kubeloginPath = viper.GetString("kubelogin-path")
 	kubeloginExtraArgs = viper.GetStringSlice("kubelogin-extra-args")
 	kubeloginTokenCacheDir = viper.GetString("kubelogin-token-cache-dir")
+	omitClientSecret = viper.GetBool("omit-client-secret")
+	convertLegacyAuth = viper.GetBool("convert-legacy-auth")
+	argTemplates, err := loadKubeloginArgsConfig()
+	if err != nil {
+		return err
+	}
+	kubeloginArgTemplates = argTemplates
+	if execAPIVersionSettings, err = loadExecAPIVersionConfig(viper.GetString("exec-api-version")); err != nil {
+		return err
+	}
+	if volatileAuthKeys, err = loadVolatileAuthKeys(viper.GetStringSlice("volatile-auth-keys")); err != nil {
+		return err
+	}
 	dryRun = viper.GetBool("dry-run")
+	verbose = viper.GetBool("verbose")
+	watch = viper.GetBool("watch")
+	syncInterval = viper.GetDuration("interval")
+	metricsAddress = viper.GetString("metrics-address")
+	myTeams = viper.GetBool("my-teams")
+	impersonateUser = viper.GetString("as")
+	impersonateGroups = viper.GetStringSlice("as-group")
+	impersonateUID = viper.GetString("as-uid")
+	greenhouseCAFile = viper.GetString("greenhouse-ca-file")
+	greenhouseTLSServerName = viper.GetString("tls-server-name")
+	greenhouseInsecure = viper.GetBool("insecure-skip-tls-verify")
+	caExpiryWarnDays = viper.GetInt("ca-expiry-warn-days")
+	if caExpiryWarnDays < 0 {
+		return fmt.Errorf("--ca-expiry-warn-days must not be negative, got %d", caExpiryWarnDays)
+	}
+	backupKubeconfigs = viper.GetBool("backup")
+	archiveRemoved = viper.GetBool("archive-removed")
+	if backupPolicy, err = loadBackupRetention(); err != nil {
+		return err
+	}
+
+	if watch {
+		if waitReady {
+			return fmt.Errorf("--wait-ready cannot be combined with --watch")
+		}
+		if dryRun {
+			return fmt.Errorf("--dry-run cannot be combined with --watch")
+		}
+		if viper.GetBool("ci") {
+			return fmt.Errorf("--ci cannot be combined with --watch")
+		}
+		if viper.GetBool("benchmark") {
+			return fmt.Errorf("--benchmark cannot be combined with --watch")
+		}
+		if viper.GetBool("verify") {
+			return fmt.Errorf("--verify cannot be combined with --watch")
+		}
+		if syncInterval <= 0 {
+			return fmt.Errorf("--interval must be positive, got %s", syncInterval)
+		}
+	} else if metricsAddress != "" {
+		return fmt.Errorf("--metrics-address requires --watch")
+	} else if viper.GetBool("notify") {
+		return fmt.Errorf("--notify requires --watch")
+	} else if viper.GetString("webhook-url") != "" {
+		return fmt.Errorf("--webhook-url requires --watch")
+	}
+	if viper.GetBool("verify") {
+		if dryRun {
+			return fmt.Errorf("--verify cannot be combined with --dry-run")
+		}
+		if timeout := viper.GetDuration("verify-timeout"); timeout <= 0 {
+			return fmt.Errorf("--verify-timeout must be positive, got %s", timeout)
+		}
+	}
+	if threshold := viper.GetInt("webhook-failure-threshold"); threshold < 1 {
+		return fmt.Errorf("--webhook-failure-threshold must be at least 1, got %d", threshold)
+	}
 
-	format, err := output.ParseFormat(viper.GetString("output"))
+	format, err := output.ParseFormat(OutputFormat())
 	if err != nil {
 		return err
 	}
 	w := cmd.OutOrStdout()
-	printer := output.New(format, output.IsTTYWriter(w), w)
+	printer := output.New(format, output.IsTTYWriter(w) && !viper.GetBool("ci"), w)
+
+	if viper.GetBool("benchmark") {
+		syncBench = newSyncBenchmark()
+		defer func() {
+			report := syncBench.result()
+			syncBench = nil
+			errW := cmd.ErrOrStderr()
+			if printErr := output.New(format, output.IsTTYWriter(errW), errW).Print(report); err == nil {
+				err = printErr
+			}
+		}()
+	}
 
-	// When path is not empty (explicit file), verify it exists before proceeding.
-	if greenhouseClusterKubeconfig != "" {
-		if _, err := os.Stat(greenhouseClusterKubeconfig); err != nil {
-			return fmt.Errorf("greenhouse cluster kubeconfig file not found at %q: %w", greenhouseClusterKubeconfig, err)
+	if err := validateAuthType(authType, kubeloginPath); err != nil {
+		return err
+	}
+
+	sources, err := loadSyncSources()
+	if err != nil {
+		return err
+	}
+	if len(sources) > 1 && remoteClusterName != "" {
+		return fmt.Errorf("--remote-cluster-name requires --greenhouse-cluster-namespace when several sources are configured")
+	}
+	clients := make([]greenhouse.Client, len(sources))
+	for i := range sources {
+		if clients[i], err = connectSource(cmd.Context(), &sources[i]); err != nil {
+			remoteClusterKubeconfig = sources[i].Output
+			if rec := lookupSync(sources[i].endpoint, sources[i].Namespace); rec != nil && !dryRun && isDepartedError(err) {
+				if pruneErr := pruneDeparted(cmd.Context(), []sourceState{*rec}); pruneErr != nil {
+					slog.Warn("failed to remove entries of departed organization", "error", pruneErr)
+				}
+			}
+			return err
+		}
+	}
+	if !dryRun {
+		if err := pruneDeparted(cmd.Context(), departedSources(cmd.Context(), sources, clients)); err != nil {
+			return err
 		}
 	}
 
-	if err := validateAuthType(authType, kubeloginPath); err != nil {
+	cycle := func(ctx context.Context) (syncOutcome, error) {
+		return syncSources(ctx, sources, clients, printer, cmd.ErrOrStderr())
+	}
+	if watch {
+		var hooks watchHooks
+		if syncHealth != nil {
+			hooks.onCycle = append(hooks.onCycle, syncHealth.cycleDone)
+		}
+		if viper.GetBool("notify") {
+			hooks.onChange = append(hooks.onChange, notifyDesktop)
+		}
+		if webhookURL := viper.GetString("webhook-url"); webhookURL != "" {
+			n, err := newWebhookNotifier(webhookURL, viper.GetInt("webhook-failure-threshold"))
+			if err != nil {
+				return err
+			}
+			hooks.onChange = append(hooks.onChange, n.fleetChanged)
+			hooks.onCycle = append(hooks.onCycle, n.cycleDone)
+		}
+		// A single cluster is fetched with a get, which is cheap enough.
+		wake := make(chan struct{}, 1)
+		if remoteClusterName == "" {
+			for i := range clients {
+				if w := newWatchedClient(clients[i], sources[i].Namespace, wake); w != nil {
+					clients[i] = w
+					go w.run(cmd.Context(), syncInterval)
+				}
+			}
+		}
+		return runSyncWatch(cmd.Context(), cycle, syncInterval, wake, metricsAddress, hooks)
+	}
+	outcome, err := cycle(cmd.Context())
+	if err == nil && viper.GetBool("verify") {
+		err = reportVerify(cmd, format, outcome.Updated)
+	}
+	if err != nil || !changedExitCode() {
 		return err
 	}
+	switch {
+	case outcome.Partial:
+		return &ExitError{Code: ExitCodePartial}
+	case outcome.Changed:
+		return &ExitError{Code: ExitCodeChanged}
+	}
+	return nil
+}
+
+// connectSource builds the Greenhouse client for src, discovering its
+// namespace when none is configured, and checks that the user may read its
+// ClusterKubeconfigs.
+func connectSource(ctx context.Context, src *syncSource) (greenhouse.Client, error) {
+	// When path is not empty (explicit file), verify it exists before proceeding.
+	// In a pod a missing default kubeconfig falls back to the in-cluster config.
+	if src.Kubeconfig != "" && !inClusterFallback(src.Context, src.Kubeconfig) {
+		if _, err := os.Stat(src.Kubeconfig); err != nil {
+			return nil, fmt.Errorf("greenhouse cluster kubeconfig file not found at %q: %w", src.Kubeconfig, err)
+		}
+	}
 
 	// Log informational summary so the user knows which files/context/namespace are active.
-	ctxLabel := greenhouseClusterContext
+	ctxLabel := src.Context
 	if ctxLabel == "" {
 		ctxLabel = "(current context)"
 	}
 	slog.Info("syncing kubeconfigs",
-		"greenhouse", displayKubeconfig(greenhouseClusterKubeconfig),
+		"greenhouse", displayKubeconfig(src.Kubeconfig),
 		"context", ctxLabel,
-		"namespace", greenhouseClusterNamespace,
+		"namespace", src.Namespace,
+		"prefix", src.Prefix,
 		"local", displayKubeconfig(remoteClusterKubeconfig),
 	)
 
-	var (
-		centralConfig *rest.Config
-	)
-	centralConfig, err = configWithContext(greenhouseClusterContext, greenhouseClusterKubeconfig)
+	centralConfig, err := greenhouseRestConfig(src.Context, src.Kubeconfig)
 	if err != nil {
-		return fmt.Errorf("failed to build greenhouse kubeconfig (source: %s, context: %s): %w", displayKubeconfig(greenhouseClusterKubeconfig), ctxLabel, err)
+		return nil, fmt.Errorf("failed to build greenhouse kubeconfig (source: %s, context: %s): %w", displayKubeconfig(src.Kubeconfig), ctxLabel, err)
 	}
-
-	// Create a scheme and register Greenhouse types.
-	scheme := runtime.NewScheme()
-	if err := v1alpha1.AddToScheme(scheme); err != nil {
-		return fmt.Errorf("failed to add greenhouse scheme: %w", err)
+	if err := applyGreenhouseTLS(centralConfig, greenhouseCAFile, greenhouseTLSServerName, greenhouseInsecure); err != nil {
+		return nil, err
+	}
+	if err := applyGreenhouseProxy(centralConfig, viper.GetString("proxy-url")); err != nil {
+		return nil, err
+	}
+	if err := applyImpersonation(centralConfig, impersonateUser, impersonateGroups, impersonateUID); err != nil {
+		return nil, err
 	}
 
-	// Create a typed client.
-	c, err := client.New(centralConfig, client.Options{Scheme: scheme})
+	if syncBench != nil {
+		centralConfig.Wrap(syncBench.wrapTransport)
+	}
+	ghClient, err := newGreenhouseClient(withTracing(centralConfig))
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return nil, err
+	}
+
+	src.endpoint = centralConfig.Host
+
+	if src.Namespace == "" {
+		if src.Namespace, err = discoverOrgNamespace(ctx, ghClient); err != nil {
+			return nil, err
+		}
+	}
+
+	verb := "list"
+	if remoteClusterName != "" {
+		verb = "get"
+	}
+	if err := preflightAccess(ctx, ghClient, greenhouse.ClusterKubeconfigAccess(src.Namespace, verb)); err != nil {
+		return nil, err
+	}
+	return ghClient, nil
+}
+
+// syncSources runs syncOnce for each source in turn with that source's
+// namespace and prefix. A failing source does not stop the others; all
+// errors are returned together.
+func syncSources(ctx context.Context, sources []syncSource, clients []greenhouse.Client, printer output.Printer, errW io.Writer) (syncOutcome, error) {
+	if len(sources) == 1 {
+		useSource(sources[0])
+		return syncOnce(ctx, clients[0], printer, errW)
 	}
+	var (
+		total syncOutcome
+		errs  []error
+	)
+	// Sources with a kubeconfig of their own switch the target; later steps
+	// such as departed-organization checks work on --kubeconfig again.
+	defer func(target string) { remoteClusterKubeconfig = target }(remoteClusterKubeconfig)
+	for i, src := range sources {
+		useSource(src)
+		outcome, err := syncOnce(ctx, clients[i], printer, errW)
+		total.Added += outcome.Added
+		total.Removed += outcome.Removed
+		total.Changed = total.Changed || outcome.Changed
+		total.Partial = total.Partial || outcome.Partial
+		if outcome.Clusters != nil {
+			if total.Clusters == nil {
+				total.Clusters = map[string]bool{}
+			}
+			maps.Copy(total.Clusters, outcome.Clusters)
+		}
+		for path, contexts := range outcome.Updated {
+			if total.Updated == nil {
+				total.Updated = map[string][]string{}
+			}
+			total.Updated[path] = append(total.Updated[path], contexts...)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", src.Namespace, err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
 
-	ctx := cmd.Context()
+// syncOutcome summarises the managed-entry changes produced by one sync cycle.
+type syncOutcome struct {
+	Added   int
+	Removed int
+	// Changed is set when the kubeconfig was (or, in a dry run, would be)
+	// modified.
+	Changed bool
+	// Partial is set when clusters were skipped or failed.
+	Partial bool
+	// Clusters holds the readiness of every fetched cluster, keyed by the
+	// local names of its contexts.
+	Clusters map[string]bool
+	// Updated lists the contexts added or changed, keyed by the kubeconfig
+	// they were written to.
+	Updated map[string][]string
+}
+
+// syncOnce performs a single fetch-merge-write cycle against the Greenhouse
+// API and prints its result. It is shared by one-shot and --watch syncs. The
+// --verbose diff is written to errW.
+func syncOnce(ctx context.Context, ghClient greenhouse.Client, printer output.Printer, errW io.Writer) (syncOutcome, error) {
+	var outcome syncOutcome
 
 	stopFetch := printer.StartSpinner("Fetching cluster kubeconfigs...")
-	var allKubeconfigs []v1alpha1.ClusterKubeconfig
+	doneFetch := syncBench.trackFetch()
+	var (
+		allKubeconfigs []v1alpha1.ClusterKubeconfig
+		err            error
+	)
 
 	// If a specific remote cluster name is provided, fetch that single resource;
 	// otherwise, list all ClusterKubeconfigs in the given namespace.
 	if remoteClusterName != "" {
-		var ckc v1alpha1.ClusterKubeconfig
-		if err := c.Get(ctx, client.ObjectKey{Namespace: greenhouseClusterNamespace, Name: remoteClusterName}, &ckc); err != nil {
+		ckc, getErr := ghClient.GetClusterKubeconfig(ctx, greenhouseClusterNamespace, remoteClusterName)
+		if waitReady && (apierrors.IsNotFound(getErr) || getErr == nil && !greenhouse.IsReady(*ckc)) {
+			stopFetch()
+			stopFetch = printer.StartSpinner(fmt.Sprintf("Waiting for %s to become ready...", remoteClusterName))
+			ckc, getErr = waitForClusterKubeconfig(ctx, ghClient, greenhouseClusterNamespace, remoteClusterName, waitReadyTimeout, greenhouse.IsReady)
+		}
+		if getErr != nil {
 			stopFetch()
-			return fmt.Errorf("failed to get ClusterKubeconfig %q: %w", remoteClusterName, err)
+			syncMetrics.apiErrors.Inc()
+			return outcome, getErr
 		}
-		allKubeconfigs = append(allKubeconfigs, ckc)
+		allKubeconfigs = append(allKubeconfigs, *ckc)
 	} else {
-		var list v1alpha1.ClusterKubeconfigList
-		if err := c.List(ctx, &list, client.InNamespace(greenhouseClusterNamespace)); err != nil {
+		allKubeconfigs, err = ghClient.ListClusterKubeconfigs(ctx, greenhouseClusterNamespace)
+		if err != nil {
 			stopFetch()
-			return fmt.Errorf("failed to list ClusterKubeconfigs: %w", err)
+			syncMetrics.apiErrors.Inc()
+			return outcome, err
+		}
+	}
+	if myTeams {
+		allKubeconfigs, err = filterByTeamBindings(ctx, ghClient, greenhouseClusterNamespace, allKubeconfigs)
+		if err != nil {
+			stopFetch()
+			syncMetrics.apiErrors.Inc()
+			return outcome, err
 		}
-		allKubeconfigs = list.Items
 	}
 	stopFetch()
+	doneFetch(len(allKubeconfigs))
 
+	prev := previousSync()
+	contextLabelValues = labelValuesByContext(allKubeconfigs, prefixFromLabel)
 	ready, notReady := partitionReady(allKubeconfigs)
+	var unchanged map[string]bool
+	// --benchmark measures every phase, so it skips the fast paths.
+	if syncBench == nil {
+		unchanged = unchangedSpecs(prev, ready)
+	}
+	if len(unchanged) > 0 {
+		slog.Debug("ClusterKubeconfigs unchanged since the last sync", "unchanged", len(unchanged), "ready", len(ready))
+	}
+	valid, invalid := partitionValid(ready, unchanged)
+	outcome.Partial = len(notReady)+len(invalid) > 0
+	outcome.Clusters = make(map[string]bool, len(allKubeconfigs))
+	for _, ckc := range ready {
+		isValid := !slices.ContainsFunc(invalid, func(r output.ClusterSyncResult) bool { return r.Name == ckc.Name })
+		recordContextReadiness(outcome.Clusters, ckc, isValid)
+	}
+	for _, ckc := range notReady {
+		recordContextReadiness(outcome.Clusters, ckc, false)
+	}
+	ready = valid
 
 	if len(ready) == 0 {
-		return printer.Print(buildSyncResult(nil, notReady))
+		recordSync(allKubeconfigs, nil, "")
+		return outcome, printer.Print(withInvalid(buildSyncResult(nil, notReady), invalid))
 	}
 
-	var localConfig *clientcmdapi.Config
-	if remoteClusterKubeconfig != "" {
-		localConfig, err = clientcmd.LoadFromFile(remoteClusterKubeconfig)
-	} else {
-		localConfig, err = clientcmd.NewDefaultClientConfigLoadingRules().Load()
-	}
+	doneLoad := syncBench.track("load")
+	localConfig, err := loadLocalKubeconfig(ctx, remoteClusterKubeconfig)
+	doneLoad()
 	if err != nil {
-		return fmt.Errorf("failed to load local kubeconfig: %w", err)
-	}
-
-	if localConfig == nil {
-		localConfig = clientcmdapi.NewConfig()
+		return outcome, err
 	}
 
+	doneBuild := syncBench.track("build")
 	serverConfig, err := buildIncomingKubeconfig(ready)
+	doneBuild()
 	if err != nil {
-		return fmt.Errorf("failed to create server config: %w", err)
+		return outcome, fmt.Errorf("failed to create server config: %w", err)
+	}
+	clusterWarnings := caExpiryWarnings(ready, certNow(), caExpiryWarnDays)
+	for name, warnings := range legacyAuthWarnings(ready) {
+		clusterWarnings[name] = append(clusterWarnings[name], warnings...)
+	}
+	fingerprint, err := incomingFingerprint(serverConfig)
+	if err != nil {
+		return outcome, fmt.Errorf("failed to create server config: %w", err)
 	}
 
-	// Take a snapshot before merge for dry-run diff — only needed when --dry-run is set.
-	var localConfigBefore *clientcmdapi.Config
-	if dryRun {
-		localConfigBefore = localConfig.DeepCopy()
+	// Nothing can change when neither Greenhouse nor the local file did since
+	// the last sync.
+	if !dryRun && syncBench == nil && syncIsCurrent(prev, resourceVersions(allKubeconfigs), fingerprint) {
+		slog.Info("ClusterKubeconfigs unchanged since the last sync, not merging", "kubeconfig", displayKubeconfig(remoteClusterKubeconfig))
+		recordSync(allKubeconfigs, ready, fingerprint)
+		result := withInvalid(buildSyncResult(ready, notReady), invalid)
+		for i := range result.Clusters {
+			result.Clusters[i].Warnings = clusterWarnings[result.Clusters[i].Name]
+		}
+		result.Unchanged = true
+		result.SharedUsers = reportSharedUsers(localConfig, serverConfig)
+		return outcome, printer.Print(result)
 	}
 
+	// Take a snapshot before merge so the changes can be diffed afterwards.
+	localConfigBefore := localConfig.DeepCopy()
+
 	spinnerLabel := "Merging kubeconfigs..."
 	if dryRun {
 		spinnerLabel = "Simulating merge (dry-run)..."
 	}
 	stopMerge := printer.StartSpinner(spinnerLabel)
+	_, mergeSpan := tracer.Start(ctx, "kubeconfig.merge")
+	unchangedClusters = clusterNamesOf(ready, unchanged)
+	doneMerge := syncBench.track("merge")
 	err = mergeKubeconfig(localConfig, serverConfig)
+	doneMerge()
+	unchangedClusters = nil
+	endSpan(mergeSpan, err)
 	stopMerge()
 	if err != nil {
-		_ = printer.Print(buildFailedSyncResult(ready, notReady, err))
-		return fmt.Errorf(`failed to merge ClusterKubeconfig: %w`, err)
+		_ = printer.Print(withInvalid(buildFailedSyncResult(ready, notReady, err), invalid))
+		return outcome, fmt.Errorf(`failed to merge ClusterKubeconfig: %w`, err)
 	}
 
+	diff := diffKubeconfig(localConfigBefore, localConfig)
+	if verbose {
+		udiff, err := unifiedKubeconfigDiff(displayKubeconfig(remoteClusterKubeconfig), localConfigBefore, localConfig)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to diff kubeconfig: %w", err)
+		}
+		fmt.Fprint(errW, udiff)
+	}
 	if dryRun {
-		diff := diffKubeconfig(localConfigBefore, localConfig)
-		return printer.Print(buildDryRunResult(diff, localConfigBefore, localConfig))
+		result := buildDryRunResult(diff, localConfigBefore, localConfig)
+		outcome.Changed = result.Added+result.Removed+result.Modified > 0
+		return outcome, printer.Print(result)
+	}
+
+	result := withInvalid(buildSyncResult(ready, notReady), invalid)
+	for i := range result.Clusters {
+		result.Clusters[i].Warnings = clusterWarnings[result.Clusters[i].Name]
+	}
+	result.SharedUsers = reportSharedUsers(localConfig, serverConfig)
+
+	// Leave the file (and its mtime) alone when the merge changed nothing, so
+	// tools watching the kubeconfig are not triggered needlessly. --benchmark
+	// still times the write, against a temporary file.
+	if kubeconfigEqual(localConfigBefore, localConfig) {
+		slog.Info("kubeconfig is up to date, not writing", "kubeconfig", displayKubeconfig(remoteClusterKubeconfig))
+		if syncBench != nil {
+			if err := benchmarkUnchangedWrite(localConfig); err != nil {
+				slog.Warn("failed to time the kubeconfig write", "error", err)
+			}
+		}
+		recordSync(allKubeconfigs, ready, fingerprint)
+		result.Unchanged = true
+		return outcome, printer.Print(result)
 	}
 
 	writeTarget, writeTargetErr := resolveWriteTarget(remoteClusterKubeconfig)
 	if writeTargetErr != nil {
-		return writeTargetErr
+		return outcome, writeTargetErr
+	}
+
+	// Archive before writing, so a failure cannot lose the removed entries.
+	if archiveRemoved {
+		archived, err := archiveRemovedContexts(localConfigBefore, diff, writeTarget, time.Now())
+		if err != nil {
+			return outcome, err
+		}
+		if archived > 0 {
+			slog.Info("archived removed contexts", "archive", archiveFile(writeTarget), "contexts", archived)
+		}
+	}
+
+	stopWrite := printer.StartSpinner(fmt.Sprintf("Writing %s...", displayKubeconfig(writeTarget)))
+	_, writeSpan := tracer.Start(ctx, "kubeconfig.write", trace.WithAttributes(attribute.String("path", writeTarget)))
+	writeErr := writeConfig(localConfig, writeTarget)
+	endSpan(writeSpan, writeErr)
+	stopWrite()
+	if writeErr != nil {
+		_ = printer.Print(withInvalid(buildFailedSyncResult(ready, notReady, writeErr), invalid))
+		return outcome, fmt.Errorf("failed to write merged kubeconfig: %w", writeErr)
+	}
+	outcome.Changed = true
+	outcome.Updated = map[string][]string{writeTarget: updatedContexts(localConfig, diff)}
+	recordSync(allKubeconfigs, ready, fingerprint)
+	if err := auditMutations("sync", writeTarget, diff); err != nil {
+		return outcome, err
 	}
 
-	if writeErr := writeConfig(localConfig, writeTarget); writeErr != nil {
-		_ = printer.Print(buildFailedSyncResult(ready, notReady, writeErr))
-		return fmt.Errorf("failed to write merged kubeconfig: %w", writeErr)
+	for _, d := range diff.Contexts {
+		switch d.ChangeType {
+		case DiffChangeAdded:
+			outcome.Added++
+		case DiffChangeRemoved:
+			outcome.Removed++
+		}
 	}
+	return outcome, printer.Print(result)
+}
 
-	return printer.Print(buildSyncResult(ready, notReady))
+// kubeconfigEqual reports whether a and b serialize to the same file content.
+func kubeconfigEqual(a, b *clientcmdapi.Config) bool {
+	da, err := clientcmd.Write(*a)
+	if err != nil {
+		return false
+	}
+	db, err := clientcmd.Write(*b)
+	return err == nil && bytes.Equal(da, db)
 }
 
-// partitionReady splits ClusterKubeconfigs into ready and notReady slices.
-// Ready means the Ready condition is set to True.
-func partitionReady(items []v1alpha1.ClusterKubeconfig) (ready, notReady []v1alpha1.ClusterKubeconfig) {
+// caExpiryWarnings checks the CA certificates served for each ClusterKubeconfig
+// and returns warnings, keyed by ClusterKubeconfig name, for those that have
+// expired or expire within warnDays. Each warning is also logged so the
+// platform team hears about it before users get TLS errors.
+func caExpiryWarnings(items []v1alpha1.ClusterKubeconfig, now time.Time, warnDays int) map[string][]string {
+	warnings := map[string][]string{}
+	for _, ckc := range items {
+		for _, c := range ckc.Spec.Kubeconfig.Clusters {
+			info := certInfo(c.Cluster.CertificateAuthorityData, "", now, warnDays)
+			if info == nil {
+				continue
+			}
+			var msg string
+			switch info.Status {
+			case output.CertStatusExpired:
+				msg = fmt.Sprintf("CA certificate of cluster %q expired on %s", c.Name, info.NotAfter.UTC().Format(time.DateOnly))
+			case output.CertStatusExpiring:
+				msg = fmt.Sprintf("CA certificate of cluster %q expires on %s (in %d day(s))", c.Name, info.NotAfter.UTC().Format(time.DateOnly), info.DaysLeft)
+			default:
+				continue
+			}
+			slog.Warn("cluster CA certificate near expiry", "clusterKubeconfig", ckc.Name, "cluster", c.Name, "notAfter", info.NotAfter, "daysLeft", info.DaysLeft)
+			warnings[ckc.Name] = append(warnings[ckc.Name], msg)
+		}
+	}
+	return warnings
+}
+
+// applyImpersonation configures cfg to impersonate the given user, groups and
+// UID. Like kubectl, groups and UID are only accepted together with a user.
+func applyImpersonation(cfg *rest.Config, user string, groups []string, uid string) error {
+	if user == "" {
+		if len(groups) > 0 || uid != "" {
+			return fmt.Errorf("--as-group and --as-uid require --as")
+		}
+		return nil
+	}
+	cfg.Impersonate = rest.ImpersonationConfig{UserName: user, Groups: groups, UID: uid}
+	slog.Info("impersonating on the Greenhouse cluster", "user", user, "groups", groups, "uid", uid)
+	return nil
+}
+
+// applyGreenhouseTLS overrides the TLS settings of the Greenhouse connection,
+// for kubeconfigs generated by tooling that does not know the private CA. A CA
+// file replaces any CA data from the kubeconfig; skipping verification drops it.
+func applyGreenhouseTLS(cfg *rest.Config, caFile, serverName string, insecure bool) error {
+	if insecure && caFile != "" {
+		return fmt.Errorf("--greenhouse-ca-file and --insecure-skip-tls-verify are mutually exclusive")
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Greenhouse CA file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in Greenhouse CA file %q", caFile)
+		}
+		cfg.CAData = pem
+		cfg.CAFile = ""
+		cfg.Insecure = false
+	}
+	if serverName != "" {
+		cfg.ServerName = serverName
+	}
+	if insecure {
+		// client-go rejects a CA combined with Insecure.
+		cfg.CAData = nil
+		cfg.CAFile = ""
+		cfg.Insecure = true
+		slog.Warn("TLS certificate verification for the Greenhouse cluster is disabled")
+	}
+	return nil
+}
+
+// applyGreenhouseProxy routes the Greenhouse connection through proxyURL.
+// Without it client-go uses the proxy-url of the kubeconfig cluster, or else
+// HTTPS_PROXY and NO_PROXY from the environment.
+func applyGreenhouseProxy(cfg *rest.Config, proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid --proxy-url %q: %w", proxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid --proxy-url %q: scheme must be http, https or socks5", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid --proxy-url %q: no host", proxyURL)
+	}
+	cfg.Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// discoverOrgNamespaces returns the sorted namespaces of the Greenhouse
+// organizations in which the user may list ClusterKubeconfigs.
+func discoverOrgNamespaces(ctx context.Context, ghClient greenhouse.Client) ([]string, error) {
+	orgs, err := ghClient.ListOrganizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, org := range orgs {
+		allowed, _, err := ghClient.CanI(ctx, greenhouse.ClusterKubeconfigAccess(org.Name, "list"))
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			namespaces = append(namespaces, org.Name)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces, nil
+}
+
+// discoverOrgNamespace picks the organization namespace to sync when none is
+// configured: the only accessible one, or the user's choice on a terminal.
+func discoverOrgNamespace(ctx context.Context, ghClient greenhouse.Client) (string, error) {
+	namespaces, err := discoverOrgNamespaces(ctx, ghClient)
+	if err != nil {
+		return "", fmt.Errorf("--greenhouse-cluster-namespace is not set and organizations could not be discovered: %w", err)
+	}
+	switch {
+	case len(namespaces) == 0:
+		return "", errors.New("--greenhouse-cluster-namespace is not set and you cannot list ClusterKubeconfigs in any organization")
+	case len(namespaces) == 1:
+		slog.Info("using the only accessible organization", "namespace", namespaces[0])
+		return namespaces[0], nil
+	case !canPrompt():
+		return "", fmt.Errorf("--greenhouse-cluster-namespace is not set and several organizations are accessible: %s", strings.Join(namespaces, ", "))
+	}
+	return selectName("organization", namespaces)
+}
+
+// filterByTeamBindings keeps only the ClusterKubeconfigs for clusters on
+// which the current user has access through a TeamRoleBinding, either via
+// team membership or by being listed directly. ClusterKubeconfigs share the
+// name of the Cluster they belong to.
+func filterByTeamBindings(ctx context.Context, ghClient greenhouse.Client, namespace string, items []v1alpha1.ClusterKubeconfig) ([]v1alpha1.ClusterKubeconfig, error) {
+	username, err := ghClient.WhoAmI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := ghClient.ListTeams(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := ghClient.ListTeamRoleBindings(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	teamNames := greenhouse.UserTeams(username, teams)
+	clusters := greenhouse.BoundClusters(username, teamNames, bindings)
+
+	kept := make([]v1alpha1.ClusterKubeconfig, 0, len(items))
 	for _, ckc := range items {
-		cond := ckc.Status.Conditions.GetConditionByType(greenhousemetav1alpha1.ReadyCondition)
-		if cond != nil && cond.IsTrue() {
-			ready = append(ready, ckc)
+		if slices.Contains(clusters, ckc.Name) {
+			kept = append(kept, ckc)
 		} else {
-			notReady = append(notReady, ckc)
+			slog.Debug("skipping cluster without team role binding", "cluster", ckc.Name)
 		}
 	}
-	return ready, notReady
+	slog.Info("filtered clusters by team role bindings",
+		"user", username, "teams", teamNames, "kept", len(kept), "dropped", len(items)-len(kept))
+	return kept, nil
+}
+
+// changedExitCode reports whether the outcome of a one-shot sync is reported
+// in the exit code, as requested by --changed-exit-code or --ci.
+func changedExitCode() bool {
+	return viper.GetBool("changed-exit-code") || viper.GetBool("ci")
+}
+
+// runSyncWatch runs cycle every interval, and whenever wake is signalled,
+// until ctx is cancelled. Failed cycles are logged and retried on the next
+// tick rather than aborting, so a long-lived daemon survives transient API
+// outages. When metricsAddr is set,
+// Prometheus metrics are served on /metrics for the lifetime of the loop.
+// hooks are told about fleet changes and the result of every cycle.
+func runSyncWatch(ctx context.Context, cycle func(context.Context) (syncOutcome, error), interval time.Duration, wake <-chan struct{}, metricsAddr string, hooks watchHooks) error {
+	if metricsAddr != "" {
+		stop, err := serveMetrics(metricsAddr)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	slog.Info("watching for changes", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var (
+		known    map[string]bool
+		failures int
+	)
+	for {
+		start := time.Now()
+		outcome, err := cycle(ctx)
+		syncMetrics.observe(outcome, err, time.Since(start))
+		switch {
+		case err == nil:
+			failures = 0
+		case ctx.Err() == nil:
+			slog.Error("sync failed", "error", err)
+			failures++
+		}
+		if ctx.Err() == nil {
+			for _, fn := range hooks.onCycle {
+				fn(err, failures)
+			}
+		}
+		// Failed cycles may have fetched only part of the fleet, so they are
+		// not compared.
+		if err == nil && outcome.Clusters != nil {
+			if change := diffFleet(known, outcome.Clusters); known != nil && !change.empty() {
+				for _, fn := range hooks.onChange {
+					fn(change)
+				}
+			}
+			known = outcome.Clusters
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("stopping watch")
+			return nil
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// partitionReady splits ClusterKubeconfigs into ready and notReady slices.
+// Ready means the Ready condition is set to True.
+func partitionReady(items []v1alpha1.ClusterKubeconfig) (ready, notReady []v1alpha1.ClusterKubeconfig) {
+	return greenhouse.PartitionReady(items)
 }
 
 // filterReady returns only ClusterKubeconfigs that have Ready condition set to True.
@@ -292,13 +1044,142 @@ func filterReady(items []v1alpha1.ClusterKubeconfig) []v1alpha1.ClusterKubeconfi
 	return ready
 }
 
+// partitionValid splits ready ClusterKubeconfigs into those that can be merged
+// and failed results for the others, so one malformed resource does not abort
+// the whole sync. Failed clusters are treated like clusters that are not
+// ready: they are not merged and their existing entries are pruned. Items in
+// unchanged were validated by the last sync and are not checked again.
+func partitionValid(ready []v1alpha1.ClusterKubeconfig, unchanged map[string]bool) (valid []v1alpha1.ClusterKubeconfig, invalid []output.ClusterSyncResult) {
+	for _, ckc := range ready {
+		if unchanged[ckc.Name] {
+			valid = append(valid, ckc)
+			continue
+		}
+		err := validateClusterKubeconfig(ckc)
+		if err == nil {
+			valid = append(valid, ckc)
+			continue
+		}
+		slog.Warn("skipping malformed ClusterKubeconfig", "cluster", ckc.Name, "error", err)
+		r := output.ClusterSyncResult{Name: ckc.Name, Status: output.ClusterSyncStatusFailed, Reason: err.Error()}
+		if len(ckc.Spec.Kubeconfig.Contexts) > 0 {
+			r.Context = localContextName(ckc.Spec.Kubeconfig.Contexts[0].Name)
+		}
+		invalid = append(invalid, r)
+	}
+	return valid, invalid
+}
+
+// validateClusterKubeconfig reports problems that would break the merge or
+// produce an unusable context: a server URL that is not https, CA data that
+// is not PEM certificates, or a context referencing a cluster or user the
+// resource does not define. All problems are reported together.
+func validateClusterKubeconfig(ckc v1alpha1.ClusterKubeconfig) error {
+	kc := ckc.Spec.Kubeconfig
+	var problems []string
+	for _, c := range kc.Clusters {
+		if err := validateServerURL(c.Cluster.Server); err != nil {
+			problems = append(problems, fmt.Sprintf("cluster %q: %v", c.Name, err))
+		}
+		if err := validateCAData(c.Cluster.CertificateAuthorityData); err != nil {
+			problems = append(problems, fmt.Sprintf("cluster %q: invalid CA data: %v", c.Name, err))
+		}
+	}
+	for _, c := range kc.Contexts {
+		if !slices.ContainsFunc(kc.Clusters, func(item v1alpha1.ClusterKubeconfigClusterItem) bool { return item.Name == c.Context.Cluster }) {
+			problems = append(problems, fmt.Sprintf("context %q references missing cluster %q", c.Name, c.Context.Cluster))
+		}
+		if !slices.ContainsFunc(kc.AuthInfo, func(item v1alpha1.ClusterKubeconfigAuthInfoItem) bool { return item.Name == c.Context.AuthInfo }) {
+			problems = append(problems, fmt.Sprintf("context %q references missing user %q", c.Name, c.Context.AuthInfo))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	_, err := buildIncomingKubeconfig([]v1alpha1.ClusterKubeconfig{ckc})
+	return err
+}
+
+// validateServerURL checks that server is an absolute https URL.
+func validateServerURL(server string) error {
+	if server == "" {
+		return errors.New("no server")
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return fmt.Errorf("invalid server URL %q: %w", server, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("server URL %q is not an https URL", server)
+	}
+	return nil
+}
+
+// validateCAData checks that data, when set, consists of parseable PEM
+// certificates.
+func validateCAData(data []byte) error {
+	rest := bytes.TrimSpace(data)
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return errors.New("not PEM encoded")
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("cannot parse certificate: %w", err)
+		}
+		rest = bytes.TrimSpace(rest)
+	}
+	return nil
+}
+
+// reportSharedUsers returns the user entries that the contexts of
+// serverConfig share in the merged localConfig when --show-shared-users is
+// set, sorted by name. Entries used by a single context are left out.
+func reportSharedUsers(localConfig, serverConfig *clientcmdapi.Config) []output.SharedUser {
+	if !viper.GetBool("show-shared-users") {
+		return nil
+	}
+	contexts := map[string][]string{}
+	for serverName := range serverConfig.Contexts {
+		name := localContextName(serverName)
+		if ctx := localConfig.Contexts[name]; ctx != nil && ctx.AuthInfo != "" {
+			contexts[ctx.AuthInfo] = append(contexts[ctx.AuthInfo], name)
+		}
+	}
+	var users []output.SharedUser
+	for _, user := range slices.Sorted(maps.Keys(contexts)) {
+		if len(contexts[user]) < 2 {
+			continue
+		}
+		ai := localConfig.AuthInfos[user]
+		shared := output.SharedUser{User: user, Contexts: slices.Sorted(slices.Values(contexts[user])), Auth: authInfoSummary(ai)}
+		if p, _, err := oidcParamsFromAuthInfo(ai); err == nil {
+			shared.Connector = p.ExtraParams["connector_id"]
+			shared.Scopes = slices.Sorted(slices.Values(p.ExtraScopes))
+		}
+		users = append(users, shared)
+	}
+	return users
+}
+
+// withInvalid adds the failed results of malformed ClusterKubeconfigs to r.
+func withInvalid(r output.SyncResult, invalid []output.ClusterSyncResult) output.SyncResult {
+	r.Clusters = append(r.Clusters, invalid...)
+	r.Failed += len(invalid)
+	return r
+}
+
 // buildSyncResult constructs an output.SyncResult from ready and notReady cluster lists.
 func buildSyncResult(ready, notReady []v1alpha1.ClusterKubeconfig) output.SyncResult {
 	result := output.SyncResult{}
 	for _, ckc := range ready {
 		ctxName := ""
 		if len(ckc.Spec.Kubeconfig.Contexts) > 0 {
-			ctxName = ckc.Spec.Kubeconfig.Contexts[0].Name
+			ctxName = localContextName(ckc.Spec.Kubeconfig.Contexts[0].Name)
 		}
 		result.Clusters = append(result.Clusters, output.ClusterSyncResult{
 			Name:    ckc.Name,
@@ -310,7 +1191,7 @@ func buildSyncResult(ready, notReady []v1alpha1.ClusterKubeconfig) output.SyncRe
 	for _, ckc := range notReady {
 		ctxName := ""
 		if len(ckc.Spec.Kubeconfig.Contexts) > 0 {
-			ctxName = ckc.Spec.Kubeconfig.Contexts[0].Name
+			ctxName = localContextName(ckc.Spec.Kubeconfig.Contexts[0].Name)
 		}
 		result.Clusters = append(result.Clusters, output.ClusterSyncResult{
 			Name:    ckc.Name,
@@ -334,7 +1215,7 @@ func buildFailedSyncResult(ready, notReady []v1alpha1.ClusterKubeconfig, reason
 	for _, ckc := range ready {
 		ctxName := ""
 		if len(ckc.Spec.Kubeconfig.Contexts) > 0 {
-			ctxName = ckc.Spec.Kubeconfig.Contexts[0].Name
+			ctxName = localContextName(ckc.Spec.Kubeconfig.Contexts[0].Name)
 		}
 		result.Clusters = append(result.Clusters, output.ClusterSyncResult{
 			Name:    ckc.Name,
@@ -347,7 +1228,7 @@ func buildFailedSyncResult(ready, notReady []v1alpha1.ClusterKubeconfig, reason
 	for _, ckc := range notReady {
 		ctxName := ""
 		if len(ckc.Spec.Kubeconfig.Contexts) > 0 {
-			ctxName = ckc.Spec.Kubeconfig.Contexts[0].Name
+			ctxName = localContextName(ckc.Spec.Kubeconfig.Contexts[0].Name)
 		}
 		result.Clusters = append(result.Clusters, output.ClusterSyncResult{
 			Name:    ckc.Name,
@@ -381,24 +1262,43 @@ func buildIncomingKubeconfig(items []v1alpha1.ClusterKubeconfig) (*clientcmdapi.
 		// Add all users (auth infos)
 		for _, authItem := range ckc.Spec.Kubeconfig.AuthInfo {
 			// Depending on the selected auth type, keep legacy auth-provider or convert to exec plugin
-			if strings.EqualFold(authType, "exec-plugin") && authItem.AuthInfo.AuthProvider.Name == "oidc" {
+			if execCmd := credentialPluginCommand(authType, kubeloginPath); execCmd != "" && authItem.AuthInfo.AuthProvider.Name == "oidc" {
+				providerCfg := writtenProviderConfig(authItem.AuthInfo.AuthProvider.Config)
+				injected, err := kubeloginArgTemplates.argsFor(kubeloginArgsData{
+					Cluster:       ckc.Name,
+					Connector:     connectorIDFromExtraParams(providerCfg["auth-request-extra-params"]),
+					IssuerURL:     providerCfg["idp-issuer-url"],
+					ClientID:      providerCfg["client-id"],
+					TokenCacheDir: kubeloginTokenCacheDir,
+				})
+				if err != nil {
+					return nil, err
+				}
 				execAuth := &clientcmdapi.AuthInfo{
 					ClientCertificateData: authItem.AuthInfo.ClientCertificateData,
 					ClientKeyData:         authItem.AuthInfo.ClientKeyData,
 					Exec: &clientcmdapi.ExecConfig{
-						APIVersion:      "client.authentication.k8s.io/v1",
-						Command:         kubeloginPath,
-						Args:            buildKubeloginArgs(authItem.AuthInfo.AuthProvider.Config, kubeloginExtraArgs, kubeloginTokenCacheDir),
+						APIVersion:      execAPIVersionSettings.apiVersionFor(ckc.Name),
+						Command:         execCmd,
+						Args:            buildKubeloginArgs(providerCfg, append(slices.Clone(kubeloginExtraArgs), injected...), kubeloginTokenCacheDir),
 						InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
 					},
 				}
 				kubeconfig.AuthInfos[authItem.Name] = execAuth
+			} else if execConfig := legacyAuthExec(authItem.AuthInfo.AuthProvider); convertLegacyAuth && execConfig != nil {
+				kubeconfig.AuthInfos[authItem.Name] = &clientcmdapi.AuthInfo{
+					ClientCertificateData: authItem.AuthInfo.ClientCertificateData,
+					ClientKeyData:         authItem.AuthInfo.ClientKeyData,
+					Exec:                  execConfig,
+				}
 			} else {
 				// Preserve the same data shape; exclude nothing here (merging will handle dedupe)
+				authProvider := authItem.AuthInfo.AuthProvider
+				authProvider.Config = writtenProviderConfig(authProvider.Config)
 				kubeconfig.AuthInfos[authItem.Name] = &clientcmdapi.AuthInfo{
 					ClientCertificateData: authItem.AuthInfo.ClientCertificateData,
 					ClientKeyData:         authItem.AuthInfo.ClientKeyData,
-					AuthProvider:          &authItem.AuthInfo.AuthProvider,
+					AuthProvider:          &authProvider,
 				}
 			}
 		}
@@ -426,12 +1326,100 @@ func buildIncomingKubeconfig(items []v1alpha1.ClusterKubeconfig) (*clientcmdapi.
 		}
 	}
 
+	// The preferences of a ClusterKubeconfig are an empty struct and it has no
+	// top-level extensions, so the local ones are never overridden.
 	return kubeconfig, nil
 }
 
-func writeConfig(config *clientcmdapi.Config, filepath string) error {
-	if err := clientcmd.WriteToFile(*config, filepath); err != nil {
-		return fmt.Errorf("failed to write kubeconfig to %s: %w", filepath, err)
+// loadLocalKubeconfig reads the local kubeconfig that managed entries are merged
+// into. An empty path uses client-go's default loading rules (KUBECONFIG or
+// ~/.kube/config). A missing file yields an empty config.
+func loadLocalKubeconfig(ctx context.Context, path string) (*clientcmdapi.Config, error) {
+	_, span := tracer.Start(ctx, "kubeconfig.load", trace.WithAttributes(attribute.String("path", displayKubeconfig(path))))
+	var (
+		cfg *clientcmdapi.Config
+		err error
+	)
+	if path != "" {
+		// A kubeconfig that does not exist yet, such as that of a newly
+		// configured source, is created by the first write.
+		if cfg, err = clientcmd.LoadFromFile(path); errors.Is(err, fs.ErrNotExist) {
+			cfg, err = clientcmdapi.NewConfig(), nil
+		}
+	} else {
+		cfg, err = clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to load local kubeconfig: %w", err)
+	}
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = clientcmdapi.NewConfig()
+	}
+	return cfg, nil
+}
+
+// checkRemovedSyncShorthands rejects -k and -c on the sync command line. They
+// used to select the Greenhouse kubeconfig and context, but would now set the
+// global --kubeconfig and --context, which also decide the kubeconfig sync
+// writes to, so an old invocation would silently merge into the Greenhouse
+// kubeconfig. The --kubeconfig and --context sync redeclares are passed on to
+// the global flags. Other commands running a sync keep the global shorthands.
+func checkRemovedSyncShorthands(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("removed-kubeconfig-shorthand") == nil {
+		return nil
+	}
+	if cmd.Flags().Changed("removed-kubeconfig-shorthand") {
+		return fmt.Errorf("-k no longer selects the Greenhouse kubeconfig of sync; use --greenhouse-cluster-kubeconfig, or spell out --kubeconfig for the kubeconfig to merge into")
+	}
+	if cmd.Flags().Changed("removed-context-shorthand") {
+		return fmt.Errorf("-c no longer selects the Greenhouse context of sync; use --greenhouse-cluster-context, or spell out --context for the context of --kubeconfig")
+	}
+	for _, name := range []string{"kubeconfig", "context"} {
+		if f := cmd.LocalFlags().Lookup(name); f != nil && f.Changed {
+			if err := rootCmd.PersistentFlags().Set(name, f.Value.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// localKubeconfig returns the kubeconfig that sync merges into: the global
+// --kubeconfig, unless the deprecated --remote-cluster-kubeconfig is set.
+func localKubeconfig() string {
+	if viper.IsSet("remote-cluster-kubeconfig") {
+		return viper.GetString("remote-cluster-kubeconfig")
+	}
+	return resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+}
+
+func writeConfig(config *clientcmdapi.Config, file string) error {
+	// The lock file needs the directory too.
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("failed to write kubeconfig to %s: %w", file, err)
+	}
+	unlock, err := lockKubeconfig(file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := backupBeforeWrite(file); err != nil {
+		return err
+	}
+	// Like clientcmd.WriteToFile, in two steps so --benchmark can time them.
+	doneSerialize := syncBench.track("serialize")
+	content, err := clientcmd.Write(*config)
+	doneSerialize()
+	if err != nil {
+		return fmt.Errorf("failed to write kubeconfig to %s: %w", file, err)
+	}
+	defer syncBench.track("write")()
+	if err := os.WriteFile(file, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write kubeconfig to %s: %w", file, err)
 	}
 	return nil
 }
@@ -447,11 +1435,154 @@ func unmanagedNameFunc(managedName string) string {
 	return strings.TrimPrefix(managedName, prefix+":")
 }
 
+// ownershipExtension is the name of the context extension that records which
+// organization and server-side context a context named with
+// --org-context-names or --prefix-from-label belongs to.
+const ownershipExtension = "ownership"
+
+// contextOwnership is the content of the ownership extension.
+type contextOwnership struct {
+	Organization string `json:"organization"`
+	Context      string `json:"context"`
+}
+
+// contextRenames maps server-side context names to the local names set with
+// the "context-renames" config key.
+var contextRenames map[string]string
+
+// loadContextRenames reads the "context-renames" key and checks that no two
+// contexts are renamed to the same local name.
+//
+//	context-renames:
+//	  prod-eu-1: prod
+//	  qa-eu-1: qa
+func loadContextRenames() (map[string]string, error) {
+	renames := viper.GetStringMapString("context-renames")
+	seen := make(map[string]string, len(renames))
+	for _, from := range slices.Sorted(maps.Keys(renames)) {
+		to := renames[from]
+		switch {
+		case to == "":
+			return nil, fmt.Errorf("invalid context-renames config: %q has no new name", from)
+		case seen[to] != "":
+			return nil, fmt.Errorf("invalid context-renames config: %q and %q are both renamed to %q", seen[to], from, to)
+		}
+		seen[to] = from
+	}
+	return renames, nil
+}
+
+// contextLabelValues maps server-side context names to the value of their
+// ClusterKubeconfig's --prefix-from-label label in the current sync.
+var contextLabelValues map[string]string
+
+// labelValuesByContext maps the contexts of items to the value of their
+// label key. Contexts whose ClusterKubeconfig lacks the label are left out.
+func labelValuesByContext(items []v1alpha1.ClusterKubeconfig, key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, ckc := range items {
+		v := ckc.Labels[key]
+		if v == "" {
+			continue
+		}
+		for _, c := range ckc.Spec.Kubeconfig.Contexts {
+			values[c.Name] = v
+		}
+	}
+	return values
+}
+
+// localContextName returns the local name of the server-side context name:
+// its entry in context-renames, or otherwise "<organization>/<name>" with
+// --org-context-names, prefixed with "<label value>:" by --prefix-from-label.
+func localContextName(name string) string {
+	if to, ok := contextRenames[name]; ok {
+		return to
+	}
+	local := name
+	if orgContextNames {
+		local = greenhouseClusterNamespace + "/" + local
+	}
+	if v := contextLabelValues[name]; v != "" {
+		local = v + ":" + local
+	}
+	return local
+}
+
+// recordContextReadiness records ready for every context ckc contributes,
+// keyed by its local name so it can be passed to --context.
+func recordContextReadiness(readiness map[string]bool, ckc v1alpha1.ClusterKubeconfig, ready bool) {
+	for _, c := range ckc.Spec.Kubeconfig.Contexts {
+		readiness[localContextName(c.Name)] = ready
+	}
+}
+
+// recordsOwnership reports whether local context names can differ from the
+// server-side ones in ways context-renames cannot undo, so contexts carry the
+// ownership extension.
+func recordsOwnership() bool {
+	return orgContextNames || prefixFromLabel != ""
+}
+
+// serverContextName returns the server-side name of the managed context
+// localName, read from its ownership extension when it has one, otherwise
+// by reversing context-renames.
+func serverContextName(localName string, ctx *clientcmdapi.Context) string {
+	var o contextOwnership
+	if raw := extensionRaw(ctx.Extensions, ownershipExtension); raw != nil && json.Unmarshal(raw, &o) == nil && o.Context != "" {
+		return o.Context
+	}
+	for from, to := range contextRenames {
+		if to == localName {
+			return from
+		}
+	}
+	return localName
+}
+
 // isManaged checks if the given name is managed by cloudctl based on the prefix.
 func isManaged(name string) bool {
 	return strings.HasPrefix(name, prefix+":")
 }
 
+// hasManagedCluster reports whether ctx was written by sync. Contexts keep
+// their server-side names, only the clusters and users they reference carry
+// the prefix, so a context is recognized by its cluster.
+func hasManagedCluster(ctx *clientcmdapi.Context) bool {
+	return ctx != nil && isManaged(ctx.Cluster)
+}
+
+// defaultTokenCacheDir returns kubelogin's default token cache directory,
+// ~/.kube/cache/oidc-login.
+func defaultTokenCacheDir() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			home = h
+		}
+	}
+	if home == "" {
+		home = "~"
+	}
+	return filepath.Join(home, ".kube", "cache", "oidc-login")
+}
+
+// writtenProviderConfig returns the auth-provider config of a ClusterKubeconfig
+// as it is written to the kubeconfig: without the client secret when
+// --omit-client-secret is set, so public clients authenticate with PKCE
+// instead of a secret shared by every user.
+func writtenProviderConfig(cfg map[string]string) map[string]string {
+	if !omitClientSecret || cfg["client-secret"] == "" {
+		return cfg
+	}
+	cfg = maps.Clone(cfg)
+	delete(cfg, "client-secret")
+	return cfg
+}
+
 // buildKubeloginArgs constructs kubelogin arguments from an oidc auth-provider config and extra args
 func buildKubeloginArgs(cfg map[string]string, extra []string, tokenCacheDir string) []string {
 	args := []string{"get-token"}
@@ -477,15 +1608,8 @@ func buildKubeloginArgs(cfg map[string]string, extra []string, tokenCacheDir str
 		// If connector_id is used, use a separate token cache directory to avoid collisions
 		// between multiple users on the same machine.
 		// See https://github.com/int128/kubelogin/issues/29
-		for _, param := range strings.Split(v, ",") {
-			kv := strings.SplitN(param, "=", 2)
-			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "connector_id" {
-				connectorID := strings.TrimSpace(kv[1])
-				if connectorID != "" {
-					args = append(args, fmt.Sprintf("--token-cache-dir=%s/%s", tokenCacheDir, connectorID))
-				}
-				break
-			}
+		if connectorID := connectorIDFromExtraParams(v); connectorID != "" {
+			args = append(args, "--token-cache-dir="+filepath.Join(tokenCacheDir, connectorID))
 		}
 	}
 	// allow caller to inject additional flags
@@ -495,11 +1619,52 @@ func buildKubeloginArgs(cfg map[string]string, extra []string, tokenCacheDir str
 	return args
 }
 
+// unchangedClusters holds the server-side names of the clusters whose
+// ClusterKubeconfig is unchanged since the last sync into an unmodified local
+// kubeconfig. mergeKubeconfig keeps their existing entries without comparing.
+var unchangedClusters map[string]bool
+
+// clusterNamesOf returns the server-side cluster names defined by the items
+// in names.
+func clusterNamesOf(items []v1alpha1.ClusterKubeconfig, names map[string]bool) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	clusters := make(map[string]bool)
+	for _, ckc := range items {
+		if !names[ckc.Name] {
+			continue
+		}
+		for _, c := range ckc.Spec.Kubeconfig.Clusters {
+			clusters[c.Name] = true
+		}
+	}
+	return clusters
+}
+
+// mergeKubeconfig merges the managed clusters, users and contexts of
+// serverConfig into localConfig. Everything else in localConfig, including
+// its current context, preferences and top-level extensions, is left alone.
 func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdapi.Config) error {
+	clusterNames := managedClusterNames(serverConfig)
+	clusterRef := func(serverName string) string {
+		if name, ok := clusterNames[serverName]; ok {
+			return name
+		}
+		return managedNameFunc(serverName)
+	}
+
 	// Merge Clusters
 	for serverName, serverCluster := range serverConfig.Clusters {
-		managedName := managedNameFunc(serverName)
+		managedName := clusterNames[serverName]
+		if managedName != managedNameFunc(serverName) {
+			slog.Debug("collapsing cluster with identical server", "name", serverName, "into", managedName)
+			continue
+		}
 		localCluster, exists := localConfig.Clusters[managedName]
+		if exists && unchangedClusters[serverName] {
+			continue
+		}
 		if !exists {
 			// Add the managed cluster from serverConfig to localConfig
 			slog.Debug("adding cluster", "name", managedName)
@@ -565,7 +1730,7 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 				if authInfoEqual(localAuth, serverAuth) {
 					slog.Debug("reusing existing local authinfo", "name", localName, "server", serverName)
 					// Credentials are identical — leave the unmanaged local entry untouched
-					// to preserve any local-only fields (Token, TokenFile, Impersonate, etc.)
+					// to preserve any local-only fields (Token, TokenFile, etc.)
 					// that are outside authInfoEqual's comparison scope.
 					authInfoMap[serverName] = localName
 					goto nextServerAuth
@@ -580,7 +1745,9 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 				// If the hash-derived name is already taken by a non-equal authinfo
 				// (two server authinfos share the same OIDC key but differ in non-OIDC
 				// exec args), fall back to a per-server managed name to avoid conflation.
-				if existingAuth, exists := localConfig.AuthInfos[managedAuthName]; exists && !authInfoEqual(existingAuth, serverAuth) {
+				// Impersonation the user added to the entry is kept by mergeAuthInfo,
+				// so it does not count as a difference.
+				if existingAuth, exists := localConfig.AuthInfos[managedAuthName]; exists && !authInfoEqual(existingAuth, mergeAuthInfo(serverAuth, existingAuth)) {
 					slog.Debug("hash collision with non-equal authinfo, using per-server name", "name", managedAuthName, "server", serverName)
 					managedAuthName = managedNameFunc(serverName)
 				}
@@ -590,6 +1757,12 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 					slog.Debug("merging authinfo tokens", "name", managedAuthName, "server", serverName)
 					mergedAuth := mergeAuthInfo(serverAuth, existingAuth)
 					localConfig.AuthInfos[managedAuthName] = mergedAuth
+				} else if previousName, previousAuth := previousAuthInfo(localConfig, serverConfig, serverName); previousAuth != nil && authInfoEqual(previousAuth, serverAuth) {
+					// The user got a new name: keep its tokens. Only an entry
+					// with the same configuration hands them on, so users that
+					// were merged wrongly do not keep sharing them.
+					slog.Debug("renaming authinfo", "name", managedAuthName, "from", previousName, "server", serverName)
+					localConfig.AuthInfos[managedAuthName] = mergeAuthInfo(serverAuth, previousAuth)
 				} else {
 					slog.Debug("adding authinfo", "name", managedAuthName, "server", serverName)
 					localConfig.AuthInfos[managedAuthName] = serverAuth
@@ -624,7 +1797,9 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 
 	// Merge Contexts
 	for serverName, serverCtx := range serverConfig.Contexts {
-		managedName := serverName // it is the same for context
+		// Contexts keep their server-side name unless --org-context-names or
+		// --prefix-from-label is set.
+		managedName := localContextName(serverName)
 
 		var managedAuthInfoName string
 		if mergeIdenticalUsers {
@@ -652,22 +1827,35 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 		}
 
 		// Update Cluster name
-		managedClusterName := managedNameFunc(serverCtx.Cluster)
+		managedClusterName := clusterRef(serverCtx.Cluster)
 
 		serverCtxCopy := serverCtx.DeepCopy()
 		serverCtxCopy.Cluster = managedClusterName
 		serverCtxCopy.AuthInfo = managedAuthInfoName
+		if recordsOwnership() {
+			ownership, err := json.Marshal(contextOwnership{Organization: greenhouseClusterNamespace, Context: serverName})
+			if err != nil {
+				return fmt.Errorf("failed to marshal ownership of context %q: %w", managedName, err)
+			}
+			serverCtxCopy.Extensions = map[string]runtime.Object{ownershipExtension: &runtime.Unknown{Raw: ownership}}
+		}
 
 		localCtx, exists := localConfig.Contexts[managedName]
+		// The namespace picked with "cloudctl ns" is kept, like the
+		// impersonation mergeAuthInfo keeps on a managed AuthInfo.
+		if exists && localCtx.Namespace != "" {
+			serverCtxCopy.Namespace = localCtx.Namespace
+		}
 		if !exists {
 			// Add the managed Context from serverConfig to localConfig
 			slog.Debug("adding context", "name", managedName)
 			localConfig.Contexts[managedName] = serverCtxCopy
 		} else {
-			// Check if Cluster, AuthInfo, or Namespace has changed
+			// Check if Cluster, AuthInfo, Namespace or the ownership extension has changed
 			if localCtx.Cluster != serverCtxCopy.Cluster ||
 				localCtx.AuthInfo != serverCtxCopy.AuthInfo ||
-				localCtx.Namespace != serverCtxCopy.Namespace {
+				localCtx.Namespace != serverCtxCopy.Namespace ||
+				!bytes.Equal(extensionRaw(localCtx.Extensions, ownershipExtension), extensionRaw(serverCtxCopy.Extensions, ownershipExtension)) {
 				slog.Debug("updating context", "name", managedName)
 				localConfig.Contexts[managedName] = serverCtxCopy
 			}
@@ -677,9 +1865,10 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 	// Delete managed Clusters not present in serverConfig
 	for localName := range localConfig.Clusters {
 		if isManaged(localName) {
-			// Derive the server-side name by stripping the prefix
+			// Derive the server-side name by stripping the prefix; clusters
+			// collapsed into another entry are stale too.
 			serverName := unmanagedNameFunc(localName)
-			if _, exists := serverConfig.Clusters[serverName]; !exists {
+			if _, exists := serverConfig.Clusters[serverName]; !exists || clusterNames[serverName] != localName {
 				slog.Debug("removing stale cluster", "name", localName)
 				delete(localConfig.Clusters, localName)
 			}
@@ -720,15 +1909,16 @@ func mergeKubeconfig(localConfig *clientcmdapi.Config, serverConfig *clientcmdap
 		if localCtx == nil || !isManaged(localCtx.Cluster) {
 			continue
 		}
-		// Context name equals the server-side name (no prefix applied).
-		serverName := localName
-		if _, exists := serverConfig.Contexts[serverName]; !exists {
+		// Map the local name back to the server-side name; contexts that
+		// would now be named differently are replaced.
+		serverName := serverContextName(localName, localCtx)
+		if _, exists := serverConfig.Contexts[serverName]; !exists || localContextName(serverName) != localName {
 			slog.Debug("removing stale context", "name", localName)
 			delete(localConfig.Contexts, localName)
 		} else {
 			// Additionally, verify that the context's Cluster and AuthInfo are still managed
 			serverCtx := serverConfig.Contexts[serverName]
-			expectedCluster := managedNameFunc(serverCtx.Cluster)
+			expectedCluster := clusterRef(serverCtx.Cluster)
 			var expectedAuthInfo string
 			if mergeIdenticalUsers {
 				serverAuthName := serverCtx.AuthInfo
@@ -760,6 +1950,29 @@ func labelsExtensionEqual(a, b map[string]runtime.Object) bool {
 	return bytes.Equal(ar, br)
 }
 
+// managedClusterNames maps the server-side cluster names of serverConfig to
+// the managed names of their local entries. With --merge-identical-clusters,
+// clusters with the same server URL and CA share the entry of the first of
+// them in lexical order.
+func managedClusterNames(serverConfig *clientcmdapi.Config) map[string]string {
+	names := make(map[string]string, len(serverConfig.Clusters))
+	canonical := map[string]string{}
+	for _, serverName := range slices.Sorted(maps.Keys(serverConfig.Clusters)) {
+		names[serverName] = managedNameFunc(serverName)
+		c := serverConfig.Clusters[serverName]
+		if !mergeIdenticalClusters || c == nil {
+			continue
+		}
+		key := c.Server + "\x00" + string(c.CertificateAuthorityData)
+		if first, ok := canonical[key]; ok {
+			names[serverName] = names[first]
+			continue
+		}
+		canonical[key] = serverName
+	}
+	return names
+}
+
 // extensionRaw extracts the raw JSON bytes for the given extension name, if present.
 func extensionRaw(m map[string]runtime.Object, name string) []byte {
 	if m == nil {
@@ -792,9 +2005,37 @@ func validateAuthType(authType, kubeloginPath string) error {
 			return fmt.Errorf("could not resolve kubelogin binary %q: install kubelogin or set --kubelogin-path, or use --auth-type=auth-provider: %w", kubeloginPath, err)
 		}
 		return nil
+	case "cloudctl":
+		return nil
+	default:
+		return fmt.Errorf("invalid --auth-type %q: must be one of \"auth-provider\", \"exec-plugin\" or \"cloudctl\"", authType)
+	}
+}
+
+// credentialPluginCommand returns the exec command for OIDC user entries, or
+// "" when authType keeps the legacy auth-provider.
+func credentialPluginCommand(authType, kubeloginPath string) string {
+	switch strings.ToLower(authType) {
+	case "exec-plugin":
+		return execCommand(kubeloginPath)
+	case "cloudctl":
+		return cloudctlCommand()
 	default:
-		return fmt.Errorf("invalid --auth-type %q: must be one of \"auth-provider\" or \"exec-plugin\"", authType)
+		return ""
+	}
+}
+
+// cloudctlCommand returns how exec entries should invoke cloudctl: by name
+// when it is on PATH, so entries survive upgrades to a different location,
+// otherwise by the absolute path of the running binary.
+func cloudctlCommand() string {
+	if _, err := exec.LookPath("cloudctl"); err == nil {
+		return execCommand("cloudctl")
+	}
+	if self, err := os.Executable(); err == nil {
+		return self
 	}
+	return execCommand("cloudctl")
 }
 
 // resolveWriteTarget returns the kubeconfig file path to write merged config into.
@@ -807,7 +2048,7 @@ func resolveWriteTarget(remoteKubeconfig string) (string, error) {
 	}
 	kc := os.Getenv("KUBECONFIG")
 	if kc == "" {
-		return "", fmt.Errorf("cannot determine write target: --remote-cluster-kubeconfig is not set and KUBECONFIG is empty")
+		return "", fmt.Errorf("cannot determine write target: --kubeconfig is not set and KUBECONFIG is empty")
 	}
 	if parts := strings.SplitN(kc, string(os.PathListSeparator), 2); len(parts) > 0 && parts[0] != "" {
 		return parts[0], nil
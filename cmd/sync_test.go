@@ -5,19 +5,29 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/pem"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+	greenhousev1alpha2 "github.com/cloudoperators/greenhouse/api/v1alpha2"
 	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/cloudoperators/cloudctl/cmd/output"
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
 )
 
 // sync_merge_test.go
@@ -89,6 +99,31 @@ func TestAuthInfoEqual_IgnoresTokens(t *testing.T) {
 	g.Expect(authInfoEqual(a, b)).To(BeTrue(), "token differences should be ignored")
 }
 
+func TestVolatileAuthKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	keys, err := loadVolatileAuthKeys([]string{" access-token", "expires-on", "id-token"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keys).To(Equal([]string{"id-token", "refresh-token", "access-token", "expires-on"}))
+	_, err = loadVolatileAuthKeys([]string{"access-token", " "})
+	g.Expect(err).To(MatchError("--volatile-auth-keys must not contain empty keys"))
+
+	volatileAuthKeys = keys
+	t.Cleanup(func() { volatileAuthKeys = defaultVolatileAuthKeys })
+	local := &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name:   "azure",
+		Config: map[string]string{"client-id": "cid", "access-token": "tokA", "expires-on": "1", "id-token": "idA"},
+	}}
+	server := &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name:   "azure",
+		Config: map[string]string{"client-id": "cid", "access-token": "tokB"},
+	}}
+	g.Expect(filterAuthProviderConfig(local.AuthProvider.Config)).To(Equal(map[string]string{"client-id": "cid"}))
+	g.Expect(authInfoEqual(local, server)).To(BeTrue(), "session state differences should be ignored")
+	g.Expect(generateAuthInfoKey(local)).To(Equal(generateAuthInfoKey(server)))
+	g.Expect(mergeAuthInfo(server, local).AuthProvider.Config).To(Equal(local.AuthProvider.Config))
+}
+
 func TestAuthInfoEqual_DiffCerts(t *testing.T) {
 	g := NewWithT(t)
 
@@ -541,6 +576,28 @@ func TestGenerateAuthInfoKey_AuthProviderIncludesIssuer(t *testing.T) {
 	g.Expect(ka).ToNot(Equal(kb), "different idp-issuer-url must produce different keys")
 }
 
+func TestGenerateAuthInfoKey_ExecIssuerAndConnector(t *testing.T) {
+	g := NewWithT(t)
+
+	execAuth := func(args ...string) *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+			APIVersion: "client.authentication.k8s.io/v1",
+			Command:    "kubelogin",
+			Args:       append([]string{"get-token", "--oidc-client-id=cid"}, args...),
+		}}
+	}
+	key := generateAuthInfoKey(execAuth("--oidc-issuer-url=https://issuer-a"))
+	g.Expect(generateAuthInfoKey(execAuth("--oidc-issuer-url", "https://issuer-a"))).To(Equal(key), "both flag forms are read")
+	g.Expect(generateAuthInfoKey(execAuth("--oidc-issuer-url", "https://issuer-b"))).ToNot(Equal(key))
+	g.Expect(key).To(Equal("exec:cmd:kubelogin;api:client.authentication.k8s.io/v1;mode:;issuer:https://issuer-a;client-id:cid;client-secret:;extra-params:;scopes:;env:"),
+		"keys without a connector are unchanged")
+
+	ldap := generateAuthInfoKey(execAuth("--oidc-issuer-url=https://issuer-a", "--oidc-auth-request-extra-params=prompt=login", "--oidc-auth-request-extra-params=connector_id=ldap"))
+	github := generateAuthInfoKey(execAuth("--oidc-issuer-url=https://issuer-a", "--oidc-auth-request-extra-params=prompt=login", "--oidc-auth-request-extra-params=connector_id=github"))
+	g.Expect(ldap).To(HaveSuffix(";connector:ldap"))
+	g.Expect(github).ToNot(Equal(ldap), "repeated extra params are all part of the key")
+}
+
 func TestGenerateAuthInfoKey_ExecEnvAffectsKey(t *testing.T) {
 	g := NewWithT(t)
 
@@ -621,6 +678,249 @@ func TestMergeKubeconfig_PrefersExistingLocalAuthName(t *testing.T) {
 	g.Expect(ctx.AuthInfo).To(Equal("my-existing-user"))
 }
 
+func TestAuthInfoEqual_ImpersonationConsidered(t *testing.T) {
+	g := NewWithT(t)
+	base := func() *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"client-id": "cid"}}}
+	}
+	a, b := base(), base()
+	a.ImpersonateGroups = []string{"ops", "admins"}
+	b.ImpersonateGroups = []string{"admins", "ops"}
+	g.Expect(authInfoEqual(a, b)).To(BeTrue(), "group order is irrelevant")
+	g.Expect(generateAuthInfoKey(a)).To(Equal(generateAuthInfoKey(b)))
+
+	for name, set := range map[string]func(*clientcmdapi.AuthInfo){
+		"user":   func(ai *clientcmdapi.AuthInfo) { ai.Impersonate = "jane" },
+		"groups": func(ai *clientcmdapi.AuthInfo) { ai.ImpersonateGroups = []string{"ops"} },
+		"extra":  func(ai *clientcmdapi.AuthInfo) { ai.ImpersonateUserExtra = map[string][]string{"scopes": {"view"}} },
+	} {
+		a := base()
+		set(a)
+		g.Expect(authInfoEqual(a, base())).To(BeFalse(), name)
+		g.Expect(generateAuthInfoKey(a)).ToNot(Equal(generateAuthInfoKey(base())), name)
+	}
+	g.Expect(generateAuthInfoKey(base())).ToNot(ContainSubstring("as:"), "keys without impersonation are unchanged")
+}
+
+func TestMergeKubeconfig_ImpersonatingLocalUserNotReused(t *testing.T) {
+	g := NewWithT(t)
+
+	orig := prefix
+	origMerge := mergeIdenticalUsers
+	prefix = "cloudctl"
+	mergeIdenticalUsers = true
+	t.Cleanup(func() {
+		prefix = orig
+		mergeIdenticalUsers = origMerge
+	})
+
+	oidc := func() *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"client-id": "cid"}}}
+	}
+	localConfig := clientcmdapi.NewConfig()
+	localConfig.AuthInfos["break-glass"] = oidc()
+	localConfig.AuthInfos["break-glass"].Impersonate = "admin"
+	serverConfig := func() *clientcmdapi.Config {
+		cfg := clientcmdapi.NewConfig()
+		cfg.AuthInfos["server-user"] = oidc()
+		cfg.Clusters["prod"] = &clientcmdapi.Cluster{Server: "https://prod.example.com"}
+		cfg.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod", AuthInfo: "server-user"}
+		return cfg
+	}
+
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+	managed := localConfig.Contexts["prod"].AuthInfo
+	g.Expect(managed).To(HavePrefix("cloudctl:auth-"), "an impersonating user is a different user")
+	g.Expect(localConfig.AuthInfos[managed].Impersonate).To(BeEmpty())
+
+	// Impersonation added to the managed entry by hand survives the next sync.
+	localConfig.AuthInfos[managed].ImpersonateGroups = []string{"ops"}
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+	g.Expect(localConfig.Contexts["prod"].AuthInfo).To(Equal(managed))
+	g.Expect(localConfig.AuthInfos[managed].ImpersonateGroups).To(Equal([]string{"ops"}))
+}
+
+func TestRecordContextReadiness(t *testing.T) {
+	g := NewWithT(t)
+
+	origNS, origOrg := greenhouseClusterNamespace, orgContextNames
+	t.Cleanup(func() { greenhouseClusterNamespace, orgContextNames = origNS, origOrg })
+	greenhouseClusterNamespace = "org"
+
+	ckc := greenhousev1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: "eu"}}
+	ckc.Spec.Kubeconfig.Contexts = []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "eu-admin"}, {Name: "eu-viewer"}}
+
+	readiness := map[string]bool{}
+	recordContextReadiness(readiness, ckc, true)
+	g.Expect(readiness).To(Equal(map[string]bool{"eu-admin": true, "eu-viewer": true}), "keyed by context, not by the prefixed cluster")
+
+	orgContextNames = true
+	readiness = map[string]bool{}
+	recordContextReadiness(readiness, ckc, false)
+	g.Expect(readiness).To(Equal(map[string]bool{"org/eu-admin": false, "org/eu-viewer": false}))
+}
+
+func TestMergeKubeconfig_OrgContextNames(t *testing.T) {
+	g := NewWithT(t)
+
+	origPrefix, origNS, origOrg, origMerge := prefix, greenhouseClusterNamespace, orgContextNames, mergeIdenticalUsers
+	t.Cleanup(func() {
+		prefix, greenhouseClusterNamespace, orgContextNames, mergeIdenticalUsers = origPrefix, origNS, origOrg, origMerge
+	})
+	mergeIdenticalUsers = false
+	orgContextNames = true
+
+	serverConfig := func() *clientcmdapi.Config {
+		cfg := clientcmdapi.NewConfig()
+		cfg.AuthInfos["user"] = &clientcmdapi.AuthInfo{Token: "t"}
+		cfg.Clusters["prod"] = &clientcmdapi.Cluster{Server: "https://prod.example.com"}
+		cfg.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod", AuthInfo: "user"}
+		return cfg
+	}
+
+	// Two organizations with an identically named cluster.
+	localConfig := clientcmdapi.NewConfig()
+	prefix, greenhouseClusterNamespace = "a", "org-a"
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+	prefix, greenhouseClusterNamespace = "b", "org-b"
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+
+	g.Expect(localConfig.Contexts).To(HaveLen(2))
+	g.Expect(localConfig.Contexts["org-a/prod"]).To(HaveField("Cluster", "a:prod"))
+	g.Expect(localConfig.Contexts["org-b/prod"]).To(HaveField("Cluster", "b:prod"))
+	g.Expect(string(extensionRaw(localConfig.Contexts["org-b/prod"].Extensions, ownershipExtension))).To(Equal(`{"organization":"org-b","context":"prod"}`))
+
+	// A second sync keeps the context; turning the option off renames it.
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+	g.Expect(localConfig.Contexts).To(HaveKey("org-b/prod"))
+	orgContextNames = false
+	g.Expect(mergeKubeconfig(localConfig, serverConfig())).To(Succeed())
+	g.Expect(localConfig.Contexts).NotTo(HaveKey("org-b/prod"))
+	g.Expect(localConfig.Contexts["prod"]).To(HaveField("Cluster", "b:prod"))
+	g.Expect(localConfig.Contexts["prod"].Extensions).To(BeEmpty())
+	g.Expect(localConfig.Contexts).To(HaveKey("org-a/prod"), "entries of other prefixes are left alone")
+}
+
+func TestMergeKubeconfig_PrefixFromLabel(t *testing.T) {
+	g := NewWithT(t)
+
+	origPrefix, origLabel, origValues, origMerge := prefix, prefixFromLabel, contextLabelValues, mergeIdenticalUsers
+	t.Cleanup(func() {
+		prefix, prefixFromLabel, contextLabelValues, mergeIdenticalUsers = origPrefix, origLabel, origValues, origMerge
+	})
+	prefix, prefixFromLabel, mergeIdenticalUsers = "cloudctl", "region", false
+
+	ckc := func(name, region string) greenhousev1alpha1.ClusterKubeconfig {
+		c := greenhousev1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if region != "" {
+			c.Labels = map[string]string{"region": region}
+		}
+		c.Spec.Kubeconfig.Contexts = []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: name}}
+		return c
+	}
+	contextLabelValues = labelValuesByContext([]greenhousev1alpha1.ClusterKubeconfig{ckc("cluster-a", "eu-de-1"), ckc("cluster-b", "")}, prefixFromLabel)
+
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.AuthInfos["user"] = &clientcmdapi.AuthInfo{Token: "t"}
+	for _, name := range []string{"cluster-a", "cluster-b"} {
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ".example.com"}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: "user"}
+	}
+
+	localConfig := clientcmdapi.NewConfig()
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts).To(HaveLen(2))
+	g.Expect(localConfig.Contexts["eu-de-1:cluster-a"]).To(HaveField("Cluster", "cloudctl:cluster-a"))
+	g.Expect(localConfig.Contexts).To(HaveKey("cluster-b"), "contexts without the label keep their name")
+	g.Expect(string(extensionRaw(localConfig.Contexts["eu-de-1:cluster-a"].Extensions, ownershipExtension))).To(ContainSubstring(`"context":"cluster-a"`))
+
+	// A changed label renames the context.
+	contextLabelValues = map[string]string{"cluster-a": "eu-nl-1"}
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts).To(HaveLen(2))
+	g.Expect(localConfig.Contexts).To(HaveKey("eu-nl-1:cluster-a"))
+}
+
+func TestMergeKubeconfig_ContextRenames(t *testing.T) {
+	g := NewWithT(t)
+
+	origPrefix, origRenames, origMerge := prefix, contextRenames, mergeIdenticalUsers
+	t.Cleanup(func() { prefix, contextRenames, mergeIdenticalUsers = origPrefix, origRenames, origMerge })
+	prefix, mergeIdenticalUsers = "cloudctl", false
+	contextRenames = map[string]string{"prod-eu-1": "prod"}
+
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.AuthInfos["user"] = &clientcmdapi.AuthInfo{Token: "t"}
+	for _, name := range []string{"prod-eu-1", "qa-eu-1"} {
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ".example.com"}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: "user"}
+	}
+
+	localConfig := clientcmdapi.NewConfig()
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts).To(HaveLen(2))
+	g.Expect(localConfig.Contexts["prod"]).To(HaveField("Cluster", "cloudctl:prod-eu-1"))
+	g.Expect(localConfig.Contexts).To(HaveKey("qa-eu-1"))
+
+	// The renamed context is matched back to its server-side name when pruning.
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts).To(HaveKey("prod"))
+
+	contextRenames = nil
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Contexts).NotTo(HaveKey("prod"))
+	g.Expect(localConfig.Contexts).To(HaveKey("prod-eu-1"))
+}
+
+func TestLoadContextRenames(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { viper.Set("context-renames", nil) })
+
+	viper.Set("context-renames", map[string]any{"prod-eu-1": "prod", "qa-eu-1": "qa"})
+	renames, err := loadContextRenames()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(renames).To(Equal(map[string]string{"prod-eu-1": "prod", "qa-eu-1": "qa"}))
+
+	viper.Set("context-renames", map[string]any{"prod-eu-1": "prod", "prod-us-1": "prod"})
+	_, err = loadContextRenames()
+	g.Expect(err).To(MatchError(`invalid context-renames config: "prod-eu-1" and "prod-us-1" are both renamed to "prod"`))
+}
+
+func TestMergeKubeconfig_MergeIdenticalClusters(t *testing.T) {
+	g := NewWithT(t)
+
+	origPrefix, origMergeUsers, origMergeClusters := prefix, mergeIdenticalUsers, mergeIdenticalClusters
+	t.Cleanup(func() {
+		prefix, mergeIdenticalUsers, mergeIdenticalClusters = origPrefix, origMergeUsers, origMergeClusters
+	})
+	prefix, mergeIdenticalUsers, mergeIdenticalClusters = "cloudctl", false, true
+
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.AuthInfos["user"] = &clientcmdapi.AuthInfo{Token: "t"}
+	for name, server := range map[string]string{"eu-admin": "https://eu", "eu-viewer": "https://eu", "us": "https://us"} {
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: server, CertificateAuthorityData: []byte("ca")}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: "user"}
+	}
+
+	localConfig := clientcmdapi.NewConfig()
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Clusters).To(HaveLen(2))
+	g.Expect(localConfig.Clusters).To(HaveKey("cloudctl:eu-admin"))
+	g.Expect(localConfig.Clusters).To(HaveKey("cloudctl:us"))
+	g.Expect(localConfig.Contexts["eu-admin"]).To(HaveField("Cluster", "cloudctl:eu-admin"))
+	g.Expect(localConfig.Contexts["eu-viewer"]).To(HaveField("Cluster", "cloudctl:eu-admin"))
+
+	// A second sync is stable, and turning the option off splits them again.
+	before := localConfig.DeepCopy()
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(kubeconfigEqual(before, localConfig)).To(BeTrue())
+
+	mergeIdenticalClusters = false
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.Clusters).To(HaveLen(3))
+	g.Expect(localConfig.Contexts["eu-viewer"]).To(HaveField("Cluster", "cloudctl:eu-viewer"))
+}
+
 func TestMergeKubeconfig_DeduplicatesSameOIDCUsers(t *testing.T) {
 	g := NewWithT(t)
 
@@ -676,6 +976,144 @@ func TestMergeKubeconfig_DeduplicatesSameOIDCUsers(t *testing.T) {
 	g.Expect(ctxB.AuthInfo).To(Equal(sharedAuthName))
 }
 
+func TestMergeKubeconfig_SplitMergedUserKeepsTokens(t *testing.T) {
+	g := NewWithT(t)
+
+	orig := prefix
+	origMerge := mergeIdenticalUsers
+	prefix = "cloudctl"
+	mergeIdenticalUsers = true
+	t.Cleanup(func() {
+		prefix = orig
+		mergeIdenticalUsers = origMerge
+	})
+
+	oidcAuth := func(connector string) *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+			Name: "oidc",
+			Config: map[string]string{
+				"idp-issuer-url":            "https://issuer.example.com",
+				"client-id":                 "cid",
+				"auth-request-extra-params": "connector_id=" + connector,
+			},
+		}}
+	}
+	serverConfig := clientcmdapi.NewConfig()
+	serverConfig.AuthInfos["ldap-user"] = oidcAuth("ldap")
+	serverConfig.AuthInfos["github-user"] = oidcAuth("github")
+	for name, user := range map[string]string{"cluster-a": "ldap-user", "cluster-b": "github-user"} {
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ".example.com"}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: user}
+	}
+
+	// An earlier version merged both users into one entry holding the ldap
+	// configuration and tokens.
+	localConfig := clientcmdapi.NewConfig()
+	legacy := oidcAuth("ldap")
+	legacy.AuthProvider.Config["id-token"] = "ldap-token"
+	localConfig.AuthInfos["cloudctl:auth-0123456789abcdef"] = legacy
+	for _, name := range []string{"cluster-a", "cluster-b"} {
+		localConfig.Clusters["cloudctl:"+name] = serverConfig.Clusters[name].DeepCopy()
+		localConfig.Contexts[name] = &clientcmdapi.Context{Cluster: "cloudctl:" + name, AuthInfo: "cloudctl:auth-0123456789abcdef"}
+	}
+
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(localConfig.AuthInfos).ToNot(HaveKey("cloudctl:auth-0123456789abcdef"))
+	ldapUser := localConfig.AuthInfos[localConfig.Contexts["cluster-a"].AuthInfo]
+	githubUser := localConfig.AuthInfos[localConfig.Contexts["cluster-b"].AuthInfo]
+	g.Expect(localConfig.Contexts["cluster-a"].AuthInfo).ToNot(Equal(localConfig.Contexts["cluster-b"].AuthInfo))
+	g.Expect(ldapUser.AuthProvider.Config).To(HaveKeyWithValue("id-token", "ldap-token"), "tokens follow the user to its new name")
+	g.Expect(githubUser.AuthProvider.Config).ToNot(HaveKey("id-token"), "tokens of another IdP are not shared")
+}
+
+func TestReportSharedUsers(t *testing.T) {
+	g := NewWithT(t)
+
+	orig := prefix
+	origMerge := mergeIdenticalUsers
+	prefix = "cloudctl"
+	mergeIdenticalUsers = true
+	t.Cleanup(func() {
+		prefix = orig
+		mergeIdenticalUsers = origMerge
+		viper.Set("show-shared-users", nil)
+	})
+
+	oidcAuth := func(issuer string) *clientcmdapi.AuthInfo {
+		return &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+			Name: "oidc",
+			Config: map[string]string{
+				"idp-issuer-url":            issuer,
+				"client-id":                 "cid",
+				"extra-scopes":              "groups,email",
+				"auth-request-extra-params": "connector_id=ldap",
+			},
+		}}
+	}
+	serverConfig := clientcmdapi.NewConfig()
+	for name, issuer := range map[string]string{"a": "https://idp", "b": "https://idp", "c": "https://other-idp"} {
+		serverConfig.AuthInfos[name+"-user"] = oidcAuth(issuer)
+		serverConfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ".example.com"}
+		serverConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name + "-user"}
+	}
+	localConfig := clientcmdapi.NewConfig()
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+
+	g.Expect(reportSharedUsers(localConfig, serverConfig)).To(BeNil(), "only reported on request")
+	viper.Set("show-shared-users", true)
+	g.Expect(reportSharedUsers(localConfig, serverConfig)).To(Equal([]output.SharedUser{{
+		User:      localConfig.Contexts["a"].AuthInfo,
+		Contexts:  []string{"a", "b"},
+		Auth:      output.ClusterInfoAuth{Mechanism: "oidc", Issuer: "https://idp", ClientID: "cid"},
+		Connector: "ldap",
+		Scopes:    []string{"email", "groups"},
+	}}))
+}
+
+func TestSync_KeepsPreferencesAndExtensions(t *testing.T) {
+	g := NewWithT(t)
+
+	orig, origType := prefix, authType
+	prefix, authType = "cloudctl", "auth-provider"
+	t.Cleanup(func() { prefix, authType = orig, origType })
+
+	path := filepath.Join(t.TempDir(), "config")
+	g.Expect(os.WriteFile(path, []byte(`apiVersion: v1
+kind: Config
+preferences:
+  colors: true
+  extensions:
+  - name: editor
+    extension: {theme: dark}
+extensions:
+- name: tool
+  extension: {enabled: true}
+`), 0o600)).To(Succeed())
+
+	serverConfig, err := buildIncomingKubeconfig([]greenhousev1alpha1.ClusterKubeconfig{{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+			Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: "prod", Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: "https://prod.example.com"}}},
+			Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "prod", Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: "prod", AuthInfo: "prod"}}},
+			AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{Name: "prod", AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{
+				AuthProvider: clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"idp-issuer-url": "https://issuer.example.com", "client-id": "cid"}},
+			}}},
+		}},
+	}})
+	g.Expect(err).NotTo(HaveOccurred())
+	localConfig, err := loadLocalKubeconfig(context.Background(), path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mergeKubeconfig(localConfig, serverConfig)).To(Succeed())
+	g.Expect(writeConfig(localConfig, path)).To(Succeed())
+
+	written, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.Contexts).To(HaveKey("prod"))
+	g.Expect(written.Preferences.Colors).To(BeTrue())
+	g.Expect(string(extensionRaw(written.Preferences.Extensions, "editor"))).To(MatchJSON(`{"theme":"dark"}`))
+	g.Expect(string(extensionRaw(written.Extensions, "tool"))).To(MatchJSON(`{"enabled":true}`))
+}
+
 // ---------------------------------------------------------------------------
 // Dry-run / diffKubeconfig tests (#51)
 // ---------------------------------------------------------------------------
@@ -684,6 +1122,43 @@ func newCfg() *clientcmdapi.Config {
 	return clientcmdapi.NewConfig()
 }
 
+// newManagedCfg returns a kubeconfig as sync leaves it: a context per name
+// whose cluster and user carry the cloudctl prefix, next to an unmanaged
+// "local" context.
+func newManagedCfg(names ...string) *clientcmdapi.Config {
+	cfg := newCfg()
+	for _, n := range names {
+		cfg.Clusters["cloudctl:"+n] = &clientcmdapi.Cluster{Server: "https://" + n + ".example"}
+		cfg.AuthInfos["cloudctl:"+n] = &clientcmdapi.AuthInfo{Token: "t"}
+		// Like sync, only the cluster and user carry the prefix.
+		cfg.Contexts[n] = &clientcmdapi.Context{Cluster: "cloudctl:" + n, AuthInfo: "cloudctl:" + n}
+	}
+	cfg.Clusters["local"] = &clientcmdapi.Cluster{Server: "https://local.example"}
+	cfg.AuthInfos["local"] = &clientcmdapi.AuthInfo{Token: "t"}
+	cfg.Contexts["local"] = &clientcmdapi.Context{Cluster: "local", AuthInfo: "local"}
+	return cfg
+}
+
+// writeTestKubeconfig writes cfg to a temporary file and points --kubeconfig
+// at it, with the cloudctl prefix and text output.
+func writeTestKubeconfig(t *testing.T, cfg *clientcmdapi.Config) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("kubeconfig", path)
+	viper.Set("output", "text")
+	viper.Set("prefix", "cloudctl")
+	t.Cleanup(func() {
+		viper.Set("kubeconfig", nil)
+		viper.Set("output", nil)
+		viper.Set("prefix", nil)
+	})
+	return path
+}
+
 func TestDiffKubeconfig_AddedCluster(t *testing.T) {
 	g := NewWithT(t)
 	orig := prefix
@@ -847,6 +1322,115 @@ func TestDiffKubeconfig_UnmanagedEntriesIgnored(t *testing.T) {
 	g.Expect(diff.AuthInfos).To(BeEmpty())
 }
 
+func TestRunSync_ChangedExitCodeWrapsErrors(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("ca-expiry-warn-days", -1)
+	t.Cleanup(func() {
+		viper.Set("ca-expiry-warn-days", nil)
+		viper.Set("changed-exit-code", nil)
+	})
+
+	err := runSync(syncCmd, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("must not be negative")))
+	g.Expect(errors.As(err, new(*ExitError))).To(BeFalse())
+
+	viper.Set("changed-exit-code", true)
+	err = runSync(syncCmd, nil)
+	var exitErr *ExitError
+	g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+	g.Expect(exitErr.Code).To(Equal(ExitCodeError))
+	g.Expect(err).To(MatchError(ContainSubstring("must not be negative")))
+}
+
+func TestRunSync_CI(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("ci", true)
+	viper.Set("watch", true)
+	t.Cleanup(func() {
+		viper.Set("ci", nil)
+		viper.Set("watch", nil)
+	})
+
+	g.Expect(OutputFormat()).To(Equal("json"))
+	g.Expect(canPrompt()).To(BeFalse())
+
+	err := runSync(syncCmd, nil)
+	var exitErr *ExitError
+	g.Expect(errors.As(err, &exitErr)).To(BeTrue(), "--ci implies --changed-exit-code")
+	g.Expect(exitErr.Code).To(Equal(ExitCodeError))
+	g.Expect(err).To(MatchError(ContainSubstring("--ci cannot be combined with --watch")))
+}
+
+func TestPartitionValid(t *testing.T) {
+	g := NewWithT(t)
+	ckc := func(name, user string) greenhousev1alpha1.ClusterKubeconfig {
+		return greenhousev1alpha1.ClusterKubeconfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: name, Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: "https://" + name}}},
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{Name: name}},
+				Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: name, Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: name, AuthInfo: user}}},
+			}},
+		}
+	}
+
+	valid, invalid := partitionValid([]greenhousev1alpha1.ClusterKubeconfig{ckc("good", "good"), ckc("bad", "missing")}, nil)
+	g.Expect(valid).To(HaveLen(1))
+	g.Expect(valid[0].Name).To(Equal("good"))
+	g.Expect(invalid).To(Equal([]output.ClusterSyncResult{{
+		Name:    "bad",
+		Context: "bad",
+		Status:  output.ClusterSyncStatusFailed,
+		Reason:  `context "bad" references missing user "missing"`,
+	}}))
+
+	valid, invalid = partitionValid([]greenhousev1alpha1.ClusterKubeconfig{ckc("bad", "missing")}, map[string]bool{"bad": true})
+	g.Expect(valid).To(HaveLen(1), "entries validated by the last sync are not checked again")
+	g.Expect(invalid).To(BeEmpty())
+
+	valid, invalid = partitionValid([]greenhousev1alpha1.ClusterKubeconfig{ckc("good", "good"), ckc("bad", "missing")}, nil)
+	result := withInvalid(buildSyncResult(valid, nil), invalid)
+	g.Expect(result.Synced).To(Equal(1))
+	g.Expect(result.Failed).To(Equal(1))
+}
+
+func TestValidateClusterKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	ckc := func(server string, ca []byte, user string) greenhousev1alpha1.ClusterKubeconfig {
+		return greenhousev1alpha1.ClusterKubeconfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu"},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{Name: "eu", Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: server, CertificateAuthorityData: ca}}},
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{Name: "eu"}},
+				Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{{Name: "eu", Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: "eu", AuthInfo: user}}},
+			}},
+		}
+	}
+	ca := testCertPEM(t, time.Now().AddDate(1, 0, 0))
+
+	g.Expect(validateClusterKubeconfig(ckc("https://eu.example", ca, "eu"))).To(Succeed())
+	g.Expect(validateClusterKubeconfig(ckc("https://eu.example", nil, "eu"))).To(Succeed(), "the system roots are used without CA data")
+	g.Expect(validateClusterKubeconfig(ckc("https://eu.example", append(append([]byte{}, ca...), ca...), "eu"))).To(Succeed())
+
+	g.Expect(validateClusterKubeconfig(ckc("http://eu.example", ca, "eu"))).To(MatchError(`cluster "eu": server URL "http://eu.example" is not an https URL`))
+	g.Expect(validateClusterKubeconfig(ckc("", ca, "eu"))).To(MatchError(`cluster "eu": no server`))
+	g.Expect(validateClusterKubeconfig(ckc("https://eu.example", []byte("garbage"), "eu"))).To(MatchError(`cluster "eu": invalid CA data: not PEM encoded`))
+	g.Expect(validateClusterKubeconfig(ckc("eu.example:6443", []byte("garbage"), "missing"))).To(MatchError(
+		`cluster "eu": server URL "eu.example:6443" is not an https URL; cluster "eu": invalid CA data: not PEM encoded; context "eu" references missing user "missing"`))
+}
+
+func TestKubeconfigEqual(t *testing.T) {
+	g := NewWithT(t)
+	a := clientcmdapi.NewConfig()
+	a.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{Server: "https://eu.example.com", LocationOfOrigin: "/a"}
+	b := a.DeepCopy()
+	b.Clusters["cloudctl:eu"].LocationOfOrigin = "/b"
+	g.Expect(kubeconfigEqual(a, b)).To(BeTrue())
+
+	b.Clusters["cloudctl:eu"].Server = "https://eu2.example.com"
+	g.Expect(kubeconfigEqual(a, b)).To(BeFalse())
+}
+
 func TestDryRun_MergeAndDiff_NoWrite(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1055,3 +1639,265 @@ func TestWriteTargetFromKUBECONFIG(t *testing.T) {
 		})
 	}
 }
+
+func TestExecCommandFor(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(execCommandFor("kubelogin", "linux")).To(Equal("kubelogin"))
+	g.Expect(execCommandFor("kubelogin", "windows")).To(Equal("kubelogin.exe"))
+	g.Expect(execCommandFor(`C:\tools\kubelogin.exe`, "windows")).To(Equal(`C:\tools\kubelogin.exe`))
+	g.Expect(execCommandFor(`C:\tools\kubelogin`, "windows")).To(Equal(`C:\tools\kubelogin.exe`))
+}
+
+// stubGreenhouseClient serves canned Greenhouse data for team filtering tests.
+type stubGreenhouseClient struct {
+	greenhouse.Client
+	username string
+	teams    []greenhousev1alpha1.Team
+	bindings []greenhousev1alpha2.TeamRoleBinding
+	allowed  bool
+	grants   []greenhouse.Grant
+	ckcs     []greenhousev1alpha1.ClusterKubeconfig
+	orgs     []greenhousev1alpha1.Organization
+	clusters []greenhousev1alpha1.Cluster
+}
+
+func (s *stubGreenhouseClient) ListClusterKubeconfigs(context.Context, string) ([]greenhousev1alpha1.ClusterKubeconfig, error) {
+	return s.ckcs, nil
+}
+
+func (s *stubGreenhouseClient) GetClusterKubeconfig(_ context.Context, _, name string) (*greenhousev1alpha1.ClusterKubeconfig, error) {
+	for i := range s.ckcs {
+		if s.ckcs[i].Name == name {
+			return &s.ckcs[i], nil
+		}
+	}
+	return nil, apierrors.NewNotFound(greenhousev1alpha1.GroupVersion.WithResource("clusterkubeconfigs").GroupResource(), name)
+}
+
+func (s *stubGreenhouseClient) ListClusters(context.Context, string) ([]greenhousev1alpha1.Cluster, error) {
+	return s.clusters, nil
+}
+
+func (s *stubGreenhouseClient) CanI(context.Context, greenhouse.ResourceAttributes) (bool, string, error) {
+	return s.allowed, "", nil
+}
+
+func (s *stubGreenhouseClient) FindGrants(context.Context, greenhouse.ResourceAttributes) ([]greenhouse.Grant, error) {
+	return s.grants, nil
+}
+
+func (s *stubGreenhouseClient) ListOrganizations(context.Context) ([]greenhousev1alpha1.Organization, error) {
+	return s.orgs, nil
+}
+
+func (s *stubGreenhouseClient) WhoAmI(context.Context) (string, error) { return s.username, nil }
+
+func (s *stubGreenhouseClient) ListTeams(context.Context, string) ([]greenhousev1alpha1.Team, error) {
+	return s.teams, nil
+}
+
+func (s *stubGreenhouseClient) ListTeamRoleBindings(context.Context, string) ([]greenhousev1alpha2.TeamRoleBinding, error) {
+	return s.bindings, nil
+}
+
+func TestDiscoverOrgNamespace(t *testing.T) {
+	g := NewWithT(t)
+	prev := canPrompt
+	canPrompt = func() bool { return false }
+	t.Cleanup(func() { canPrompt = prev })
+
+	org := func(name string) greenhousev1alpha1.Organization {
+		return greenhousev1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	ctx := context.Background()
+
+	ns, err := discoverOrgNamespace(ctx, &stubGreenhouseClient{allowed: true, orgs: []greenhousev1alpha1.Organization{org("org-a")}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ns).To(Equal("org-a"))
+
+	_, err = discoverOrgNamespace(ctx, &stubGreenhouseClient{allowed: true, orgs: []greenhousev1alpha1.Organization{org("org-b"), org("org-a")}})
+	g.Expect(err).To(MatchError(ContainSubstring("several organizations are accessible: org-a, org-b")))
+
+	_, err = discoverOrgNamespace(ctx, &stubGreenhouseClient{allowed: false, orgs: []greenhousev1alpha1.Organization{org("org-a")}})
+	g.Expect(err).To(MatchError(ContainSubstring("cannot list ClusterKubeconfigs in any organization")))
+}
+
+func TestFilterByTeamBindings(t *testing.T) {
+	g := NewWithT(t)
+	c := &stubGreenhouseClient{
+		username: "jane@example.com",
+		teams: []greenhousev1alpha1.Team{{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Status:     greenhousev1alpha1.TeamStatus{Members: []greenhousev1alpha1.User{{Email: "jane@example.com"}}},
+		}},
+		bindings: []greenhousev1alpha2.TeamRoleBinding{{
+			Spec:   greenhousev1alpha2.TeamRoleBindingSpec{TeamRef: "platform"},
+			Status: greenhousev1alpha2.TeamRoleBindingStatus{PropagationStatus: []greenhousev1alpha2.PropagationStatus{{ClusterName: "eu-1"}}},
+		}},
+	}
+	items := []greenhousev1alpha1.ClusterKubeconfig{
+		{ObjectMeta: metav1.ObjectMeta{Name: "eu-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "us-1"}},
+	}
+
+	kept, err := filterByTeamBindings(context.Background(), c, "org", items)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kept).To(HaveLen(1))
+	g.Expect(kept[0].Name).To(Equal("eu-1"))
+}
+
+func TestApplyImpersonation(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg := &rest.Config{}
+	g.Expect(applyImpersonation(cfg, "", nil, "")).To(Succeed())
+	g.Expect(cfg.Impersonate).To(BeZero())
+
+	g.Expect(applyImpersonation(cfg, "", []string{"devs"}, "")).To(MatchError(ContainSubstring("require --as")))
+	g.Expect(applyImpersonation(cfg, "", nil, "1234")).To(MatchError(ContainSubstring("require --as")))
+
+	g.Expect(applyImpersonation(cfg, "jane", []string{"devs", "ops"}, "1234")).To(Succeed())
+	g.Expect(cfg.Impersonate).To(Equal(rest.ImpersonationConfig{UserName: "jane", Groups: []string{"devs", "ops"}, UID: "1234"}))
+}
+
+func TestCredentialPluginCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateAuthType("cloudctl", "")).To(Succeed())
+	g.Expect(credentialPluginCommand("auth-provider", "kubelogin")).To(BeEmpty())
+	g.Expect(credentialPluginCommand("exec-plugin", "/opt/kubelogin")).To(Equal(execCommand("/opt/kubelogin")))
+
+	// Not on PATH: the running binary is referenced by absolute path.
+	t.Setenv("PATH", t.TempDir())
+	self, err := os.Executable()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(credentialPluginCommand("cloudctl", "kubelogin")).To(Equal(self))
+}
+
+func TestApplyGreenhouseTLS(t *testing.T) {
+	g := NewWithT(t)
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	g.Expect(os.WriteFile(caFile, caPEM, 0o600)).To(Succeed())
+	badFile := filepath.Join(dir, "bad.pem")
+	g.Expect(os.WriteFile(badFile, []byte("not a cert"), 0o600)).To(Succeed())
+
+	cfg := &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("old"), CAFile: "/old"}}
+	g.Expect(applyGreenhouseTLS(cfg, caFile, "greenhouse.internal", false)).To(Succeed())
+	g.Expect(cfg.CAData).To(Equal(caPEM))
+	g.Expect(cfg.CAFile).To(BeEmpty())
+	g.Expect(cfg.ServerName).To(Equal("greenhouse.internal"))
+
+	cfg = &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("old")}}
+	g.Expect(applyGreenhouseTLS(cfg, "", "", true)).To(Succeed())
+	g.Expect(cfg.Insecure).To(BeTrue())
+	g.Expect(cfg.CAData).To(BeNil())
+
+	g.Expect(applyGreenhouseTLS(&rest.Config{}, caFile, "", true)).To(MatchError(ContainSubstring("mutually exclusive")))
+	g.Expect(applyGreenhouseTLS(&rest.Config{}, badFile, "", false)).To(MatchError(ContainSubstring("no PEM certificates")))
+}
+
+func TestApplyGreenhouseProxy(t *testing.T) {
+	g := NewWithT(t)
+
+	// The proxy answers every request itself, so the Greenhouse host need not exist.
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	cfg := &rest.Config{Host: "http://greenhouse.invalid"}
+	g.Expect(applyGreenhouseProxy(cfg, proxy.URL)).To(Succeed())
+	client, err := rest.HTTPClientFor(cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	resp, err := client.Get("http://greenhouse.invalid/version")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.Body.Close()).To(Succeed())
+	g.Expect(proxied).To(Equal("http://greenhouse.invalid/version"))
+
+	cfg = &rest.Config{}
+	g.Expect(applyGreenhouseProxy(cfg, "")).To(Succeed())
+	g.Expect(cfg.Proxy).To(BeNil(), "client-go falls back to the environment")
+	g.Expect(applyGreenhouseProxy(cfg, "ftp://proxy:21")).To(MatchError(ContainSubstring("scheme must be")))
+	g.Expect(applyGreenhouseProxy(cfg, "http://")).To(MatchError(ContainSubstring("no host")))
+}
+
+func TestCAExpiryWarnings(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ckc := func(name string, ca []byte) greenhousev1alpha1.ClusterKubeconfig {
+		return greenhousev1alpha1.ClusterKubeconfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{{
+					Name:    name,
+					Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{CertificateAuthorityData: ca},
+				}},
+			}},
+		}
+	}
+
+	warnings := caExpiryWarnings([]greenhousev1alpha1.ClusterKubeconfig{
+		ckc("fine", testCertPEM(t, now.AddDate(1, 0, 0))),
+		ckc("soon", testCertPEM(t, now.AddDate(0, 0, 12))),
+		ckc("gone", testCertPEM(t, now.AddDate(0, 0, -2))),
+		ckc("none", nil),
+	}, now, 30)
+
+	g.Expect(warnings).To(Equal(map[string][]string{
+		"soon": {`CA certificate of cluster "soon" expires on 2026-01-13 (in 12 day(s))`},
+		"gone": {`CA certificate of cluster "gone" expired on 2025-12-30`},
+	}))
+}
+
+func TestRunSync_BenchmarkWatch(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("benchmark", true)
+	viper.Set("watch", true)
+	t.Cleanup(func() {
+		viper.Set("benchmark", nil)
+		viper.Set("watch", nil)
+	})
+
+	err := runSync(syncCmd, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("--benchmark cannot be combined with --watch")))
+}
+
+func TestBuildIncomingKubeconfig_OmitClientSecret(t *testing.T) {
+	g := NewWithT(t)
+	prevType, prevPath, prevOmit := authType, kubeloginPath, omitClientSecret
+	t.Cleanup(func() { authType, kubeloginPath, omitClientSecret = prevType, prevPath, prevOmit })
+	kubeloginPath, omitClientSecret = "kubelogin", true
+
+	providerCfg := map[string]string{"idp-issuer-url": "https://issuer.example.com", "client-id": "cid", "client-secret": "shared"}
+	items := []greenhousev1alpha1.ClusterKubeconfig{{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+			AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{{
+				Name:     "prod",
+				AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{AuthProvider: clientcmdapi.AuthProviderConfig{Name: "oidc", Config: providerCfg}},
+			}},
+		}},
+	}}
+
+	authType = "exec-plugin"
+	cfg, err := buildIncomingKubeconfig(items)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AuthInfos["prod"].Exec.Args).To(ContainElement("--oidc-client-id=cid"))
+	g.Expect(cfg.AuthInfos["prod"].Exec.Args).NotTo(ContainElement(HavePrefix("--oidc-client-secret")))
+
+	authType = "auth-provider"
+	cfg, err = buildIncomingKubeconfig(items)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AuthInfos["prod"].AuthProvider.Config).To(HaveKeyWithValue("client-id", "cid"))
+	g.Expect(cfg.AuthInfos["prod"].AuthProvider.Config).NotTo(HaveKey("client-secret"))
+	g.Expect(providerCfg).To(HaveKey("client-secret"), "the ClusterKubeconfig is not modified")
+}
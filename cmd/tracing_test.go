@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTracingEnabled(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	g.Expect(tracingEnabled()).To(BeFalse(), "tracing must be opt-in")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	g.Expect(tracingEnabled()).To(BeTrue())
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	g.Expect(tracingEnabled()).To(BeFalse(), "OTEL_SDK_DISABLED must win")
+}
+
+func TestTracingRoundTripper_RecordsSpanAndPropagates(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator, prevTracer := otel.GetTracerProvider(), otel.GetTextMapPropagator(), tracer
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	// tracer is bound to the first provider ever installed, so it is swapped too.
+	tracer = tp.Tracer("github.com/cloudoperators/cloudctl")
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		tracer = prevTracer
+		_ = tp.Shutdown(context.Background())
+	})
+
+	var gotTraceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: &tracingRoundTripper{next: http.DefaultTransport}}
+	resp, err := client.Get(srv.URL + "/apis")
+	g.Expect(err).ToNot(HaveOccurred())
+	_ = resp.Body.Close()
+
+	g.Expect(gotTraceparent).ToNot(BeEmpty())
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].Name()).To(Equal("HTTP GET"))
+	g.Expect(spans[0].Status().Description).To(ContainSubstring("403"))
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+)
+
+// tunnelRule routes the probes of fan-out commands to clusters whose labels
+// match Selector through a SOCKS5 proxy or an SSH jump host. Rules are
+// configured with the "tunnels" config-file key; the first matching rule
+// wins.
+//
+//	tunnels:
+//	  - selector: {region: cn-north-1}
+//	    socks5: socks5://localhost:1080
+//	  - selector: {network: restricted}
+//	    ssh: jump@bastion.example.com
+type tunnelRule struct {
+	Selector map[string]string `mapstructure:"selector"`
+	SOCKS5   string            `mapstructure:"socks5"`
+	SSH      string            `mapstructure:"ssh"`
+}
+
+// matches reports whether labels carry every label of the selector. Keys are
+// compared case-insensitively because the config file lowercases them.
+func (r tunnelRule) matches(labels map[string]string) bool {
+	for k, v := range r.Selector {
+		found := false
+		for lk, lv := range labels {
+			if strings.EqualFold(lk, k) && lv == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// loadTunnelRules reads and validates the "tunnels" config-file key.
+func loadTunnelRules() ([]tunnelRule, error) {
+	var rules []tunnelRule
+	if err := viper.UnmarshalKey("tunnels", &rules); err != nil {
+		return nil, fmt.Errorf("invalid tunnels config: %w", err)
+	}
+	for i, r := range rules {
+		switch {
+		case len(r.Selector) == 0:
+			return nil, fmt.Errorf("invalid tunnels config: entry %d has no selector", i+1)
+		case (r.SOCKS5 == "") == (r.SSH == ""):
+			return nil, fmt.Errorf("invalid tunnels config: entry %d needs exactly one of socks5 or ssh", i+1)
+		case r.SOCKS5 != "":
+			u, err := url.Parse(r.SOCKS5)
+			if err != nil || (u.Scheme != "socks5" && u.Scheme != "socks5h") || u.Host == "" {
+				return nil, fmt.Errorf("invalid tunnels config: entry %d: socks5 must be a socks5:// URL, got %q", i+1, r.SOCKS5)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// tunnels resolves the proxy for each probed cluster. SSH jump hosts are
+// started on first use and shared by all clusters routed through them; Close
+// stops them.
+type tunnels struct {
+	rules []tunnelRule
+	mu    sync.Mutex
+	ssh   map[string]*sshTunnel
+}
+
+// sshTunnel is a running SSH jump host, or the error starting it.
+type sshTunnel struct {
+	proxy *url.URL
+	stop  func()
+	err   error
+}
+
+// newTunnels loads the tunnel rules from the config file.
+func newTunnels() (*tunnels, error) {
+	rules, err := loadTunnelRules()
+	if err != nil {
+		return nil, err
+	}
+	return &tunnels{rules: rules, ssh: map[string]*sshTunnel{}}, nil
+}
+
+// proxyFor returns the proxy to reach a cluster with labels through, or nil
+// to connect as usual.
+func (t *tunnels) proxyFor(ctx context.Context, labels map[string]string) (*url.URL, error) {
+	if t == nil {
+		return nil, nil
+	}
+	for _, r := range t.rules {
+		if !r.matches(labels) {
+			continue
+		}
+		if r.SOCKS5 != "" {
+			return url.Parse(r.SOCKS5)
+		}
+		return t.sshProxy(ctx, r.SSH)
+	}
+	return nil, nil
+}
+
+// apply routes cfg through the proxy for labels, if any.
+func (t *tunnels) apply(ctx context.Context, cfg *rest.Config, labels map[string]string) error {
+	proxy, err := t.proxyFor(ctx, labels)
+	if err != nil {
+		return err
+	}
+	if proxy != nil {
+		cfg.Proxy = http.ProxyURL(proxy)
+	}
+	return nil
+}
+
+func (t *tunnels) sshProxy(ctx context.Context, destination string) (*url.URL, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tun, ok := t.ssh[destination]
+	if !ok {
+		slog.Debug("starting SSH tunnel", "destination", destination)
+		proxy, stop, err := startSSHTunnel(ctx, destination)
+		tun = &sshTunnel{proxy: proxy, stop: stop, err: err}
+		// A failed start is remembered so the other clusters behind the same
+		// jump host fail fast instead of retrying.
+		t.ssh[destination] = tun
+	}
+	if tun.err != nil {
+		return nil, fmt.Errorf("SSH tunnel to %s: %w", destination, tun.err)
+	}
+	return tun.proxy, nil
+}
+
+// Close stops the SSH jump hosts started so far.
+func (t *tunnels) Close() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tun := range t.ssh {
+		if tun.stop != nil {
+			tun.stop()
+		}
+	}
+	t.ssh = map[string]*sshTunnel{}
+}
+
+// sshTunnelStartTimeout bounds how long an SSH jump host may take to accept
+// connections.
+const sshTunnelStartTimeout = 15 * time.Second
+
+// startSSHTunnel runs `ssh -N -D` to destination, which serves a SOCKS5 proxy
+// on a free local port, and waits until it accepts connections. The user's
+// SSH configuration and agent are used; ssh must not prompt. It is a variable
+// so tests can avoid running ssh.
+var startSSHTunnel = func(ctx context.Context, destination string) (*url.URL, func(), error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	// Not bound to ctx: the tunnel outlives the probe that started it.
+	cmd := exec.Command("ssh", "-N", "-D", addr, "-o", "BatchMode=yes", "-o", "ExitOnForwardFailure=yes", destination) // #nosec G204 -- destination comes from the user's config file
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to run ssh: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	stop := func() {
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sshTunnelStartTimeout)
+	defer cancel()
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			_ = conn.Close()
+			return &url.URL{Scheme: "socks5", Host: addr}, stop, nil
+		}
+		select {
+		case err := <-exited:
+			return nil, nil, fmt.Errorf("ssh exited before the tunnel was up (%v); check that `ssh %s` works without prompting", err, destination)
+		case <-ctx.Done():
+			stop()
+			return nil, nil, fmt.Errorf("ssh did not open %s: %w", addr, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
+)
+
+func setTunnelsConfig(t *testing.T, rules []map[string]any) {
+	t.Helper()
+	viper.Set("tunnels", rules)
+	t.Cleanup(func() { viper.Set("tunnels", nil) })
+}
+
+func TestLoadTunnelRules(t *testing.T) {
+	g := NewWithT(t)
+
+	setTunnelsConfig(t, nil)
+	rules, err := loadTunnelRules()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rules).To(BeEmpty())
+
+	for _, tc := range []struct {
+		rule map[string]any
+		err  string
+	}{
+		{map[string]any{"socks5": "socks5://localhost:1080"}, "has no selector"},
+		{map[string]any{"selector": map[string]string{"region": "cn"}}, "exactly one of socks5 or ssh"},
+		{map[string]any{"selector": map[string]string{"region": "cn"}, "socks5": "socks5://localhost:1080", "ssh": "bastion"}, "exactly one of socks5 or ssh"},
+		{map[string]any{"selector": map[string]string{"region": "cn"}, "socks5": "http://localhost:3128"}, "must be a socks5:// URL"},
+	} {
+		setTunnelsConfig(t, []map[string]any{tc.rule})
+		_, err := loadTunnelRules()
+		g.Expect(err).To(MatchError(ContainSubstring(tc.err)), "%v", tc.rule)
+	}
+}
+
+func TestTunnels_ProxyFor(t *testing.T) {
+	g := NewWithT(t)
+	setTunnelsConfig(t, []map[string]any{
+		{"selector": map[string]string{"Region": "cn"}, "socks5": "socks5://localhost:1080"},
+		{"selector": map[string]string{"network": "restricted"}, "ssh": "jump@bastion"},
+		{"selector": map[string]string{"network": "broken"}, "ssh": "jump@broken"},
+	})
+
+	var started, stopped []string
+	prev := startSSHTunnel
+	startSSHTunnel = func(_ context.Context, destination string) (*url.URL, func(), error) {
+		started = append(started, destination)
+		if destination == "jump@broken" {
+			return nil, nil, errors.New("permission denied")
+		}
+		return &url.URL{Scheme: "socks5", Host: "127.0.0.1:40000"}, func() { stopped = append(stopped, destination) }, nil
+	}
+	t.Cleanup(func() { startSSHTunnel = prev })
+
+	tun, err := newTunnels()
+	g.Expect(err).NotTo(HaveOccurred())
+	ctx := context.Background()
+
+	proxy, err := tun.proxyFor(ctx, map[string]string{"region": "cn", "env": "prod"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(proxy.String()).To(Equal("socks5://localhost:1080"))
+
+	proxy, err = tun.proxyFor(ctx, map[string]string{"region": "eu"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(proxy).To(BeNil(), "clusters without a matching rule connect directly")
+
+	for range 2 {
+		proxy, err = tun.proxyFor(ctx, map[string]string{"network": "restricted"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(proxy.String()).To(Equal("socks5://127.0.0.1:40000"))
+	}
+	for range 2 {
+		_, err = tun.proxyFor(ctx, map[string]string{"network": "broken"})
+		g.Expect(err).To(MatchError(ContainSubstring("SSH tunnel to jump@broken: permission denied")))
+	}
+	g.Expect(started).To(Equal([]string{"jump@bastion", "jump@broken"}), "jump hosts are started once")
+
+	cfg := &rest.Config{}
+	g.Expect(tun.apply(ctx, cfg, map[string]string{"region": "cn"})).To(Succeed())
+	req, _ := http.NewRequest(http.MethodGet, "https://cluster.example", nil)
+	g.Expect(cfg.Proxy(req)).To(HaveField("Host", "localhost:1080"))
+
+	tun.Close()
+	g.Expect(stopped).To(Equal([]string{"jump@bastion"}))
+
+	var none *tunnels
+	g.Expect(none.proxyFor(ctx, map[string]string{"region": "cn"})).To(BeNil())
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"slices"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.yaml.in/yaml/v3"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// redactedKubeconfigKeys are kubeconfig fields whose values are replaced by
+// <redacted> in unified diffs.
+var redactedKubeconfigKeys = map[string]bool{
+	"client-key-data": true,
+	"token":           true,
+	"password":        true,
+	"id-token":        true,
+	"refresh-token":   true,
+	"client-secret":   true,
+}
+
+// fingerprintedKubeconfigKeys are base64 certificate fields shown as their
+// fingerprint in unified diffs.
+var fingerprintedKubeconfigKeys = map[string]bool{
+	"certificate-authority-data": true,
+	"client-certificate-data":    true,
+}
+
+// unifiedKubeconfigDiff returns a unified diff of the serialized kubeconfigs
+// with credentials redacted and certificates reduced to fingerprints. It
+// returns "" when they serialize identically.
+func unifiedKubeconfigDiff(name string, before, after *clientcmdapi.Config) (string, error) {
+	a, err := redactedKubeconfigYAML(before)
+	if err != nil {
+		return "", err
+	}
+	b, err := redactedKubeconfigYAML(after)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: name + " (before)",
+		ToFile:   name + " (after)",
+		Context:  3,
+	})
+}
+
+// redactedKubeconfigYAML serializes cfg for display without secrets.
+func redactedKubeconfigYAML(cfg *clientcmdapi.Config) (string, error) {
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	redactKubeconfigNode(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// redactKubeconfigNode rewrites sensitive values below n in place: secrets,
+// exec environment values and sensitive exec arguments.
+func redactKubeconfigNode(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			redactKubeconfigNode(c)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i].Value, n.Content[i+1]
+			switch {
+			case value.Kind != yaml.ScalarNode:
+				if key == "args" {
+					for _, arg := range value.Content {
+						arg.Value = redactArg(arg.Value)
+					}
+				}
+				if key == "env" {
+					for _, env := range value.Content {
+						if v := mappingValue(env, "value"); v != nil {
+							v.Value = "<redacted>"
+						}
+					}
+				}
+				redactKubeconfigNode(value)
+			case redactedKubeconfigKeys[key], slices.Contains(volatileAuthKeys, key):
+				value.Value = "<redacted>"
+			case fingerprintedKubeconfigKeys[key]:
+				raw, err := base64.StdEncoding.DecodeString(value.Value)
+				if err != nil {
+					value.Value = "<redacted>"
+					break
+				}
+				value.Value = fmt.Sprintf("<sha256 %s>", caFingerprint(raw))
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestUnifiedKubeconfigDiff_RedactsSecrets(t *testing.T) {
+	g := NewWithT(t)
+	before := clientcmdapi.NewConfig()
+	before.Clusters["cloudctl:eu"] = &clientcmdapi.Cluster{Server: "https://eu.example.com", CertificateAuthorityData: []byte("ca")}
+	before.AuthInfos["cloudctl:eu"] = &clientcmdapi.AuthInfo{
+		Token: "secret-token",
+		Exec: &clientcmdapi.ExecConfig{
+			Command: "kubelogin",
+			Args:    []string{"get-token", "--oidc-client-secret=hunter2"},
+			Env:     []clientcmdapi.ExecEnvVar{{Name: "PASSWORD", Value: "hunter3"}},
+		},
+	}
+	after := before.DeepCopy()
+	after.Clusters["cloudctl:eu"].Server = "https://eu2.example.com"
+	after.AuthInfos["cloudctl:eu"].Token = "other-token"
+
+	udiff, err := unifiedKubeconfigDiff("config", before, after)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(udiff).To(HavePrefix("--- config (before)\n+++ config (after)\n"))
+	g.Expect(udiff).To(ContainSubstring("-      server: https://eu.example.com\n+      server: https://eu2.example.com\n"))
+	g.Expect(udiff).To(ContainSubstring("certificate-authority-data: <sha256 " + caFingerprint([]byte("ca")) + ">"))
+	for _, secret := range []string{"secret-token", "other-token", "hunter2", "hunter3"} {
+		g.Expect(udiff).NotTo(ContainSubstring(secret))
+	}
+
+	udiff, err = unifiedKubeconfigDiff("config", before, before.DeepCopy())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(udiff).To(BeEmpty())
+}
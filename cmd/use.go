@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/cloudoperators/cloudctl/cmd/output"
+)
+
+var useCmd = &cobra.Command{
+	Use:   "use [context]",
+	Short: "Switch the current kubeconfig context",
+	Long: `Sets the current-context of your kubeconfig.
+
+The argument is matched against context names and the label values of their
+clusters (as written by sync). An exact name or a unique fuzzy match is used
+directly; otherwise a built-in fuzzy finder is shown so you can pick one
+without installing fzf. Without an argument the finder lists all contexts.
+
+Examples:
+  # Pick a context interactively
+  cloudctl use
+
+  # Switch by (partial) name
+  cloudctl use prod-eu
+
+  # Match on cluster labels and name together
+  cloudctl use europe qa`,
+	Args:              cobra.ArbitraryArgs,
+	PreRunE:           bindCommandFlags,
+	ValidArgsFunction: completeContexts,
+	RunE:              runUse,
+}
+
+// bindCommandFlags binds the flags of cmd to viper when it runs. Use it as
+// PreRunE for commands whose flag names are shared with other commands, since
+// binding the same key from several init functions lets the last one win.
+func bindCommandFlags(cmd *cobra.Command, _ []string) error {
+	return viper.BindPFlags(cmd.Flags())
+}
+
+// kubeconfigPathOptions returns PathOptions for reading and modifying the
+// kubeconfig at path, or the default KUBECONFIG / ~/.kube/config chain when
+// path is empty. clientcmd.ModifyConfig writes each change back to the file it
+// came from and locks it while doing so.
+func kubeconfigPathOptions(path string) *clientcmd.PathOptions {
+	po := clientcmd.NewDefaultPathOptions()
+	po.LoadingRules.ExplicitPath = path
+	return po
+}
+
+func runUse(cmd *cobra.Command, args []string) error {
+	path := resolveKubeconfig("kubeconfig", viper.GetString("kubeconfig"))
+	format, err := output.ParseFormat(viper.GetString("output"))
+	if err != nil {
+		return err
+	}
+
+	po := kubeconfigPathOptions(path)
+	cfg, err := po.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %w", displayKubeconfig(path), err)
+	}
+
+	query := strings.Join(args, " ")
+	name, err := selectTarget("context", query, contextItems(cfg))
+	if err != nil {
+		return err
+	}
+
+	previous := cfg.CurrentContext
+	if previous != name {
+		cfg.CurrentContext = name
+		if err := clientcmd.ModifyConfig(po, *cfg, true); err != nil {
+			return fmt.Errorf("failed to update kubeconfig: %w", err)
+		}
+		if err := auditFieldChange("use", path, "current-context", name, "current-context", previous, name); err != nil {
+			return err
+		}
+	}
+
+	warnIfStale(path, cfg, name)
+
+	w := cmd.OutOrStdout()
+	printer := output.New(format, output.IsTTYWriter(w), w)
+	return printer.Print(output.UseResult{Context: name, Previous: previous})
+}
+
+// completeContexts completes context names from the selected kubeconfig.
+func completeContexts(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	path, _ := cmd.Flags().GetString("kubeconfig")
+	if !cmd.Flags().Changed("kubeconfig") {
+		path = resolveKubeconfig("kubeconfig", path)
+	}
+	cfg, err := kubeconfigPathOptions(path).GetStartingConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	items := contextItems(cfg)
+	names := make([]string, 0, len(items))
+	for _, it := range items {
+		names = append(names, it.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/cloudoperators/cloudctl/cmd/fuzzy"
+
+	. "github.com/onsi/gomega"
+)
+
+func disablePrompt(t *testing.T) {
+	t.Helper()
+	prev := canPrompt
+	canPrompt = func() bool { return false }
+	t.Cleanup(func() { canPrompt = prev })
+}
+
+func TestSelectTarget(t *testing.T) {
+	g := NewWithT(t)
+	disablePrompt(t)
+	items := []fuzzy.Item{
+		{Name: "prod-eu"},
+		{Name: "prod-us"},
+		{Name: "qa", Keywords: []string{"europe"}},
+	}
+
+	g.Expect(selectTarget("context", "prod-eu", items)).To(Equal("prod-eu"))
+	g.Expect(selectTarget("context", "peu", items)).To(Equal("prod-eu"))
+	g.Expect(selectTarget("context", "europe", items)).To(Equal("qa"))
+
+	_, err := selectTarget("context", "prod", items)
+	g.Expect(err).To(MatchError(ContainSubstring("ambiguous")))
+	g.Expect(err.Error()).To(ContainSubstring("prod-us"))
+
+	_, err = selectTarget("context", "nope", items)
+	g.Expect(err).To(MatchError(ContainSubstring(`no context matches "nope"`)))
+
+	_, err = selectTarget("context", "", items)
+	g.Expect(err).To(MatchError(ContainSubstring("required when not running in a terminal")))
+
+	_, err = selectTarget("context", "x", nil)
+	g.Expect(err).To(MatchError("no contexts found"))
+}
+
+func TestContextItemsUsesClusterLabels(t *testing.T) {
+	g := NewWithT(t)
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cloudctl:a"] = &clientcmdapi.Cluster{
+		Extensions: map[string]runtime.Object{
+			"labels": &runtime.Unknown{Raw: []byte(`{"region":"eu-de-1","env":"prod","tier":"prod"}`)},
+		},
+	}
+	cfg.Contexts["a"] = &clientcmdapi.Context{Cluster: "cloudctl:a"}
+	cfg.Contexts["local"] = &clientcmdapi.Context{Cluster: "missing"}
+
+	g.Expect(contextItems(cfg)).To(Equal([]fuzzy.Item{
+		{Name: "a", Keywords: []string{"eu-de-1", "prod"}},
+		{Name: "local"},
+	}))
+}
+
+func TestRunUseSwitchesCurrentContext(t *testing.T) {
+	g := NewWithT(t)
+	disablePrompt(t)
+
+	cfg := newManagedCfg("prod-eu", "qa-eu")
+	cfg.CurrentContext = "qa-eu"
+	path := writeTestKubeconfig(t, cfg)
+
+	var out bytes.Buffer
+	useCmd.SetOut(&out)
+	t.Cleanup(func() { useCmd.SetOut(nil) })
+	g.Expect(runUse(useCmd, []string{"prod"})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`Switched to context "prod-eu"`))
+
+	written, err := clientcmd.LoadFromFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(written.CurrentContext).To(Equal("prod-eu"))
+}
+
+func TestRunUseWarnsAboutStaleSync(t *testing.T) {
+	g := NewWithT(t)
+	disablePrompt(t)
+	useTempSyncState(t)
+
+	path := writeTestKubeconfig(t, newManagedCfg("prod-eu"))
+
+	st, err := loadSyncState()
+	g.Expect(err).NotTo(HaveOccurred())
+	st.record(sourceState{Kubeconfig: stateKubeconfigPath(path), Endpoint: "https://gh", Namespace: "org", Prefix: "cloudctl", LastSync: time.Now().Add(-30 * 24 * time.Hour)})
+	g.Expect(st.save()).To(Succeed())
+
+	var logs bytes.Buffer
+	prevLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prevLogger) })
+	useCmd.SetOut(io.Discard)
+	t.Cleanup(func() { useCmd.SetOut(nil) })
+
+	g.Expect(runUse(useCmd, []string{"local"})).To(Succeed())
+	g.Expect(logs.String()).NotTo(ContainSubstring("not been synced recently"), "the context was not written by sync")
+
+	g.Expect(runUse(useCmd, []string{"prod-eu"})).To(Succeed())
+	g.Expect(logs.String()).To(ContainSubstring("kubeconfig has not been synced recently"))
+	g.Expect(logs.String()).To(ContainSubstring("daysOld=30"))
+}
+
+func TestCanPrompt_NonInteractive(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("non-interactive", true)
+	t.Cleanup(func() { viper.Set("non-interactive", nil) })
+
+	g.Expect(canPrompt()).To(BeFalse())
+	_, err := selectTarget("context", "prod", []fuzzy.Item{{Name: "prod-eu"}, {Name: "prod-us"}})
+	g.Expect(err).To(MatchError(`"prod" is ambiguous, matching contexts: prod-eu, prod-us`))
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// readyPollInterval is how often a ClusterKubeconfig is fetched while waiting
+// for it. It is a variable so tests can poll faster.
+var readyPollInterval = 5 * time.Second
+
+// waitForClusterKubeconfig fetches the ClusterKubeconfig name of namespace
+// every readyPollInterval until done reports true for it, and returns it.
+// A ClusterKubeconfig that does not exist yet, as right after onboarding, is
+// waited for as well. The reason it is not done yet is logged whenever it
+// changes. It gives up after timeout.
+func waitForClusterKubeconfig(ctx context.Context, ghClient greenhouse.Client, namespace, name string, timeout time.Duration, done func(v1alpha1.ClusterKubeconfig) bool) (*v1alpha1.ClusterKubeconfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	lastReason := ""
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		ckc, err := ghClient.GetClusterKubeconfig(ctx, namespace, name)
+		var reason string
+		switch {
+		case err == nil && done(*ckc):
+			return ckc, nil
+		case err == nil:
+			reason = cmp.Or(readyReason(*ckc), "not ready")
+		case apierrors.IsNotFound(err):
+			reason = "ClusterKubeconfig not created yet"
+		case ctx.Err() == nil:
+			return nil, err
+		}
+		if reason != "" && reason != lastReason {
+			slog.Info("Waiting for cluster", "cluster", name, "reason", reason, "elapsed", time.Since(start).Round(time.Second).String())
+			lastReason = reason
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("cluster %s is not ready after %s: %s", name, timeout, cmp.Or(lastReason, "no response from Greenhouse"))
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
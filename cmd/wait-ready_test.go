@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+// sequenceClient answers GetClusterKubeconfig with the next of its
+// responses, repeating the last one.
+type sequenceClient struct {
+	greenhouse.Client
+	responses []func() (*v1alpha1.ClusterKubeconfig, error)
+	gets      int
+}
+
+func (s *sequenceClient) GetClusterKubeconfig(context.Context, string, string) (*v1alpha1.ClusterKubeconfig, error) {
+	s.gets++
+	return s.responses[min(s.gets, len(s.responses))-1]()
+}
+
+func useFastReadyPoll(t *testing.T) {
+	prev := readyPollInterval
+	readyPollInterval = time.Millisecond
+	t.Cleanup(func() { readyPollInterval = prev })
+}
+
+func TestWaitForClusterKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	useFastReadyPoll(t)
+	notFound := func() (*v1alpha1.ClusterKubeconfig, error) {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "clusterkubeconfigs"}, "eu-1")
+	}
+	onboarding := func() (*v1alpha1.ClusterKubeconfig, error) {
+		return readyCKC("eu-1", "1", false, "waiting for nodes"), nil
+	}
+	ready := func() (*v1alpha1.ClusterKubeconfig, error) { return readyCKC("eu-1", "2", true, ""), nil }
+
+	c := &sequenceClient{responses: []func() (*v1alpha1.ClusterKubeconfig, error){notFound, onboarding, onboarding, ready}}
+	ckc, err := waitForClusterKubeconfig(context.Background(), c, "org", "eu-1", time.Minute, greenhouse.IsReady)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ckc.ResourceVersion).To(Equal("2"))
+	g.Expect(c.gets).To(Equal(4))
+
+	c = &sequenceClient{responses: []func() (*v1alpha1.ClusterKubeconfig, error){onboarding}}
+	_, err = waitForClusterKubeconfig(context.Background(), c, "org", "eu-1", 20*time.Millisecond, greenhouse.IsReady)
+	g.Expect(err).To(MatchError("cluster eu-1 is not ready after 20ms: waiting for nodes"))
+
+	forbidden := func() (*v1alpha1.ClusterKubeconfig, error) {
+		return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "clusterkubeconfigs"}, "eu-1", nil)
+	}
+	c = &sequenceClient{responses: []func() (*v1alpha1.ClusterKubeconfig, error){forbidden}}
+	_, err = waitForClusterKubeconfig(context.Background(), c, "org", "eu-1", time.Minute, greenhouse.IsReady)
+	g.Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	g.Expect(c.gets).To(Equal(1))
+}
+
+func TestRunSync_WaitReadyValidation(t *testing.T) {
+	g := NewWithT(t)
+	viper.Set("wait-ready", true)
+	t.Cleanup(func() {
+		viper.Set("wait-ready", nil)
+		viper.Set("remote-cluster-name", nil)
+		viper.Set("wait-ready-timeout", nil)
+		viper.Set("watch", nil)
+	})
+
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--wait-ready requires --remote-cluster-name")))
+
+	viper.Set("remote-cluster-name", "eu-1")
+	viper.Set("wait-ready-timeout", 0)
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--wait-ready-timeout must be positive")))
+
+	viper.Set("wait-ready-timeout", time.Minute)
+	viper.Set("watch", true)
+	g.Expect(runSync(syncCmd, nil)).To(MatchError(ContainSubstring("--wait-ready cannot be combined with --watch")))
+}
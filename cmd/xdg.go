@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
+// configDir, cacheDir and stateDir return the cloudctl directories of the XDG
+// base directory specification: $XDG_CONFIG_HOME/cloudctl,
+// $XDG_CACHE_HOME/cloudctl and $XDG_STATE_HOME/cloudctl, defaulting to
+// ~/.config, ~/.cache and ~/.local/state on every platform but Windows, which
+// uses the roaming (config) and local (cache, state) app data directories.
+func configDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config", os.UserConfigDir)
+}
+
+func cacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache", os.UserCacheDir)
+}
+
+func stateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"), os.UserCacheDir)
+}
+
+// xdgDir returns the cloudctl directory below $env, which the specification
+// requires to be absolute, or below the platform default.
+func xdgDir(env, homeDefault string, windowsDefault func() (string, error)) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "cloudctl"), nil
+	}
+	if runtime.GOOS == "windows" {
+		dir, err := windowsDefault()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "cloudctl"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, homeDefault, "cloudctl"), nil
+}
+
+// cacheSubdirs are the directories cloudctl keeps below cacheDir.
+var cacheSubdirs = []string{"completion", "versions", "env"}
+
+// pathMigration is the outcome of moving one legacy file or directory to its
+// XDG location.
+type pathMigration struct {
+	From string
+	To   string
+	// Shadowed is set when To already existed, so From was left alone and is
+	// no longer read.
+	Shadowed bool
+	Err      error
+}
+
+// pathMigrations holds the migrations done by setupConfig, which runs before
+// the logger is configured; logPathMigrations reports them.
+var pathMigrations []pathMigration
+
+// migrateLegacyPaths moves the config file and caches of earlier cloudctl
+// versions to the XDG directories, once: a legacy path is only moved while
+// its new location does not exist. The config file is not touched when
+// explicit is set, since --config may name a legacy path. State has always
+// been kept in stateDir and needs no migration.
+func migrateLegacyPaths(explicit bool) []pathMigration {
+	var migrations []pathMigration
+	if !explicit {
+		if target, err := configDir(); err == nil {
+			target = filepath.Join(target, "cloudctl.yaml")
+			for _, legacy := range legacyConfigFiles(target) {
+				if m, ok := migratePath(legacy, target); ok {
+					migrations = append(migrations, m)
+				}
+			}
+		}
+	}
+	// Caches used the platform cache directory, ~/Library/Caches on macOS.
+	legacy, err := os.UserCacheDir()
+	target, targetErr := cacheDir()
+	if err == nil && targetErr == nil && filepath.Join(legacy, "cloudctl") != target {
+		for _, sub := range cacheSubdirs {
+			if m, ok := migratePath(filepath.Join(legacy, "cloudctl", sub), filepath.Join(target, sub)); ok && !m.Shadowed {
+				migrations = append(migrations, m)
+			}
+		}
+	}
+	return migrations
+}
+
+// legacyConfigFiles returns the config file locations read by earlier
+// versions besides target, in the order they were searched.
+func legacyConfigFiles(target string) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	candidates := []string{
+		filepath.Join(home, ".cloudctl.yaml"),
+		filepath.Join(home, "cloudctl.yaml"),
+		filepath.Join(filepath.Dir(filepath.Dir(target)), "cloudctl.yaml"),
+		filepath.Join(home, ".config", "cloudctl", "cloudctl.yaml"),
+		filepath.Join(home, ".config", "cloudctl.yaml"),
+	}
+	var files []string
+	for _, c := range candidates {
+		if c != target && !slices.Contains(files, c) {
+			files = append(files, c)
+		}
+	}
+	return files
+}
+
+// migratePath moves from to to unless to exists. ok is false when there is
+// nothing at from.
+func migratePath(from, to string) (m pathMigration, ok bool) {
+	if _, err := os.Lstat(from); err != nil {
+		return pathMigration{}, false
+	}
+	m = pathMigration{From: from, To: to}
+	if _, err := os.Lstat(to); err == nil {
+		m.Shadowed = true
+		return m, true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		m.Err = err
+		return m, true
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+		m.Err = fmt.Errorf("failed to create %s: %w", filepath.Dir(to), err)
+		return m, true
+	}
+	m.Err = os.Rename(from, to)
+	return m, true
+}
+
+// logPathMigrations reports the migrations done at startup.
+func logPathMigrations() {
+	for _, m := range pathMigrations {
+		switch {
+		case m.Err != nil:
+			slog.Warn("failed to move legacy file to its XDG location", "from", m.From, "to", m.To, "error", m.Err)
+		case m.Shadowed:
+			slog.Warn("ignoring legacy config file, remove it", "file", m.From, "using", m.To)
+		default:
+			slog.Info("moved legacy file to its XDG location", "from", m.From, "to", m.To)
+		}
+	}
+	pathMigrations = nil
+}
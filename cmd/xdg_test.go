@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestXDGDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows defaults to the app data directories")
+	}
+	g := NewWithT(t)
+	home, xdg := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(xdg, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(xdg, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(xdg, "state"))
+
+	g.Expect(configDir()).To(Equal(filepath.Join(xdg, "config", "cloudctl")))
+	g.Expect(cacheDir()).To(Equal(filepath.Join(xdg, "cache", "cloudctl")))
+	g.Expect(stateDir()).To(Equal(filepath.Join(xdg, "state", "cloudctl")))
+	g.Expect(syncStateFile()).To(Equal(filepath.Join(xdg, "state", "cloudctl", "state.json")))
+	g.Expect(versionCacheDir()).To(Equal(filepath.Join(xdg, "cache", "cloudctl", "versions")))
+
+	// Unset or relative variables fall back to the defaults below $HOME.
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "relative")
+	t.Setenv("XDG_STATE_HOME", "")
+	g.Expect(configDir()).To(Equal(filepath.Join(home, ".config", "cloudctl")))
+	g.Expect(cacheDir()).To(Equal(filepath.Join(home, ".cache", "cloudctl")))
+	g.Expect(stateDir()).To(Equal(filepath.Join(home, ".local", "state", "cloudctl")))
+}
+
+func TestSetupConfig_MigratesLegacyConfigFile(t *testing.T) {
+	g := NewWithT(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("CLOUDCTL_CONFIG", "")
+	t.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		viper.Reset()
+		pathMigrations = nil
+	})
+	legacy := filepath.Join(home, ".cloudctl.yaml")
+	g.Expect(os.WriteFile(legacy, ConfigA, 0o600)).To(Succeed())
+	other := filepath.Join(xdg, "cloudctl.yaml")
+	g.Expect(os.MkdirAll(xdg, 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(other, []byte("kubeconfig: other\n"), 0o600)).To(Succeed())
+
+	g.Expect(setupConfig()).To(Succeed())
+	target := filepath.Join(xdg, "cloudctl", "cloudctl.yaml")
+	g.Expect(viper.ConfigFileUsed()).To(Equal(target))
+	g.Expect(viper.GetString("kubeconfig")).To(Equal("A"))
+	g.Expect(legacy).NotTo(BeAnExistingFile())
+	g.Expect(other).To(BeAnExistingFile(), "only the first legacy file is moved")
+	g.Expect(pathMigrations).To(Equal([]pathMigration{
+		{From: legacy, To: target},
+		{From: other, To: target, Shadowed: true},
+	}))
+
+	// Once moved, nothing is migrated again.
+	pathMigrations = nil
+	viper.Reset()
+	g.Expect(os.Remove(other)).To(Succeed())
+	g.Expect(setupConfig()).To(Succeed())
+	g.Expect(pathMigrations).To(BeEmpty())
+}
+
+func TestSetupConfig_ExplicitConfigIsNotMigrated(t *testing.T) {
+	g := NewWithT(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	legacy := filepath.Join(home, ".cloudctl.yaml")
+	g.Expect(os.WriteFile(legacy, ConfigA, 0o600)).To(Succeed())
+	t.Setenv("CLOUDCTL_CONFIG", legacy)
+	t.Cleanup(func() {
+		viper.Reset()
+		pathMigrations = nil
+	})
+
+	g.Expect(setupConfig()).To(Succeed())
+	g.Expect(viper.GetString("kubeconfig")).To(Equal("A"))
+	g.Expect(legacy).To(BeAnExistingFile())
+}
+
+func TestMigratePath(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "Library", "Caches", "cloudctl", "env"), filepath.Join(dir, ".cache", "cloudctl", "env")
+
+	_, ok := migratePath(from, to)
+	g.Expect(ok).To(BeFalse())
+
+	g.Expect(os.MkdirAll(from, 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(from, "prod-eu.yaml"), []byte("x"), 0o600)).To(Succeed())
+	m, ok := migratePath(from, to)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m).To(Equal(pathMigration{From: from, To: to}))
+	g.Expect(filepath.Join(to, "prod-eu.yaml")).To(BeAnExistingFile())
+	g.Expect(from).NotTo(BeADirectory())
+
+	g.Expect(os.MkdirAll(from, 0o700)).To(Succeed())
+	m, ok = migratePath(from, to)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m.Shadowed).To(BeTrue())
+	g.Expect(from).To(BeADirectory())
+}
@@ -22,6 +22,15 @@ func main() {
 	defer stop()
 
 	if err := cmd.Execute(ctx); err != nil {
+		code := 1
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+			if exitErr.Err == nil {
+				os.Exit(code)
+			}
+		}
+
 		// Build a printer in the requested output format so errors are
 		// machine-parseable when -o json or -o yaml is used.
 		format, fmtErr := output.ParseFormat(cmd.OutputFormat())
@@ -37,6 +46,6 @@ func main() {
 		} else {
 			p.PrintError(err)
 		}
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
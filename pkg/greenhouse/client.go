@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package greenhouse provides a small client for the Greenhouse API resources
+// cloudctl consumes. The Client interface can be faked in unit tests and used
+// by other Go programs that need the same view of an organization's clusters.
+package greenhouse
+
+import (
+	"context"
+	"fmt"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Client gives read access to Greenhouse resources in an organization namespace.
+type Client interface {
+	// ListClusterKubeconfigs returns all ClusterKubeconfigs in the namespace.
+	ListClusterKubeconfigs(ctx context.Context, namespace string) ([]v1alpha1.ClusterKubeconfig, error)
+	// GetClusterKubeconfig returns a single ClusterKubeconfig by name.
+	GetClusterKubeconfig(ctx context.Context, namespace, name string) (*v1alpha1.ClusterKubeconfig, error)
+	// ListClusters returns all Clusters in the namespace. Each Cluster owns
+	// the ClusterKubeconfig of the same name.
+	ListClusters(ctx context.Context, namespace string) ([]v1alpha1.Cluster, error)
+	// ListTeams returns all Teams in the namespace.
+	ListTeams(ctx context.Context, namespace string) ([]v1alpha1.Team, error)
+	// ListTeamRoleBindings returns all TeamRoleBindings in the namespace.
+	ListTeamRoleBindings(ctx context.Context, namespace string) ([]v1alpha2.TeamRoleBinding, error)
+	// ListOrganizations returns all Organizations. Each organization owns the
+	// namespace of the same name.
+	ListOrganizations(ctx context.Context) ([]v1alpha1.Organization, error)
+	// WhoAmI returns the username the API server authenticated the client as.
+	WhoAmI(ctx context.Context) (string, error)
+	// CanI reports whether the current user may perform the request, and the
+	// authorizer's reason when not.
+	CanI(ctx context.Context, attrs ResourceAttributes) (allowed bool, reason string, err error)
+	// FindGrants returns the RoleBindings in the namespace that would allow
+	// the request. It needs read access to RBAC objects.
+	FindGrants(ctx context.Context, attrs ResourceAttributes) ([]Grant, error)
+}
+
+// NewScheme returns a runtime.Scheme with the Greenhouse API types registered.
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add greenhouse scheme: %w", err)
+	}
+	if err := v1alpha2.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add greenhouse v1alpha2 scheme: %w", err)
+	}
+	if err := authenticationv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add authentication scheme: %w", err)
+	}
+	if err := authorizationv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add authorization scheme: %w", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add rbac scheme: %w", err)
+	}
+	return scheme, nil
+}
+
+// New builds a Client talking to the Greenhouse cluster described by cfg.
+func New(cfg *rest.Config) (Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	// Fall back to the vendored version when discovery is unavailable; the
+	// first API call then reports the underlying problem.
+	g := &crClient{c: c, ckcVersion: v1alpha1.GroupVersion}
+	if dc, err := discovery.NewDiscoveryClientForConfig(cfg); err == nil {
+		if served, err := servedClusterKubeconfigVersions(dc); err == nil {
+			g.ckcVersion, g.served = negotiate(served), served
+		}
+	}
+	return g, nil
+}
+
+// NewFromClient wraps an existing controller-runtime client. The client's
+// scheme must have the Greenhouse types registered (see NewScheme).
+func NewFromClient(c client.Client) Client {
+	return NewFromClientWithVersion(c, v1alpha1.GroupVersion)
+}
+
+// NewFromClientWithVersion is like NewFromClient but reads ClusterKubeconfigs
+// from the given group version (see NegotiateClusterKubeconfigVersion).
+// Versions other than the vendored v1alpha1 are read as unstructured objects
+// and converted.
+func NewFromClientWithVersion(c client.Client, ckcVersion schema.GroupVersion) Client {
+	return &crClient{c: c, ckcVersion: ckcVersion}
+}
+
+// tracer emits spans for API calls. It is a no-op unless the program installs
+// an OpenTelemetry tracer provider.
+var tracer = otel.Tracer("github.com/cloudoperators/cloudctl/pkg/greenhouse")
+
+type crClient struct {
+	c          client.Client
+	ckcVersion schema.GroupVersion
+	// served are the versions discovery found, for SchemaMismatchError.
+	served []schema.GroupVersion
+}
+
+// schemaMismatch describes a failure to decode ClusterKubeconfigs into the
+// vendored types.
+func (g *crClient) schemaMismatch(err error, name string) error {
+	return &SchemaMismatchError{Name: name, Served: g.served, Built: v1alpha1.GroupVersion, Err: err}
+}
+
+func (g *crClient) ListClusterKubeconfigs(ctx context.Context, namespace string) ([]v1alpha1.ClusterKubeconfig, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.ListClusterKubeconfigs", trace.WithAttributes(attribute.String("namespace", namespace)))
+	defer span.End()
+	span.SetAttributes(attribute.String("apiVersion", g.ckcVersion.String()))
+
+	if g.ckcVersion == v1alpha1.GroupVersion {
+		var list v1alpha1.ClusterKubeconfigList
+		if err := g.c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			if isDecodeError(err) {
+				return nil, recordError(span, g.schemaMismatch(err, ""))
+			}
+			return nil, recordError(span, fmt.Errorf("failed to list ClusterKubeconfigs: %w", err))
+		}
+		span.SetAttributes(attribute.Int("count", len(list.Items)))
+		return list.Items, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(g.ckcVersion.WithKind(clusterKubeconfigKind + "List"))
+	if err := g.c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list ClusterKubeconfigs (%s): %w", g.ckcVersion, err))
+	}
+	items := make([]v1alpha1.ClusterKubeconfig, 0, len(list.Items))
+	for i := range list.Items {
+		ckc, err := convertClusterKubeconfig(&list.Items[i])
+		if err != nil {
+			return nil, recordError(span, err)
+		}
+		items = append(items, ckc)
+	}
+	span.SetAttributes(attribute.Int("count", len(items)))
+	return items, nil
+}
+
+func (g *crClient) GetClusterKubeconfig(ctx context.Context, namespace, name string) (*v1alpha1.ClusterKubeconfig, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.GetClusterKubeconfig", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+	))
+	defer span.End()
+	span.SetAttributes(attribute.String("apiVersion", g.ckcVersion.String()))
+
+	if g.ckcVersion == v1alpha1.GroupVersion {
+		var ckc v1alpha1.ClusterKubeconfig
+		if err := g.c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &ckc); err != nil {
+			if isDecodeError(err) {
+				return nil, recordError(span, g.schemaMismatch(err, name))
+			}
+			return nil, recordError(span, fmt.Errorf("failed to get ClusterKubeconfig %q: %w", name, err))
+		}
+		return &ckc, nil
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(g.ckcVersion.WithKind(clusterKubeconfigKind))
+	if err := g.c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, u); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to get ClusterKubeconfig %q (%s): %w", name, g.ckcVersion, err))
+	}
+	ckc, err := convertClusterKubeconfig(u)
+	if err != nil {
+		return nil, recordError(span, err)
+	}
+	return &ckc, nil
+}
+
+func (g *crClient) ListClusters(ctx context.Context, namespace string) ([]v1alpha1.Cluster, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.ListClusters", trace.WithAttributes(attribute.String("namespace", namespace)))
+	defer span.End()
+
+	var list v1alpha1.ClusterList
+	if err := g.c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list Clusters: %w", err))
+	}
+	span.SetAttributes(attribute.Int("count", len(list.Items)))
+	return list.Items, nil
+}
+
+func (g *crClient) ListTeams(ctx context.Context, namespace string) ([]v1alpha1.Team, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.ListTeams", trace.WithAttributes(attribute.String("namespace", namespace)))
+	defer span.End()
+
+	var list v1alpha1.TeamList
+	if err := g.c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list Teams: %w", err))
+	}
+	span.SetAttributes(attribute.Int("count", len(list.Items)))
+	return list.Items, nil
+}
+
+func (g *crClient) ListTeamRoleBindings(ctx context.Context, namespace string) ([]v1alpha2.TeamRoleBinding, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.ListTeamRoleBindings", trace.WithAttributes(attribute.String("namespace", namespace)))
+	defer span.End()
+
+	var list v1alpha2.TeamRoleBindingList
+	if err := g.c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list TeamRoleBindings: %w", err))
+	}
+	span.SetAttributes(attribute.Int("count", len(list.Items)))
+	return list.Items, nil
+}
+
+func (g *crClient) ListOrganizations(ctx context.Context) ([]v1alpha1.Organization, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.ListOrganizations")
+	defer span.End()
+
+	var list v1alpha1.OrganizationList
+	if err := g.c.List(ctx, &list); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list Organizations: %w", err))
+	}
+	span.SetAttributes(attribute.Int("count", len(list.Items)))
+	return list.Items, nil
+}
+
+func (g *crClient) WhoAmI(ctx context.Context) (string, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.WhoAmI")
+	defer span.End()
+
+	review := &authenticationv1.SelfSubjectReview{}
+	if err := g.c.Create(ctx, review); err != nil {
+		return "", recordError(span, fmt.Errorf("failed to determine current user: %w", err))
+	}
+	return review.Status.UserInfo.Username, nil
+}
+
+// recordError marks span as failed and returns err unchanged.
+func recordError(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}
+
+// IsReady reports whether the ClusterKubeconfig's Ready condition is True.
+func IsReady(ckc v1alpha1.ClusterKubeconfig) bool {
+	cond := ckc.Status.Conditions.GetConditionByType(greenhousemetav1alpha1.ReadyCondition)
+	return cond != nil && cond.IsTrue()
+}
+
+// PartitionReady splits ClusterKubeconfigs into ready and notReady slices.
+// Ready means the Ready condition is set to True.
+func PartitionReady(items []v1alpha1.ClusterKubeconfig) (ready, notReady []v1alpha1.ClusterKubeconfig) {
+	for _, ckc := range items {
+		if IsReady(ckc) {
+			ready = append(ready, ckc)
+		} else {
+			notReady = append(notReady, ckc)
+		}
+	}
+	return ready, notReady
+}
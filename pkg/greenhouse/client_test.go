@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"context"
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func readyCKC(namespace, name string, ready bool) *v1alpha1.ClusterKubeconfig {
+	ckc := &v1alpha1.ClusterKubeconfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}
+	cond := greenhousemetav1alpha1.FalseCondition(greenhousemetav1alpha1.ReadyCondition, "Test", "not ready")
+	if ready {
+		cond = greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "Test", "ready")
+	}
+	ckc.Status.Conditions.SetConditions(cond)
+	return ckc
+}
+
+func newFakeClient(t *testing.T, objs ...*v1alpha1.ClusterKubeconfig) greenhouse.Client {
+	t.Helper()
+	scheme, err := greenhouse.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := fake.NewClientBuilder().WithScheme(scheme)
+	for _, o := range objs {
+		b = b.WithObjects(o)
+	}
+	return greenhouse.NewFromClient(b.Build())
+}
+
+func TestListClusterKubeconfigs_ScopedToNamespace(t *testing.T) {
+	g := NewWithT(t)
+	c := newFakeClient(t,
+		readyCKC("org-a", "one", true),
+		readyCKC("org-a", "two", false),
+		readyCKC("org-b", "three", true),
+	)
+
+	items, err := c.ListClusterKubeconfigs(context.Background(), "org-a")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(items).To(HaveLen(2))
+}
+
+func TestGetClusterKubeconfig_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	c := newFakeClient(t)
+
+	_, err := c.GetClusterKubeconfig(context.Background(), "org-a", "missing")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("missing"))
+}
+
+func TestListOrganizations(t *testing.T) {
+	g := NewWithT(t)
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	c := greenhouse.NewFromClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "org-a"}},
+		&v1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "org-b"}},
+	).Build())
+
+	orgs, err := c.ListOrganizations(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(orgs).To(HaveLen(2))
+}
+
+func TestPartitionReady(t *testing.T) {
+	g := NewWithT(t)
+	items := []v1alpha1.ClusterKubeconfig{
+		*readyCKC("ns", "a", true),
+		*readyCKC("ns", "b", false),
+		{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+	}
+	ready, notReady := greenhouse.PartitionReady(items)
+	g.Expect(ready).To(HaveLen(1))
+	g.Expect(ready[0].Name).To(Equal("a"))
+	g.Expect(notReady).To(HaveLen(2))
+}
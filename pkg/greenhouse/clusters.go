@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"time"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/api"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+)
+
+// OwningCluster returns the Cluster among clusters that owns ckc: the one
+// named in its Cluster owner reference, or else the one of the same name.
+// It returns nil when there is none.
+func OwningCluster(ckc v1alpha1.ClusterKubeconfig, clusters []v1alpha1.Cluster) *v1alpha1.Cluster {
+	name := ckc.Name
+	for _, ref := range ckc.OwnerReferences {
+		if ref.Kind == "Cluster" && ref.APIVersion == v1alpha1.GroupVersion.String() {
+			name = ref.Name
+			break
+		}
+	}
+	for i := range clusters {
+		if clusters[i].Name == name {
+			return &clusters[i]
+		}
+	}
+	return nil
+}
+
+// DeletionSchedule reports whether the Cluster is marked for deletion and
+// when Greenhouse will delete it. The time is zero when the schedule is not
+// set yet or cannot be parsed.
+func DeletionSchedule(cluster v1alpha1.Cluster) (time.Time, bool) {
+	if _, marked := cluster.Annotations[greenhouseapis.MarkClusterDeletionAnnotation]; !marked {
+		return time.Time{}, false
+	}
+	schedule, err := time.Parse(time.DateTime, cluster.Annotations[greenhouseapis.ScheduleClusterDeletionAnnotation])
+	if err != nil {
+		return time.Time{}, true
+	}
+	return schedule, true
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/api"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestListClusters(t *testing.T) {
+	g := NewWithT(t)
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	c := greenhouse.NewFromClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "org-a", Name: "one"}},
+		&v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "org-b", Name: "two"}},
+	).Build())
+
+	clusters, err := c.ListClusters(context.Background(), "org-a")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(1))
+	g.Expect(clusters[0].Name).To(Equal("one"))
+}
+
+func TestOwningCluster(t *testing.T) {
+	g := NewWithT(t)
+	clusters := []v1alpha1.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "eu-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "eu-1-renamed"}},
+	}
+
+	ckc := v1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: "eu-1"}}
+	g.Expect(greenhouse.OwningCluster(ckc, clusters)).To(Equal(&clusters[0]))
+
+	ckc.OwnerReferences = []metav1.OwnerReference{{APIVersion: v1alpha1.GroupVersion.String(), Kind: "Cluster", Name: "eu-1-renamed"}}
+	g.Expect(greenhouse.OwningCluster(ckc, clusters)).To(Equal(&clusters[1]))
+
+	ckc = v1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{Name: "us-1"}}
+	g.Expect(greenhouse.OwningCluster(ckc, clusters)).To(BeNil())
+}
+
+func TestDeletionSchedule(t *testing.T) {
+	g := NewWithT(t)
+	cluster := func(annotations map[string]string) v1alpha1.Cluster {
+		return v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+
+	_, scheduled := greenhouse.DeletionSchedule(cluster(nil))
+	g.Expect(scheduled).To(BeFalse())
+
+	at, scheduled := greenhouse.DeletionSchedule(cluster(map[string]string{
+		greenhouseapis.MarkClusterDeletionAnnotation:     "true",
+		greenhouseapis.ScheduleClusterDeletionAnnotation: "2026-10-20 12:00:00",
+	}))
+	g.Expect(scheduled).To(BeTrue())
+	g.Expect(at).To(Equal(time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)))
+
+	at, scheduled = greenhouse.DeletionSchedule(cluster(map[string]string{greenhouseapis.MarkClusterDeletionAnnotation: "true"}))
+	g.Expect(scheduled).To(BeTrue())
+	g.Expect(at.IsZero()).To(BeTrue())
+}
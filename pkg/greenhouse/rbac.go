@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourceAttributes identifies an API request for an access check.
+type ResourceAttributes struct {
+	Namespace string
+	Verb      string
+	Group     string
+	Resource  string
+}
+
+// String renders the attributes the way kubectl auth can-i reports them,
+// e.g. `list clusterkubeconfigs.greenhouse.sap in namespace "my-org"`.
+func (a ResourceAttributes) String() string {
+	res := a.Resource
+	if a.Group != "" {
+		res += "." + a.Group
+	}
+	return fmt.Sprintf("%s %s in namespace %q", a.Verb, res, a.Namespace)
+}
+
+// ClusterKubeconfigAccess returns the attributes for reading ClusterKubeconfigs
+// in namespace with verb ("list" or "get").
+func ClusterKubeconfigAccess(namespace, verb string) ResourceAttributes {
+	return ResourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     v1alpha1.GroupVersion.Group,
+		Resource:  "clusterkubeconfigs",
+	}
+}
+
+// Grant names a Role and the subject bound to it that would allow a request,
+// together with the Team whose IdP group is that subject, if any.
+type Grant struct {
+	RoleKind    string
+	RoleName    string
+	SubjectKind string
+	SubjectName string
+	Team        string
+}
+
+// String renders the grant for error messages.
+func (g Grant) String() string {
+	s := fmt.Sprintf("%s %q bound to %s %q", g.RoleKind, g.RoleName, g.SubjectKind, g.SubjectName)
+	if g.Team != "" {
+		s += fmt.Sprintf(" (team %q)", g.Team)
+	}
+	return s
+}
+
+// CanI performs a SelfSubjectAccessReview for attrs and reports whether the
+// current user is allowed, along with the authorizer's reason when denied.
+func (g *crClient) CanI(ctx context.Context, attrs ResourceAttributes) (bool, string, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.CanI", trace.WithAttributes(attribute.String("request", attrs.String())))
+	defer span.End()
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: attrs.Namespace,
+				Verb:      attrs.Verb,
+				Group:     attrs.Group,
+				Resource:  attrs.Resource,
+			},
+		},
+	}
+	if err := g.c.Create(ctx, review); err != nil {
+		return false, "", recordError(span, fmt.Errorf("failed to check access: %w", err))
+	}
+	span.SetAttributes(attribute.Bool("allowed", review.Status.Allowed))
+	return review.Status.Allowed, review.Status.Reason, nil
+}
+
+// FindGrants lists the Roles and RoleBindings in the request namespace and
+// returns the bindings that would allow attrs. Group subjects are mapped to
+// Teams through their MappedIDPGroup. Callers usually lack permission to read
+// RBAC objects, so errors should be treated as "unknown" rather than fatal.
+func (g *crClient) FindGrants(ctx context.Context, attrs ResourceAttributes) ([]Grant, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.FindGrants", trace.WithAttributes(attribute.String("request", attrs.String())))
+	defer span.End()
+
+	var roles rbacv1.RoleList
+	if err := g.c.List(ctx, &roles, client.InNamespace(attrs.Namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list Roles: %w", err))
+	}
+	var bindings rbacv1.RoleBindingList
+	if err := g.c.List(ctx, &bindings, client.InNamespace(attrs.Namespace)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to list RoleBindings: %w", err))
+	}
+	// Teams are only used to annotate the result.
+	teams, _ := g.ListTeams(ctx, attrs.Namespace)
+	return MatchGrants(attrs, roles.Items, bindings.Items, teams), nil
+}
+
+// MatchGrants returns the subjects of bindings whose Role has a rule allowing
+// attrs. Only namespaced Roles are inspected; bindings to ClusterRoles are
+// not resolved.
+func MatchGrants(attrs ResourceAttributes, roles []rbacv1.Role, bindings []rbacv1.RoleBinding, teams []v1alpha1.Team) []Grant {
+	allowing := map[string]bool{}
+	for _, r := range roles {
+		if slices.ContainsFunc(r.Rules, func(rule rbacv1.PolicyRule) bool { return ruleAllows(rule, attrs) }) {
+			allowing[r.Name] = true
+		}
+	}
+
+	var grants []Grant
+	for _, b := range bindings {
+		if b.RoleRef.Kind != "Role" || !allowing[b.RoleRef.Name] {
+			continue
+		}
+		for _, s := range b.Subjects {
+			grant := Grant{RoleKind: b.RoleRef.Kind, RoleName: b.RoleRef.Name, SubjectKind: s.Kind, SubjectName: s.Name}
+			if s.Kind == rbacv1.GroupKind {
+				for _, t := range teams {
+					if t.Spec.MappedIDPGroup != "" && strings.EqualFold(t.Spec.MappedIDPGroup, s.Name) {
+						grant.Team = t.Name
+						break
+					}
+				}
+			}
+			grants = append(grants, grant)
+		}
+	}
+	slices.SortFunc(grants, func(a, b Grant) int {
+		return strings.Compare(a.RoleName+"/"+a.SubjectName, b.RoleName+"/"+b.SubjectName)
+	})
+	return grants
+}
+
+func ruleAllows(rule rbacv1.PolicyRule, attrs ResourceAttributes) bool {
+	return matchesAny(rule.Verbs, attrs.Verb) &&
+		matchesAny(rule.APIGroups, attrs.Group) &&
+		matchesAny(rule.Resources, attrs.Resource)
+}
+
+func matchesAny(values []string, want string) bool {
+	return slices.Contains(values, rbacv1.ResourceAll) || slices.Contains(values, want)
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"testing"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestMatchGrants(t *testing.T) {
+	g := NewWithT(t)
+	attrs := greenhouse.ClusterKubeconfigAccess("org", "list")
+	g.Expect(attrs.String()).To(Equal(`list clusterkubeconfigs.greenhouse.sap in namespace "org"`))
+
+	roles := []rbacv1.Role{
+		{ObjectMeta: metav1.ObjectMeta{Name: "viewer"}, Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"greenhouse.sap"}, Resources: []string{"clusterkubeconfigs"}, Verbs: []string{"get", "list"},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "admin"}, Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plugins"}, Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"greenhouse.sap"}, Resources: []string{"plugins"}, Verbs: []string{"list"},
+		}}},
+	}
+	bindings := []rbacv1.RoleBinding{
+		{RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "viewer"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "ORG-MEMBERS"}}},
+		{RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "admin"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "root@example.com"}}},
+		{RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "plugins"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "PLUGIN-DEVS"}}},
+		{RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "viewer"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "OTHERS"}}},
+	}
+	teams := []v1alpha1.Team{
+		{ObjectMeta: metav1.ObjectMeta{Name: "members"}, Spec: v1alpha1.TeamSpec{MappedIDPGroup: "org-members"}},
+	}
+
+	grants := greenhouse.MatchGrants(attrs, roles, bindings, teams)
+	g.Expect(grants).To(Equal([]greenhouse.Grant{
+		{RoleKind: "Role", RoleName: "admin", SubjectKind: "User", SubjectName: "root@example.com"},
+		{RoleKind: "Role", RoleName: "viewer", SubjectKind: "Group", SubjectName: "ORG-MEMBERS", Team: "members"},
+	}))
+	g.Expect(grants[1].String()).To(Equal(`Role "viewer" bound to Group "ORG-MEMBERS" (team "members")`))
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RefreshRequestedAnnotation records when a ClusterKubeconfig was last asked
+// to be rendered again. Greenhouse reconciles a ClusterKubeconfig on every
+// change to it, so updating the annotation is enough to trigger that.
+const RefreshRequestedAnnotation = "cloudctl.greenhouse.sap/refresh-requested-at"
+
+// Refresher is implemented by Clients that can ask the Greenhouse controller
+// to render a ClusterKubeconfig again, e.g. after credentials were rotated.
+type Refresher interface {
+	// RequestRefresh sets RefreshRequestedAnnotation on the ClusterKubeconfig
+	// to at and returns the updated ClusterKubeconfig.
+	RequestRefresh(ctx context.Context, namespace, name string, at time.Time) (*v1alpha1.ClusterKubeconfig, error)
+}
+
+func (g *crClient) RequestRefresh(ctx context.Context, namespace, name string, at time.Time) (*v1alpha1.ClusterKubeconfig, error) {
+	ctx, span := tracer.Start(ctx, "greenhouse.RequestRefresh", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+	))
+	defer span.End()
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{RefreshRequestedAnnotation: at.UTC().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return nil, recordError(span, err)
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(g.ckcVersion.WithKind(clusterKubeconfigKind))
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	if err := g.c.Patch(ctx, u, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return nil, recordError(span, fmt.Errorf("failed to request a refresh of ClusterKubeconfig %q: %w", name, err))
+	}
+	ckc, err := convertClusterKubeconfig(u)
+	if err != nil {
+		return nil, recordError(span, err)
+	}
+	return &ckc, nil
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestRequestRefresh(t *testing.T) {
+	g := NewWithT(t)
+	ckc := readyCKC("org-a", "one", true)
+	ckc.Annotations = map[string]string{"keep": "me"}
+	c := newFakeClient(t, ckc)
+	r, ok := c.(greenhouse.Refresher)
+	g.Expect(ok).To(BeTrue())
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated, err := r.RequestRefresh(context.Background(), "org-a", "one", at)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.Annotations).To(Equal(map[string]string{
+		"keep":                                "me",
+		greenhouse.RefreshRequestedAnnotation: "2026-01-02T03:04:05Z",
+	}))
+	g.Expect(greenhouse.IsReady(*updated)).To(BeTrue())
+
+	got, err := c.GetClusterKubeconfig(context.Background(), "org-a", "one")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got.Annotations).To(HaveKeyWithValue(greenhouse.RefreshRequestedAnnotation, "2026-01-02T03:04:05Z"))
+
+	_, err = r.RequestRefresh(context.Background(), "org-a", "missing", at)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// UserTeams returns the names of the Teams that list username as a member.
+// Members are matched by ID or email, case-insensitively, because the
+// username seen by the API server is one or the other depending on the IdP.
+func UserTeams(username string, teams []v1alpha1.Team) []string {
+	var names []string
+	for _, team := range teams {
+		if slices.ContainsFunc(team.Status.Members, func(u v1alpha1.User) bool {
+			return strings.EqualFold(u.ID, username) || strings.EqualFold(u.Email, username)
+		}) {
+			names = append(names, team.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// BoundClusters returns the sorted names of the clusters on which a
+// TeamRoleBinding grants access to username, either through one of
+// teamNames or by listing the user directly. Only clusters the binding has
+// actually been propagated to are considered.
+func BoundClusters(username string, teamNames []string, bindings []v1alpha2.TeamRoleBinding) []string {
+	seen := map[string]bool{}
+	for _, trb := range bindings {
+		if !slices.Contains(teamNames, trb.Spec.TeamRef) &&
+			!slices.ContainsFunc(trb.Spec.Usernames, func(u string) bool { return strings.EqualFold(u, username) }) {
+			continue
+		}
+		for _, ps := range trb.Status.PropagationStatus {
+			seen[ps.ClusterName] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// RoleGrant is a TeamRole that a TeamRoleBinding grants a user on a cluster.
+type RoleGrant struct {
+	Cluster  string
+	TeamRole string
+	// Team is the Team through which the user holds the role, empty when the
+	// binding lists the user directly.
+	Team string
+	// Namespaces the role is bound in; empty means cluster-wide.
+	Namespaces []string
+	// Applied is set when the binding's RBAC objects were created on the
+	// cluster successfully.
+	Applied bool
+}
+
+// UserRoleGrants returns the roles that bindings grant username on each
+// cluster, either through one of teamNames or by listing the user directly,
+// sorted by cluster, role and team. Like BoundClusters, it only considers
+// clusters a binding has been propagated to.
+func UserRoleGrants(username string, teamNames []string, bindings []v1alpha2.TeamRoleBinding) []RoleGrant {
+	var grants []RoleGrant
+	for _, trb := range bindings {
+		team := ""
+		switch {
+		case slices.Contains(teamNames, trb.Spec.TeamRef):
+			team = trb.Spec.TeamRef
+		case slices.ContainsFunc(trb.Spec.Usernames, func(u string) bool { return strings.EqualFold(u, username) }):
+		default:
+			continue
+		}
+		for _, ps := range trb.Status.PropagationStatus {
+			grants = append(grants, RoleGrant{
+				Cluster:    ps.ClusterName,
+				TeamRole:   trb.Spec.TeamRoleRef,
+				Team:       team,
+				Namespaces: trb.Spec.Namespaces,
+				Applied:    ps.IsTrue(),
+			})
+		}
+	}
+	slices.SortStableFunc(grants, func(a, b RoleGrant) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.TeamRole, b.TeamRole), cmp.Compare(a.Team, b.Team))
+	})
+	return grants
+}
+
+// ClusterBindings returns the TeamRoleBindings that apply to the cluster with
+// the given labels: those propagated to it and those whose cluster selector
+// names or matches it but that have not been propagated (yet). An empty
+// label selector matches no cluster.
+func ClusterBindings(cluster string, clusterLabels map[string]string, bindings []v1alpha2.TeamRoleBinding) []v1alpha2.TeamRoleBinding {
+	var matched []v1alpha2.TeamRoleBinding
+	for _, trb := range bindings {
+		if slices.ContainsFunc(trb.Status.PropagationStatus, func(ps v1alpha2.PropagationStatus) bool { return ps.ClusterName == cluster }) ||
+			selectsCluster(trb.Spec.ClusterSelector, cluster, clusterLabels) {
+			matched = append(matched, trb)
+		}
+	}
+	return matched
+}
+
+// selectsCluster reports whether sel names the cluster or matches its labels.
+func selectsCluster(sel v1alpha2.ClusterSelector, cluster string, clusterLabels map[string]string) bool {
+	if sel.Name != "" {
+		return sel.Name == cluster
+	}
+	if len(sel.LabelSelector.MatchLabels) == 0 && len(sel.LabelSelector.MatchExpressions) == 0 {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&sel.LabelSelector)
+	return err == nil && selector.Matches(labels.Set(clusterLabels))
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"testing"
+
+	greenhousemetav1alpha1 "github.com/cloudoperators/greenhouse/api/meta/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	"github.com/cloudoperators/greenhouse/api/v1alpha2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func team(name string, members ...v1alpha1.User) v1alpha1.Team {
+	return v1alpha1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     v1alpha1.TeamStatus{Members: members},
+	}
+}
+
+func binding(teamRef string, usernames []string, clusters ...string) v1alpha2.TeamRoleBinding {
+	trb := v1alpha2.TeamRoleBinding{Spec: v1alpha2.TeamRoleBindingSpec{TeamRef: teamRef, Usernames: usernames}}
+	for _, c := range clusters {
+		trb.Status.PropagationStatus = append(trb.Status.PropagationStatus, v1alpha2.PropagationStatus{
+			ClusterName: c,
+			Condition:   greenhousemetav1alpha1.TrueCondition(greenhousemetav1alpha1.ReadyCondition, "", ""),
+		})
+	}
+	return trb
+}
+
+func TestUserTeams_MatchesIDOrEmail(t *testing.T) {
+	g := NewWithT(t)
+	teams := []v1alpha1.Team{
+		team("platform", v1alpha1.User{ID: "I123", Email: "jane@example.com"}),
+		team("observability", v1alpha1.User{ID: "D999", Email: "Jane@Example.com"}),
+		team("storage", v1alpha1.User{ID: "D000", Email: "bob@example.com"}),
+	}
+
+	g.Expect(greenhouse.UserTeams("jane@example.com", teams)).To(Equal([]string{"observability", "platform"}))
+	g.Expect(greenhouse.UserTeams("i123", teams)).To(Equal([]string{"platform"}))
+	g.Expect(greenhouse.UserTeams("nobody", teams)).To(BeEmpty())
+}
+
+func TestBoundClusters(t *testing.T) {
+	g := NewWithT(t)
+	bindings := []v1alpha2.TeamRoleBinding{
+		binding("platform", nil, "eu-1", "us-1"),
+		binding("storage", nil, "storage-1"),
+		binding("storage", []string{"JANE@example.com"}, "storage-2"),
+		binding("platform", nil, "eu-1"),
+	}
+
+	g.Expect(greenhouse.BoundClusters("jane@example.com", []string{"platform"}, bindings)).
+		To(Equal([]string{"eu-1", "storage-2", "us-1"}))
+	g.Expect(greenhouse.BoundClusters("bob", nil, bindings)).To(BeEmpty())
+}
+
+func TestUserRoleGrants(t *testing.T) {
+	g := NewWithT(t)
+	admin := binding("platform", nil, "eu-1", "us-1")
+	admin.Spec.TeamRoleRef = "cluster-admin"
+	viewer := binding("storage", []string{"JANE@example.com"}, "eu-1")
+	viewer.Spec.TeamRoleRef = "viewer"
+	viewer.Spec.Namespaces = []string{"storage"}
+	viewer.Status.PropagationStatus[0].Condition = greenhousemetav1alpha1.FalseCondition(greenhousemetav1alpha1.ReadyCondition, "", "")
+	other := binding("storage", nil, "eu-1")
+	other.Spec.TeamRoleRef = "editor"
+
+	g.Expect(greenhouse.UserRoleGrants("jane@example.com", []string{"platform"}, []v1alpha2.TeamRoleBinding{viewer, other, admin})).To(Equal([]greenhouse.RoleGrant{
+		{Cluster: "eu-1", TeamRole: "cluster-admin", Team: "platform", Applied: true},
+		{Cluster: "eu-1", TeamRole: "viewer", Namespaces: []string{"storage"}},
+		{Cluster: "us-1", TeamRole: "cluster-admin", Team: "platform", Applied: true},
+	}))
+	g.Expect(greenhouse.UserRoleGrants("bob", nil, []v1alpha2.TeamRoleBinding{admin})).To(BeEmpty())
+}
+
+func TestClusterBindings(t *testing.T) {
+	g := NewWithT(t)
+	propagated := binding("platform", nil, "eu-1")
+	propagated.Name = "propagated"
+	byName := binding("platform", nil)
+	byName.Name = "by-name"
+	byName.Spec.ClusterSelector.Name = "eu-1"
+	byLabel := binding("platform", nil)
+	byLabel.Name = "by-label"
+	byLabel.Spec.ClusterSelector.LabelSelector = metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}}
+	otherLabel := binding("platform", nil)
+	otherLabel.Name = "other-label"
+	otherLabel.Spec.ClusterSelector.LabelSelector = metav1.LabelSelector{MatchLabels: map[string]string{"region": "us"}}
+	empty := binding("platform", nil, "us-1")
+	empty.Name = "empty"
+
+	matched := greenhouse.ClusterBindings("eu-1", map[string]string{"region": "eu"}, []v1alpha2.TeamRoleBinding{propagated, byName, byLabel, otherLabel, empty})
+	var names []string
+	for _, trb := range matched {
+		names = append(names, trb.Name)
+	}
+	g.Expect(names).To(Equal([]string{"propagated", "by-name", "by-label"}))
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// clusterKubeconfigKind is the kind cloudctl reads, independent of its group
+// and version.
+const clusterKubeconfigKind = "ClusterKubeconfig"
+
+// NegotiateClusterKubeconfigVersion asks the API server which group versions
+// serve ClusterKubeconfig. The vendored v1alpha1 is used whenever the server
+// still serves it; otherwise the first group version found is returned, so a
+// server that moved ahead (or to another group) keeps working. Partial
+// discovery failures are tolerated as long as some group version is found.
+func NegotiateClusterKubeconfigVersion(dc discovery.DiscoveryInterface) (schema.GroupVersion, error) {
+	served, err := servedClusterKubeconfigVersions(dc)
+	if err != nil {
+		return schema.GroupVersion{}, err
+	}
+	return negotiate(served), nil
+}
+
+// negotiate picks the version to read from the non-empty served versions.
+func negotiate(served []schema.GroupVersion) schema.GroupVersion {
+	if slices.Contains(served, v1alpha1.GroupVersion) {
+		return v1alpha1.GroupVersion
+	}
+	return served[0]
+}
+
+// servedClusterKubeconfigVersions returns the group versions serving
+// ClusterKubeconfig, in discovery order.
+func servedClusterKubeconfigVersions(dc discovery.DiscoveryInterface) ([]schema.GroupVersion, error) {
+	_, lists, err := dc.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover Greenhouse API versions: %w", err)
+	}
+
+	var served []schema.GroupVersion
+	for _, list := range lists {
+		gv, parseErr := schema.ParseGroupVersion(list.GroupVersion)
+		if parseErr != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if r.Kind == clusterKubeconfigKind {
+				served = append(served, gv)
+				break
+			}
+		}
+	}
+	if len(served) == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover Greenhouse API versions: %w", err)
+		}
+		return nil, fmt.Errorf("the cluster does not serve %s resources; is this a Greenhouse cluster?", clusterKubeconfigKind)
+	}
+	return served, nil
+}
+
+// SchemaMismatchError reports a ClusterKubeconfig that could not be decoded
+// into the vendored Greenhouse API types, typically because the server runs
+// a newer CRD than cloudctl was built against.
+type SchemaMismatchError struct {
+	// Name is the ClusterKubeconfig, or empty when a list failed.
+	Name string
+	// Served are the group versions the server serves ClusterKubeconfig
+	// in, when known.
+	Served []schema.GroupVersion
+	// Built is the group version of the vendored types.
+	Built schema.GroupVersion
+	Err   error
+}
+
+func (e *SchemaMismatchError) Error() string {
+	object := clusterKubeconfigKind + "s"
+	if e.Name != "" {
+		object = fmt.Sprintf("%s %q", clusterKubeconfigKind, e.Name)
+	}
+	built := e.Built.String()
+	if v := apiModuleVersion(); v != "" {
+		built += " (greenhouse " + v + ")"
+	}
+	var server string
+	switch {
+	case len(e.Served) == 0:
+		server = "the server's schema differs from " + built + ", which cloudctl was built against"
+	case slices.Contains(e.Served, e.Built):
+		server = fmt.Sprintf("the server's %s schema differs from the one cloudctl was built against%s", e.Built, strings.TrimPrefix(built, e.Built.String()))
+	default:
+		versions := make([]string, len(e.Served))
+		for i, gv := range e.Served {
+			versions[i] = gv.String()
+		}
+		server = fmt.Sprintf("the server serves %s, cloudctl was built against %s", strings.Join(versions, ", "), built)
+	}
+	return fmt.Sprintf("cannot decode %s: %s; upgrade cloudctl (%v)", object, server, e.Err)
+}
+
+func (e *SchemaMismatchError) Unwrap() error { return e.Err }
+
+// isDecodeError reports whether err came from decoding a response rather
+// than from the API server or the connection. The JSON decoder client-go
+// uses does not export its error types, so its message is matched too.
+func isDecodeError(err error) bool {
+	if errors.As(err, new(apierrors.APIStatus)) {
+		return false
+	}
+	return errors.As(err, new(*json.UnmarshalTypeError)) || strings.Contains(err.Error(), "json: cannot unmarshal")
+}
+
+// apiModuleVersion returns the version of the Greenhouse API module cloudctl
+// was built with, or "" when the build info does not tell.
+func apiModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/cloudoperators/greenhouse" {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// convertClusterKubeconfig decodes a ClusterKubeconfig of any served version
+// into the vendored v1alpha1 type. Fields v1alpha1 does not know are dropped;
+// the ones cloudctl relies on (kubeconfig data, labels, conditions) have kept
+// their shape across versions.
+func convertClusterKubeconfig(u *unstructured.Unstructured) (v1alpha1.ClusterKubeconfig, error) {
+	var ckc v1alpha1.ClusterKubeconfig
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &ckc); err != nil {
+		return ckc, &SchemaMismatchError{
+			Name:   u.GetName(),
+			Served: []schema.GroupVersion{u.GroupVersionKind().GroupVersion()},
+			Built:  v1alpha1.GroupVersion,
+			Err:    err,
+		}
+	}
+	return ckc, nil
+}
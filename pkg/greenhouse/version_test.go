@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+var v1beta1 = schema.GroupVersion{Group: v1alpha1.GroupVersion.Group, Version: "v1beta1"}
+
+func discoveryServing(gvs ...schema.GroupVersion) *fakediscovery.FakeDiscovery {
+	fd := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	for _, gv := range gvs {
+		fd.Resources = append(fd.Resources, &metav1.APIResourceList{
+			GroupVersion: gv.String(),
+			APIResources: []metav1.APIResource{{Name: "clusterkubeconfigs", Kind: "ClusterKubeconfig", Namespaced: true}},
+		})
+	}
+	return fd
+}
+
+func TestNegotiateClusterKubeconfigVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	gv, err := greenhouse.NegotiateClusterKubeconfigVersion(discoveryServing(v1beta1, v1alpha1.GroupVersion))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(gv).To(Equal(v1alpha1.GroupVersion), "vendored version preferred while served")
+
+	gv, err = greenhouse.NegotiateClusterKubeconfigVersion(discoveryServing(v1beta1))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(gv).To(Equal(v1beta1))
+
+	_, err = greenhouse.NegotiateClusterKubeconfigVersion(discoveryServing())
+	g.Expect(err).To(MatchError(ContainSubstring("does not serve ClusterKubeconfig")))
+}
+
+func TestListClusterKubeconfigs_NewerVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{v1beta1})
+	mapper.Add(v1beta1.WithKind("ClusterKubeconfig"), meta.RESTScopeNamespace)
+
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"namespace": "org-a", "name": "one", "labels": map[string]any{"region": "eu"}},
+		"spec": map[string]any{
+			"kubeconfig": map[string]any{
+				"clusters":    []any{map[string]any{"name": "one", "cluster": map[string]any{"server": "https://one.example.com"}}},
+				"futureField": "ignored",
+			},
+		},
+	}}
+	obj.SetGroupVersionKind(v1beta1.WithKind("ClusterKubeconfig"))
+
+	c := greenhouse.NewFromClientWithVersion(fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(obj).Build(), v1beta1)
+
+	items, err := c.ListClusterKubeconfigs(context.Background(), "org-a")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(items).To(HaveLen(1))
+	g.Expect(items[0].Name).To(Equal("one"))
+	g.Expect(items[0].Labels).To(HaveKeyWithValue("region", "eu"))
+	g.Expect(items[0].Spec.Kubeconfig.Clusters[0].Cluster.Server).To(Equal("https://one.example.com"))
+
+	ckc, err := c.GetClusterKubeconfig(context.Background(), "org-a", "one")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ckc.Name).To(Equal("one"))
+}
+
+func TestListClusterKubeconfigs_SchemaMismatch(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{v1beta1})
+	mapper.Add(v1beta1.WithKind("ClusterKubeconfig"), meta.RESTScopeNamespace)
+
+	// A newer version that turned the cluster list into a string.
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"namespace": "org-a", "name": "one"},
+		"spec":     map[string]any{"kubeconfig": map[string]any{"clusters": "one"}},
+	}}
+	obj.SetGroupVersionKind(v1beta1.WithKind("ClusterKubeconfig"))
+	c := greenhouse.NewFromClientWithVersion(fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(obj).Build(), v1beta1)
+
+	_, err = c.ListClusterKubeconfigs(context.Background(), "org-a")
+	var mismatch *greenhouse.SchemaMismatchError
+	g.Expect(errors.As(err, &mismatch)).To(BeTrue())
+	g.Expect(mismatch.Served).To(Equal([]schema.GroupVersion{v1beta1}))
+	g.Expect(err.Error()).To(HavePrefix(`cannot decode ClusterKubeconfig "one": the server serves greenhouse.sap/v1beta1, cloudctl was built against greenhouse.sap/v1alpha1`))
+	g.Expect(err.Error()).To(ContainSubstring("; upgrade cloudctl ("))
+}
+
+func TestGetClusterKubeconfig_DecodeErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	var getErr error
+	c := greenhouse.NewFromClient(fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+			return getErr
+		},
+	}).Build())
+
+	getErr = errors.New(`json: cannot unmarshal string into Go struct field KubeconfigSpec.spec.kubeconfig.clusters of type []v1alpha1.ClusterKubeconfigClusterItem`)
+	_, err = c.GetClusterKubeconfig(context.Background(), "org-a", "one")
+	g.Expect(err).To(BeAssignableToTypeOf(&greenhouse.SchemaMismatchError{}))
+	g.Expect(err.Error()).To(HavePrefix(`cannot decode ClusterKubeconfig "one": the server's schema differs from greenhouse.sap/v1alpha1`))
+
+	// API errors are reported as they are.
+	getErr = apierrors.NewForbidden(schema.GroupResource{Resource: "clusterkubeconfigs"}, "one", errors.New("denied"))
+	_, err = c.GetClusterKubeconfig(context.Background(), "org-a", "one")
+	g.Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	g.Expect(errors.As(err, new(*greenhouse.SchemaMismatchError))).To(BeFalse())
+}
+
+func TestSchemaMismatchError_SameVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	err := &greenhouse.SchemaMismatchError{Served: []schema.GroupVersion{v1beta1, v1alpha1.GroupVersion}, Built: v1alpha1.GroupVersion, Err: errors.New("boom")}
+	g.Expect(err.Error()).To(HavePrefix("cannot decode ClusterKubeconfigs: the server's greenhouse.sap/v1alpha1 schema differs from the one cloudctl was built against"))
+	g.Expect(err.Error()).To(HaveSuffix("; upgrade cloudctl (boom)"))
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrWatchUnsupported is returned by WatchClusterKubeconfigs when the client
+// was built from a controller-runtime client that cannot watch.
+var ErrWatchUnsupported = errors.New("the Greenhouse client does not support watches")
+
+// Watcher is implemented by Clients that can stream changes to
+// ClusterKubeconfigs, so long-running programs need not list them again and
+// again.
+type Watcher interface {
+	// ListClusterKubeconfigsForWatch is ListClusterKubeconfigs that also
+	// returns the resourceVersion of the list to start watching from.
+	ListClusterKubeconfigsForWatch(ctx context.Context, namespace string) ([]v1alpha1.ClusterKubeconfig, string, error)
+	// WatchClusterKubeconfigs streams the changes after resourceVersion,
+	// including bookmarks. Added, Modified, Deleted and Bookmark events carry
+	// a *v1alpha1.ClusterKubeconfig (only its resourceVersion is set for
+	// bookmarks); Error events carry a status for apierrors.FromObject. A
+	// 410 Gone status means resourceVersion is too old to resume from and
+	// the caller has to list again.
+	WatchClusterKubeconfigs(ctx context.Context, namespace, resourceVersion string) (watch.Interface, error)
+}
+
+// WatchExpired reports whether err, returned by WatchClusterKubeconfigs or
+// decoded from an Error event, means the watch has to start over from a new
+// list.
+func WatchExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+func (g *crClient) ListClusterKubeconfigsForWatch(ctx context.Context, namespace string) ([]v1alpha1.ClusterKubeconfig, string, error) {
+	if g.ckcVersion == v1alpha1.GroupVersion {
+		var list v1alpha1.ClusterKubeconfigList
+		if err := g.c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			return nil, "", fmt.Errorf("failed to list ClusterKubeconfigs: %w", err)
+		}
+		return list.Items, list.ResourceVersion, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(g.ckcVersion.WithKind(clusterKubeconfigKind + "List"))
+	if err := g.c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, "", fmt.Errorf("failed to list ClusterKubeconfigs (%s): %w", g.ckcVersion, err)
+	}
+	items := make([]v1alpha1.ClusterKubeconfig, 0, len(list.Items))
+	for i := range list.Items {
+		ckc, err := convertClusterKubeconfig(&list.Items[i])
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, ckc)
+	}
+	return items, list.GetResourceVersion(), nil
+}
+
+func (g *crClient) WatchClusterKubeconfigs(ctx context.Context, namespace, resourceVersion string) (watch.Interface, error) {
+	wc, ok := g.c.(client.WithWatch)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	opts := []client.ListOption{
+		client.InNamespace(namespace),
+		&client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: resourceVersion, AllowWatchBookmarks: true}},
+	}
+
+	if g.ckcVersion == v1alpha1.GroupVersion {
+		w, err := wc.Watch(ctx, &v1alpha1.ClusterKubeconfigList{}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch ClusterKubeconfigs: %w", err)
+		}
+		return w, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(g.ckcVersion.WithKind(clusterKubeconfigKind + "List"))
+	w, err := wc.Watch(ctx, list, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch ClusterKubeconfigs (%s): %w", g.ckcVersion, err)
+	}
+	return watch.Filter(w, convertWatchEvent), nil
+}
+
+// convertWatchEvent converts the object of an unstructured watch event to the
+// vendored v1alpha1 type. Error events are passed on unchanged.
+func convertWatchEvent(e watch.Event) (watch.Event, bool) {
+	u, ok := e.Object.(*unstructured.Unstructured)
+	if !ok || e.Type == watch.Error {
+		return e, true
+	}
+	if e.Type == watch.Bookmark {
+		e.Object = &v1alpha1.ClusterKubeconfig{ObjectMeta: metav1.ObjectMeta{ResourceVersion: u.GetResourceVersion()}}
+		return e, true
+	}
+	ckc, err := convertClusterKubeconfig(u)
+	if err != nil {
+		status := apierrors.NewInternalError(err).ErrStatus
+		return watch.Event{Type: watch.Error, Object: &status}, true
+	}
+	e.Object = &ckc
+	return e, true
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package greenhouse_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cloudoperators/cloudctl/pkg/greenhouse"
+)
+
+func TestWatchClusterKubeconfigs(t *testing.T) {
+	g := NewWithT(t)
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	fc := fake.NewClientBuilder().WithScheme(scheme).WithObjects(readyCKC("org-a", "one", true)).Build()
+	w, ok := greenhouse.NewFromClient(fc).(greenhouse.Watcher)
+	g.Expect(ok).To(BeTrue())
+
+	ctx := context.Background()
+	items, rv, err := w.ListClusterKubeconfigsForWatch(ctx, "org-a")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(items).To(HaveLen(1))
+
+	events, err := w.WatchClusterKubeconfigs(ctx, "org-a", rv)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer events.Stop()
+	g.Expect(fc.Create(ctx, readyCKC("org-b", "other", true))).To(Succeed())
+	g.Expect(fc.Create(ctx, readyCKC("org-a", "two", true))).To(Succeed())
+
+	var e watch.Event
+	g.Eventually(events.ResultChan(), time.Second).Should(Receive(&e))
+	g.Expect(e.Type).To(Equal(watch.Added))
+	g.Expect(e.Object).To(BeAssignableToTypeOf(&v1alpha1.ClusterKubeconfig{}))
+	g.Expect(e.Object.(*v1alpha1.ClusterKubeconfig).Name).To(Equal("two"))
+}
+
+func TestWatchClusterKubeconfigs_Unsupported(t *testing.T) {
+	g := NewWithT(t)
+	scheme, err := greenhouse.NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	// Embedding hides the Watch method of the fake client.
+	type listOnly struct{ client.Client }
+	c := greenhouse.NewFromClient(listOnly{fake.NewClientBuilder().WithScheme(scheme).Build()})
+
+	_, err = c.(greenhouse.Watcher).WatchClusterKubeconfigs(context.Background(), "org-a", "")
+	g.Expect(err).To(MatchError(greenhouse.ErrWatchUnsupported))
+}
+
+func TestWatchExpired(t *testing.T) {
+	g := NewWithT(t)
+	gr := schema.GroupResource{Resource: "clusterkubeconfigs"}
+	g.Expect(greenhouse.WatchExpired(errors.NewResourceExpired("too old"))).To(BeTrue())
+	g.Expect(greenhouse.WatchExpired(errors.NewGone("gone"))).To(BeTrue())
+	g.Expect(greenhouse.WatchExpired(errors.NewForbidden(gr, "", nil))).To(BeFalse())
+	g.Expect(greenhouse.WatchExpired(nil)).To(BeFalse())
+}